@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/anonymiser"
+)
+
+// NewAnonymisersCmd creates a new anonymisers command
+func NewAnonymisersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "anonymisers",
+		Aliases: []string{"faker"},
+		Short:   "Inspect the anonymisers usable in the Anonymise config",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the available anonymisers with their arguments and an example output",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunAnonymisersList(cmd.OutOrStdout())
+		},
+	})
+
+	return cmd
+}
+
+// RunAnonymisersList runs the anonymisers list command
+func RunAnonymisersList(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKIND\tEXAMPLE")
+	for _, a := range anonymiser.Catalog() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", a.Signature, a.Kind, a.Example)
+	}
+
+	return tw.Flush()
+}
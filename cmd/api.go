@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/hellofresh/klepto/pkg/api"
+	"github.com/hellofresh/klepto/pkg/health"
+	"github.com/hellofresh/klepto/pkg/notify"
+)
+
+// shutdownTimeout is the maximum time spent finishing the API requests on shutdown.
+const shutdownTimeout = 10 * time.Second
+
+// APIOptions represents the api command options
+type APIOptions struct {
+	addr     string
+	profiles string
+	token    string
+}
+
+// NewAPICmd creates a new api command
+func NewAPICmd() *cobra.Command {
+	opts := new(APIOptions)
+	cmd := &cobra.Command{
+		Use:   "api",
+		Short: "Serves a REST API starting the steals of named profiles and reporting their progress",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunAPI(opts)
+		},
+	}
+
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVar(&opts.addr, "addr", ":8080", "Address the API is served at")
+	persistentFlags.StringVar(&opts.profiles, "profiles", "", "Path to the file defining the profiles that can be stolen")
+	persistentFlags.StringVar(&opts.token, "token", "", "Bearer token the API requests must be authenticated with, preferably set with KLEPTO_TOKEN")
+	cmd.MarkPersistentFlagRequired("profiles")
+
+	return cmd
+}
+
+// RunAPI is the handler for the api command.
+func RunAPI(opts *APIOptions) error {
+	profiles, err := api.LoadProfiles(opts.profiles)
+	if err != nil {
+		return err
+	}
+	// fails on start on invalid flags, the configuration files are loaded when the steal starts
+	for _, profile := range profiles {
+		if _, err := profileOptions(profile); err != nil {
+			return fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+	}
+	if opts.token == "" {
+		log.Warn("The API is not authenticated, set a --token unless it is only reachable by trusted clients")
+	}
+
+	listener, err := net.Listen("tcp", opts.addr)
+	if err != nil {
+		return fmt.Errorf("could not listen for API requests: %w", err)
+	}
+
+	server := api.NewServer(profiles, opts.token, runProfile)
+	httpServer := &http.Server{Handler: server}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+	log.WithFields(log.Fields{"addr": listener.Addr().String(), "profiles": len(profiles)}).Info("Serving the API")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("API server stopped: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		log.WithError(err).Warn("Failed to stop the API server")
+	}
+
+	log.Info("Waiting for the running steals to finish")
+	server.Wait()
+
+	return nil
+}
+
+// runProfile steals the database of a profile.
+func runProfile(profile api.Profile, progress func(health.Reporter)) (*notify.Event, error) {
+	opts, err := profileOptions(profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	finished := new(finishedNotifier)
+	opts.progress, opts.events = progress, notify.Notifiers{finished}
+	err = RunSteal(opts)
+
+	return finished.event, err
+}
+
+// profileOptions parses the steal flags of a profile, its dsns and configuration files taking precedence over
+// the additional flags.
+func profileOptions(profile api.Profile) (*StealOptions, error) {
+	args := append([]string{}, profile.Args...)
+	if profile.From != "" {
+		args = append(args, "--from", os.ExpandEnv(profile.From))
+	}
+	if profile.To != "" {
+		args = append(args, "--to", os.ExpandEnv(profile.To))
+	}
+	for _, configPath := range profile.Config {
+		args = append(args, "--config", configPath)
+	}
+
+	opts := new(StealOptions)
+	flags := pflag.NewFlagSet("steal", pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	addStealFlags(flags, opts)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments %s", strings.Join(flags.Args(), " "))
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/bench"
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// BenchOptions represents the bench command options
+type BenchOptions struct {
+	configPaths []string
+	cfgTables   config.Tables
+
+	from        string
+	tables      []string
+	rows        uint64
+	pageSizes   []uint
+	concurrency []int
+	readTimeout time.Duration
+	format      string
+}
+
+// NewBenchCmd creates a new bench command
+func NewBenchCmd() *cobra.Command {
+	opts := new(BenchOptions)
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measures the read throughput of the source tables and recommends page sizes and concurrency",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(opts.configPaths) == 0 {
+				return nil
+			}
+
+			var err error
+			opts.cfgTables, err = config.LoadFromFiles(opts.configPaths...)
+			return err
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunBench(opts, cmd.OutOrStdout())
+		},
+	}
+
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringArrayVarP(&opts.configPaths, "config", "c", nil, "Path to config file, the tables are read with their filter, the following ones are overlays deep merged into it")
+	persistentFlags.StringVarP(&opts.from, "from", "f", "", "Database dsn to measure")
+	persistentFlags.StringSliceVar(&opts.tables, "tables", nil, "Only measures these tables (comma separated), all the tables are measured when empty")
+	persistentFlags.Uint64Var(&opts.rows, "rows", 100000, "Sets the amount of rows read from each table per measure")
+	persistentFlags.UintSliceVar(&opts.pageSizes, "page-sizes", []uint{0, 1000, 10000, 50000}, "Page sizes measured (comma separated), 0 reading a table with a single query")
+	persistentFlags.IntSliceVar(&opts.concurrency, "concurrency", []int{1, 2, 4, 8}, "Amounts of tables read concurrently measured (comma separated)")
+	persistentFlags.DurationVar(&opts.readTimeout, "read-timeout", 5*time.Minute, "Sets the timeout for read operations")
+	persistentFlags.StringVar(&opts.format, "format", "text", "Output format: text or json")
+	cmd.MarkPersistentFlagRequired("from")
+
+	return cmd
+}
+
+// RunBench is the handler for the bench command.
+func RunBench(opts *BenchOptions, w io.Writer) error {
+	if opts.format != "text" && opts.format != "json" {
+		return fmt.Errorf("unknown format %q, supported formats are text and json", opts.format)
+	}
+
+	maxConns := 1
+	for _, concurrency := range opts.concurrency {
+		if concurrency > maxConns {
+			maxConns = concurrency
+		}
+	}
+	source, err := reader.Connect(reader.ConnOpts{DSN: opts.from, Timeout: opts.readTimeout, MaxConns: maxConns})
+	if err != nil {
+		return fmt.Errorf("could not connecting to reader: %w", err)
+	}
+	defer func() {
+		if err := source.Close(); err != nil {
+			log.WithError(err).Error("Something is not ok with closing source connection")
+		}
+	}()
+
+	tables := opts.tables
+	if len(tables) == 0 {
+		if tables, err = source.GetTables(); err != nil {
+			return fmt.Errorf("could not list the tables: %w", err)
+		}
+	}
+	pageSizes := make([]uint64, len(opts.pageSizes))
+	for i, size := range opts.pageSizes {
+		pageSizes[i] = uint64(size)
+	}
+
+	report, err := bench.Run(source, opts.cfgTables, tables, bench.Options{
+		Rows:        opts.rows,
+		PageSizes:   pageSizes,
+		Concurrency: opts.concurrency,
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	return writeBenchReport(w, report)
+}
+
+// writeBenchReport writes the measures as tables, followed by the recommended settings.
+func writeBenchReport(w io.Writer, report *bench.Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tPAGE SIZE\tROWS\tDURATION\tROWS/S")
+	for _, table := range report.Tables {
+		for _, m := range table.PageSizes {
+			recommended := ""
+			if m.PageSize == table.RecommendedPageSize {
+				recommended = " *"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%.0f%s\n", table.Table, pageSizeText(m.PageSize), m.Rows, m.Duration.Round(time.Millisecond), m.RowsPerSecond, recommended)
+		}
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "CONCURRENCY\tROWS\tDURATION\tROWS/S")
+	for _, c := range report.Concurrency {
+		recommended := ""
+		if c.Concurrency == report.RecommendedConcurrency {
+			recommended = " *"
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%.0f%s\n", c.Concurrency, c.Rows, c.Duration.Round(time.Millisecond), c.RowsPerSecond, recommended)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	if report.RecommendedConcurrency > 0 {
+		fmt.Fprintf(w, "Recommended: klepto steal --concurrency=%d\n", report.RecommendedConcurrency)
+	}
+	for _, table := range report.Tables {
+		if table.RecommendedPageSize > 0 {
+			fmt.Fprintf(w, "\n[[Tables]]\n  Name = %q\n  PageSize = %d\n", table.Table, table.RecommendedPageSize)
+		}
+	}
+
+	return nil
+}
+
+// pageSizeText returns the text of a page size, 0 being a single query.
+func pageSizeText(pageSize uint64) string {
+	if pageSize == 0 {
+		return "none"
+	}
+
+	return fmt.Sprint(pageSize)
+}
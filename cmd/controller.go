@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/kube"
+	"github.com/hellofresh/klepto/pkg/refresh"
+)
+
+// ControllerOptions represents the controller command options
+type ControllerOptions struct {
+	namespace string
+	all       bool
+	interval  time.Duration
+	apiServer string
+}
+
+// NewControllerCmd creates a new controller command
+func NewControllerCmd() *cobra.Command {
+	opts := new(ControllerOptions)
+	cmd := &cobra.Command{
+		Use:   "controller",
+		Short: "Runs the DatabaseRefresh resources of a Kubernetes cluster on their schedule",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunController(opts)
+		},
+	}
+
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVar(&opts.namespace, "namespace", "", "Namespace of the refreshes (default is the namespace of the controller pod)")
+	persistentFlags.BoolVar(&opts.all, "all-namespaces", false, "Runs the refreshes of all the namespaces")
+	persistentFlags.DurationVar(&opts.interval, "interval", time.Minute, "Sets how often the refreshes are checked")
+	persistentFlags.StringVar(&opts.apiServer, "api-server", "", "Kubernetes API url, e.g. of kubectl proxy, used without authentication instead of the in-cluster configuration")
+
+	return cmd
+}
+
+// RunController is the handler for the controller command.
+func RunController(opts *ControllerOptions) error {
+	client := kube.NewClient(opts.apiServer, "", nil)
+	if opts.apiServer == "" {
+		var err error
+		if client, err = kube.NewInClusterClient(); err != nil {
+			return err
+		}
+	}
+
+	namespace := opts.namespace
+	if namespace == "" {
+		namespace = kube.InClusterNamespace()
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+	if opts.all {
+		namespace = ""
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.WithFields(log.Fields{"namespace": namespace, "interval": opts.interval}).Info("watching the database refreshes")
+	refresh.NewController(client, namespace, runRefresh).Run(ctx, opts.interval)
+
+	return nil
+}
+
+// runRefresh steals the database of a refresh, its flags taking precedence over the additional ones.
+func runRefresh(ctx context.Context, run refresh.Run) error {
+	args := append([]string{}, run.Args...)
+	args = append(args, "--from", run.Source, "--to", run.Target)
+	if run.ConfigPath != "" {
+		args = append(args, "--config", run.ConfigPath)
+	}
+
+	steal := NewStealCmd()
+	steal.SetArgs(args)
+	steal.SilenceUsage = true
+	steal.SilenceErrors = true
+
+	return steal.ExecuteContext(ctx)
+}
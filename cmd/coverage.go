@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/coverage"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// CoverageOptions represents the coverage command options
+type CoverageOptions struct {
+	configPaths []string
+	cfgTables   config.Tables
+	strict      bool
+
+	from        string
+	format      string
+	piiPatterns []string
+}
+
+// NewCoverageCmd creates a new coverage command
+func NewCoverageCmd() *cobra.Command {
+	opts := new(CoverageOptions)
+	cmd := &cobra.Command{
+		Use:   "coverage",
+		Short: "Reports whether each source column is dumped, ignored or anonymised, and by which rule",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			load := config.LoadFromFiles
+			if opts.strict {
+				load = config.LoadStrictFromFiles
+			}
+
+			var err error
+			opts.cfgTables, err = load(opts.configPaths...)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCoverage(opts, cmd.OutOrStdout())
+		},
+	}
+
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringArrayVarP(&opts.configPaths, "config", "c", []string{config.DefaultConfigFileName}, "Path to config file, the following ones are overlays deep merged into it")
+	persistentFlags.BoolVar(&opts.strict, "strict", false, "Fails on unknown config keys and on config tables or columns missing from the source instead of warning")
+	persistentFlags.StringVarP(&opts.from, "from", "f", "", "Database dsn to report the columns of")
+	persistentFlags.StringVar(&opts.format, "format", "text", "Output format: text, csv or json")
+	persistentFlags.StringArrayVar(&opts.piiPatterns, "pii-pattern", defaultPIIPatterns, "Regular expression matching the names of columns holding personal data, flagged in the report (case insensitive)")
+	cmd.MarkPersistentFlagRequired("from")
+
+	return cmd
+}
+
+// RunCoverage is the handler for the coverage command.
+func RunCoverage(opts *CoverageOptions, w io.Writer) error {
+	if opts.format != "text" && opts.format != "csv" && opts.format != "json" {
+		return fmt.Errorf("unknown format %q, supported formats are text, csv and json", opts.format)
+	}
+	patterns, err := compilePIIPatterns(opts.piiPatterns)
+	if err != nil {
+		return err
+	}
+
+	source, err := reader.Connect(reader.ConnOpts{DSN: opts.from, MaxConns: 1})
+	if err != nil {
+		return fmt.Errorf("could not connecting to reader: %w", err)
+	}
+	defer func() {
+		if err := source.Close(); err != nil {
+			log.WithError(err).Error("Something is not ok with closing source connection")
+		}
+	}()
+
+	if err := checkConfig(source, opts.cfgTables, opts.strict); err != nil {
+		return err
+	}
+
+	report, err := coverage.Report(source, opts.cfgTables, patterns)
+	if err != nil {
+		return err
+	}
+
+	switch opts.format {
+	case "csv":
+		return coverage.WriteCSV(w, report)
+	case "json":
+		return coverage.WriteJSON(w, report)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tCOLUMN\tSTATUS\tRULE\tPII")
+	for _, c := range report {
+		pii := ""
+		if c.PII {
+			pii = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Table, c.Column, c.Status, c.Rule, pii)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/datadiff"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// DiffDataOptions represents the diff-data command options
+type DiffDataOptions struct {
+	from      string
+	to        string
+	tables    []string
+	chunkSize int
+	format    string
+}
+
+// NewDiffDataCmd creates a new diff-data command
+func NewDiffDataCmd() *cobra.Command {
+	opts := new(DiffDataOptions)
+	cmd := &cobra.Command{
+		Use:   "diff-data",
+		Short: "Compares the rows of the tables of two databases and prints the primary key ranges that differ",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDiffData(opts, cmd.OutOrStdout())
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.from, "from", "f", "", "Database dsn to compare from")
+	flags.StringVarP(&opts.to, "to", "t", "", "Database dsn to compare to, e.g. the refreshed database")
+	flags.StringSliceVar(&opts.tables, "tables", nil, "Only compares these tables (comma separated), all the tables by default")
+	flags.IntVar(&opts.chunkSize, "chunk-size", datadiff.DefaultChunkSize, "Amount of primary keys compared per checksum, the differing ranges are reported with this precision")
+	flags.StringVar(&opts.format, "format", "text", "Output format: text or json")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+// RunDiffData is the handler for the diff-data command, it fails when the data of a table differ.
+func RunDiffData(opts *DiffDataOptions, w io.Writer) error {
+	if opts.format != "text" && opts.format != "json" {
+		return fmt.Errorf("unknown format %q, supported formats are text and json", opts.format)
+	}
+
+	from, err := reader.Connect(reader.ConnOpts{DSN: opts.from, MaxConns: 1})
+	if err != nil {
+		return fmt.Errorf("could not connect to the database to compare from: %w", err)
+	}
+	defer closeReader(from)
+	to, err := reader.Connect(reader.ConnOpts{DSN: opts.to, MaxConns: 1})
+	if err != nil {
+		return fmt.Errorf("could not connect to the database to compare to: %w", err)
+	}
+	defer closeReader(to)
+
+	diff, err := datadiff.Compare(from, to, datadiff.Options{Tables: opts.tables, ChunkSize: opts.chunkSize})
+	if err != nil {
+		return err
+	}
+
+	if opts.format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(diff)
+	} else {
+		err = diff.WriteText(w)
+	}
+	if err != nil {
+		return err
+	}
+
+	if n := diff.Differing(); n > 0 {
+		return fmt.Errorf("the data of %d tables differ", n)
+	}
+
+	return nil
+}
+
+func closeReader(r reader.Reader) {
+	if err := r.Close(); err != nil {
+		log.WithError(err).Error("Something is not ok with closing the connection")
+	}
+}
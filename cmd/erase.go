@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/dumper/query"
+	"github.com/hellofresh/klepto/pkg/erasure"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/subject"
+)
+
+// EraseOptions represents the erase command options
+type EraseOptions struct {
+	configPaths []string
+	cfgTables   config.Tables
+	strict      bool
+
+	from    string
+	subject string
+	dialect string
+	seed    int64
+}
+
+// NewEraseCmd creates a new erase command
+func NewEraseCmd() *cobra.Command {
+	opts := new(EraseOptions)
+	cmd := &cobra.Command{
+		Use:   "erase",
+		Short: "Prints the statements that would erase a data subject, for review",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			load := config.LoadFromFiles
+			if opts.strict {
+				load = config.LoadStrictFromFiles
+			}
+
+			var err error
+			opts.cfgTables, err = load(opts.configPaths...)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunErase(opts, cmd.OutOrStdout())
+		},
+	}
+
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringArrayVarP(&opts.configPaths, "config", "c", []string{config.DefaultConfigFileName}, "Path to config file, the following ones are overlays deep merged into it")
+	persistentFlags.BoolVar(&opts.strict, "strict", false, "Fails on unknown config keys and on config tables or columns missing from the source instead of warning")
+	persistentFlags.StringVarP(&opts.from, "from", "f", "", "Database dsn holding the data subject")
+	persistentFlags.StringVar(&opts.subject, "subject", "", "Data subject to erase, as table.column=key")
+	persistentFlags.StringVar(&opts.dialect, "dialect", "", "SQL dialect of the statements (mysql, postgres, redshift, sqlite or ansi), defaults to the kind of the --from database")
+	persistentFlags.Int64Var(&opts.seed, "seed", 0, "Seeds the fakers and random values with this seed to reproduce a previous run, the seed is logged (0 for a random seed)")
+	cmd.MarkPersistentFlagRequired("from")
+	cmd.MarkPersistentFlagRequired("subject")
+
+	return cmd
+}
+
+// RunErase is the handler for the erase command.
+func RunErase(opts *EraseOptions, w io.Writer) error {
+	subj, err := subject.Parse(opts.subject)
+	if err != nil {
+		return err
+	}
+	name := opts.dialect
+	if name == "" {
+		name = reader.DriverName(opts.from)
+	}
+	dialect, err := query.GetDialect(name)
+	if err != nil {
+		return err
+	}
+	seedRandom(opts.seed)
+
+	source, err := reader.Connect(reader.ConnOpts{DSN: opts.from, MaxConns: 1})
+	if err != nil {
+		return fmt.Errorf("could not connecting to reader: %w", err)
+	}
+	defer func() {
+		if err := source.Close(); err != nil {
+			log.WithError(err).Error("Something is not ok with closing source connection")
+		}
+	}()
+
+	if err := checkConfig(source, opts.cfgTables, opts.strict); err != nil {
+		return err
+	}
+
+	statements, err := erasure.Plan(source, opts.cfgTables, subj, dialect)
+	if err != nil {
+		return err
+	}
+
+	var updates, deletes int
+	for _, stmt := range statements {
+		if stmt.Kind == erasure.Update {
+			updates++
+		} else {
+			deletes++
+		}
+		if _, err := fmt.Fprintf(w, "%s;\n", stmt.SQL); err != nil {
+			return err
+		}
+	}
+	log.WithFields(log.Fields{"updates": updates, "deletes": deletes}).Info("the statements were not run, review them before applying them")
+
+	return nil
+}
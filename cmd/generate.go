@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/anonymiser"
+	"github.com/hellofresh/klepto/pkg/cast"
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/dumper/query"
+	"github.com/hellofresh/klepto/pkg/generator"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	// GenerateOptions represents the generate command options
+	GenerateOptions struct {
+		configPaths []string
+		cfgTables   config.Tables
+		strict      bool
+
+		from        string
+		to          string
+		rows        uint64
+		statsSample uint64
+		concurrency int
+		writeOpts   connOpts
+		dataOnly    bool
+		dialect     string
+		anonWorkers int
+		httpHeaders []string
+		httpBatch   int
+		writeBuffer int
+		seed        int64
+	}
+)
+
+// NewGenerateCmd creates a new generate command
+func NewGenerateCmd() *cobra.Command {
+	opts := new(GenerateOptions)
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generates synthetic data from a database schema",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			load := config.LoadFromFiles
+			if opts.strict {
+				load = config.LoadStrictFromFiles
+			}
+
+			var err error
+			opts.cfgTables, err = load(opts.configPaths...)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunGenerate(opts)
+		},
+	}
+
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringArrayVarP(&opts.configPaths, "config", "c", []string{config.DefaultConfigFileName}, "Path to config file, the following ones are overlays deep merged into it")
+	persistentFlags.BoolVar(&opts.strict, "strict", false, "Fails on unknown config keys and on config tables or columns missing from the source instead of warning")
+	persistentFlags.StringVarP(&opts.from, "from", "f", "", "Dsn of the schema to generate data for, a database or a schema file (sqlfile:///path/schema.sql)")
+	persistentFlags.StringVarP(&opts.to, "to", "t", "os://stdout/", "Database to output to (default writes to stdOut)")
+	persistentFlags.Uint64Var(&opts.rows, "rows", 100, "Sets the amount of rows generated for each table, overridden by the SyntheticRows of the table configuration")
+	persistentFlags.Uint64Var(&opts.statsSample, "stats-sample", 0, "Samples the column statistics from this amount of source rows, so the generated data follows their null ratio, cardinality and range")
+	persistentFlags.IntVar(&opts.concurrency, "concurrency", runtime.NumCPU(), "Sets the amount of tables to be generated concurrently")
+	persistentFlags.DurationVar(&opts.writeOpts.timeout, "write-timeout", 30*time.Second, "Sets the timeout for write operations")
+	persistentFlags.IntVar(&opts.writeOpts.maxConns, "write-max-conns", 5, "Sets the maximum number of open connections to the write database")
+	persistentFlags.IntVar(&opts.writeOpts.maxIdleConns, "write-max-idle-conns", 0, "Sets the maximum number of connections in the idle connection pool for the write database")
+	persistentFlags.BoolVar(&opts.dataOnly, "data-only", false, "Only generate data; requires that the target database structure already exists")
+	persistentFlags.IntVar(&opts.anonWorkers, "anonymiser-workers", 1, "Sets the amount of workers anonymising the rows of each table")
+	persistentFlags.StringArrayVar(&opts.httpHeaders, "http-header", nil, "Header sent with every request when writing to an http(s) endpoint, as \"Name: value\" (environment variables are expanded)")
+	persistentFlags.IntVar(&opts.httpBatch, "http-batch-size", 500, "Sets the amount of rows posted per request when writing to an http(s) endpoint")
+	persistentFlags.IntVar(&opts.writeBuffer, "write-buffer-size", query.DefaultBufferSize, "Sets the size in bytes of the buffer the statements are written through when writing to stdout, stderr or a pg_dump")
+	persistentFlags.Int64Var(&opts.seed, "seed", 0, "Seeds the generated values with this seed to reproduce a previous run, the seed of each run is logged (0 for a random seed)")
+	persistentFlags.StringVar(&opts.dialect, "target-dialect", "", "SQL dialect of the statements written to a file or stdout (mysql, postgres, redshift, sqlite or ansi)")
+	cmd.MarkPersistentFlagRequired("from")
+
+	return cmd
+}
+
+// RunGenerate is the handler for the generate command.
+func RunGenerate(opts *GenerateOptions) (err error) {
+	seedRandom(opts.seed)
+
+	source, err := reader.Connect(reader.ConnOpts{DSN: opts.from, MaxConns: 1})
+	if err != nil {
+		return fmt.Errorf("could not connecting to reader: %w", err)
+	}
+	defer func() {
+		if err := source.Close(); err != nil {
+			log.WithError(err).Error("Something is not ok with closing source connection")
+		}
+	}()
+
+	if err := checkConfig(source, opts.cfgTables, opts.strict); err != nil {
+		return err
+	}
+
+	// the configured anonymisers act as column generators
+	source = generator.NewReader(source, opts.rows, opts.statsSample)
+	source = anonymiser.NewAnonymiser(source, opts.cfgTables, opts.anonWorkers)
+	source, err = cast.NewReader(source, opts.cfgTables)
+	if err != nil {
+		return err
+	}
+
+	headers, err := parseHeaders(opts.httpHeaders)
+	if err != nil {
+		return err
+	}
+
+	target, err := dumper.NewDumper(dumper.ConnOpts{
+		DSN:             opts.to,
+		Timeout:         opts.writeOpts.timeout,
+		MaxConns:        opts.writeOpts.maxConns,
+		MaxIdleConns:    opts.writeOpts.maxIdleConns,
+		TargetDialect:   opts.dialect,
+		HTTPHeaders:     headers,
+		HTTPBatchSize:   opts.httpBatch,
+		WriteBufferSize: opts.writeBuffer,
+	}, source)
+	if err != nil {
+		return fmt.Errorf("error creating dumper: %w", err)
+	}
+	defer func() {
+		if err := target.Close(); err != nil {
+			log.WithError(err).Error("Something is not ok with closing target connection")
+		}
+	}()
+
+	log.WithField("rows", opts.rows).Info("Generating...")
+
+	start := time.Now()
+	result, err := target.Dump(opts.cfgTables, opts.concurrency, opts.dataOnly)
+	if err != nil {
+		return fmt.Errorf("error while dumping: %w", err)
+	}
+	if err := result.Err(); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"total_time": time.Since(start), "rows": result.Rows()}).Info("Done!")
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/anonymiser"
+	"github.com/hellofresh/klepto/pkg/cast"
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/filter"
+	"github.com/hellofresh/klepto/pkg/ignore"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/transform"
+)
+
+type (
+	// PreviewOptions represents the preview command options
+	PreviewOptions struct {
+		configPaths []string
+		cfgTables   config.Tables
+		strict      bool
+
+		from         string
+		table        string
+		rows         uint64
+		showOriginal bool
+		seed         int64
+	}
+
+	// replayReader publishes a copy of rows already read from the source.
+	replayReader struct {
+		reader.Reader
+		rows []database.Row
+	}
+)
+
+// NewPreviewCmd creates a new preview command
+func NewPreviewCmd() *cobra.Command {
+	opts := new(PreviewOptions)
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Prints a few rows of a table as they would be dumped",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			load := config.LoadFromFiles
+			if opts.strict {
+				load = config.LoadStrictFromFiles
+			}
+
+			var err error
+			opts.cfgTables, err = load(opts.configPaths...)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunPreview(opts, cmd.OutOrStdout())
+		},
+	}
+
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringArrayVarP(&opts.configPaths, "config", "c", []string{config.DefaultConfigFileName}, "Path to config file, the following ones are overlays deep merged into it")
+	persistentFlags.BoolVar(&opts.strict, "strict", false, "Fails on unknown config keys and on config tables or columns missing from the source instead of warning")
+	persistentFlags.StringVarP(&opts.from, "from", "f", "", "Database dsn to preview the data from")
+	persistentFlags.StringVar(&opts.table, "table", "", "Table to preview")
+	persistentFlags.Uint64Var(&opts.rows, "rows", 10, "Sets the amount of rows previewed")
+	persistentFlags.BoolVar(&opts.showOriginal, "show-original", false, "Prints the original values next to the anonymised ones")
+	persistentFlags.Int64Var(&opts.seed, "seed", 0, "Seeds the fakers and random values with this seed to reproduce a previous preview, the seed is logged (0 for a random seed)")
+	cmd.MarkPersistentFlagRequired("from")
+	cmd.MarkPersistentFlagRequired("table")
+
+	return cmd
+}
+
+// RunPreview is the handler for the preview command.
+func RunPreview(opts *PreviewOptions, w io.Writer) (err error) {
+	seedRandom(opts.seed)
+
+	source, err := reader.Connect(reader.ConnOpts{DSN: opts.from, MaxConns: 1})
+	if err != nil {
+		return fmt.Errorf("could not connecting to reader: %w", err)
+	}
+	defer func() {
+		if err := source.Close(); err != nil {
+			log.WithError(err).Error("Something is not ok with closing source connection")
+		}
+	}()
+
+	if err := checkConfig(source, opts.cfgTables, opts.strict); err != nil {
+		return err
+	}
+	source, err = filter.NewReader(source, opts.cfgTables, nil)
+	if err != nil {
+		return err
+	}
+	source = ignore.NewReader(source, opts.cfgTables)
+
+	var readOpts reader.ReadTableOpt
+	table := opts.cfgTables.FindByName(opts.table)
+	if table != nil {
+		readOpts = reader.NewReadTableOpt(table)
+	}
+	readOpts.Limit = opts.rows
+
+	// originals are read first, so they can be printed next to the rows they were anonymised into
+	var originals []database.Row
+	if table == nil || table.SyntheticRows == 0 {
+		// dropped rows are left out of the originals, the transforms are previewed as changes
+		kept, err := transform.NewDropReader(source, opts.cfgTables)
+		if err != nil {
+			return err
+		}
+		originals, err = readRows(kept, opts.table, readOpts)
+		if err != nil {
+			return err
+		}
+	}
+
+	var preview reader.Reader = &replayReader{Reader: source, rows: originals}
+	preview, err = transform.NewReader(preview, opts.cfgTables)
+	if err != nil {
+		return err
+	}
+	preview = anonymiser.NewAnonymiser(preview, opts.cfgTables, 1)
+	preview, err = cast.NewReader(preview, opts.cfgTables)
+	if err != nil {
+		return err
+	}
+
+	rows, err := readRows(preview, opts.table, readOpts)
+	if err != nil {
+		return err
+	}
+	if uint64(len(rows)) > opts.rows {
+		rows = rows[:opts.rows]
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, row := range rows {
+		var original database.Row
+		hasOriginal := i < len(originals)
+		if hasOriginal {
+			original = originals[i]
+		}
+
+		fmt.Fprintf(tw, "# row %d\n", i+1)
+		if opts.showOriginal {
+			fmt.Fprintln(tw, "COLUMN\tORIGINAL\tVALUE\tCHANGED")
+		} else {
+			fmt.Fprintln(tw, "COLUMN\tVALUE\tCHANGED")
+		}
+		for _, column := range row.Columns() {
+			value := row.Get(column)
+
+			before := original.Get(column)
+			changed := !hasOriginal || !reflect.DeepEqual(before, value)
+
+			mark := ""
+			if changed {
+				mark = "*"
+			}
+			if opts.showOriginal {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", column, previewValue(before, hasOriginal), previewValue(value, true), mark)
+			} else {
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", column, previewValue(value, true), mark)
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// ReadTable publishes a copy of the rows, as the anonymiser changes them.
+func (r *replayReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	if len(r.rows) == 0 {
+		return nil
+	}
+
+	columns := r.rows[0].ColumnIndex()
+	for _, row := range r.rows {
+		values := make([]interface{}, row.Len())
+		copy(values, row.Values())
+		rowChan <- database.NewRow(columns, values)
+	}
+
+	return nil
+}
+
+// readRows reads all the rows returned by a reader for a table.
+func readRows(source reader.Reader, tableName string, opts reader.ReadTableOpt) ([]database.Row, error) {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- source.ReadTable(tableName, rowChan, opts)
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+	if err := <-errChan; err != nil {
+		return nil, fmt.Errorf("could not read table %s: %w", tableName, err)
+	}
+
+	return rows, nil
+}
+
+// previewValue returns the printable text of a column value, known is false when there is no value to print.
+func previewValue(value interface{}, known bool) string {
+	if !known {
+		return "-"
+	}
+
+	if p, ok := value.(*interface{}); ok && p != nil {
+		value = *p
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+
+	return fmt.Sprint(value)
+}
@@ -1,14 +1,20 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/hellofresh/klepto/pkg/formatter"
 )
 
+// envPrefix prefixes the environment variables setting the flags, e.g. KLEPTO_FROM sets --from.
+const envPrefix = "KLEPTO_"
+
 var (
 	verbose bool
 	version = "0.0.0-dev"
@@ -30,16 +36,60 @@ var (
 
 func init() {
 	RootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Make the operation more talkative")
-	RootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+	RootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := setFlagsFromEnv(cmd.Flags()); err != nil {
+			return err
+		}
 		if verbose {
 			log.SetLevel(log.DebugLevel)
 		}
+
+		return nil
 	}
 
 	RootCmd.AddCommand(NewUpdateCmd())
 	RootCmd.AddCommand(NewInitCmd())
 	RootCmd.AddCommand(NewStealCmd())
+	RootCmd.AddCommand(NewGenerateCmd())
+	RootCmd.AddCommand(NewAnonymisersCmd())
+	RootCmd.AddCommand(NewPreviewCmd())
+	RootCmd.AddCommand(NewSchemaCmd())
+	RootCmd.AddCommand(NewDiffDataCmd())
+	RootCmd.AddCommand(NewCoverageCmd())
+	RootCmd.AddCommand(NewTestRulesCmd())
+	RootCmd.AddCommand(NewBenchCmd())
+	RootCmd.AddCommand(NewEraseCmd())
+	RootCmd.AddCommand(NewControllerCmd())
+	RootCmd.AddCommand(NewServeCmd())
+	RootCmd.AddCommand(NewAPICmd())
 
 	log.SetOutput(os.Stderr)
 	log.SetFormatter(&formatter.CliFormatter{})
 }
+
+// setFlagsFromEnv sets the flags that are not given on the command line from their environment variable,
+// so the flags given on the command line take precedence. Repeatable flags take one value per line.
+func setFlagsFromEnv(flags *pflag.FlagSet) error {
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed {
+			return
+		}
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+
+		if values, ok := f.Value.(pflag.SliceValue); ok && f.Value.Type() == "stringArray" {
+			err = values.Replace(strings.Split(strings.TrimRight(value, "\n"), "\n"))
+			f.Changed = true
+			return
+		}
+		if setErr := flags.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value of %s: %w", name, setErr)
+		}
+	})
+
+	return err
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/schema"
+)
+
+// SchemaDiffOptions represents the schema diff command options
+type SchemaDiffOptions struct {
+	from   string
+	to     string
+	format string
+}
+
+// NewSchemaCmd creates a new schema command
+func NewSchemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Inspect the schema of databases",
+	}
+
+	opts := new(SchemaDiffOptions)
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Prints the tables, columns and indexes that differ from one database to another",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunSchemaDiff(opts, cmd.OutOrStdout())
+		},
+	}
+
+	flags := diffCmd.Flags()
+	flags.StringVarP(&opts.from, "from", "f", "", "Database dsn to compare from")
+	flags.StringVarP(&opts.to, "to", "t", "", "Database dsn to compare to")
+	flags.StringVar(&opts.format, "format", "text", "Output format: text or json")
+	diffCmd.MarkFlagRequired("from")
+	diffCmd.MarkFlagRequired("to")
+	cmd.AddCommand(diffCmd)
+
+	return cmd
+}
+
+// RunSchemaDiff is the handler for the schema diff command.
+func RunSchemaDiff(opts *SchemaDiffOptions, w io.Writer) error {
+	if opts.format != "text" && opts.format != "json" {
+		return fmt.Errorf("unknown format %q, supported formats are text and json", opts.format)
+	}
+
+	from, err := readSchema(opts.from)
+	if err != nil {
+		return fmt.Errorf("could not read the schema to compare from: %w", err)
+	}
+	to, err := readSchema(opts.to)
+	if err != nil {
+		return fmt.Errorf("could not read the schema to compare to: %w", err)
+	}
+
+	// the types of different database kinds can not be compared
+	diff := schema.Compare(from, to, reader.DriverName(opts.from) == reader.DriverName(opts.to))
+	if opts.format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	}
+
+	return diff.WriteText(w)
+}
+
+func readSchema(dsn string) (*schema.Schema, error) {
+	r, err := reader.Connect(reader.ConnOpts{DSN: dsn, MaxConns: 1})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			log.WithError(err).Error("Something is not ok with closing the connection")
+		}
+	}()
+
+	return schema.Read(r)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/notify"
+	"github.com/hellofresh/klepto/pkg/schedule"
+)
+
+// ServeOptions represents the serve command options
+type ServeOptions struct {
+	StealOptions
+	schedule string
+}
+
+// NewServeCmd creates a new serve command
+func NewServeCmd() *cobra.Command {
+	opts := new(ServeOptions)
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Steals and anonymises databases on a cron schedule",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// fails on start on an invalid configuration, it is loaded again before each run
+			return opts.loadConfig()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunServe(opts)
+		},
+	}
+
+	addStealFlags(cmd.PersistentFlags(), &opts.StealOptions)
+	cmd.PersistentFlags().StringVar(&opts.schedule, "schedule", "", "Cron expression the steal is run on, e.g. \"0 3 * * *\" or @daily, in the local time zone")
+	cmd.MarkPersistentFlagRequired("schedule")
+
+	return cmd
+}
+
+// RunServe is the handler for the serve command.
+// Runs never overlap: the scheduled times passing while a run is still going are skipped.
+func RunServe(opts *ServeOptions) error {
+	sched, err := schedule.Parse(opts.schedule)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for {
+		next := sched.Next(time.Now())
+		if next.IsZero() {
+			return fmt.Errorf("the schedule %q has no next run", opts.schedule)
+		}
+		log.WithField("next_run", next.Format(time.RFC3339)).Info("Waiting for the next run")
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			serveRun(opts)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			// restores the default behaviour, so that a second interrupt aborts the run
+			stop()
+			log.Warn("Waiting for the running steal to finish, interrupt again to abort")
+			<-done
+			return nil
+		}
+
+		if skipped := countRuns(sched, next, time.Now()); skipped > 0 {
+			log.WithField("skipped", skipped).Warn("The run lasted past the next scheduled runs, they were skipped")
+		}
+	}
+}
+
+// serveRun runs the steal with its configuration loaded again, so that its changes apply without restarting.
+func serveRun(opts *ServeOptions) {
+	run := opts.StealOptions
+	if err := run.loadConfig(); err != nil {
+		log.WithError(err).Error("Could not load the config, the run is skipped")
+		run.notifiers().Send(notify.Event{Kind: notify.Failure, Command: "steal", Error: err.Error()})
+		return
+	}
+
+	if err := RunSteal(&run); err != nil {
+		log.WithError(err).Error("The run failed")
+	}
+}
+
+// countRuns counts the scheduled times after since and until now.
+func countRuns(sched *schedule.Schedule, since time.Time, now time.Time) int {
+	count := 0
+	for next := sched.Next(since); !next.IsZero() && !next.After(now); next = sched.Next(next) {
+		count++
+	}
+
+	return count
+}
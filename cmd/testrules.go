@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/ruletest"
+)
+
+// TestRulesOptions represents the test-rules command options
+type TestRulesOptions struct {
+	configPaths []string
+	files       []string
+}
+
+// NewTestRulesCmd creates a new test-rules command
+func NewTestRulesCmd() *cobra.Command {
+	opts := new(TestRulesOptions)
+	cmd := &cobra.Command{
+		Use:   "test-rules FILE...",
+		Short: "Runs the assertions of rule test files against the anonymisers of the config",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.files = args
+			// failing tests are not a usage error
+			cmd.SilenceUsage = true
+			return RunTestRules(opts, cmd.OutOrStdout())
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringArrayVarP(&opts.configPaths, "config", "c", []string{config.DefaultConfigFileName}, "Path to config file, the following ones are overlays deep merged into it")
+
+	return cmd
+}
+
+// RunTestRules is the handler for the test-rules command, it fails when a test fails.
+func RunTestRules(opts *TestRulesOptions, w io.Writer) error {
+	suites := make([]*ruletest.Suite, len(opts.files))
+	usesConfig := false
+	for i, file := range opts.files {
+		suite, err := ruletest.Load(file)
+		if err != nil {
+			return err
+		}
+		suites[i] = suite
+		usesConfig = usesConfig || suite.UsesConfig()
+	}
+
+	// the config is only needed by the tests of its rules
+	var tables config.Tables
+	if usesConfig {
+		var err error
+		if tables, err = config.LoadFromFiles(opts.configPaths...); err != nil {
+			return err
+		}
+	}
+
+	total, failed := 0, 0
+	for _, suite := range suites {
+		for _, result := range suite.Run(tables) {
+			total++
+			if result.Passed() {
+				fmt.Fprintf(w, "PASS  %s\n", result.Name)
+				continue
+			}
+
+			failed++
+			fmt.Fprintf(w, "FAIL  %s\n", result.Name)
+			for _, failure := range result.Failures {
+				fmt.Fprintf(w, "      %s\n", failure)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rule tests failed", failed, total)
+	}
+	fmt.Fprintf(w, "%d rule tests passed\n", total)
+
+	return nil
+}
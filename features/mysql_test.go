@@ -51,11 +51,9 @@ func (s *MysqlTestSuite) TestExample() {
 		s.Assert().NoError(err)
 	}()
 
-	done := make(chan struct{})
-	defer close(done)
-	s.Require().NoError(dmp.Dump(done, config.Tables{}, 4, false), "Failed to dump")
-
-	<-done
+	result, err := dmp.Dump(config.Tables{}, 4, false)
+	s.Require().NoError(err, "Failed to dump")
+	s.Require().NoError(result.Err(), "Failed to dump the tables")
 
 	s.assertDatabaseAreTheSame(readDSN, dumpDSN)
 }
@@ -0,0 +1,2 @@
+ANALYZE users;
+ANALYZE orders;
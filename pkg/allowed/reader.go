@@ -0,0 +1,210 @@
+// Package allowed keeps the anonymised values within what their columns allow, so that their inserts do not fail
+// on the target: the columns restricted to a list of values, by their ENUM or SET type or a CHECK constraint, only
+// receive allowed values and the values of the character columns are truncated to their maximum length.
+package allowed
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	allowedReader struct {
+		reader.Reader
+		// columns are the restricted anonymised columns by table and column.
+		columns map[string]map[string]*column
+	}
+
+	// column holds what a restricted column allows.
+	column struct {
+		reader.AllowedValues
+		allowed map[string]bool
+		// maxLength is the maximum amount of characters of the column, 0 when it is not limited.
+		maxLength int
+		// warn logs once that values of the column are replaced.
+		warn sync.Once
+	}
+)
+
+// NewReader returns a reader replacing the anonymised values that are not allowed in their column by allowed ones.
+// The allowed values and the column types of the anonymised columns are read from schema when it knows them
+// (see reader.AllowedValuer and reader.ColumnTyper).
+func NewReader(source reader.Reader, schema reader.Reader, tables config.Tables) reader.Reader {
+	valuer, hasValues := schema.(reader.AllowedValuer)
+	typer, hasTypes := schema.(reader.ColumnTyper)
+	if !hasValues && !hasTypes {
+		log.Debug("the reader does not know what the columns allow, the anonymised values are not checked")
+		return source
+	}
+
+	columns := make(map[string]map[string]*column)
+	for _, table := range tables {
+		if len(table.Anonymise) == 0 {
+			continue
+		}
+
+		logger := log.WithField("table", table.Name)
+		var (
+			values map[string]reader.AllowedValues
+			types  map[string]string
+			err    error
+		)
+		if hasValues {
+			values, err = valuer.GetAllowedValues(table.Name)
+			if err != nil && !errors.Is(err, reader.ErrAllowedValuesUnsupported) {
+				logger.WithError(err).Warn("could not get the allowed values of the columns, the anonymised values are not checked")
+				continue
+			}
+		}
+		if hasTypes {
+			types, err = typer.GetColumnTypes(table.Name)
+			if err != nil && !errors.Is(err, reader.ErrColumnTypesUnsupported) {
+				logger.WithError(err).Warn("could not get the column types, the anonymised values are not checked")
+				continue
+			}
+		}
+
+		for name := range table.Anonymise {
+			restricted, isRestricted := values[name]
+			maxLength, isLimited := reader.ParseMaxLength(types[name])
+			if !isRestricted && !isLimited {
+				continue
+			}
+			if columns[table.Name] == nil {
+				columns[table.Name] = make(map[string]*column)
+			}
+			columns[table.Name][name] = newColumn(restricted, maxLength)
+		}
+	}
+
+	if len(columns) == 0 {
+		return source
+	}
+
+	return &allowedReader{Reader: source, columns: columns}
+}
+
+func newColumn(values reader.AllowedValues, maxLength int) *column {
+	allowed := make(map[string]bool, len(values.Values))
+	for _, value := range values.Values {
+		allowed[value] = true
+	}
+
+	return &column{AllowedValues: values, allowed: allowed, maxLength: maxLength}
+}
+
+// ReadTable replaces the values of the rows read that are not allowed in their column.
+func (r *allowedReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	columns, ok := r.columns[tableName]
+	if !ok {
+		return r.Reader.ReadTable(tableName, rowChan, opts)
+	}
+	defer close(rowChan)
+
+	rawChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.Reader.ReadTable(tableName, rawChan, opts)
+	}()
+
+	for row := range rawChan {
+		for name, c := range columns {
+			value, ok := row.Lookup(name)
+			if !ok {
+				continue
+			}
+			if p, ok := value.(*interface{}); ok && p != nil {
+				value = *p
+			}
+			if value == nil {
+				continue
+			}
+
+			if constrained, replaced := c.constrain(value); replaced {
+				c.warn.Do(func() {
+					logger := log.WithFields(log.Fields{"table": tableName, "column": name})
+					if len(c.Values) == 0 {
+						logger.WithField("length", c.maxLength).Warn("anonymised values longer than the column are truncated")
+						return
+					}
+					logger.Warn("anonymised values not allowed in the column are replaced by allowed ones")
+				})
+				row.Set(name, constrained)
+			}
+		}
+		rowChan <- row
+	}
+
+	return <-errChan
+}
+
+// constrain returns the value, or an allowed value replacing it when it is not allowed.
+// The elements of a SET that are not allowed are dropped, the values longer than the column are truncated.
+func (c *column) constrain(value interface{}) (interface{}, bool) {
+	text := toString(value)
+
+	if len(c.Values) == 0 {
+		if c.maxLength == 0 || utf8.RuneCountInString(text) <= c.maxLength {
+			return value, false
+		}
+		return truncate(text, c.maxLength), true
+	}
+
+	if c.Set {
+		if text == "" {
+			return value, false
+		}
+		elements := strings.Split(text, ",")
+		kept := elements[:0:0]
+		for _, element := range elements {
+			if c.allowed[element] {
+				kept = append(kept, element)
+			}
+		}
+		if len(kept) == len(elements) {
+			return value, false
+		}
+		if len(kept) > 0 {
+			return strings.Join(kept, ","), true
+		}
+	} else if c.allowed[text] {
+		return value, false
+	}
+
+	// the same values are replaced by the same allowed value, so that they keep grouping together
+	h := fnv.New32a()
+	h.Write([]byte(text))
+	return c.Values[h.Sum32()%uint32(len(c.Values))], true
+}
+
+// truncate returns the first n characters of the text.
+func truncate(text string, n int) string {
+	for i := range text {
+		if n == 0 {
+			return text[:i]
+		}
+		n--
+	}
+	return text
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,104 @@
+package allowed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTable(t *testing.T) {
+	source := &mockReader{rows: [][]interface{}{
+		{"paid", "red,green", "a"},
+		{"lorem", "red,blue", "b"},
+		{[]byte("lorem"), "blue", nil},
+	}}
+	tables := config.Tables{{Name: "orders", Anonymise: map[string]string{"status": "Word", "colors": "Word", "note": "Word"}}}
+
+	rdr := NewReader(source, source, tables)
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- rdr.ReadTable("orders", rowChan, reader.ReadTableOpt{})
+	}()
+
+	var rows [][]interface{}
+	for row := range rowChan {
+		rows = append(rows, row.Values())
+	}
+	require.NoError(t, <-errChan)
+
+	require.Len(t, rows, 3)
+	assert.Equal(t, []interface{}{"paid", "red,green", "a"}, rows[0], "allowed values are kept")
+	assert.Contains(t, []string{"new", "paid"}, rows[1][0])
+	assert.Equal(t, "red", rows[1][1], "the elements of a set that are not allowed are dropped")
+	assert.Equal(t, rows[1][0], rows[2][0], "the same values are replaced by the same allowed value")
+	assert.Contains(t, []string{"red", "green"}, rows[2][1])
+	assert.Nil(t, rows[2][2])
+}
+
+func TestReadTableTruncates(t *testing.T) {
+	source := &mockReader{
+		rows:  [][]interface{}{{"paid", "red", "Schröder-Müller"}, {"paid", "red", "short"}},
+		types: map[string]string{"note": "varchar(8)"},
+	}
+	tables := config.Tables{{Name: "orders", Anonymise: map[string]string{"note": "Company"}}}
+
+	rdr := NewReader(source, source, tables)
+	rowChan := make(chan database.Row)
+	go rdr.ReadTable("orders", rowChan, reader.ReadTableOpt{})
+
+	var notes []interface{}
+	for row := range rowChan {
+		notes = append(notes, row.Get("note"))
+	}
+	assert.Equal(t, []interface{}{"Schröder", "short"}, notes, "the values are truncated to the amount of characters of the column")
+}
+
+func TestNewReaderUnsupported(t *testing.T) {
+	source := &mockReader{}
+	tables := config.Tables{{Name: "orders", Anonymise: map[string]string{"status": "Word"}}}
+
+	assert.Same(t, source, NewReader(source, struct{ reader.Reader }{source}, tables))
+	assert.Same(t, source, NewReader(source, source, config.Tables{{Name: "orders"}}))
+}
+
+type mockReader struct {
+	rows  [][]interface{}
+	types map[string]string
+}
+
+func (m *mockReader) GetStructure() (string, error) { return "", nil }
+func (m *mockReader) GetTables() ([]string, error)  { return []string{"orders"}, nil }
+func (m *mockReader) GetColumns(string) ([]string, error) {
+	return []string{"status", "colors", "note"}, nil
+}
+func (m *mockReader) FormatColumn(tableName string, column string) string { return column }
+func (m *mockReader) Close() error                                        { return nil }
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	columns := database.NewColumns([]string{"status", "colors", "note"})
+	for _, values := range m.rows {
+		rowChan <- database.NewRow(columns, append([]interface{}{}, values...))
+	}
+	return nil
+}
+func (m *mockReader) GetColumnTypes(string) (map[string]string, error) {
+	if m.types == nil {
+		return nil, reader.ErrColumnTypesUnsupported
+	}
+	return m.types, nil
+}
+func (m *mockReader) GetAllowedValues(string) (map[string]reader.AllowedValues, error) {
+	if m.types != nil {
+		return nil, reader.ErrAllowedValuesUnsupported
+	}
+	return map[string]reader.AllowedValues{
+		"status": {Values: []string{"new", "paid"}},
+		"colors": {Values: []string{"red", "green"}, Set: true},
+	}, nil
+}
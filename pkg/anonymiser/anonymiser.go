@@ -1,18 +1,23 @@
 package anonymiser
 
 import (
-	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math/rand"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/hellofresh/klepto/pkg/audit"
 	"github.com/hellofresh/klepto/pkg/config"
 	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/generator"
 	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/stats"
 )
 
 const (
@@ -39,12 +44,39 @@ type (
 	anonymiser struct {
 		reader.Reader
 		tables config.Tables
+		// workers is the amount of goroutines anonymising the rows of a table.
+		workers int
+		// pseudonyms are the fake values of the pseudonymised columns.
+		pseudonyms *Pseudonyms
+		// trail records how the sampled rows are anonymised, when set.
+		trail *audit.Trail
 	}
 )
 
 // NewAnonymiser returns a new anonymiser reader.
-func NewAnonymiser(source reader.Reader, tables config.Tables) reader.Reader {
-	return &anonymiser{source, tables}
+// Rows of a table are anonymised by the given amount of workers, when more than one
+// worker is used the rows are not published in the order they were read.
+func NewAnonymiser(source reader.Reader, tables config.Tables, workers int) reader.Reader {
+	return NewAnonymiserWithPseudonyms(source, tables, workers, NewPseudonyms())
+}
+
+// NewAnonymiserWithPseudonyms returns a new anonymiser reader faking the pseudonymised columns with the given
+// pseudonyms, so that anonymisers sharing them fake an original value identically. New pseudonyms are used when nil.
+func NewAnonymiserWithPseudonyms(source reader.Reader, tables config.Tables, workers int, pseudonyms *Pseudonyms) reader.Reader {
+	return NewAuditedAnonymiser(source, tables, workers, pseudonyms, nil)
+}
+
+// NewAuditedAnonymiser returns a new anonymiser reader recording in the trail how the columns of the sampled rows
+// are anonymised. Nothing is recorded when the trail is nil.
+func NewAuditedAnonymiser(source reader.Reader, tables config.Tables, workers int, pseudonyms *Pseudonyms, trail *audit.Trail) reader.Reader {
+	if workers < 1 {
+		workers = 1
+	}
+	if pseudonyms == nil {
+		pseudonyms = NewPseudonyms()
+	}
+
+	return &anonymiser{source, tables, workers, pseudonyms, trail}
 }
 
 // ReadTable decorates reader.ReadTable method for anonymising rows published from the reader.Reader
@@ -57,68 +89,167 @@ func (a *anonymiser) ReadTable(tableName string, rowChan chan<- database.Row, op
 		return a.Reader.ReadTable(tableName, rowChan, opts)
 	}
 
+	source := a.Reader
+	if table.SyntheticRows > 0 {
+		logger.WithField("rows", table.SyntheticRows).Debug("generating synthetic rows, the table data is not read")
+		var sample uint64
+		if table.PreserveStats {
+			sample = stats.DefaultSampleSize
+		}
+		source = generator.NewReader(a.Reader, table.SyntheticRows, sample)
+	}
+
+	var values *consistentValues
+	if table.PreserveStats {
+		values = newConsistentValues()
+	}
+	pseudonymised := make(map[string]bool, len(table.Pseudonymise))
+	for _, column := range table.Pseudonymise {
+		pseudonymised[column] = true
+	}
+
 	if len(table.Anonymise) == 0 {
 		logger.Debug("Skipping anonymiser")
-		return a.Reader.ReadTable(tableName, rowChan, opts)
+		return source.ReadTable(tableName, rowChan, opts)
 	}
 
 	// Create read/write chanel
 	rawChan := make(chan database.Row)
+	// rows are numbered in the order they are anonymised, for the sequence generators
+	var rows uint64
 
-	go func(rowChan chan<- database.Row, rawChan chan database.Row, table *config.Table) {
-		for {
-			row, more := <-rawChan
-			if !more {
-				close(rowChan)
-				return
-			}
+	workers := a.workers
+	if table.Workers > 0 {
+		workers = table.Workers
+	}
 
-			for column, fakerType := range table.Anonymise {
-				if strings.HasPrefix(fakerType, literalPrefix) {
-					row[column] = strings.TrimPrefix(fakerType, literalPrefix)
-					continue
-				}
-
-				fakerType, args := getTypeArgs(fakerType)
-				faker, found := Functions[fakerType]
-				if !found {
-					logger.WithField("anonymiser", fakerType).Error("Anonymiser is not found")
-					// TODO: actually we should stop the whole process here,
-					// but currently there is no simple way of doing this, so as a workaround
-					// we'll just break dump in case log error will be missed by the user
-					row[column] = fmt.Sprintf("Invalid anonymiser: %s", fakerType)
-					continue
-				}
-
-				var value string
-				switch fakerType {
-				case email, username:
-					b := make([]byte, 2)
-					rand.Read(b)
-					value = fmt.Sprintf(
-						"%s.%s",
-						faker.Call([]reflect.Value{})[0].String(),
-						hex.EncodeToString(b),
-					)
-				case latitude, longitude:
-					value = fmt.Sprintf("%f", faker.Call(args)[0].Float())
-				default:
-					value = faker.Call(args)[0].String()
-				}
-				row[column] = value
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(rowChan chan<- database.Row, rawChan <-chan database.Row, table *config.Table) {
+			defer wg.Done()
+			for row := range rawChan {
+				a.anonymiseRow(row, atomic.AddUint64(&rows, 1), table, values, pseudonymised, logger)
+				rowChan <- row
 			}
+		}(rowChan, rawChan, table)
+	}
 
-			rowChan <- row
-		}
-	}(rowChan, rawChan, table)
+	go func() {
+		wg.Wait()
+		close(rowChan)
+	}()
 
-	if err := a.Reader.ReadTable(tableName, rawChan, opts); err != nil {
+	if err := source.ReadTable(tableName, rawChan, opts); err != nil {
 		return fmt.Errorf("anonymiser: error while reading table: %w", err)
 	}
 
 	return nil
 }
 
+// anonymiseRow replaces the configured columns of a row with fake values, n being the number of the row.
+// When values is set, NULL values are kept and an original value is always replaced by the same fake value.
+// The pseudonymised columns are faked the same way, with the fake values shared by the columns with the same anonymiser.
+// The anonymisation of the row is recorded in the audit trail when the row is sampled.
+func (a *anonymiser) anonymiseRow(row database.Row, n uint64, table *config.Table, values *consistentValues, pseudonymised map[string]bool, logger *log.Entry) {
+	var record *audit.Record
+	if a.trail.Sampled(n) {
+		// the audited columns are not moved by appending the following ones
+		record = &audit.Record{Table: table.Name, Row: n, Columns: make([]audit.Column, 0, len(table.Anonymise))}
+		defer func() {
+			if err := a.trail.Write(*record); err != nil {
+				logger.WithError(err).Warn("could not write the audit trail")
+			}
+		}()
+	}
+
+	for column, anonymiser := range table.Anonymise {
+		original, ok := row.Lookup(column)
+		if !ok {
+			logger.WithField("column", column).Debug("anonymised column is not part of the table")
+			continue
+		}
+
+		var trace func(step string, value interface{})
+		if record != nil {
+			record.Columns = append(record.Columns, audit.Column{Column: column, Rule: anonymiser, Before: a.trail.Hash(nullable(original))})
+			audited := &record.Columns[len(record.Columns)-1]
+			trace = func(step string, value interface{}) {
+				audited.Steps = append(audited.Steps, audit.Step{Step: step, After: a.trail.Hash(value)})
+			}
+			defer func(column string, original interface{}) {
+				audited.After = a.trail.Hash(nullable(row.Get(column)))
+				// the pseudonymised and consistent values are only faked once
+				audited.Reused = len(audited.Steps) == 0 && !isNull(original)
+			}(column, original)
+		}
+
+		if strings.HasPrefix(anonymiser, literalPrefix) {
+			row.Set(column, strings.TrimPrefix(anonymiser, literalPrefix))
+			if trace != nil {
+				trace(anonymiser, row.Get(column))
+			}
+			continue
+		}
+
+		steps := parseChain(anonymiser)
+		fake := func() interface{} { return traceChain(steps, original, n, logger, trace) }
+		if values == nil && !pseudonymised[column] {
+			row.Set(column, fake())
+			continue
+		}
+
+		if isNull(original) {
+			continue
+		}
+		if pseudonymised[column] {
+			row.Set(column, a.pseudonyms.get(anonymiser, original, fake))
+			continue
+		}
+		row.Set(column, values.get(column, original, fake))
+	}
+}
+
+// nullable returns nil for the NULL values, whatever their type.
+func nullable(value interface{}) interface{} {
+	if isNull(value) {
+		return nil
+	}
+
+	return value
+}
+
+// fakeValue returns a value of the given faker type.
+func fakeValue(fakerType string, logger *log.Entry) string {
+	fakerType, args := getTypeArgs(fakerType)
+	faker, found := Functions[fakerType]
+	if !found {
+		logger.WithField("anonymiser", fakerType).Error("Anonymiser is not found")
+		// TODO: actually we should stop the whole process here,
+		// but currently there is no simple way of doing this, so as a workaround
+		// we'll just break dump in case log error will be missed by the user
+		return fmt.Sprintf("Invalid anonymiser: %s", fakerType)
+	}
+
+	var value string
+	switch fakerType {
+	case email, username:
+		b := make([]byte, 2)
+		rand.Read(b)
+		value = fmt.Sprintf(
+			"%s.%s",
+			faker.Call([]reflect.Value{})[0].String(),
+			hex.EncodeToString(b),
+		)
+	case latitude, longitude:
+		value = fmt.Sprintf("%f", faker.Call(args)[0].Float())
+	default:
+		value = fmt.Sprint(faker.Call(args)[0].Interface())
+	}
+
+	return value
+}
+
 func getTypeArgs(fakerType string) (string, []reflect.Value) {
 	parts := strings.Split(fakerType, ":")
 	fType := parts[0]
@@ -1,14 +1,19 @@
 package anonymiser
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"testing"
 	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hellofresh/klepto/pkg/audit"
 	"github.com/hellofresh/klepto/pkg/config"
 	"github.com/hellofresh/klepto/pkg/database"
 	"github.com/hellofresh/klepto/pkg/reader"
@@ -95,7 +100,7 @@ func TestReadTable(t *testing.T) {
 }
 
 func testWhenAnonymiserIsNotInitialized(t *testing.T, opts reader.ReadTableOpt, tables config.Tables) {
-	anonymiser := NewAnonymiser(&mockReader{}, tables)
+	anonymiser := NewAnonymiser(&mockReader{}, tables, 1)
 
 	rowChan := make(chan database.Row, 1)
 	defer close(rowChan)
@@ -105,7 +110,7 @@ func testWhenAnonymiserIsNotInitialized(t *testing.T, opts reader.ReadTableOpt,
 }
 
 func testWhenTableIsNotSetInConfig(t *testing.T, opts reader.ReadTableOpt, tables config.Tables) {
-	anonymiser := NewAnonymiser(&mockReader{}, tables)
+	anonymiser := NewAnonymiser(&mockReader{}, tables, 1)
 
 	rowChan := make(chan database.Row, 1)
 	defer close(rowChan)
@@ -115,7 +120,7 @@ func testWhenTableIsNotSetInConfig(t *testing.T, opts reader.ReadTableOpt, table
 }
 
 func testWhenColumnIsAnonymised(t *testing.T, opts reader.ReadTableOpt, tables config.Tables) {
-	anonymiser := NewAnonymiser(&mockReader{}, tables)
+	anonymiser := NewAnonymiser(&mockReader{}, tables, 1)
 
 	rowChan := make(chan database.Row)
 	defer close(rowChan)
@@ -126,14 +131,14 @@ func testWhenColumnIsAnonymised(t *testing.T, opts reader.ReadTableOpt, tables c
 	timeoutChan := time.After(waitTimeout)
 	select {
 	case row := <-rowChan:
-		assert.NotEqual(t, "to_be_anonimised", row["column_test"])
+		assert.NotEqual(t, "to_be_anonimised", row.Get("column_test"))
 	case <-timeoutChan:
 		assert.FailNow(t, "Failing due to timeout")
 	}
 }
 
 func testWhenColumnIsAnonymisedWithLiteral(t *testing.T, opts reader.ReadTableOpt, tables config.Tables) {
-	anonymiser := NewAnonymiser(&mockReader{}, tables)
+	anonymiser := NewAnonymiser(&mockReader{}, tables, 1)
 
 	rowChan := make(chan database.Row)
 	defer close(rowChan)
@@ -144,14 +149,14 @@ func testWhenColumnIsAnonymisedWithLiteral(t *testing.T, opts reader.ReadTableOp
 	timeoutChan := time.After(waitTimeout)
 	select {
 	case row := <-rowChan:
-		assert.Equal(t, "Hello", row["column_test"])
+		assert.Equal(t, "Hello", row.Get("column_test"))
 	case <-timeoutChan:
 		assert.FailNow(t, "Failing due to timeout")
 	}
 }
 
 func testWhenColumnIsAnonymisedWithFloatValue(t *testing.T, opts reader.ReadTableOpt, tables config.Tables) {
-	anonymiser := NewAnonymiser(&mockReader{}, tables)
+	anonymiser := NewAnonymiser(&mockReader{}, tables, 1)
 
 	rowChan := make(chan database.Row)
 	defer close(rowChan)
@@ -162,14 +167,14 @@ func testWhenColumnIsAnonymisedWithFloatValue(t *testing.T, opts reader.ReadTabl
 	timeoutChan := time.After(waitTimeout)
 	select {
 	case row := <-rowChan:
-		assert.NotEqual(t, "<float32 Value>", row["column_test"])
+		assert.NotEqual(t, "<float32 Value>", row.Get("column_test"))
 	case <-timeoutChan:
 		assert.FailNow(t, "Failing due to timeout")
 	}
 }
 
 func testWhenColumnAnonymiserIsInvalid(t *testing.T, opts reader.ReadTableOpt, tables config.Tables) {
-	anonymiser := NewAnonymiser(&mockReader{}, tables)
+	anonymiser := NewAnonymiser(&mockReader{}, tables, 1)
 
 	rowChan := make(chan database.Row)
 	defer close(rowChan)
@@ -180,14 +185,14 @@ func testWhenColumnAnonymiserIsInvalid(t *testing.T, opts reader.ReadTableOpt, t
 	timeoutChan := time.After(waitTimeout)
 	select {
 	case row := <-rowChan:
-		assert.Equal(t, "Invalid anonymiser: Hello", row["column_test"])
+		assert.Equal(t, "Invalid anonymiser: Hello", row.Get("column_test"))
 	case <-timeoutChan:
 		assert.FailNow(t, "Failing due to timeout")
 	}
 }
 
 func testWhenColumnAnonymiserRequireArgs(t *testing.T, opts reader.ReadTableOpt, tables config.Tables) {
-	anonymiser := NewAnonymiser(&mockReader{}, tables)
+	anonymiser := NewAnonymiser(&mockReader{}, tables, 1)
 
 	rowChan := make(chan database.Row)
 	defer close(rowChan)
@@ -198,15 +203,15 @@ func testWhenColumnAnonymiserRequireArgs(t *testing.T, opts reader.ReadTableOpt,
 	timeoutChan := time.After(waitTimeout)
 	select {
 	case row := <-rowChan:
-		assert.NotEqual(t, "to_be_anonimised", row["column_test"])
-		assert.Len(t, row["column_test"], 20)
+		assert.NotEqual(t, "to_be_anonimised", row.Get("column_test"))
+		assert.Len(t, row.Get("column_test"), 20)
 	case <-timeoutChan:
 		assert.FailNow(t, "Failing due to timeout")
 	}
 }
 
 func testWhenColumnAnonymiserRequireMultipleArgs(t *testing.T, opts reader.ReadTableOpt, tables config.Tables) {
-	anonymiser := NewAnonymiser(&mockReader{}, tables)
+	anonymiser := NewAnonymiser(&mockReader{}, tables, 1)
 
 	rowChan := make(chan database.Row)
 	defer close(rowChan)
@@ -217,14 +222,14 @@ func testWhenColumnAnonymiserRequireMultipleArgs(t *testing.T, opts reader.ReadT
 	timeoutChan := time.After(waitTimeout)
 	select {
 	case row := <-rowChan:
-		assert.NotEqual(t, "to_be_anonimised", row["column_test"])
+		assert.NotEqual(t, "to_be_anonimised", row.Get("column_test"))
 	case <-timeoutChan:
 		assert.FailNow(t, "Failing due to timeout")
 	}
 }
 
 func testWhenColumnAnonymiserRequireArgsNoValues(t *testing.T, opts reader.ReadTableOpt, tables config.Tables) {
-	anonymiser := NewAnonymiser(&mockReader{}, tables)
+	anonymiser := NewAnonymiser(&mockReader{}, tables, 1)
 
 	rowChan := make(chan database.Row)
 	defer close(rowChan)
@@ -235,14 +240,14 @@ func testWhenColumnAnonymiserRequireArgsNoValues(t *testing.T, opts reader.ReadT
 	timeoutChan := time.After(waitTimeout)
 	select {
 	case row := <-rowChan:
-		assert.NotEqual(t, "to_be_anonimised", row["column_test"])
+		assert.NotEqual(t, "to_be_anonimised", row.Get("column_test"))
 	case <-timeoutChan:
 		assert.FailNow(t, "Failing due to timeout")
 	}
 }
 
 func testWhenColumnAnonymiserRequireArgsInvalidValues(t *testing.T, opts reader.ReadTableOpt, tables config.Tables) {
-	anonymiser := NewAnonymiser(&mockReader{}, tables)
+	anonymiser := NewAnonymiser(&mockReader{}, tables, 1)
 
 	rowChan := make(chan database.Row)
 	defer close(rowChan)
@@ -253,13 +258,347 @@ func testWhenColumnAnonymiserRequireArgsInvalidValues(t *testing.T, opts reader.
 	timeoutChan := time.After(waitTimeout)
 	select {
 	case row := <-rowChan:
-		assert.NotEqual(t, "to_be_anonimised", row["column_test1"])
-		assert.NotEqual(t, "to_be_anonimised", row["column_test2"])
+		assert.NotEqual(t, "to_be_anonimised", row.Get("column_test1"))
+		assert.NotEqual(t, "to_be_anonimised", row.Get("column_test2"))
 	case <-timeoutChan:
 		assert.FailNow(t, "Failing due to timeout")
 	}
 }
 
+func TestReadTableWithWorkers(t *testing.T) {
+	t.Parallel()
+
+	const rows = 100
+	for _, table := range []*config.Table{
+		{Name: "test", Anonymise: map[string]string{"column_test": "literal:anonymised"}},
+		// the workers of the table override the anonymiser ones
+		{Name: "test", Workers: 3, Anonymise: map[string]string{"column_test": "literal:anonymised"}},
+	} {
+		anonymiser := NewAnonymiser(&mockMultiRowReader{rows: rows}, config.Tables{table}, 4)
+
+		rowChan := make(chan database.Row)
+		go func() {
+			err := anonymiser.ReadTable("test", rowChan, reader.ReadTableOpt{})
+			require.NoError(t, err)
+		}()
+
+		var read int
+		for row := range rowChan {
+			assert.Equal(t, "anonymised", row.Get("column_test"))
+			read++
+		}
+		assert.Equal(t, rows, read)
+	}
+}
+
+func TestReadTableSyntheticRows(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{{
+		Name:          "test",
+		SyntheticRows: 3,
+		Anonymise:     map[string]string{"column_test": "FirstName"},
+	}}
+	anonymiser := NewAnonymiser(&mockFailingReader{}, tables, 1)
+
+	rowChan := make(chan database.Row)
+	go func() {
+		err := anonymiser.ReadTable("test", rowChan, reader.ReadTableOpt{})
+		require.NoError(t, err)
+	}()
+
+	var read int
+	for row := range rowChan {
+		assert.NotEmpty(t, row.Get("column_test"))
+		assert.NotNil(t, row.Get("column_other"))
+		read++
+	}
+	assert.Equal(t, 3, read)
+}
+
+func TestReadTablePreserveStats(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{{
+		Name:          "test",
+		PreserveStats: true,
+		Anonymise:     map[string]string{"column_test": "FirstName"},
+	}}
+	source := &mockValuesReader{values: []interface{}{[]byte("a"), []byte("b"), []byte("a"), nil}}
+	anonymiser := NewAnonymiser(source, tables, 2)
+
+	rowChan := make(chan database.Row)
+	go func() {
+		err := anonymiser.ReadTable("test", rowChan, reader.ReadTableOpt{})
+		require.NoError(t, err)
+	}()
+
+	fakes := make(map[string]string)
+	var nulls int
+	for row := range rowChan {
+		value := row.Get("column_test")
+		if value == nil {
+			nulls++
+			continue
+		}
+
+		original := string(row.Get("original").([]byte))
+		if fake, ok := fakes[original]; ok {
+			assert.Equal(t, fake, value)
+		}
+		fakes[original] = value.(string)
+	}
+	assert.Equal(t, 1, nulls)
+	assert.Len(t, fakes, 2)
+}
+
+func TestReadTableChain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scenario string
+		chain    string
+		value    interface{}
+		expected interface{}
+	}{
+		{scenario: "trim and lower", chain: "Trim | Lower", value: []byte("  John@Example.COM "), expected: "john@example.com"},
+		{scenario: "scrub", chain: "Scrub", value: "AB-12 cd", expected: "XX-00 xx"},
+		{scenario: "keep last", chain: "KeepLast:4", value: int64(4111111111111111), expected: "************1111"},
+		{scenario: "truncate", chain: "Truncate:3", value: "abcdef", expected: "abc"},
+		{scenario: "hash with salt", chain: "Trim|Hash:salt", value: " a ", expected: "c48e22d109fbdc9ea9d09115591b16133717abf8f0faad86b3656f23f0a8de5b"},
+		{scenario: "literal step", chain: "Trim | literal:secret | Upper", value: "a", expected: "SECRET"},
+		{scenario: "faker step", chain: "Trim | DigitsN:5 | KeepLast:2", value: "a", expected: nil},
+		{scenario: "null is not transformed", chain: "Trim | Upper", value: nil, expected: nil},
+		{scenario: "literal is not split", chain: "literal:a | b", value: "a", expected: "a | b"},
+		{scenario: "template", chain: "{{Trim | upper}}-{{literal:x}}@example.test", value: " ab ", expected: "AB-x@example.test"},
+		{scenario: "template in chain", chain: "Trim | <{{Scrub}}> | Upper", value: " ab1 ", expected: "<XX0>"},
+		{scenario: "template with null", chain: "{{Trim}}@example.test", value: nil, expected: "@example.test"},
+		{scenario: "unclosed template", chain: "{{Upper}}-{{Lower", value: "Ab", expected: "AB-{{Lower"},
+		{scenario: "template with faker", chain: "{{FirstName | lower}}.{{LastName | lower}}@example.test", value: "a", expected: nil},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.scenario, func(t *testing.T) {
+			tables := config.Tables{{Name: "test", Anonymise: map[string]string{"column_test": test.chain}}}
+			anonymiser := NewAnonymiser(&mockValuesReader{values: []interface{}{test.value}}, tables, 1)
+
+			rowChan := make(chan database.Row, 1)
+			err := anonymiser.ReadTable("test", rowChan, reader.ReadTableOpt{})
+			require.NoError(t, err)
+
+			row := <-rowChan
+			switch test.scenario {
+			case "faker step":
+				assert.Regexp(t, `^\*{3}[0-9]{2}$`, row.Get("column_test"))
+				return
+			case "template with faker":
+				assert.Regexp(t, `^[a-z]+\.[a-z]+@example\.test$`, row.Get("column_test"))
+				return
+			}
+			assert.Equal(t, test.expected, row.Get("column_test"))
+		})
+	}
+}
+
+func TestPseudonyms(t *testing.T) {
+	t.Parallel()
+
+	read := func(anonymiser reader.Reader, table string) []interface{} {
+		rowChan := make(chan database.Row, 4)
+		require.NoError(t, anonymiser.ReadTable(table, rowChan, reader.ReadTableOpt{}))
+
+		var values []interface{}
+		for row := range rowChan {
+			values = append(values, row.Get("column_test"))
+		}
+		return values
+	}
+
+	values := []interface{}{"a@example.com", "b@example.com", "a@example.com", nil}
+	pseudonyms := NewPseudonyms()
+	users := NewAnonymiserWithPseudonyms(&mockValuesReader{values: values}, config.Tables{
+		{Name: "users", Anonymise: map[string]string{"column_test": "EmailAddress"}, Pseudonymise: []string{"column_test"}},
+	}, 1, pseudonyms)
+	billing := NewAnonymiserWithPseudonyms(&mockValuesReader{values: values}, config.Tables{
+		{Name: "invoices", Anonymise: map[string]string{"column_test": "EmailAddress"}, Pseudonymise: []string{"column_test"}},
+	}, 1, pseudonyms)
+
+	faked := read(users, "users")
+	require.Len(t, faked, 4)
+	assert.NotEqual(t, "a@example.com", faked[0])
+	assert.Equal(t, faked[0], faked[2], "an original value is always faked the same way")
+	assert.NotEqual(t, faked[0], faked[1])
+	assert.Nil(t, faked[3], "NULL values are kept")
+	assert.Equal(t, faked, read(billing, "invoices"), "the pseudonyms are shared by the anonymisers")
+
+	other := NewAnonymiser(&mockValuesReader{values: values}, config.Tables{
+		{Name: "users", Anonymise: map[string]string{"column_test": "EmailAddress"}, Pseudonymise: []string{"column_test"}},
+	}, 1)
+	assert.NotEqual(t, faked[0], read(other, "users")[0], "anonymisers with their own pseudonyms fake values independently")
+}
+
+func TestAuditedAnonymiser(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	trail, err := audit.NewTrail(&buf, 1, "key")
+	require.NoError(t, err)
+
+	tables := config.Tables{{Name: "users", Anonymise: map[string]string{"column_test": "Trim | Upper"}, Pseudonymise: []string{"column_test"}}}
+	anonymiser := NewAuditedAnonymiser(&mockValuesReader{values: []interface{}{" jane ", " jane ", nil}}, tables, 1, nil, trail)
+
+	rowChan := make(chan database.Row, 3)
+	require.NoError(t, anonymiser.ReadTable("users", rowChan, reader.ReadTableOpt{}))
+	var values []interface{}
+	for row := range rowChan {
+		values = append(values, row.Get("column_test"))
+	}
+	assert.Equal(t, []interface{}{"JANE", "JANE", nil}, values)
+
+	var records []audit.Record
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var record audit.Record
+		require.NoError(t, dec.Decode(&record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 3)
+	assert.NotContains(t, buf.String(), "jane", "the values are never recorded")
+
+	assert.Equal(t, audit.Record{Table: "users", Row: 1, Columns: []audit.Column{{
+		Column: "column_test",
+		Rule:   "Trim | Upper",
+		Before: trail.Hash(" jane "),
+		After:  trail.Hash("JANE"),
+		Steps:  []audit.Step{{Step: "Trim", After: trail.Hash("jane")}, {Step: "Upper", After: trail.Hash("JANE")}},
+	}}}, records[0])
+	assert.Equal(t, audit.Column{
+		Column: "column_test", Rule: "Trim | Upper", Before: trail.Hash(" jane "), After: trail.Hash("JANE"), Reused: true,
+	}, records[1].Columns[0], "the pseudonym is reused")
+	assert.Equal(t, audit.Column{Column: "column_test", Rule: "Trim | Upper", Before: "NULL", After: "NULL"}, records[2].Columns[0])
+}
+
+func TestWeighted(t *testing.T) {
+	t.Parallel()
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		counts[weighted([]string{"active=80%", "churned=15%", "banned=5%", "deleted=0"})]++
+	}
+	assert.Len(t, counts, 3)
+	assert.InDelta(t, 8000, counts["active"], 400)
+	assert.InDelta(t, 1500, counts["churned"], 300)
+	assert.InDelta(t, 500, counts["banned"], 200)
+
+	assert.Equal(t, "a=b", weighted([]string{"a=b=1"}))
+	assert.Equal(t, "", weighted(nil))
+
+	tables := config.Tables{{Name: "test", Anonymise: map[string]string{"column_test": "Weighted:active | Upper"}}}
+	anonymiser := NewAnonymiser(&mockValuesReader{values: []interface{}{nil}}, tables, 1)
+
+	rowChan := make(chan database.Row, 1)
+	err := anonymiser.ReadTable("test", rowChan, reader.ReadTableOpt{})
+	require.NoError(t, err)
+	assert.Equal(t, "ACTIVE", (<-rowChan).Get("column_test"))
+}
+
+func TestSequenceGenerators(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "7", sequence(nil, 7))
+	assert.Equal(t, "000007", sequence([]string{"6"}, 7))
+	assert.Equal(t, "001006", sequence([]string{"6", "1000"}, 7))
+
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	ulid := newULID(now)
+	assert.Regexp(t, `^[0-9A-HJKMNP-TV-Z]{26}$`, ulid)
+	assert.Equal(t, "01EZXT1YGR", ulid[:10])
+	assert.Less(t, ulid, newULID(now.Add(time.Millisecond)))
+
+	uuid := newUUIDv7(now)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, uuid)
+	assert.Equal(t, "0177fba0-fa18", uuid[:13])
+
+	tables := config.Tables{{Name: "test", Anonymise: map[string]string{"column_test": "INV-{{Sequence:6}}"}}}
+	anonymiser := NewAnonymiser(&mockValuesReader{values: []interface{}{"a", "b", "c"}}, tables, 2)
+
+	rowChan := make(chan database.Row, 3)
+	err := anonymiser.ReadTable("test", rowChan, reader.ReadTableOpt{})
+	require.NoError(t, err)
+
+	var ids []string
+	for row := range rowChan {
+		ids = append(ids, row.Get("column_test").(string))
+	}
+	assert.ElementsMatch(t, []string{"INV-000001", "INV-000002", "INV-000003"}, ids)
+}
+
+func TestCatalog(t *testing.T) {
+	t.Parallel()
+
+	catalog := Catalog()
+	require.NotEmpty(t, catalog)
+
+	byName := make(map[string]Anonymiser)
+	for _, a := range catalog {
+		assert.NotEmpty(t, a.Example, a.Name)
+		assert.NotContains(t, a.Example, "Invalid anonymiser", a.Name)
+		byName[a.Name] = a
+	}
+
+	assert.Equal(t, Anonymiser{Name: "Year", Kind: KindFaker, Signature: "Year:int:int", Example: byName["Year"].Example}, byName["Year"])
+	assert.Regexp(t, `^(199[0-9]|20[0-2][0-9])$`, byName["Year"].Example)
+	assert.Equal(t, Anonymiser{Name: "Upper", Kind: KindTransformer, Signature: "Upper", Example: "JANE.DOE@EXAMPLE.COM"}, byName["Upper"])
+	assert.Equal(t, Anonymiser{Name: "Sequence", Kind: KindGenerator, Signature: "Sequence:[width]:[start]", Example: "001000"}, byName["Sequence"])
+	assert.NotContains(t, byName, "SetLang")
+
+	assert.Equal(t, KindFaker, catalog[0].Kind)
+	assert.Equal(t, KindGenerator, catalog[len(catalog)-1].Kind)
+}
+
+// mockValuesReader publishes a row per value, with a copy of the value kept in the original column.
+type mockValuesReader struct {
+	mockReader
+	values []interface{}
+}
+
+func (m *mockValuesReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	columns := database.NewColumns([]string{"column_test", "original"})
+	for _, value := range m.values {
+		rowChan <- database.NewRow(columns, []interface{}{value, value})
+	}
+	return nil
+}
+
+// mockFailingReader fails if the table data is read.
+type mockFailingReader struct {
+	mockReader
+}
+
+func (m *mockFailingReader) GetColumns(string) ([]string, error) {
+	return []string{"column_test", "column_other"}, nil
+}
+
+func (m *mockFailingReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	close(rowChan)
+	return errors.New("the table data must not be read")
+}
+
+type mockMultiRowReader struct {
+	mockReader
+	rows int
+}
+
+func (m *mockMultiRowReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	for i := 0; i < m.rows; i++ {
+		rowChan <- database.NewRow(database.NewColumns([]string{"column_test"}), []interface{}{"to_be_anonimised"})
+	}
+	return nil
+}
+
 type mockReader struct{}
 
 func (m *mockReader) GetTables() ([]string, error)        { return []string{"table_test"}, nil }
@@ -271,8 +610,43 @@ func (m *mockReader) FormatColumn(tbl string, col string) string {
 	return fmt.Sprintf("%s.%s", strconv.Quote(tbl), strconv.Quote(col))
 }
 func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
-	row := make(database.Row)
-	row["column_test"] = "to_be_anonimised"
-	rowChan <- row
+	rowChan <- database.NewRow(
+		database.NewColumns([]string{"column_test", "column_test1", "column_test2"}),
+		[]interface{}{"to_be_anonimised", "to_be_anonimised", "to_be_anonimised"},
+	)
 	return nil
 }
+
+func TestSeed(t *testing.T) {
+	values := func() []string {
+		logger := log.WithField("table", "test")
+		return []string{fakeValue("FullName", logger), fakeValue("EmailAddress", logger), weighted([]string{"a", "b", "c", "d"}), newUUIDv7(time.Unix(0, 0))}
+	}
+
+	assert.Equal(t, int64(42), Seed(42))
+	first := values()
+	Seed(42)
+	assert.Equal(t, first, values())
+
+	assert.NotZero(t, Seed(0))
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "******oe", Apply("Trim | Lower | Truncate:8 | KeepLast:2", " JOHN.DOE@Example.com", 1))
+	assert.Equal(t, "000042", Apply("Sequence:6:41", nil, 2))
+	assert.Equal(t, "user-0042@example.test", Apply("User-{{Sequence:4:41}}@Example.test | Lower", nil, 2))
+	assert.Nil(t, Apply("Lower", nil, 1))
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, valid := range []string{"EmailAddress", "Trim | Lower | Hash:salt", "DigitsN:4", "literal:a|b", "{{FirstName | lower}}@example.test", "Weighted:a=1:b=2"} {
+		assert.NoError(t, Validate(valid), valid)
+	}
+
+	assert.EqualError(t, Validate("Trim | Emailaddress"), "unknown anonymiser Emailaddress")
+	assert.EqualError(t, Validate("{{Nope}}@example.test"), "unknown anonymiser Nope")
+}
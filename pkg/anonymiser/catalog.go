@@ -0,0 +1,110 @@
+package anonymiser
+
+import (
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Anonymiser kinds listed in the catalog.
+const (
+	KindFaker       = "faker"
+	KindTransformer = "transformer"
+	KindGenerator   = "generator"
+)
+
+// catalogSample is the value the transformers of the catalog are applied to.
+const catalogSample = "Jane.Doe@Example.com"
+
+var (
+	// exampleArgs are the arguments used to show an example of the anonymisers requiring some.
+	exampleArgs = map[string]string{
+		"CharactersN":   "8",
+		"DigitsN":       "5",
+		"ParagraphsN":   "1",
+		"SentencesN":    "2",
+		"WordsN":        "3",
+		"CreditCardNum": "visa",
+		"Password":      "8:12:true:true:false",
+		"Year":          "1990:2020",
+		"Hash":          "my-salt",
+		"Truncate":      "4",
+		"KeepLast":      "4",
+		"Weighted":      "active=80%:churned=15%:banned=5%",
+		"Sequence":      "6:1000",
+	}
+
+	// signatures are the arguments of the transformers and generators.
+	signatures = map[string]string{
+		"Hash":     "[salt]",
+		"Truncate": "N",
+		"KeepLast": "N",
+		"Weighted": "value=weight:...",
+		"Sequence": "[width]:[start]",
+	}
+)
+
+// Anonymiser describes an anonymiser usable in the Anonymise config of a table.
+type Anonymiser struct {
+	Name      string
+	Kind      string
+	Signature string
+	Example   string
+}
+
+// Catalog returns the available anonymisers sorted by kind and name, with an example of their output.
+// Transformers examples are applied to a sample value.
+func Catalog() []Anonymiser {
+	var catalog []Anonymiser
+	for name, faker := range Functions {
+		t := faker.Type()
+		if t.NumIn() > 0 && !requireArgs[name] {
+			continue
+		}
+
+		args := make([]string, t.NumIn())
+		for i := range args {
+			args[i] = t.In(i).Kind().String()
+		}
+		catalog = append(catalog, Anonymiser{Name: name, Kind: KindFaker, Signature: signature(name, strings.Join(args, ":"))})
+	}
+	for name := range transformers {
+		catalog = append(catalog, Anonymiser{Name: name, Kind: KindTransformer, Signature: signature(name, signatures[name])})
+	}
+	for name := range generators {
+		catalog = append(catalog, Anonymiser{Name: name, Kind: KindGenerator, Signature: signature(name, signatures[name])})
+	}
+
+	logger := log.WithField("catalog", true)
+	for i, a := range catalog {
+		step := a.Name
+		if args, ok := exampleArgs[a.Name]; ok {
+			step += ":" + args
+		}
+
+		var original interface{}
+		if a.Kind == KindTransformer {
+			original = catalogSample
+		}
+		catalog[i].Example = toText(applyChain([]string{step}, original, 1, logger))
+	}
+
+	kinds := map[string]int{KindFaker: 0, KindTransformer: 1, KindGenerator: 2}
+	sort.Slice(catalog, func(i, j int) bool {
+		if catalog[i].Kind != catalog[j].Kind {
+			return kinds[catalog[i].Kind] < kinds[catalog[j].Kind]
+		}
+		return catalog[i].Name < catalog[j].Name
+	})
+
+	return catalog
+}
+
+func signature(name string, args string) string {
+	if args == "" {
+		return name
+	}
+
+	return name + ":" + args
+}
@@ -0,0 +1,218 @@
+package anonymiser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// chainSeparator separates the steps of an anonymiser chain, e.g. "Trim | Lower | Hash".
+const chainSeparator = "|"
+
+// transformers change the current value of a column instead of replacing it with a fake one.
+var transformers = map[string]func(value string, args []string) string{
+	"Trim":  func(value string, _ []string) string { return strings.TrimSpace(value) },
+	"Lower": func(value string, _ []string) string { return strings.ToLower(value) },
+	"Upper": func(value string, _ []string) string { return strings.ToUpper(value) },
+	// Hash returns the hex encoded SHA-256 of the value, prefixed with the optional salt argument.
+	"Hash": func(value string, args []string) string {
+		sum := sha256.Sum256([]byte(strings.Join(args, ":") + value))
+		return hex.EncodeToString(sum[:])
+	},
+	// Scrub replaces letters with x and digits with 0, keeping the format of the value.
+	"Scrub": func(value string, _ []string) string {
+		return strings.Map(func(r rune) rune {
+			switch {
+			case unicode.IsUpper(r):
+				return 'X'
+			case unicode.IsLetter(r):
+				return 'x'
+			case unicode.IsDigit(r):
+				return '0'
+			}
+			return r
+		}, value)
+	},
+	// Truncate keeps the first n characters of the value.
+	"Truncate": func(value string, args []string) string {
+		runes := []rune(value)
+		if n := intArg(args); n < len(runes) {
+			return string(runes[:n])
+		}
+		return value
+	},
+	// KeepLast masks all but the last n characters of the value.
+	"KeepLast": func(value string, args []string) string {
+		runes := []rune(value)
+		for i := 0; i < len(runes)-intArg(args); i++ {
+			runes[i] = '*'
+		}
+		return string(runes)
+	},
+}
+
+// Apply anonymises a value with an anonymiser of the Anonymise config, n being the number of the row starting from 1.
+// NULL values are replaced by fakers and generators like in a table, see Validate for the unknown anonymisers.
+func Apply(anonymiser string, value interface{}, n uint64) interface{} {
+	return applyChain(parseChain(anonymiser), value, n, log.WithField("anonymiser", anonymiser))
+}
+
+// Validate checks that the steps of an anonymiser, the ones of its templates included, are known anonymisers.
+func Validate(anonymiser string) error {
+	for _, step := range parseChain(anonymiser) {
+		if strings.HasPrefix(step, literalPrefix) {
+			continue
+		}
+
+		if isTemplate(step) {
+			for _, expression := range templateExpressions(step) {
+				if err := Validate(expression); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		name := strings.Split(step, ":")[0]
+		if _, ok := findTransformer(name); ok {
+			continue
+		}
+		if _, ok := generators[name]; ok {
+			continue
+		}
+		if _, ok := Functions[name]; !ok {
+			return fmt.Errorf("unknown anonymiser %s", name)
+		}
+	}
+
+	return nil
+}
+
+// parseChain splits an anonymiser into its steps, an anonymiser starting with a literal is never split.
+func parseChain(anonymiser string) []string {
+	if strings.HasPrefix(anonymiser, literalPrefix) {
+		return []string{anonymiser}
+	}
+
+	// separators inside template expressions belong to the expression
+	var (
+		steps []string
+		depth int
+		start int
+	)
+	for i := 0; i < len(anonymiser); i++ {
+		switch {
+		case strings.HasPrefix(anonymiser[i:], templateStart):
+			depth++
+			i++
+		case strings.HasPrefix(anonymiser[i:], templateEnd) && depth > 0:
+			depth--
+			i++
+		case strings.HasPrefix(anonymiser[i:], chainSeparator) && depth == 0:
+			steps = append(steps, strings.TrimSpace(anonymiser[start:i]))
+			start = i + len(chainSeparator)
+		}
+	}
+
+	return append(steps, strings.TrimSpace(anonymiser[start:]))
+}
+
+// applyChain applies the anonymiser steps in order: literals, templates, generators and fakers replace the value,
+// transformers change the value returned by the previous step. NULL values are not transformed.
+// n is the number of the anonymised row, starting from 1.
+func applyChain(steps []string, original interface{}, n uint64, logger *log.Entry) interface{} {
+	return traceChain(steps, original, n, logger, nil)
+}
+
+// traceChain applies the anonymiser steps as applyChain does, calling trace with each step and the value it returned
+// when trace is set.
+func traceChain(steps []string, original interface{}, n uint64, logger *log.Entry, trace func(step string, value interface{})) interface{} {
+	value := original
+	if isNull(value) {
+		value = nil
+	}
+
+	for _, step := range steps {
+		value = applyStep(step, value, n, logger)
+		if trace != nil {
+			trace(step, value)
+		}
+	}
+
+	return value
+}
+
+// applyStep applies an anonymiser step to the value returned by the previous one, nil for NULL.
+func applyStep(step string, value interface{}, n uint64, logger *log.Entry) interface{} {
+	if strings.HasPrefix(step, literalPrefix) {
+		return strings.TrimPrefix(step, literalPrefix)
+	}
+
+	if isTemplate(step) {
+		return renderTemplate(step, value, n, logger)
+	}
+
+	parts := strings.Split(step, ":")
+	if transform, ok := findTransformer(parts[0]); ok {
+		if value == nil {
+			return nil
+		}
+		return transform(toText(value), parts[1:])
+	}
+	if generate, ok := generators[parts[0]]; ok {
+		return generate(parts[1:], n)
+	}
+
+	return fakeValue(step, logger)
+}
+
+// findTransformer returns the transformer with the given name, template filters being usually lower-cased.
+func findTransformer(name string) (func(string, []string) string, bool) {
+	if transform, ok := transformers[name]; ok {
+		return transform, true
+	}
+	for n, transform := range transformers {
+		if strings.EqualFold(n, name) {
+			return transform, true
+		}
+	}
+
+	return nil, false
+}
+
+// toText returns the text of a column value.
+func toText(value interface{}) string {
+	if p, ok := value.(*interface{}); ok && p != nil {
+		value = *p
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999")
+	}
+
+	return fmt.Sprint(value)
+}
+
+func intArg(args []string) int {
+	if len(args) == 0 {
+		return 0
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		log.WithField("argument", args[0]).Warn("Failed to parse argument as integer. Falling back to default")
+	}
+
+	return n
+}
@@ -0,0 +1,82 @@
+package anonymiser
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxConsistentValues bounds the memory used to remember the fake value of each original value of a table.
+const maxConsistentValues = 1 << 20
+
+// consistentValues replaces each original value of a column by the same fake value, so the anonymised
+// columns keep the cardinality and value frequencies of the source.
+type consistentValues struct {
+	mu      sync.Mutex
+	values  map[string]map[string]interface{}
+	size    int
+	warning sync.Once
+}
+
+func newConsistentValues() *consistentValues {
+	return &consistentValues{values: make(map[string]map[string]interface{})}
+}
+
+// get returns the fake value of an original column value, faking a new one the first time it is seen.
+func (c *consistentValues) get(column string, original interface{}, fake func() interface{}) interface{} {
+	key := fmt.Sprint(original)
+	if b, ok := original.([]byte); ok {
+		key = string(b)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	columnValues, ok := c.values[column]
+	if !ok {
+		columnValues = make(map[string]interface{})
+		c.values[column] = columnValues
+	}
+	if value, ok := columnValues[key]; ok {
+		return value
+	}
+
+	value := fake()
+	if c.size >= maxConsistentValues {
+		c.warning.Do(func() {
+			log.WithField("column", column).Warn("too many distinct values to keep the column statistics, new values are faked independently")
+		})
+		return value
+	}
+	columnValues[key] = value
+	c.size++
+
+	return value
+}
+
+// isNull reports whether a column value is NULL.
+func isNull(value interface{}) bool {
+	if p, ok := value.(*interface{}); ok {
+		return p == nil || *p == nil
+	}
+
+	return value == nil
+}
+
+// Pseudonyms replaces each original value of the pseudonymised columns by the same fake value, for all the
+// columns anonymised with the same anonymiser. Sharing them between the anonymisers of several databases keeps
+// the values joining the databases, e.g. an email faked identically in the users and billing databases.
+type Pseudonyms struct {
+	values *consistentValues
+}
+
+// NewPseudonyms returns an empty pseudonymisation mapping, safe for concurrent use.
+func NewPseudonyms() *Pseudonyms {
+	return &Pseudonyms{values: newConsistentValues()}
+}
+
+// get returns the pseudonym of an original value anonymised by the given anonymiser.
+func (p *Pseudonyms) get(anonymiser string, original interface{}, fake func() interface{}) interface{} {
+	return p.values.get(anonymiser, original, fake)
+}
@@ -0,0 +1,113 @@
+package anonymiser
+
+import (
+	"fmt"
+	mrand "math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// generators replace the value of a column with a value built from their arguments and the row number.
+var generators = map[string]func(args []string, n uint64) string{
+	"Weighted": func(args []string, _ uint64) string { return weighted(args) },
+	"Sequence": sequence,
+	"ULID":     func([]string, uint64) string { return newULID(time.Now()) },
+	"UUIDv7":   func([]string, uint64) string { return newUUIDv7(time.Now()) },
+}
+
+// weighted picks one of the "value=weight" arguments with a probability proportional to its weight,
+// e.g. "Weighted:active=80%:churned=15%:banned=5%". Values without a weight have a weight of 1.
+func weighted(args []string) string {
+	values := make([]string, len(args))
+	weights := make([]float64, len(args))
+	var total float64
+	for i, arg := range args {
+		values[i], weights[i] = arg, 1
+		if eq := strings.LastIndex(arg, "="); eq >= 0 {
+			values[i] = arg[:eq]
+			w, err := strconv.ParseFloat(strings.TrimSuffix(arg[eq+1:], "%"), 64)
+			if err != nil || w < 0 {
+				log.WithField("argument", arg).Warn("Failed to parse argument as weight. Falling back to default")
+				w = 1
+			}
+			weights[i] = w
+		}
+		total += weights[i]
+	}
+
+	r := mrand.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return values[i]
+		}
+		r -= w
+	}
+
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[len(values)-1]
+}
+
+// sequence returns the row number, zero padded to the width argument and shifted to start from the start argument,
+// e.g. "INV-{{Sequence:6:1000}}" gives INV-001000, INV-001001...
+func sequence(args []string, n uint64) string {
+	var width, start int
+	if len(args) > 0 {
+		width = intArg(args[:1])
+	}
+	if len(args) > 1 {
+		start = intArg(args[1:]) - 1
+	}
+
+	return fmt.Sprintf("%0*d", width, int64(n)+int64(start))
+}
+
+// crockford is the alphabet of the ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID returns a ULID: a millisecond timestamp followed by 80 random bits, encoded in base 32.
+func newULID(t time.Time) string {
+	var b [16]byte
+	putMillis(b[:], t)
+	mrand.Read(b[6:])
+
+	// 128 bits are encoded as 26 characters of 5 bits, the first one holding 3 bits only
+	id := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		var c byte
+		for j := 0; j < 5; j++ {
+			bit := 127 - ((25-i)*5 + j)
+			if bit >= 0 && b[bit/8]&(1<<(7-uint(bit%8))) != 0 {
+				c |= 1 << uint(j)
+			}
+		}
+		id[i] = crockford[c]
+	}
+
+	return string(id)
+}
+
+// newUUIDv7 returns a version 7 UUID: a millisecond timestamp followed by random bits.
+func newUUIDv7(t time.Time) string {
+	var b [16]byte
+	putMillis(b[:], t)
+	mrand.Read(b[6:])
+	b[6] = b[6]&0x0f | 0x70
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// putMillis writes the 48 bits unix timestamp in milliseconds of t in the first 6 bytes of b.
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
@@ -0,0 +1,21 @@
+package anonymiser
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/icrowley/fake"
+)
+
+// Seed seeds the fakers and the random values of the anonymisers and generators, a zero seed
+// being replaced by a random one. It returns the seed used, so that the run can be reproduced.
+func Seed(seed int64) int64 {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	rand.Seed(seed)
+	fake.Seed(seed)
+
+	return seed
+}
@@ -0,0 +1,62 @@
+package anonymiser
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	templateStart = "{{"
+	templateEnd   = "}}"
+)
+
+// isTemplate reports whether an anonymiser step is a template, e.g. "{{FirstName | lower}}@example.test".
+func isTemplate(step string) bool {
+	return strings.Contains(step, templateStart)
+}
+
+// renderTemplate replaces each expression between {{ and }} with its value, an expression being a chain of
+// anonymisers applied to the current value of the column. The text around expressions is kept as it is.
+func renderTemplate(template string, value interface{}, n uint64, logger *log.Entry) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(template, templateStart)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(template[start:], templateEnd)
+		if end < 0 {
+			logger.WithField("template", template).Warn("Unclosed template expression")
+			break
+		}
+
+		b.WriteString(template[:start])
+		expression := template[start+len(templateStart) : start+end]
+		if rendered := applyChain(parseChain(expression), value, n, logger); rendered != nil {
+			b.WriteString(toText(rendered))
+		}
+		template = template[start+end+len(templateEnd):]
+	}
+	b.WriteString(template)
+
+	return b.String()
+}
+
+// templateExpressions returns the expressions between {{ and }} of a template, an unclosed one being left out.
+func templateExpressions(template string) []string {
+	var expressions []string
+	for {
+		start := strings.Index(template, templateStart)
+		if start < 0 {
+			return expressions
+		}
+		end := strings.Index(template[start:], templateEnd)
+		if end < 0 {
+			return expressions
+		}
+
+		expressions = append(expressions, template[start+len(templateStart):start+end])
+		template = template[start+end+len(templateEnd):]
+	}
+}
@@ -0,0 +1,372 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"github.com/hellofresh/klepto/pkg/deadline"
+	"github.com/hellofresh/klepto/pkg/health"
+	"github.com/hellofresh/klepto/pkg/notify"
+)
+
+// States of a run.
+const (
+	// Running runs are stealing the database.
+	Running = "running"
+	// Succeeded runs completed the steal.
+	Succeeded = "succeeded"
+	// Failed runs stopped with an error.
+	Failed = "failed"
+)
+
+// maxFinishedRuns is the amount of finished runs kept, the oldest ones are forgotten.
+const maxFinishedRuns = 100
+
+type (
+	// Profile is a named steal that can be started through the API.
+	Profile struct {
+		Name string
+		// From is the database dsn to steal from, environment variables are expanded when the steal starts.
+		From string
+		// To is the database dsn to output to, environment variables are expanded when the steal starts.
+		To string
+		// Config are the paths of the configuration files, relative to the profiles file.
+		Config []string
+		// Args are additional steal flags, e.g. --concurrency=4.
+		Args []string
+	}
+
+	// Runner runs the steal of a profile, calling progress with the progress of the steal once it reads the tables.
+	// The event notifying the end of the steal is returned once it started, even when it fails.
+	Runner func(profile Profile, progress func(health.Reporter)) (*notify.Event, error)
+
+	// Run is a steal started through the API.
+	Run struct {
+		ID       string     `json:"id"`
+		Profile  string     `json:"profile"`
+		State    string     `json:"state"`
+		Started  time.Time  `json:"started"`
+		Finished *time.Time `json:"finished,omitempty"`
+		Error    string     `json:"error,omitempty"`
+		// Progress is the progress of the steal while it reads the tables.
+		Progress *health.Status `json:"progress,omitempty"`
+		// Report lists the tables by state once the steal finished.
+		Report *deadline.Report `json:"report,omitempty"`
+		// Profiles are the outcomes of the profiles of a steal of several profiles, once it finished.
+		Profiles []notify.ProfileReport `json:"profiles,omitempty"`
+	}
+
+	// Server is an HTTP API starting the steals of profiles and reporting their progress.
+	// A profile is never stolen twice at the same time.
+	Server struct {
+		profiles map[string]Profile
+		token    string
+		run      Runner
+		now      func() time.Time
+
+		mu     sync.Mutex
+		runs   []*job
+		lastID int
+		wg     sync.WaitGroup
+	}
+
+	job struct {
+		run      Run
+		progress health.Reporter
+		event    *notify.Event
+	}
+
+	errorResponse struct {
+		Error string `json:"error"`
+	}
+)
+
+// LoadProfiles reads the profiles of a toml, yaml or json file.
+func LoadProfiles(path string) ([]Profile, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("could not read profiles: %w", err)
+	}
+
+	var file struct {
+		Profiles []Profile
+	}
+	if err := v.UnmarshalExact(&file); err != nil {
+		return nil, fmt.Errorf("could not unmarshal profiles file: %w", err)
+	}
+
+	names := make(map[string]bool, len(file.Profiles))
+	for i, profile := range file.Profiles {
+		if profile.Name == "" {
+			return nil, fmt.Errorf("profile %d has no name", i+1)
+		}
+		if names[profile.Name] {
+			return nil, fmt.Errorf("profile %s is defined twice", profile.Name)
+		}
+		names[profile.Name] = true
+
+		for j, configPath := range profile.Config {
+			if !filepath.IsAbs(configPath) {
+				file.Profiles[i].Config[j] = filepath.Join(filepath.Dir(path), configPath)
+			}
+		}
+	}
+
+	return file.Profiles, nil
+}
+
+// NewServer returns an API running the steals of the profiles, requiring the bearer token when set.
+func NewServer(profiles []Profile, token string, run Runner) *Server {
+	s := &Server{profiles: make(map[string]Profile, len(profiles)), token: token, run: run, now: time.Now}
+	for _, profile := range profiles {
+		s.profiles[profile.Name] = profile
+	}
+
+	return s
+}
+
+// ServeHTTP serves the API:
+//
+//	GET  /profiles           lists the profile names
+//	POST /runs               starts the steal of the profile given as {"profile": "name"}
+//	GET  /runs               lists the runs, the latest first
+//	GET  /runs/{id}          returns a run with its progress
+//	GET  /runs/{id}/report   returns the report of a finished run, as sent to the notifications
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.token)) != 1 {
+		writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "profiles":
+		if allow(w, r, http.MethodGet) {
+			s.listProfiles(w)
+		}
+	case len(parts) == 1 && parts[0] == "runs":
+		if allow(w, r, http.MethodGet, http.MethodPost) {
+			if r.Method == http.MethodPost {
+				s.startRun(w, r)
+			} else {
+				s.listRuns(w)
+			}
+		}
+	case len(parts) == 2 && parts[0] == "runs":
+		if allow(w, r, http.MethodGet) {
+			s.getRun(w, parts[1])
+		}
+	case len(parts) == 3 && parts[0] == "runs" && parts[2] == "report":
+		if allow(w, r, http.MethodGet) {
+			s.getReport(w, parts[1])
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// Wait waits for the running steals to finish.
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Server) listProfiles(w http.ResponseWriter) {
+	names := make([]string, 0, len(s.profiles))
+	for name := range s.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (s *Server) startRun(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Profile string `json:"profile"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid body: %s", err))
+		return
+	}
+	profile, ok := s.profiles[body.Profile]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown profile %q", body.Profile))
+		return
+	}
+
+	s.mu.Lock()
+	for _, j := range s.runs {
+		if j.run.Profile == profile.Name && j.run.State == Running {
+			s.mu.Unlock()
+			writeError(w, http.StatusConflict, fmt.Sprintf("profile %s is already running as run %s", profile.Name, j.run.ID))
+			return
+		}
+	}
+	s.lastID++
+	j := &job{run: Run{ID: strconv.Itoa(s.lastID), Profile: profile.Name, State: Running, Started: s.now()}}
+	s.runs = append(s.runs, j)
+	s.forgetFinished()
+	run := s.snapshot(j)
+	s.mu.Unlock()
+
+	logger := log.WithFields(log.Fields{"run": j.run.ID, "profile": profile.Name})
+	logger.Info("Starting the steal")
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		event, err := s.run(profile, func(progress health.Reporter) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			j.progress = progress
+		})
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		finished := s.now()
+		j.run.Finished, j.progress, j.event = &finished, nil, event
+		if event != nil {
+			j.run.Report, j.run.Profiles = event.Report, event.Profiles
+		}
+		if err != nil {
+			logger.WithError(err).Error("The steal failed")
+			j.run.State, j.run.Error = Failed, err.Error()
+		} else {
+			logger.Info("The steal succeeded")
+			j.run.State = Succeeded
+		}
+	}()
+
+	w.Header().Set("Location", "/runs/"+run.ID)
+	writeJSON(w, http.StatusAccepted, run)
+}
+
+func (s *Server) listRuns(w http.ResponseWriter) {
+	s.mu.Lock()
+	runs := make([]Run, 0, len(s.runs))
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		runs = append(runs, s.snapshot(s.runs[i]))
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (s *Server) getRun(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	j := s.find(id)
+	var run Run
+	if j != nil {
+		run = s.snapshot(j)
+	}
+	s.mu.Unlock()
+
+	if j == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown run %q", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+func (s *Server) getReport(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	j := s.find(id)
+	var run Run
+	var event *notify.Event
+	if j != nil {
+		run, event = j.run, j.event
+	}
+	s.mu.Unlock()
+
+	switch {
+	case j == nil:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown run %q", id))
+	case run.State == Running:
+		writeError(w, http.StatusConflict, fmt.Sprintf("run %s is still running", id))
+	case event != nil:
+		writeJSON(w, http.StatusOK, event)
+	default:
+		// the steal failed before it started
+		writeJSON(w, http.StatusOK, notify.Event{Kind: notify.Failure, Command: "steal", Duration: run.Finished.Sub(run.Started), Error: run.Error})
+	}
+}
+
+// find returns the job of a run, nil when unknown, s.mu must be held.
+func (s *Server) find(id string) *job {
+	for _, j := range s.runs {
+		if j.run.ID == id {
+			return j
+		}
+	}
+
+	return nil
+}
+
+// snapshot returns a copy of the run with its current progress, s.mu must be held.
+func (s *Server) snapshot(j *job) Run {
+	run := j.run
+	if j.progress != nil {
+		status := j.progress.Status()
+		run.Progress = &status
+	}
+
+	return run
+}
+
+// forgetFinished drops the oldest finished runs over the maximum kept, s.mu must be held.
+func (s *Server) forgetFinished() {
+	finished := 0
+	for _, j := range s.runs {
+		if j.run.State != Running {
+			finished++
+		}
+	}
+
+	kept := s.runs[:0]
+	for _, j := range s.runs {
+		if j.run.State != Running && finished > maxFinishedRuns {
+			finished--
+			continue
+		}
+		kept = append(kept, j)
+	}
+	s.runs = kept
+}
+
+// allow writes a 405 response when the request method is not one of the allowed ones.
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed", r.Method))
+	return false
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.WithError(err).Warn("failed to write the API response")
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/deadline"
+	"github.com/hellofresh/klepto/pkg/health"
+	"github.com/hellofresh/klepto/pkg/notify"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "profiles.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[[Profiles]]
+  Name = "users"
+  From = "mysql://$PROD_DSN"
+  To = "os://stdout/"
+  Config = ["users.toml", "/etc/klepto/base.toml"]
+  Args = ["--concurrency=4"]
+`), 0o600))
+
+	profiles, err := LoadProfiles(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Profile{{
+		Name:   "users",
+		From:   "mysql://$PROD_DSN",
+		To:     "os://stdout/",
+		Config: []string{filepath.Join(dir, "users.toml"), "/etc/klepto/base.toml"},
+		Args:   []string{"--concurrency=4"},
+	}}, profiles)
+
+	require.NoError(t, os.WriteFile(path, []byte("[[Profiles]]\n  Name = \"users\"\n[[Profiles]]\n  Name = \"users\"\n"), 0o600))
+	_, err = LoadProfiles(path)
+	assert.EqualError(t, err, "profile users is defined twice")
+}
+
+func TestServer(t *testing.T) {
+	release := make(chan error)
+	started := make(chan struct{})
+	server := NewServer([]Profile{{Name: "users"}, {Name: "orders"}}, "secret", func(profile Profile, progress func(health.Reporter)) (*notify.Event, error) {
+		progress(health.NewReader(nil, 0))
+		started <- struct{}{}
+		err := <-release
+
+		event := &notify.Event{Kind: notify.Success, Command: "steal", Duration: time.Minute, Report: &deadline.Report{Done: []string{profile.Name}}}
+		if err != nil {
+			event.Kind, event.Error = notify.Failure, err.Error()
+		}
+		return event, err
+	})
+	clock := time.Date(2021, time.March, 3, 3, 0, 0, 0, time.UTC)
+	server.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+
+	request := func(method string, path string, body string) (int, string) {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		return w.Code, w.Body.String()
+	}
+
+	code, body := request(http.MethodGet, "/profiles", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `["orders", "users"]`, body)
+
+	code, body = request(http.MethodPost, "/runs", `{"profile": "users"}`)
+	require.Equal(t, http.StatusAccepted, code, body)
+	assert.JSONEq(t, `{"id": "1", "profile": "users", "state": "running", "started": "2021-03-03T03:01:00Z"}`, body)
+	<-started
+
+	code, body = request(http.MethodPost, "/runs", `{"profile": "users"}`)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.JSONEq(t, `{"error": "profile users is already running as run 1"}`, body)
+
+	code, _ = request(http.MethodPost, "/runs", `{"profile": "payments"}`)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, body = request(http.MethodGet, "/runs/1", "")
+	assert.Equal(t, http.StatusOK, code)
+	var run Run
+	require.NoError(t, json.Unmarshal([]byte(body), &run))
+	assert.Equal(t, Running, run.State)
+	require.NotNil(t, run.Progress, "the progress of a running steal is reported")
+
+	code, _ = request(http.MethodGet, "/runs/1/report", "")
+	assert.Equal(t, http.StatusConflict, code)
+
+	release <- errors.New("tables timed out: users")
+	server.Wait()
+
+	code, body = request(http.MethodGet, "/runs/1/report", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{
+		"kind": "failure",
+		"command": "steal",
+		"duration": "1m0s",
+		"error": "tables timed out: users",
+		"report": {"done": ["users"]}
+	}`, body)
+
+	code, body = request(http.MethodPost, "/runs", `{"profile": "orders"}`)
+	require.Equal(t, http.StatusAccepted, code, body)
+	<-started
+	release <- nil
+	server.Wait()
+
+	code, body = request(http.MethodGet, "/runs", "")
+	assert.Equal(t, http.StatusOK, code)
+	var runs []Run
+	require.NoError(t, json.Unmarshal([]byte(body), &runs))
+	require.Len(t, runs, 2)
+	assert.Equal(t, "2", runs[0].ID, "the latest run is listed first")
+	assert.Equal(t, Succeeded, runs[0].State)
+	assert.Nil(t, runs[0].Progress)
+	assert.Equal(t, Failed, runs[1].State)
+
+	code, _ = request(http.MethodDelete, "/runs/1", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/runs", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
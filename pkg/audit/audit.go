@@ -0,0 +1,119 @@
+// Package audit records how the values of sampled rows were anonymised, to troubleshoot the values that come out
+// wrong without dumping the data again. The values are never recorded, only keyed hashes of them.
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// hashLength is the amount of hexadecimal characters of the hashes recorded.
+const hashLength = 16
+
+type (
+	// Trail writes the records of the sampled rows as JSON lines.
+	Trail struct {
+		mu    sync.Mutex
+		enc   *json.Encoder
+		every uint64
+		key   []byte
+	}
+
+	// Record is how the columns of a row were anonymised.
+	Record struct {
+		Table string `json:"table"`
+		// Row is the number of the row in the order the rows of the table were anonymised, starting from 1.
+		Row     uint64   `json:"row"`
+		Columns []Column `json:"columns"`
+	}
+
+	// Column is how the value of a column was anonymised.
+	Column struct {
+		Column string `json:"column"`
+		// Rule is the anonymiser of the column.
+		Rule string `json:"rule"`
+		// Before and After are the hashes of the original and the anonymised value.
+		Before string `json:"before"`
+		After  string `json:"after"`
+		// Reused tells that the value was not anonymised again, but reused from a previous row or table,
+		// e.g. for a pseudonymised column.
+		Reused bool `json:"reused,omitempty"`
+		// Steps are the steps of the rule that were applied, in order.
+		Steps []Step `json:"steps,omitempty"`
+	}
+
+	// Step is a step of an anonymiser chain and the hash of the value it returned.
+	Step struct {
+		Step  string `json:"step"`
+		After string `json:"after"`
+	}
+)
+
+// NewTrail returns a trail recording one row every given amount of rows of each table, the first one included.
+// The values are hashed with HMAC-SHA256 keyed with the key, a random key being used when it is empty, so that the
+// hashes can only be compared with each other.
+func NewTrail(w io.Writer, every uint64, key string) (*Trail, error) {
+	if every == 0 {
+		every = 1
+	}
+
+	secret := []byte(key)
+	if key == "" {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("could not generate the audit key: %w", err)
+		}
+	}
+
+	return &Trail{enc: json.NewEncoder(w), every: every, key: secret}, nil
+}
+
+// Sampled tells whether the row with the given number is recorded, never when the trail is nil.
+func (t *Trail) Sampled(n uint64) bool {
+	return t != nil && n > 0 && (n-1)%t.every == 0
+}
+
+// Hash returns the hash of a value, NULL being recorded as NULL.
+func (t *Trail) Hash(value interface{}) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(text(value)))
+
+	return hex.EncodeToString(mac.Sum(nil))[:hashLength]
+}
+
+// Write writes a record, the records of concurrent rows being written one after the other.
+func (t *Trail) Write(record Record) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.enc.Encode(record)
+}
+
+// text returns the text of a value as it is hashed.
+func text(value interface{}) string {
+	if p, ok := value.(*interface{}); ok && p != nil {
+		value = *p
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999")
+	}
+
+	return fmt.Sprint(value)
+}
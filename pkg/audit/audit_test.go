@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampled(t *testing.T) {
+	t.Parallel()
+
+	trail, err := NewTrail(&bytes.Buffer{}, 3, "key")
+	require.NoError(t, err)
+
+	var sampled []uint64
+	for n := uint64(0); n <= 7; n++ {
+		if trail.Sampled(n) {
+			sampled = append(sampled, n)
+		}
+	}
+	assert.Equal(t, []uint64{1, 4, 7}, sampled)
+
+	var nilTrail *Trail
+	assert.False(t, nilTrail.Sampled(1))
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	trail, err := NewTrail(&bytes.Buffer{}, 1, "key")
+	require.NoError(t, err)
+	// echo -n jane | openssl dgst -sha256 -hmac key
+	assert.Equal(t, "7e024875be2f8230", trail.Hash("jane"))
+	assert.Equal(t, trail.Hash("jane"), trail.Hash([]byte("jane")))
+	assert.Equal(t, trail.Hash("42"), trail.Hash(42))
+	assert.Equal(t, "NULL", trail.Hash(nil))
+
+	other, err := NewTrail(&bytes.Buffer{}, 1, "")
+	require.NoError(t, err)
+	assert.NotEqual(t, trail.Hash("jane"), other.Hash("jane"), "a random key is used without a key")
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	trail, err := NewTrail(&buf, 1, "key")
+	require.NoError(t, err)
+
+	require.NoError(t, trail.Write(Record{Table: "users", Row: 1, Columns: []Column{
+		{Column: "email", Rule: "Lower", Before: "a", After: "b", Steps: []Step{{Step: "Lower", After: "b"}}},
+		{Column: "name", Rule: "FirstName", Before: "c", After: "d", Reused: true},
+	}}))
+	assert.Equal(t, `{"table":"users","row":1,"columns":[`+
+		`{"column":"email","rule":"Lower","before":"a","after":"b","steps":[{"step":"Lower","after":"b"}]},`+
+		`{"column":"name","rule":"FirstName","before":"c","after":"d","reused":true}]}`+"\n", buf.String())
+}
@@ -0,0 +1,324 @@
+package aurora
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultInstanceClass is the class of the instance the clones are read from.
+	DefaultInstanceClass = "db.r6g.large"
+	// apiVersion is the version of the RDS query API.
+	apiVersion = "2014-10-31"
+	// requestTimeout is the maximum time spent on an API request.
+	requestTimeout = 30 * time.Second
+	// maxIdentifier is the maximum length of the cluster and instance identifiers.
+	maxIdentifier = 63
+)
+
+// ErrNoCredentials is returned when no source provides AWS credentials.
+var ErrNoCredentials = errors.New("no AWS credentials found in the environment, the profile, the container or the instance role")
+
+type (
+	// Client is a minimal RDS API client cloning Aurora clusters.
+	Client struct {
+		endpoint string
+		region   string
+		creds    Provider
+		http     *http.Client
+		// pollInterval is the wait between the checks of the clone status.
+		pollInterval time.Duration
+	}
+
+	// Credentials are the AWS credentials the requests are signed with.
+	Credentials struct {
+		AccessKeyID     string
+		SecretAccessKey string
+		// SessionToken is set for temporary credentials, e.g. of an assumed role.
+		SessionToken string
+		// Expires is when the temporary credentials expire, zero for long term ones.
+		Expires time.Time
+	}
+
+	// Clone is a copy-on-write clone of an Aurora cluster, read through a single instance.
+	Clone struct {
+		// Cluster is the clone cluster identifier.
+		Cluster string
+		// Instance is the identifier of the instance of the clone.
+		Instance string
+		// Address is the host:port of the clone cluster endpoint, known once the clone is available.
+		Address string
+	}
+
+	// APIError is returned for the API responses that are not successful.
+	APIError struct {
+		// Service is the API responding, e.g. rds or sts.
+		Service    string
+		StatusCode int
+		Code       string
+		Message    string
+	}
+
+	// cluster is an Aurora cluster, as described by the API.
+	cluster struct {
+		Engine                  string   `xml:"Engine"`
+		Status                  string   `xml:"Status"`
+		Endpoint                string   `xml:"Endpoint"`
+		Port                    int      `xml:"Port"`
+		DBSubnetGroup           string   `xml:"DBSubnetGroup"`
+		DBClusterParameterGroup string   `xml:"DBClusterParameterGroup"`
+		VpcSecurityGroupIDs     []string `xml:"VpcSecurityGroups>VpcSecurityGroupMembership>VpcSecurityGroupId"`
+	}
+)
+
+// NewClient returns a client of the RDS API at the given endpoint, e.g. https://rds.eu-west-1.amazonaws.com.
+func NewClient(endpoint string, region string, creds Provider, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout}
+	}
+
+	return &Client{
+		endpoint:     strings.TrimSuffix(endpoint, "/") + "/",
+		region:       region,
+		creds:        creds,
+		http:         httpClient,
+		pollInterval: 15 * time.Second,
+	}
+}
+
+// NewDefaultClient returns a client authenticated with the credentials found the way the AWS SDKs find them: the
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN variables, the static keys of the AWS_PROFILE profile
+// of the shared credentials and config files, the web identity token of AWS_WEB_IDENTITY_TOKEN_FILE, the container
+// credentials, then the role of the EC2 instance. The region is the one of AWS_REGION, AWS_DEFAULT_REGION, the
+// profile or the instance.
+func NewDefaultClient(ctx context.Context) (*Client, error) {
+	creds := newChain(os.Getenv)
+	region, err := creds.Region(ctx)
+	if err != nil {
+		return nil, err
+	}
+	creds.region = region
+
+	// the credentials are checked before anything is cloned
+	if _, err := creds.Retrieve(ctx); err != nil {
+		return nil, err
+	}
+
+	return NewClient(fmt.Sprintf("https://rds.%s.amazonaws.com", region), region, creds, nil), nil
+}
+
+// CreateClone starts a copy-on-write clone of the source cluster, in its subnet group and security groups,
+// with an instance of the given class. The clone must be deleted with DeleteClone, even when it fails to
+// become available.
+func (c *Client) CreateClone(ctx context.Context, source string, instanceClass string) (*Clone, error) {
+	src, err := c.describeCluster(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("could not describe cluster %s: %w", source, err)
+	}
+
+	name := cloneName(source, time.Now())
+	params := url.Values{
+		"SourceDBClusterIdentifier": {source},
+		"DBClusterIdentifier":       {name},
+		"RestoreType":               {"copy-on-write"},
+		"UseLatestRestorableTime":   {"true"},
+		"Tags.member.1.Key":         {"klepto:clone-of"},
+		"Tags.member.1.Value":       {source},
+	}
+	if src.DBSubnetGroup != "" {
+		params.Set("DBSubnetGroupName", src.DBSubnetGroup)
+	}
+	if src.DBClusterParameterGroup != "" {
+		params.Set("DBClusterParameterGroupName", src.DBClusterParameterGroup)
+	}
+	for i, id := range src.VpcSecurityGroupIDs {
+		params.Set(fmt.Sprintf("VpcSecurityGroupIds.member.%d", i+1), id)
+	}
+	if err := c.call(ctx, "RestoreDBClusterToPointInTime", params, nil); err != nil {
+		return nil, fmt.Errorf("could not clone cluster %s: %w", source, err)
+	}
+
+	clone := &Clone{Cluster: name}
+	log.WithFields(log.Fields{"source": source, "clone": name}).Info("Cloning Aurora cluster")
+
+	instance := name + "-1"
+	err = c.call(ctx, "CreateDBInstance", url.Values{
+		"DBInstanceIdentifier": {instance},
+		"DBClusterIdentifier":  {name},
+		"DBInstanceClass":      {instanceClass},
+		"Engine":               {src.Engine},
+		"Tags.member.1.Key":    {"klepto:clone-of"},
+		"Tags.member.1.Value":  {source},
+	}, nil)
+	if err != nil {
+		return clone, fmt.Errorf("could not create the instance of clone %s: %w", name, err)
+	}
+	clone.Instance = instance
+
+	return clone, nil
+}
+
+// WaitAvailable waits until the instance of the clone is available and sets the address of the clone.
+func (c *Client) WaitAvailable(ctx context.Context, clone *Clone) error {
+	logger := log.WithField("clone", clone.Cluster)
+	for {
+		status, err := c.instanceStatus(ctx, clone.Instance)
+		if err != nil {
+			return fmt.Errorf("could not describe instance %s: %w", clone.Instance, err)
+		}
+		if status == "available" {
+			break
+		}
+		logger.WithField("status", status).Debug("waiting for the clone to be available")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("clone %s is not available, its instance is %s: %w", clone.Cluster, status, ctx.Err())
+		case <-time.After(c.pollInterval):
+		}
+	}
+
+	cl, err := c.describeCluster(ctx, clone.Cluster)
+	if err != nil {
+		return fmt.Errorf("could not describe clone %s: %w", clone.Cluster, err)
+	}
+	clone.Address = net.JoinHostPort(cl.Endpoint, strconv.Itoa(cl.Port))
+	logger.WithField("address", clone.Address).Info("Aurora clone is available")
+
+	return nil
+}
+
+// DeleteClone deletes the instance and the cluster of the clone, without a final snapshot.
+func (c *Client) DeleteClone(ctx context.Context, clone *Clone) error {
+	if clone.Instance != "" {
+		err := c.call(ctx, "DeleteDBInstance", url.Values{"DBInstanceIdentifier": {clone.Instance}}, nil)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("could not delete instance %s: %w", clone.Instance, err)
+		}
+	}
+
+	err := c.call(ctx, "DeleteDBCluster", url.Values{
+		"DBClusterIdentifier": {clone.Cluster},
+		"SkipFinalSnapshot":   {"true"},
+	}, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("could not delete clone %s: %w", clone.Cluster, err)
+	}
+	log.WithField("clone", clone.Cluster).Info("Aurora clone deleted")
+
+	return nil
+}
+
+func (c *Client) describeCluster(ctx context.Context, identifier string) (cluster, error) {
+	var resp struct {
+		Clusters []cluster `xml:"DescribeDBClustersResult>DBClusters>DBCluster"`
+	}
+	if err := c.call(ctx, "DescribeDBClusters", url.Values{"DBClusterIdentifier": {identifier}}, &resp); err != nil {
+		return cluster{}, err
+	}
+	if len(resp.Clusters) == 0 {
+		return cluster{}, fmt.Errorf("cluster %s does not exist", identifier)
+	}
+
+	return resp.Clusters[0], nil
+}
+
+func (c *Client) instanceStatus(ctx context.Context, identifier string) (string, error) {
+	var resp struct {
+		Statuses []string `xml:"DescribeDBInstancesResult>DBInstances>DBInstance>DBInstanceStatus"`
+	}
+	if err := c.call(ctx, "DescribeDBInstances", url.Values{"DBInstanceIdentifier": {identifier}}, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Statuses) == 0 {
+		return "", fmt.Errorf("instance %s does not exist", identifier)
+	}
+
+	return resp.Statuses[0], nil
+}
+
+// call runs an action of the RDS query API, decoding its XML response into out.
+func (c *Client) call(ctx context.Context, action string, params url.Values, out interface{}) error {
+	params.Set("Action", action)
+	params.Set("Version", apiVersion)
+	body := []byte(params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds, err := c.creds.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("could not retrieve the AWS credentials: %w", err)
+	}
+	sign(req, body, creds, c.region, "rds", time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return readAPIError(resp, "rds")
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return xml.NewDecoder(resp.Body).Decode(out)
+}
+
+// readAPIError returns the error of an unsuccessful response of the query API of a service, e.g. rds or sts.
+func readAPIError(resp *http.Response, service string) *APIError {
+	apiErr := &APIError{Service: service, StatusCode: resp.StatusCode}
+	var errResp struct {
+		Code    string `xml:"Error>Code"`
+		Message string `xml:"Error>Message"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if xml.Unmarshal(b, &errResp) == nil {
+		apiErr.Code, apiErr.Message = errResp.Code, errResp.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(b))
+	}
+
+	return apiErr
+}
+
+// Error returns the code and message of the API response.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s api responded %d %s: %s", e.Service, e.StatusCode, e.Code, e.Message)
+}
+
+// isNotFound tells whether the error reports a cluster or instance that does not exist, e.g. already deleted.
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.Code == "DBClusterNotFoundFault" || apiErr.Code == "DBInstanceNotFound")
+}
+
+// cloneName returns the identifier of a clone of the source, e.g. orders-klepto-20240102150405.
+func cloneName(source string, now time.Time) string {
+	suffix := "-klepto-" + now.UTC().Format("20060102150405")
+	// the instance identifier adds -1 to the clone one
+	if limit := maxIdentifier - len("-1") - len(suffix); len(source) > limit {
+		source = strings.TrimRight(source[:limit], "-")
+	}
+
+	return source + suffix
+}
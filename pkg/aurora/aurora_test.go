@@ -0,0 +1,114 @@
+package aurora
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	// the example of the AWS signature version 4 documentation
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	sign(req, nil, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, "+
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7", req.Header.Get("Authorization"))
+}
+
+func TestClone(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		actions []string
+		polls   int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Contains(t, r.Header.Get("Authorization"), "Credential=key/")
+		mu.Lock()
+		defer mu.Unlock()
+
+		action := r.PostForm.Get("Action")
+		actions = append(actions, action)
+		switch action {
+		case "DescribeDBClusters":
+			endpoint := "orders.cluster-abc.eu-west-1.rds.amazonaws.com"
+			if id := r.PostForm.Get("DBClusterIdentifier"); id != "orders" {
+				endpoint = id + ".cluster-abc.eu-west-1.rds.amazonaws.com"
+			}
+			fmt.Fprintf(w, `<DescribeDBClustersResponse xmlns="http://rds.amazonaws.com/doc/2014-10-31/">
+  <DescribeDBClustersResult><DBClusters><DBCluster>
+    <Engine>aurora-mysql</Engine><Status>available</Status><Endpoint>%s</Endpoint><Port>3306</Port>
+    <DBSubnetGroup>private</DBSubnetGroup><DBClusterParameterGroup>orders-params</DBClusterParameterGroup>
+    <VpcSecurityGroups>
+      <VpcSecurityGroupMembership><VpcSecurityGroupId>sg-1</VpcSecurityGroupId><Status>active</Status></VpcSecurityGroupMembership>
+      <VpcSecurityGroupMembership><VpcSecurityGroupId>sg-2</VpcSecurityGroupId><Status>active</Status></VpcSecurityGroupMembership>
+    </VpcSecurityGroups>
+  </DBCluster></DBClusters></DescribeDBClustersResult>
+</DescribeDBClustersResponse>`, endpoint)
+		case "RestoreDBClusterToPointInTime":
+			assert.Equal(t, "orders", r.PostForm.Get("SourceDBClusterIdentifier"))
+			assert.Equal(t, "copy-on-write", r.PostForm.Get("RestoreType"))
+			assert.Equal(t, "private", r.PostForm.Get("DBSubnetGroupName"))
+			assert.Equal(t, "sg-2", r.PostForm.Get("VpcSecurityGroupIds.member.2"))
+		case "CreateDBInstance":
+			assert.Equal(t, "aurora-mysql", r.PostForm.Get("Engine"))
+			assert.Equal(t, "db.t4g.medium", r.PostForm.Get("DBInstanceClass"))
+		case "DescribeDBInstances":
+			status := "creating"
+			if polls++; polls > 1 {
+				status = "available"
+			}
+			fmt.Fprintf(w, `<DescribeDBInstancesResponse><DescribeDBInstancesResult><DBInstances><DBInstance>
+  <DBInstanceStatus>%s</DBInstanceStatus>
+</DBInstance></DBInstances></DescribeDBInstancesResult></DescribeDBInstancesResponse>`, status)
+		case "DeleteDBInstance":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>DBInstanceNotFound</Code><Message>gone</Message></Error></ErrorResponse>`)
+		case "DeleteDBCluster":
+			assert.Equal(t, "true", r.PostForm.Get("SkipFinalSnapshot"))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "eu-west-1", Credentials{AccessKeyID: "key", SecretAccessKey: "secret"}, nil)
+	client.pollInterval = time.Millisecond
+
+	ctx := context.Background()
+	clone, err := client.CreateClone(ctx, "orders", "db.t4g.medium")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(clone.Cluster, "orders-klepto-"), clone.Cluster)
+	assert.Equal(t, clone.Cluster+"-1", clone.Instance)
+
+	require.NoError(t, client.WaitAvailable(ctx, clone))
+	assert.Equal(t, clone.Cluster+".cluster-abc.eu-west-1.rds.amazonaws.com:3306", clone.Address)
+
+	require.NoError(t, client.DeleteClone(ctx, clone), "an instance already deleted is not an error")
+	assert.Equal(t, []string{
+		"DescribeDBClusters", "RestoreDBClusterToPointInTime", "CreateDBInstance",
+		"DescribeDBInstances", "DescribeDBInstances", "DescribeDBClusters",
+		"DeleteDBInstance", "DeleteDBCluster",
+	}, actions)
+}
+
+func TestCloneName(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	assert.Equal(t, "orders-klepto-20240102150405", cloneName("orders", now))
+
+	name := cloneName(strings.Repeat("a", 38)+"-"+strings.Repeat("b", 30), now)
+	assert.Equal(t, strings.Repeat("a", 38)+"-klepto-20240102150405", name, "the truncated source does not end with a hyphen")
+	assert.LessOrEqual(t, len(name+"-1"), maxIdentifier)
+}
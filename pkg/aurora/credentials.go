@@ -0,0 +1,450 @@
+package aurora
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// refreshWindow is how long before they expire the temporary credentials are retrieved again.
+	refreshWindow = 5 * time.Minute
+	// metadataTimeout is the maximum time spent on a request to the instance metadata service, which does not
+	// answer outside of EC2.
+	metadataTimeout = 2 * time.Second
+	// metadataTokenTTL is the lifetime in seconds of the IMDSv2 session tokens.
+	metadataTokenTTL = 21600
+	// stsAPIVersion is the version of the STS query API.
+	stsAPIVersion = "2011-06-15"
+)
+
+type (
+	// Provider provides the credentials the requests are signed with.
+	Provider interface {
+		// Retrieve returns the credentials, retrieving them again once the temporary ones are about to expire.
+		Retrieve(ctx context.Context) (Credentials, error)
+	}
+
+	// chain retrieves the credentials from the sources of the AWS SDKs, in their order: the environment, the static
+	// keys of the profile, a web identity token, the container credentials and the instance role.
+	chain struct {
+		getenv func(string) string
+		http   *http.Client
+		region string
+		// imds, container and sts are the endpoints of the instance metadata service, of the container credentials
+		// and of STS, the regional one when empty.
+		imds      string
+		container string
+		sts       string
+
+		mu    sync.Mutex
+		creds Credentials
+	}
+
+	// temporaryCredentials are the credentials of the container and instance metadata endpoints.
+	temporaryCredentials struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+)
+
+// Retrieve returns the credentials, they are static.
+func (c Credentials) Retrieve(context.Context) (Credentials, error) {
+	return c, nil
+}
+
+// newChain returns the default credentials chain, reading the environment with getenv.
+func newChain(getenv func(string) string) *chain {
+	imds := getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT")
+	if imds == "" {
+		imds = "http://169.254.169.254"
+	}
+
+	return &chain{
+		getenv:    getenv,
+		http:      &http.Client{Timeout: requestTimeout},
+		imds:      strings.TrimSuffix(imds, "/"),
+		container: "http://169.254.170.2",
+	}
+}
+
+// Retrieve returns the credentials of the first source providing them, cached until they are about to expire.
+func (c *chain) Retrieve(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.creds.AccessKeyID != "" && (c.creds.Expires.IsZero() || time.Until(c.creds.Expires) > refreshWindow) {
+		return c.creds, nil
+	}
+
+	creds, err := c.retrieve(ctx)
+	if err != nil {
+		return Credentials{}, err
+	}
+	c.creds = creds
+
+	return creds, nil
+}
+
+func (c *chain) retrieve(ctx context.Context) (Credentials, error) {
+	creds := Credentials{
+		AccessKeyID:     c.getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: c.getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    c.getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+		return creds, nil
+	}
+
+	if creds, ok, err := c.profileCredentials(); err != nil || ok {
+		return creds, err
+	}
+
+	// the sources configured in the environment fail rather than falling back to the instance role
+	if file := c.getenv("AWS_WEB_IDENTITY_TOKEN_FILE"); file != "" {
+		return c.webIdentityCredentials(ctx, file)
+	}
+	if c.getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || c.getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" {
+		return c.containerCredentials(ctx)
+	}
+
+	if strings.EqualFold(c.getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return Credentials{}, ErrNoCredentials
+	}
+	creds, err := c.instanceCredentials(ctx)
+	if err != nil {
+		log.WithError(err).Debug("could not read the credentials of the instance role")
+		return Credentials{}, ErrNoCredentials
+	}
+
+	return creds, nil
+}
+
+// Region returns the region of the environment, of the profile or of the instance.
+func (c *chain) Region(ctx context.Context) (string, error) {
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := c.getenv(name); region != "" {
+			return region, nil
+		}
+	}
+
+	profile, err := readProfile(c.configFile(), c.configSection())
+	if err != nil {
+		return "", err
+	}
+	if profile["region"] != "" {
+		return profile["region"], nil
+	}
+
+	if !strings.EqualFold(c.getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		if region, err := c.metadata(ctx, "placement/region"); err == nil && region != "" {
+			return region, nil
+		}
+	}
+
+	return "", errors.New("the AWS region is not set, AWS_REGION, AWS_DEFAULT_REGION or the region of the profile must be set")
+}
+
+// profileCredentials returns the static keys of the profile, in the shared credentials file or in the config file.
+// A profile set with AWS_PROFILE must have them.
+func (c *chain) profileCredentials() (Credentials, bool, error) {
+	for _, file := range []struct{ path, section string }{
+		{c.credentialsFile(), c.profile()},
+		{c.configFile(), c.configSection()},
+	} {
+		profile, err := readProfile(file.path, file.section)
+		if err != nil {
+			return Credentials{}, false, err
+		}
+
+		creds := Credentials{
+			AccessKeyID:     profile["aws_access_key_id"],
+			SecretAccessKey: profile["aws_secret_access_key"],
+			SessionToken:    profile["aws_session_token"],
+		}
+		if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+			return creds, true, nil
+		}
+	}
+
+	if c.getenv("AWS_PROFILE") != "" {
+		return Credentials{}, false, fmt.Errorf(
+			"the AWS profile %s has no aws_access_key_id and aws_secret_access_key, only static keys are supported",
+			c.profile(),
+		)
+	}
+
+	return Credentials{}, false, nil
+}
+
+func (c *chain) profile() string {
+	if profile := c.getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+
+	return "default"
+}
+
+// configSection returns the section of the profile in the config file, prefixed by profile but for the default one.
+func (c *chain) configSection() string {
+	if profile := c.profile(); profile != "default" {
+		return "profile " + profile
+	}
+
+	return "default"
+}
+
+func (c *chain) credentialsFile() string {
+	if path := c.getenv("AWS_SHARED_CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+
+	return c.homeFile("credentials")
+}
+
+func (c *chain) configFile() string {
+	if path := c.getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+
+	return c.homeFile("config")
+}
+
+// homeFile returns the path of a file of the .aws directory of the user, empty when the home directory is unknown.
+func (c *chain) homeFile(name string) string {
+	home := c.getenv("HOME")
+	if home == "" {
+		home = c.getenv("USERPROFILE")
+	}
+	if home == "" {
+		return ""
+	}
+
+	return filepath.Join(home, ".aws", name)
+}
+
+// webIdentityCredentials assumes the role of AWS_ROLE_ARN with the web identity token of the file, e.g. the token of
+// the service account of an EKS pod.
+func (c *chain) webIdentityCredentials(ctx context.Context, file string) (Credentials, error) {
+	token, err := os.ReadFile(file)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("could not read the web identity token: %w", err)
+	}
+	session := c.getenv("AWS_ROLE_SESSION_NAME")
+	if session == "" {
+		session = "klepto-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+
+	endpoint := c.sts
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com", c.region)
+	}
+	body := []byte(url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {stsAPIVersion},
+		"RoleArn":          {c.getenv("AWS_ROLE_ARN")},
+		"RoleSessionName":  {session},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}.Encode())
+
+	// the request is authenticated by the token, it is not signed
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("could not assume role with web identity: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Credentials{}, fmt.Errorf("could not assume role with web identity: %w", readAPIError(resp, "sts"))
+	}
+
+	var result struct {
+		AccessKeyID     string    `xml:"AssumeRoleWithWebIdentityResult>Credentials>AccessKeyId"`
+		SecretAccessKey string    `xml:"AssumeRoleWithWebIdentityResult>Credentials>SecretAccessKey"`
+		SessionToken    string    `xml:"AssumeRoleWithWebIdentityResult>Credentials>SessionToken"`
+		Expiration      time.Time `xml:"AssumeRoleWithWebIdentityResult>Credentials>Expiration"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Credentials{}, fmt.Errorf("could not decode the web identity credentials: %w", err)
+	}
+
+	return Credentials{
+		AccessKeyID:     result.AccessKeyID,
+		SecretAccessKey: result.SecretAccessKey,
+		SessionToken:    result.SessionToken,
+		Expires:         result.Expiration,
+	}, nil
+}
+
+// containerCredentials reads the credentials of the container endpoint, e.g. of an ECS task or an EKS pod identity.
+func (c *chain) containerCredentials(ctx context.Context) (Credentials, error) {
+	endpoint := c.getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := c.getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = c.container + relative
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	token := c.getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := c.getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("could not read the container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	body, err := c.get(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("could not read the container credentials: %w", err)
+	}
+
+	return decodeTemporaryCredentials(body)
+}
+
+// instanceCredentials reads the credentials of the role of the EC2 instance.
+func (c *chain) instanceCredentials(ctx context.Context) (Credentials, error) {
+	roles, err := c.metadata(ctx, "iam/security-credentials/")
+	if err != nil {
+		return Credentials{}, err
+	}
+	role := strings.TrimSpace(strings.SplitN(roles, "\n", 2)[0])
+	if role == "" {
+		return Credentials{}, errors.New("the instance has no role")
+	}
+
+	body, err := c.metadata(ctx, "iam/security-credentials/"+role)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	return decodeTemporaryCredentials([]byte(body))
+}
+
+// metadata reads an instance metadata path with an IMDSv2 session token.
+func (c *chain) metadata(ctx context.Context, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.imds+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", strconv.Itoa(metadataTokenTTL))
+	token, err := c.get(req)
+	if err != nil {
+		return "", fmt.Errorf("could not get an instance metadata token: %w", err)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, c.imds+"/latest/meta-data/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	body, err := c.get(req)
+	if err != nil {
+		return "", fmt.Errorf("could not read instance metadata %s: %w", path, err)
+	}
+
+	return string(body), nil
+}
+
+// get runs the request and returns the body of a successful response.
+func (c *chain) get(req *http.Request) ([]byte, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s responded %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
+
+func decodeTemporaryCredentials(body []byte) (Credentials, error) {
+	var creds temporaryCredentials
+	if err := json.Unmarshal(body, &creds); err != nil {
+		return Credentials{}, fmt.Errorf("could not decode the credentials: %w", err)
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, errors.New("the credentials have no access key")
+	}
+
+	return Credentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.Token,
+		Expires:         creds.Expiration,
+	}, nil
+}
+
+// readProfile returns the keys of a section of an ini file, e.g. ~/.aws/credentials, none when the file or the
+// section does not exist.
+func readProfile(path string, section string) (map[string]string, error) {
+	keys := make(map[string]string)
+	if path == "" {
+		return keys, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read the AWS profile: %w", err)
+	}
+	defer f.Close()
+
+	var current string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[' && line[len(line)-1] == ']':
+			current = strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+		case current == section:
+			if i := strings.IndexByte(line, '='); i > 0 {
+				keys[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read the AWS profile: %w", err)
+	}
+
+	return keys, nil
+}
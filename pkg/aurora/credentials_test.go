@@ -0,0 +1,248 @@
+package aurora
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainEnvironment(t *testing.T) {
+	creds := newChain(env{
+		"AWS_ACCESS_KEY_ID":     "env-key",
+		"AWS_SECRET_ACCESS_KEY": "env-secret",
+		"AWS_SESSION_TOKEN":     "env-token",
+		"AWS_PROFILE":           "ci",
+		"AWS_REGION":            "eu-west-1",
+	}.get)
+
+	got, err := creds.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{AccessKeyID: "env-key", SecretAccessKey: "env-secret", SessionToken: "env-token"}, got)
+
+	region, err := creds.Region(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", region)
+}
+
+func TestChainProfile(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(home, ".aws"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte(`
+[default]
+aws_access_key_id = default-key
+aws_secret_access_key = default-secret
+
+# the keys of the ci runners
+[ci]
+aws_access_key_id=ci-key
+aws_secret_access_key=ci-secret
+aws_session_token=ci-token
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".aws", "config"), []byte(`
+[default]
+region = us-east-1
+
+[profile  ci]
+region = eu-central-1
+
+[profile ops]
+region = eu-west-1
+aws_access_key_id = ops-key
+aws_secret_access_key = ops-secret
+
+[profile sso]
+sso_start_url = https://example.awsapps.com/start
+`), 0600))
+
+	tests := []struct {
+		profile string
+		creds   Credentials
+		region  string
+		err     string
+	}{
+		{profile: "", creds: Credentials{AccessKeyID: "default-key", SecretAccessKey: "default-secret"}, region: "us-east-1"},
+		{profile: "ci", creds: Credentials{AccessKeyID: "ci-key", SecretAccessKey: "ci-secret", SessionToken: "ci-token"}, region: "eu-central-1"},
+		{profile: "ops", creds: Credentials{AccessKeyID: "ops-key", SecretAccessKey: "ops-secret"}, region: "eu-west-1"},
+		{profile: "sso", err: "the AWS profile sso has no aws_access_key_id and aws_secret_access_key, only static keys are supported"},
+	}
+	for _, test := range tests {
+		creds := newChain(env{"HOME": home, "AWS_PROFILE": test.profile, "AWS_EC2_METADATA_DISABLED": "true"}.get)
+
+		got, err := creds.Retrieve(context.Background())
+		if test.err != "" {
+			assert.EqualError(t, err, test.err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, test.creds, got)
+
+		region, err := creds.Region(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, test.region, region)
+	}
+}
+
+func TestChainWebIdentity(t *testing.T) {
+	token := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(token, []byte("eyJhbGciOi\n"), 0600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Empty(t, r.Header.Get("Authorization"), "the request is authenticated by the token")
+		assert.Equal(t, "AssumeRoleWithWebIdentity", r.PostForm.Get("Action"))
+		assert.Equal(t, "arn:aws:iam::123:role/klepto", r.PostForm.Get("RoleArn"))
+		assert.Equal(t, "eyJhbGciOi", r.PostForm.Get("WebIdentityToken"))
+		assert.Equal(t, "refresh", r.PostForm.Get("RoleSessionName"))
+		fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult><Credentials>
+    <AccessKeyId>ASIAWEB</AccessKeyId><SecretAccessKey>web-secret</SecretAccessKey>
+    <SessionToken>web-token</SessionToken><Expiration>2030-01-02T15:04:05Z</Expiration>
+  </Credentials></AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`)
+	}))
+	defer server.Close()
+
+	creds := newChain(env{
+		"AWS_WEB_IDENTITY_TOKEN_FILE": token,
+		"AWS_ROLE_ARN":                "arn:aws:iam::123:role/klepto",
+		"AWS_ROLE_SESSION_NAME":       "refresh",
+	}.get)
+	creds.sts = server.URL
+
+	got, err := creds.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{
+		AccessKeyID:     "ASIAWEB",
+		SecretAccessKey: "web-secret",
+		SessionToken:    "web-token",
+		Expires:         time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC),
+	}, got)
+}
+
+func TestChainWebIdentityDenied(t *testing.T) {
+	token := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(token, []byte("expired"), 0600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `<ErrorResponse><Error><Code>ExpiredTokenException</Code><Message>Token expired</Message></Error></ErrorResponse>`)
+	}))
+	defer server.Close()
+
+	creds := newChain(env{"AWS_WEB_IDENTITY_TOKEN_FILE": token, "AWS_ROLE_ARN": "arn:aws:iam::123:role/klepto"}.get)
+	creds.sts = server.URL
+
+	_, err := creds.Retrieve(context.Background())
+	assert.EqualError(t, err, "could not assume role with web identity: sts api responded 400 ExpiredTokenException: Token expired")
+}
+
+func TestChainContainer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/credentials/abc", r.URL.Path)
+		assert.Equal(t, "secret-token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"AccessKeyId":"ASIACONTAINER","SecretAccessKey":"container-secret","Token":"container-token","Expiration":"2030-01-02T15:04:05Z"}`)
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret-token\n"), 0600))
+
+	for _, environment := range []env{
+		{"AWS_CONTAINER_CREDENTIALS_FULL_URI": server.URL + "/v2/credentials/abc", "AWS_CONTAINER_AUTHORIZATION_TOKEN": "secret-token"},
+		{"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI": "/v2/credentials/abc", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE": tokenFile},
+	} {
+		creds := newChain(environment.get)
+		creds.container = server.URL
+
+		got, err := creds.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, Credentials{
+			AccessKeyID:     "ASIACONTAINER",
+			SecretAccessKey: "container-secret",
+			SessionToken:    "container-token",
+			Expires:         time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC),
+		}, got)
+	}
+}
+
+func TestChainInstanceRole(t *testing.T) {
+	var (
+		expires = time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		reads   int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest/api/token" {
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "21600", r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds"))
+			fmt.Fprint(w, "imds-token")
+			return
+		}
+
+		assert.Equal(t, "imds-token", r.Header.Get("X-aws-ec2-metadata-token"), "IMDSv2 requires the session token")
+		switch r.URL.Path {
+		case "/latest/meta-data/placement/region":
+			fmt.Fprint(w, "eu-west-1")
+		case "/latest/meta-data/iam/security-credentials/":
+			fmt.Fprint(w, "klepto-node\n")
+		case "/latest/meta-data/iam/security-credentials/klepto-node":
+			reads++
+			fmt.Fprintf(w, `{"Code":"Success","AccessKeyId":"ASIAROLE","SecretAccessKey":"role-secret","Token":"role-token","Expiration":%q}`,
+				expires.Format(time.RFC3339))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	creds := newChain(env{"AWS_EC2_METADATA_SERVICE_ENDPOINT": server.URL + "/"}.get)
+
+	region, err := creds.Region(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", region)
+
+	for i := 0; i < 2; i++ {
+		got, err := creds.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, Credentials{AccessKeyID: "ASIAROLE", SecretAccessKey: "role-secret", SessionToken: "role-token", Expires: expires}, got)
+	}
+	assert.Equal(t, 1, reads, "the credentials are cached until they are about to expire")
+
+	expires = time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	creds.creds.Expires = time.Now().Add(refreshWindow / 2)
+	_, err = creds.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, reads, "the credentials about to expire are read again")
+}
+
+func TestChainNoCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	for _, environment := range []env{
+		{"AWS_EC2_METADATA_DISABLED": "true"},
+		{"AWS_EC2_METADATA_SERVICE_ENDPOINT": server.URL},
+	} {
+		creds := newChain(environment.get)
+
+		_, err := creds.Retrieve(context.Background())
+		assert.Equal(t, ErrNoCredentials, err)
+		_, err = creds.Region(context.Background())
+		assert.Error(t, err)
+	}
+}
+
+type env map[string]string
+
+func (e env) get(name string) string {
+	return e[name]
+}
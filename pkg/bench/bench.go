@@ -0,0 +1,255 @@
+// Package bench measures the read throughput of the source tables with different page sizes and concurrency, to
+// recommend the settings of the steals.
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/paging"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// tolerance is the fraction of the best throughput within which a cheaper setting is recommended instead, with fewer
+// queries or connections, the differences being within the measurement noise.
+const tolerance = 0.1
+
+type (
+	// Options are the settings measured.
+	Options struct {
+		// Rows is the amount of rows read from each table per measure.
+		Rows uint64
+		// PageSizes are the page sizes measured, 0 reading a table with a single query.
+		PageSizes []uint64
+		// Concurrency are the amounts of tables read concurrently measured.
+		Concurrency []int
+	}
+
+	// Report is the throughput measured for each setting, with the recommended ones.
+	Report struct {
+		Tables      []TableResult       `json:"tables"`
+		Concurrency []ConcurrencyResult `json:"concurrency"`
+		// RecommendedConcurrency is the smallest concurrency reading the tables within 10% of the best throughput.
+		RecommendedConcurrency int `json:"recommended_concurrency"`
+	}
+
+	// TableResult is the throughput of a table with each page size.
+	TableResult struct {
+		Table     string        `json:"table"`
+		PageSizes []Measurement `json:"page_sizes"`
+		// RecommendedPageSize is the page size reading the table within 10% of the best throughput with the fewest
+		// queries, 0 for a single query.
+		RecommendedPageSize uint64 `json:"recommended_page_size"`
+	}
+
+	// ConcurrencyResult is the throughput of all the tables read with an amount of concurrent reads.
+	ConcurrencyResult struct {
+		Concurrency   int           `json:"concurrency"`
+		Rows          uint64        `json:"rows"`
+		Duration      time.Duration `json:"duration"`
+		RowsPerSecond float64       `json:"rows_per_second"`
+	}
+
+	// Measurement is the throughput of a read.
+	Measurement struct {
+		PageSize      uint64        `json:"page_size"`
+		Rows          uint64        `json:"rows"`
+		Duration      time.Duration `json:"duration"`
+		RowsPerSecond float64       `json:"rows_per_second"`
+	}
+)
+
+// Run measures the read throughput of the tables, read with their config Filter. Each table is read once beforehand
+// for the measures not to depend on the order they are taken in, e.g. because of the source caches. The tables whose
+// data is ignored are not read.
+func Run(source reader.Reader, tables config.Tables, names []string, opts Options) (*Report, error) {
+	if opts.Rows == 0 {
+		return nil, errors.New("the amount of rows read per measure must be positive")
+	}
+
+	var report Report
+	pageSizes := make(map[string]uint64, len(names))
+	for _, name := range names {
+		table := tables.FindByName(name)
+		if table == nil {
+			table = &config.Table{Name: name}
+		}
+		if table.IgnoreData {
+			continue
+		}
+
+		logger := log.WithField("table", name)
+		logger.Info("Measuring the table read throughput")
+		if _, err := measure(source, table, 0, opts.Rows); err != nil {
+			return nil, fmt.Errorf("could not read table %s: %w", name, err)
+		}
+
+		result := TableResult{Table: name}
+		for _, pageSize := range opts.PageSizes {
+			// a page of all the rows read is a single query
+			if pageSize >= opts.Rows {
+				continue
+			}
+			m, err := measure(source, table, pageSize, opts.Rows)
+			if err != nil {
+				return nil, fmt.Errorf("could not read table %s: %w", name, err)
+			}
+			logger.WithFields(log.Fields{"page_size": pageSize, "rows_per_second": int64(m.RowsPerSecond)}).Debug("measured")
+			result.PageSizes = append(result.PageSizes, m)
+		}
+		result.RecommendedPageSize = recommendPageSize(result.PageSizes)
+		pageSizes[name] = result.RecommendedPageSize
+		report.Tables = append(report.Tables, result)
+	}
+
+	for _, concurrency := range opts.Concurrency {
+		log.WithField("concurrency", concurrency).Info("Measuring the concurrent read throughput")
+		result, err := measureConcurrency(source, tables, report.Tables, pageSizes, concurrency, opts.Rows)
+		if err != nil {
+			return nil, err
+		}
+		report.Concurrency = append(report.Concurrency, result)
+	}
+	report.RecommendedConcurrency = recommendConcurrency(report.Concurrency)
+
+	return &report, nil
+}
+
+// measure reads up to rows rows of the table with the page size.
+func measure(source reader.Reader, table *config.Table, pageSize uint64, rows uint64) (Measurement, error) {
+	cfg := *table
+	cfg.PageSize = pageSize
+	paged := paging.NewReader(source, config.Tables{&cfg})
+
+	opts := reader.NewReadTableOpt(&cfg)
+	if opts.Limit == 0 || opts.Limit > rows {
+		opts.Limit = rows
+	}
+
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		errChan <- paged.ReadTable(cfg.Name, rowChan, opts)
+	}()
+
+	m := Measurement{PageSize: pageSize}
+	for range rowChan {
+		m.Rows++
+	}
+	if err := <-errChan; err != nil {
+		return m, err
+	}
+	m.Duration = time.Since(start)
+	m.RowsPerSecond = throughput(m.Rows, m.Duration)
+
+	return m, nil
+}
+
+// measureConcurrency reads the tables with their page size, with concurrency tables read at the same time.
+func measureConcurrency(source reader.Reader, tables config.Tables, results []TableResult, pageSizes map[string]uint64, concurrency int, rows uint64) (ConcurrencyResult, error) {
+	if concurrency < 1 {
+		return ConcurrencyResult{}, fmt.Errorf("invalid concurrency %d", concurrency)
+	}
+
+	var (
+		mu    sync.Mutex
+		total = ConcurrencyResult{Concurrency: concurrency}
+		err   error
+		wg    sync.WaitGroup
+	)
+	queue := make(chan string)
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range queue {
+				table := tables.FindByName(name)
+				if table == nil {
+					table = &config.Table{Name: name}
+				}
+				m, readErr := measure(source, table, pageSizes[name], rows)
+
+				mu.Lock()
+				total.Rows += m.Rows
+				if readErr != nil && err == nil {
+					err = fmt.Errorf("could not read table %s: %w", name, readErr)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, result := range results {
+		queue <- result.Table
+	}
+	close(queue)
+	wg.Wait()
+
+	total.Duration = time.Since(start)
+	total.RowsPerSecond = throughput(total.Rows, total.Duration)
+
+	return total, err
+}
+
+// recommendPageSize returns the largest page size within the tolerance of the best throughput, a single query
+// being the largest page.
+func recommendPageSize(measurements []Measurement) uint64 {
+	var best float64
+	for _, m := range measurements {
+		best = math.Max(best, m.RowsPerSecond)
+	}
+
+	var recommended *Measurement
+	for i, m := range measurements {
+		if m.RowsPerSecond >= best*(1-tolerance) && (recommended == nil || pageOrder(m.PageSize) > pageOrder(recommended.PageSize)) {
+			recommended = &measurements[i]
+		}
+	}
+	if recommended == nil {
+		return 0
+	}
+
+	return recommended.PageSize
+}
+
+// recommendConcurrency returns the smallest concurrency within the tolerance of the best throughput.
+func recommendConcurrency(results []ConcurrencyResult) int {
+	var best float64
+	for _, r := range results {
+		best = math.Max(best, r.RowsPerSecond)
+	}
+
+	var recommended int
+	for _, r := range results {
+		if r.RowsPerSecond >= best*(1-tolerance) && (recommended == 0 || r.Concurrency < recommended) {
+			recommended = r.Concurrency
+		}
+	}
+
+	return recommended
+}
+
+// pageOrder orders the page sizes by the amount of rows per query, 0 being a single query.
+func pageOrder(pageSize uint64) uint64 {
+	if pageSize == 0 {
+		return ^uint64(0)
+	}
+
+	return pageSize
+}
+
+func throughput(rows uint64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+
+	return float64(rows) / d.Seconds()
+}
@@ -0,0 +1,114 @@
+package bench
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{rows: map[string]uint64{"users": 250, "orders": 40, "logs": 1000}}
+	tables := config.Tables{
+		{Name: "orders", Filter: config.Filter{Match: "state = 'paid'"}},
+		{Name: "logs", IgnoreData: true},
+	}
+	report, err := Run(source, tables, []string{"users", "orders", "logs"}, Options{
+		Rows:        100,
+		PageSizes:   []uint64{0, 30, 100, 1000},
+		Concurrency: []int{1, 2},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, report.Tables, 2, "the ignored tables are not read")
+	users := report.Tables[0]
+	assert.Equal(t, "users", users.Table)
+	require.Len(t, users.PageSizes, 2, "the pages of all the rows read are not measured")
+	assert.Equal(t, uint64(0), users.PageSizes[0].PageSize)
+	assert.Equal(t, uint64(100), users.PageSizes[0].Rows)
+	assert.Equal(t, uint64(30), users.PageSizes[1].PageSize)
+	assert.Equal(t, uint64(100), users.PageSizes[1].Rows)
+	assert.Equal(t, uint64(40), report.Tables[1].PageSizes[0].Rows)
+
+	require.Len(t, report.Concurrency, 2)
+	assert.Equal(t, 2, report.Concurrency[1].Concurrency)
+	assert.Equal(t, uint64(140), report.Concurrency[1].Rows)
+	assert.Contains(t, []int{1, 2}, report.RecommendedConcurrency)
+
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	assert.Equal(t, []string{"state = 'paid'"}, source.matches, "the tables are read with their filter")
+	// warm up, single query, 4 pages of 30 rows and 2 concurrency runs
+	assert.Equal(t, 1+1+4+2, source.queries["users"])
+
+	_, err = Run(source, tables, []string{"users"}, Options{})
+	assert.Error(t, err)
+}
+
+func TestRecommend(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, uint64(10000), recommendPageSize([]Measurement{
+		{PageSize: 0, RowsPerSecond: 800},
+		{PageSize: 1000, RowsPerSecond: 700},
+		{PageSize: 10000, RowsPerSecond: 1000},
+	}), "the best page size")
+	assert.Equal(t, uint64(0), recommendPageSize([]Measurement{
+		{PageSize: 1000, RowsPerSecond: 1000},
+		{PageSize: 0, RowsPerSecond: 950},
+	}), "the fewest queries within 10% of the best")
+	assert.Equal(t, uint64(0), recommendPageSize(nil))
+
+	assert.Equal(t, 4, recommendConcurrency([]ConcurrencyResult{
+		{Concurrency: 8, RowsPerSecond: 4000},
+		{Concurrency: 1, RowsPerSecond: 1000},
+		{Concurrency: 4, RowsPerSecond: 3700},
+		{Concurrency: 2, RowsPerSecond: 2000},
+	}), "the smallest concurrency within 10% of the best")
+	assert.Equal(t, 0, recommendConcurrency(nil))
+}
+
+// mockReader publishes the rows of the tables, counting the queries.
+type mockReader struct {
+	rows map[string]uint64
+
+	mu      sync.Mutex
+	queries map[string]int
+	matches []string
+}
+
+func (m *mockReader) GetTables() ([]string, error)  { return nil, nil }
+func (m *mockReader) GetStructure() (string, error) { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) {
+	return []string{"id"}, nil
+}
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return tableName + "." + columnName
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	m.mu.Lock()
+	if m.queries == nil {
+		m.queries = make(map[string]int)
+	}
+	m.queries[tableName]++
+	if opts.Match != "" && len(m.matches) == 0 {
+		m.matches = append(m.matches, opts.Match)
+	}
+	m.mu.Unlock()
+
+	columns := database.NewColumns([]string{"id"})
+	for i := opts.Offset; i < m.rows[tableName] && i < opts.Offset+opts.Limit; i++ {
+		rowChan <- database.NewRow(columns, []interface{}{int64(i)})
+	}
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
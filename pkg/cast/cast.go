@@ -0,0 +1,220 @@
+package cast
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// caster converts a non NULL value to a type.
+type caster func(value interface{}) (interface{}, error)
+
+var (
+	decimalType = regexp.MustCompile(`^(?:decimal|numeric)\s*\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\)$`)
+
+	casters = map[string]caster{
+		"string":    toString,
+		"text":      toString,
+		"varchar":   toString,
+		"int":       toInt,
+		"integer":   toInt,
+		"bigint":    toInt,
+		"float":     toFloat,
+		"double":    toFloat,
+		"bool":      toBool,
+		"boolean":   toBool,
+		"bytes":     toBytes,
+		"binary":    toBytes,
+		"datetime":  toTime,
+		"timestamp": toTime,
+		"date":      toDate,
+	}
+
+	timeLayouts = []string{
+		"2006-01-02 15:04:05.999999999",
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02",
+	}
+)
+
+// newCaster returns the caster of a type name such as string, int or decimal(12,2).
+func newCaster(typeName string) (caster, error) {
+	typeName = strings.ToLower(strings.TrimSpace(typeName))
+	if c, ok := casters[typeName]; ok {
+		return c, nil
+	}
+
+	if m := decimalType.FindStringSubmatch(typeName); m != nil {
+		precision, _ := strconv.Atoi(m[1])
+		scale, _ := strconv.Atoi(m[2])
+		if scale > precision {
+			return nil, fmt.Errorf("invalid cast %q, the scale is greater than the precision", typeName)
+		}
+		return toDecimal(precision, scale), nil
+	}
+
+	return nil, fmt.Errorf("unknown cast %q, supported casts are string, int, float, decimal(p,s), bool, bytes, datetime and date", typeName)
+}
+
+// text returns the text of a value read as bytes or string.
+func text(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	}
+
+	return "", false
+}
+
+func toString(value interface{}) (interface{}, error) {
+	if s, ok := text(value); ok {
+		return s, nil
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999"), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+
+	return fmt.Sprint(value), nil
+}
+
+func toInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, fmt.Errorf("%v is not an integer", v)
+		}
+		return int64(v), nil
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}
+
+	s, ok := text(value)
+	if !ok {
+		return nil, fmt.Errorf("could not cast %T to int", value)
+	}
+
+	return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+}
+
+func toFloat(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	}
+
+	s, ok := text(value)
+	if !ok {
+		return nil, fmt.Errorf("could not cast %T to float", value)
+	}
+
+	return strconv.ParseFloat(strings.TrimSpace(s), 64)
+}
+
+// toDecimal returns a caster formatting numbers with the given scale, as a string so no precision is lost.
+func toDecimal(precision int, scale int) caster {
+	return func(value interface{}) (interface{}, error) {
+		var s string
+		switch v := value.(type) {
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			t, ok := text(value)
+			if !ok {
+				return nil, fmt.Errorf("could not cast %T to decimal", value)
+			}
+			s = strings.TrimSpace(t)
+		}
+
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a number", s)
+		}
+
+		formatted := r.FloatString(scale)
+		digits := strings.TrimPrefix(formatted, "-")
+		if i := strings.IndexByte(digits, '.'); i >= 0 {
+			digits = digits[:i]
+		}
+		if len(strings.TrimLeft(digits, "0")) > precision-scale {
+			return nil, fmt.Errorf("%s does not fit in decimal(%d,%d)", s, precision, scale)
+		}
+
+		return formatted, nil
+	}
+}
+
+func toBool(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case int64:
+		return v != 0, nil
+	}
+
+	s, ok := text(value)
+	if !ok {
+		return nil, fmt.Errorf("could not cast %T to bool", value)
+	}
+
+	return strconv.ParseBool(strings.TrimSpace(s))
+}
+
+func toBytes(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+
+	return nil, fmt.Errorf("could not cast %T to bytes", value)
+}
+
+func toTime(value interface{}) (interface{}, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+
+	s, ok := text(value)
+	if !ok {
+		return nil, fmt.Errorf("could not cast %T to time", value)
+	}
+
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%q is not a time", s)
+}
+
+func toDate(value interface{}) (interface{}, error) {
+	t, err := toTime(value)
+	if err != nil {
+		return nil, err
+	}
+
+	y, m, d := t.(time.Time).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), nil
+}
@@ -0,0 +1,135 @@
+package cast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestCasters(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		cast     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"string", []byte("12.50"), "12.50"},
+		{"string", int64(3), "3"},
+		{"string", day.Add(time.Hour), "2021-03-04 01:00:00"},
+		{"int", []byte(" 42 "), int64(42)},
+		{"INTEGER", 2.0, int64(2)},
+		{"float", []byte("1.5"), 1.5},
+		{"decimal(12,2)", []byte("10.5"), "10.50"},
+		{"numeric(5, 1)", -3.14, "-3.1"},
+		{"decimal(4)", int64(1234), "1234"},
+		{"bool", []byte("1"), true},
+		{"boolean", int64(0), false},
+		{"bytes", "abc", []byte("abc")},
+		{"datetime", []byte("2021-03-04 01:00:00"), day.Add(time.Hour)},
+		{"date", "2021-03-04T10:11:12Z", day},
+	}
+
+	for _, test := range tests {
+		c, err := newCaster(test.cast)
+		require.NoError(t, err, test.cast)
+
+		value, err := c(test.value)
+		require.NoError(t, err, test.cast)
+		assert.Equal(t, test.expected, value, test.cast)
+	}
+}
+
+func TestCastersErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := newCaster("money")
+	assert.Error(t, err)
+	_, err = newCaster("decimal(2,3)")
+	assert.Error(t, err)
+
+	tests := []struct {
+		cast  string
+		value interface{}
+	}{
+		{"int", []byte("1.5")},
+		{"int", 1.5},
+		{"decimal(4,2)", []byte("123.4")},
+		{"decimal(4,2)", []byte("abc")},
+		{"bool", []byte("maybe")},
+		{"bytes", int64(1)},
+		{"datetime", []byte("yesterday")},
+	}
+	for _, test := range tests {
+		c, err := newCaster(test.cast)
+		require.NoError(t, err, test.cast)
+
+		_, err = c(test.value)
+		assert.Error(t, err, test.cast)
+	}
+}
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{{Name: "orders", Cast: map[string]string{"amount": "decimal(12,2)", "missing": "int"}}}
+	r, err := NewReader(&mockReader{values: []interface{}{[]byte("10"), nil}}, tables)
+	require.NoError(t, err)
+
+	rows, err := readAll(r, "orders")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "10.00", rows[0].Get("amount"))
+	assert.Nil(t, rows[1].Get("amount"))
+
+	r, err = NewReader(&mockReader{values: []interface{}{[]byte("abc")}}, tables)
+	require.NoError(t, err)
+	_, err = readAll(r, "orders")
+	assert.EqualError(t, err, `cast: column amount: "abc" is not a number`)
+
+	_, err = NewReader(&mockReader{}, config.Tables{{Name: "orders", Cast: map[string]string{"amount": "money"}}})
+	assert.Error(t, err)
+}
+
+func readAll(r reader.Reader, table string) ([]database.Row, error) {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable(table, rowChan, reader.ReadTableOpt{})
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+
+	return rows, <-errChan
+}
+
+// mockReader publishes a row per value in the amount column.
+type mockReader struct {
+	values []interface{}
+}
+
+func (m *mockReader) GetTables() ([]string, error)        { return []string{"orders"}, nil }
+func (m *mockReader) GetStructure() (string, error)       { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) { return []string{"amount"}, nil }
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return ""
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	columns := database.NewColumns([]string{"amount"})
+	for _, value := range m.values {
+		rowChan <- database.NewRow(columns, []interface{}{value})
+	}
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
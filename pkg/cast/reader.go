@@ -0,0 +1,100 @@
+package cast
+
+import (
+	"fmt"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type castReader struct {
+	reader.Reader
+	// casters are the column casters by table and column.
+	casters map[string]map[string]caster
+}
+
+// NewReader returns a reader casting the columns configured with Cast, it fails on unknown casts.
+func NewReader(source reader.Reader, tables config.Tables) (reader.Reader, error) {
+	casters := make(map[string]map[string]caster)
+	for _, table := range tables {
+		if len(table.Cast) == 0 {
+			continue
+		}
+
+		casters[table.Name] = make(map[string]caster, len(table.Cast))
+		for column, typeName := range table.Cast {
+			c, err := newCaster(typeName)
+			if err != nil {
+				return nil, fmt.Errorf("table %s column %s: %w", table.Name, column, err)
+			}
+			casters[table.Name][column] = c
+		}
+	}
+
+	if len(casters) == 0 {
+		return source, nil
+	}
+
+	return &castReader{Reader: source, casters: casters}, nil
+}
+
+// ReadTable casts the configured columns of the rows read, a value that can not be cast fails the table.
+func (r *castReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	casters, ok := r.casters[tableName]
+	if !ok {
+		return r.Reader.ReadTable(tableName, rowChan, opts)
+	}
+	defer close(rowChan)
+
+	rawChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.Reader.ReadTable(tableName, rawChan, opts)
+	}()
+
+	var castErr error
+	for row := range rawChan {
+		if castErr != nil {
+			continue
+		}
+
+		if err := castRow(row, casters); err != nil {
+			castErr = err
+			continue
+		}
+		rowChan <- row
+	}
+
+	if err := <-errChan; err != nil {
+		return err
+	}
+	if castErr != nil {
+		return fmt.Errorf("cast: %w", castErr)
+	}
+
+	return nil
+}
+
+func castRow(row database.Row, casters map[string]caster) error {
+	for column, c := range casters {
+		value, ok := row.Lookup(column)
+		if !ok {
+			continue
+		}
+		if p, ok := value.(*interface{}); ok && p != nil {
+			value = *p
+		}
+		if value == nil {
+			continue
+		}
+
+		cast, err := c(value)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column, err)
+		}
+		row.Set(column, cast)
+	}
+
+	return nil
+}
@@ -4,7 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	log "github.com/sirupsen/logrus"
@@ -14,6 +19,17 @@ import (
 // Config-related defaults
 const (
 	DefaultConfigFileName = ".klepto.toml"
+	// EnvConfig is the environment variable holding a JSON config merged over the config files,
+	// so that a run can be configured without mounting files, e.g. in a container.
+	EnvConfig = "KLEPTO_CONFIG_JSON"
+
+	// includeKey is the key listing the files included by a config file.
+	includeKey = "include"
+	// profilesKey is the key listing the profiles of a config file.
+	profilesKey = "profiles"
+
+	// hookFilePrefix prefixes the hooks that are paths to SQL script files.
+	hookFilePrefix = "file:"
 )
 
 type (
@@ -21,6 +37,52 @@ type (
 	spec struct {
 		Matchers
 		Tables
+		Hooks   *Hooks   `toml:",omitempty"`
+		Targets *Targets `toml:",omitempty"`
+
+		// profile is the selected profile, its tables, matchers and hooks are merged in the spec.
+		profile Profile
+	}
+
+	// Profile is a named steal of a config file, e.g. one per service, selected with --profile.
+	// Its tables, matchers and hooks are merged over the top level ones.
+	Profile struct {
+		Name string
+		// From is the database dsn to steal from, environment variables are expanded.
+		From string
+		// To is the database dsn to output to, environment variables are expanded.
+		To string
+		// Tables and Hooks are the top level ones merged with the ones of the profile.
+		Tables Tables
+		Hooks  Hooks
+	}
+
+	// Hooks are SQL statements run during a steal, each one is either a statement or
+	// a "file:" prefixed path to a SQL script file.
+	Hooks struct {
+		// BeforeRead are run on the source before the tables are read, e.g. to create temporary views.
+		BeforeRead []string `toml:",omitempty"`
+		// AfterLoad are run on the target once the tables are loaded, e.g. ANALYZE.
+		AfterLoad []string `toml:",omitempty"`
+	}
+
+	// Targets restrict the hosts the steals output to, e.g. so that no production database is ever written to.
+	// The patterns are matched against the target host like path.Match, e.g. "*.staging.example.com".
+	Targets struct {
+		// Allow are the patterns one of which the target host must match, any host is allowed when empty.
+		Allow []string `toml:",omitempty"`
+		// Deny are the patterns the target host must not match.
+		Deny []string `toml:",omitempty"`
+		// Assert are the queries run on the target database before anything is loaded, e.g. to check its environment.
+		Assert []Assertion `toml:",omitempty"`
+	}
+
+	// Assertion is a query run on the target database that must return the expected value.
+	Assertion struct {
+		// Query returns a single value, e.g. SELECT env FROM meta.
+		Query string
+		// Equals is the value the query must return.
+		Equals string
 	}
 
 	// Matchers are variables to store filter data,
@@ -34,14 +96,73 @@ type (
 	Table struct {
 		// Name is the table name.
 		Name string
+		// Shards is a pattern matching the shards of the table, e.g. events_% for events_0 to events_255, % matching
+		// any characters. The shards are read with the configuration of the table, a shard configured on its own
+		// keeps its configuration.
+		Shards string `toml:",omitempty"`
+		// MergeShards if set to true, the rows of the shards are dumped into a single table named Name,
+		// created like the first shard.
+		MergeShards bool `toml:",omitempty"`
 		// IgnoreData if set to true, it will dump the table structure without importing data.
 		IgnoreData bool
+		// SyntheticRows if set, the table data is never read and this amount of rows is generated
+		// from the column types and the Anonymise functions instead.
+		SyntheticRows uint64 `toml:",omitzero"`
+		// PreserveStats if set to true, anonymised columns keep the cardinality, null ratio and value frequencies
+		// of the source and synthetic rows follow the statistics sampled from the source columns.
+		PreserveStats bool `toml:",omitempty"`
+		// Materialize if set to true, the table is a view dumped as a table with the rows of its result set
+		// instead of its definition.
+		Materialize bool `toml:",omitempty"`
+		// Full if set to true, the whole table is dumped whatever its filter and the default limit, e.g. for lookup tables.
+		Full bool `toml:",omitempty"`
+		// Query is a SELECT returning the columns of the table, read instead of the table, e.g. to join or deduplicate.
+		// The filter still applies to its rows.
+		Query string `toml:",omitempty"`
 		// Filter represents the way you want to filter the results.
 		Filter Filter
+		// IgnoreColumns are the columns left out of the dump, their structure is kept.
+		IgnoreColumns []string `toml:",omitempty"`
+		// ChunkColumns are the columns holding large values, e.g. TEXT or BLOB, read in chunks when they are written
+		// instead of being held in memory.
+		ChunkColumns []string `toml:",omitempty"`
+		// ChunkSize is the length of the chunks the ChunkColumns are read in, 1MiB by default.
+		ChunkSize int64 `toml:",omitzero"`
+		// Drop is an expression, rows for which it is true are not dumped, e.g. row.country == "US" && row.opt_out.
+		Drop string `toml:",omitempty"`
+		// Transform sets columns to the result of an expression evaluated on the row read, before they are anonymised.
+		Transform map[string]string `toml:",omitempty"`
 		// Anonymise anonymises columns.
 		Anonymise map[string]string
+		// Pseudonymise are anonymised columns whose original values are always replaced by the same fake value, shared
+		// by the columns anonymised with the same anonymiser in every table and profile of the run, to keep them joining.
+		Pseudonymise []string `toml:",omitempty"`
+		// Cast forces the type of columns in the output, e.g. decimal(12,2) or string.
+		Cast map[string]string `toml:",omitempty"`
 		// Relationship is an collection of relationship definitions.
 		Relationships []*Relationship
+		// Model is the application model the table maps to, used by the Rails and Django fixture dumpers.
+		Model string `toml:",omitempty"`
+		// DistKey is the Redshift distribution key column, applied after the table is loaded.
+		DistKey string `toml:",omitempty"`
+		// SortKeys are the Redshift compound sort key columns, applied after the table is loaded.
+		SortKeys []string `toml:",omitempty"`
+		// Workers overrides the amount of workers anonymising the rows of the table.
+		Workers int `toml:",omitzero"`
+		// BatchSize overrides the amount of rows of the table written per batch by the dumpers writing in batches.
+		BatchSize int `toml:",omitzero"`
+		// PageSize if set, the table is read with one query per page of this amount of rows instead of a single query.
+		PageSize uint64 `toml:",omitzero"`
+		// NoPrimaryKey is how the table is read when it has no primary key to paginate it or to chunk its large
+		// values by: "scan" reads it with a single query, "ctid" in pages of physical positions (Postgres only) and
+		// "skip" does not dump its data.
+		NoPrimaryKey string `toml:",omitempty"`
+		// Timeout if set, the table stops being read after this duration and the run fails, e.g. "10m".
+		Timeout time.Duration `toml:",omitzero"`
+		// MinRows and MaxRows if set, the run fails when the amount of rows dumped of the table is outside them,
+		// given as an amount of rows, e.g. "100", or as a percentage of the source rows, e.g. "10%".
+		MinRows string `toml:",omitempty"`
+		MaxRows string `toml:",omitempty"`
 	}
 
 	// Filter represents the way you want to filter the results.
@@ -52,6 +173,9 @@ type (
 		Limit uint64
 		// Sorts is the sort condition for the table.
 		Sorts map[string]string
+		// Expression is evaluated on the rows read, only the rows for which it is true are dumped, e.g.
+		// row.country == "US". Unlike Match it does not depend on the query capabilities of the source.
+		Expression string `toml:",omitempty"`
 	}
 
 	// Relationship represents the relationship between the table and referenced table.
@@ -78,25 +202,242 @@ func (t Tables) FindByName(name string) *Table {
 	return nil
 }
 
+// ExpandShards returns the tables configuration with a copy of the configuration of the sharded tables for each of
+// their shards among the given tables. The configuration of a sharded table is only kept when its shards are merged,
+// for the output of the merged table.
+func (t Tables) ExpandShards(tables []string) Tables {
+	expanded := make(Tables, 0, len(t))
+	for _, table := range t {
+		if table.Shards == "" || table.MergeShards {
+			expanded = append(expanded, table)
+		}
+	}
+
+	for _, table := range t {
+		if table.Shards == "" {
+			continue
+		}
+
+		for _, name := range table.MatchShards(tables) {
+			if expanded.FindByName(name) != nil {
+				continue
+			}
+
+			shard := *table
+			shard.Name, shard.Shards, shard.MergeShards = name, "", false
+			expanded = append(expanded, &shard)
+		}
+	}
+
+	return expanded
+}
+
+// MatchShards returns the shards of the table among the given tables, in their order.
+func (t *Table) MatchShards(tables []string) []string {
+	if t.Shards == "" {
+		return nil
+	}
+
+	var shards []string
+	for _, name := range tables {
+		if matchShard(t.Shards, name) {
+			shards = append(shards, name)
+		}
+	}
+
+	return shards
+}
+
+// matchShard tells whether the name matches the shards pattern, % matching any characters.
+func matchShard(pattern string, name string) bool {
+	parts := strings.Split(pattern, "%")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+
+	return strings.HasSuffix(name, parts[len(parts)-1])
+}
+
 // LoadFromFile loads klepto tables config from file
 func LoadFromFile(configPath string) (Tables, error) {
-	if configPath == "" {
-		return nil, errors.New("config file path can not be empty")
+	return LoadFromFiles(configPath)
+}
+
+// LoadFromFiles loads klepto tables config from a base file and the overlay files that follow it.
+// Files can include other files with the Include key, see readSettings.
+// Each file is deep merged into the previous ones: tables are merged by name, nested tables such as
+// Filter and Anonymise are merged key by key and other values, lists included, are replaced.
+func LoadFromFiles(configPaths ...string) (Tables, error) {
+	return loadFromFiles(false, configPaths)
+}
+
+// LoadStrictFromFiles loads the config files like LoadFromFiles, failing on unknown keys.
+func LoadStrictFromFiles(configPaths ...string) (Tables, error) {
+	return loadFromFiles(true, configPaths)
+}
+
+// LoadHooksFromFiles loads the hooks of the config files merged like LoadFromFiles.
+// The script files of the hooks are read, relative to the working directory.
+func LoadHooksFromFiles(configPaths ...string) (Hooks, error) {
+	cfgSpec, err := loadSpec(false, "", configPaths)
+	if err != nil {
+		return Hooks{}, err
 	}
 
-	log.Debugf("Reading config from %s ...", configPath)
-	viper.SetConfigFile(configPath)
+	return loadHooks(cfgSpec)
+}
 
-	err := viper.ReadInConfig()
+// LoadTargetsFromFiles loads the target host restrictions of the config files merged like LoadFromFiles.
+func LoadTargetsFromFiles(configPaths ...string) (Targets, error) {
+	cfgSpec, err := loadSpec(false, "", configPaths)
 	if err != nil {
-		return nil, fmt.Errorf("could not read configurations: %w", err)
+		return Targets{}, err
+	}
+	if cfgSpec.Targets == nil {
+		return Targets{}, nil
 	}
 
-	cfgSpec := new(spec)
-	err = viper.Unmarshal(cfgSpec)
+	return *cfgSpec.Targets, nil
+}
+
+// Check returns an error when the host is denied or not allowed.
+func (t Targets) Check(host string) error {
+	host = strings.ToLower(host)
+	for _, pattern := range t.Deny {
+		matched, err := path.Match(strings.ToLower(pattern), host)
+		if err != nil {
+			return fmt.Errorf("invalid target pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return fmt.Errorf("the target host %s is denied by the pattern %q", host, pattern)
+		}
+	}
+
+	if len(t.Allow) == 0 {
+		return nil
+	}
+	for _, pattern := range t.Allow {
+		matched, err := path.Match(strings.ToLower(pattern), host)
+		if err != nil {
+			return fmt.Errorf("invalid target pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("the target host %s is not allowed, it matches none of the patterns %s", host, strings.Join(t.Allow, ", "))
+}
+
+// LoadProfileFromFiles loads the named profile of the config files merged like LoadFromFiles.
+// The profiles of the files are merged by name as well.
+func LoadProfileFromFiles(name string, configPaths ...string) (Profile, error) {
+	return loadProfile(false, name, configPaths)
+}
+
+// LoadStrictProfileFromFiles loads the profile like LoadProfileFromFiles, failing on unknown keys.
+func LoadStrictProfileFromFiles(name string, configPaths ...string) (Profile, error) {
+	return loadProfile(true, name, configPaths)
+}
+
+// LoadProfileNamesFromFiles returns the names of the profiles of the config files merged like LoadFromFiles.
+func LoadProfileNamesFromFiles(configPaths ...string) ([]string, error) {
+	settings, err := loadSettings(configPaths)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := profilesOf(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(profiles))
+	for i, profile := range profiles {
+		names[i] = fmt.Sprint(profile[findKey(profile, "name")])
+	}
+
+	return names, nil
+}
+
+func loadProfile(strict bool, name string, configPaths []string) (Profile, error) {
+	cfgSpec, err := loadSpec(strict, name, configPaths)
 	if err != nil {
+		return Profile{}, err
+	}
+
+	profile := cfgSpec.profile
+	profile.Tables = cfgSpec.Tables
+	if profile.Hooks, err = loadHooks(cfgSpec); err != nil {
+		return Profile{}, err
+	}
+
+	return profile, nil
+}
+
+// loadHooks reads the script files of the hooks of the spec.
+func loadHooks(cfgSpec *spec) (Hooks, error) {
+	var err error
+	hooks := Hooks{}
+	if cfgSpec.Hooks == nil {
+		return hooks, nil
+	}
+	if hooks.BeforeRead, err = readHooks(cfgSpec.Hooks.BeforeRead); err != nil {
+		return Hooks{}, err
+	}
+	if hooks.AfterLoad, err = readHooks(cfgSpec.Hooks.AfterLoad); err != nil {
+		return Hooks{}, err
+	}
+
+	return hooks, nil
+}
+
+func loadFromFiles(strict bool, configPaths []string) (Tables, error) {
+	cfgSpec, err := loadSpec(strict, "", configPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfgSpec.Tables, nil
+}
+
+// loadSpec merges the config files and the config of the environment, with the named profile when not empty.
+func loadSpec(strict bool, profileName string, configPaths []string) (*spec, error) {
+	settings, err := loadSettings(configPaths)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := applyProfile(settings, profileName)
+	if err != nil {
+		return nil, err
+	}
+	joinAnonymiserChains(settings)
+
+	v := viper.New()
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("could not merge configurations: %w", err)
+	}
+
+	cfgSpec := new(spec)
+	unmarshal := v.Unmarshal
+	if strict {
+		unmarshal = v.UnmarshalExact
+	}
+	if err := unmarshal(cfgSpec); err != nil {
 		return nil, fmt.Errorf("could not unmarshal config file: %w", err)
 	}
+	cfgSpec.profile = profile
 
 	// replace matchers aliases in tables with matchers expressions
 	for i, t := range cfgSpec.Tables {
@@ -116,7 +457,325 @@ func LoadFromFile(configPath string) (Tables, error) {
 		}
 	}
 
-	return cfgSpec.Tables, nil
+	return cfgSpec, nil
+}
+
+// loadSettings merges the settings of the config files and of the environment.
+func loadSettings(configPaths []string) (map[string]interface{}, error) {
+	if len(configPaths) == 0 {
+		return nil, errors.New("config file path can not be empty")
+	}
+
+	envConfig := os.Getenv(EnvConfig)
+	settings := make(map[string]interface{})
+	for _, configPath := range configPaths {
+		if configPath == "" {
+			return nil, errors.New("config file path can not be empty")
+		}
+
+		// the default config file is optional when the config is given by the environment
+		if envConfig != "" && configPath == DefaultConfigFileName {
+			if _, err := os.Stat(configPath); errors.Is(err, os.ErrNotExist) {
+				log.Debugf("%s does not exist, only the config of %s is used", configPath, EnvConfig)
+				continue
+			}
+		}
+
+		fileSettings, err := readSettings(configPath, nil)
+		if err != nil {
+			return nil, err
+		}
+		mergeSettings(settings, fileSettings)
+	}
+	if envConfig != "" {
+		envSettings, err := readEnvSettings(envConfig)
+		if err != nil {
+			return nil, err
+		}
+		mergeSettings(settings, envSettings)
+	}
+
+	return settings, nil
+}
+
+// readHooks replaces the script file paths of the hooks with the content of the files.
+func readHooks(hooks []string) ([]string, error) {
+	statements := make([]string, 0, len(hooks))
+	for _, hook := range hooks {
+		if !strings.HasPrefix(hook, hookFilePrefix) {
+			statements = append(statements, hook)
+			continue
+		}
+
+		path := strings.TrimSpace(strings.TrimPrefix(hook, hookFilePrefix))
+		script, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read hook script: %w", err)
+		}
+		statements = append(statements, string(script))
+	}
+
+	return statements, nil
+}
+
+// readSettings reads the settings of a config file, merged on top of the settings of the files it includes.
+// Included paths are glob patterns relative to the including file, the including files are given to detect cycles.
+func readSettings(configPath string, including []string) (map[string]interface{}, error) {
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve config file path: %w", err)
+	}
+	for _, p := range including {
+		if p == absPath {
+			return nil, fmt.Errorf("config file %s includes itself", configPath)
+		}
+	}
+
+	log.Debugf("Reading config from %s ...", configPath)
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("could not read configurations: %w", err)
+	}
+
+	fileSettings := v.AllSettings()
+	key := findKey(fileSettings, includeKey)
+	if key == "" {
+		return fileSettings, nil
+	}
+
+	patterns, ok := fileSettings[key].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config file %s: Include must be a list of paths", configPath)
+	}
+	delete(fileSettings, key)
+	including = append(including[:len(including):len(including)], absPath)
+
+	settings := make(map[string]interface{})
+	for _, pattern := range patterns {
+		p := fmt.Sprint(pattern)
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(absPath), p)
+		}
+
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: invalid include %q: %w", configPath, pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("config file %s: include %q matches no file", configPath, pattern)
+		}
+		sort.Strings(matches)
+
+		for _, match := range matches {
+			included, err := readSettings(match, including)
+			if err != nil {
+				return nil, err
+			}
+			mergeSettings(settings, included)
+		}
+	}
+	mergeSettings(settings, fileSettings)
+
+	return settings, nil
+}
+
+// readEnvSettings reads the settings of the JSON config given by the environment, it can not include files.
+func readEnvSettings(envConfig string) (map[string]interface{}, error) {
+	log.Debugf("Reading config from %s ...", EnvConfig)
+	v := viper.New()
+	v.SetConfigType("json")
+	if err := v.ReadConfig(strings.NewReader(envConfig)); err != nil {
+		return nil, fmt.Errorf("could not read the configuration of %s: %w", EnvConfig, err)
+	}
+
+	settings := v.AllSettings()
+	if findKey(settings, includeKey) != "" {
+		return nil, fmt.Errorf("the configuration of %s can not include files", EnvConfig)
+	}
+
+	return settings, nil
+}
+
+// applyProfile merges the settings of the named profile over the top level settings and removes the profiles,
+// the profiles that are not selected are not checked. The name and dsns of the profile are returned.
+func applyProfile(settings map[string]interface{}, name string) (Profile, error) {
+	profiles, err := profilesOf(settings)
+	if err != nil {
+		return Profile{}, err
+	}
+	delete(settings, findKey(settings, profilesKey))
+	if name == "" {
+		return Profile{}, nil
+	}
+
+	names := make([]string, 0, len(profiles))
+	for _, profileSettings := range profiles {
+		profileName := fmt.Sprint(profileSettings[findKey(profileSettings, "name")])
+		if profileName != name {
+			names = append(names, profileName)
+			continue
+		}
+
+		profile := Profile{Name: name}
+		overlay := make(map[string]interface{}, len(profileSettings))
+		for k, value := range profileSettings {
+			switch strings.ToLower(k) {
+			case "name":
+			case "from":
+				profile.From = os.ExpandEnv(fmt.Sprint(value))
+			case "to":
+				profile.To = os.ExpandEnv(fmt.Sprint(value))
+			default:
+				overlay[k] = value
+			}
+		}
+		mergeSettings(settings, overlay)
+
+		return profile, nil
+	}
+
+	if len(names) == 0 {
+		return Profile{}, fmt.Errorf("unknown profile %q, the config has no profiles", name)
+	}
+	return Profile{}, fmt.Errorf("unknown profile %q, the config has the profiles: %s", name, strings.Join(names, ", "))
+}
+
+// profilesOf returns the settings of the profiles.
+func profilesOf(settings map[string]interface{}) ([]map[string]interface{}, error) {
+	key := findKey(settings, profilesKey)
+	if key == "" {
+		return nil, nil
+	}
+	profiles, ok := toSettingsList(settings[key])
+	if !ok {
+		return nil, errors.New("the Profiles key must be a list of profiles")
+	}
+
+	return profiles, nil
+}
+
+// mergeSettings deep merges the overlay settings into the base ones.
+func mergeSettings(base map[string]interface{}, overlay map[string]interface{}) {
+	for key, value := range overlay {
+		baseKey := findKey(base, key)
+		if baseKey == "" {
+			base[key] = value
+			continue
+		}
+
+		baseMap, baseIsMap := toSettings(base[baseKey])
+		overlayMap, overlayIsMap := toSettings(value)
+		switch {
+		case baseIsMap && overlayIsMap:
+			mergeSettings(baseMap, overlayMap)
+			base[baseKey] = baseMap
+		case strings.EqualFold(key, "tables"), strings.EqualFold(key, profilesKey):
+			base[baseKey] = mergeByName(base[baseKey], value)
+		default:
+			base[baseKey] = value
+		}
+	}
+}
+
+// mergeByName merges the overlay tables or profiles into the base ones with the same name, others are appended.
+func mergeByName(base interface{}, overlay interface{}) interface{} {
+	baseTables, ok := toSettingsList(base)
+	if !ok {
+		return overlay
+	}
+	overlayTables, ok := toSettingsList(overlay)
+	if !ok {
+		return overlay
+	}
+
+	for _, table := range overlayTables {
+		name := table[findKey(table, "name")]
+		merged := false
+		for _, baseTable := range baseTables {
+			if nameKey := findKey(baseTable, "name"); nameKey != "" && baseTable[nameKey] == name {
+				mergeSettings(baseTable, table)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			baseTables = append(baseTables, table)
+		}
+	}
+
+	tables := make([]interface{}, len(baseTables))
+	for i, table := range baseTables {
+		tables[i] = table
+	}
+
+	return tables
+}
+
+// joinAnonymiserChains turns the anonymisers given as a list of steps into a chain, e.g. "Trim | Hash".
+func joinAnonymiserChains(settings map[string]interface{}) {
+	tables, ok := toSettingsList(settings[findKey(settings, "tables")])
+	if !ok {
+		return
+	}
+
+	for _, table := range tables {
+		anonymise, ok := toSettings(table[findKey(table, "anonymise")])
+		if !ok {
+			continue
+		}
+
+		for column, value := range anonymise {
+			steps, ok := value.([]interface{})
+			if !ok {
+				continue
+			}
+
+			chain := make([]string, len(steps))
+			for i, step := range steps {
+				chain[i] = fmt.Sprint(step)
+			}
+			anonymise[column] = strings.Join(chain, " | ")
+		}
+	}
+}
+
+// findKey returns the key of the settings matching the given key, the parsers do not keep the case of all keys.
+func findKey(settings map[string]interface{}, key string) string {
+	if _, ok := settings[key]; ok {
+		return key
+	}
+	for k := range settings {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+
+	return ""
+}
+
+func toSettings(value interface{}) (map[string]interface{}, bool) {
+	settings, ok := value.(map[string]interface{})
+	return settings, ok
+}
+
+func toSettingsList(value interface{}) ([]map[string]interface{}, bool) {
+	switch list := value.(type) {
+	case []map[string]interface{}:
+		return list, true
+	case []interface{}:
+		settings := make([]map[string]interface{}, len(list))
+		for i, item := range list {
+			s, ok := toSettings(item)
+			if !ok {
+				return nil, false
+			}
+			settings[i] = s
+		}
+		return settings, true
+	}
+
+	return nil, false
 }
 
 // WriteSample generates and writes sample config to a writer
@@ -0,0 +1,141 @@
+package coverage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// Statuses of a source column in the dump.
+const (
+	// Dumped columns are copied as they are read.
+	Dumped Status = "dumped"
+	// Anonymised columns are replaced by their Anonymise function.
+	Anonymised Status = "anonymised"
+	// Transformed columns are replaced by their Transform expression.
+	Transformed Status = "transformed"
+	// Generated columns belong to tables whose rows are synthetic.
+	Generated Status = "generated"
+	// Ignored columns are not dumped, either themselves or with their table data.
+	Ignored Status = "ignored"
+)
+
+type (
+	// Status is what happens to a source column in the dump.
+	Status string
+
+	// Column is the coverage of a source column.
+	Column struct {
+		Table  string `json:"table"`
+		Column string `json:"column"`
+		Status Status `json:"status"`
+		// Rule is the configuration the status comes from, e.g. "Anonymise: FirstName".
+		Rule string `json:"rule,omitempty"`
+		// PII is true when the column name matches a personal data pattern.
+		PII bool `json:"pii"`
+	}
+)
+
+// Report returns the coverage of every column of the source tables, in table and column order.
+// The columns are matched against the personal data patterns, see reader.CheckPII.
+func Report(source reader.Reader, tables config.Tables, patterns []*regexp.Regexp) ([]Column, error) {
+	sourceTables, err := source.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("could not get tables: %w", err)
+	}
+
+	var report []Column
+	for _, tableName := range sourceTables {
+		columns, err := source.GetColumns(tableName)
+		if err != nil {
+			return nil, fmt.Errorf("could not get columns of %s: %w", tableName, err)
+		}
+
+		table := tables.FindByName(tableName)
+		for _, column := range columns {
+			c := Column{Table: tableName, Column: column, Status: Dumped}
+			if table != nil {
+				c.Status, c.Rule = status(table, column)
+			}
+			for _, pattern := range patterns {
+				if pattern.MatchString(column) {
+					c.PII = true
+					break
+				}
+			}
+
+			report = append(report, c)
+		}
+	}
+
+	return report, nil
+}
+
+// status returns the status of a column and the rule it comes from, the rules being applied
+// in the order of the steal: the ignored data first, then the transforms and the anonymisers.
+func status(table *config.Table, column string) (Status, string) {
+	switch {
+	case table.IgnoreData:
+		return Ignored, "IgnoreData"
+	case contains(table.IgnoreColumns, column):
+		return Ignored, "IgnoreColumns"
+	}
+
+	anonymise, anonymised := table.Anonymise[column]
+	if table.SyntheticRows > 0 {
+		if anonymised {
+			return Generated, "Anonymise: " + anonymise
+		}
+		return Generated, "SyntheticRows"
+	}
+	if anonymised {
+		return Anonymised, "Anonymise: " + anonymise
+	}
+	if transform, ok := table.Transform[column]; ok {
+		return Transformed, "Transform: " + transform
+	}
+
+	return Dumped, ""
+}
+
+// WriteCSV writes the report as CSV, with a header line.
+func WriteCSV(w io.Writer, report []Column) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"table", "column", "status", "rule", "pii"}); err != nil {
+		return err
+	}
+	for _, c := range report {
+		if err := cw.Write([]string{c.Table, c.Column, string(c.Status), c.Rule, strconv.FormatBool(c.PII)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// WriteJSON writes the report as a JSON array.
+func WriteJSON(w io.Writer, report []Column) error {
+	if report == nil {
+		report = []Column{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
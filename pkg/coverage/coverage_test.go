@@ -0,0 +1,78 @@
+package coverage
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type mockReader struct {
+	reader.Reader
+}
+
+func (m *mockReader) GetTables() ([]string, error) {
+	return []string{"users", "logs", "fixtures"}, nil
+}
+
+func (m *mockReader) GetColumns(table string) ([]string, error) {
+	switch table {
+	case "users":
+		return []string{"id", "email", "name", "password", "notes"}, nil
+	case "fixtures":
+		return []string{"id", "email"}, nil
+	}
+	return []string{"id", "message"}, nil
+}
+
+func TestReport(t *testing.T) {
+	tables := config.Tables{
+		{
+			Name:          "users",
+			IgnoreColumns: []string{"password"},
+			Transform:     map[string]string{"name": "upper(name)", "email": "lower(email)"},
+			Anonymise:     map[string]string{"email": "EmailAddress"},
+		},
+		{Name: "logs", IgnoreData: true},
+		{Name: "fixtures", SyntheticRows: 10, Anonymise: map[string]string{"email": "EmailAddress"}},
+	}
+	patterns := []*regexp.Regexp{regexp.MustCompile("(?i)e_?mail"), regexp.MustCompile("(?i)password")}
+
+	report, err := Report(&mockReader{}, tables, patterns)
+	require.NoError(t, err)
+	assert.Equal(t, []Column{
+		{Table: "users", Column: "id", Status: Dumped},
+		{Table: "users", Column: "email", Status: Anonymised, Rule: "Anonymise: EmailAddress", PII: true},
+		{Table: "users", Column: "name", Status: Transformed, Rule: "Transform: upper(name)"},
+		{Table: "users", Column: "password", Status: Ignored, Rule: "IgnoreColumns", PII: true},
+		{Table: "users", Column: "notes", Status: Dumped},
+		{Table: "logs", Column: "id", Status: Ignored, Rule: "IgnoreData"},
+		{Table: "logs", Column: "message", Status: Ignored, Rule: "IgnoreData"},
+		{Table: "fixtures", Column: "id", Status: Generated, Rule: "SyntheticRows"},
+		{Table: "fixtures", Column: "email", Status: Generated, Rule: "Anonymise: EmailAddress", PII: true},
+	}, report)
+}
+
+func TestWrite(t *testing.T) {
+	report := []Column{
+		{Table: "users", Column: "id", Status: Dumped},
+		{Table: "users", Column: "name", Status: Transformed, Rule: `when(name == "", "n/a", name)`},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, WriteCSV(&b, report))
+	assert.Equal(t, "table,column,status,rule,pii\nusers,id,dumped,,false\nusers,name,transformed,\"when(name == \"\"\"\", \"\"n/a\"\", name)\",false\n", b.String())
+
+	b.Reset()
+	require.NoError(t, WriteJSON(&b, nil))
+	assert.Equal(t, "[]\n", b.String())
+
+	b.Reset()
+	require.NoError(t, WriteJSON(&b, report[:1]))
+	assert.JSONEq(t, `[{"table": "users", "column": "id", "status": "dumped", "pii": false}]`, b.String())
+}
@@ -1,6 +1,137 @@
 package database
 
 type (
+	// Columns is an ordered list of column names with a lookup index.
+	// It is created once per table and shared by all the rows read from it.
+	Columns struct {
+		names []string
+		index map[string]int
+		// types are the database type names of the columns, e.g. JSON, nil when unknown.
+		types []string
+	}
+
 	// Row is the database column row.
-	Row map[string]interface{}
+	// Values are stored in the order of the row columns.
+	Row struct {
+		columns *Columns
+		values  []interface{}
+	}
 )
+
+// NewColumns creates the column index for the given column names.
+func NewColumns(names []string) *Columns {
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+
+	return &Columns{names: names, index: index}
+}
+
+// NewTypedColumns creates the column index for the given column names and their database type names.
+func NewTypedColumns(names []string, types []string) *Columns {
+	c := NewColumns(names)
+	c.types = types
+
+	return c
+}
+
+// Names returns the column names in order.
+func (c *Columns) Names() []string {
+	return c.names
+}
+
+// Index returns the position of a column.
+func (c *Columns) Index(name string) (int, bool) {
+	i, ok := c.index[name]
+	return i, ok
+}
+
+// Len returns the amount of columns.
+func (c *Columns) Len() int {
+	return len(c.names)
+}
+
+// Type returns the database type name of a column, e.g. JSON, empty when unknown.
+func (c *Columns) Type(name string) string {
+	i, ok := c.index[name]
+	if !ok || i >= len(c.types) {
+		return ""
+	}
+
+	return c.types[i]
+}
+
+// NewRow creates a row holding the values for the given columns.
+// The values slice is used as is and must have one value per column.
+func NewRow(columns *Columns, values []interface{}) Row {
+	return Row{columns: columns, values: values}
+}
+
+// Columns returns the row column names in order.
+func (r Row) Columns() []string {
+	if r.columns == nil {
+		return nil
+	}
+
+	return r.columns.names
+}
+
+// ColumnIndex returns the columns of the row, shared by the rows of the same table.
+func (r Row) ColumnIndex() *Columns {
+	return r.columns
+}
+
+// ColumnType returns the database type name of a column, e.g. JSON, empty when unknown.
+func (r Row) ColumnType(column string) string {
+	if r.columns == nil {
+		return ""
+	}
+
+	return r.columns.Type(column)
+}
+
+// Values returns the row values in column order.
+func (r Row) Values() []interface{} {
+	return r.values
+}
+
+// Len returns the amount of columns of the row.
+func (r Row) Len() int {
+	return len(r.values)
+}
+
+// Lookup returns the value of a column and whether the row has this column.
+func (r Row) Lookup(column string) (interface{}, bool) {
+	if r.columns == nil {
+		return nil, false
+	}
+
+	i, ok := r.columns.index[column]
+	if !ok {
+		return nil, false
+	}
+
+	return r.values[i], true
+}
+
+// Get returns the value of a column, nil if the row does not have this column.
+func (r Row) Get(column string) interface{} {
+	v, _ := r.Lookup(column)
+	return v
+}
+
+// Set replaces the value of a column, it returns false if the row does not have this column.
+func (r Row) Set(column string, value interface{}) bool {
+	if r.columns == nil {
+		return false
+	}
+
+	i, ok := r.columns.index[column]
+	if !ok {
+		return false
+	}
+
+	r.values[i] = value
+	return true
+}
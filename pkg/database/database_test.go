@@ -0,0 +1,45 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRow(t *testing.T) {
+	columns := NewColumns([]string{"id", "name"})
+	row := NewRow(columns, []interface{}{int64(1), "foo"})
+
+	assert.Equal(t, []string{"id", "name"}, row.Columns())
+	assert.Equal(t, 2, row.Len())
+	assert.Equal(t, int64(1), row.Get("id"))
+
+	v, ok := row.Lookup("name")
+	assert.True(t, ok)
+	assert.Equal(t, "foo", v)
+
+	_, ok = row.Lookup("email")
+	assert.False(t, ok)
+	assert.Nil(t, row.Get("email"))
+
+	assert.True(t, row.Set("name", "bar"))
+	assert.Equal(t, []interface{}{int64(1), "bar"}, row.Values())
+	assert.False(t, row.Set("email", "foo@example.test"))
+}
+
+func TestColumnType(t *testing.T) {
+	row := NewRow(NewTypedColumns([]string{"id", "data"}, []string{"INT", "JSON"}), []interface{}{int64(1), nil})
+
+	assert.Equal(t, "JSON", row.ColumnType("data"))
+	assert.Equal(t, "", row.ColumnType("email"))
+	assert.Equal(t, "", NewRow(NewColumns([]string{"id"}), []interface{}{int64(1)}).ColumnType("id"))
+}
+
+func TestZeroRow(t *testing.T) {
+	var row Row
+
+	assert.Nil(t, row.Columns())
+	assert.Nil(t, row.Get("id"))
+	assert.False(t, row.Set("id", 1))
+	assert.Equal(t, "", row.ColumnType("id"))
+}
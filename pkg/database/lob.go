@@ -0,0 +1,109 @@
+package database
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"io"
+)
+
+// DefaultChunkSize is the default length of the chunks large values are read in.
+const DefaultChunkSize = 1 << 20
+
+type (
+	// LOB is a large value that is read in chunks when it is written instead of being held in memory.
+	LOB struct {
+		// Size is the length of the value, in characters for text and in bytes for binary values.
+		Size int64
+		// Binary is true for binary values, e.g. BLOB or bytea columns.
+		Binary bool
+		// chunkSize is the length of the chunks read at once.
+		chunkSize int64
+		// read reads the part of the value starting at the 1 based offset.
+		read func(offset int64, length int64) ([]byte, error)
+	}
+)
+
+// NewLOB returns a large value read with the given function in chunks of chunkSize.
+func NewLOB(size int64, binary bool, chunkSize int64, read func(offset int64, length int64) ([]byte, error)) *LOB {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	return &LOB{Size: size, Binary: binary, chunkSize: chunkSize, read: read}
+}
+
+// Chunks reads the value chunk by chunk, passing each chunk to fn.
+func (l *LOB) Chunks(fn func(chunk []byte) error) error {
+	for offset := int64(1); offset <= l.Size; offset += l.chunkSize {
+		chunk, err := l.read(offset, l.chunkSize)
+		if err != nil {
+			return fmt.Errorf("could not read large value at %d: %w", offset, err)
+		}
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteTo writes the value to w chunk by chunk.
+func (l *LOB) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	err := l.Chunks(func(chunk []byte) error {
+		n, err := w.Write(chunk)
+		written += int64(n)
+		return err
+	})
+
+	return written, err
+}
+
+// Bytes reads the whole value.
+func (l *LOB) Bytes() ([]byte, error) {
+	var b bytes.Buffer
+	if _, err := l.WriteTo(&b); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Value reads the whole value, so that it can be written with database/sql.
+func (l *LOB) Value() (driver.Value, error) {
+	return l.Bytes()
+}
+
+// ReadLOBs replaces the large values of the row with their whole value.
+func ReadLOBs(row Row) error {
+	for i, value := range row.values {
+		lob, ok := value.(*LOB)
+		if !ok {
+			continue
+		}
+
+		b, err := lob.Bytes()
+		if err != nil {
+			return err
+		}
+		if lob.Binary {
+			row.values[i] = b
+		} else {
+			row.values[i] = string(b)
+		}
+	}
+
+	return nil
+}
+
+// HasLOB reports whether the row holds large values.
+func (r Row) HasLOB() bool {
+	for _, value := range r.values {
+		if _, ok := value.(*LOB); ok {
+			return true
+		}
+	}
+
+	return false
+}
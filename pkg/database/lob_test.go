@@ -0,0 +1,62 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLOB(value string, binary bool, chunkSize int64) *LOB {
+	return NewLOB(int64(len(value)), binary, chunkSize, func(offset int64, length int64) ([]byte, error) {
+		end := offset - 1 + length
+		if end > int64(len(value)) {
+			end = int64(len(value))
+		}
+		return []byte(value[offset-1 : end]), nil
+	})
+}
+
+func TestLOBChunks(t *testing.T) {
+	var chunks []string
+	err := newTestLOB("abcdefg", false, 3).Chunks(func(chunk []byte) error {
+		chunks = append(chunks, string(chunk))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc", "def", "g"}, chunks)
+
+	var b bytes.Buffer
+	n, err := newTestLOB("abcdefg", false, 2).WriteTo(&b)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+	assert.Equal(t, "abcdefg", b.String())
+
+	empty, err := newTestLOB("", false, 0).Bytes()
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestLOBReadError(t *testing.T) {
+	lob := NewLOB(10, false, 4, func(offset int64, length int64) ([]byte, error) {
+		return nil, errors.New("connection lost")
+	})
+
+	_, err := lob.Bytes()
+	assert.EqualError(t, err, "could not read large value at 1: connection lost")
+}
+
+func TestReadLOBs(t *testing.T) {
+	row := NewRow(NewColumns([]string{"id", "body", "data"}), []interface{}{
+		int64(1),
+		newTestLOB("some text", false, 4),
+		newTestLOB("\x00\x01", true, 4),
+	})
+	assert.True(t, row.HasLOB())
+
+	require.NoError(t, ReadLOBs(row))
+	assert.False(t, row.HasLOB())
+	assert.Equal(t, []interface{}{int64(1), "some text", []byte{0, 1}}, row.Values())
+}
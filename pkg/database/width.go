@@ -0,0 +1,70 @@
+package database
+
+// BatchSizer sizes the batches of rows of a table to a byte budget from the average width of the rows seen so far,
+// so that narrow tables are read or written in large batches and wide ones, e.g. holding blobs, in small ones.
+type BatchSizer struct {
+	budget   int64
+	fallback uint64
+	rows     int64
+	bytes    int64
+}
+
+// NewBatchSizer returns a sizer of batches of budget bytes, fallback being the size of the batches until a row is
+// seen. The batches are always of fallback rows when the budget is 0.
+func NewBatchSizer(budget int64, fallback uint64) *BatchSizer {
+	return &BatchSizer{budget: budget, fallback: fallback}
+}
+
+// Observe adds a row to the average width.
+func (s *BatchSizer) Observe(row Row) {
+	s.rows++
+	s.bytes += row.Width()
+}
+
+// Size returns the amount of rows of the next batch, at least 1.
+func (s *BatchSizer) Size() uint64 {
+	if s.budget <= 0 || s.rows == 0 {
+		return s.fallback
+	}
+
+	width := s.bytes / s.rows
+	if width < 1 {
+		width = 1
+	}
+	if size := s.budget / width; size > 1 {
+		return uint64(size)
+	}
+
+	return 1
+}
+
+// Width estimates the size in bytes of the row values, large values counting for their whole size.
+func (r Row) Width() int64 {
+	var width int64
+	for _, v := range r.values {
+		width += valueWidth(v)
+	}
+
+	return width
+}
+
+func valueWidth(v interface{}) int64 {
+	switch value := v.(type) {
+	case nil:
+		return 0
+	case *interface{}:
+		if value == nil {
+			return 0
+		}
+		return valueWidth(*value)
+	case string:
+		return int64(len(value))
+	case []byte:
+		return int64(len(value))
+	case *LOB:
+		return value.Size
+	}
+
+	// numbers and times
+	return 8
+}
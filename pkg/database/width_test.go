@@ -0,0 +1,34 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRowWidth(t *testing.T) {
+	var text interface{} = "bar"
+	row := NewRow(NewColumns([]string{"id", "name", "data", "photo", "deleted_at"}), []interface{}{
+		int64(1), &text, []byte("foobar"), NewLOB(1000, true, 0, nil), nil,
+	})
+
+	assert.Equal(t, int64(8+3+6+1000), row.Width())
+}
+
+func TestBatchSizer(t *testing.T) {
+	columns := NewColumns([]string{"data"})
+
+	s := NewBatchSizer(1000, 50)
+	assert.Equal(t, uint64(50), s.Size(), "the fallback is used until a row is seen")
+
+	s.Observe(NewRow(columns, []interface{}{"0123456789"}))
+	s.Observe(NewRow(columns, []interface{}{"0123456789012345678901234567890123456789"}))
+	assert.Equal(t, uint64(40), s.Size())
+
+	s.Observe(NewRow(columns, []interface{}{string(make([]byte, 5000))}))
+	assert.Equal(t, uint64(1), s.Size(), "batches hold at least a row")
+
+	s = NewBatchSizer(0, 50)
+	s.Observe(NewRow(columns, []interface{}{"0123456789"}))
+	assert.Equal(t, uint64(50), s.Size(), "the fallback is used without budget")
+}
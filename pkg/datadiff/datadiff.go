@@ -0,0 +1,487 @@
+package datadiff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// DefaultChunkSize is the amount of primary keys compared per checksum by default.
+const DefaultChunkSize = 1000
+
+// Changes of the data of a table between two databases.
+const (
+	// Same is a table with the same rows in both databases.
+	Same Change = "same"
+	// Changed is a table whose rows differ.
+	Changed Change = "changed"
+	// Added is a table only in the second database.
+	Added Change = "added"
+	// Removed is a table only in the first database.
+	Removed Change = "removed"
+	// Skipped is a table that could not be compared, e.g. without primary key.
+	Skipped Change = "skipped"
+)
+
+type (
+	// Change is the way the data of a table differs between two databases.
+	Change string
+
+	// Options are the options of a data comparison.
+	Options struct {
+		// Tables are the tables compared, all the tables of both databases when empty.
+		Tables []string
+		// ChunkSize is the amount of primary keys compared per checksum.
+		ChunkSize int
+	}
+
+	// Diff is the difference between the data of two databases.
+	Diff struct {
+		Tables []TableDiff `json:"tables"`
+	}
+
+	// TableDiff is the difference between the rows of a table of two databases.
+	TableDiff struct {
+		Name     string `json:"name"`
+		Change   Change `json:"change"`
+		FromRows uint64 `json:"from_rows"`
+		ToRows   uint64 `json:"to_rows"`
+		// Ranges are the primary key ranges whose rows differ.
+		Ranges []Range `json:"ranges,omitempty"`
+		// Reason tells why a skipped table could not be compared.
+		Reason string `json:"reason,omitempty"`
+	}
+
+	// Range is a range of primary keys whose rows differ, with the amount of rows of each database in it.
+	Range struct {
+		First    string `json:"first"`
+		Last     string `json:"last"`
+		FromRows uint64 `json:"from_rows"`
+		ToRows   uint64 `json:"to_rows"`
+	}
+
+	// stream reads the rows of a table in primary key order.
+	stream struct {
+		name    string
+		key     []string
+		rows    chan database.Row
+		errChan chan error
+		row     database.Row
+		current []string
+		count   uint64
+		// done is set once all the rows are read.
+		done bool
+	}
+
+	// chunk is the checksum of the rows of both databases for a range of primary keys.
+	chunk struct {
+		first, last []string
+		keys        int
+		from, to    hash.Hash
+		fromRows    uint64
+		toRows      uint64
+	}
+)
+
+// Compare compares the rows of the tables of two databases, in the order of the tables of from followed by the
+// ones only in to. The rows are read in primary key order and compared by chunks of keys, only the checksums of a
+// chunk being kept in memory.
+func Compare(from, to reader.Reader, opts Options) (*Diff, error) {
+	if opts.ChunkSize < 1 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+
+	fromTables, err := from.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the tables to compare from: %w", err)
+	}
+	toTables, err := to.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the tables to compare to: %w", err)
+	}
+
+	inFrom, inTo := toSet(fromTables), toSet(toTables)
+	names := opts.Tables
+	if len(names) == 0 {
+		names = fromTables
+		for _, table := range toTables {
+			if !inFrom[table] {
+				names = append(names, table)
+			}
+		}
+	}
+
+	diff := &Diff{Tables: []TableDiff{}}
+	for _, table := range names {
+		switch {
+		case !inFrom[table] && !inTo[table]:
+			return nil, fmt.Errorf("table %s does not exist in either database", table)
+		case !inTo[table]:
+			diff.Tables = append(diff.Tables, TableDiff{Name: table, Change: Removed})
+		case !inFrom[table]:
+			diff.Tables = append(diff.Tables, TableDiff{Name: table, Change: Added})
+		default:
+			log.WithField("table", table).Debug("comparing the table data")
+			d, err := CompareTable(from, to, table, opts.ChunkSize)
+			if err != nil {
+				return nil, err
+			}
+			diff.Tables = append(diff.Tables, d)
+		}
+	}
+
+	return diff, nil
+}
+
+// CompareTable compares the rows of a table in both databases by chunks of chunkSize primary keys.
+// The columns only in one of the databases are left out of the comparison.
+func CompareTable(from, to reader.Reader, table string, chunkSize int) (TableDiff, error) {
+	d := TableDiff{Name: table, Change: Same}
+
+	key, err := primaryKey(from, table)
+	if err == nil && len(key) == 0 {
+		key, err = primaryKey(to, table)
+	}
+	if err != nil {
+		return d, err
+	}
+	if len(key) == 0 {
+		d.Change, d.Reason = Skipped, "no primary key"
+		return d, nil
+	}
+
+	columns, err := commonColumns(from, to, table)
+	if err != nil {
+		return d, err
+	}
+	for _, column := range key {
+		if !contains(columns, column) {
+			d.Change, d.Reason = Skipped, fmt.Sprintf("primary key column %s is not in both databases", column)
+			return d, nil
+		}
+	}
+
+	fromRows, toRows := readStream(from, "from", table, key), readStream(to, "to", table, key)
+	err = compareStreams(&d, fromRows, toRows, columns, chunkSize)
+	// the readers are always waited for, so that none is left publishing rows
+	if closeErr := fromRows.close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := toRows.close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return d, fmt.Errorf("could not compare %s: %w", table, err)
+	}
+
+	d.FromRows, d.ToRows = fromRows.count, toRows.count
+	if len(d.Ranges) > 0 {
+		d.Change = Changed
+	}
+
+	return d, nil
+}
+
+// Differing returns the amount of tables whose data differ, the skipped tables are not counted.
+func (d *Diff) Differing() int {
+	count := 0
+	for _, table := range d.Tables {
+		if table.Change != Same && table.Change != Skipped {
+			count++
+		}
+	}
+
+	return count
+}
+
+// WriteText writes the difference in a human readable form, one line per table prefixed by = for the same data,
+// - for tables only in from, + for tables only in to, ! for skipped tables and ~ for differing data, followed by
+// one line per differing primary key range.
+func (d *Diff) WriteText(w io.Writer) error {
+	for _, table := range d.Tables {
+		var lines []string
+		switch table.Change {
+		case Same:
+			lines = append(lines, fmt.Sprintf("= table %s %d rows", table.Name, table.FromRows))
+		case Removed:
+			lines = append(lines, fmt.Sprintf("- table %s", table.Name))
+		case Added:
+			lines = append(lines, fmt.Sprintf("+ table %s", table.Name))
+		case Skipped:
+			lines = append(lines, fmt.Sprintf("! table %s skipped: %s", table.Name, table.Reason))
+		default:
+			lines = append(lines, fmt.Sprintf("~ table %s %d -> %d rows", table.Name, table.FromRows, table.ToRows))
+			for _, r := range table.Ranges {
+				lines = append(lines, fmt.Sprintf("~ rows %s %s..%s %d -> %d rows", table.Name, r.First, r.Last, r.FromRows, r.ToRows))
+			}
+		}
+
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// compareStreams merges the rows of both databases in primary key order, adding the ranges of the chunks
+// whose checksums differ to the table difference.
+func compareStreams(d *TableDiff, from, to *stream, columns []string, chunkSize int) error {
+	if err := from.next(); err != nil {
+		return err
+	}
+	if err := to.next(); err != nil {
+		return err
+	}
+
+	c := newChunk()
+	// adjacent differing chunks are reported as one range
+	differed := false
+	flush := func() {
+		if c.keys == 0 {
+			return
+		}
+		if bytes.Equal(c.from.Sum(nil), c.to.Sum(nil)) {
+			differed = false
+		} else if n := len(d.Ranges); differed && n > 0 {
+			last := &d.Ranges[n-1]
+			last.Last, last.FromRows, last.ToRows = formatKey(c.last), last.FromRows+c.fromRows, last.ToRows+c.toRows
+		} else {
+			d.Ranges = append(d.Ranges, Range{First: formatKey(c.first), Last: formatKey(c.last), FromRows: c.fromRows, ToRows: c.toRows})
+			differed = true
+		}
+		c = newChunk()
+	}
+
+	for !from.done || !to.done {
+		cmp := 0
+		switch {
+		case to.done:
+			cmp = -1
+		case from.done:
+			cmp = 1
+		default:
+			cmp = compareKeys(from.current, to.current)
+		}
+
+		if cmp <= 0 {
+			c.add(from.current, c.from, from.row, columns)
+			c.fromRows++
+			if err := from.next(); err != nil {
+				return err
+			}
+		}
+		if cmp >= 0 {
+			c.add(to.current, c.to, to.row, columns)
+			c.toRows++
+			if err := to.next(); err != nil {
+				return err
+			}
+		}
+
+		if c.keys++; c.keys >= chunkSize {
+			flush()
+		}
+	}
+	flush()
+
+	return nil
+}
+
+func newChunk() *chunk {
+	return &chunk{from: fnv.New128a(), to: fnv.New128a()}
+}
+
+// add adds a row to the checksum of one of the databases.
+func (c *chunk) add(key []string, h hash.Hash, row database.Row, columns []string) {
+	if c.first == nil {
+		c.first = key
+	}
+	c.last = key
+
+	for _, column := range columns {
+		value, ok := row.Lookup(column)
+		if s, notNull := format(value); ok && notNull {
+			h.Write([]byte{1})
+			h.Write([]byte(s))
+		}
+		h.Write([]byte{0})
+	}
+}
+
+func readStream(r reader.Reader, name string, table string, key []string) *stream {
+	s := &stream{name: name, key: key, rows: make(chan database.Row), errChan: make(chan error, 1)}
+	go func() {
+		s.errChan <- r.ReadTable(table, s.rows, reader.ReadTableOpt{KeyOrder: true})
+	}()
+
+	return s
+}
+
+// next reads the next row, setting done once all of them are read.
+func (s *stream) next() error {
+	previous := s.current
+	row, ok := <-s.rows
+	if !ok {
+		s.done = true
+		return nil
+	}
+
+	s.row, s.count = row, s.count+1
+	s.current = make([]string, len(s.key))
+	for i, column := range s.key {
+		value, _ := row.Lookup(column)
+		s.current[i], _ = format(value)
+	}
+	if previous != nil && compareKeys(previous, s.current) >= 0 {
+		return fmt.Errorf("the rows of the %s database are not in primary key order, %s is read after %s", s.name, formatKey(s.current), formatKey(previous))
+	}
+
+	return nil
+}
+
+// close drains the rows left and returns the error of the reader.
+func (s *stream) close() error {
+	for range s.rows {
+	}
+
+	return <-s.errChan
+}
+
+// compareKeys compares two primary keys column by column, as numbers when both values are numbers.
+func compareKeys(a, b []string) int {
+	for i := range a {
+		if cmp := compareValues(a[i], b[i]); cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
+
+func compareValues(a, b string) int {
+	if x, err := strconv.ParseInt(a, 10, 64); err == nil {
+		if y, err := strconv.ParseInt(b, 10, 64); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		if y, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	return strings.Compare(a, b)
+}
+
+// format returns the text of a column value, false for NULL values.
+func format(value interface{}) (string, bool) {
+	if p, ok := value.(*interface{}); ok && p != nil {
+		value = *p
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), true
+	}
+
+	return fmt.Sprint(value), true
+}
+
+func formatKey(key []string) string {
+	if len(key) == 1 {
+		return key[0]
+	}
+
+	return "(" + strings.Join(key, ", ") + ")"
+}
+
+// primaryKey returns the primary key of a table, none when the reader does not know it.
+func primaryKey(r reader.Reader, table string) ([]string, error) {
+	keyer, ok := r.(reader.PrimaryKeyer)
+	if !ok {
+		return nil, nil
+	}
+
+	key, err := keyer.GetPrimaryKey(table)
+	if errors.Is(err, reader.ErrPrimaryKeysUnsupported) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get the primary key of %s: %w", table, err)
+	}
+
+	return key, nil
+}
+
+// commonColumns returns the columns of a table in both databases, in the order of from.
+func commonColumns(from, to reader.Reader, table string) ([]string, error) {
+	fromColumns, err := from.GetColumns(table)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the columns of %s: %w", table, err)
+	}
+	toColumns, err := to.GetColumns(table)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the columns of %s: %w", table, err)
+	}
+
+	inTo := toSet(toColumns)
+	columns := make([]string, 0, len(fromColumns))
+	for _, column := range fromColumns {
+		if inTo[column] {
+			columns = append(columns, column)
+		}
+	}
+
+	return columns, nil
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+
+	return set
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
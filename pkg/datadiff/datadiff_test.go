@@ -0,0 +1,116 @@
+package datadiff
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestCompare(t *testing.T) {
+	from := &mockReader{keys: map[string][]string{"users": {"id"}}, tables: map[string][][]interface{}{
+		"users":  users(1, 10),
+		"logs":   {{"1", "boot"}},
+		"orders": {{"1", "x"}},
+	}}
+	toUsers := users(1, 10)
+	// row 4 changed, rows 7 and 8 are missing
+	toUsers[3][1] = "changed@example.com"
+	toUsers = append(toUsers[:6], toUsers[8:]...)
+	to := &mockReader{tables: map[string][][]interface{}{
+		"users":  toUsers,
+		"logs":   {{"1", "boot"}},
+		"events": {{"1", "x"}},
+	}}
+
+	diff, err := Compare(from, to, Options{ChunkSize: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []TableDiff{
+		{Name: "logs", Change: Skipped, Reason: "no primary key"},
+		{Name: "orders", Change: Removed},
+		{Name: "users", Change: Changed, FromRows: 10, ToRows: 8, Ranges: []Range{
+			{First: "4", Last: "9", FromRows: 6, ToRows: 4},
+		}},
+		{Name: "events", Change: Added},
+	}, diff.Tables)
+	assert.Equal(t, 3, diff.Differing())
+
+	var text bytes.Buffer
+	require.NoError(t, diff.WriteText(&text))
+	assert.Equal(t, `! table logs skipped: no primary key
+- table orders
+~ table users 10 -> 8 rows
+~ rows users 4..9 6 -> 4 rows
++ table events
+`, text.String())
+
+	diff, err = Compare(from, from, Options{Tables: []string{"users"}})
+	require.NoError(t, err)
+	assert.Equal(t, []TableDiff{{Name: "users", Change: Same, FromRows: 10, ToRows: 10}}, diff.Tables)
+	assert.Zero(t, diff.Differing())
+
+	_, err = Compare(from, to, Options{Tables: []string{"payments"}})
+	assert.EqualError(t, err, "table payments does not exist in either database")
+}
+
+func TestCompareUnordered(t *testing.T) {
+	from := &mockReader{keys: map[string][]string{"users": {"id"}}, tables: map[string][][]interface{}{"users": users(1, 3)}}
+	to := &mockReader{tables: map[string][][]interface{}{"users": {{"2", "a"}, {"1", "b"}}}}
+
+	_, err := CompareTable(from, to, "users", 10)
+	assert.EqualError(t, err, "could not compare users: the rows of the to database are not in primary key order, 1 is read after 2")
+}
+
+func TestCompareKeys(t *testing.T) {
+	assert.Equal(t, -1, compareKeys([]string{"9"}, []string{"10"}), "numbers are compared as numbers")
+	assert.Equal(t, 1, compareKeys([]string{"b"}, []string{"a"}))
+	assert.Equal(t, -1, compareKeys([]string{"1", "b"}, []string{"1", "c"}))
+	assert.Equal(t, 0, compareKeys([]string{"1.5"}, []string{"1.50"}))
+	assert.Equal(t, "(1, b)", formatKey([]string{"1", "b"}))
+}
+
+func users(first, last int) [][]interface{} {
+	var rows [][]interface{}
+	for id := first; id <= last; id++ {
+		rows = append(rows, []interface{}{[]byte(strconv.Itoa(id)), "user@example.com"})
+	}
+	return rows
+}
+
+type mockReader struct {
+	keys   map[string][]string
+	tables map[string][][]interface{}
+}
+
+func (m *mockReader) GetStructure() (string, error) { return "", nil }
+func (m *mockReader) GetTables() ([]string, error) {
+	var tables []string
+	for _, name := range []string{"logs", "orders", "users", "events"} {
+		if _, ok := m.tables[name]; ok {
+			tables = append(tables, name)
+		}
+	}
+	return tables, nil
+}
+func (m *mockReader) GetColumns(string) ([]string, error) { return []string{"id", "email"}, nil }
+func (m *mockReader) GetPrimaryKey(table string) ([]string, error) {
+	if m.keys == nil {
+		return nil, reader.ErrPrimaryKeysUnsupported
+	}
+	return m.keys[table], nil
+}
+func (m *mockReader) FormatColumn(tableName string, columnName string) string { return columnName }
+func (m *mockReader) ReadTable(table string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	columns := database.NewColumns([]string{"id", "email"})
+	for _, values := range m.tables[table] {
+		rowChan <- database.NewRow(columns, values)
+	}
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
@@ -0,0 +1,202 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// ErrTableTimeout is returned when tables are not read within their timeout.
+var ErrTableTimeout = errors.New("tables timed out")
+
+// Table read states.
+const (
+	reading = "reading"
+	done    = "done"
+	failed  = "failed"
+	expired = "timed out"
+)
+
+type (
+	// Reader stops reading the tables taking longer than their timeout and keeps track of the read tables.
+	Reader struct {
+		reader.Reader
+		ctx     context.Context
+		tables  config.Tables
+		timeout time.Duration
+
+		mu     sync.Mutex
+		states map[string]string
+	}
+
+	// Report lists the tables of a run by state.
+	Report struct {
+		Done     []string `json:"done,omitempty"`
+		Reading  []string `json:"reading,omitempty"`
+		Failed   []string `json:"failed,omitempty"`
+		TimedOut []string `json:"timed_out,omitempty"`
+		Pending  []string `json:"pending,omitempty"`
+	}
+)
+
+// NewReader returns a reader stopping the tables read for longer than their configured Timeout,
+// or the given timeout for the tables without one. A zero timeout disables it. All the tables are stopped
+// once ctx is done, e.g. when the run times out.
+func NewReader(ctx context.Context, source reader.Reader, tables config.Tables, timeout time.Duration) *Reader {
+	return &Reader{Reader: source, ctx: ctx, tables: tables, timeout: timeout, states: make(map[string]string)}
+}
+
+// ReadTable reads the table, the rows are not published anymore once the table timeout is reached or the context of
+// the reader is done.
+func (r *Reader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	timeout := r.timeout
+	if table := r.tables.FindByName(tableName); table != nil && table.Timeout > 0 {
+		timeout = table.Timeout
+	}
+
+	// the tables not started yet when the run is stopped are left pending
+	if err := r.ctx.Err(); err != nil {
+		close(rowChan)
+		return fmt.Errorf("table %s was not read: %w", tableName, err)
+	}
+
+	r.setState(tableName, reading)
+	if timeout == 0 && r.ctx.Done() == nil {
+		err := r.Reader.ReadTable(tableName, rowChan, opts)
+		r.finish(tableName, err)
+		return err
+	}
+
+	defer close(rowChan)
+
+	sourceChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.Reader.ReadTable(tableName, sourceChan, opts)
+	}()
+
+	var expiry <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expiry = timer.C
+	}
+
+	for {
+		select {
+		case row, ok := <-sourceChan:
+			if !ok {
+				err := <-errChan
+				r.finish(tableName, err)
+				return err
+			}
+
+			select {
+			case rowChan <- row:
+			case <-expiry:
+				return r.expire(tableName, timeout, sourceChan)
+			case <-r.ctx.Done():
+				return r.stop(tableName, sourceChan)
+			}
+		case <-expiry:
+			return r.expire(tableName, timeout, sourceChan)
+		case <-r.ctx.Done():
+			return r.stop(tableName, sourceChan)
+		}
+	}
+}
+
+// Err returns an error listing the tables that timed out, if any.
+func (r *Reader) Err() error {
+	if timedOut := r.Report().TimedOut; len(timedOut) > 0 {
+		return fmt.Errorf("%w: %s", ErrTableTimeout, strings.Join(timedOut, ", "))
+	}
+
+	return nil
+}
+
+// Report returns the tables of the run by state, tables not read yet are pending.
+func (r *Reader) Report() Report {
+	tables, err := r.GetTables()
+	if err != nil {
+		log.WithError(err).Warn("could not list the pending tables")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var report Report
+	for _, table := range tables {
+		if cfg := r.tables.FindByName(table); cfg != nil && cfg.IgnoreData {
+			continue
+		}
+		if _, ok := r.states[table]; !ok {
+			report.Pending = append(report.Pending, table)
+		}
+	}
+	for table, state := range r.states {
+		switch state {
+		case reading:
+			report.Reading = append(report.Reading, table)
+		case done:
+			report.Done = append(report.Done, table)
+		case failed:
+			report.Failed = append(report.Failed, table)
+		case expired:
+			report.TimedOut = append(report.TimedOut, table)
+		}
+	}
+	for _, tables := range [][]string{report.Done, report.Reading, report.Failed, report.TimedOut} {
+		sort.Strings(tables)
+	}
+
+	return report
+}
+
+// expire marks the table as timed out, the rows still read from the source are discarded.
+func (r *Reader) expire(tableName string, timeout time.Duration, sourceChan <-chan database.Row) error {
+	r.discard(tableName, sourceChan)
+
+	log.WithFields(log.Fields{"table": tableName, "timeout": timeout}).Error("Table timed out, its data is incomplete")
+	return fmt.Errorf("table %s was not read within %s", tableName, timeout)
+}
+
+// stop marks the table read when the run is stopped as timed out, the rows still read from the source are discarded.
+func (r *Reader) stop(tableName string, sourceChan <-chan database.Row) error {
+	r.discard(tableName, sourceChan)
+
+	log.WithField("table", tableName).Error("The run was stopped, the table data is incomplete")
+	return fmt.Errorf("table %s was not read: %w", tableName, r.ctx.Err())
+}
+
+func (r *Reader) discard(tableName string, sourceChan <-chan database.Row) {
+	r.setState(tableName, expired)
+	go func() {
+		for range sourceChan {
+		}
+	}()
+}
+
+func (r *Reader) finish(tableName string, err error) {
+	if err != nil {
+		r.setState(tableName, failed)
+		return
+	}
+	r.setState(tableName, done)
+}
+
+func (r *Reader) setState(tableName string, state string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[tableName] = state
+}
@@ -0,0 +1,121 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{
+		tables: []string{"users", "orders", "events", "logs", "payments"},
+		delays: map[string]time.Duration{"events": time.Hour, "orders": 10 * time.Millisecond},
+	}
+	tables := config.Tables{
+		{Name: "orders", Timeout: time.Second},
+		{Name: "logs", IgnoreData: true},
+	}
+	r := NewReader(context.Background(), source, tables, 50*time.Millisecond)
+
+	// the table timeout overrides the default one
+	assert.Len(t, read(t, r, "orders", nil), 3)
+	assert.Len(t, read(t, r, "users", nil), 3)
+
+	rows := read(t, r, "events", errors.New("table events was not read within 50ms"))
+	assert.Len(t, rows, 1)
+
+	assert.Equal(t, Report{
+		Done:     []string{"orders", "users"},
+		TimedOut: []string{"events"},
+		Pending:  []string{"payments"},
+	}, r.Report())
+
+	err := r.Err()
+	assert.True(t, errors.Is(err, ErrTableTimeout))
+	assert.EqualError(t, err, "tables timed out: events")
+}
+
+func TestReadTableWithoutTimeout(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{tables: []string{"users"}, delays: map[string]time.Duration{"users": 10 * time.Millisecond}}
+	r := NewReader(context.Background(), source, nil, 0)
+
+	assert.Len(t, read(t, r, "users", nil), 3)
+	assert.Equal(t, Report{Done: []string{"users"}}, r.Report())
+	assert.NoError(t, r.Err())
+}
+
+func TestReadTableStopped(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{tables: []string{"users", "orders"}, delays: map[string]time.Duration{"users": time.Hour}}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewReader(ctx, source, nil, 0)
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	rows := read(t, r, "users", errors.New("table users was not read: context canceled"))
+	assert.Len(t, rows, 1)
+
+	read(t, r, "orders", errors.New("table orders was not read: context canceled"))
+	assert.Equal(t, Report{TimedOut: []string{"users"}, Pending: []string{"orders"}}, r.Report())
+}
+
+func read(t *testing.T, r reader.Reader, table string, expected error) []database.Row {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable(table, rowChan, reader.ReadTableOpt{})
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+
+	err := <-errChan
+	if expected == nil {
+		require.NoError(t, err)
+	} else {
+		require.EqualError(t, err, expected.Error())
+	}
+
+	return rows
+}
+
+// mockReader publishes three rows per table, waiting for the table delay after the first one.
+type mockReader struct {
+	tables []string
+	delays map[string]time.Duration
+}
+
+func (m *mockReader) GetTables() ([]string, error)        { return m.tables, nil }
+func (m *mockReader) GetStructure() (string, error)       { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) { return nil, nil }
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return ""
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	columns := database.NewColumns([]string{"id"})
+	for i := 0; i < 3; i++ {
+		rowChan <- database.NewRow(columns, []interface{}{int64(i)})
+		if i == 0 {
+			time.Sleep(m.delays[tableName])
+		}
+	}
+
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
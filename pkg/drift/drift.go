@@ -0,0 +1,248 @@
+package drift
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/schema"
+)
+
+// Modes of handling a target schema that drifted from the source.
+const (
+	// Off does not compare the schemas.
+	Off Mode = "off"
+	// Warn logs the differences.
+	Warn Mode = "warn"
+	// Fail logs the differences and fails before anything is dumped.
+	Fail Mode = "fail"
+	// Skip logs the differences and does not dump the data of the divergent tables.
+	Skip Mode = "skip"
+	// Create creates the tables and columns missing from the target.
+	Create Mode = "create"
+)
+
+// ErrDrift is returned when the target schema differs from the source.
+var ErrDrift = errors.New("the target schema differs from the source")
+
+type (
+	// Mode is the way differences between the source and target schemas are handled.
+	Mode string
+
+	// Table is the difference between a source table and the target one.
+	Table struct {
+		// Name is the table name.
+		Name string
+		// Missing is true when the table does not exist in the target.
+		Missing bool
+		// Columns are the columns missing from the target, with their source type when known.
+		Columns map[string]string
+		// Types are the columns whose type differs, with their source and target types.
+		Types map[string][2]string
+	}
+
+	// creator is implemented by the targets the missing tables and columns can be created in.
+	creator interface {
+		reader.Executor
+		QuoteIdentifier(string) string
+	}
+)
+
+// ParseMode parses a drift mode.
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(s)); mode {
+	case Off, Warn, Fail, Skip, Create:
+		return mode, nil
+	case "":
+		return Off, nil
+	}
+
+	return "", fmt.Errorf("unknown target drift mode %q, supported modes are off, warn, fail, skip and create", s)
+}
+
+// Compare returns the differences between the source tables that are dumped and the target ones,
+// based on the schema diff. The ignored columns are left out, and the types are only compared
+// when compareTypes is set, e.g. when both databases are of the same kind.
+func Compare(source, target reader.Reader, cfgTables config.Tables, compareTypes bool) ([]Table, error) {
+	sourceSchema, err := schema.Read(source)
+	if err != nil {
+		return nil, fmt.Errorf("drift: could not read the source schema: %w", err)
+	}
+	targetSchema, err := schema.Read(target)
+	if err != nil {
+		return nil, fmt.Errorf("drift: could not read the target schema: %w", err)
+	}
+	diff := schema.Compare(sourceSchema, targetSchema, compareTypes)
+
+	var drifts []Table
+	for _, table := range sourceSchema.Tables {
+		cfg := cfgTables.FindByName(table.Name)
+		if cfg != nil && cfg.IgnoreData {
+			continue
+		}
+		tableDiff := diff.Table(table.Name)
+		if tableDiff == nil {
+			continue
+		}
+		ignored := func(column string) bool {
+			return cfg != nil && contains(cfg.IgnoreColumns, column)
+		}
+
+		drift := Table{Name: table.Name, Missing: tableDiff.Change == schema.Removed, Columns: make(map[string]string), Types: make(map[string][2]string)}
+		if drift.Missing {
+			for _, column := range table.Columns {
+				if !ignored(column.Name) {
+					drift.Columns[column.Name] = column.Type
+				}
+			}
+		}
+		for _, column := range tableDiff.Columns {
+			if ignored(column.Name) {
+				continue
+			}
+			switch column.Change {
+			case schema.Removed:
+				drift.Columns[column.Name] = column.From
+			case schema.Changed:
+				drift.Types[column.Name] = [2]string{column.From, column.To}
+			}
+		}
+
+		if drift.Missing || len(drift.Columns) > 0 || len(drift.Types) > 0 {
+			drifts = append(drifts, drift)
+		}
+	}
+
+	return drifts, nil
+}
+
+// Problems describes the differences, one line each.
+func (t Table) Problems() []string {
+	if t.Missing {
+		return []string{fmt.Sprintf("table %s does not exist in the target", t.Name)}
+	}
+
+	var problems []string
+	for _, column := range sortedKeys(t.Columns) {
+		problems = append(problems, fmt.Sprintf("column %s.%s does not exist in the target", t.Name, column))
+	}
+	types := make([]string, 0, len(t.Types))
+	for column := range t.Types {
+		types = append(types, column)
+	}
+	sort.Strings(types)
+	for _, column := range types {
+		problems = append(problems, fmt.Sprintf(
+			"column %s.%s is %s in the source but %s in the target", t.Name, column, t.Types[column][0], t.Types[column][1],
+		))
+	}
+
+	return problems
+}
+
+// Apply handles the differences as set by the mode: in Skip mode the data of the divergent tables is
+// ignored, in Create mode the missing tables and columns are created with their source type.
+// It returns the tables configuration the steal goes on with.
+func Apply(mode Mode, drifts []Table, target reader.Reader, cfgTables config.Tables) (config.Tables, error) {
+	if mode == Off || len(drifts) == 0 {
+		return cfgTables, nil
+	}
+
+	if mode == Fail {
+		var problems []string
+		for _, drift := range drifts {
+			problems = append(problems, drift.Problems()...)
+		}
+		return nil, fmt.Errorf("%w:\n  %s", ErrDrift, strings.Join(problems, "\n  "))
+	}
+
+	var c creator
+	if mode == Create {
+		var ok bool
+		if c, ok = target.(creator); !ok {
+			return nil, errors.New("drift: the target does not support creating tables")
+		}
+	}
+
+	for _, drift := range drifts {
+		logger := log.WithField("table", drift.Name)
+
+		switch mode {
+		case Create:
+			if err := create(c, drift); err != nil {
+				return nil, err
+			}
+			// the type mismatches are left as they are
+			drift = Table{Name: drift.Name, Types: drift.Types}
+		case Skip:
+			cfg := cfgTables.FindByName(drift.Name)
+			if cfg == nil {
+				cfg = &config.Table{Name: drift.Name}
+				cfgTables = append(cfgTables, cfg)
+			}
+			cfg.IgnoreData = true
+		}
+
+		for _, problem := range drift.Problems() {
+			logger.Warn(problem)
+		}
+		if mode == Skip {
+			logger.Warn("the table data is not dumped, its schema differs in the target")
+		}
+	}
+
+	return cfgTables, nil
+}
+
+// create creates the missing table or columns.
+func create(c creator, drift Table) error {
+	columns := sortedKeys(drift.Columns)
+	definitions := make([]string, len(columns))
+	for i, column := range columns {
+		columnType := drift.Columns[column]
+		if columnType == "" {
+			return fmt.Errorf("drift: could not create column %s.%s, its source type is unknown", drift.Name, column)
+		}
+		definitions[i] = c.QuoteIdentifier(column) + " " + columnType
+	}
+	if len(definitions) == 0 {
+		return nil
+	}
+
+	var stmt string
+	if drift.Missing {
+		stmt = fmt.Sprintf("CREATE TABLE %s (%s)", c.QuoteIdentifier(drift.Name), strings.Join(definitions, ", "))
+	} else {
+		stmt = fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", c.QuoteIdentifier(drift.Name), strings.Join(definitions, ", ADD COLUMN "))
+	}
+
+	log.WithField("table", drift.Name).Info("creating the missing target schema")
+	if err := c.Exec(stmt); err != nil {
+		return fmt.Errorf("drift: could not create %s in the target: %w", drift.Name, err)
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
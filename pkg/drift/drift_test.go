@@ -0,0 +1,117 @@
+package drift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type mockReader struct {
+	reader.Reader
+	types map[string]map[string]string
+	execs []string
+}
+
+func (m *mockReader) GetTables() ([]string, error) {
+	var tables []string
+	for table := range m.types {
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func (m *mockReader) GetColumns(table string) ([]string, error) {
+	var columns []string
+	for column := range m.types[table] {
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func (m *mockReader) GetColumnTypes(table string) (map[string]string, error) {
+	return m.types[table], nil
+}
+
+func (m *mockReader) QuoteIdentifier(name string) string { return `"` + name + `"` }
+
+func (m *mockReader) Exec(query string) error {
+	m.execs = append(m.execs, query)
+	return nil
+}
+
+func newReaders() (*mockReader, *mockReader) {
+	source := &mockReader{types: map[string]map[string]string{
+		"users":  {"id": "int4", "email": "varchar(255)", "notes": "text"},
+		"orders": {"id": "int4", "total": "numeric"},
+		"logs":   {"id": "int4"},
+	}}
+	target := &mockReader{types: map[string]map[string]string{
+		"users":  {"id": "int8", "email": "VARCHAR(255)"},
+		"orders": {"id": "int4", "total": "numeric"},
+	}}
+	return source, target
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("")
+	require.NoError(t, err)
+	assert.Equal(t, Off, mode)
+
+	mode, err = ParseMode("Create")
+	require.NoError(t, err)
+	assert.Equal(t, Create, mode)
+
+	_, err = ParseMode("repair")
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	source, target := newReaders()
+
+	drifts, err := Compare(source, target, nil, true)
+	require.NoError(t, err)
+
+	var problems []string
+	for _, drift := range drifts {
+		problems = append(problems, drift.Problems()...)
+	}
+	assert.ElementsMatch(t, []string{
+		"table logs does not exist in the target",
+		"column users.notes does not exist in the target",
+		"column users.id is int4 in the source but int8 in the target",
+	}, problems)
+
+	cfgTables := config.Tables{
+		{Name: "logs", IgnoreData: true},
+		{Name: "users", IgnoreColumns: []string{"notes"}},
+	}
+	drifts, err = Compare(source, target, cfgTables, false)
+	require.NoError(t, err)
+	assert.Empty(t, drifts)
+}
+
+func TestApply(t *testing.T) {
+	source, target := newReaders()
+	drifts, err := Compare(source, target, nil, true)
+	require.NoError(t, err)
+
+	_, err = Apply(Fail, drifts, target, nil)
+	assert.ErrorIs(t, err, ErrDrift)
+
+	cfgTables, err := Apply(Skip, drifts, target, config.Tables{{Name: "users"}})
+	require.NoError(t, err)
+	assert.True(t, cfgTables.FindByName("users").IgnoreData)
+	assert.True(t, cfgTables.FindByName("logs").IgnoreData)
+	assert.Nil(t, cfgTables.FindByName("orders"))
+
+	_, err = Apply(Create, drifts, target, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		`CREATE TABLE "logs" ("id" int4)`,
+		`ALTER TABLE "users" ADD COLUMN "notes" text`,
+	}, target.execs)
+}
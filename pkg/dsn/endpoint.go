@@ -0,0 +1,102 @@
+package dsn
+
+import (
+	"net"
+	"strings"
+)
+
+// defaultPorts are the default ports of the kinds of dsns connecting to a server.
+var defaultPorts = map[string]string{
+	"mysql":      "3306",
+	"postgres":   "5432",
+	"postgresql": "5432",
+	"http":       "80",
+	"https":      "443",
+}
+
+// Endpoint is the server and database a dsn connects to.
+type Endpoint struct {
+	// Kind is the scheme of the dsn, mysql for the dsns without one.
+	Kind     string
+	Host     string
+	Port     string
+	Database string
+}
+
+// ParseEndpoint returns the endpoint of a dsn connecting to a server, e.g. postgres://user@host/db or
+// user@tcp(host:3306)/db. It is false for the dsns of files and streams.
+func ParseEndpoint(s string) (Endpoint, bool) {
+	kind, rest := "mysql", s
+	if i := strings.Index(s, "://"); i >= 0 {
+		kind, rest = strings.ToLower(s[:i]), s[i+len("://"):]
+	}
+	port, ok := defaultPorts[kind]
+	if !ok {
+		return Endpoint{}, false
+	}
+	if kind == "postgresql" {
+		kind = "postgres"
+	}
+
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest = rest[:i]
+	}
+	// the user info ends at the last @, the password may hold @ itself
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		rest = rest[i+1:]
+	}
+
+	// mysql addresses are given as protocol(address), e.g. tcp(host:3306) or unix(/tmp/mysql.sock)
+	address, database := rest, ""
+	if open, end := strings.Index(rest, "("), strings.Index(rest, ")"); open >= 0 && end > open {
+		address, database = rest[open+1:end], strings.TrimPrefix(rest[end+1:], "/")
+	} else if i := strings.Index(rest, "/"); i >= 0 {
+		address, database = rest[:i], rest[i+1:]
+	}
+
+	host := address
+	if h, p, err := net.SplitHostPort(address); err == nil {
+		host, port = h, p
+	}
+
+	return Endpoint{Kind: kind, Host: normalizeHost(host), Port: port, Database: strings.ToLower(database)}, true
+}
+
+// normalizeHost lower cases the host and names the loopback addresses localhost.
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	switch host {
+	case "", "127.0.0.1", "::1":
+		return "localhost"
+	}
+	return host
+}
+
+// ReplaceAddress returns the dsn connecting to the given host:port address instead, keeping its user, database and
+// parameters. It is false for the dsns ParseEndpoint does not know.
+func ReplaceAddress(s string, address string) (string, bool) {
+	start := 0
+	kind := "mysql"
+	if i := strings.Index(s, "://"); i >= 0 {
+		kind, start = strings.ToLower(s[:i]), i+len("://")
+	}
+	if _, ok := defaultPorts[kind]; !ok {
+		return "", false
+	}
+
+	end := len(s)
+	if i := strings.Index(s[start:], "?"); i >= 0 {
+		end = start + i
+	}
+	if i := strings.LastIndex(s[start:end], "@"); i >= 0 {
+		start += i + 1
+	}
+
+	if open, closing := strings.Index(s[start:end], "("), strings.Index(s[start:end], ")"); open >= 0 && closing > open {
+		start, end = start+open+1, start+closing
+	} else if i := strings.Index(s[start:end], "/"); i >= 0 {
+		end = start + i
+	}
+
+	return s[:start] + address + s[end:], true
+}
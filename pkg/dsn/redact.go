@@ -0,0 +1,76 @@
+package dsn
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redactedPassword replaces the passwords of the redacted dsns.
+const redactedPassword = "xxxxx"
+
+// passwordParam matches the passwords given as parameters, e.g. in postgres key/value dsns or query strings.
+var passwordParam = regexp.MustCompile(`(?i)\b(password|passwd|pwd)=('[^']*'|[^\s&;]*)`)
+
+// Redacted is a dsn that formats itself with its password redacted, so that it can be logged or wrapped in errors.
+type Redacted string
+
+// String returns the dsn with its password redacted.
+func (r Redacted) String() string {
+	return Redact(string(r))
+}
+
+// Redact returns the dsn with the password of its user info and its password parameters redacted.
+func Redact(s string) string {
+	return redact(s, userPassword(s))
+}
+
+// RedactError returns the error with the password of the dsn redacted from its message, e.g. when a driver
+// quotes the dsn it failed to connect with. The returned error still wraps err.
+func RedactError(err error, s string) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err, password: userPassword(s)}
+}
+
+type redactedError struct {
+	err      error
+	password string
+}
+
+func (e *redactedError) Error() string {
+	return redact(e.err.Error(), e.password)
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redact replaces the user info password, when set, and the password parameters of s.
+func redact(s string, password string) string {
+	if password != "" {
+		s = strings.ReplaceAll(s, ":"+password+"@", ":"+redactedPassword+"@")
+	}
+	return passwordParam.ReplaceAllString(s, "${1}="+redactedPassword)
+}
+
+// userPassword returns the password of the user info of the dsn, empty when it has none.
+func userPassword(s string) string {
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+len("://"):]
+	}
+	if i := strings.Index(s, "?"); i >= 0 {
+		s = s[:i]
+	}
+
+	// the user info ends at the last @, the password may hold @ itself
+	at := strings.LastIndex(s, "@")
+	if at < 0 {
+		return ""
+	}
+	colon := strings.Index(s[:at], ":")
+	if colon < 0 {
+		return ""
+	}
+	return s[colon+1 : at]
+}
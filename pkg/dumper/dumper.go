@@ -1,13 +1,27 @@
 package dumper
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/hellofresh/klepto/pkg/config"
+	parser "github.com/hellofresh/klepto/pkg/dsn"
 	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/retry"
+	"github.com/hellofresh/klepto/pkg/spool"
+)
+
+var (
+	// ErrExecUnsupported is returned when the dumper can not execute statements.
+	ErrExecUnsupported = errors.New("the dumper does not support executing statements")
+	// ErrAnalyzeUnsupported is returned when the dumper can not analyze the tables it loaded.
+	ErrAnalyzeUnsupported = errors.New("the dumper does not support analyzing tables")
+	// ErrRefreshUnsupported is returned when the dumper can not refresh the materialized views of the target.
+	ErrRefreshUnsupported = errors.New("the dumper does not support refreshing materialized views")
 )
 
 type (
@@ -21,12 +35,33 @@ type (
 
 	// A Dumper writes a database's structure to the provided stream.
 	Dumper interface {
-		// Dump executes the dump process.
-		Dump(chan<- struct{}, config.Tables, int, bool) error
+		// Dump executes the dump process and returns once all the tables are dumped, with the result of each table.
+		// The error is only set when the dump could not run, e.g. when the structure could not be dumped.
+		Dump(cfgTables config.Tables, concurrency int, dataOnly bool) (*Result, error)
 		// Close closes the dumper resources and releases them.
 		Close() error
 	}
 
+	// Executor is implemented by dumpers that can execute statements on the target, e.g. the after load hooks.
+	Executor interface {
+		// Exec executes the statement.
+		Exec(query string) error
+	}
+
+	// Analyzer is implemented by the database dumpers that can refresh the statistics of the tables they loaded,
+	// for the query planner of the target not to start from empty statistics.
+	Analyzer interface {
+		// Analyze refreshes the statistics of the tables.
+		Analyze(tables []string) error
+	}
+
+	// Refresher is implemented by the database dumpers whose target has materialized views, created empty with the
+	// structure, that can be refreshed once the tables are loaded.
+	Refresher interface {
+		// RefreshViews refreshes the materialized views of the target and returns their names.
+		RefreshViews() ([]string, error)
+	}
+
 	// ConnOpts are the options to create a connection
 	ConnOpts struct {
 		// DSN is the connection address.
@@ -41,6 +76,36 @@ type (
 		MaxConns int
 		// MaxIdleConns is the maximum number of connections in the idle connection pool for the write database.
 		MaxIdleConns int
+		// MaxConnIdleTime is the maximum amount of time a connection may be idle before being closed.
+		MaxConnIdleTime time.Duration
+		// Retry is the policy for retrying write statements and transactions failing with transient errors.
+		Retry retry.Policy
+		// ReplayRows is the amount of rows each write transaction keeps to insert them again when it is retried.
+		ReplayRows int
+		// WriteWorkers is the amount of transactions the database dumpers insert the rows of a table in concurrently.
+		WriteWorkers int
+		// TableMaxConns caps the amount of connections the database dumpers insert the rows of a table with.
+		TableMaxConns int
+		// OrderedCommit lets the database dumpers commit the transactions of a table once all of them inserted their rows.
+		OrderedCommit bool
+		// TargetDialect is the SQL dialect written by the query dumper (mysql, postgres, redshift, sqlite or ansi).
+		TargetDialect string
+		// HTTPHeaders are the headers sent with every request of the webhook dumper, e.g. Authorization.
+		HTTPHeaders http.Header
+		// HTTPBatchSize is the amount of rows posted per request by the webhook dumper.
+		HTTPBatchSize int
+		// HTTPBatchBytes if set, the webhook dumper sizes its batches to about this amount of bytes from the average width of the rows.
+		HTTPBatchBytes int64
+		// WriteBufferSize is the size in bytes of the buffer the query dumper writes its statements through.
+		WriteBufferSize int
+		// Interleave lets the query dumper write the rows of the tables read concurrently as they come, instead of table by table.
+		Interleave bool
+		// MemoryBudget is the memory the query dumper buffers the rows of the tables read ahead of their turn in, shared
+		// with the buffered reads when set.
+		MemoryBudget *spool.Budget
+		// SpillDir is the directory the rows over the memory budget are spilled to, the system temporary directory when
+		// not set.
+		SpillDir string
 	}
 )
 
@@ -58,11 +123,11 @@ func NewDumper(opts ConnOpts, rdr reader.Reader) (dumper Dumper, err error) {
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("could not create dumper for DSN %q : %w", opts.DSN, err)
+		return nil, fmt.Errorf("could not create dumper for DSN %q : %w", parser.Redacted(opts.DSN), parser.RedactError(err, opts.DSN))
 	}
 
 	if dumper == nil {
-		return nil, fmt.Errorf("no supported driver found for dumper DSN %q", opts.DSN)
+		return nil, fmt.Errorf("no supported driver found for dumper DSN %q", parser.Redacted(opts.DSN))
 	}
 
 	return
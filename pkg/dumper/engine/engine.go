@@ -36,6 +36,12 @@ type (
 		// PostDumpTables performs a action after dumping tables before dumping tables.
 		PostDumpTables([]string) error
 	}
+
+	// Configurer is implemented by dumpers that need the tables configuration.
+	Configurer interface {
+		// Configure receives the tables configuration before anything is dumped.
+		Configure(config.Tables)
+	}
 )
 
 // New creates a new engine given the reader and dumper.
@@ -47,47 +53,89 @@ func New(rdr reader.Reader, dumper Dumper) dumper.Dumper {
 }
 
 // Dump executes the dump process.
-func (e *Engine) Dump(done chan<- struct{}, cfgTables config.Tables, concurrency int, dataOnly bool) error {
+func (e *Engine) Dump(cfgTables config.Tables, concurrency int, dataOnly bool) (*dumper.Result, error) {
+	if c, ok := e.Dumper.(Configurer); ok {
+		c.Configure(cfgTables)
+	}
+
+	var postData string
 	if !dataOnly {
-		if err := e.readAndDumpStructure(); err != nil {
-			return err
+		var err error
+		if postData, err = e.readAndDumpStructure(); err != nil {
+			return nil, err
 		}
 	}
 
-	return e.readAndDumpTables(done, cfgTables, concurrency)
+	return e.readAndDumpTables(cfgTables, concurrency, postData)
+}
+
+// Exec executes a statement on the target, if supported by the dumper.
+func (e *Engine) Exec(query string) error {
+	x, ok := e.Dumper.(dumper.Executor)
+	if !ok {
+		return dumper.ErrExecUnsupported
+	}
+
+	return x.Exec(query)
+}
+
+// Analyze refreshes the statistics of the tables on the target, if supported by the dumper.
+func (e *Engine) Analyze(tables []string) error {
+	a, ok := e.Dumper.(dumper.Analyzer)
+	if !ok {
+		return dumper.ErrAnalyzeUnsupported
+	}
+
+	return a.Analyze(tables)
+}
+
+// RefreshViews refreshes the materialized views of the target, if supported by the dumper.
+func (e *Engine) RefreshViews() ([]string, error) {
+	r, ok := e.Dumper.(dumper.Refresher)
+	if !ok {
+		return nil, dumper.ErrRefreshUnsupported
+	}
+
+	return r.RefreshViews()
 }
 
-func (e *Engine) readAndDumpStructure() error {
+// readAndDumpStructure dumps the pre-data section of the structure and returns the post-data one,
+// dumped once the tables are loaded.
+func (e *Engine) readAndDumpStructure() (string, error) {
 	log.Debug("dumping structure...")
-	sql, err := e.reader.GetStructure()
+	preData, postData, err := reader.GetStructureSections(e.reader)
 	if err != nil {
-		return fmt.Errorf("failed to get structure: %w", err)
+		return "", fmt.Errorf("failed to get structure: %w", err)
 	}
 
-	if err := e.DumpStructure(sql); err != nil {
-		return fmt.Errorf("failed to dump structure: %w", err)
+	if err := e.DumpStructure(preData); err != nil {
+		return "", fmt.Errorf("failed to dump structure: %w", err)
 	}
 
 	log.Debug("structure was dumped")
-	return nil
+	return postData, nil
 }
 
-func (e *Engine) readAndDumpTables(done chan<- struct{}, cfgTables config.Tables, concurrency int) error {
+func (e *Engine) readAndDumpTables(cfgTables config.Tables, concurrency int, postData string) (*dumper.Result, error) {
 	tables, err := e.reader.GetTables()
 	if err != nil {
-		return fmt.Errorf("failed to read and dump tables: %w", err)
+		return nil, fmt.Errorf("failed to read and dump tables: %w", err)
 	}
 
 	// Trigger pre dump tables
 	if adv, ok := e.Dumper.(Hooker); ok {
 		if err := adv.PreDumpTables(tables); err != nil {
-			return fmt.Errorf("failed to execute pre dump tables: %w", err)
+			return nil, fmt.Errorf("failed to execute pre dump tables: %w", err)
 		}
 	}
 
+	result := &dumper.Result{Tables: make([]dumper.TableResult, len(tables))}
 	semChan := make(chan struct{}, concurrency)
 	var wg sync.WaitGroup
-	for _, tbl := range tables {
+	for i, tbl := range tables {
+		table := &result.Tables[i]
+		table.Name = tbl
+
 		logger := log.WithField("table", tbl)
 		tableConfig := cfgTables.FindByName(tbl)
 		if tableConfig == nil {
@@ -98,6 +146,7 @@ func (e *Engine) readAndDumpTables(done chan<- struct{}, cfgTables config.Tables
 		if tableConfig != nil {
 			if tableConfig.IgnoreData {
 				logger.Debug("ignoring data to dump")
+				table.Skipped = true
 				continue
 			}
 
@@ -109,36 +158,84 @@ func (e *Engine) readAndDumpTables(done chan<- struct{}, cfgTables config.Tables
 		semChan <- struct{}{}
 		wg.Add(1)
 
+		dumpChan, lobErr := countRows(rowChan, &table.Rows, len(opts.ChunkColumns) > 0, logger)
+		readErr := make(chan error, 1)
+
 		go func(tableName string, rowChan <-chan database.Row, logger *log.Entry) {
 			defer wg.Done()
 			defer func(semChan <-chan struct{}) { <-semChan }(semChan)
 
-			if err := e.DumpTable(tableName, rowChan); err != nil {
+			err := e.DumpTable(tableName, rowChan)
+			if err != nil {
 				logger.WithError(err).Error("Failed to dump table")
+				err = fmt.Errorf("failed to dump table: %w", err)
+			}
+			// the rows left by a failing dumper are drained, so that the reader returns
+			for range rowChan {
+			}
+			if rErr := <-readErr; err == nil && rErr != nil {
+				err = fmt.Errorf("failed to read table: %w", rErr)
 			}
-		}(tbl, rowChan, logger)
+			if lErr := <-lobErr; err == nil && lErr != nil {
+				err = fmt.Errorf("failed to read large values: %w", lErr)
+			}
+			table.Err = err
+		}(tbl, dumpChan, logger)
 
 		go func(tableName string, opts reader.ReadTableOpt, rowChan chan<- database.Row, logger *log.Entry) {
-			if err := e.reader.ReadTable(tableName, rowChan, opts); err != nil {
+			err := e.reader.ReadTable(tableName, rowChan, opts)
+			if err != nil {
 				logger.WithError(err).Error("Failed to read table")
 			}
+			readErr <- err
 		}(tbl, opts, rowChan, logger)
 	}
 
+	// Wait for all table to be dumped
+	wg.Wait()
+	close(semChan)
+
+	// Trigger post dump tables
+	if adv, ok := e.Dumper.(Hooker); ok {
+		if err := adv.PostDumpTables(tables); err != nil {
+			return nil, fmt.Errorf("post dump tables failed: %w", err)
+		}
+	}
+
+	if postData != "" {
+		log.Debug("dumping post-data structure...")
+		if err := e.DumpStructure(postData); err != nil {
+			return nil, fmt.Errorf("failed to dump post-data structure: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// countRows counts the rows given to the dumper, reading their large values whole first when readLOBs is set:
+// the engine dumpers write their rows with database/sql and can not stream them. A large value failing to be read
+// ends the rows given to the dumper, the error being sent once they are closed.
+func countRows(rowChan <-chan database.Row, rows *uint64, readLOBs bool, logger *log.Entry) (<-chan database.Row, <-chan error) {
+	countChan := make(chan database.Row)
+	errChan := make(chan error, 1)
 	go func() {
-		// Wait for all table to be dumped
-		wg.Wait()
-		close(semChan)
-
-		// Trigger post dump tables
-		if adv, ok := e.Dumper.(Hooker); ok {
-			if err := adv.PostDumpTables(tables); err != nil {
-				log.WithError(err).Error("post dump tables failed")
+		defer close(countChan)
+		for row := range rowChan {
+			if readLOBs {
+				if err := database.ReadLOBs(row); err != nil {
+					logger.WithError(err).Error("Failed to read large values")
+					errChan <- err
+					// the rows left are drained, so that the reader returns
+					for range rowChan {
+					}
+					return
+				}
 			}
+			countChan <- row
+			*rows++
 		}
-
-		done <- struct{}{}
+		errChan <- nil
 	}()
 
-	return nil
+	return countChan, errChan
 }
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestDumpLOBReadError(t *testing.T) {
+	t.Parallel()
+
+	columns := database.NewColumns([]string{"id", "body"})
+	rdr := &mockReader{rows: []database.Row{
+		database.NewRow(columns, []interface{}{int64(1), database.NewLOB(3, false, 0, func(int64, int64) ([]byte, error) {
+			return []byte("foo"), nil
+		})}),
+		database.NewRow(columns, []interface{}{int64(2), database.NewLOB(3, false, 0, func(int64, int64) ([]byte, error) {
+			return nil, errors.New("connection lost")
+		})}),
+		database.NewRow(columns, []interface{}{int64(3), "bar"}),
+	}}
+	dmp := &mockDumper{}
+
+	result, err := New(rdr, dmp).Dump(config.Tables{{Name: "posts", ChunkColumns: []string{"body"}}}, 1, true)
+	require.NoError(t, err)
+	require.Len(t, result.Tables, 1)
+	assert.EqualError(t, result.Tables[0].Err, "failed to read large values: could not read large value at 1: connection lost")
+	assert.Equal(t, []interface{}{"foo"}, dmp.values, "the rows are not skipped past the failing one")
+	assert.Equal(t, uint64(1), result.Tables[0].Rows)
+}
+
+type mockReader struct {
+	reader.Reader
+	rows []database.Row
+}
+
+func (m *mockReader) GetTables() ([]string, error) {
+	return []string{"posts"}, nil
+}
+
+func (m *mockReader) ReadTable(_ string, rowChan chan<- database.Row, _ reader.ReadTableOpt) error {
+	defer close(rowChan)
+	for _, row := range m.rows {
+		rowChan <- row
+	}
+
+	return nil
+}
+
+type mockDumper struct {
+	values []interface{}
+}
+
+func (m *mockDumper) DumpStructure(string) error { return nil }
+
+func (m *mockDumper) DumpTable(_ string, rowChan <-chan database.Row) error {
+	for row := range rowChan {
+		m.values = append(m.values, row.Get("body"))
+	}
+
+	return nil
+}
+
+func (m *mockDumper) Close() error { return nil }
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Statements caches the statements inserting the rows of the tables, prepared once on the target database for each
+// table and column set, and reused by all the transactions writing the table through Tx.Stmt.
+type Statements struct {
+	conn *sql.DB
+
+	mu         sync.Mutex
+	statements map[string]*sql.Stmt
+}
+
+// NewStatements returns the statements prepared on conn.
+func NewStatements(conn *sql.DB) *Statements {
+	return &Statements{conn: conn, statements: make(map[string]*sql.Stmt)}
+}
+
+// Prepare returns the statement inserting into the columns of the table, prepared with query the first time. It is
+// meant to be called before the transactions writing the table are opened, as they may hold all the connections.
+func (s *Statements) Prepare(tableName string, columns []string, query string) (*sql.Stmt, error) {
+	// NUL is neither in table nor in column names
+	key := tableName + "\x00" + strings.Join(columns, "\x00")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stmt, ok := s.statements[key]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := s.conn.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare insert into %s: %w", tableName, err)
+	}
+	s.statements[key] = stmt
+
+	return stmt, nil
+}
+
+// Close closes the prepared statements.
+func (s *Statements) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	for key, stmt := range s.statements {
+		if closeErr := stmt.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		delete(s.statements, key)
+	}
+
+	return err
+}
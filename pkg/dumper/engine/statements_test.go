@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/internal/sqlmock"
+)
+
+func TestStatementsPrepare(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	mock.ExpectPrepare(`^INSERT INTO users \(id, email\)`)
+	mock.ExpectPrepare(`^INSERT INTO users \(id\)`)
+	mock.ExpectBegin()
+	mock.ExpectExec(`^INSERT INTO users \(id, email\)`).WithArgs(int64(1), "a@example.com")
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^INSERT INTO users \(id, email\)`).WithArgs(int64(2), "b@example.com")
+	mock.ExpectCommit()
+
+	statements := NewStatements(db)
+	stmt, err := statements.Prepare("users", []string{"id", "email"}, "INSERT INTO users (id, email) VALUES (?, ?)")
+	require.NoError(t, err)
+	again, err := statements.Prepare("users", []string{"id", "email"}, "INSERT INTO users (id, email) VALUES (?, ?)")
+	require.NoError(t, err)
+	assert.Same(t, stmt, again, "the statement of a table and column set is prepared once")
+	other, err := statements.Prepare("users", []string{"id"}, "INSERT INTO users (id) VALUES (?)")
+	require.NoError(t, err)
+	assert.NotSame(t, stmt, other)
+
+	// the transactions reuse the statement prepared on their connection
+	for i, email := range []string{"a@example.com", "b@example.com"} {
+		txn, err := db.Begin()
+		require.NoError(t, err)
+		_, err = txn.Stmt(stmt).Exec(int64(i+1), email)
+		require.NoError(t, err)
+		require.NoError(t, txn.Commit())
+	}
+
+	require.NoError(t, statements.Close())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStatementsPrepareFails(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	mock.ExpectPrepare(`^INSERT INTO users`).WillReturnError(errors.New("table users does not exist"))
+	mock.ExpectPrepare(`^INSERT INTO users`)
+
+	statements := NewStatements(db)
+	_, err := statements.Prepare("users", []string{"id"}, "INSERT INTO users (id) VALUES (?)")
+	assert.EqualError(t, err, "failed to prepare insert into users: table users does not exist")
+	_, err = statements.Prepare("users", []string{"id"}, "INSERT INTO users (id) VALUES (?)")
+	assert.NoError(t, err, "a failing statement is not cached")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
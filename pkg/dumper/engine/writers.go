@@ -0,0 +1,245 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/retry"
+)
+
+type (
+	// Writers are the transactions the database dumpers insert the rows of a table in.
+	Writers struct {
+		// Workers is the amount of transactions inserting the rows of a table concurrently, 1 when not set.
+		Workers int
+		// MaxConns caps the amount of connections, and so of workers, inserting the rows of a table, no cap when not
+		// set.
+		MaxConns int
+		// OrderedCommit holds the commits of the workers until all of them inserted their rows, to commit them one
+		// after the other, or to roll them all back when one fails.
+		OrderedCommit bool
+		// Retry is the policy the transactions failing with transient errors, e.g. deadlocks or serialization
+		// failures, are retried with: the transaction is opened again and its rows are inserted again.
+		Retry retry.Policy
+		// ReplayRows is the amount of rows each worker keeps to insert them again when its transaction is retried,
+		// a transaction failing once it received more rows is not retried.
+		ReplayRows int
+	}
+
+	// InsertFunc inserts the rows received on rowChan within the transaction of a worker, numbered from 0, and
+	// returns the amount of rows inserted.
+	InsertFunc func(txn *sql.Tx, worker int, rowChan <-chan database.Row) (int64, error)
+
+	// worker inserts rows of a table in its own transaction.
+	worker struct {
+		Writers
+		id      int
+		rowChan <-chan database.Row
+		begin   func() (*sql.Tx, error)
+		insert  InsertFunc
+		// received are the rows received by the transaction so far, replayed when it is retried.
+		received []database.Row
+		// overflow is set once more than ReplayRows rows were received, the transaction can not be retried.
+		overflow bool
+	}
+)
+
+// Insert inserts the rows of a table with the workers, each one receiving rows from rowChan in its own transaction
+// opened with begin, retried on transient errors. It returns the amount of rows inserted by the committed
+// transactions.
+func (w Writers) Insert(rowChan <-chan database.Row, begin func() (*sql.Tx, error), insert InsertFunc) (int64, error) {
+	workers := w.Workers
+	if w.MaxConns > 0 && workers > w.MaxConns {
+		workers = w.MaxConns
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		all      = make([]*worker, workers)
+		txns     = make([]*sql.Tx, workers)
+		inserted = make([]int64, workers)
+		errs     = make([]error, workers)
+	)
+	for i := 0; i < workers; i++ {
+		all[i] = &worker{Writers: w, id: i, rowChan: rowChan, begin: begin, insert: insert}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			txns[i], inserted[i], errs[i] = all[i].run(!w.OrderedCommit)
+		}(i)
+	}
+	wg.Wait()
+
+	var err error
+	for _, workerErr := range errs {
+		if workerErr != nil {
+			err = workerErr
+			break
+		}
+	}
+
+	var total int64
+	for i, txn := range txns {
+		switch {
+		case txn == nil:
+		case err != nil:
+			rollback(txn)
+			continue
+		default:
+			if inserted[i], err = all[i].commit(txn, inserted[i]); err != nil {
+				continue
+			}
+		}
+		if errs[i] == nil {
+			total += inserted[i]
+		}
+	}
+
+	return total, err
+}
+
+// run inserts the rows of the worker in a transaction, committed when commit is set and returned otherwise.
+func (wk *worker) run(commit bool) (txn *sql.Tx, inserted int64, err error) {
+	err = wk.retry(func() (err error) {
+		txn, inserted, err = wk.attempt(commit)
+		return err
+	})
+
+	return txn, inserted, err
+}
+
+// commit commits the transaction of the worker, inserting its rows again in a new transaction when the commit fails
+// with a transient error.
+func (wk *worker) commit(txn *sql.Tx, inserted int64) (int64, error) {
+	err := wk.retry(func() (err error) {
+		if txn == nil {
+			_, inserted, err = wk.attempt(true)
+			return err
+		}
+
+		err = txn.Commit()
+		txn = nil
+		if err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
+
+	return inserted, err
+}
+
+// attempt opens a transaction and inserts the rows received so far again, then the next rows.
+func (wk *worker) attempt(commit bool) (*sql.Tx, int64, error) {
+	txn, err := wk.begin()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open transaction: %w", err)
+	}
+
+	rowChan, stop := wk.feed()
+	inserted, err := wk.insert(txn, wk.id, rowChan)
+	stop()
+	if err != nil {
+		rollback(txn)
+		return nil, 0, fmt.Errorf("failed to insert rows: %w", err)
+	}
+
+	if !commit {
+		return txn, inserted, nil
+	}
+	if err := txn.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil, inserted, nil
+}
+
+// retry runs fn with the retry policy, as long as the rows received by the worker can be replayed.
+func (wk *worker) retry(fn func() error) error {
+	return wk.Retry.Do(context.Background(), func() error {
+		err := fn()
+		if wk.overflow && retry.IsTransient(err) {
+			log.WithError(err).WithField("replay_rows", wk.ReplayRows).
+				Warn("the transaction received more rows than can be replayed, it is not retried")
+			return retry.Permanent(err)
+		}
+
+		return err
+	})
+}
+
+// feed returns the rows of an attempt, the rows received by the previous attempts first, and the function stopping
+// it once the rows are inserted.
+func (wk *worker) feed() (<-chan database.Row, func()) {
+	rowChan := make(chan database.Row)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		defer close(rowChan)
+
+		for _, row := range wk.received {
+			select {
+			case rowChan <- row:
+			case <-done:
+				return
+			}
+		}
+
+		for {
+			var (
+				row  database.Row
+				more bool
+			)
+			select {
+			case row, more = <-wk.rowChan:
+			case <-done:
+				return
+			}
+			if !more {
+				return
+			}
+
+			wk.keep(row)
+			select {
+			case rowChan <- row:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return rowChan, func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// keep keeps a received row to replay it, until more than ReplayRows rows are received.
+func (wk *worker) keep(row database.Row) {
+	if wk.overflow {
+		return
+	}
+	if len(wk.received) >= wk.ReplayRows {
+		wk.overflow = true
+		wk.received = nil
+		return
+	}
+
+	wk.received = append(wk.received, row)
+}
+
+func rollback(txn *sql.Tx) {
+	if err := txn.Rollback(); err != nil {
+		log.WithError(err).Error("failed to rollback")
+	}
+}
@@ -0,0 +1,212 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/retry"
+)
+
+func TestWritersInsert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		writers   Writers
+		failing   int
+		inserted  int64
+		commits   int
+		rollbacks int
+	}{
+		{name: "single worker", writers: Writers{}, failing: -1, inserted: 10, commits: 1},
+		{name: "workers", writers: Writers{Workers: 3}, failing: -1, inserted: 10, commits: 3},
+		{name: "ordered commit", writers: Writers{Workers: 3, OrderedCommit: true}, failing: -1, inserted: 10, commits: 3},
+		{name: "connections capped", writers: Writers{Workers: 3, MaxConns: 2}, failing: -1, inserted: 10, commits: 2},
+		{name: "failing worker", writers: Writers{Workers: 3}, failing: 1, commits: 2, rollbacks: 1},
+		{name: "failing worker rolls back all", writers: Writers{Workers: 3, OrderedCommit: true}, failing: 1, rollbacks: 3},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			drv := new(txDriver)
+			db := sql.OpenDB(drv)
+			defer db.Close()
+
+			rowChan := make(chan database.Row)
+			go func() {
+				defer close(rowChan)
+				columns := database.NewColumns([]string{"id"})
+				for i := 0; i < 10; i++ {
+					rowChan <- database.NewRow(columns, []interface{}{int64(i)})
+				}
+			}()
+
+			// the workers wait for each other, so that each of them receives rows
+			var ready sync.WaitGroup
+			workers := test.writers.Workers
+			if test.writers.MaxConns > 0 && workers > test.writers.MaxConns {
+				workers = test.writers.MaxConns
+			}
+			if workers < 1 {
+				workers = 1
+			}
+			ready.Add(workers)
+
+			inserted, err := test.writers.Insert(rowChan, db.Begin, func(txn *sql.Tx, worker int, rowChan <-chan database.Row) (int64, error) {
+				ready.Done()
+				ready.Wait()
+				if worker == test.failing {
+					return 0, errors.New("duplicate key")
+				}
+
+				var n int64
+				for range rowChan {
+					n++
+				}
+				return n, nil
+			})
+			for range rowChan {
+			}
+
+			if test.failing >= 0 {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "duplicate key")
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.inserted, inserted)
+			}
+			assert.Equal(t, test.commits, drv.commits)
+			assert.Equal(t, test.rollbacks, drv.rollbacks)
+		})
+	}
+}
+
+func TestWritersRetry(t *testing.T) {
+	t.Parallel()
+
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	tests := []struct {
+		name        string
+		writers     Writers
+		failInserts int32
+		failCommits int
+		attempts    int32
+		commits     int
+		rollbacks   int
+		err         bool
+	}{
+		{name: "deadlock while inserting", writers: Writers{ReplayRows: 100}, failInserts: 1, attempts: 2, commits: 1, rollbacks: 1},
+		{name: "deadlock on commit", writers: Writers{ReplayRows: 100}, failCommits: 1, attempts: 2, commits: 1},
+		{name: "deadlock on ordered commit", writers: Writers{OrderedCommit: true, ReplayRows: 100}, failCommits: 1, attempts: 2, commits: 1},
+		{name: "attempts exhausted", writers: Writers{ReplayRows: 100}, failInserts: 3, attempts: 3, rollbacks: 3, err: true},
+		{name: "too many rows to replay", writers: Writers{ReplayRows: 2}, failInserts: 1, attempts: 1, rollbacks: 1, err: true},
+		{name: "retries disabled", writers: Writers{Retry: retry.Policy{Attempts: 1}, ReplayRows: 100}, failInserts: 1, attempts: 1, rollbacks: 1, err: true},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			drv := &txDriver{failCommits: test.failCommits, commitErr: deadlock}
+			db := sql.OpenDB(drv)
+			defer db.Close()
+
+			rowChan := make(chan database.Row)
+			go func() {
+				defer close(rowChan)
+				columns := database.NewColumns([]string{"id"})
+				for i := 0; i < 10; i++ {
+					rowChan <- database.NewRow(columns, []interface{}{int64(i)})
+				}
+			}()
+
+			writers := test.writers
+			if writers.Retry.Attempts == 0 {
+				writers.Retry = retry.Policy{Attempts: 3}
+			}
+
+			var attempts int32
+			var ids []interface{}
+			inserted, err := writers.Insert(rowChan, db.Begin, func(txn *sql.Tx, _ int, rowChan <-chan database.Row) (int64, error) {
+				attempt := atomic.AddInt32(&attempts, 1)
+				ids = ids[:0]
+				for row := range rowChan {
+					ids = append(ids, row.Get("id"))
+					if attempt <= test.failInserts && len(ids) == 4 {
+						return 0, fmt.Errorf("failed to copy in row: %w", deadlock)
+					}
+				}
+				return int64(len(ids)), nil
+			})
+			for range rowChan {
+			}
+
+			assert.Equal(t, test.attempts, attempts)
+			assert.Equal(t, test.commits, drv.commits)
+			assert.Equal(t, test.rollbacks, drv.rollbacks)
+			if test.err {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, deadlock)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(10), inserted)
+			assert.Equal(t, []interface{}{int64(0), int64(1), int64(2), int64(3), int64(4), int64(5), int64(6), int64(7), int64(8), int64(9)}, ids)
+		})
+	}
+}
+
+// txDriver is a database driver counting the committed and rolled back transactions, the first failCommits
+// commits failing with commitErr.
+type txDriver struct {
+	mu                 sync.Mutex
+	commits, rollbacks int
+	failCommits        int
+	commitErr          error
+}
+
+func (d *txDriver) Connect(context.Context) (driver.Conn, error) { return &txConn{driver: d}, nil }
+func (d *txDriver) Open(string) (driver.Conn, error)             { return &txConn{driver: d}, nil }
+
+func (d *txDriver) Driver() driver.Driver { return d }
+
+type txConn struct{ driver *txDriver }
+
+func (c *txConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("unexpected query %s", query)
+}
+func (c *txConn) Close() error              { return nil }
+func (c *txConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *txConn) Commit() error {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	if c.driver.failCommits > 0 {
+		c.driver.failCommits--
+		return c.driver.commitErr
+	}
+	c.driver.commits++
+	return nil
+}
+
+func (c *txConn) Rollback() error {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	c.driver.rollbacks++
+	return nil
+}
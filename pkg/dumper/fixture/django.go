@@ -0,0 +1,89 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+// djangoPrimaryKey is the column used as the Django fixture pk.
+const djangoPrimaryKey = "id"
+
+// djangoEncoder writes Django JSON fixtures, a list of objects with model, pk and fields.
+type djangoEncoder struct{}
+
+// Extension returns the fixture file extension.
+func (e *djangoEncoder) Extension() string { return ".json" }
+
+// Begin opens the JSON list.
+func (e *djangoEncoder) Begin(w io.Writer, table Table) error {
+	_, err := io.WriteString(w, "[")
+	return err
+}
+
+// Encode writes a row as a fixture object, keeping the columns in schema order.
+func (e *djangoEncoder) Encode(w io.Writer, table Table, n int, row yaml.MapSlice) error {
+	model := table.Model
+	if model == "" {
+		model = table.Name
+	}
+
+	buf := new(bytes.Buffer)
+	if n > 0 {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\n  {\"model\": ")
+	if err := writeJSON(buf, model); err != nil {
+		return err
+	}
+
+	var fields int
+	fieldsBuf := new(bytes.Buffer)
+	for _, item := range row {
+		column, _ := item.Key.(string)
+		if column == djangoPrimaryKey {
+			buf.WriteString(", \"pk\": ")
+			if err := writeJSON(buf, item.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if fields > 0 {
+			fieldsBuf.WriteString(", ")
+		}
+		if err := writeJSON(fieldsBuf, column); err != nil {
+			return err
+		}
+		fieldsBuf.WriteString(": ")
+		if err := writeJSON(fieldsBuf, item.Value); err != nil {
+			return err
+		}
+		fields++
+	}
+
+	buf.WriteString(", \"fields\": {")
+	buf.Write(fieldsBuf.Bytes())
+	buf.WriteString("}}")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// End closes the JSON list.
+func (e *djangoEncoder) End(w io.Writer, table Table, n int) error {
+	_, err := io.WriteString(w, "\n]\n")
+	return err
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) error {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	buf.Write(out)
+	return nil
+}
@@ -0,0 +1,158 @@
+package fixture
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/dumper/engine"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	fixtureDumper struct {
+		reader  reader.Reader
+		dir     string
+		encoder Encoder
+		models  map[string]string
+	}
+
+	// Table describes the table a fixture file is written for.
+	Table struct {
+		// Name is the table name.
+		Name string
+		// Model is the application model the table maps to, empty if not configured.
+		Model string
+	}
+
+	// Encoder writes the rows of a table into a fixture file.
+	Encoder interface {
+		// Extension returns the fixture file extension.
+		Extension() string
+		// Begin is called before the first row of a table is written.
+		Begin(w io.Writer, table Table) error
+		// Encode writes the n-th row of a table, with the columns in schema order.
+		Encode(w io.Writer, table Table, n int, row yaml.MapSlice) error
+		// End is called after the last row of a table is written, n being the amount of rows.
+		End(w io.Writer, table Table, n int) error
+	}
+)
+
+// NewDumper returns a new fixture dumper writing one file per table into dir.
+func NewDumper(dir string, encoder Encoder, rdr reader.Reader) dumper.Dumper {
+	return engine.New(rdr, &fixtureDumper{
+		reader:  rdr,
+		dir:     dir,
+		encoder: encoder,
+	})
+}
+
+// Configure collects the table to model mapping.
+func (d *fixtureDumper) Configure(cfgTables config.Tables) {
+	d.models = make(map[string]string, len(cfgTables))
+	for _, t := range cfgTables {
+		if t.Model != "" {
+			d.models[t.Name] = t.Model
+		}
+	}
+}
+
+// DumpStructure is a no-op, fixtures only carry data.
+func (d *fixtureDumper) DumpStructure(sql string) error {
+	log.Debug("fixtures do not contain the database structure, skipping")
+	return nil
+}
+
+// DumpTable writes the table rows into a fixture file.
+func (d *fixtureDumper) DumpTable(tableName string, rowChan <-chan database.Row) error {
+	columns, err := d.reader.GetColumns(tableName)
+	if err != nil {
+		drain(rowChan)
+		return fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	path := filepath.Join(d.dir, tableName+d.encoder.Extension())
+	f, err := os.Create(path)
+	if err != nil {
+		drain(rowChan)
+		return fmt.Errorf("failed to create fixture file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	n, err := d.writeRows(w, Table{Name: tableName, Model: d.models[tableName]}, columns, rowChan)
+	if err != nil {
+		drain(rowChan)
+		return fmt.Errorf("failed to write fixture file %s: %w", path, err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush fixture file %s: %w", path, err)
+	}
+
+	log.WithFields(log.Fields{
+		"table":   tableName,
+		"written": n,
+		"file":    path,
+	}).Debug("written fixtures")
+
+	return f.Close()
+}
+
+// Close is a no-op, fixture files are closed once a table is dumped.
+func (d *fixtureDumper) Close() error {
+	return nil
+}
+
+func (d *fixtureDumper) writeRows(w io.Writer, table Table, columns []string, rowChan <-chan database.Row) (int, error) {
+	if err := d.encoder.Begin(w, table); err != nil {
+		return 0, err
+	}
+
+	var n int
+	for row := range rowChan {
+		fixture := make(yaml.MapSlice, len(columns))
+		for i, column := range columns {
+			fixture[i] = yaml.MapItem{Key: column, Value: toFixtureValue(row.Get(column))}
+		}
+
+		if err := d.encoder.Encode(w, table, n, fixture); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, d.encoder.End(w, table, n)
+}
+
+// toFixtureValue converts a database value into a value that can be marshalled.
+func toFixtureValue(src interface{}) interface{} {
+	switch value := src.(type) {
+	case []byte:
+		return string(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case *interface{}:
+		if value == nil {
+			return nil
+		}
+		return toFixtureValue(*value)
+	default:
+		return value
+	}
+}
+
+// drain consumes the remaining rows so the reader does not block forever.
+func drain(rowChan <-chan database.Row) {
+	for range rowChan {
+	}
+}
@@ -0,0 +1,114 @@
+package fixture
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestDumpTableTestFixtures(t *testing.T) {
+	dir := t.TempDir()
+	d := &fixtureDumper{reader: &mockReader{}, dir: dir, encoder: new(testFixturesEncoder)}
+
+	rowChan := make(chan database.Row, 2)
+	rowChan <- newRow(int64(1), []byte("foo"), nil)
+	rowChan <- newRow(int64(2), "bar", "bar@example.test")
+	close(rowChan)
+
+	require.NoError(t, d.DumpTable("users", rowChan))
+
+	out, err := os.ReadFile(filepath.Join(dir, "users.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, `- id: 1
+  name: foo
+  email: null
+- id: 2
+  name: bar
+  email: bar@example.test
+`, string(out))
+}
+
+func TestDumpTableTestFixturesEmpty(t *testing.T) {
+	dir := t.TempDir()
+	d := &fixtureDumper{reader: &mockReader{}, dir: dir, encoder: new(testFixturesEncoder)}
+
+	rowChan := make(chan database.Row)
+	close(rowChan)
+
+	require.NoError(t, d.DumpTable("users", rowChan))
+
+	out, err := os.ReadFile(filepath.Join(dir, "users.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", string(out))
+}
+
+func TestDumpTableRails(t *testing.T) {
+	dir := t.TempDir()
+	d := &fixtureDumper{reader: &mockReader{}, dir: dir, encoder: new(railsEncoder)}
+	d.Configure(config.Tables{{Name: "users", Model: "Admin::User"}})
+
+	rowChan := make(chan database.Row, 1)
+	rowChan <- newRow(int64(1), "foo", nil)
+	close(rowChan)
+
+	require.NoError(t, d.DumpTable("users", rowChan))
+
+	out, err := os.ReadFile(filepath.Join(dir, "users.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, `_fixture:
+  model_class: Admin::User
+users_1:
+  id: 1
+  name: foo
+  email: null
+`, string(out))
+}
+
+func TestDumpTableDjango(t *testing.T) {
+	dir := t.TempDir()
+	d := &fixtureDumper{reader: &mockReader{}, dir: dir, encoder: new(djangoEncoder)}
+	d.Configure(config.Tables{{Name: "users", Model: "auth.user"}})
+
+	rowChan := make(chan database.Row, 2)
+	rowChan <- newRow(int64(1), "foo", nil)
+	rowChan <- newRow(int64(2), "bar", "bar@example.test")
+	close(rowChan)
+
+	require.NoError(t, d.DumpTable("users", rowChan))
+
+	out, err := os.ReadFile(filepath.Join(dir, "users.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+  {"model": "auth.user", "pk": 1, "fields": {"name": "foo", "email": null}},
+  {"model": "auth.user", "pk": 2, "fields": {"name": "bar", "email": "bar@example.test"}}
+]`, string(out))
+}
+
+var columns = database.NewColumns([]string{"id", "name", "email"})
+
+func newRow(values ...interface{}) database.Row {
+	return database.NewRow(columns, values)
+}
+
+type mockReader struct{}
+
+func (m *mockReader) GetTables() ([]string, error)  { return []string{"users"}, nil }
+func (m *mockReader) GetStructure() (string, error) { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) {
+	return columns.Names(), nil
+}
+func (m *mockReader) Close() error { return nil }
+func (m *mockReader) FormatColumn(tbl string, col string) string {
+	return fmt.Sprintf("%s.%s", tbl, col)
+}
+func (m *mockReader) ReadTable(string, chan<- database.Row, reader.ReadTableOpt) error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package fixture
+
+import (
+	"fmt"
+	"os"
+
+	parser "github.com/hellofresh/klepto/pkg/dsn"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// formats maps a dsn type to the encoder used to write the fixture files.
+var formats = map[string]Encoder{
+	"testfixtures": new(testFixturesEncoder),
+	"rails":        new(railsEncoder),
+	"django":       new(djangoEncoder),
+}
+
+type driver struct{}
+
+// IsSupported checks if the given dsn connection string is supported.
+func (m *driver) IsSupported(dsn string) bool {
+	d, err := parser.Parse(dsn)
+	if err != nil {
+		return false
+	}
+
+	_, ok := formats[d.Type]
+	return ok
+}
+
+// NewConnection creates the fixtures directory and retrieves a new fixture dumper.
+func (m *driver) NewConnection(opts dumper.ConnOpts, rdr reader.Reader) (dumper.Dumper, error) {
+	d, err := parser.Parse(opts.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Address == "" {
+		return nil, fmt.Errorf("no fixtures directory given in dsn %q", parser.Redacted(opts.DSN))
+	}
+
+	if err := os.MkdirAll(d.Address, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fixtures directory: %w", err)
+	}
+
+	return NewDumper(d.Address, formats[d.Type], rdr), nil
+}
+
+func init() {
+	dumper.Register("fixture", &driver{})
+}
@@ -0,0 +1,47 @@
+package fixture
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+// railsEncoder writes Rails YAML fixtures, a map of records keyed by fixture label.
+type railsEncoder struct{}
+
+// Extension returns the fixture file extension.
+func (e *railsEncoder) Extension() string { return ".yml" }
+
+// Begin sets the fixture model class when the table is mapped to a model.
+func (e *railsEncoder) Begin(w io.Writer, table Table) error {
+	if table.Model == "" {
+		return nil
+	}
+
+	out, err := yaml.Marshal(yaml.MapSlice{
+		{Key: "_fixture", Value: yaml.MapSlice{{Key: "model_class", Value: table.Model}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+// Encode writes a row labelled after the table and its position.
+func (e *railsEncoder) Encode(w io.Writer, table Table, n int, row yaml.MapSlice) error {
+	out, err := yaml.Marshal(yaml.MapSlice{
+		{Key: fmt.Sprintf("%s_%d", table.Name, n+1), Value: row},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+// End is a no-op, an empty file is a valid Rails fixture.
+func (e *railsEncoder) End(w io.Writer, table Table, n int) error { return nil }
@@ -0,0 +1,38 @@
+package fixture
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+// testFixturesEncoder writes github.com/go-testfixtures/testfixtures YAML files,
+// a list of records keyed by column name.
+type testFixturesEncoder struct{}
+
+// Extension returns the fixture file extension.
+func (e *testFixturesEncoder) Extension() string { return ".yml" }
+
+// Begin is a no-op, the file is a plain YAML list.
+func (e *testFixturesEncoder) Begin(w io.Writer, table Table) error { return nil }
+
+// Encode writes a row as a YAML list item.
+func (e *testFixturesEncoder) Encode(w io.Writer, table Table, n int, row yaml.MapSlice) error {
+	out, err := yaml.Marshal([]yaml.MapSlice{row})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+// End writes an empty list for tables without rows, so the fixture still empties the table.
+func (e *testFixturesEncoder) End(w io.Writer, table Table, n int) error {
+	if n > 0 {
+		return nil
+	}
+
+	_, err := io.WriteString(w, "[]\n")
+	return err
+}
@@ -1,13 +1,16 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"encoding/csv"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	log "github.com/sirupsen/logrus"
@@ -16,36 +19,54 @@ import (
 	"github.com/hellofresh/klepto/pkg/dumper"
 	"github.com/hellofresh/klepto/pkg/dumper/engine"
 	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/retry"
 )
 
 const (
 	null = "NULL"
+	// timeFormat is the format of the DATETIME and TIMESTAMP values, with their fractional seconds.
+	timeFormat = "2006-01-02 15:04:05.999999"
 )
 
 type (
 	myDumper struct {
 		conn                *sql.DB
 		reader              reader.Reader
+		retry               retry.Policy
+		writers             engine.Writers
 		setGlobalInline     sync.Once
 		disableGlobalInline bool
+		// insertRows is set when local_infile can not be enabled, the rows are then inserted with the statements.
+		insertRows bool
+		statements *engine.Statements
+		// registerReader registers the reader the rows of a LOAD DATA statement are read from.
+		registerReader func(name string, handler func() io.Reader)
 	}
 )
 
-// NewDumper returns a new mysql dumper.
-func NewDumper(conn *sql.DB, rdr reader.Reader) dumper.Dumper {
+// NewDumper returns a new mysql dumper, inserting the rows of each table with the writers.
+func NewDumper(conn *sql.DB, rdr reader.Reader, policy retry.Policy, writers engine.Writers) dumper.Dumper {
 	return engine.New(rdr, &myDumper{
-		conn:   conn,
-		reader: rdr,
+		conn:           conn,
+		reader:         rdr,
+		retry:          policy,
+		writers:        writers,
+		statements:     engine.NewStatements(conn),
+		registerReader: mysql.RegisterReaderHandler,
 	})
 }
 
 // DumpStructure dump the mysql database structure.
 func (d *myDumper) DumpStructure(sql string) error {
-	if _, err := d.conn.Exec(sql); err != nil {
+	return d.retry.Do(context.Background(), func() error {
+		_, err := d.conn.Exec(sql)
 		return err
-	}
+	})
+}
 
-	return nil
+// Exec executes a statement on the target database.
+func (d *myDumper) Exec(query string) error {
+	return d.DumpStructure(query)
 }
 
 // DumpTable dumps a mysql table.
@@ -62,7 +83,9 @@ func (d *myDumper) DumpTable(tableName string, rowChan <-chan database.Row) erro
 			return
 		}
 
-		if _, err = d.conn.Exec("SET GLOBAL local_infile=1"); err != nil {
+		if _, setErr := d.conn.Exec("SET GLOBAL local_infile=1"); setErr != nil {
+			log.WithError(setErr).Warn("failed to enable local_infile, the rows are inserted with prepared statements")
+			d.insertRows = true
 			return
 		}
 		d.disableGlobalInline = true
@@ -71,19 +94,17 @@ func (d *myDumper) DumpTable(tableName string, rowChan <-chan database.Row) erro
 		return err
 	}
 
-	txn, err := d.conn.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to open transaction: %w", err)
+	insert := func(txn *sql.Tx, worker int, rowChan <-chan database.Row) (int64, error) {
+		return d.insertIntoTable(txn, tableName, worker, rowChan)
+	}
+	if d.insertRows {
+		if insert, err = d.prepareInsert(tableName); err != nil {
+			return err
+		}
 	}
 
-	insertedRows, err := d.insertIntoTable(txn, tableName, rowChan)
+	insertedRows, err := d.writers.Insert(rowChan, d.conn.Begin, insert)
 	if err != nil {
-		defer func() {
-			if err := txn.Rollback(); err != nil {
-				log.WithError(err).Error("failed to rollback")
-			}
-		}()
-		err = fmt.Errorf("failed to insert rows: %w", err)
 		return err
 	}
 
@@ -92,15 +113,27 @@ func (d *myDumper) DumpTable(tableName string, rowChan <-chan database.Row) erro
 		"inserted": insertedRows,
 	}).Debug("inserted rows")
 
-	if err := txn.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	return nil
+}
+
+// Analyze runs ANALYZE TABLE on the tables.
+func (d *myDumper) Analyze(tables []string) error {
+	for _, table := range tables {
+		log.WithField("table", table).Debug("analyzing table")
+		if err := d.Exec("ANALYZE TABLE " + d.quoteIdentifier(table)); err != nil {
+			return fmt.Errorf("failed to analyze %s: %w", table, err)
+		}
 	}
 
 	return nil
 }
 
-// Close closes the mysql database connection.
+// Close closes the prepared statements and the mysql database connection.
 func (d *myDumper) Close() error {
+	if err := d.statements.Close(); err != nil {
+		log.WithError(err).Error("failed to close the prepared statements")
+	}
+
 	var errGlobalInline error
 	if d.disableGlobalInline {
 		_, errGlobalInline = d.conn.Exec("SET GLOBAL local_infile=0")
@@ -122,7 +155,7 @@ func (d *myDumper) Close() error {
 	return nil
 }
 
-func (d *myDumper) insertIntoTable(txn *sql.Tx, tableName string, rowChan <-chan database.Row) (int64, error) {
+func (d *myDumper) insertIntoTable(txn *sql.Tx, tableName string, worker int, rowChan <-chan database.Row) (int64, error) {
 	columns, err := d.reader.GetColumns(tableName)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get columns: %w", err)
@@ -133,15 +166,19 @@ func (d *myDumper) insertIntoTable(txn *sql.Tx, tableName string, rowChan <-chan
 		columnsQuoted[i] = d.quoteIdentifier(column)
 	}
 
+	// the workers of a table each load their rows from their own reader
+	handler := fmt.Sprintf("%s#%d", tableName, worker)
 	query := fmt.Sprintf(
 		"LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s FIELDS TERMINATED BY ',' ENCLOSED BY '\"' ESCAPED BY '\"' (%s)",
-		tableName,
+		handler,
 		d.quoteIdentifier(tableName),
 		strings.Join(columnsQuoted, ","),
 	)
 
 	// Write all rows as csv to the pipe
 	rowReader, rowWriter := io.Pipe()
+	// a failing LOAD DATA stops reading the rows, closing the pipe unblocks the writer so that the transaction ends
+	defer rowReader.Close()
 	var inserted int64
 	go func(writer *io.PipeWriter) {
 		defer writer.Close()
@@ -158,17 +195,7 @@ func (d *myDumper) insertIntoTable(txn *sql.Tx, tableName string, rowChan <-chan
 			// Put the data in the correct order and format
 			rowValues := make([]string, len(columns))
 			for i, col := range columns {
-				switch v := row[col].(type) {
-				case nil:
-					rowValues[i] = null
-				case string:
-					rowValues[i] = row[col].(string)
-				case []uint8:
-					rowValues[i] = string(row[col].([]uint8))
-				default:
-					log.WithField("type", v).Info("we have an unhandled type. attempting to convert to a string \n")
-					rowValues[i] = row[col].(string)
-				}
+				rowValues[i] = toCSVValue(row.Get(col))
 			}
 
 			if err := w.Write(rowValues); err != nil {
@@ -180,8 +207,8 @@ func (d *myDumper) insertIntoTable(txn *sql.Tx, tableName string, rowChan <-chan
 	}(rowWriter)
 
 	// Register the reader for reading the csv
-	mysql.RegisterReaderHandler(tableName, func() io.Reader { return rowReader })
-	defer mysql.DeregisterReaderHandler(tableName)
+	d.registerReader(handler, func() io.Reader { return rowReader })
+	defer mysql.DeregisterReaderHandler(handler)
 
 	if _, err := txn.Exec("SET foreign_key_checks = 0;"); err != nil {
 		return 0, fmt.Errorf("failed to disable foreign key checks: %w", err)
@@ -191,7 +218,87 @@ func (d *myDumper) insertIntoTable(txn *sql.Tx, tableName string, rowChan <-chan
 		return 0, fmt.Errorf("failed to execute query: %w", err)
 	}
 
-	return inserted, nil
+	return atomic.LoadInt64(&inserted), nil
+}
+
+// prepareInsert prepares the INSERT of the table, before the transactions writing it are opened, and returns the
+// function inserting the rows one by one with it.
+func (d *myDumper) prepareInsert(tableName string) (engine.InsertFunc, error) {
+	columns, err := d.reader.GetColumns(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	columnsQuoted := make([]string, len(columns))
+	params := make([]string, len(columns))
+	for i, column := range columns {
+		columnsQuoted[i] = d.quoteIdentifier(column)
+		params[i] = "?"
+	}
+
+	stmt, err := d.statements.Prepare(tableName, columns, fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		d.quoteIdentifier(tableName),
+		strings.Join(columnsQuoted, ","),
+		strings.Join(params, ","),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(txn *sql.Tx, _ int, rowChan <-chan database.Row) (int64, error) {
+		if _, err := txn.Exec("SET foreign_key_checks = 0;"); err != nil {
+			return 0, fmt.Errorf("failed to disable foreign key checks: %w", err)
+		}
+
+		// the statement is prepared again on the connection of the transaction only when it was not yet
+		insert := txn.Stmt(stmt)
+		var inserted int64
+		for row := range rowChan {
+			rowValues := make([]interface{}, len(columns))
+			for i, col := range columns {
+				rowValues[i] = row.Get(col)
+			}
+
+			if _, err := insert.Exec(rowValues...); err != nil {
+				return 0, fmt.Errorf("failed to insert row: %w", err)
+			}
+			inserted++
+		}
+
+		return inserted, nil
+	}, nil
+}
+
+// toCSVValue formats a value the way LOAD DATA reads it, e.g. the generated and cast values that are not text.
+func toCSVValue(src interface{}) string {
+	switch v := src.(type) {
+	case nil:
+		return null
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return v.Format(timeFormat)
+	case *interface{}:
+		if v == nil {
+			return null
+		}
+		return toCSVValue(*v)
+	default:
+		log.WithField("type", fmt.Sprintf("%T", v)).Warn("unhandled type, formatting it as text")
+		return fmt.Sprint(v)
+	}
 }
 
 func (d *myDumper) quoteIdentifier(name string) string {
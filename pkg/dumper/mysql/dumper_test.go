@@ -0,0 +1,209 @@
+package mysql
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/cast"
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/dumper/engine"
+	"github.com/hellofresh/klepto/pkg/generator"
+	"github.com/hellofresh/klepto/pkg/internal/sqlmock"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestDumpTableGenerated(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{
+		columns: []string{"id", "active", "created_at", "amount", "name"},
+		types: map[string]string{
+			"id":         "int",
+			"active":     "tinyint(1)",
+			"created_at": "datetime",
+			"amount":     "double",
+			"name":       "varchar(32)",
+		},
+	}
+	records := dumpTable(t, generator.NewReader(source, 3, 0), "users")
+
+	require.Len(t, records, 3)
+	for i, record := range records {
+		require.Len(t, record, 5)
+		assert.Equal(t, []string{"1", "2", "3"}[i], record[0], "the ids are numbered")
+		assert.Contains(t, []string{"0", "1"}, record[1], "the booleans are loaded as 0 or 1")
+		_, err := time.Parse(timeFormat, record[2])
+		assert.NoError(t, err)
+		assert.NotEmpty(t, record[4])
+	}
+}
+
+func TestDumpTableCast(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{
+		columns: []string{"id", "amount", "paid", "ratio", "paid_at", "due_on"},
+		rows: [][]interface{}{
+			{[]byte("1"), []byte("10.5"), []byte("1"), []byte("0.25"), []byte("2021-03-04 05:06:07"), []byte("2021-03-31")},
+			{[]byte("2"), nil, []byte("false"), []byte("2"), nil, []byte("2021-04-30T10:00:00Z")},
+		},
+	}
+	rdr, err := cast.NewReader(source, config.Tables{{Name: "orders", Cast: map[string]string{
+		"id":      "int",
+		"amount":  "decimal(10,2)",
+		"paid":    "bool",
+		"ratio":   "float",
+		"paid_at": "datetime",
+		"due_on":  "date",
+	}}})
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"1", "10.50", "1", "0.25", "2021-03-04 05:06:07", "2021-03-31 00:00:00"},
+		{"2", "NULL", "0", "2", "NULL", "2021-04-30 00:00:00"},
+	}, dumpTable(t, rdr, "orders"))
+}
+
+func TestDumpTableWithoutLocalInfile(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	insert := "^INSERT INTO `users` \\(`id`,`email`\\) VALUES \\(\\?,\\?\\)$"
+
+	mock.ExpectQuery(`^SELECT @@GLOBAL.local_infile$`).WillReturnRows(sqlmock.NewRows("@@GLOBAL.local_infile").AddRow(0))
+	mock.ExpectExec(`^SET GLOBAL local_infile=1$`).WillReturnError(errors.New("Access denied; you need the SUPER privilege"))
+	mock.ExpectPrepare(insert)
+	for _, id := range []int64{1, 2} {
+		mock.ExpectBegin()
+		mock.ExpectExec(`^SET foreign_key_checks = 0;$`)
+		mock.ExpectExec(insert).WithArgs(id, "a@example.com")
+		mock.ExpectExec(insert).WithArgs(id+10, nil)
+		mock.ExpectCommit()
+	}
+
+	d := &myDumper{
+		conn:       db,
+		reader:     &mockReader{columns: []string{"id", "email"}},
+		statements: engine.NewStatements(db),
+	}
+
+	// the statement prepared for the first dump of the table is reused by the next one
+	for _, id := range []int64{1, 2} {
+		rowChan := make(chan database.Row, 2)
+		columns := database.NewColumns([]string{"id", "email"})
+		rowChan <- database.NewRow(columns, []interface{}{id, "a@example.com"})
+		rowChan <- database.NewRow(columns, []interface{}{id + 10, nil})
+		close(rowChan)
+
+		require.NoError(t, d.DumpTable("users", rowChan))
+	}
+	assert.False(t, d.disableGlobalInline)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestToCSVValue(t *testing.T) {
+	t.Parallel()
+
+	value := interface{}(int64(7))
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "NULL"},
+		{"text", "text"},
+		{[]byte("bytes"), "bytes"},
+		{int64(-42), "-42"},
+		{float64(1.5), "1.5"},
+		{float64(1e21), "1000000000000000000000"},
+		{true, "1"},
+		{false, "0"},
+		{time.Date(2021, 3, 4, 5, 6, 7, 800000000, time.UTC), "2021-03-04 05:06:07.8"},
+		{&value, "7"},
+		{uint8(3), "3"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, toCSVValue(test.value))
+	}
+}
+
+// dumpTable dumps the rows read from the table into a mocked database, and returns the CSV records it loads.
+func dumpTable(t *testing.T, rdr reader.Reader, tableName string) [][]string {
+	t.Helper()
+
+	db, mock := sqlmock.New(t)
+
+	var (
+		mu      sync.Mutex
+		readers = make(map[string]func() io.Reader)
+		records [][]string
+	)
+	d := &myDumper{
+		conn:   db,
+		reader: rdr,
+		registerReader: func(name string, handler func() io.Reader) {
+			mu.Lock()
+			defer mu.Unlock()
+			readers[name] = handler
+		},
+	}
+
+	mock.ExpectQuery(`^SELECT @@GLOBAL.local_infile$`).WillReturnRows(sqlmock.NewRows("@@GLOBAL.local_infile").AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec(`^SET foreign_key_checks = 0;$`)
+	mock.ExpectExec(`^LOAD DATA LOCAL INFILE 'Reader::` + tableName + `#0' INTO TABLE`).WillRun(func() (err error) {
+		// the server reads the rows of the registered reader
+		mu.Lock()
+		handler := readers[tableName+"#0"]
+		mu.Unlock()
+
+		records, err = csv.NewReader(handler()).ReadAll()
+		return err
+	})
+	mock.ExpectCommit()
+
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- rdr.ReadTable(tableName, rowChan, reader.ReadTableOpt{})
+	}()
+
+	require.NoError(t, d.DumpTable(tableName, rowChan))
+	require.NoError(t, <-errChan)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	return records
+}
+
+type mockReader struct {
+	reader.Reader
+	columns []string
+	types   map[string]string
+	rows    [][]interface{}
+}
+
+func (m *mockReader) GetColumns(string) ([]string, error) {
+	return m.columns, nil
+}
+
+func (m *mockReader) GetColumnTypes(string) (map[string]string, error) {
+	return m.types, nil
+}
+
+func (m *mockReader) ReadTable(_ string, rowChan chan<- database.Row, _ reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	columns := database.NewColumns(m.columns)
+	for _, values := range m.rows {
+		rowChan <- database.NewRow(columns, values)
+	}
+
+	return nil
+}
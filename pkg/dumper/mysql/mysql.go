@@ -8,6 +8,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/dumper/engine"
 	"github.com/hellofresh/klepto/pkg/reader"
 )
 
@@ -44,8 +45,15 @@ func (m *driver) NewConnection(opts dumper.ConnOpts, rdr reader.Reader) (dumper.
 	conn.SetMaxOpenConns(opts.MaxConns)
 	conn.SetMaxIdleConns(opts.MaxIdleConns)
 	conn.SetConnMaxLifetime(opts.MaxConnLifetime)
-
-	return NewDumper(conn, rdr), nil
+	conn.SetConnMaxIdleTime(opts.MaxConnIdleTime)
+
+	return NewDumper(conn, rdr, opts.Retry, engine.Writers{
+		Workers:       opts.WriteWorkers,
+		MaxConns:      opts.TableMaxConns,
+		OrderedCommit: opts.OrderedCommit,
+		Retry:         opts.Retry,
+		ReplayRows:    opts.ReplayRows,
+	}), nil
 }
 
 func init() {
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/hellofresh/klepto/pkg/dumper"
 	"github.com/hellofresh/klepto/pkg/dumper/engine"
 	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/retry"
 )
 
 type (
@@ -25,6 +27,8 @@ type (
 		conn        *sql.DB
 		reader      reader.Reader
 		isRDS       bool
+		retry       retry.Policy
+		writers     engine.Writers
 		foreignKeys []foreignKeyInfo
 	}
 )
@@ -35,33 +39,28 @@ func NewDumper(opts dumper.ConnOpts, conn *sql.DB, rdr reader.Reader) dumper.Dum
 		conn:   conn,
 		reader: rdr,
 		isRDS:  opts.IsRDS,
+		retry:  opts.Retry,
+		writers: engine.Writers{
+			Workers:       opts.WriteWorkers,
+			MaxConns:      opts.TableMaxConns,
+			OrderedCommit: opts.OrderedCommit,
+			Retry:         opts.Retry,
+			ReplayRows:    opts.ReplayRows,
+		},
 	})
 }
 
 // DumpStructure dump the mysql database structure.
 func (d *pgDumper) DumpStructure(sql string) error {
-	if _, err := d.conn.Exec(sql); err != nil {
-		return err
-	}
-
-	return nil
+	return d.exec(sql)
 }
 
 // DumpTable dumps a postgres table.
 func (d *pgDumper) DumpTable(tableName string, rowChan <-chan database.Row) error {
-	txn, err := d.conn.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to open transaction: %w", err)
-	}
-
-	insertedRows, err := d.insertIntoTable(txn, tableName, rowChan)
+	insertedRows, err := d.writers.Insert(rowChan, d.conn.Begin, func(txn *sql.Tx, _ int, rowChan <-chan database.Row) (int64, error) {
+		return d.insertIntoTable(txn, tableName, rowChan)
+	})
 	if err != nil {
-		defer func() {
-			if err := txn.Rollback(); err != nil {
-				log.WithError(err).Error("failed to rollback")
-			}
-		}()
-		err = fmt.Errorf("failed to insert rows: %w", err)
 		return err
 	}
 
@@ -70,10 +69,6 @@ func (d *pgDumper) DumpTable(tableName string, rowChan <-chan database.Row) erro
 		"inserted": insertedRows,
 	}).Debug("inserted rows")
 
-	if err := txn.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return nil
 }
 
@@ -86,7 +81,7 @@ func (d *pgDumper) PreDumpTables(tables []string) error {
 		log.Debug("Disabling triggers")
 		for _, tbl := range tables {
 			query := fmt.Sprintf("ALTER TABLE %q DISABLE TRIGGER ALL", strings.Trim(tbl, "\""))
-			if _, err := d.conn.Exec(query); err != nil {
+			if err := d.exec(query); err != nil {
 				return fmt.Errorf("failed to disable triggers for %s: %w", tbl, err)
 			}
 		}
@@ -112,7 +107,7 @@ func (d *pgDumper) PreDumpTables(tables []string) error {
 			return fmt.Errorf("failed to load ForeignKeyInfo: %w", err)
 		}
 		query := fmt.Sprintf("ALTER TABLE %q DROP CONSTRAINT %q", strings.Trim(fk.tableName, "\""), strings.Trim(fk.constraintName, "\""))
-		if _, err := d.conn.Exec(query); err != nil {
+		if err := d.exec(query); err != nil {
 			return fmt.Errorf("failed to drop constraint %s.%s: %w", fk.tableName, fk.constraintName, err)
 		}
 		d.foreignKeys = append(d.foreignKeys, fk)
@@ -127,7 +122,7 @@ func (d *pgDumper) PostDumpTables(tables []string) error {
 		log.Debug("Reenabling triggers")
 		for _, tbl := range tables {
 			query := fmt.Sprintf("ALTER TABLE %q ENABLE TRIGGER ALL", strings.Trim(tbl, "\""))
-			if _, err := d.conn.Exec(query); err != nil {
+			if err := d.exec(query); err != nil {
 				return fmt.Errorf("failed to enable triggers for %s: %w", tbl, err)
 			}
 		}
@@ -137,13 +132,62 @@ func (d *pgDumper) PostDumpTables(tables []string) error {
 	log.Debug("Recreating foreign keys")
 	for _, fk := range d.foreignKeys {
 		query := fmt.Sprintf("ALTER TABLE %q ADD CONSTRAINT %q %s", strings.Trim(fk.tableName, "\""), strings.Trim(fk.constraintName, "\""), fk.constraintDefinition)
-		if _, err := d.conn.Exec(query); err != nil {
+		if err := d.exec(query); err != nil {
 			return fmt.Errorf("failed to re-create ForeignKey %s.%s: %w", fk.tableName, fk.constraintName, err)
 		}
 	}
 	return nil
 }
 
+// Analyze runs ANALYZE on the tables.
+func (d *pgDumper) Analyze(tables []string) error {
+	for _, tbl := range tables {
+		log.WithField("table", tbl).Debug("analyzing table")
+		if err := d.exec(fmt.Sprintf("ANALYZE %q", strings.Trim(tbl, "\""))); err != nil {
+			return fmt.Errorf("failed to analyze %s: %w", tbl, err)
+		}
+	}
+
+	return nil
+}
+
+// RefreshViews runs REFRESH MATERIALIZED VIEW on the materialized views, in the order they were created so that the
+// views selecting from other ones are refreshed after them.
+func (d *pgDumper) RefreshViews() ([]string, error) {
+	rows, err := d.conn.Query(
+		`SELECT cl.oid::regclass::text FROM pg_class cl
+		 JOIN pg_namespace ns ON ns.oid = cl.relnamespace
+		 WHERE cl.relkind = 'm' AND ns.nspname NOT IN ('pg_catalog', 'information_schema')
+		 ORDER BY cl.oid`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query materialized views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []string
+	for rows.Next() {
+		var view string
+		if err := rows.Scan(&view); err != nil {
+			return nil, fmt.Errorf("failed to load materialized view: %w", err)
+		}
+		views = append(views, view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, view := range views {
+		log.WithField("view", view).Debug("refreshing materialized view")
+		// the name is quoted by regclass
+		if err := d.exec("REFRESH MATERIALIZED VIEW " + view); err != nil {
+			return nil, fmt.Errorf("failed to refresh %s: %w", view, err)
+		}
+	}
+
+	return views, nil
+}
+
 // Close closes the postgres database connection.
 func (d *pgDumper) Close() error {
 	err := d.conn.Close()
@@ -153,6 +197,19 @@ func (d *pgDumper) Close() error {
 	return nil
 }
 
+// Exec executes a statement on the target database.
+func (d *pgDumper) Exec(query string) error {
+	return d.exec(query)
+}
+
+// exec executes a statement, retrying it on transient errors.
+func (d *pgDumper) exec(query string) error {
+	return d.retry.Do(context.Background(), func() error {
+		_, err := d.conn.Exec(query)
+		return err
+	})
+}
+
 func (d *pgDumper) insertIntoTable(txn *sql.Tx, tableName string, rowChan <-chan database.Row) (int64, error) {
 	columns, err := d.reader.GetColumns(tableName)
 	if err != nil {
@@ -187,7 +244,7 @@ func (d *pgDumper) insertIntoTable(txn *sql.Tx, tableName string, rowChan <-chan
 		// Put the data in the correct order
 		rowValues := make([]interface{}, len(columns))
 		for i, col := range columns {
-			val := row[col]
+			val := row.Get(col)
 			if bytesVal, ok := val.([]byte); ok {
 				val = string(bytesVal)
 			}
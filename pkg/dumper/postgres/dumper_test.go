@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/dumper/engine"
+	"github.com/hellofresh/klepto/pkg/internal/sqlmock"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/retry"
+)
+
+type mockReader struct {
+	reader.Reader
+}
+
+func (m *mockReader) GetColumns(string) ([]string, error) {
+	return []string{"id", "email"}, nil
+}
+
+func TestDumpTableRetriesDeadlock(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	copyIn := `^COPY "users" \("id", "email"\) FROM STDIN$`
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(copyIn)
+	mock.ExpectExec(copyIn).WithArgs(int64(1), "a@example.com")
+	mock.ExpectExec(copyIn).WithArgs(int64(2), "b@example.com").
+		WillReturnError(&pq.Error{Code: "40P01", Message: "deadlock detected"})
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectPrepare(copyIn)
+	mock.ExpectExec(copyIn).WithArgs(int64(1), "a@example.com")
+	mock.ExpectExec(copyIn).WithArgs(int64(2), "b@example.com")
+	mock.ExpectExec(copyIn).WithArgs(int64(3), "c@example.com")
+	mock.ExpectExec(copyIn).WithArgs()
+	mock.ExpectCommit()
+
+	d := &pgDumper{
+		conn:    db,
+		reader:  &mockReader{},
+		writers: engine.Writers{Retry: retry.Policy{Attempts: 2}, ReplayRows: 10},
+	}
+
+	rowChan := make(chan database.Row)
+	go func() {
+		defer close(rowChan)
+		columns := database.NewColumns([]string{"id", "email"})
+		for i, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			rowChan <- database.NewRow(columns, []interface{}{int64(i + 1), []byte(email)})
+		}
+	}()
+
+	require.NoError(t, d.DumpTable("users", rowChan))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -23,6 +23,7 @@ func (m *driver) NewConnection(opts dumper.ConnOpts, rdr reader.Reader) (dumper.
 	conn.SetMaxOpenConns(opts.MaxConns)
 	conn.SetMaxIdleConns(opts.MaxIdleConns)
 	conn.SetConnMaxLifetime(opts.MaxConnLifetime)
+	conn.SetConnMaxIdleTime(opts.MaxConnIdleTime)
 
 	return NewDumper(opts, conn, rdr), nil
 }
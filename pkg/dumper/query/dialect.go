@@ -0,0 +1,266 @@
+package query
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+type (
+	// Dialect quotes the identifiers and formats the literals of a SQL flavour.
+	Dialect interface {
+		// QuoteIdentifier quotes a table or column name.
+		QuoteIdentifier(name string) string
+		// FormatValue formats a value as a SQL literal.
+		FormatValue(src interface{}) (string, error)
+	}
+
+	// dialect describes how a SQL flavour quotes identifiers and formats literals.
+	dialect struct {
+		name string
+		// identQuote is the character used to quote identifiers.
+		identQuote string
+		// escapeString escapes a string literal content.
+		escapeString func(string) string
+		// trueValue and falseValue are the boolean literals.
+		trueValue, falseValue string
+		// timeFormat is the layout used for timestamp literals.
+		timeFormat string
+		// timePrefix is written before timestamp literals, e.g. TIMESTAMP.
+		timePrefix string
+		// castJSON casts the values of JSON columns, so that they are not loaded as strings.
+		castJSON bool
+		// insertOverride is written before the values of the inserts, e.g. so that they are accepted
+		// by identity columns.
+		insertOverride string
+	}
+)
+
+var (
+	standardEscaper = strings.NewReplacer(`'`, `''`)
+	mysqlEscaper    = strings.NewReplacer(
+		`\`, `\\`,
+		`'`, `\'`,
+		"\x00", `\0`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\x1a", `\Z`,
+	)
+
+	dialects = map[string]*dialect{
+		"mysql": {
+			name:         "mysql",
+			identQuote:   "`",
+			escapeString: mysqlEscaper.Replace,
+			trueValue:    "1",
+			falseValue:   "0",
+			timeFormat:   "2006-01-02 15:04:05.999999",
+			castJSON:     true,
+		},
+		"postgres": {
+			name:         "postgres",
+			identQuote:   `"`,
+			escapeString: standardEscaper.Replace,
+			trueValue:    "TRUE",
+			falseValue:   "FALSE",
+			timeFormat:   "2006-01-02 15:04:05.999999-07:00",
+			// GENERATED ALWAYS identity columns reject the values of the rows otherwise,
+			// the clause is accepted by any table since Postgres 10
+			insertOverride: "OVERRIDING SYSTEM VALUE ",
+		},
+		"sqlite": {
+			name:         "sqlite",
+			identQuote:   `"`,
+			escapeString: standardEscaper.Replace,
+			trueValue:    "1",
+			falseValue:   "0",
+			timeFormat:   "2006-01-02 15:04:05.999",
+		},
+		"redshift": {
+			name:         "redshift",
+			identQuote:   `"`,
+			escapeString: standardEscaper.Replace,
+			trueValue:    "TRUE",
+			falseValue:   "FALSE",
+			timeFormat:   "2006-01-02 15:04:05.999999",
+		},
+		"ansi": {
+			name:         "ansi",
+			identQuote:   `"`,
+			escapeString: standardEscaper.Replace,
+			trueValue:    "TRUE",
+			falseValue:   "FALSE",
+			timeFormat:   "2006-01-02 15:04:05.999999",
+			timePrefix:   "TIMESTAMP ",
+		},
+	}
+)
+
+// getDialect returns the dialect registered with the given name.
+func getDialect(name string) (*dialect, error) {
+	d, ok := dialects[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(dialects))
+		for n := range dialects {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("unknown target dialect %q, supported dialects are %s", name, strings.Join(names, ", "))
+	}
+
+	return d, nil
+}
+
+// GetDialect returns the dialect with the given name: mysql, postgres, redshift, sqlite or ansi.
+func GetDialect(name string) (Dialect, error) {
+	d, err := getDialect(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// QuoteIdentifier quotes a table or column name.
+func (d *dialect) QuoteIdentifier(name string) string {
+	return d.identQuote + strings.ReplaceAll(name, d.identQuote, d.identQuote+d.identQuote) + d.identQuote
+}
+
+// Insert builds an INSERT statement for the row with an explicit column list.
+func (d *dialect) Insert(tableName string, columns []string, row database.Row) (string, error) {
+	var b strings.Builder
+	if err := d.WriteInsert(&b, tableName, columns, row); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// WriteInsert writes the INSERT statement of the row, the large values are streamed chunk by chunk.
+func (d *dialect) WriteInsert(w io.Writer, tableName string, columns []string, row database.Row) error {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = d.QuoteIdentifier(column)
+	}
+	if _, err := fmt.Fprintf(w, "INSERT INTO %s (%s) %sVALUES (", d.QuoteIdentifier(tableName), strings.Join(quoted, ", "), d.insertOverride); err != nil {
+		return err
+	}
+
+	for i, column := range columns {
+		if i > 0 {
+			if _, err := io.WriteString(w, ", "); err != nil {
+				return err
+			}
+		}
+
+		if lob, ok := row.Get(column).(*database.LOB); ok {
+			if err := d.writeLOB(w, lob); err != nil {
+				return fmt.Errorf("could not write column %s: %w", column, err)
+			}
+			continue
+		}
+
+		value, err := d.FormatValue(row.Get(column))
+		if err != nil {
+			return fmt.Errorf("could not format column %s: %w", column, err)
+		}
+		if d.castJSON && row.ColumnType(column) == jsonType && row.Get(column) != nil {
+			value = "CAST(" + value + " AS JSON)"
+		}
+		if _, err := io.WriteString(w, value); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, ");")
+	return err
+}
+
+// writeLOB writes a large value as a string literal, or a hex literal for binary values.
+func (d *dialect) writeLOB(w io.Writer, lob *database.LOB) error {
+	prefix, format := "'", d.escapeString
+	if lob.Binary {
+		prefix, format = d.hexPrefix(), func(chunk string) string { return hex.EncodeToString([]byte(chunk)) }
+	}
+
+	if _, err := io.WriteString(w, prefix); err != nil {
+		return err
+	}
+	if err := lob.Chunks(func(chunk []byte) error {
+		_, err := io.WriteString(w, format(string(chunk)))
+		return err
+	}); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "'")
+	return err
+}
+
+// FormatValue formats a value as a SQL literal.
+func (d *dialect) FormatValue(src interface{}) (string, error) {
+	switch value := src.(type) {
+	case nil:
+		return "NULL", nil
+	case *interface{}:
+		if value == nil {
+			return "NULL", nil
+		}
+		return d.FormatValue(*value)
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), nil
+	case bool:
+		if value {
+			return d.trueValue, nil
+		}
+		return d.falseValue, nil
+	case string:
+		return d.quoteString(value), nil
+	case []byte:
+		// drivers return text columns as bytes, only fall back to hex for invalid text
+		if !isText(value) {
+			return d.hexLiteral(value), nil
+		}
+		return d.quoteString(string(value)), nil
+	case time.Time:
+		return d.timePrefix + d.quoteString(value.Format(d.timeFormat)), nil
+	default:
+		return "", fmt.Errorf("could not format type %T", src)
+	}
+}
+
+func (d *dialect) quoteString(s string) string {
+	return "'" + d.escapeString(s) + "'"
+}
+
+func (d *dialect) hexLiteral(b []byte) string {
+	return d.hexPrefix() + hex.EncodeToString(b) + "'"
+}
+
+// hexPrefix returns the start of a hex literal, closed by a quote.
+func (d *dialect) hexPrefix() string {
+	switch d.name {
+	case "postgres":
+		return `'\x`
+	case "redshift":
+		// redshift has no binary type to load bytes into, keep them as hex text
+		return "'"
+	}
+
+	return "X'"
+}
+
+// isText reports whether the bytes are valid UTF-8 without NUL characters.
+func isText(b []byte) bool {
+	s := string(b)
+	return !strings.ContainsRune(s, 0) && strings.ToValidUTF8(s, "") == s
+}
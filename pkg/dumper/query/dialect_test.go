@@ -0,0 +1,107 @@
+package query
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+func TestDialectInsert(t *testing.T) {
+	columns := []string{"id", "name", "active", "created_at", "deleted_at"}
+	row := database.NewRow(database.NewColumns(columns), []interface{}{
+		int64(1),
+		[]byte("O'Reilly"),
+		true,
+		time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		nil,
+	})
+
+	tests := []struct {
+		dialect  string
+		expected string
+	}{
+		{
+			dialect:  "mysql",
+			expected: "INSERT INTO `users` (`id`, `name`, `active`, `created_at`, `deleted_at`) VALUES (1, 'O\\'Reilly', 1, '2020-01-02 03:04:05', NULL);",
+		},
+		{
+			dialect:  "postgres",
+			expected: `INSERT INTO "users" ("id", "name", "active", "created_at", "deleted_at") OVERRIDING SYSTEM VALUE VALUES (1, 'O''Reilly', TRUE, '2020-01-02 03:04:05+00:00', NULL);`,
+		},
+		{
+			dialect:  "sqlite",
+			expected: `INSERT INTO "users" ("id", "name", "active", "created_at", "deleted_at") VALUES (1, 'O''Reilly', 1, '2020-01-02 03:04:05', NULL);`,
+		},
+		{
+			dialect:  "redshift",
+			expected: `INSERT INTO "users" ("id", "name", "active", "created_at", "deleted_at") VALUES (1, 'O''Reilly', TRUE, '2020-01-02 03:04:05', NULL);`,
+		},
+		{
+			dialect:  "ansi",
+			expected: `INSERT INTO "users" ("id", "name", "active", "created_at", "deleted_at") VALUES (1, 'O''Reilly', TRUE, TIMESTAMP '2020-01-02 03:04:05', NULL);`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dialect, func(t *testing.T) {
+			d, err := getDialect(test.dialect)
+			require.NoError(t, err)
+
+			insert, err := d.Insert("users", columns, row)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, insert)
+		})
+	}
+}
+
+func TestDialectBinaryValue(t *testing.T) {
+	d, err := getDialect("postgres")
+	require.NoError(t, err)
+
+	value, err := d.FormatValue([]byte{0x00, 0xff})
+	require.NoError(t, err)
+	assert.Equal(t, `'\x00ff'`, value)
+
+	d, err = getDialect("redshift")
+	require.NoError(t, err)
+
+	value, err = d.FormatValue([]byte{0x00, 0xff})
+	require.NoError(t, err)
+	assert.Equal(t, `'00ff'`, value)
+}
+
+func TestDialectWriteInsertLOB(t *testing.T) {
+	lob := func(value string, binary bool) *database.LOB {
+		return database.NewLOB(int64(len(value)), binary, 2, func(offset int64, length int64) ([]byte, error) {
+			end := offset - 1 + length
+			if end > int64(len(value)) {
+				end = int64(len(value))
+			}
+			return []byte(value[offset-1 : end]), nil
+		})
+	}
+
+	columns := []string{"id", "body", "data"}
+	row := database.NewRow(database.NewColumns(columns), []interface{}{
+		int64(1),
+		lob("O'Reilly", false),
+		lob("\x00\xff", true),
+	})
+
+	d, err := getDialect("postgres")
+	require.NoError(t, err)
+
+	var b strings.Builder
+	require.NoError(t, d.WriteInsert(&b, "docs", columns, row))
+	assert.Equal(t, `INSERT INTO "docs" ("id", "body", "data") OVERRIDING SYSTEM VALUE VALUES (1, 'O''Reilly', '\x00ff');`, b.String())
+}
+
+func TestGetDialectUnknown(t *testing.T) {
+	_, err := getDialect("oracle")
+	assert.EqualError(t, err, `unknown target dialect "oracle", supported dialects are ansi, mysql, postgres, redshift, sqlite`)
+}
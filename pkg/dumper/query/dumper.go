@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,42 +16,88 @@ import (
 	"github.com/hellofresh/klepto/pkg/database"
 	"github.com/hellofresh/klepto/pkg/dumper"
 	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/spool"
+)
+
+const (
+	// jsonType is the database type name of JSON columns.
+	jsonType = "JSON"
+	// defaultReadAheadBudget is the memory the rows of the tables read ahead are buffered in when no budget is given,
+	// the rows over it are spilled to disk.
+	defaultReadAheadBudget = 64 << 20
 )
 
 type (
 	textDumper struct {
 		reader reader.Reader
-		output io.Writer
+		output *bufferedWriter
+		// dialect is the SQL dialect of the written statements, nil keeps the generic output.
+		dialect *dialect
+		// interleave writes the rows of the tables read at once as they come instead of one table after the other.
+		interleave bool
+		// budget is the memory the rows of the tables read ahead of the one being written are buffered in, the rows
+		// over it are spilled to files in spillDir.
+		budget   *spool.Budget
+		spillDir string
+		// mu keeps the statements of the tables from interleaving in the output.
+		mu sync.Mutex
+	}
+
+	// tableDump is a table being read for the dump.
+	tableDump struct {
+		result  *dumper.TableResult
+		columns []string
+		opts    reader.ReadTableOpt
+		rows    chan database.Row
+		readErr chan error
 	}
 )
 
 // NewDumper returns a new text dumper implementation.
 func NewDumper(output io.Writer, rdr reader.Reader) dumper.Dumper {
+	return newTextDumper(output, rdr, nil, DefaultBufferSize, false)
+}
+
+// newTextDumper returns a text dumper writing the statements of the dialect, when set, through a buffer of bufferSize bytes.
+func newTextDumper(output io.Writer, rdr reader.Reader, dialect *dialect, bufferSize int, interleave bool) *textDumper {
 	return &textDumper{
-		reader: rdr,
-		output: output,
+		reader:     rdr,
+		output:     newBufferedWriter(output, bufferSize),
+		dialect:    dialect,
+		interleave: interleave,
+		budget:     spool.NewBudget(defaultReadAheadBudget),
 	}
 }
 
 // Dump executes the dump stream process.
-func (d *textDumper) Dump(done chan<- struct{}, cfgTables config.Tables, concurrency int, dataOnly bool) error {
+func (d *textDumper) Dump(cfgTables config.Tables, concurrency int, dataOnly bool) (*dumper.Result, error) {
 	tables, err := d.reader.GetTables()
 	if err != nil {
-		return fmt.Errorf("failed to get tables: %w", err)
+		return nil, fmt.Errorf("failed to get tables: %w", err)
 	}
 
+	// the indexes and constraints of the post-data section are written after the rows
+	var postData string
 	if !dataOnly {
-		structure, err := d.reader.GetStructure()
+		var preData string
+		preData, postData, err = reader.GetStructureSections(d.reader)
 		if err != nil {
-			return fmt.Errorf("could not get database structure: %w", err)
+			return nil, fmt.Errorf("could not get database structure: %w", err)
 		}
-		if _, err := io.WriteString(d.output, structure); err != nil {
-			return fmt.Errorf("could not write structure to output: %w", err)
+		if _, err := io.WriteString(d.output, preData); err != nil {
+			return nil, fmt.Errorf("could not write structure to output: %w", err)
+		}
+		if d.dialect != nil {
+			log.WithField("dialect", d.dialect.name).Warn("the structure is written as read from the source and is not converted to the target dialect")
 		}
 	}
 
-	var wg sync.WaitGroup
-	for _, tbl := range tables {
+	result := &dumper.Result{Tables: make([]dumper.TableResult, len(tables))}
+	var dumps []*tableDump
+	for i, tbl := range tables {
+		table := &result.Tables[i]
+		table.Name = tbl
+
 		var opts reader.ReadTableOpt
 		logger := log.WithField("table", tbl)
 
@@ -60,78 +107,281 @@ func (d *textDumper) Dump(done chan<- struct{}, cfgTables config.Tables, concurr
 		} else {
 			if tableConfig.IgnoreData {
 				logger.Debug("ignoring data to dump")
+				table.Skipped = true
 				continue
 			}
 			opts = reader.NewReadTableOpt(tableConfig)
 		}
 
-		// Create read/write chanel
-		rowChan := make(chan database.Row)
+		// the inserts list the columns in the table order, so that dumps of the same data are identical
+		columns, err := d.reader.GetColumns(tbl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get columns for %s: %w", tbl, err)
+		}
 
-		wg.Add(1)
-		go func(tableName string) {
-			defer wg.Done()
+		dumps = append(dumps, &tableDump{
+			result:  table,
+			columns: columns,
+			opts:    opts,
+			rows:    make(chan database.Row),
+			readErr: make(chan error, 1),
+		})
+	}
 
-			for {
-				row, more := <-rowChan
-				if !more {
-					return
-				}
-
-				columnMap, err := d.toSQLColumnMap(row)
-				if err != nil {
-					logger.WithError(err).Fatal("could not convert value to string")
-				}
-
-				insert := sq.Insert(tableName).SetMap(columnMap)
-				if _, err := io.WriteString(d.output, sq.DebugSqlizer(insert)); err != nil {
-					logger.WithError(err).Error("could not write insert statement to output")
-				}
-				if _, err := io.WriteString(d.output, "\n"); err != nil {
-					logger.WithError(err).Error("could not write new line to output")
-				}
-			}
-		}(tbl)
+	// up to concurrency tables are read at once, they are started in the order they are written
+	stop := make(chan struct{})
+	go d.readTables(dumps, concurrency, stop)
+
+	var writeErr error
+	if d.interleave {
+		writeErr = d.writeInterleaved(dumps, stop)
+	} else {
+		writeErr = d.writeOrdered(dumps, stop)
+	}
+	if writeErr != nil {
+		return nil, writeErr
+	}
 
-		if err := d.reader.ReadTable(tbl, rowChan, opts); err != nil {
-			log.WithError(err).WithField("table", tbl).Error("error while reading table")
+	if postData != "" {
+		if _, err := io.WriteString(d.output, "\n"+postData); err != nil {
+			return nil, fmt.Errorf("could not write post-data structure to output: %w", err)
 		}
 	}
+	if err := d.output.Flush(); err != nil {
+		return nil, fmt.Errorf("could not flush output: %w", err)
+	}
+
+	return result, nil
+}
+
+// readTables reads the tables with up to concurrency tables read at once, the tables not started when stop is closed are not read.
+func (d *textDumper) readTables(dumps []*tableDump, concurrency int, stop <-chan struct{}) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	semChan := make(chan struct{}, concurrency)
+	// the tables written one after the other are read ahead of their turn
+	spooled := !d.interleave && concurrency > 1
+
+	for _, t := range dumps {
+		select {
+		case semChan <- struct{}{}:
+		case <-stop:
+			close(t.rows)
+			t.readErr <- nil
+			continue
+		}
 
+		go func(t *tableDump) {
+			defer func() { <-semChan }()
+			t.readErr <- d.readTable(t, spooled)
+		}(t)
+	}
+}
+
+// readTable reads the rows of the table, spooling them when set so that the read is not held back until the table
+// is written: a read left waiting could outlast the read timeout.
+func (d *textDumper) readTable(t *tableDump, spooled bool) error {
+	if !spooled {
+		return d.reader.ReadTable(t.result.Name, t.rows, t.opts)
+	}
+
+	rawChan := make(chan database.Row)
+	errChan := make(chan error, 1)
 	go func() {
-		wg.Wait()
-		done <- struct{}{}
+		errChan <- d.reader.ReadTable(t.result.Name, rawChan, t.opts)
 	}()
 
+	spool.Pipe(t.result.Name, d.budget, d.spillDir, rawChan, t.rows)
+
+	return <-errChan
+}
+
+// writeOrdered writes the tables one after the other, the rows of the tables read ahead are spooled until their turn.
+func (d *textDumper) writeOrdered(dumps []*tableDump, stop chan<- struct{}) error {
+	var writeErr error
+	for _, t := range dumps {
+		if err := d.writeTable(t, writeErr != nil); err != nil {
+			writeErr = err
+			close(stop)
+		}
+	}
+
+	return writeErr
+}
+
+// writeInterleaved writes the tables as their rows are read, every insert names its table so the statements of the
+// tables can be mixed in the output.
+func (d *textDumper) writeInterleaved(dumps []*tableDump, stop chan<- struct{}) error {
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		writeErr error
+	)
+	for _, t := range dumps {
+		wg.Add(1)
+		go func(t *tableDump) {
+			defer wg.Done()
+			if err := d.writeTable(t, false); err != nil {
+				once.Do(func() {
+					writeErr = err
+					close(stop)
+				})
+			}
+		}(t)
+	}
+	wg.Wait()
+
+	return writeErr
+}
+
+// writeTable writes the rows of the table, or only drains them when discard is set, and records the outcome of the read.
+func (d *textDumper) writeTable(t *tableDump, discard bool) error {
+	var writeErr error
+	for row := range t.rows {
+		if discard || writeErr != nil {
+			// keep draining the channel so the reader does not block
+			continue
+		}
+		if writeErr = d.writeRow(t.result.Name, t.columns, row); writeErr == nil {
+			t.result.Rows++
+		}
+	}
+
+	if readErr := <-t.readErr; readErr != nil {
+		log.WithError(readErr).WithField("table", t.result.Name).Error("error while reading table")
+		t.result.Err = fmt.Errorf("failed to read table: %w", readErr)
+	}
+	if writeErr != nil {
+		return fmt.Errorf("could not write rows of %s to output: %w", t.result.Name, writeErr)
+	}
+
+	return nil
+}
+
+// Exec writes the statement to the output.
+func (d *textDumper) Exec(query string) error {
+	statement := strings.TrimSpace(query)
+	if !strings.HasSuffix(statement, ";") {
+		statement += ";"
+	}
+	if _, err := io.WriteString(d.output, statement+"\n"); err != nil {
+		return fmt.Errorf("could not write statement to output: %w", err)
+	}
+	// the statements are run by the hooks once the rows are written, they are not held back
+	if err := d.output.Flush(); err != nil {
+		return fmt.Errorf("could not flush output: %w", err)
+	}
+
 	return nil
 }
 
-// Close closes the output stream.
+// Close flushes and closes the output stream.
 func (d *textDumper) Close() error {
-	closer, ok := d.output.(io.WriteCloser)
-	if ok {
-		if err := closer.Close(); err != nil {
-			return fmt.Errorf("failed to close output stream: %w", err)
-		}
-		return nil
+	if err := d.output.Close(); err != nil {
+		return fmt.Errorf("failed to close output stream: %w", err)
+	}
+
+	return nil
+}
+
+// writeRow writes the insert statement of a row.
+func (d *textDumper) writeRow(tableName string, columns []string, row database.Row) error {
+	if row.HasLOB() {
+		return d.writeLOBInsert(tableName, columns, row)
+	}
+
+	insert, err := d.toInsert(tableName, columns, row)
+	if err != nil {
+		return fmt.Errorf("could not convert value to string: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err = io.WriteString(d.output, insert+"\n")
+	return err
+}
+
+// toInsert builds the insert statement for a row, using the target dialect when one is set.
+func (d *textDumper) toInsert(tableName string, columns []string, row database.Row) (string, error) {
+	if d.dialect != nil {
+		return d.dialect.Insert(tableName, columns, row)
+	}
+
+	values, err := d.toSQLValues(columns, row)
+	if err != nil {
+		return "", err
 	}
 
-	return errors.New("unable to close output: wrong closer type")
+	return sq.DebugSqlizer(sq.Insert(tableName).Columns(columns...).Values(values...)), nil
 }
 
-func (d *textDumper) toSQLColumnMap(row database.Row) (map[string]interface{}, error) {
-	sqlColumnMap := make(map[string]interface{})
+// writeLOBInsert writes the insert statement of a row holding large values, streaming them chunk by chunk.
+func (d *textDumper) writeLOBInsert(tableName string, columns []string, row database.Row) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.dialect != nil {
+		if err := d.dialect.WriteInsert(d.output, tableName, columns, row); err != nil {
+			return err
+		}
+		_, err := io.WriteString(d.output, "\n")
+		return err
+	}
+
+	// the same statement as toInsert, with the values quoted as is
+	if _, err := fmt.Fprintf(d.output, "INSERT INTO %s (%s) VALUES (", tableName, strings.Join(columns, ",")); err != nil {
+		return err
+	}
+	for i, column := range columns {
+		separator := "'"
+		if i > 0 {
+			separator = ",'"
+		}
+		if _, err := io.WriteString(d.output, separator); err != nil {
+			return err
+		}
 
-	for column, value := range row {
-		strValue, err := d.toSQLStringValue(value)
+		if lob, ok := row.Get(column).(*database.LOB); ok {
+			if _, err := lob.WriteTo(d.output); err != nil {
+				return fmt.Errorf("could not write column %s: %w", column, err)
+			}
+		} else {
+			value, err := d.toSQLStringValue(row.Get(column))
+			if err != nil {
+				return fmt.Errorf("could not convert column %s: %w", column, err)
+			}
+			if _, err := io.WriteString(d.output, value); err != nil {
+				return err
+			}
+		}
+
+		if _, err := io.WriteString(d.output, "'"); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(d.output, ")\n")
+	return err
+}
+
+// toSQLValues returns the values of the row columns, in the order of the columns.
+func (d *textDumper) toSQLValues(columns []string, row database.Row) ([]interface{}, error) {
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		strValue, err := d.toSQLStringValue(row.Get(column))
 		if err != nil {
-			return sqlColumnMap, err
+			return nil, err
 		}
 
-		sqlColumnMap[column] = fmt.Sprintf("%v", strValue)
+		if row.ColumnType(column) == jsonType && row.Get(column) != nil {
+			values[i] = sq.Expr("CAST(? AS JSON)", strValue)
+			continue
+		}
+		values[i] = strValue
 	}
 
-	return sqlColumnMap, nil
+	return values, nil
 }
 
 // ResolveType accepts a value and attempts to determine its type
@@ -0,0 +1,212 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestExec(t *testing.T) {
+	var output bytes.Buffer
+	d := NewDumper(&output, nil)
+
+	executor, ok := d.(dumper.Executor)
+	require.True(t, ok)
+	require.NoError(t, executor.Exec("ANALYZE users"))
+	require.NoError(t, executor.Exec("ANALYZE orders;\n"))
+
+	assert.Equal(t, "ANALYZE users;\nANALYZE orders;\n", output.String())
+}
+
+func TestDumpResult(t *testing.T) {
+	rows := []database.Row{
+		database.NewRow(database.NewColumns([]string{"id"}), []interface{}{int64(1)}),
+		database.NewRow(database.NewColumns([]string{"id"}), []interface{}{int64(2)}),
+	}
+	var output bytes.Buffer
+	d := NewDumper(&output, &mockReader{rows: rows})
+
+	result, err := d.Dump(nil, 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, &dumper.Result{Tables: []dumper.TableResult{{Name: "users", Rows: 2}}}, result)
+	assert.Equal(t, "INSERT INTO users (id) VALUES ('1')\nINSERT INTO users (id) VALUES ('2')\n", output.String())
+
+	result, err = d.Dump(config.Tables{{Name: "users", IgnoreData: true}}, 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, &dumper.Result{Tables: []dumper.TableResult{{Name: "users", Skipped: true}}}, result)
+}
+
+func TestDumpWriteError(t *testing.T) {
+	rows := []database.Row{
+		database.NewRow(database.NewColumns([]string{"id"}), []interface{}{int64(1)}),
+		database.NewRow(database.NewColumns([]string{"id"}), []interface{}{int64(2)}),
+	}
+	d := newTextDumper(failingWriter{}, &mockReader{rows: rows}, nil, 1, false)
+
+	_, err := d.Dump(nil, 1, true)
+	assert.EqualError(t, err, "could not write rows of users to output: disk full")
+}
+
+func TestToInsertColumnOrder(t *testing.T) {
+	d := &textDumper{}
+	row := database.NewRow(database.NewColumns([]string{"name", "id", "active"}), []interface{}{"foo", int64(1), true})
+
+	insert, err := d.toInsert("users", []string{"id", "name", "active"}, row)
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO users (id,name,active) VALUES ('1','foo','true')", insert)
+}
+
+func TestToInsertJSON(t *testing.T) {
+	d := &textDumper{}
+	columns := database.NewTypedColumns([]string{"id", "data", "extra"}, []string{"INT", "JSON", "JSON"})
+	row := database.NewRow(columns, []interface{}{int64(1), []byte(`{"a": 1}`), nil})
+
+	insert, err := d.toInsert("docs", []string{"id", "data", "extra"}, row)
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO docs (id,data,extra) VALUES ('1',CAST('{"a": 1}' AS JSON),'NULL')`, insert)
+
+	d.dialect, err = getDialect("mysql")
+	require.NoError(t, err)
+	insert, err = d.toInsert("docs", []string{"id", "data", "extra"}, row)
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `docs` (`id`, `data`, `extra`) VALUES (1, CAST('{\"a\": 1}' AS JSON), NULL);", insert)
+}
+
+func TestDumpConcurrentTables(t *testing.T) {
+	rows := []database.Row{
+		database.NewRow(database.NewColumns([]string{"id"}), []interface{}{int64(1)}),
+		database.NewRow(database.NewColumns([]string{"id"}), []interface{}{int64(2)}),
+	}
+	rdr := &mockReader{tables: []string{"users", "orders", "payments"}, rows: rows}
+	expected := &dumper.Result{Tables: []dumper.TableResult{
+		{Name: "users", Rows: 2},
+		{Name: "orders", Rows: 2},
+		{Name: "payments", Rows: 2},
+	}}
+
+	var output bytes.Buffer
+	result, err := NewDumper(&output, rdr).Dump(nil, 3, true)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	assert.Equal(t, `INSERT INTO users (id) VALUES ('1')
+INSERT INTO users (id) VALUES ('2')
+INSERT INTO orders (id) VALUES ('1')
+INSERT INTO orders (id) VALUES ('2')
+INSERT INTO payments (id) VALUES ('1')
+INSERT INTO payments (id) VALUES ('2')
+`, output.String(), "the tables are written in order")
+
+	output.Reset()
+	result, err = newTextDumper(&output, rdr, nil, DefaultBufferSize, true).Dump(nil, 3, true)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.ElementsMatch(t, []string{
+		"INSERT INTO users (id) VALUES ('1')",
+		"INSERT INTO users (id) VALUES ('2')",
+		"INSERT INTO orders (id) VALUES ('1')",
+		"INSERT INTO orders (id) VALUES ('2')",
+		"INSERT INTO payments (id) VALUES ('1')",
+		"INSERT INTO payments (id) VALUES ('2')",
+	}, lines)
+
+	_, err = newTextDumper(failingWriter{}, rdr, nil, 1, true).Dump(nil, 2, true)
+	assert.Error(t, err)
+}
+
+func TestDumpSlowFirstTable(t *testing.T) {
+	rows := []database.Row{
+		database.NewRow(database.NewColumns([]string{"id"}), []interface{}{int64(1)}),
+		database.NewRow(database.NewColumns([]string{"id"}), []interface{}{int64(2)}),
+	}
+	rdr := &slowReader{
+		mockReader: mockReader{tables: []string{"users", "orders"}, rows: rows},
+		read:       make(chan struct{}),
+	}
+
+	var output bytes.Buffer
+	d := newTextDumper(&output, rdr, nil, DefaultBufferSize, false)
+	d.spillDir = t.TempDir()
+	result, err := d.Dump(nil, 2, true)
+	require.NoError(t, err)
+	assert.Equal(t, &dumper.Result{Tables: []dumper.TableResult{
+		{Name: "users", Rows: 2},
+		{Name: "orders", Rows: 2},
+	}}, result, "the table read ahead is not held back by the slow one")
+	assert.Equal(t, `INSERT INTO users (id) VALUES ('1')
+INSERT INTO users (id) VALUES ('2')
+INSERT INTO orders (id) VALUES ('1')
+INSERT INTO orders (id) VALUES ('2')
+`, output.String())
+}
+
+// slowReader reads the first table until the others are read, which fail when they are held for longer than the read
+// timeout.
+type slowReader struct {
+	mockReader
+	read chan struct{}
+}
+
+func (s *slowReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	if tableName == s.tables[0] {
+		rowChan <- s.rows[0]
+		select {
+		case <-s.read:
+		case <-time.After(5 * time.Second):
+			return errors.New("the next table was not read ahead")
+		}
+		for _, row := range s.rows[1:] {
+			rowChan <- row
+		}
+		return nil
+	}
+
+	timeout := time.NewTimer(100 * time.Millisecond)
+	defer timeout.Stop()
+	for _, row := range s.rows {
+		select {
+		case rowChan <- row:
+		case <-timeout.C:
+			return context.DeadlineExceeded
+		}
+	}
+	close(s.read)
+
+	return nil
+}
+
+type mockReader struct {
+	tables []string
+	rows   []database.Row
+}
+
+func (m *mockReader) GetTables() ([]string, error) {
+	if m.tables == nil {
+		return []string{"users"}, nil
+	}
+	return m.tables, nil
+}
+func (m *mockReader) GetStructure() (string, error)                       { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error)                 { return []string{"id"}, nil }
+func (m *mockReader) FormatColumn(tableName string, column string) string { return column }
+func (m *mockReader) Close() error                                        { return nil }
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	for _, row := range m.rows {
+		rowChan <- row
+	}
+	return nil
+}
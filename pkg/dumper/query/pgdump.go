@@ -0,0 +1,360 @@
+package query
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/reader/postgres"
+)
+
+const (
+	pgDumpNull = `\N`
+
+	pgDumpHeader = `--
+-- PostgreSQL database dump
+--
+-- This database was nicked by Klepto™.
+-- https://github.com/hellofresh/klepto
+--
+
+SET statement_timeout = 0;
+SET lock_timeout = 0;
+SET idle_in_transaction_session_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET check_function_bodies = false;
+SET xmloption = content;
+SET client_min_messages = warning;
+SET row_security = off;
+
+`
+	pgDumpFooter = `--
+-- PostgreSQL database dump complete
+--
+`
+)
+
+var (
+	// pgDumpOwnedSequence matches the statement linking a sequence to the column it feeds.
+	pgDumpOwnedSequence = regexp.MustCompile(`(?m)^ALTER SEQUENCE (\S+) OWNED BY (\S+)\.(\S+)\.(\S+);$`)
+)
+
+type (
+	pgDumpDumper struct {
+		reader reader.Reader
+		output *bufferedWriter
+		schema string
+	}
+
+	// ownedSequence is a sequence that generates the values of a table column.
+	ownedSequence struct {
+		name   string
+		column string
+	}
+)
+
+// NewPgDumpDumper returns a new dumper that writes the pg_dump plain format.
+func NewPgDumpDumper(output io.Writer, rdr reader.Reader, schema string) dumper.Dumper {
+	return newPgDumpDumper(output, rdr, schema, DefaultBufferSize)
+}
+
+// newPgDumpDumper returns a pg_dump dumper writing through a buffer of bufferSize bytes.
+func newPgDumpDumper(output io.Writer, rdr reader.Reader, schema string, bufferSize int) *pgDumpDumper {
+	return &pgDumpDumper{
+		reader: rdr,
+		output: newBufferedWriter(output, bufferSize),
+		schema: schema,
+	}
+}
+
+// Dump executes the dump stream process.
+func (d *pgDumpDumper) Dump(cfgTables config.Tables, concurrency int, dataOnly bool) (*dumper.Result, error) {
+	tables, err := d.reader.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tables: %w", err)
+	}
+
+	var preData, postData string
+	if !dataOnly {
+		structure, err := d.reader.GetStructure()
+		if err != nil {
+			return nil, fmt.Errorf("could not get database structure: %w", err)
+		}
+		preData, postData = postgres.SplitSections(structure)
+	}
+
+	if _, err := io.WriteString(d.output, pgDumpHeader+preData); err != nil {
+		return nil, fmt.Errorf("could not write pre-data section to output: %w", err)
+	}
+
+	result := &dumper.Result{Tables: make([]dumper.TableResult, len(tables))}
+	sequences := ownedSequences(preData)
+	var setvals strings.Builder
+	for i, tbl := range tables {
+		table := &result.Tables[i]
+		table.Name = tbl
+
+		var opts reader.ReadTableOpt
+		logger := log.WithField("table", tbl)
+
+		tableConfig := cfgTables.FindByName(tbl)
+		if tableConfig == nil {
+			logger.Debug("no configuration found for table")
+		} else {
+			if tableConfig.IgnoreData {
+				logger.Debug("ignoring data to dump")
+				table.Skipped = true
+				continue
+			}
+			opts = reader.NewReadTableOpt(tableConfig)
+		}
+
+		maxValues, err := d.dumpTable(table, opts, sequences[tbl])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, seq := range sequences[tbl] {
+			if highest, ok := maxValues[seq.column]; ok {
+				fmt.Fprintf(&setvals, "SELECT pg_catalog.setval('%s', %d, true);\n", seq.name, highest)
+				continue
+			}
+			fmt.Fprintf(&setvals, "SELECT pg_catalog.setval('%s', 1, false);\n", seq.name)
+		}
+	}
+
+	if setvals.Len() > 0 {
+		if _, err := io.WriteString(d.output, "\n"+setvals.String()+"\n"); err != nil {
+			return nil, fmt.Errorf("could not write sequence values to output: %w", err)
+		}
+	}
+
+	if _, err := io.WriteString(d.output, postData+pgDumpFooter); err != nil {
+		return nil, fmt.Errorf("could not write post-data section to output: %w", err)
+	}
+	if err := d.output.Flush(); err != nil {
+		return nil, fmt.Errorf("could not flush output: %w", err)
+	}
+
+	return result, nil
+}
+
+// Close flushes and closes the output stream.
+func (d *pgDumpDumper) Close() error {
+	if err := d.output.Close(); err != nil {
+		return fmt.Errorf("failed to close output stream: %w", err)
+	}
+
+	return nil
+}
+
+// dumpTable writes a COPY block for the table and returns the highest value seen for each sequence column,
+// the rows written and the read error being set on the table result.
+func (d *pgDumpDumper) dumpTable(table *dumper.TableResult, opts reader.ReadTableOpt, sequences []ownedSequence) (map[string]int64, error) {
+	tableName := table.Name
+	columns, err := d.reader.GetColumns(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns for %s: %w", tableName, err)
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pq.QuoteIdentifier(column)
+	}
+
+	if _, err := fmt.Fprintf(
+		d.output,
+		"\n--\n-- Data for Name: %s; Type: TABLE DATA; Schema: %s; Owner: -\n--\n\nCOPY %s.%s (%s) FROM stdin;\n",
+		tableName,
+		d.schema,
+		pq.QuoteIdentifier(d.schema),
+		pq.QuoteIdentifier(tableName),
+		strings.Join(quoted, ", "),
+	); err != nil {
+		return nil, fmt.Errorf("could not write copy statement to output: %w", err)
+	}
+
+	rowChan := make(chan database.Row)
+	maxValues := make(map[string]int64)
+	errChan := make(chan error, 1)
+
+	go func() {
+		var writeErr error
+		fields := make([]string, len(columns))
+		for row := range rowChan {
+			if writeErr != nil {
+				// keep draining the channel so the reader does not block
+				continue
+			}
+
+			for _, seq := range sequences {
+				if n, ok := toInt64(row.Get(seq.column)); ok {
+					if highest, seen := maxValues[seq.column]; !seen || n > highest {
+						maxValues[seq.column] = n
+					}
+				}
+			}
+
+			if row.HasLOB() {
+				writeErr = writeCopyLOBRow(d.output, columns, row)
+			} else {
+				for i, column := range columns {
+					fields[i] = toCopyValue(row.Get(column))
+				}
+				_, writeErr = io.WriteString(d.output, strings.Join(fields, "\t")+"\n")
+			}
+			if writeErr == nil {
+				table.Rows++
+			}
+		}
+		errChan <- writeErr
+	}()
+
+	readErr := d.reader.ReadTable(tableName, rowChan, opts)
+	if readErr != nil {
+		log.WithError(readErr).WithField("table", tableName).Error("error while reading table")
+		table.Err = fmt.Errorf("failed to read table: %w", readErr)
+	}
+
+	if err := <-errChan; err != nil {
+		return nil, fmt.Errorf("could not write rows of %s to output: %w", tableName, err)
+	}
+
+	if _, err := io.WriteString(d.output, "\\.\n"); err != nil {
+		return nil, fmt.Errorf("could not write copy terminator to output: %w", err)
+	}
+
+	return maxValues, nil
+}
+
+// ownedSequences returns the sequences owned by table columns, grouped by table name.
+func ownedSequences(structure string) map[string][]ownedSequence {
+	sequences := make(map[string][]ownedSequence)
+	for _, m := range pgDumpOwnedSequence.FindAllStringSubmatch(structure, -1) {
+		table := unquotePgIdentifier(m[3])
+		sequences[table] = append(sequences[table], ownedSequence{
+			name:   m[1],
+			column: unquotePgIdentifier(m[4]),
+		})
+	}
+
+	return sequences
+}
+
+func unquotePgIdentifier(name string) string {
+	if len(name) > 1 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+		return strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+
+	return name
+}
+
+// writeCopyLOBRow writes a COPY line of a row holding large values, streaming them chunk by chunk.
+func writeCopyLOBRow(w io.Writer, columns []string, row database.Row) error {
+	for i, column := range columns {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\t"); err != nil {
+				return err
+			}
+		}
+
+		lob, ok := row.Get(column).(*database.LOB)
+		if !ok {
+			if _, err := io.WriteString(w, toCopyValue(row.Get(column))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if lob.Binary {
+			if _, err := io.WriteString(w, `\\x`); err != nil {
+				return err
+			}
+		}
+		err := lob.Chunks(func(chunk []byte) error {
+			if lob.Binary {
+				_, err := io.WriteString(w, hex.EncodeToString(chunk))
+				return err
+			}
+			_, err := copyEscaper.WriteString(w, string(chunk))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("could not write column %s: %w", column, err)
+		}
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// toCopyValue formats a value using the COPY text format escaping rules.
+func toCopyValue(src interface{}) string {
+	var value string
+	switch v := src.(type) {
+	case nil:
+		return pgDumpNull
+	case *interface{}:
+		if v == nil {
+			return pgDumpNull
+		}
+		return toCopyValue(*v)
+	case bool:
+		if v {
+			return "t"
+		}
+		return "f"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		value = v.Format("2006-01-02 15:04:05.999999-07:00")
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		value = fmt.Sprintf("%v", v)
+	}
+
+	return copyEscaper.Replace(value)
+}
+
+var copyEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\b", `\b`,
+	"\f", `\f`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"\v", `\v`,
+)
+
+func toInt64(src interface{}) (int64, bool) {
+	switch v := src.(type) {
+	case int64:
+		return v, true
+	case []byte:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
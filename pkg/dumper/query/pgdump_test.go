@@ -0,0 +1,71 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pgDumpStructure = `SET statement_timeout = 0;
+
+--
+-- Name: users; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    name text
+);
+
+--
+-- Name: users_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.users_id_seq;
+
+ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
+
+--
+-- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+--
+-- Name: users_name_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX users_name_idx ON public.users USING btree (name);
+`
+
+func TestOwnedSequences(t *testing.T) {
+	sequences := ownedSequences(pgDumpStructure)
+
+	require.Len(t, sequences["users"], 1)
+	assert.Equal(t, "public.users_id_seq", sequences["users"][0].name)
+	assert.Equal(t, "id", sequences["users"][0].column)
+}
+
+func TestToCopyValue(t *testing.T) {
+	var nilPtr *interface{}
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{value: nil, expected: `\N`},
+		{value: nilPtr, expected: `\N`},
+		{value: true, expected: "t"},
+		{value: int64(42), expected: "42"},
+		{value: 1.5, expected: "1.5"},
+		{value: []byte("foo"), expected: "foo"},
+		{value: "a\tb\nc\\d", expected: `a\tb\nc\\d`},
+		{value: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), expected: "2020-01-02 03:04:05+00:00"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, toCopyValue(test.value))
+	}
+}
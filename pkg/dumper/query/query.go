@@ -3,25 +3,63 @@ package query
 import (
 	parser "github.com/hellofresh/klepto/pkg/dsn"
 	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/output"
 	"github.com/hellofresh/klepto/pkg/reader"
 )
 
+const (
+	// pgDumpType is the dsn type used to write the pg_dump plain format.
+	pgDumpType = "pgdump"
+	// defaultPgDumpSchema is the schema data is copied into when none is given.
+	defaultPgDumpSchema = "public"
+)
+
 type driver struct{}
 
+// IsSupported tells whether the dsn is a pg_dump or the url of a registered output sink.
 func (m *driver) IsSupported(dsn string) bool {
-	d, err := parser.Parse(dsn)
-	if err != nil {
-		return false
-	}
-	return d.Type == "os"
+	return isPgDump(dsn) || output.IsSupported(dsn)
 }
 
 func (m *driver) NewConnection(opts dumper.ConnOpts, rdr reader.Reader) (dumper.Dumper, error) {
+	if isPgDump(opts.DSN) {
+		d, err := parser.Parse(opts.DSN)
+		if err != nil {
+			return nil, err
+		}
+		schema := d.Params["schema"]
+		if schema == "" {
+			schema = defaultPgDumpSchema
+		}
+
+		writer, err := getOutputWriter(opts.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return newPgDumpDumper(writer, rdr, schema, opts.WriteBufferSize), nil
+	}
+
+	var targetDialect *dialect
+	if opts.TargetDialect != "" {
+		var err error
+		if targetDialect, err = getDialect(opts.TargetDialect); err != nil {
+			return nil, err
+		}
+	}
+
+	// the output is only opened once the options are valid, so that no empty file is created
 	writer, err := getOutputWriter(opts.DSN)
 	if err != nil {
 		return nil, err
 	}
-	return NewDumper(writer, rdr), nil
+
+	d := newTextDumper(writer, rdr, targetDialect, opts.WriteBufferSize, opts.Interleave)
+	if opts.MemoryBudget != nil {
+		d.budget = opts.MemoryBudget
+	}
+	d.spillDir = opts.SpillDir
+
+	return d, nil
 }
 
 func init() {
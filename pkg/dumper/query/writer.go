@@ -1,33 +1,69 @@
 package query
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	parser "github.com/hellofresh/klepto/pkg/dsn"
+	"github.com/hellofresh/klepto/pkg/output"
 )
 
-func getOsWriter(address string) io.Writer {
-	if address == "stderr" {
-		return os.Stderr
+// DefaultBufferSize is the size of the buffer the statements are written through by default.
+const DefaultBufferSize = 64 * 1024
+
+// bufferedWriter buffers the statements written to the output, so that small writes do not cost a syscall each.
+type bufferedWriter struct {
+	*bufio.Writer
+	output io.Writer
+}
+
+func newBufferedWriter(output io.Writer, size int) *bufferedWriter {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+
+	return &bufferedWriter{Writer: bufio.NewWriterSize(output, size), output: output}
+}
+
+// Close flushes the buffer, syncs the output when it is a regular file and closes it when it is a closer.
+func (w *bufferedWriter) Close() error {
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("could not flush output: %w", err)
+	}
+
+	// terminals and pipes can not be synced
+	if f, ok := w.output.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			if err := f.Sync(); err != nil {
+				return fmt.Errorf("could not sync output: %w", err)
+			}
+		}
 	}
-	if address == "stdout" {
-		return os.Stdout
+
+	if closer, ok := w.output.(io.Closer); ok {
+		return closer.Close()
 	}
+
 	return nil
 }
 
-// TODO: Implement writer interface for file.
+// getOutputWriter opens the output of the dsn through the sink registered for its scheme,
+// a pg_dump is written to the standard stream named by its address.
 func getOutputWriter(dsn string) (io.Writer, error) {
+	if !isPgDump(dsn) {
+		return output.Open(dsn)
+	}
+
 	config, err := parser.Parse(dsn)
 	if err != nil {
 		return nil, err
 	}
-	switch config.Type {
-	case "os":
-		return getOsWriter(config.Address), nil
-	default:
-		return nil, fmt.Errorf("unknown output writer type: %v", config.Type)
-	}
+	return output.Open("os://" + config.Address + "/")
+}
+
+func isPgDump(dsn string) bool {
+	return strings.HasPrefix(dsn, pgDumpType+"://")
 }
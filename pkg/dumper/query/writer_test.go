@@ -1,11 +1,14 @@
 package query
 
 import (
+	"bytes"
+	"errors"
 	"io"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var tests = []struct {
@@ -14,6 +17,7 @@ var tests = []struct {
 }{
 	{Dsn: "os://stdout/", Writer: os.Stdout},
 	{Dsn: "os://stderr/", Writer: os.Stderr},
+	{Dsn: "pgdump://stdout/", Writer: os.Stdout},
 }
 
 func TestWriter(t *testing.T) {
@@ -28,3 +32,41 @@ func TestWriter(t *testing.T) {
 		}
 	}
 }
+
+func TestBufferedWriter(t *testing.T) {
+	var output closingBuffer
+	w := newBufferedWriter(&output, 16)
+
+	_, err := io.WriteString(w, "SELECT 1;\n")
+	require.NoError(t, err)
+	assert.Empty(t, output.String(), "small writes are buffered")
+
+	_, err = io.WriteString(w, "SELECT 2;\n")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;\nSELECT", output.String(), "the buffer is written once full")
+
+	require.NoError(t, w.Close())
+	assert.Equal(t, "SELECT 1;\nSELECT 2;\n", output.String())
+	assert.True(t, output.closed)
+
+	w = newBufferedWriter(failingWriter{}, 16)
+	_, err = io.WriteString(w, "SELECT 1;\n")
+	require.NoError(t, err)
+	assert.EqualError(t, w.Close(), "could not flush output: disk full")
+}
+
+type closingBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closingBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
@@ -0,0 +1,74 @@
+package dumper
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// Result is the outcome of a dump, with one result per table of the source.
+	Result struct {
+		Tables []TableResult
+	}
+
+	// TableResult is the outcome of the dump of a table.
+	TableResult struct {
+		// Name is the table name.
+		Name string
+		// Skipped is true when the table data is not dumped, e.g. with IgnoreData.
+		Skipped bool
+		// Rows is the amount of rows of the table given to the dumper.
+		Rows uint64
+		// Err is the error the table data could not be read or dumped with.
+		Err error
+	}
+)
+
+// Failed returns the results of the tables that could not be read or dumped.
+func (r *Result) Failed() []TableResult {
+	var failed []TableResult
+	for _, table := range r.Tables {
+		if table.Err != nil {
+			failed = append(failed, table)
+		}
+	}
+
+	return failed
+}
+
+// Loaded returns the names of the tables whose data was dumped.
+func (r *Result) Loaded() []string {
+	var loaded []string
+	for _, table := range r.Tables {
+		if !table.Skipped && table.Err == nil {
+			loaded = append(loaded, table.Name)
+		}
+	}
+
+	return loaded
+}
+
+// Rows returns the amount of rows of all the tables.
+func (r *Result) Rows() uint64 {
+	var rows uint64
+	for _, table := range r.Tables {
+		rows += table.Rows
+	}
+
+	return rows
+}
+
+// Err returns an error listing the tables that could not be read or dumped, nil when all of them were.
+func (r *Result) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(failed))
+	for i, table := range failed {
+		messages[i] = fmt.Sprintf("%s: %s", table.Name, table.Err)
+	}
+
+	return fmt.Errorf("%d of %d tables failed: %s", len(failed), len(r.Tables), strings.Join(messages, "; "))
+}
@@ -0,0 +1,24 @@
+package dumper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResult(t *testing.T) {
+	result := &Result{Tables: []TableResult{
+		{Name: "users", Rows: 10},
+		{Name: "logs", Skipped: true},
+		{Name: "orders", Rows: 3, Err: errors.New("failed to read table: connection reset")},
+	}}
+
+	assert.Equal(t, uint64(13), result.Rows())
+	assert.Equal(t, []TableResult{result.Tables[2]}, result.Failed())
+	assert.Equal(t, []string{"users"}, result.Loaded())
+	assert.EqualError(t, result.Err(), "1 of 3 tables failed: orders: failed to read table: connection reset")
+
+	result.Tables[2].Err = nil
+	assert.NoError(t, result.Err())
+}
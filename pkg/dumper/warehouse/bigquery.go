@@ -0,0 +1,120 @@
+package warehouse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+// bigQuery stages newline delimited JSON files that are loaded with bq load.
+type bigQuery struct {
+	project string
+	dataset string
+}
+
+func newBigQuery(params url.Values) (Target, error) {
+	if params.Get("dataset") == "" {
+		return nil, errors.New("the bigquery dsn requires a dataset parameter")
+	}
+
+	return &bigQuery{project: params.Get("project"), dataset: params.Get("dataset")}, nil
+}
+
+// Extension returns the staged file extension.
+func (q *bigQuery) Extension() string {
+	return ".json"
+}
+
+// WriteRows writes one JSON object per line.
+func (q *bigQuery) WriteRows(w io.Writer, columns []string, rowChan <-chan database.Row) (int, error) {
+	enc := json.NewEncoder(w)
+
+	var n int
+	for row := range rowChan {
+		object := make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			object[column] = toJSONValue(row.Get(column))
+		}
+		if err := enc.Encode(object); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// Script returns a shell script loading the staged files with the bq command line tool, the schema of the tables that
+// do not exist yet is detected from the files.
+func (q *bigQuery) Script(dir string, tables []string) (string, string) {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n# Loads the tables staged by Klepto, requires the bq command line tool.\nset -e\n\n")
+	for _, table := range tables {
+		fmt.Fprintf(
+			&b,
+			"bq load --source_format=NEWLINE_DELIMITED_JSON --autodetect %s %s\n",
+			shellQuote(q.destination(table)),
+			shellQuote(filepath.Join(dir, table+q.Extension())),
+		)
+	}
+
+	return "load.sh", b.String()
+}
+
+// Program returns the bq command line tool.
+func (q *bigQuery) Program() string {
+	return "bq"
+}
+
+// LoadArgs returns the arguments of the load job of each table, the same as the ones of the load script.
+func (q *bigQuery) LoadArgs(dir string, _ string, tables []string) [][]string {
+	args := make([][]string, len(tables))
+	for i, table := range tables {
+		args[i] = []string{
+			"load",
+			"--source_format=NEWLINE_DELIMITED_JSON",
+			"--autodetect",
+			q.destination(table),
+			filepath.Join(dir, table+q.Extension()),
+		}
+	}
+
+	return args
+}
+
+// destination returns the table the rows of a staged table are loaded into.
+func (q *bigQuery) destination(table string) string {
+	dataset := q.dataset
+	if q.project != "" {
+		dataset = q.project + ":" + dataset
+	}
+
+	return dataset + "." + table
+}
+
+func toJSONValue(src interface{}) interface{} {
+	switch value := src.(type) {
+	case []byte:
+		return string(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case *interface{}:
+		if value == nil {
+			return nil
+		}
+		return toJSONValue(*value)
+	default:
+		return value
+	}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,183 @@
+package warehouse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/dumper/engine"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	warehouseDumper struct {
+		reader reader.Reader
+		dir    string
+		target Target
+		// loader is the path of the program loading the staged tables into the warehouse, the tables are only staged
+		// when not set.
+		loader string
+
+		mu     sync.Mutex
+		staged []string
+	}
+
+	// Target stages table files in the format a warehouse bulk loads and writes the script loading them.
+	Target interface {
+		// Extension returns the staged file extension.
+		Extension() string
+		// WriteRows writes the rows of a table, with the columns in schema order, and returns the amount written.
+		WriteRows(w io.Writer, columns []string, rowChan <-chan database.Row) (int, error)
+		// Script returns the name and content of the script loading the staged tables from dir.
+		Script(dir string, tables []string) (string, string)
+	}
+
+	// Loader is a Target whose staged tables are loaded by a command line tool of the warehouse.
+	Loader interface {
+		// Program returns the name of the command line tool loading the tables.
+		Program() string
+		// LoadArgs returns the arguments of each run of the program loading the tables staged in dir, script being
+		// the path of the load script.
+		LoadArgs(dir string, script string, tables []string) [][]string
+	}
+)
+
+// NewDumper returns a new dumper staging one file per table into dir for a warehouse bulk load, loaded with the
+// program at loader once staged when set.
+func NewDumper(dir string, target Target, loader string, rdr reader.Reader) dumper.Dumper {
+	return engine.New(rdr, &warehouseDumper{
+		reader: rdr,
+		dir:    dir,
+		target: target,
+		loader: loader,
+	})
+}
+
+// Configure passes the tables configuration to the targets using it.
+func (d *warehouseDumper) Configure(cfgTables config.Tables) {
+	if c, ok := d.target.(engine.Configurer); ok {
+		c.Configure(cfgTables)
+	}
+}
+
+// DumpStructure is a no-op, the warehouse tables are expected to exist or be created by the load.
+func (d *warehouseDumper) DumpStructure(sql string) error {
+	log.Debug("warehouse staging does not convert the database structure, skipping")
+	return nil
+}
+
+// DumpTable writes the table rows into a staged file.
+func (d *warehouseDumper) DumpTable(tableName string, rowChan <-chan database.Row) error {
+	columns, err := d.reader.GetColumns(tableName)
+	if err != nil {
+		drain(rowChan)
+		return fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	path := filepath.Join(d.dir, tableName+d.target.Extension())
+	f, err := os.Create(path)
+	if err != nil {
+		drain(rowChan)
+		return fmt.Errorf("failed to create staged file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	n, err := d.target.WriteRows(w, columns, rowChan)
+	if err != nil {
+		drain(rowChan)
+		return fmt.Errorf("failed to write staged file %s: %w", path, err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush staged file %s: %w", path, err)
+	}
+
+	log.WithFields(log.Fields{
+		"table":   tableName,
+		"written": n,
+		"file":    path,
+	}).Debug("staged table")
+
+	d.mu.Lock()
+	d.staged = append(d.staged, tableName)
+	d.mu.Unlock()
+
+	return f.Close()
+}
+
+// PreDumpTables is a no-op.
+func (d *warehouseDumper) PreDumpTables([]string) error {
+	return nil
+}
+
+// PostDumpTables writes the load script for the staged tables, and loads them when the dumper has a loader.
+func (d *warehouseDumper) PostDumpTables([]string) error {
+	d.mu.Lock()
+	tables := append([]string(nil), d.staged...)
+	d.mu.Unlock()
+	sort.Strings(tables)
+
+	name, script := d.target.Script(d.dir, tables)
+	path := filepath.Join(d.dir, name)
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		return fmt.Errorf("failed to write load script: %w", err)
+	}
+
+	loader, ok := d.target.(Loader)
+	if d.loader == "" || !ok {
+		log.WithField("script", path).Info("staged tables, run the load script to load them into the warehouse")
+		return nil
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	for _, args := range loader.LoadArgs(d.dir, path, tables) {
+		if err := d.load(args); err != nil {
+			return fmt.Errorf("failed to load the staged tables, the load script can be run again once fixed: %w", err)
+		}
+	}
+	log.WithField("tables", len(tables)).Info("loaded the staged tables into the warehouse")
+
+	return nil
+}
+
+// load runs the loader with the arguments, its output is logged.
+func (d *warehouseDumper) load(args []string) error {
+	logger := log.WithField("command", d.loader)
+	cmd := exec.Command(d.loader, args...)
+
+	logger.WithField("args", args).Debug("loading staged tables")
+	cmdOut := logger.WriterLevel(log.DebugLevel)
+	defer cmdOut.Close()
+	cmdErr := logger.WriterLevel(log.WarnLevel)
+	defer cmdErr.Close()
+
+	cmd.Stdin = nil
+	cmd.Stdout = cmdOut
+	cmd.Stderr = cmdErr
+
+	return cmd.Run()
+}
+
+// Close is a no-op, staged files are closed once a table is dumped.
+func (d *warehouseDumper) Close() error {
+	return nil
+}
+
+// drain consumes the remaining rows so the reader does not block forever.
+func drain(rowChan <-chan database.Row) {
+	for range rowChan {
+	}
+}
@@ -0,0 +1,209 @@
+package warehouse
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestStageSnowflake(t *testing.T) {
+	dir := t.TempDir()
+	target, err := newSnowflake(url.Values{"stage": {"@analytics"}})
+	require.NoError(t, err)
+	d := &warehouseDumper{reader: &mockReader{}, dir: dir, target: target}
+
+	require.NoError(t, d.DumpTable("users", newRows(
+		[]interface{}{int64(1), []byte("Doe, John"), nil},
+		[]interface{}{int64(2), "", "a@example.test"},
+		[]interface{}{int64(3), `\N "quoted"`, (*interface{})(nil)},
+	)))
+	require.NoError(t, d.PostDumpTables(nil))
+
+	out, err := os.ReadFile(filepath.Join(dir, "users.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "\"id\",\"name\",\"email\"\n\"1\",\"Doe, John\",\n\"2\",\"\",\"a@example.test\"\n\"3\",\"\\N \"\"quoted\"\"\",\n", string(out))
+
+	script, err := os.ReadFile(filepath.Join(dir, "load.sql"))
+	require.NoError(t, err)
+	assert.Contains(t, string(script), "CREATE STAGE IF NOT EXISTS analytics;")
+	assert.Contains(t, string(script), "PUT 'file://"+filepath.ToSlash(filepath.Join(dir, "users.csv"))+"' @analytics")
+	assert.Contains(t, string(script), `COPY INTO "users" FROM @analytics/users.csv.gz`)
+	assert.Contains(t, string(script), "NULL_IF = () EMPTY_FIELD_AS_NULL = TRUE", "only the empty unquoted fields are loaded as NULL")
+}
+
+func TestStageBigQuery(t *testing.T) {
+	dir := t.TempDir()
+	target, err := newBigQuery(url.Values{"project": {"acme"}, "dataset": {"staging"}})
+	require.NoError(t, err)
+	d := &warehouseDumper{reader: &mockReader{}, dir: dir, target: target}
+
+	require.NoError(t, d.DumpTable("users", newRows(
+		[]interface{}{int64(1), []byte("foo"), nil},
+	)))
+	require.NoError(t, d.PostDumpTables(nil))
+
+	out, err := os.ReadFile(filepath.Join(dir, "users.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"email":null,"id":1,"name":"foo"}`+"\n", string(out))
+
+	script, err := os.ReadFile(filepath.Join(dir, "load.sh"))
+	require.NoError(t, err)
+	assert.Contains(t, string(script), "bq load --source_format=NEWLINE_DELIMITED_JSON --autodetect 'acme:staging.users' '"+filepath.Join(dir, "users.json")+"'")
+}
+
+func TestBigQueryRequiresDataset(t *testing.T) {
+	_, err := newBigQuery(url.Values{})
+	assert.Error(t, err)
+}
+
+func newRows(values ...[]interface{}) <-chan database.Row {
+	columns := database.NewColumns([]string{"id", "name", "email"})
+	rowChan := make(chan database.Row, len(values))
+	for _, v := range values {
+		rowChan <- database.NewRow(columns, v)
+	}
+	close(rowChan)
+
+	return rowChan
+}
+
+type mockReader struct{}
+
+func (m *mockReader) GetStructure() (string, error) { return "", nil }
+func (m *mockReader) GetTables() ([]string, error)  { return []string{"users"}, nil }
+func (m *mockReader) GetColumns(string) ([]string, error) {
+	return []string{"id", "name", "email"}, nil
+}
+func (m *mockReader) FormatColumn(tableName string, columnName string) string { return columnName }
+func (m *mockReader) ReadTable(string, chan<- database.Row, reader.ReadTableOpt) error {
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
+
+func TestStageRedshift(t *testing.T) {
+	dir := t.TempDir()
+	target, err := newRedshift(url.Values{"s3": {"s3://bucket/klepto/"}, "iam_role": {"arn:aws:iam::1:role/load"}})
+	require.NoError(t, err)
+	d := &warehouseDumper{reader: &mockReader{}, dir: dir, target: target}
+	d.Configure(config.Tables{{Name: "users", DistKey: "id", SortKeys: []string{"email", "id"}}})
+
+	require.NoError(t, d.DumpTable("users", newRows(
+		[]interface{}{int64(1), []byte{0xff, 0x00}, nil},
+	)))
+	require.NoError(t, d.PostDumpTables(nil))
+
+	out, err := os.ReadFile(filepath.Join(dir, "users.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "id,name,email\n1,ff00,\\N\n", string(out))
+
+	script, err := os.ReadFile(filepath.Join(dir, "load.sql"))
+	require.NoError(t, err)
+	assert.Contains(t, string(script), `COPY "users" FROM 's3://bucket/klepto/users.csv'
+  IAM_ROLE 'arn:aws:iam::1:role/load'
+  FORMAT AS CSV IGNOREHEADER 1 NULL AS '\\N' TIMEFORMAT 'auto';
+ALTER TABLE "users" ALTER DISTKEY "id";
+ALTER TABLE "users" ALTER COMPOUND SORTKEY ("email", "id");
+`)
+}
+
+func TestRedshiftRequiresS3(t *testing.T) {
+	_, err := newRedshift(url.Values{"s3": {"bucket"}})
+	assert.Error(t, err)
+}
+
+func TestNewConnection(t *testing.T) {
+	dir := t.TempDir()
+	drv := &driver{}
+	dsn := "redshift://" + dir + "/stage/?s3=s3://bucket/klepto&iam_role=arn:aws:iam::123:role/load"
+	require.True(t, drv.IsSupported(dsn))
+
+	_, err := drv.NewConnection(dumper.ConnOpts{DSN: dsn}, &mockReader{})
+	require.NoError(t, err)
+	assert.DirExists(t, filepath.Join(dir, "stage"))
+}
+
+func TestLoadSnowflake(t *testing.T) {
+	dir := t.TempDir()
+	loader, calls := fakeLoader(t, "snowsql", 0)
+	target, err := newSnowflake(url.Values{"connection": {"analytics"}})
+	require.NoError(t, err)
+	d := &warehouseDumper{reader: &mockReader{}, dir: dir, target: target, loader: loader}
+
+	require.NoError(t, d.DumpTable("users", newRows([]interface{}{int64(1), "foo", nil})))
+	require.NoError(t, d.PostDumpTables(nil))
+
+	out, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, "-o exit_on_error=true -o friendly=false -c analytics -f "+filepath.Join(dir, "load.sql")+"\n", string(out))
+}
+
+func TestLoadBigQuery(t *testing.T) {
+	dir := t.TempDir()
+	loader, calls := fakeLoader(t, "bq", 0)
+	target, err := newBigQuery(url.Values{"dataset": {"staging"}})
+	require.NoError(t, err)
+	d := &warehouseDumper{reader: &mockReader{}, dir: dir, target: target, loader: loader}
+
+	require.NoError(t, d.DumpTable("users", newRows([]interface{}{int64(1), "foo", nil})))
+	require.NoError(t, d.DumpTable("orders", newRows([]interface{}{int64(1), "bar", nil})))
+	require.NoError(t, d.PostDumpTables(nil))
+
+	out, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, "load --source_format=NEWLINE_DELIMITED_JSON --autodetect staging.orders "+filepath.Join(dir, "orders.json")+"\n"+
+		"load --source_format=NEWLINE_DELIMITED_JSON --autodetect staging.users "+filepath.Join(dir, "users.json")+"\n", string(out))
+}
+
+func TestLoadFails(t *testing.T) {
+	dir := t.TempDir()
+	loader, _ := fakeLoader(t, "bq", 1)
+	target, err := newBigQuery(url.Values{"dataset": {"staging"}})
+	require.NoError(t, err)
+	d := &warehouseDumper{reader: &mockReader{}, dir: dir, target: target, loader: loader}
+
+	require.NoError(t, d.DumpTable("users", newRows([]interface{}{int64(1), "foo", nil})))
+	assert.Error(t, d.PostDumpTables(nil))
+	assert.FileExists(t, filepath.Join(dir, "load.sh"), "the load script is kept to load the tables again")
+}
+
+func TestNewConnectionLoader(t *testing.T) {
+	dir := t.TempDir()
+	drv := &driver{}
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := drv.NewConnection(dumper.ConnOpts{DSN: "bigquery://" + dir + "/stage/?dataset=staging"}, &mockReader{})
+	assert.Error(t, err, "bq is not installed")
+	_, err = drv.NewConnection(dumper.ConnOpts{DSN: "bigquery://" + dir + "/stage/?dataset=staging&load=maybe"}, &mockReader{})
+	assert.Error(t, err)
+
+	_, err = drv.NewConnection(dumper.ConnOpts{DSN: "bigquery://" + dir + "/stage/?dataset=staging&load=false"}, &mockReader{})
+	assert.NoError(t, err)
+
+	loader, _ := fakeLoader(t, "bq", 0)
+	t.Setenv("PATH", filepath.Dir(loader))
+	_, err = drv.NewConnection(dumper.ConnOpts{DSN: "bigquery://" + dir + "/stage/?dataset=staging"}, &mockReader{})
+	assert.NoError(t, err)
+}
+
+// fakeLoader writes a program recording its arguments in the returned calls file and exiting with code.
+func fakeLoader(t *testing.T, name string, code int) (string, string) {
+	t.Helper()
+
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	program := filepath.Join(bin, name)
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %s\nexit %d\n", calls, code)
+	require.NoError(t, os.WriteFile(program, []byte(script), 0755))
+
+	return program, calls
+}
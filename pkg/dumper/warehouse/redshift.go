@@ -0,0 +1,108 @@
+package warehouse
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+// redshift stages CSV files that are uploaded to S3 and loaded with COPY.
+type redshift struct {
+	s3      string
+	iamRole string
+	tables  map[string]*config.Table
+}
+
+func newRedshift(params url.Values) (Target, error) {
+	if !strings.HasPrefix(params.Get("s3"), "s3://") {
+		return nil, errors.New("the redshift dsn requires a s3 parameter with the s3:// prefix the staged files are uploaded to")
+	}
+
+	iamRole := params.Get("iam_role")
+	if iamRole == "" {
+		iamRole = "default"
+	}
+
+	return &redshift{s3: strings.TrimSuffix(params.Get("s3"), "/"), iamRole: iamRole}, nil
+}
+
+// Configure collects the distribution and sort keys of the tables.
+func (r *redshift) Configure(cfgTables config.Tables) {
+	r.tables = make(map[string]*config.Table, len(cfgTables))
+	for _, t := range cfgTables {
+		r.tables[t.Name] = t
+	}
+}
+
+// Extension returns the staged file extension.
+func (r *redshift) Extension() string {
+	return ".csv"
+}
+
+// WriteRows writes a CSV file with a header, NULL values are written as \N and binary values as hex.
+func (r *redshift) WriteRows(w io.Writer, columns []string, rowChan <-chan database.Row) (int, error) {
+	return writeCSV(w, columns, rowChan, toRedshiftValue)
+}
+
+// Script returns a SQL script copying the staged files from S3 and applying the configured keys.
+func (r *redshift) Script(dir string, tables []string) (string, string) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Loads the tables staged by Klepto, upload %s to %s/ then run with: psql -f load.sql\n", dir, r.s3)
+	for _, table := range tables {
+		quoted := quoteIdentifier(table)
+		fmt.Fprintf(
+			&b,
+			"\nCOPY %s FROM '%s/%s%s'\n  IAM_ROLE %s\n  FORMAT AS CSV IGNOREHEADER 1 NULL AS '\\\\N' TIMEFORMAT 'auto';\n",
+			quoted,
+			r.s3,
+			table,
+			r.Extension(),
+			r.iamRoleLiteral(),
+		)
+
+		cfg := r.tables[table]
+		if cfg == nil {
+			continue
+		}
+		if cfg.DistKey != "" {
+			fmt.Fprintf(&b, "ALTER TABLE %s ALTER DISTKEY %s;\n", quoted, quoteIdentifier(cfg.DistKey))
+		}
+		if len(cfg.SortKeys) > 0 {
+			keys := make([]string, len(cfg.SortKeys))
+			for i, key := range cfg.SortKeys {
+				keys[i] = quoteIdentifier(key)
+			}
+			fmt.Fprintf(&b, "ALTER TABLE %s ALTER COMPOUND SORTKEY (%s);\n", quoted, strings.Join(keys, ", "))
+		}
+	}
+
+	return "load.sql", b.String()
+}
+
+func (r *redshift) iamRoleLiteral() string {
+	if r.iamRole == "default" {
+		return "default"
+	}
+
+	return "'" + strings.ReplaceAll(r.iamRole, "'", "''") + "'"
+}
+
+// toRedshiftValue formats a CSV value, redshift has no binary type so invalid text is written as hex.
+func toRedshiftValue(src interface{}) string {
+	if b, ok := src.([]byte); ok && (!utf8.Valid(b) || strings.ContainsRune(string(b), 0)) {
+		return hex.EncodeToString(b)
+	}
+
+	return toCSVValue(src)
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,170 @@
+package warehouse
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+const (
+	csvNull               = `\N`
+	snowflakeDefaultStage = "klepto_stage"
+)
+
+// snowflake stages CSV files that are uploaded with PUT and loaded with COPY INTO.
+type snowflake struct {
+	stage string
+	// connection is the named connection of the SnowSQL configuration the tables are loaded with, the default one
+	// when not set.
+	connection string
+}
+
+func newSnowflake(params url.Values) (Target, error) {
+	stage := params.Get("stage")
+	if stage == "" {
+		stage = snowflakeDefaultStage
+	}
+
+	return &snowflake{stage: strings.TrimPrefix(stage, "@"), connection: params.Get("connection")}, nil
+}
+
+// Extension returns the staged file extension.
+func (s *snowflake) Extension() string {
+	return ".csv"
+}
+
+// WriteRows writes a CSV file with a header, all the values are quoted and NULL values are written as empty unquoted
+// fields, so no text value can be read as NULL.
+func (s *snowflake) WriteRows(w io.Writer, columns []string, rowChan <-chan database.Row) (int, error) {
+	bw := bufio.NewWriter(w)
+	record := make([]interface{}, len(columns))
+	for i, column := range columns {
+		record[i] = column
+	}
+	if err := writeQuotedRecord(bw, record); err != nil {
+		return 0, err
+	}
+
+	var n int
+	for row := range rowChan {
+		for i, column := range columns {
+			record[i] = row.Get(column)
+		}
+		if err := writeQuotedRecord(bw, record); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, bw.Flush()
+}
+
+// Script returns a SnowSQL script uploading the staged files to the stage and copying them into the tables.
+func (s *snowflake) Script(dir string, tables []string) (string, string) {
+	var b strings.Builder
+	b.WriteString("-- Loads the tables staged by Klepto, run with: snowsql -f load.sql\n")
+	fmt.Fprintf(&b, "CREATE STAGE IF NOT EXISTS %s;\n", s.stage)
+	for _, table := range tables {
+		file := table + s.Extension()
+		fmt.Fprintf(&b, "\nPUT 'file://%s' @%s AUTO_COMPRESS = TRUE OVERWRITE = TRUE;\n", filepath.ToSlash(filepath.Join(dir, file)), s.stage)
+		fmt.Fprintf(
+			&b,
+			"COPY INTO %s FROM @%s/%s.gz\n  FILE_FORMAT = (TYPE = CSV SKIP_HEADER = 1 FIELD_OPTIONALLY_ENCLOSED_BY = '\"' NULL_IF = () EMPTY_FIELD_AS_NULL = TRUE);\n",
+			quoteIdentifier(table),
+			s.stage,
+			file,
+		)
+	}
+
+	return "load.sql", b.String()
+}
+
+// Program returns the SnowSQL command line tool.
+func (s *snowflake) Program() string {
+	return "snowsql"
+}
+
+// LoadArgs returns the arguments running the load script with SnowSQL, stopping at the first failing statement.
+func (s *snowflake) LoadArgs(dir string, script string, tables []string) [][]string {
+	args := []string{"-o", "exit_on_error=true", "-o", "friendly=false"}
+	if s.connection != "" {
+		args = append(args, "-c", s.connection)
+	}
+
+	return [][]string{append(args, "-f", script)}
+}
+
+// writeQuotedRecord writes a CSV line with every value quoted, but the NULL ones written as empty fields.
+func writeQuotedRecord(w *bufio.Writer, record []interface{}) error {
+	for i, value := range record {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if isNull(value) {
+			continue
+		}
+		w.WriteByte('"')
+		w.WriteString(strings.ReplaceAll(toCSVValue(value), `"`, `""`))
+		w.WriteByte('"')
+	}
+	_, err := w.WriteString("\n")
+	return err
+}
+
+// writeCSV writes the rows with a header line, formatting the values with format.
+func writeCSV(w io.Writer, columns []string, rowChan <-chan database.Row, format func(interface{}) string) (int, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return 0, err
+	}
+
+	var n int
+	record := make([]string, len(columns))
+	for row := range rowChan {
+		for i, column := range columns {
+			record[i] = format(row.Get(column))
+		}
+		if err := cw.Write(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	cw.Flush()
+	return n, cw.Error()
+}
+
+func isNull(src interface{}) bool {
+	if value, ok := src.(*interface{}); ok {
+		return value == nil || isNull(*value)
+	}
+
+	return src == nil
+}
+
+func toCSVValue(src interface{}) string {
+	switch value := src.(type) {
+	case nil:
+		return csvNull
+	case *interface{}:
+		if value == nil {
+			return csvNull
+		}
+		return toCSVValue(*value)
+	case []byte:
+		return string(value)
+	case string:
+		return value
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
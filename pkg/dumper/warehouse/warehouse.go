@@ -0,0 +1,103 @@
+package warehouse
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	parser "github.com/hellofresh/klepto/pkg/dsn"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// targets maps a dsn type to the warehouse the files are staged for.
+var targets = map[string]func(params url.Values) (Target, error){
+	"snowflake": newSnowflake,
+	"bigquery":  newBigQuery,
+	"redshift":  newRedshift,
+}
+
+type driver struct{}
+
+// IsSupported checks if the given dsn connection string is supported.
+func (m *driver) IsSupported(dsn string) bool {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return false
+	}
+
+	_, ok := targets[u.Scheme]
+	return ok
+}
+
+// NewConnection creates the staging directory and retrieves a new warehouse dumper.
+func (m *driver) NewConnection(opts dumper.ConnOpts, rdr reader.Reader) (dumper.Dumper, error) {
+	// parameters such as the s3 prefix are urls themselves, which the klepto dsn parser does not handle
+	u, err := url.Parse(opts.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := u.Path
+	if u.Host != "" {
+		// relative paths such as snowflake://./stage/
+		dir = filepath.Join(u.Host, u.Path)
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("no staging directory given in dsn %q", parser.Redacted(opts.DSN))
+	}
+
+	target, err := targets[u.Scheme](u.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	// load scripts reference the staged files with absolute paths
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	loader, err := findLoader(target, u.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDumper(dir, target, loader, rdr), nil
+}
+
+// findLoader returns the path of the program loading the staged tables, none when the target has no loader or the
+// load parameter is false.
+func findLoader(target Target, params url.Values) (string, error) {
+	l, ok := target.(Loader)
+	if !ok {
+		return "", nil
+	}
+
+	if load := params.Get("load"); load != "" {
+		enabled, err := strconv.ParseBool(load)
+		if err != nil {
+			return "", fmt.Errorf("invalid load parameter %q: %w", load, err)
+		}
+		if !enabled {
+			return "", nil
+		}
+	}
+
+	path, err := exec.LookPath(l.Program())
+	if err != nil {
+		return "", fmt.Errorf("%s loads the staged tables, set load=false to only stage them: %w", l.Program(), err)
+	}
+
+	return path, nil
+}
+
+func init() {
+	dumper.Register("warehouse", &driver{})
+}
@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/dumper/engine"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/retry"
+)
+
+type (
+	webhookDumper struct {
+		client    *http.Client
+		endpoint  string
+		headers   http.Header
+		batchSize int
+		// batchBytes is the amount of bytes posted per batch, 0 for batches of batchSize rows.
+		batchBytes int64
+		policy     retry.Policy
+		// batchSizes are the batch sizes configured per table.
+		batchSizes map[string]int
+	}
+
+	// Batch is the JSON body posted to the endpoint.
+	Batch struct {
+		// Table is the name of the table the rows belong to.
+		Table string `json:"table"`
+		// Rows are the anonymised rows keyed by column name.
+		Rows []map[string]interface{} `json:"rows"`
+	}
+)
+
+// NewDumper returns a new dumper posting batches of rows as JSON to the endpoint. With batchBytes, the batches are
+// sized to about this amount of bytes from the average width of the rows posted, the first one being of batchSize rows.
+func NewDumper(client *http.Client, endpoint string, headers http.Header, batchSize int, batchBytes int64, policy retry.Policy, rdr reader.Reader) dumper.Dumper {
+	return engine.New(rdr, &webhookDumper{
+		client:     client,
+		endpoint:   endpoint,
+		headers:    headers,
+		batchSize:  batchSize,
+		batchBytes: batchBytes,
+		policy:     policy,
+	})
+}
+
+// DumpStructure is a no-op, only rows are sent to the endpoint.
+func (d *webhookDumper) DumpStructure(sql string) error {
+	log.Debug("the webhook endpoint does not receive the database structure, skipping")
+	return nil
+}
+
+// Configure collects the batch sizes configured per table.
+func (d *webhookDumper) Configure(cfgTables config.Tables) {
+	d.batchSizes = make(map[string]int, len(cfgTables))
+	for _, t := range cfgTables {
+		if t.BatchSize > 0 {
+			d.batchSizes[t.Name] = t.BatchSize
+		}
+	}
+}
+
+// DumpTable posts the table rows in batches, the batch size configured for the table not being adapted.
+func (d *webhookDumper) DumpTable(tableName string, rowChan <-chan database.Row) error {
+	batchSize, budget := d.batchSize, d.batchBytes
+	if size, ok := d.batchSizes[tableName]; ok {
+		batchSize, budget = size, 0
+	}
+	sizer := database.NewBatchSizer(budget, uint64(batchSize))
+	limit := sizer.Size()
+	batch := Batch{Table: tableName, Rows: make([]map[string]interface{}, 0, batchSize)}
+
+	var sent int
+	for row := range rowChan {
+		sizer.Observe(row)
+		batch.Rows = append(batch.Rows, toObject(row))
+		if uint64(len(batch.Rows)) < limit {
+			continue
+		}
+
+		if err := d.post(batch); err != nil {
+			drain(rowChan)
+			return err
+		}
+		sent += len(batch.Rows)
+		batch.Rows = batch.Rows[:0]
+		limit = sizer.Size()
+	}
+
+	if len(batch.Rows) > 0 {
+		if err := d.post(batch); err != nil {
+			return err
+		}
+		sent += len(batch.Rows)
+	}
+
+	log.WithFields(log.Fields{
+		"table": tableName,
+		"sent":  sent,
+	}).Debug("posted rows to webhook")
+
+	return nil
+}
+
+// Close releases the idle connections to the endpoint.
+func (d *webhookDumper) Close() error {
+	d.client.CloseIdleConnections()
+	return nil
+}
+
+// post sends a batch, retrying on network errors, 429 and 5xx responses.
+func (d *webhookDumper) post(batch Batch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode rows of %s: %w", batch.Table, err)
+	}
+
+	return d.policy.Do(context.Background(), func() error {
+		req, err := http.NewRequest(http.MethodPost, d.endpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		for name, values := range d.headers {
+			req.Header[name] = values
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to post rows of %s: %w", batch.Table, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			_, err := io.Copy(io.Discard, resp.Body)
+			return err
+		}
+
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err = fmt.Errorf("webhook responded %s to rows of %s: %s", resp.Status, batch.Table, bytes.TrimSpace(msg))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return retry.Transient(err)
+		}
+
+		return err
+	})
+}
+
+// toObject converts a row into a JSON object.
+func toObject(row database.Row) map[string]interface{} {
+	object := make(map[string]interface{}, row.Len())
+	for i, column := range row.Columns() {
+		object[column] = toJSONValue(row.Values()[i])
+	}
+
+	return object
+}
+
+func toJSONValue(src interface{}) interface{} {
+	switch value := src.(type) {
+	case []byte:
+		return string(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case *interface{}:
+		if value == nil {
+			return nil
+		}
+		return toJSONValue(*value)
+	default:
+		return value
+	}
+}
+
+// drain consumes the remaining rows so the reader does not block forever.
+func drain(rowChan <-chan database.Row) {
+	for range rowChan {
+	}
+}
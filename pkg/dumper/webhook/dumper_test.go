@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/retry"
+)
+
+func TestDumpTableBatches(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches []Batch
+		calls   int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls++
+		if calls == 1 {
+			// the first attempt fails and must be retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var batch Batch
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+
+	d := &webhookDumper{
+		client:    server.Client(),
+		endpoint:  server.URL,
+		headers:   http.Header{"Authorization": []string{"Bearer secret"}},
+		batchSize: 2,
+		policy:    retry.Policy{Attempts: 2, Backoff: time.Millisecond},
+	}
+
+	columns := database.NewColumns([]string{"id", "name"})
+	rowChan := make(chan database.Row, 3)
+	rowChan <- database.NewRow(columns, []interface{}{int64(1), []byte("foo")})
+	rowChan <- database.NewRow(columns, []interface{}{int64(2), nil})
+	rowChan <- database.NewRow(columns, []interface{}{int64(3), "bar"})
+	close(rowChan)
+
+	require.NoError(t, d.DumpTable("users", rowChan))
+
+	require.Len(t, batches, 2)
+	assert.Equal(t, "users", batches[0].Table)
+	assert.Equal(t, []map[string]interface{}{
+		{"id": float64(1), "name": "foo"},
+		{"id": float64(2), "name": nil},
+	}, batches[0].Rows)
+	assert.Equal(t, []map[string]interface{}{{"id": float64(3), "name": "bar"}}, batches[1].Rows)
+
+	// the batch size configured for the table overrides the default one
+	d.Configure(config.Tables{{Name: "orders", BatchSize: 3}})
+	rowChan = make(chan database.Row, 3)
+	for i := 1; i <= 3; i++ {
+		rowChan <- database.NewRow(columns, []interface{}{int64(i), "baz"})
+	}
+	close(rowChan)
+
+	require.NoError(t, d.DumpTable("orders", rowChan))
+	require.Len(t, batches, 3)
+	assert.Equal(t, "orders", batches[2].Table)
+	assert.Len(t, batches[2].Rows, 3)
+}
+
+func TestDumpTableBatchBytes(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch Batch
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		sizes = append(sizes, len(batch.Rows))
+	}))
+	defer server.Close()
+
+	d := &webhookDumper{
+		client:     server.Client(),
+		endpoint:   server.URL,
+		batchSize:  2,
+		batchBytes: 300,
+		policy:     retry.Policy{Attempts: 1},
+	}
+
+	// the rows are 100 bytes wide, the batches after the first one hold 3 rows
+	columns := database.NewColumns([]string{"id", "name"})
+	rowChan := make(chan database.Row, 8)
+	for i := 1; i <= 8; i++ {
+		rowChan <- database.NewRow(columns, []interface{}{int64(i), strings.Repeat("x", 92)})
+	}
+	close(rowChan)
+
+	require.NoError(t, d.DumpTable("users", rowChan))
+	assert.Equal(t, []int{2, 3, 3}, sizes)
+
+	// the batch size configured for the table is not adapted
+	d.Configure(config.Tables{{Name: "orders", BatchSize: 2}})
+	sizes = nil
+	rowChan = make(chan database.Row, 5)
+	for i := 1; i <= 5; i++ {
+		rowChan <- database.NewRow(columns, []interface{}{int64(i), strings.Repeat("x", 92)})
+	}
+	close(rowChan)
+
+	require.NoError(t, d.DumpTable("orders", rowChan))
+	assert.Equal(t, []int{2, 2, 1}, sizes)
+}
+
+func TestDumpTableClientError(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	d := &webhookDumper{
+		client:    server.Client(),
+		endpoint:  server.URL,
+		batchSize: 1,
+		policy:    retry.Policy{Attempts: 3, Backoff: time.Millisecond},
+	}
+
+	columns := database.NewColumns([]string{"id"})
+	rowChan := make(chan database.Row, 2)
+	rowChan <- database.NewRow(columns, []interface{}{int64(1)})
+	rowChan <- database.NewRow(columns, []interface{}{int64(2)})
+	close(rowChan)
+
+	err := d.DumpTable("users", rowChan)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid payload")
+	assert.Equal(t, 1, calls, "client errors must not be retried")
+}
@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+const defaultBatchSize = 500
+
+type driver struct{}
+
+// IsSupported checks if the given dsn connection string is supported.
+func (m *driver) IsSupported(dsn string) bool {
+	dsn = strings.ToLower(dsn)
+	return strings.HasPrefix(dsn, "http://") || strings.HasPrefix(dsn, "https://")
+}
+
+// NewConnection retrieves a new webhook dumper posting the rows to the dsn.
+func (m *driver) NewConnection(opts dumper.ConnOpts, rdr reader.Reader) (dumper.Dumper, error) {
+	batchSize := opts.HTTPBatchSize
+	if batchSize < 1 {
+		batchSize = defaultBatchSize
+	}
+
+	client := &http.Client{Timeout: opts.Timeout}
+
+	return NewDumper(client, opts.DSN, opts.HTTPHeaders, batchSize, opts.HTTPBatchBytes, opts.Retry, rdr), nil
+}
+
+func init() {
+	dumper.Register("webhook", &driver{})
+}
@@ -0,0 +1,254 @@
+package erasure
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/anonymiser"
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/dumper/query"
+	"github.com/hellofresh/klepto/pkg/integrity"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/subject"
+)
+
+// Kinds of erasure statements.
+const (
+	// Update replaces the anonymised columns of a row with anonymised values.
+	Update Kind = "update"
+	// Delete deletes a row of a table without anonymised columns.
+	Delete Kind = "delete"
+)
+
+type (
+	// Kind is the kind of statement erasing a row.
+	Kind string
+
+	// Statement is a statement erasing a row of the data subject.
+	Statement struct {
+		Table string
+		Kind  Kind
+		SQL   string
+	}
+
+	// capture keeps a copy of the rows read, before they are anonymised.
+	capture struct {
+		reader.Reader
+		originals []database.Row
+	}
+)
+
+// Plan returns the statements erasing the rows of the data subject, found like the subject extraction does
+// (see subject.NewReader). The rows of the tables with Anonymise rules are updated with values of their anonymisers,
+// the rows of the other tables are deleted. The updates come first, parents first, then the deletes, children first.
+// Rows are identified by their primary key, or by all their values when the source does not know it.
+func Plan(source reader.Reader, cfgTables config.Tables, subj subject.Subject, dialect query.Dialect) ([]Statement, error) {
+	subjects, err := subject.NewReader(source, cfgTables, subj)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := subjects.GetTables()
+	if err != nil {
+		return nil, err
+	}
+
+	// only the anonymisers apply, the rows of synthetic tables are erased like any other rows
+	var anonymised config.Tables
+	for _, table := range cfgTables {
+		if len(table.Anonymise) > 0 {
+			anonymised = append(anonymised, &config.Table{Name: table.Name, Anonymise: table.Anonymise})
+		}
+	}
+
+	var (
+		updates []Statement
+		deletes [][]Statement
+		deleted = make(map[string]bool)
+	)
+	for _, tableName := range tables {
+		key, err := primaryKey(source, tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		table := anonymised.FindByName(tableName)
+		if table == nil {
+			rows, err := readAll(subjects, tableName)
+			if err != nil {
+				return nil, err
+			}
+
+			statements := make([]Statement, 0, len(rows))
+			for _, row := range rows {
+				stmt, err := deleteRow(dialect, tableName, key, row)
+				if err != nil {
+					return nil, err
+				}
+				statements = append(statements, stmt)
+			}
+			deletes = append(deletes, statements)
+			deleted[tableName] = true
+			continue
+		}
+
+		c := &capture{Reader: subjects}
+		rows, err := readAll(anonymiser.NewAnonymiser(c, config.Tables{table}, 1), tableName)
+		if err != nil {
+			return nil, err
+		}
+		for i, row := range rows {
+			stmt, err := updateRow(dialect, table, key, c.originals[i], row)
+			if err != nil {
+				return nil, err
+			}
+			updates = append(updates, stmt)
+		}
+	}
+
+	foreignKeys, err := integrity.ForeignKeys(source, cfgTables, tables)
+	if err != nil {
+		return nil, err
+	}
+	for _, fk := range foreignKeys {
+		if !deleted[fk.Table] && deleted[fk.ReferencedTable] {
+			log.WithFields(log.Fields{"table": fk.Table, "referenced_table": fk.ReferencedTable}).
+				Warn("updated rows reference deleted rows, the deletes fail or cascade unless the references are removed")
+		}
+	}
+
+	statements := updates
+	for i := len(deletes) - 1; i >= 0; i-- {
+		statements = append(statements, deletes[i]...)
+	}
+
+	return statements, nil
+}
+
+// primaryKey returns the primary key of a table, none when the source does not know it.
+func primaryKey(source reader.Reader, tableName string) ([]string, error) {
+	keyer, ok := source.(reader.PrimaryKeyer)
+	if !ok {
+		return nil, nil
+	}
+
+	key, err := keyer.GetPrimaryKey(tableName)
+	if errors.Is(err, reader.ErrPrimaryKeysUnsupported) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erasure: could not get the primary key of %s: %w", tableName, err)
+	}
+
+	return key, nil
+}
+
+func updateRow(dialect query.Dialect, table *config.Table, key []string, original database.Row, row database.Row) (Statement, error) {
+	columns := make([]string, 0, len(table.Anonymise))
+	for column := range table.Anonymise {
+		if _, ok := row.Lookup(column); ok {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		value, err := dialect.FormatValue(row.Get(column))
+		if err != nil {
+			return Statement{}, fmt.Errorf("erasure: could not format %s.%s: %w", table.Name, column, err)
+		}
+		assignments[i] = dialect.QuoteIdentifier(column) + " = " + value
+	}
+
+	where, err := whereRow(dialect, table.Name, key, original)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	return Statement{
+		Table: table.Name,
+		Kind:  Update,
+		SQL:   fmt.Sprintf("UPDATE %s SET %s WHERE %s", dialect.QuoteIdentifier(table.Name), strings.Join(assignments, ", "), where),
+	}, nil
+}
+
+func deleteRow(dialect query.Dialect, tableName string, key []string, row database.Row) (Statement, error) {
+	where, err := whereRow(dialect, tableName, key, row)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	return Statement{
+		Table: tableName,
+		Kind:  Delete,
+		SQL:   fmt.Sprintf("DELETE FROM %s WHERE %s", dialect.QuoteIdentifier(tableName), where),
+	}, nil
+}
+
+// whereRow builds the condition matching a row by its key columns, or by all its columns without key.
+func whereRow(dialect query.Dialect, tableName string, key []string, row database.Row) (string, error) {
+	columns := key
+	if len(columns) == 0 {
+		columns = row.Columns()
+	}
+
+	conditions := make([]string, len(columns))
+	for i, column := range columns {
+		value, err := dialect.FormatValue(row.Get(column))
+		if err != nil {
+			return "", fmt.Errorf("erasure: could not format %s.%s: %w", tableName, column, err)
+		}
+		if value == "NULL" {
+			conditions[i] = dialect.QuoteIdentifier(column) + " IS NULL"
+			continue
+		}
+		conditions[i] = dialect.QuoteIdentifier(column) + " = " + value
+	}
+
+	return strings.Join(conditions, " AND "), nil
+}
+
+// readAll reads all the rows of a table.
+func readAll(source reader.Reader, tableName string) ([]database.Row, error) {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- source.ReadTable(tableName, rowChan, reader.ReadTableOpt{})
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+	if err := <-errChan; err != nil {
+		return nil, fmt.Errorf("erasure: could not read %s: %w", tableName, err)
+	}
+
+	return rows, nil
+}
+
+// ReadTable copies the rows read from the source before publishing them.
+func (c *capture) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	rawChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- c.Reader.ReadTable(tableName, rawChan, opts)
+	}()
+
+	for row := range rawChan {
+		values := make([]interface{}, row.Len())
+		copy(values, row.Values())
+		c.originals = append(c.originals, database.NewRow(row.ColumnIndex(), values))
+
+		rowChan <- row
+	}
+
+	return <-errChan
+}
@@ -0,0 +1,91 @@
+package erasure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/dumper/query"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/subject"
+)
+
+type mockReader struct {
+	reader.Reader
+	rows map[string][]database.Row
+}
+
+func newMockReader() *mockReader {
+	users := database.NewColumns([]string{"id", "email", "name"})
+	orders := database.NewColumns([]string{"id", "user_id", "address"})
+	sessions := database.NewColumns([]string{"user_id", "token"})
+
+	return &mockReader{rows: map[string][]database.Row{
+		"users": {
+			database.NewRow(users, []interface{}{int64(1), "a@example.com", "A"}),
+			database.NewRow(users, []interface{}{int64(2), "b@example.com", "B"}),
+		},
+		"orders": {
+			database.NewRow(orders, []interface{}{int64(10), int64(1), "1 Main St"}),
+			database.NewRow(orders, []interface{}{int64(20), int64(2), "2 Main St"}),
+		},
+		"sessions": {
+			database.NewRow(sessions, []interface{}{int64(2), "abc"}),
+			database.NewRow(sessions, []interface{}{int64(2), nil}),
+		},
+	}}
+}
+
+func (m *mockReader) GetTables() ([]string, error) {
+	return []string{"sessions", "orders", "users"}, nil
+}
+
+func (m *mockReader) GetForeignKeys() ([]reader.ForeignKey, error) {
+	return []reader.ForeignKey{
+		{Table: "orders", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "sessions", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}, nil
+}
+
+func (m *mockReader) GetPrimaryKey(tableName string) ([]string, error) {
+	if tableName == "sessions" {
+		return nil, nil
+	}
+	return []string{"id"}, nil
+}
+
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return "`" + tableName + "`.`" + columnName + "`"
+}
+
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	for _, row := range m.rows[tableName] {
+		rowChan <- row
+	}
+	return nil
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	dialect, err := query.GetDialect("mysql")
+	require.NoError(t, err)
+
+	cfgTables := config.Tables{
+		{Name: "users", Anonymise: map[string]string{"name": "literal:erased", "email": "literal:erased@example.com"}},
+		{Name: "orders", Anonymise: map[string]string{"address": "literal:"}},
+	}
+	statements, err := Plan(newMockReader(), cfgTables, subject.Subject{Table: "users", Column: "id", Key: "2"}, dialect)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Statement{
+		{Table: "users", Kind: Update, SQL: "UPDATE `users` SET `email` = 'erased@example.com', `name` = 'erased' WHERE `id` = 2"},
+		{Table: "orders", Kind: Update, SQL: "UPDATE `orders` SET `address` = '' WHERE `id` = 20"},
+		{Table: "sessions", Kind: Delete, SQL: "DELETE FROM `sessions` WHERE `user_id` = 2 AND `token` = 'abc'"},
+		{Table: "sessions", Kind: Delete, SQL: "DELETE FROM `sessions` WHERE `user_id` = 2 AND `token` IS NULL"},
+	}, statements)
+}
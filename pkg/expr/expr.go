@@ -0,0 +1,493 @@
+package expr
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+// rowName is the identifier of the row in the expressions.
+const rowName = "row"
+
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Expression is a compiled expression evaluated against rows, written with the Go syntax,
+// e.g. row.country == "US" && row.opt_out.
+type Expression struct {
+	source string
+	root   ast.Expr
+}
+
+// Compile parses an expression, failing on syntax errors, unknown functions and unsupported operations.
+func Compile(source string) (*Expression, error) {
+	root, err := parser.ParseExpr(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+
+	if err := check(root); err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against the row. Numbers are returned as int64 when they are whole
+// and float64 otherwise, NULL as nil.
+func (e *Expression) Eval(row database.Row) (interface{}, error) {
+	value, err := eval(e.root, row)
+	if err != nil {
+		return nil, fmt.Errorf("could not evaluate %q: %w", e.source, err)
+	}
+
+	if f, ok := value.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+		return int64(f), nil
+	}
+
+	return value, nil
+}
+
+// EvalBool evaluates the expression against the row as a condition, NULL being false.
+func (e *Expression) EvalBool(row database.Row) (bool, error) {
+	value, err := eval(e.root, row)
+	if err != nil {
+		return false, fmt.Errorf("could not evaluate %q: %w", e.source, err)
+	}
+
+	b, err := truthy(value)
+	if err != nil {
+		return false, fmt.Errorf("could not evaluate %q: %w", e.source, err)
+	}
+
+	return b, nil
+}
+
+// check validates the nodes of the expression before it is evaluated.
+func check(node ast.Expr) error {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		if n.Kind == token.CHAR || n.Kind == token.IMAG {
+			return fmt.Errorf("unsupported literal %s, strings are double quoted", n.Value)
+		}
+		return nil
+	case *ast.Ident:
+		switch n.Name {
+		case "true", "false", "nil":
+			return nil
+		case rowName:
+			return fmt.Errorf("%s must be followed by a column, e.g. %s.id", rowName, rowName)
+		}
+		return fmt.Errorf("unknown identifier %s, columns are read with %s.column", n.Name, rowName)
+	case *ast.SelectorExpr:
+		if x, ok := n.X.(*ast.Ident); ok && x.Name == rowName {
+			return nil
+		}
+		return fmt.Errorf("unsupported selector, columns are read with %s.column", rowName)
+	case *ast.IndexExpr:
+		if x, ok := n.X.(*ast.Ident); !ok || x.Name != rowName {
+			return fmt.Errorf("unsupported index, columns are read with %s[\"column\"]", rowName)
+		}
+		if lit, ok := n.Index.(*ast.BasicLit); !ok || lit.Kind != token.STRING {
+			return fmt.Errorf("the column of %s[...] must be a string", rowName)
+		}
+		return nil
+	case *ast.ParenExpr:
+		return check(n.X)
+	case *ast.UnaryExpr:
+		switch n.Op {
+		case token.NOT, token.SUB, token.ADD:
+			return check(n.X)
+		}
+		return fmt.Errorf("unsupported operator %s", n.Op)
+	case *ast.BinaryExpr:
+		switch n.Op {
+		case token.LAND, token.LOR, token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ,
+			token.ADD, token.SUB, token.MUL, token.QUO, token.REM:
+		default:
+			return fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		if err := check(n.X); err != nil {
+			return err
+		}
+		return check(n.Y)
+	case *ast.CallExpr:
+		name, ok := n.Fun.(*ast.Ident)
+		if !ok {
+			return fmt.Errorf("unsupported call")
+		}
+		f, ok := functions[name.Name]
+		if !ok {
+			return fmt.Errorf("unknown function %s", name.Name)
+		}
+		if len(n.Args) < f.minArgs || (f.maxArgs >= 0 && len(n.Args) > f.maxArgs) {
+			return fmt.Errorf("wrong number of arguments for %s", name.Name)
+		}
+		for _, arg := range n.Args {
+			if err := check(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported syntax %T", node)
+}
+
+func eval(node ast.Expr, row database.Row) (interface{}, error) {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		return literal(n)
+	case *ast.Ident:
+		switch n.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, nil
+	case *ast.SelectorExpr:
+		return column(row, n.Sel.Name), nil
+	case *ast.IndexExpr:
+		name, err := strconv.Unquote(n.Index.(*ast.BasicLit).Value)
+		if err != nil {
+			return nil, err
+		}
+		return column(row, name), nil
+	case *ast.ParenExpr:
+		return eval(n.X, row)
+	case *ast.UnaryExpr:
+		return evalUnary(n, row)
+	case *ast.BinaryExpr:
+		return evalBinary(n, row)
+	case *ast.CallExpr:
+		f := functions[n.Fun.(*ast.Ident).Name]
+		args := make([]interface{}, len(n.Args))
+		for i, arg := range n.Args {
+			value, err := eval(arg, row)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = value
+		}
+		return f.call(args)
+	}
+
+	return nil, fmt.Errorf("unsupported syntax %T", node)
+}
+
+func literal(lit *ast.BasicLit) (interface{}, error) {
+	switch lit.Kind {
+	case token.STRING:
+		return strconv.Unquote(lit.Value)
+	case token.INT:
+		i, err := strconv.ParseInt(lit.Value, 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		return float64(i), nil
+	}
+
+	return strconv.ParseFloat(lit.Value, 64)
+}
+
+// column returns the normalised value of a row column, nil when the row does not have it.
+func column(row database.Row, name string) interface{} {
+	value, ok := row.Lookup(name)
+	if !ok {
+		return nil
+	}
+	if p, ok := value.(*interface{}); ok && p != nil {
+		value = *p
+	}
+
+	return normalise(value)
+}
+
+// normalise converts the values read from the databases to string, float64, bool, time.Time or nil.
+func normalise(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+
+	return value
+}
+
+func evalUnary(n *ast.UnaryExpr, row database.Row) (interface{}, error) {
+	value, err := eval(n.X, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.Op == token.NOT {
+		b, err := truthy(value)
+		if err != nil {
+			return nil, err
+		}
+		return !b, nil
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+	f, ok := number(value)
+	if !ok {
+		return nil, fmt.Errorf("%s%v is not a number", n.Op, value)
+	}
+	if n.Op == token.SUB {
+		return -f, nil
+	}
+
+	return f, nil
+}
+
+func evalBinary(n *ast.BinaryExpr, row database.Row) (interface{}, error) {
+	x, err := eval(n.X, row)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Op {
+	case token.LAND, token.LOR:
+		left, err := truthy(x)
+		if err != nil {
+			return nil, err
+		}
+		if (n.Op == token.LAND && !left) || (n.Op == token.LOR && left) {
+			return left, nil
+		}
+		y, err := eval(n.Y, row)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(y)
+	}
+
+	y, err := eval(n.Y, row)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Op {
+	case token.EQL:
+		return equal(x, y), nil
+	case token.NEQ:
+		return !equal(x, y), nil
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		if x == nil || y == nil {
+			return false, nil
+		}
+		c, err := compare(x, y)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case token.LSS:
+			return c < 0, nil
+		case token.LEQ:
+			return c <= 0, nil
+		case token.GTR:
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	}
+
+	return arithmetic(n.Op, x, y)
+}
+
+// arithmetic applies an arithmetic operator, + concatenates strings and NULL operands give NULL.
+func arithmetic(op token.Token, x interface{}, y interface{}) (interface{}, error) {
+	if x == nil || y == nil {
+		return nil, nil
+	}
+
+	if op == token.ADD {
+		xs, xString := x.(string)
+		ys, yString := y.(string)
+		if xString && yString {
+			return xs + ys, nil
+		}
+	}
+
+	a, ok := number(x)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", x)
+	}
+	b, ok := number(y)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", y)
+	}
+
+	switch op {
+	case token.ADD:
+		return a + b, nil
+	case token.SUB:
+		return a - b, nil
+	case token.MUL:
+		return a * b, nil
+	case token.QUO:
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	}
+
+	if b == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	return math.Mod(a, b), nil
+}
+
+// truthy returns the boolean value of a condition, strings such as "1" or "false" are parsed.
+func truthy(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, fmt.Errorf("%q is not a boolean", v)
+		}
+		return b, nil
+	}
+
+	return false, fmt.Errorf("%v is not a boolean", value)
+}
+
+// number returns the numeric value of a number or a numeric string.
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// equal compares two values, converting strings to the type of the other operand.
+func equal(x interface{}, y interface{}) bool {
+	if x == nil || y == nil {
+		return x == nil && y == nil
+	}
+
+	switch a := x.(type) {
+	case string:
+		if b, ok := y.(string); ok {
+			return a == b
+		}
+		return equal(y, x)
+	case float64:
+		b, ok := number(y)
+		return ok && a == b
+	case bool:
+		b, err := truthy(y)
+		return err == nil && a == b
+	case time.Time:
+		b, ok := toTime(y)
+		return ok && a.Equal(b)
+	}
+
+	return x == y
+}
+
+// compare orders two values, converting strings to the type of the other operand.
+func compare(x interface{}, y interface{}) (int, error) {
+	if a, ok := x.(string); ok {
+		if b, ok := y.(string); ok {
+			return strings.Compare(a, b), nil
+		}
+		c, err := compare(y, x)
+		return -c, err
+	}
+
+	switch a := x.(type) {
+	case float64:
+		if b, ok := number(y); ok {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case time.Time:
+		if b, ok := toTime(y); ok {
+			switch {
+			case a.Before(b):
+				return -1, nil
+			case a.After(b):
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+
+	return 0, fmt.Errorf("can not compare %v with %v", x, y)
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
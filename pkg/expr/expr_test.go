@@ -0,0 +1,102 @@
+package expr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+
+	columns := database.NewColumns([]string{"id", "email", "country", "opt_out", "age", "score", "created_at", "nickname"})
+	row := database.NewRow(columns, []interface{}{
+		int64(7), []byte(" John@Example.com "), "US", []byte("1"), []byte("42"), 2.5,
+		time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC), nil,
+	})
+
+	tests := []struct {
+		expression string
+		expected   interface{}
+	}{
+		{`row.id + 1`, int64(8)},
+		{`row.score * 2`, int64(5)},
+		{`row.score / 2`, 1.25},
+		{`-row.id % 4`, int64(-3)},
+		{`lower(trim(row.email))`, "john@example.com"},
+		{`row.country + "-" + string(row.id)`, "US-7"},
+		{`row["opt_out"] == true`, true},
+		{`row.age > 18 && row.age <= 42`, true},
+		{`row.created_at >= "2021-03-01"`, true},
+		{`row.nickname == nil`, true},
+		{`row.nickname + "x"`, nil},
+		{`coalesce(row.nickname, row.country)`, "US"},
+		{`when(row.country == "US", "domestic", "foreign")`, "domestic"},
+		{`matches(row.email, "(?i)@example\\.com")`, true},
+		{`replace(row.country, "U", "A")`, "AS"},
+		{`len(row.country)`, int64(2)},
+		{`hasPrefix(row.country, "U") || row.missing`, true},
+		{`row.unknown`, nil},
+	}
+
+	for _, test := range tests {
+		e, err := Compile(test.expression)
+		require.NoError(t, err, test.expression)
+
+		value, err := e.Eval(row)
+		require.NoError(t, err, test.expression)
+		assert.Equal(t, test.expected, value, test.expression)
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	t.Parallel()
+
+	columns := database.NewColumns([]string{"country", "opt_out"})
+	tests := []struct {
+		values   []interface{}
+		expected bool
+	}{
+		{[]interface{}{"US", []byte("1")}, true},
+		{[]interface{}{"US", true}, true},
+		{[]interface{}{"US", int64(0)}, false},
+		{[]interface{}{"DE", true}, false},
+		{[]interface{}{"US", nil}, false},
+	}
+
+	e, err := Compile(`row.country == "US" && row.opt_out`)
+	require.NoError(t, err)
+	for _, test := range tests {
+		drop, err := e.EvalBool(database.NewRow(columns, test.values))
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, drop, test.values)
+	}
+
+	_, err = e.EvalBool(database.NewRow(columns, []interface{}{"US", "maybe"}))
+	assert.EqualError(t, err, `could not evaluate "row.country == \"US\" && row.opt_out": "maybe" is not a boolean`)
+}
+
+func TestCompileErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, expression := range []string{
+		`row.country ==`,
+		`row`,
+		`country == "US"`,
+		`row.a.b`,
+		`row[1]`,
+		`'x'`,
+		`row.a & 1`,
+		`lower(row.a, row.b)`,
+		`strings.ToLower(row.a)`,
+		`missing(row.a)`,
+		`func() {}`,
+	} {
+		_, err := Compile(expression)
+		assert.Error(t, err, expression)
+	}
+}
@@ -0,0 +1,147 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// function is a function callable from the expressions, maxArgs is -1 for variadic functions.
+type function struct {
+	minArgs int
+	maxArgs int
+	call    func(args []interface{}) (interface{}, error)
+}
+
+var (
+	functions = map[string]function{
+		"lower":     {1, 1, stringFunc(strings.ToLower)},
+		"upper":     {1, 1, stringFunc(strings.ToUpper)},
+		"trim":      {1, 1, stringFunc(strings.TrimSpace)},
+		"len":       {1, 1, length},
+		"contains":  {2, 2, stringPredicate(strings.Contains)},
+		"hasPrefix": {2, 2, stringPredicate(strings.HasPrefix)},
+		"hasSuffix": {2, 2, stringPredicate(strings.HasSuffix)},
+		"matches":   {2, 2, matches},
+		"replace":   {3, 3, replace},
+		"coalesce":  {1, -1, coalesce},
+		"when":      {3, 3, when},
+		"string":    {1, 1, stringFunc(func(s string) string { return s })},
+		"number":    {1, 1, toNumber},
+	}
+
+	// patterns caches the compiled regular expressions of matches.
+	patterns sync.Map
+)
+
+// text returns the text of a non NULL value.
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999")
+	}
+
+	return fmt.Sprint(value)
+}
+
+// stringFunc returns a function of a string, NULL giving NULL.
+func stringFunc(f func(string) string) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return f(text(args[0])), nil
+	}
+}
+
+// stringPredicate returns a predicate of two strings, NULL giving false.
+func stringPredicate(f func(string, string) bool) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil || args[1] == nil {
+			return false, nil
+		}
+		return f(text(args[0]), text(args[1])), nil
+	}
+}
+
+func length(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return float64(0), nil
+	}
+
+	return float64(utf8.RuneCountInString(text(args[0]))), nil
+}
+
+func matches(args []interface{}) (interface{}, error) {
+	if args[0] == nil || args[1] == nil {
+		return false, nil
+	}
+
+	pattern := text(args[1])
+	re, ok := patterns.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		re, _ = patterns.LoadOrStore(pattern, compiled)
+	}
+
+	return re.(*regexp.Regexp).MatchString(text(args[0])), nil
+}
+
+func replace(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	if args[1] == nil || args[2] == nil {
+		return args[0], nil
+	}
+
+	return strings.ReplaceAll(text(args[0]), text(args[1]), text(args[2])), nil
+}
+
+// coalesce returns the first non NULL argument.
+func coalesce(args []interface{}) (interface{}, error) {
+	for _, arg := range args {
+		if arg != nil {
+			return arg, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// when returns the second argument when the first one is true, the third one otherwise.
+func when(args []interface{}) (interface{}, error) {
+	condition, err := truthy(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if condition {
+		return args[1], nil
+	}
+
+	return args[2], nil
+}
+
+func toNumber(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	f, ok := number(args[0])
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", args[0])
+	}
+
+	return f, nil
+}
@@ -0,0 +1,177 @@
+// Package extension creates the extensions the dumped objects depend on before the structure, e.g. citext or
+// pg_trgm, so that the structure restores on a target where they were never created.
+package extension
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// Modes of handling the extensions the dumped objects depend on.
+const (
+	// Create creates the extensions the structure does not create before it.
+	Create Mode = "create"
+	// Report only logs the extensions the structure does not create, to be created on the target beforehand.
+	Report Mode = "report"
+	// Off leaves the structure as it is.
+	Off Mode = "off"
+)
+
+// createExtension matches the statements creating an extension, e.g. CREATE EXTENSION IF NOT EXISTS "uuid-ossp".
+var createExtension = regexp.MustCompile(`(?i)^\s*CREATE\s+EXTENSION\s+(?:IF\s+NOT\s+EXISTS\s+)?("(?:[^"]|"")*"|[\w$]+)`)
+
+type (
+	// Mode is the way the extensions the dumped objects depend on are handled.
+	Mode string
+
+	extensionReader struct {
+		reader.Reader
+		lister reader.ExtensionLister
+		mode   Mode
+	}
+)
+
+// ParseMode parses an extensions mode.
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(s)); mode {
+	case Create, Report, Off:
+		return mode, nil
+	}
+
+	return "", fmt.Errorf("unknown extensions mode %q, expected create, report or off", s)
+}
+
+// NewReader returns a reader whose structure creates the extensions its objects depend on, listed by connected.
+// The source is returned as it is when the mode is off or connected can not list its extensions.
+func NewReader(source reader.Reader, connected reader.Reader, mode Mode) reader.Reader {
+	lister, ok := connected.(reader.ExtensionLister)
+	if !ok || mode == Off {
+		return source
+	}
+
+	return &extensionReader{Reader: source, lister: lister, mode: mode}
+}
+
+// GetStructure returns the structure preceded by the extensions it does not create.
+func (r *extensionReader) GetStructure() (string, error) {
+	structure, err := r.Reader.GetStructure()
+	if err != nil {
+		return "", err
+	}
+
+	prerequisites, err := r.prerequisites(structure)
+	if err != nil {
+		return "", err
+	}
+
+	return prerequisites + structure, nil
+}
+
+// GetStructureSections returns the sections of the structure, the pre-data one preceded by the extensions the
+// structure does not create.
+func (r *extensionReader) GetStructureSections() (string, string, error) {
+	preData, postData, err := reader.GetStructureSections(r.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	prerequisites, err := r.prerequisites(preData + postData)
+	if err != nil {
+		return "", "", err
+	}
+
+	return prerequisites + preData, postData, nil
+}
+
+// prerequisites returns the statements creating the extensions the structure depends on without creating them,
+// empty when they are only reported.
+func (r *extensionReader) prerequisites(structure string) (string, error) {
+	extensions, err := r.lister.GetExtensions()
+	if errors.Is(err, reader.ErrExtensionsUnsupported) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not list the extensions: %w", err)
+	}
+
+	missing := Missing(structure, extensions)
+	if len(missing) == 0 {
+		return "", nil
+	}
+
+	if r.mode == Report {
+		for _, extension := range missing {
+			log.WithFields(log.Fields{"extension": extension.Name, "schema": extension.Schema}).
+				Warn("the structure depends on an extension it does not create, create it on the target before loading it")
+		}
+		return "", nil
+	}
+
+	var buf strings.Builder
+	for _, extension := range missing {
+		log.WithField("extension", extension.Name).Debug("creating the extension the structure depends on")
+		buf.WriteString(Statement(extension))
+		buf.WriteString("\n")
+	}
+
+	return buf.String(), nil
+}
+
+// Missing returns the extensions the structure does not create.
+func Missing(structure string, extensions []reader.Extension) []reader.Extension {
+	created := make(map[string]bool)
+	for _, statement := range reader.SplitStatements(structure) {
+		if m := createExtension.FindStringSubmatch(stripComments(statement)); m != nil {
+			created[unquote(m[1])] = true
+		}
+	}
+
+	var missing []reader.Extension
+	for _, extension := range extensions {
+		if !created[extension.Name] {
+			missing = append(missing, extension)
+		}
+	}
+
+	return missing
+}
+
+// Statement returns the statement creating the extension in its schema unless it exists.
+func Statement(extension reader.Extension) string {
+	stmt := "CREATE EXTENSION IF NOT EXISTS " + strconv.Quote(extension.Name)
+	if extension.Schema != "" {
+		stmt += " WITH SCHEMA " + strconv.Quote(extension.Schema)
+	}
+
+	return stmt + ";"
+}
+
+// stripComments returns the statement without its leading comment lines, pg_dump framing each object with them.
+func stripComments(statement string) string {
+	for {
+		statement = strings.TrimLeft(statement, " \t\r\n")
+		if !strings.HasPrefix(statement, "--") {
+			return statement
+		}
+		end := strings.IndexByte(statement, '\n')
+		if end < 0 {
+			return ""
+		}
+		statement = statement[end+1:]
+	}
+}
+
+func unquote(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		return strings.Replace(name[1:len(name)-1], `""`, `"`, -1)
+	}
+
+	return strings.ToLower(name)
+}
@@ -0,0 +1,81 @@
+package extension
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+const structure = "--\n-- Name: citext; Type: EXTENSION; Schema: -; Owner: -\n--\n\n" +
+	"CREATE EXTENSION IF NOT EXISTS citext WITH SCHEMA public;\n\n" +
+	"CREATE TABLE public.users (id uuid DEFAULT public.uuid_generate_v4(), email public.citext);\n"
+
+type mockReader struct {
+	reader.Reader
+	extensions []reader.Extension
+	err        error
+}
+
+func (m *mockReader) GetStructure() (string, error) {
+	return structure, nil
+}
+
+func (m *mockReader) GetExtensions() ([]reader.Extension, error) {
+	return m.extensions, m.err
+}
+
+func TestNewReader(t *testing.T) {
+	t.Parallel()
+
+	connected := &mockReader{extensions: []reader.Extension{
+		{Name: "citext", Schema: "public"},
+		{Name: "uuid-ossp", Schema: "public"},
+	}}
+
+	r := NewReader(connected, connected, Create)
+	created, err := r.GetStructure()
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\" WITH SCHEMA \"public\";\n"+structure, created)
+
+	preData, postData, err := reader.GetStructureSections(r)
+	require.NoError(t, err)
+	assert.Equal(t, created, preData)
+	assert.Empty(t, postData)
+
+	reported, err := NewReader(connected, connected, Report).GetStructure()
+	require.NoError(t, err)
+	assert.Equal(t, structure, reported)
+
+	assert.Same(t, connected, NewReader(connected, connected, Off))
+	assert.Same(t, connected, NewReader(connected, struct{ reader.Reader }{}, Create))
+
+	_, err = NewReader(connected, &mockReader{err: errors.New("permission denied")}, Create).GetStructure()
+	assert.EqualError(t, err, "could not list the extensions: permission denied")
+
+	unsupported, err := NewReader(connected, &mockReader{err: reader.ErrExtensionsUnsupported}, Create).GetStructure()
+	require.NoError(t, err)
+	assert.Equal(t, structure, unsupported)
+}
+
+func TestMissing(t *testing.T) {
+	t.Parallel()
+
+	extensions := []reader.Extension{{Name: "uuid-ossp"}, {Name: "pg_trgm"}, {Name: "postgis"}}
+	assert.Equal(t, []reader.Extension{{Name: "postgis"}}, Missing(
+		"CREATE EXTENSION \"uuid-ossp\";\n-- comment\ncreate extension if not exists PG_TRGM;", extensions))
+	assert.Equal(t, "CREATE EXTENSION IF NOT EXISTS \"pg_trgm\";", Statement(reader.Extension{Name: "pg_trgm"}))
+}
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	mode, err := ParseMode("Report")
+	require.NoError(t, err)
+	assert.Equal(t, Report, mode)
+	_, err = ParseMode("install")
+	assert.Error(t, err)
+}
@@ -0,0 +1,570 @@
+package filter
+
+import (
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/expr"
+)
+
+// unsupported are the SQL keywords of the conditions that can not be evaluated on the rows.
+var unsupported = map[string]bool{
+	"LIKE": true, "ILIKE": true, "REGEXP": true, "RLIKE": true, "SIMILAR": true, "EXISTS": true, "SELECT": true,
+	"CASE": true, "ANY": true, "ALL": true, "COLLATE": true, "INTERVAL": true, "ESCAPE": true, "DIV": true, "MOD": true,
+}
+
+type (
+	// Condition is the SQL condition of a Match filter evaluated on the rows read, instead of by the source.
+	Condition struct {
+		sql     string
+		expr    *expr.Expression
+		columns []string
+	}
+
+	// cond is a node of a parsed condition.
+	cond interface{}
+
+	and struct{ x, y cond }
+	or  struct{ x, y cond }
+	not struct{ x cond }
+	// comparison compares two values with a SQL operator.
+	comparison struct {
+		op   string
+		x, y value
+	}
+	// isNull is an IS NULL or IS NOT NULL predicate.
+	isNull struct {
+		x   value
+		not bool
+	}
+	// in is an IN predicate.
+	in struct {
+		x    value
+		list []value
+	}
+	// value is a value expression with the Go syntax of the expressions.
+	value struct {
+		expr string
+		null bool
+		// literal is true for the values not reading any column, which are never NULL unless null is set.
+		literal bool
+	}
+
+	parser struct {
+		tokens  []string
+		pos     int
+		columns []string
+	}
+)
+
+// Compile translates a SQL condition to an expression evaluated on the rows. It supports the comparisons, IS NULL,
+// IN, BETWEEN, AND, OR, NOT and arithmetic on columns, strings, numbers, TRUE, FALSE and NULL. The columns may be
+// qualified with their table, which is left out. NULL is handled like SQL does, a condition on NULL never matching.
+func Compile(condition string) (*Condition, error) {
+	tokens, err := tokenize(condition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %s", p.tokens[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+
+	e, err := expr.Compile(emit(root, false))
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+
+	return &Condition{sql: condition, expr: e, columns: p.columns}, nil
+}
+
+// String returns the expression the condition is evaluated with.
+func (c *Condition) String() string {
+	return c.expr.String()
+}
+
+// Columns returns the columns the condition reads, in their order of first appearance.
+func (c *Condition) Columns() []string {
+	return c.columns
+}
+
+// Match tells whether the row matches the condition.
+func (c *Condition) Match(row database.Row) (bool, error) {
+	return c.expr.EvalBool(row)
+}
+
+func (p *parser) parseOr() (cond, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = or{x, y}
+	}
+
+	return x, nil
+}
+
+func (p *parser) parseAnd() (cond, error) {
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		y, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		x = and{x, y}
+	}
+
+	return x, nil
+}
+
+func (p *parser) parseNot() (cond, error) {
+	if p.keyword("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return not{x}, nil
+	}
+
+	return p.parsePredicate()
+}
+
+// parsePredicate parses a comparison, a predicate or a value used as a condition.
+func (p *parser) parsePredicate() (cond, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	x, ok := left.(value)
+	if !ok {
+		return left, nil
+	}
+
+	switch tok := p.peek(); tok {
+	case "=", "==", "!=", "<>", "<", "<=", ">", ">=":
+		p.pos++
+		y, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return comparison{op: tok, x: x, y: y}, nil
+	}
+
+	if p.keyword("IS") {
+		negated := p.keyword("NOT")
+		if !p.keyword("NULL") {
+			return nil, fmt.Errorf("only IS NULL and IS NOT NULL are supported")
+		}
+		return isNull{x: x, not: negated}, nil
+	}
+
+	negated := p.keyword("NOT")
+	var c cond
+	switch {
+	case p.keyword("IN"):
+		c, err = p.parseIn(x)
+	case p.keyword("BETWEEN"):
+		c, err = p.parseBetween(x)
+	case negated:
+		return nil, fmt.Errorf("unexpected NOT")
+	default:
+		return x, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if negated {
+		return not{c}, nil
+	}
+
+	return c, nil
+}
+
+func (p *parser) parseIn(x value) (cond, error) {
+	if !p.symbol("(") {
+		return nil, fmt.Errorf("IN must be followed by a list of values")
+	}
+
+	c := in{x: x}
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		c.list = append(c.list, v)
+
+		if p.symbol(")") {
+			return c, nil
+		}
+		if !p.symbol(",") {
+			return nil, fmt.Errorf("unterminated IN list")
+		}
+	}
+}
+
+func (p *parser) parseBetween(x value) (cond, error) {
+	low, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keyword("AND") {
+		return nil, fmt.Errorf("BETWEEN must be followed by AND")
+	}
+	high, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return and{comparison{op: ">=", x: x, y: low}, comparison{op: "<=", x: x, y: high}}, nil
+}
+
+// parseValue parses an operand, which can not be a condition.
+func (p *parser) parseValue() (value, error) {
+	c, err := p.parseSum()
+	if err != nil {
+		return value{}, err
+	}
+	v, ok := c.(value)
+	if !ok {
+		return value{}, fmt.Errorf("a condition can not be used as a value")
+	}
+
+	return v, nil
+}
+
+func (p *parser) parseSum() (cond, error) {
+	return p.parseArithmetic(p.parseTerm, "+", "-")
+}
+
+func (p *parser) parseTerm() (cond, error) {
+	return p.parseArithmetic(p.parseUnary, "*", "/", "%")
+}
+
+// parseArithmetic parses the operands of left associative operators.
+func (p *parser) parseArithmetic(operand func() (cond, error), operators ...string) (cond, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := "", false
+		for _, operator := range operators {
+			if p.symbol(operator) {
+				op, ok = operator, true
+				break
+			}
+		}
+		if !ok {
+			return x, nil
+		}
+
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left, ok := x.(value)
+		right, ok2 := y.(value)
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("a condition can not be used as a value")
+		}
+		x = value{expr: left.expr + " " + op + " " + right.expr, null: left.null || right.null, literal: left.literal && right.literal}
+	}
+}
+
+func (p *parser) parseUnary() (cond, error) {
+	if p.symbol("-") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		v, ok := x.(value)
+		if !ok {
+			return nil, fmt.Errorf("a condition can not be used as a value")
+		}
+		return value{expr: "-" + v.expr, null: v.null, literal: v.literal}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (cond, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch {
+	case tok == "(":
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.symbol(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		if v, ok := c.(value); ok {
+			return value{expr: "(" + v.expr + ")", null: v.null, literal: v.literal}, nil
+		}
+		return c, nil
+	case tok[0] == '\'':
+		return value{expr: strconv.Quote(strings.ReplaceAll(tok[1:len(tok)-1], "''", "'")), literal: true}, nil
+	case tok[0] >= '0' && tok[0] <= '9' || tok[0] == '.':
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", tok)
+		}
+		return value{expr: strconv.FormatFloat(f, 'g', -1, 64), literal: true}, nil
+	case tok[0] == '"' || tok[0] == '`':
+		return p.parseColumn(strings.ReplaceAll(tok[1:len(tok)-1], tok[:1]+tok[:1], tok[:1]))
+	case isWord(tok):
+		switch upper := strings.ToUpper(tok); {
+		case upper == "NULL":
+			return value{expr: "nil", null: true, literal: true}, nil
+		case upper == "TRUE" || upper == "FALSE":
+			return value{expr: strings.ToLower(tok), literal: true}, nil
+		case unsupported[upper]:
+			return nil, fmt.Errorf("%s is not supported", upper)
+		case p.peek() == "(":
+			return nil, fmt.Errorf("function %s is not supported", tok)
+		}
+		return p.parseColumn(tok)
+	}
+
+	return nil, fmt.Errorf("unexpected %s", tok)
+}
+
+// parseColumn parses a column, the table it may be qualified with being left out.
+func (p *parser) parseColumn(name string) (cond, error) {
+	for p.symbol(".") {
+		if p.pos >= len(p.tokens) {
+			return nil, fmt.Errorf("unexpected end of condition")
+		}
+		tok := p.tokens[p.pos]
+		p.pos++
+
+		switch {
+		case tok[0] == '"' || tok[0] == '`':
+			name = strings.ReplaceAll(tok[1:len(tok)-1], tok[:1]+tok[:1], tok[:1])
+		case isWord(tok):
+			name = tok
+		default:
+			return nil, fmt.Errorf("unexpected %s", tok)
+		}
+	}
+	known := false
+	for _, column := range p.columns {
+		known = known || column == name
+	}
+	if !known {
+		p.columns = append(p.columns, name)
+	}
+
+	if token.IsIdentifier(name) && name != "row" {
+		return value{expr: "row." + name}, nil
+	}
+	return value{expr: "row[" + strconv.Quote(name) + "]"}, nil
+}
+
+// keyword consumes the next token if it is the keyword.
+func (p *parser) keyword(keyword string) bool {
+	if p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], keyword) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+// symbol consumes the next token if it is the symbol.
+func (p *parser) symbol(symbol string) bool {
+	if p.peek() == symbol {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+// emit returns the expression of a condition, which is true when the SQL condition is true. NOT is pushed down to
+// the comparisons, so that a negated condition on NULL does not match either.
+func emit(c cond, negated bool) string {
+	switch n := c.(type) {
+	case and:
+		if negated {
+			return "(" + emit(n.x, true) + " || " + emit(n.y, true) + ")"
+		}
+		return "(" + emit(n.x, false) + " && " + emit(n.y, false) + ")"
+	case or:
+		if negated {
+			return "(" + emit(n.x, true) + " && " + emit(n.y, true) + ")"
+		}
+		return "(" + emit(n.x, false) + " || " + emit(n.y, false) + ")"
+	case not:
+		return emit(n.x, !negated)
+	case comparison:
+		return compare(n.op, n.x, n.y, negated)
+	case isNull:
+		if n.not != negated {
+			return n.x.expr + " != nil"
+		}
+		return n.x.expr + " == nil"
+	case in:
+		parts := make([]string, 0, len(n.list))
+		for _, v := range n.list {
+			if v.null {
+				// x NOT IN (..., NULL) is never true
+				if negated {
+					return "false"
+				}
+				continue
+			}
+			if negated {
+				parts = append(parts, compare("!=", n.x, v, false))
+			} else {
+				parts = append(parts, compare("=", n.x, v, false))
+			}
+		}
+		if len(parts) == 0 {
+			return "false"
+		}
+		if negated {
+			return "(" + strings.Join(parts, " && ") + ")"
+		}
+		return "(" + strings.Join(parts, " || ") + ")"
+	case value:
+		if n.null {
+			return "false"
+		}
+		switch {
+		case n.literal && negated:
+			return "!" + n.expr
+		case n.literal:
+			return n.expr
+		case negated:
+			return "(" + n.expr + " != nil && !" + n.expr + ")"
+		}
+		return "(" + n.expr + " != nil && " + n.expr + ")"
+	}
+
+	return "false"
+}
+
+// compare returns the expression of a comparison, false when an operand is NULL.
+func compare(op string, x value, y value, negated bool) string {
+	if x.null || y.null {
+		return "false"
+	}
+
+	switch op {
+	case "=":
+		op = "=="
+	case "<>":
+		op = "!="
+	}
+	if negated {
+		op = map[string]string{"==": "!=", "!=": "==", "<": ">=", "<=": ">", ">": "<=", ">=": "<"}[op]
+	}
+
+	guards := make([]string, 0, 3)
+	for _, v := range []value{x, y} {
+		if !v.literal {
+			guards = append(guards, v.expr+" != nil")
+		}
+	}
+	comparison := x.expr + " " + op + " " + y.expr
+	if len(guards) == 0 {
+		return comparison
+	}
+
+	return "(" + strings.Join(append(guards, comparison), " && ") + ")"
+}
+
+// tokenize splits a condition into words, numbers, quoted strings and identifiers and symbols.
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for {
+				j := strings.IndexRune(s[end:], c)
+				if j < 0 {
+					return nil, fmt.Errorf("unterminated %c", c)
+				}
+				end += j + 1
+				// quotes are escaped by doubling them
+				if end < len(s) && rune(s[end]) == c {
+					end++
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, s[i:end])
+			i = end
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			end := i + 1
+			for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.' ||
+				(s[end] == 'e' || s[end] == 'E') ||
+				(s[end] == '+' || s[end] == '-') && (s[end-1] == 'e' || s[end-1] == 'E')) {
+				end++
+			}
+			tokens = append(tokens, s[i:end])
+			i = end
+		case c == '_' || c >= 0x80 || unicode.IsLetter(c):
+			end := i + 1
+			for end < len(s) && isWordByte(s[end]) {
+				end++
+			}
+			tokens = append(tokens, s[i:end])
+			i = end
+		case i+1 < len(s) && (s[i:i+2] == "<=" || s[i:i+2] == ">=" || s[i:i+2] == "<>" || s[i:i+2] == "!=" || s[i:i+2] == "=="):
+			tokens = append(tokens, s[i:i+2])
+			i += 2
+		case strings.ContainsRune("=<>+-*/%(),.", c):
+			tokens = append(tokens, s[i:i+1])
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %c", c)
+		}
+	}
+
+	return tokens, nil
+}
+
+func isWord(tok string) bool {
+	return tok[0] == '_' || tok[0] >= 0x80 || unicode.IsLetter(rune(tok[0]))
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '$' || b >= 0x80 || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
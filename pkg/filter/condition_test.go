@@ -0,0 +1,83 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		condition string
+		expr      string
+		columns   []string
+	}{
+		{"users.active = TRUE", `(row.active != nil && row.active == true)`, []string{"active"}},
+		{"state <> 'it''s'", `(row.state != nil && row.state != "it's")`, []string{"state"}},
+		{"`order`.`type` = 1 OR \"first name\" IS NOT NULL", `((row["type"] != nil && row["type"] == 1) || row["first name"] != nil)`, []string{"type", "first name"}},
+		{"NOT (a < 1 AND b IS NULL)", `((row.a != nil && row.a >= 1) || row.b != nil)`, []string{"a", "b"}},
+		{"id NOT IN (1, 2)", `((row.id != nil && row.id != 1) && (row.id != nil && row.id != 2))`, []string{"id"}},
+		{"id BETWEEN 10 AND 20.5 AND deleted", `(((row.id != nil && row.id >= 10) && (row.id != nil && row.id <= 20.5)) && (row.deleted != nil && row.deleted))`, []string{"id", "deleted"}},
+		{"price * 2 > -1 AND a = NULL", `((row.price * 2 != nil && row.price * 2 > -1) && false)`, []string{"price", "a"}},
+	}
+
+	for _, tt := range tests {
+		c, err := Compile(tt.condition)
+		require.NoError(t, err, tt.condition)
+		assert.Equal(t, tt.expr, c.String(), tt.condition)
+		assert.Equal(t, tt.columns, c.Columns(), tt.condition)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	t.Parallel()
+
+	for condition, err := range map[string]string{
+		"name LIKE 'a%'":            `invalid condition "name LIKE 'a%'": unexpected LIKE`,
+		"lower(name) = 'a'":         `invalid condition "lower(name) = 'a'": function lower is not supported`,
+		"id IN (SELECT id FROM t)":  `invalid condition "id IN (SELECT id FROM t)": SELECT is not supported`,
+		"state = 'paid":             `invalid condition "state = 'paid": unterminated '`,
+		"(a = 1":                    `invalid condition "(a = 1": missing )`,
+		"a IS TRUE":                 `invalid condition "a IS TRUE": only IS NULL and IS NOT NULL are supported`,
+		"created_at > NOW() - 1":    `invalid condition "created_at > NOW() - 1": function NOW is not supported`,
+		"a = 1 ; DELETE FROM users": `invalid condition "a = 1 ; DELETE FROM users": unexpected ;`,
+		"(a = 1) + 1 > 0":           `invalid condition "(a = 1) + 1 > 0": a condition can not be used as a value`,
+	} {
+		_, e := Compile(condition)
+		assert.EqualError(t, e, err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	columns := database.NewColumns([]string{"id", "state", "deleted_at"})
+	rows := []database.Row{
+		database.NewRow(columns, []interface{}{int64(1), []byte("paid"), nil}),
+		database.NewRow(columns, []interface{}{int64(2), []byte("open"), nil}),
+		database.NewRow(columns, []interface{}{int64(3), nil, []byte("2024-01-01 00:00:00")}),
+	}
+
+	tests := map[string][]bool{
+		"state = 'paid'":                          {true, false, false},
+		"NOT state = 'paid'":                      {false, true, false},
+		"state NOT IN ('open')":                   {true, false, false},
+		"deleted_at IS NULL AND id >= 2":          {false, true, false},
+		"id % 2 = 1 OR deleted_at > '2023-12-31'": {true, false, true},
+	}
+	for condition, expected := range tests {
+		c, err := Compile(condition)
+		require.NoError(t, err, condition)
+
+		for i, row := range rows {
+			match, err := c.Match(row)
+			require.NoError(t, err, condition)
+			assert.Equal(t, expected[i], match, "%s on row %d", condition, i+1)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/expr"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	// Mode is the way the filters the source rejects are handled.
+	Mode string
+
+	filterReader struct {
+		reader.Reader
+		// rules are the filters evaluated on the rows by table.
+		rules map[string]*rules
+	}
+
+	// rules are the filters of a table evaluated on the rows read.
+	rules struct {
+		// match is the Match filter the source rejected, evaluated instead of being sent to the source.
+		match *Condition
+		// expression is the Expression of the table filter.
+		expression *expr.Expression
+	}
+)
+
+// Modes of handling the filters the source rejects.
+const (
+	// Fail fails the run before anything is dumped.
+	Fail Mode = "fail"
+	// Client evaluates the filters on the rows read, reading the tables completely.
+	Client Mode = "client"
+)
+
+// ParseMode parses an invalid filters mode.
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(s)); mode {
+	case Fail, Client:
+		return mode, nil
+	case "":
+		return Fail, nil
+	}
+
+	return "", fmt.Errorf("unknown invalid filters mode %q, supported modes are fail and client", s)
+}
+
+// NewReader returns a reader evaluating the filter Expression of the tables on the rows read, as well as the Match
+// filters of the given conditions instead of sending them to the source. The filtered tables are read completely,
+// their limit and offset applying to the matching rows. Tables marked as Full are not filtered, and it fails on
+// invalid expressions.
+func NewReader(source reader.Reader, tables config.Tables, conditions map[string]*Condition) (reader.Reader, error) {
+	tableRules := make(map[string]*rules, len(conditions))
+	for table, condition := range conditions {
+		tableRules[table] = &rules{match: condition}
+	}
+	for _, table := range tables {
+		if table.Filter.Expression == "" || table.Full {
+			continue
+		}
+
+		e, err := expr.Compile(table.Filter.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("table %s filter: %w", table.Name, err)
+		}
+		if _, ok := tableRules[table.Name]; !ok {
+			tableRules[table.Name] = &rules{}
+		}
+		tableRules[table.Name].expression = e
+	}
+
+	if len(tableRules) == 0 {
+		return source, nil
+	}
+
+	return &filterReader{Reader: source, rules: tableRules}, nil
+}
+
+// ReadTable reads the rows of the table matching its filters.
+func (r *filterReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	tableRules, ok := r.rules[tableName]
+	if !ok {
+		return r.Reader.ReadTable(tableName, rowChan, opts)
+	}
+
+	// the rejected filter only replaces the configured one, e.g. not the one a data subject is read with
+	match := tableRules.match
+	if match != nil && opts.Match != match.sql {
+		match = nil
+	}
+	if match == nil && tableRules.expression == nil {
+		return r.Reader.ReadTable(tableName, rowChan, opts)
+	}
+	defer close(rowChan)
+
+	if match != nil {
+		opts.Match = ""
+	}
+	limit, offset := opts.Limit, opts.Offset
+	opts.Limit, opts.Offset = 0, 0
+
+	rawChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.Reader.ReadTable(tableName, rawChan, opts)
+	}()
+
+	var (
+		matched uint64
+		evalErr error
+	)
+	for row := range rawChan {
+		if evalErr != nil {
+			continue
+		}
+
+		keep, err := tableRules.keep(row, match)
+		if err != nil {
+			evalErr = err
+			continue
+		}
+		if !keep {
+			continue
+		}
+
+		matched++
+		if matched > offset && (limit == 0 || matched <= offset+limit) {
+			rowChan <- row
+		}
+	}
+
+	if err := <-errChan; err != nil {
+		return err
+	}
+	if evalErr != nil {
+		return fmt.Errorf("filter: %w", evalErr)
+	}
+
+	return nil
+}
+
+// keep tells whether the row matches the rejected Match filter, if any, and the filter expression of its table.
+func (r *rules) keep(row database.Row, match *Condition) (bool, error) {
+	if match != nil {
+		ok, err := match.Match(row)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	if r.expression != nil {
+		return r.expression.EvalBool(row)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,127 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	condition, err := Compile("orders.state = 'paid'")
+	require.NoError(t, err)
+
+	source := &mockReader{rows: [][]interface{}{
+		{int64(1), []byte("paid")},
+		{int64(2), []byte("open")},
+		{int64(3), []byte("paid")},
+		{int64(4), []byte("paid")},
+		{int64(5), []byte("paid")},
+	}}
+	r, err := NewReader(source, nil, map[string]*Condition{"orders": condition})
+	require.NoError(t, err)
+
+	rows, err := readAll(r, "orders", reader.ReadTableOpt{Match: "orders.state = 'paid'", Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	// the limit and offset apply to the matching rows
+	assert.Equal(t, int64(3), rows[0].Get("id"))
+	assert.Equal(t, int64(4), rows[1].Get("id"))
+	assert.Equal(t, reader.ReadTableOpt{}, source.opts)
+
+	// another filter, e.g. the one of a data subject, is read by the source
+	_, err = readAll(r, "orders", reader.ReadTableOpt{Match: "orders.id = 2"})
+	require.NoError(t, err)
+	assert.Equal(t, "orders.id = 2", source.opts.Match)
+
+	condition, err = Compile("state > 1")
+	require.NoError(t, err)
+	r, err = NewReader(source, nil, map[string]*Condition{"orders": condition})
+	require.NoError(t, err)
+	_, err = readAll(r, "orders", reader.ReadTableOpt{Match: "state > 1"})
+	assert.EqualError(t, err, `filter: could not evaluate "(row.state != nil && row.state > 1)": can not compare 1 with paid`)
+
+	r, err = NewReader(source, config.Tables{{Name: "orders", Filter: config.Filter{Expression: "row.id < 3"}, Full: true}}, nil)
+	require.NoError(t, err)
+	assert.Same(t, source, r)
+}
+
+func TestReadTableExpression(t *testing.T) {
+	t.Parallel()
+
+	condition, err := Compile("state = 'paid'")
+	require.NoError(t, err)
+
+	source := &mockReader{rows: [][]interface{}{
+		{int64(1), []byte("paid")},
+		{int64(2), []byte("open")},
+		{int64(3), []byte("paid")},
+		{int64(4), []byte("paid")},
+	}}
+	tables := config.Tables{{Name: "orders", Filter: config.Filter{Expression: "row.id >= 2"}}}
+
+	r, err := NewReader(source, tables, nil)
+	require.NoError(t, err)
+	rows, err := readAll(r, "orders", reader.ReadTableOpt{Match: "id > 0", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, int64(2), rows[0].Get("id"))
+	assert.Equal(t, int64(3), rows[1].Get("id"))
+	// the Match filter is still read by the source
+	assert.Equal(t, reader.ReadTableOpt{Match: "id > 0"}, source.opts)
+
+	r, err = NewReader(source, tables, map[string]*Condition{"orders": condition})
+	require.NoError(t, err)
+	rows, err = readAll(r, "orders", reader.ReadTableOpt{Match: "state = 'paid'"})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, int64(3), rows[0].Get("id"))
+	assert.Equal(t, int64(4), rows[1].Get("id"))
+
+	_, err = NewReader(source, config.Tables{{Name: "orders", Filter: config.Filter{Expression: "row.id <"}}}, nil)
+	assert.Error(t, err)
+}
+
+func readAll(r reader.Reader, table string, opts reader.ReadTableOpt) ([]database.Row, error) {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable(table, rowChan, opts)
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+
+	return rows, <-errChan
+}
+
+// mockReader publishes the rows of an orders table.
+type mockReader struct {
+	rows [][]interface{}
+	opts reader.ReadTableOpt
+}
+
+func (m *mockReader) GetTables() ([]string, error)        { return []string{"orders"}, nil }
+func (m *mockReader) GetStructure() (string, error)       { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) { return []string{"id", "state"}, nil }
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return ""
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	m.opts = opts
+	columns := database.NewColumns([]string{"id", "state"})
+	for _, values := range m.rows {
+		rowChan <- database.NewRow(columns, values)
+	}
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
@@ -0,0 +1,230 @@
+// Package foreign applies the policy of the foreign tables, e.g. the tables of a postgres foreign data wrapper or
+// the FEDERATED tables of MySQL, whose rows are read from a remote system rather than the source.
+package foreign
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// Policies of dumping the foreign tables.
+const (
+	// Skip leaves the foreign tables out of the structure and the data.
+	Skip Policy = "skip"
+	// Structure dumps the structure of the foreign tables as in the source, without reading their rows.
+	Structure Policy = "structure"
+	// Read reads the rows of the foreign tables through the remote system and dumps them into local tables.
+	Read Policy = "read"
+)
+
+// ident matches an identifier, quoted or not.
+const ident = "(?:\"(?:[^\"]|\"\")*\"|`(?:[^`]|``)*`|[\\w$]+)"
+
+var (
+	// framing matches the comment lines framing a statement, e.g. the object headers of pg_dump.
+	framing = regexp.MustCompile(`^(?:\s*--[^\n]*\n)*`)
+	// tableStatement matches the statements about a table, after the comments framing them, the name of the table
+	// being the last identifier of the first matching group.
+	tableStatement = regexp.MustCompile(`(?is)^(?:\s*--[^\n]*\n)*\s*(?:` +
+		`(?:CREATE|ALTER)\s+(?:FOREIGN\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(?:ONLY\s+)?((?:` + ident + `\.)?` + ident + `)` +
+		`|COMMENT\s+ON\s+(?:FOREIGN\s+)?TABLE\s+((?:` + ident + `\.)?` + ident + `)` +
+		`|COMMENT\s+ON\s+COLUMN\s+((?:` + ident + `\.)?` + ident + `)\.` + ident + `\s+IS` +
+		`|(?:GRANT|REVOKE)\s.*?\bON\s+(?:FOREIGN\s+)?TABLE\s+((?:` + ident + `\.)?` + ident + `))`)
+	// lastIdent matches the last identifier of a qualified name.
+	lastIdent = regexp.MustCompile(ident + `$`)
+	// foreignTable matches the FOREIGN keyword of the postgres statements about a foreign table.
+	foreignTable = regexp.MustCompile(`(?i)\bFOREIGN\s+TABLE\b`)
+	// server matches the server and the options of a postgres foreign table, following its columns.
+	server = regexp.MustCompile(`(?is)\)\s*SERVER\s+` + ident + `(?:\s+OPTIONS\s*\((?:[^()']|'(?:[^']|'')*')*\))?\s*$`)
+	// options matches the options of a postgres foreign table or column.
+	options = regexp.MustCompile(`(?i)\bOPTIONS\s*\(`)
+	// federated matches the FEDERATED engine and the remote connection of a MySQL table.
+	federated = regexp.MustCompile(`(?i)\s*\bENGINE\s*=\s*FEDERATED\b|\s*\bCONNECTION\s*=\s*'(?:[^'\\]|\\.|'')*'`)
+)
+
+type (
+	// Policy is the way the foreign tables are dumped.
+	Policy string
+
+	foreignReader struct {
+		reader.Reader
+		policy Policy
+		tables map[string]bool
+	}
+)
+
+// ParsePolicy parses a foreign tables policy.
+func ParsePolicy(s string) (Policy, error) {
+	switch policy := Policy(strings.ToLower(s)); policy {
+	case Skip, Structure, Read:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("unknown foreign tables policy %q, expected skip, structure or read", s)
+}
+
+// NewReader returns a reader dumping the foreign tables listed by connected according to the policy. The source is
+// returned as it is when connected can not list its foreign tables or has none.
+func NewReader(source reader.Reader, connected reader.Reader, policy Policy) (reader.Reader, error) {
+	lister, ok := connected.(reader.ForeignTableLister)
+	if !ok {
+		return source, nil
+	}
+
+	names, err := lister.GetForeignTables()
+	if errors.Is(err, reader.ErrForeignTablesUnsupported) {
+		return source, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not list the foreign tables: %w", err)
+	}
+	if len(names) == 0 {
+		return source, nil
+	}
+
+	tables := make(map[string]bool, len(names))
+	for _, name := range names {
+		tables[name] = true
+		logger := log.WithFields(log.Fields{"table": name, "policy": policy})
+		if policy == Read {
+			logger.Warn("the rows of the foreign table are read from its remote system")
+		} else {
+			logger.Info("the rows of the foreign table are not dumped")
+		}
+	}
+
+	return &foreignReader{Reader: source, policy: policy, tables: tables}, nil
+}
+
+// GetTables returns the tables of the source, the foreign tables being read only with the read policy.
+func (r *foreignReader) GetTables() ([]string, error) {
+	tables, err := r.Reader.GetTables()
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make(map[string]bool, len(tables))
+	kept := make([]string, 0, len(tables))
+	for _, table := range tables {
+		listed[table] = true
+		if r.policy == Read || !r.tables[table] {
+			kept = append(kept, table)
+		}
+	}
+	// the postgres foreign tables are not listed with the base tables
+	if r.policy == Read {
+		for _, table := range sortedNames(r.tables) {
+			if !listed[table] {
+				kept = append(kept, table)
+			}
+		}
+	}
+
+	return kept, nil
+}
+
+// GetStructure returns the structure rewritten according to the policy.
+func (r *foreignReader) GetStructure() (string, error) {
+	structure, err := r.Reader.GetStructure()
+	if err != nil {
+		return "", err
+	}
+
+	return r.rewrite(structure), nil
+}
+
+// GetStructureSections returns the sections of the structure rewritten according to the policy.
+func (r *foreignReader) GetStructureSections() (string, string, error) {
+	preData, postData, err := reader.GetStructureSections(r.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return r.rewrite(preData), r.rewrite(postData), nil
+}
+
+// rewrite leaves the statements about the foreign tables out of the structure with the skip policy and turns them
+// into statements about local tables with the read policy, so that the rows read are not written to the remote
+// system through the target.
+func (r *foreignReader) rewrite(structure string) string {
+	if r.policy == Structure {
+		return structure
+	}
+
+	statements := reader.SplitStatements(structure)
+	kept := make([]string, 0, len(statements))
+	for _, statement := range statements {
+		if !r.tables[Table(statement)] {
+			kept = append(kept, statement)
+			continue
+		}
+		if r.policy == Skip {
+			continue
+		}
+
+		local, ok := Localize(statement)
+		if ok {
+			kept = append(kept, local)
+		}
+	}
+
+	return strings.Join(kept, ";")
+}
+
+// Table returns the unquoted name of the table a CREATE TABLE, ALTER TABLE, COMMENT ON or GRANT statement is about,
+// empty for the other statements.
+func Table(statement string) string {
+	m := tableStatement.FindStringSubmatch(statement)
+	if m == nil {
+		return ""
+	}
+
+	for _, name := range m[1:] {
+		if name != "" {
+			return unquote(lastIdent.FindString(name))
+		}
+	}
+
+	return ""
+}
+
+// Localize returns the statement about a foreign table turned into a statement about a local table, false when the
+// statement only sets the options of the foreign table or its columns and is left out.
+func Localize(statement string) (string, bool) {
+	if words := reader.Keywords(statement); len(words) > 0 && words[0] == "ALTER" && options.MatchString(statement) {
+		return "", false
+	}
+	start := len(framing.FindString(statement))
+	if loc := foreignTable.FindStringIndex(statement[start:]); loc != nil {
+		statement = statement[:start+loc[0]] + "TABLE" + statement[start+loc[1]:]
+		statement = server.ReplaceAllString(statement, ")")
+	}
+
+	return federated.ReplaceAllString(statement, ""), true
+}
+
+func sortedNames(names map[string]bool) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+func unquote(name string) string {
+	if len(name) >= 2 && (name[0] == '"' || name[0] == '`') && name[len(name)-1] == name[0] {
+		quote := name[:1]
+		return strings.Replace(name[1:len(name)-1], quote+quote, quote, -1)
+	}
+
+	return name
+}
@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/stats"
+)
+
+type generator struct {
+	reader.Reader
+	// rows is the amount of rows generated for each table.
+	rows uint64
+	// sample is the amount of source rows the column statistics are sampled from, 0 to not sample them.
+	sample uint64
+}
+
+// NewReader returns a reader generating rows from the source schema instead of reading the table data.
+// Values are generated from the column types when the source knows them (see reader.ColumnTyper),
+// integer id columns are numbered from 1 so that foreign keys reference existing rows.
+// When sample is set, the generated columns follow the null ratio, cardinality and range of that amount
+// of source rows, only their statistics are kept.
+func NewReader(source reader.Reader, rows uint64, sample uint64) reader.Reader {
+	return &generator{Reader: source, rows: rows, sample: sample}
+}
+
+// ReadTable publishes the generated rows, the read options are ignored.
+func (g *generator) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	names, err := g.Reader.GetColumns(tableName)
+	if err != nil {
+		return fmt.Errorf("generator: could not get columns: %w", err)
+	}
+
+	types := g.columnTypes(tableName)
+	sampled := g.sampleStats(tableName)
+	values := make([]valueFunc, len(names))
+	for i, name := range names {
+		values[i] = withStats(newValueFunc(name, types[name], sampled[name]), sampled[name])
+	}
+
+	columns := database.NewColumns(names)
+	for n := uint64(1); n <= g.rows; n++ {
+		row := make([]interface{}, len(names))
+		for i, value := range values {
+			row[i] = value(n)
+		}
+		rowChan <- database.NewRow(columns, row)
+	}
+
+	return nil
+}
+
+// columnTypes returns the column types of a table, empty if the source does not know them.
+func (g *generator) columnTypes(tableName string) map[string]string {
+	logger := log.WithField("table", tableName)
+
+	types, err := g.GetColumnTypes(tableName)
+	if errors.Is(err, reader.ErrColumnTypesUnsupported) {
+		logger.Debug("the reader does not know the column types, generating text values")
+		return nil
+	}
+	if err != nil {
+		logger.WithError(err).Warn("could not get column types, generating text values")
+		return nil
+	}
+
+	return types
+}
+
+// sampleStats returns the statistics of the source table columns, empty if they are not sampled.
+func (g *generator) sampleStats(tableName string) stats.Table {
+	if g.sample == 0 {
+		return nil
+	}
+
+	sampled, err := stats.Sample(g.Reader, tableName, g.sample)
+	if err != nil {
+		log.WithError(err).WithField("table", tableName).Warn("could not sample column statistics, generating default values")
+		return nil
+	}
+
+	return sampled
+}
+
+// GetColumnTypes returns the column types known by the source, so generators can be stacked.
+func (g *generator) GetColumnTypes(tableName string) (map[string]string, error) {
+	typer, ok := g.Reader.(reader.ColumnTyper)
+	if !ok {
+		return nil, reader.ErrColumnTypesUnsupported
+	}
+
+	return typer.GetColumnTypes(tableName)
+}
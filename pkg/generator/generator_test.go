@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	rows := readAll(t, NewReader(&mockReader{}, 3, 0), "users")
+	require.Len(t, rows, 3)
+
+	for i, row := range rows {
+		assert.Equal(t, int64(i+1), row.Get("id"))
+		assert.IsType(t, int64(0), row.Get("age"))
+		assert.LessOrEqual(t, len(row.Get("name").(string)), 4)
+		assert.IsType(t, true, row.Get("active"))
+		assert.Contains(t, []string{"new", "it's"}, row.Get("status"))
+		assert.IsType(t, time.Time{}, row.Get("created_at"))
+		assert.Len(t, row.Get("token").(string), 36)
+		assert.Regexp(t, `^\d+\.\d{2}$`, row.Get("amount"))
+	}
+}
+
+func TestReadTableWithoutTypes(t *testing.T) {
+	t.Parallel()
+
+	rows := readAll(t, NewReader(&mockUntypedReader{}, 2, 0), "users")
+	require.Len(t, rows, 2)
+	assert.Equal(t, int64(2), rows[1].Get("user_id"))
+	assert.IsType(t, "", rows[1].Get("name"))
+}
+
+func TestReadTableWithStats(t *testing.T) {
+	t.Parallel()
+
+	source := &mockSampledReader{}
+	rows := readAll(t, NewReader(source, 200, 10), "users")
+	require.Len(t, rows, 200)
+
+	countries := make(map[interface{}]bool)
+	for _, row := range rows {
+		assert.Nil(t, row.Get("deleted_at"))
+		age := row.Get("age").(int64)
+		assert.True(t, age >= 18 && age <= 30, age)
+		countries[row.Get("country")] = true
+	}
+	// the pool holds the two sampled distinct values, fake words may collide
+	assert.NotEmpty(t, countries)
+	assert.LessOrEqual(t, len(countries), 2)
+}
+
+func readAll(t *testing.T, r reader.Reader, table string) []database.Row {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable(table, rowChan, reader.ReadTableOpt{})
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+	require.NoError(t, <-errChan)
+
+	return rows
+}
+
+type mockReader struct {
+	mockUntypedReader
+}
+
+func (m *mockReader) GetColumns(string) ([]string, error) {
+	return []string{"id", "age", "name", "active", "status", "created_at", "token", "amount"}, nil
+}
+
+func (m *mockReader) GetColumnTypes(string) (map[string]string, error) {
+	return map[string]string{
+		"id":         "int unsigned",
+		"age":        "smallint",
+		"name":       "varchar(4)",
+		"active":     "tinyint(1)",
+		"status":     "enum('new','it''s')",
+		"created_at": "timestamp",
+		"token":      "uuid",
+		"amount":     "decimal(10,2)",
+	}, nil
+}
+
+// mockUntypedReader does not know the column types and fails if the table data is read.
+type mockUntypedReader struct{}
+
+func (m *mockUntypedReader) GetTables() ([]string, error)  { return []string{"users"}, nil }
+func (m *mockUntypedReader) GetStructure() (string, error) { return "", nil }
+func (m *mockUntypedReader) GetColumns(string) ([]string, error) {
+	return []string{"user_id", "name"}, nil
+}
+func (m *mockUntypedReader) FormatColumn(tableName string, columnName string) string { return "" }
+func (m *mockUntypedReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	close(rowChan)
+	return errors.New("the table data must not be read")
+}
+func (m *mockUntypedReader) Close() error { return nil }
+
+// mockSampledReader has source rows to sample statistics from.
+type mockSampledReader struct {
+	mockUntypedReader
+}
+
+func (m *mockSampledReader) GetColumns(string) ([]string, error) {
+	return []string{"age", "country", "deleted_at"}, nil
+}
+
+func (m *mockSampledReader) GetColumnTypes(string) (map[string]string, error) {
+	return map[string]string{"age": "int", "country": "varchar(2)", "deleted_at": "datetime"}, nil
+}
+
+func (m *mockSampledReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	columns := database.NewColumns([]string{"age", "country", "deleted_at"})
+	rowChan <- database.NewRow(columns, []interface{}{int64(18), []byte("de"), nil})
+	rowChan <- database.NewRow(columns, []interface{}{int64(30), []byte("fr"), nil})
+	rowChan <- database.NewRow(columns, []interface{}{int64(25), []byte("de"), nil})
+	return nil
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	mrand "math/rand"
+	"time"
+
+	"github.com/hellofresh/klepto/pkg/stats"
+)
+
+// pool holds as many values as the sampled column has distinct values, the most frequent sampled
+// values keep their frequency and the others are picked uniformly.
+type pool struct {
+	value   valueFunc
+	values  []interface{}
+	set     []bool
+	weights []float64
+}
+
+// withStats makes a column generator follow the sampled null ratio and cardinality of the column.
+func withStats(value valueFunc, column *stats.Column) valueFunc {
+	if column == nil || column.Rows == 0 {
+		return value
+	}
+
+	if !column.Unique() {
+		p := &pool{
+			value:  value,
+			values: make([]interface{}, column.Distinct),
+			set:    make([]bool, column.Distinct),
+		}
+		nonNull := float64(column.Rows - column.Nulls)
+		for _, top := range column.Top {
+			p.weights = append(p.weights, float64(top.Count)/nonNull)
+		}
+		value = p.get
+	}
+
+	ratio := column.NullRatio()
+	if ratio == 0 {
+		return value
+	}
+
+	return func(n uint64) interface{} {
+		if mrand.Float64() < ratio {
+			return nil
+		}
+		return value(n)
+	}
+}
+
+func (p *pool) get(n uint64) interface{} {
+	i := p.index()
+	if !p.set[i] {
+		p.values[i] = p.value(n)
+		p.set[i] = true
+	}
+
+	return p.values[i]
+}
+
+func (p *pool) index() int {
+	r := mrand.Float64()
+	for i, w := range p.weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+
+	rest := len(p.values) - len(p.weights)
+	if rest <= 0 {
+		return len(p.values) - 1
+	}
+
+	return len(p.weights) + mrand.Intn(rest)
+}
+
+// numberRange returns the sampled range of a numeric column, ok is false when it was not sampled.
+func numberRange(column *stats.Column) (min, max float64, ok bool) {
+	if column == nil || !column.Numeric {
+		return 0, 0, false
+	}
+
+	return column.Min, column.Max, true
+}
+
+// timeRange returns the sampled range of a time column, from the generation epoch to now when it was not sampled.
+func timeRange(column *stats.Column) (from, to time.Time) {
+	if column == nil || !column.Temporal {
+		return epoch, time.Now()
+	}
+
+	return column.MinTime, column.MaxTime
+}
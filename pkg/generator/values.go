@@ -0,0 +1,196 @@
+package generator
+
+import (
+	"fmt"
+	mrand "math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icrowley/fake"
+
+	"github.com/hellofresh/klepto/pkg/stats"
+)
+
+// valueFunc returns the value of a column for the n-th generated row.
+type valueFunc func(n uint64) interface{}
+
+var (
+	typeArgs  = regexp.MustCompile(`\(([^)]*)\)`)
+	enumValue = regexp.MustCompile(`'((?:[^']|'')*)'`)
+
+	// epoch is the start of the period generated dates fall in.
+	epoch = time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// newValueFunc returns the generator of a column given its SQL type, empty when unknown.
+// Numbers and times are generated within the sampled range of the column when it is known.
+func newValueFunc(column string, sqlType string, sampled *stats.Column) valueFunc {
+	sqlType = strings.ToLower(strings.TrimSpace(sqlType))
+	base := sqlType
+	if i := strings.IndexAny(base, "( "); i >= 0 {
+		base = base[:i]
+	}
+
+	switch base {
+	case "":
+		if isIDColumn(column) {
+			return sequence
+		}
+		return text(0)
+
+	case "tinyint", "bit":
+		if strings.HasPrefix(sqlType, base+"(1)") {
+			return boolean
+		}
+		return integer(sqlType, column, sampled)
+
+	case "smallint", "mediumint", "int", "integer", "bigint", "int2", "int4", "int8",
+		"serial", "smallserial", "bigserial", "serial2", "serial4", "serial8":
+		return integer(sqlType, column, sampled)
+
+	case "decimal", "numeric", "float", "double", "real", "float4", "float8", "money", "dec", "fixed":
+		return decimal(sampled)
+
+	case "bool", "boolean":
+		return boolean
+
+	case "date":
+		return func(uint64) interface{} { return randomTime(sampled).Format("2006-01-02") }
+
+	case "datetime", "timestamp", "timestamptz":
+		return func(uint64) interface{} { return randomTime(sampled) }
+
+	case "time", "timetz":
+		return func(uint64) interface{} { return randomTime(sampled).Format("15:04:05") }
+
+	case "year":
+		if min, max, ok := numberRange(sampled); ok {
+			return func(uint64) interface{} { return randomInt(min, max) }
+		}
+		return func(uint64) interface{} { return int64(randomTime(sampled).Year()) }
+
+	case "uuid":
+		return func(uint64) interface{} { return newUUID() }
+
+	case "json", "jsonb":
+		return func(uint64) interface{} { return "{}" }
+
+	case "binary", "varbinary", "blob", "tinyblob", "mediumblob", "longblob", "bytea":
+		return func(uint64) interface{} {
+			b := make([]byte, 16)
+			mrand.Read(b)
+			return b
+		}
+
+	case "enum", "set":
+		if values := enumValues(sqlType); len(values) > 0 {
+			return func(uint64) interface{} { return values[mrand.Intn(len(values))] }
+		}
+	}
+
+	return text(typeLength(sqlType))
+}
+
+func sequence(n uint64) interface{} {
+	return int64(n)
+}
+
+func integer(sqlType string, column string, sampled *stats.Column) valueFunc {
+	if isIDColumn(column) || strings.Contains(sqlType, "serial") {
+		return sequence
+	}
+
+	if min, max, ok := numberRange(sampled); ok {
+		return func(uint64) interface{} { return randomInt(min, max) }
+	}
+
+	return func(uint64) interface{} { return int64(mrand.Intn(1000)) }
+}
+
+// randomInt returns an integer between min and max included.
+func randomInt(min, max float64) int64 {
+	span := int64(max) - int64(min) + 1
+	if span <= 0 {
+		return int64(min)
+	}
+
+	return int64(min) + mrand.Int63n(span)
+}
+
+func decimal(sampled *stats.Column) valueFunc {
+	min, max, ok := numberRange(sampled)
+	if !ok {
+		min, max = 0, 1000
+	}
+
+	return func(uint64) interface{} {
+		return strconv.FormatFloat(min+mrand.Float64()*(max-min), 'f', 2, 64)
+	}
+}
+
+func boolean(uint64) interface{} {
+	return mrand.Intn(2) == 1
+}
+
+// text returns words up to the given length, 0 meaning no limit.
+func text(length int) valueFunc {
+	return func(uint64) interface{} {
+		value := fake.Word()
+		if length > 0 && len(value) > length {
+			value = value[:length]
+		}
+		return value
+	}
+}
+
+func randomTime(sampled *stats.Column) time.Time {
+	from, to := timeRange(sampled)
+	span := to.Sub(from)
+	if span <= 0 {
+		return from
+	}
+
+	return from.Add(time.Duration(mrand.Int63n(int64(span)))).Truncate(time.Second)
+}
+
+func newUUID() string {
+	b := make([]byte, 16)
+	mrand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// isIDColumn checks if a column holds a numeric identifier, by name.
+func isIDColumn(column string) bool {
+	column = strings.ToLower(column)
+	return column == "id" || strings.HasSuffix(column, "_id")
+}
+
+// typeLength returns the length of a type such as varchar(255), 0 if it has none.
+func typeLength(sqlType string) int {
+	m := typeArgs.FindStringSubmatch(sqlType)
+	if m == nil {
+		return 0
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(m[1]))
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+// enumValues returns the values of an enum('a','b') type.
+func enumValues(sqlType string) []string {
+	var values []string
+	for _, m := range enumValue.FindAllStringSubmatch(sqlType, -1) {
+		values = append(values, strings.ReplaceAll(m[1], "''", "'"))
+	}
+
+	return values
+}
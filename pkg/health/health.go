@@ -0,0 +1,208 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	// Monitor is a reader keeping track of the progress of a run, served as an HTTP health endpoint.
+	Monitor struct {
+		reader.Reader
+		// stallAfter is the duration without progress after which the run is unhealthy, 0 to never be.
+		stallAfter time.Duration
+		now        func() time.Time
+
+		mu           sync.Mutex
+		started      time.Time
+		lastProgress time.Time
+		rows         uint64
+		reading      map[string]uint64
+		// tableStarts are the times the tables being read were started at.
+		tableStarts map[string]time.Time
+	}
+
+	// Reporter reports the progress of a run.
+	Reporter interface {
+		Status() Status
+	}
+
+	// Group is the progress of several runs, e.g. of the profiles of a steal, unhealthy when one of them is.
+	Group struct {
+		mu   sync.Mutex
+		runs map[string]Reporter
+	}
+
+	// Status is the progress of a run.
+	Status struct {
+		// Healthy is false when no progress was made for longer than the stall duration.
+		Healthy bool `json:"healthy"`
+		// Tables are the tables being read with the amount of rows read so far.
+		Tables map[string]uint64 `json:"tables"`
+		// Current is the name of the table being read for the longest time.
+		Current string `json:"current_table"`
+		// Rows is the amount of rows read since the start.
+		Rows uint64 `json:"rows"`
+		// RowsPerSecond is the average amount of rows read per second since the start.
+		RowsPerSecond float64 `json:"rows_per_second"`
+		// LastProgress is the time a row was last read or a table last started or completed.
+		LastProgress time.Time `json:"last_progress"`
+	}
+)
+
+// NewReader returns a monitor of the rows read from the source, the run being unhealthy when
+// no progress is made for longer than stallAfter.
+func NewReader(source reader.Reader, stallAfter time.Duration) *Monitor {
+	return newMonitor(source, stallAfter, time.Now)
+}
+
+func newMonitor(source reader.Reader, stallAfter time.Duration, now func() time.Time) *Monitor {
+	started := now()
+	return &Monitor{
+		Reader:       source,
+		stallAfter:   stallAfter,
+		now:          now,
+		started:      started,
+		lastProgress: started,
+		reading:      make(map[string]uint64),
+		tableStarts:  make(map[string]time.Time),
+	}
+}
+
+// ReadTable reads the table, counting its rows.
+func (m *Monitor) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	m.progress(func() {
+		m.reading[tableName] = 0
+		m.tableStarts[tableName] = m.now()
+	})
+	defer m.progress(func() {
+		delete(m.reading, tableName)
+		delete(m.tableStarts, tableName)
+	})
+	defer close(rowChan)
+
+	sourceChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- m.Reader.ReadTable(tableName, sourceChan, opts)
+	}()
+
+	for row := range sourceChan {
+		rowChan <- row
+		m.progress(func() {
+			m.reading[tableName]++
+			m.rows++
+		})
+	}
+
+	return <-errChan
+}
+
+// Status returns the current progress of the run.
+func (m *Monitor) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	status := Status{
+		Healthy:      m.stallAfter == 0 || now.Sub(m.lastProgress) <= m.stallAfter,
+		Tables:       make(map[string]uint64, len(m.reading)),
+		Rows:         m.rows,
+		LastProgress: m.lastProgress,
+	}
+	if elapsed := now.Sub(m.started).Seconds(); elapsed > 0 {
+		status.RowsPerSecond = float64(m.rows) / elapsed
+	}
+
+	for table, rows := range m.reading {
+		status.Tables[table] = rows
+
+		current, ok := m.tableStarts[status.Current]
+		if started := m.tableStarts[table]; !ok || started.Before(current) || (started.Equal(current) && table < status.Current) {
+			status.Current = table
+		}
+	}
+
+	return status
+}
+
+// ServeHTTP writes the status as JSON, with a 503 status code when the run is not healthy.
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, m.Status())
+}
+
+// NewGroup returns an empty group of runs.
+func NewGroup() *Group {
+	return &Group{runs: make(map[string]Reporter)}
+}
+
+// Add adds the progress of a named run to the group.
+func (g *Group) Add(name string, run Reporter) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.runs[name] = run
+}
+
+// Status returns the progress of the runs summed, their tables being prefixed by the name of their run,
+// e.g. "orders/items". The current table is the one of the first run reading tables, by name.
+func (g *Group) Status() Status {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	names := make([]string, 0, len(g.runs))
+	for name := range g.runs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	status := Status{Healthy: true, Tables: make(map[string]uint64)}
+	for _, name := range names {
+		s := g.runs[name].Status()
+		status.Healthy = status.Healthy && s.Healthy
+		status.Rows += s.Rows
+		status.RowsPerSecond += s.RowsPerSecond
+		if s.LastProgress.After(status.LastProgress) {
+			status.LastProgress = s.LastProgress
+		}
+		if status.Current == "" && s.Current != "" {
+			status.Current = name + "/" + s.Current
+		}
+		for table, rows := range s.Tables {
+			status.Tables[name+"/"+table] = rows
+		}
+	}
+
+	return status
+}
+
+// ServeHTTP writes the status as JSON, with a 503 status code when a run is not healthy.
+func (g *Group) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, g.Status())
+}
+
+func writeStatus(w http.ResponseWriter, status Status) {
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.WithError(err).Warn("failed to write the health status")
+	}
+}
+
+func (m *Monitor) progress(update func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	update()
+	m.lastProgress = m.now()
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestMonitor(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	source := &mockReader{release: make(chan struct{})}
+	m := newMonitor(source, time.Minute, clock.Now)
+
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- m.ReadTable("users", rowChan, reader.ReadTableOpt{})
+	}()
+	<-rowChan
+	<-rowChan
+
+	// the second row is counted once received
+	require.Eventually(t, func() bool { return m.Status().Rows == 2 }, time.Second, time.Millisecond)
+	clock.Add(10 * time.Second)
+
+	status := m.Status()
+	assert.True(t, status.Healthy)
+	assert.Equal(t, "users", status.Current)
+	assert.Equal(t, map[string]uint64{"users": 2}, status.Tables)
+	assert.Equal(t, 0.2, status.RowsPerSecond)
+
+	clock.Add(2 * time.Minute)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var served Status
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&served))
+	assert.False(t, served.Healthy)
+	assert.Equal(t, uint64(2), served.Rows)
+
+	close(source.release)
+	for range rowChan {
+	}
+	require.NoError(t, <-errChan)
+
+	status = m.Status()
+	assert.True(t, status.Healthy)
+	assert.Empty(t, status.Tables)
+	assert.Empty(t, status.Current)
+	assert.Equal(t, uint64(3), status.Rows)
+}
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	orders := newMonitor(nil, time.Minute, clock.Now)
+	users := newMonitor(nil, 0, clock.Now)
+	orders.progress(func() {
+		orders.reading["items"] = 3
+		orders.tableStarts["items"] = clock.Now()
+		orders.rows = 10
+	})
+	users.progress(func() { users.rows = 5 })
+
+	group := NewGroup()
+	group.Add("users", users)
+	group.Add("orders", orders)
+
+	status := group.Status()
+	assert.True(t, status.Healthy)
+	assert.Equal(t, uint64(15), status.Rows)
+	assert.Equal(t, map[string]uint64{"orders/items": 3}, status.Tables)
+	assert.Equal(t, "orders/items", status.Current)
+
+	clock.Add(2 * time.Minute)
+	rec := httptest.NewRecorder()
+	group.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "the orders run stalled")
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// mockReader publishes two rows, then a third one once released.
+type mockReader struct {
+	release chan struct{}
+}
+
+func (m *mockReader) GetTables() ([]string, error)        { return nil, nil }
+func (m *mockReader) GetStructure() (string, error)       { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) { return nil, nil }
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return ""
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	columns := database.NewColumns([]string{"id"})
+	rowChan <- database.NewRow(columns, []interface{}{int64(1)})
+	rowChan <- database.NewRow(columns, []interface{}{int64(2)})
+	<-m.release
+	rowChan <- database.NewRow(columns, []interface{}{int64(3)})
+
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
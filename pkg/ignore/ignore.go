@@ -0,0 +1,114 @@
+package ignore
+
+import (
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type ignoreReader struct {
+	reader.Reader
+	// ignored are the ignored columns by table.
+	ignored map[string]map[string]bool
+}
+
+// NewReader returns a reader leaving out the columns configured with IgnoreColumns,
+// they are neither read nor listed in the table columns.
+func NewReader(source reader.Reader, tables config.Tables) reader.Reader {
+	ignored := make(map[string]map[string]bool)
+	for _, table := range tables {
+		if len(table.IgnoreColumns) == 0 {
+			continue
+		}
+
+		ignored[table.Name] = make(map[string]bool, len(table.IgnoreColumns))
+		for _, column := range table.IgnoreColumns {
+			ignored[table.Name][column] = true
+		}
+	}
+
+	if len(ignored) == 0 {
+		return source
+	}
+
+	return &ignoreReader{Reader: source, ignored: ignored}
+}
+
+// GetColumns returns the columns of the table that are not ignored.
+func (r *ignoreReader) GetColumns(tableName string) ([]string, error) {
+	columns, err := r.Reader.GetColumns(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	ignored, ok := r.ignored[tableName]
+	if !ok {
+		return columns, nil
+	}
+
+	kept := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !ignored[column] {
+			kept = append(kept, column)
+		}
+	}
+
+	return kept, nil
+}
+
+// ReadTable reads the columns of the table that are not ignored, the ignored columns are removed
+// from the rows of the readers that read all the columns anyway.
+func (r *ignoreReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	ignored, ok := r.ignored[tableName]
+	if !ok {
+		return r.Reader.ReadTable(tableName, rowChan, opts)
+	}
+	defer close(rowChan)
+
+	if len(opts.Columns) == 0 {
+		columns, err := r.GetColumns(tableName)
+		if err != nil {
+			return err
+		}
+		for _, column := range columns {
+			opts.Columns = append(opts.Columns, r.FormatColumn(tableName, column))
+		}
+	}
+
+	rawChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.Reader.ReadTable(tableName, rawChan, opts)
+	}()
+
+	// the rows of a table share their columns, the kept ones are resolved on the first row
+	var (
+		columns *database.Columns
+		keep    []int
+	)
+	for row := range rawChan {
+		if columns == nil {
+			var names, types []string
+			for i, name := range row.Columns() {
+				if !ignored[name] {
+					names = append(names, name)
+					types = append(types, row.ColumnType(name))
+					keep = append(keep, i)
+				}
+			}
+			columns = database.NewTypedColumns(names, types)
+		}
+		if len(keep) == row.Len() {
+			rowChan <- row
+			continue
+		}
+
+		values := make([]interface{}, len(keep))
+		for i, j := range keep {
+			values[i] = row.Values()[j]
+		}
+		rowChan <- database.NewRow(columns, values)
+	}
+
+	return <-errChan
+}
@@ -0,0 +1,71 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{}
+	r := NewReader(source, config.Tables{{Name: "users", IgnoreColumns: []string{"notes", "payload"}}})
+
+	columns, err := r.GetColumns("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "email"}, columns)
+
+	rows, err := readAll(r, "users")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"id", "email"}, rows[0].Columns())
+	assert.Equal(t, []interface{}{int64(2), "b@example.com"}, rows[1].Values())
+	// only the kept columns are requested from the source
+	assert.Equal(t, []string{"users.id", "users.email"}, source.requested)
+
+	assert.Same(t, source, NewReader(source, config.Tables{{Name: "users"}}))
+}
+
+func readAll(r reader.Reader, table string) ([]database.Row, error) {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable(table, rowChan, reader.ReadTableOpt{})
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+
+	return rows, <-errChan
+}
+
+// mockReader publishes all the columns of a users table, whatever the requested columns.
+type mockReader struct {
+	requested []string
+}
+
+func (m *mockReader) GetTables() ([]string, error)  { return []string{"users"}, nil }
+func (m *mockReader) GetStructure() (string, error) { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) {
+	return []string{"id", "notes", "email", "payload"}, nil
+}
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return tableName + "." + columnName
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	m.requested = opts.Columns
+	columns := database.NewColumns([]string{"id", "notes", "email", "payload"})
+	rowChan <- database.NewRow(columns, []interface{}{int64(1), "note", "a@example.com", []byte("{}")})
+	rowChan <- database.NewRow(columns, []interface{}{int64(2), nil, "b@example.com", nil})
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
@@ -0,0 +1,343 @@
+package integrity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// Modes of handling rows referencing parent rows that are not dumped.
+const (
+	// Off does not check the references.
+	Off Mode = "off"
+	// Warn logs the broken references.
+	Warn Mode = "warn"
+	// Fail logs the broken references and fails once the dump is done.
+	Fail Mode = "fail"
+	// Include reads the missing parent rows from the source and dumps them with their table.
+	Include Mode = "include"
+)
+
+// ErrBrokenReferences is returned when dumped rows reference parent rows that were not dumped.
+var ErrBrokenReferences = errors.New("dumped rows reference missing parent rows")
+
+type (
+	// Mode is the way broken references are handled.
+	Mode string
+
+	// Reader checks that the foreign keys of the rows read reference rows that are read as well.
+	Reader struct {
+		reader.Reader
+		mode        Mode
+		tables      []string
+		foreignKeys []reader.ForeignKey
+		ignored     map[string]bool
+		position    map[string]int
+		done        map[string]chan struct{}
+
+		mu       sync.Mutex
+		keys     map[string]map[string]set
+		refs     map[int]set
+		finished map[string]bool
+		checked  map[int]bool
+		broken   []string
+	}
+
+	set map[string]struct{}
+)
+
+// ParseMode parses an integrity check mode.
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(s)); mode {
+	case Off, Warn, Fail, Include:
+		return mode, nil
+	case "":
+		return Off, nil
+	}
+
+	return "", fmt.Errorf("unknown integrity check mode %q, supported modes are off, warn, fail and include", s)
+}
+
+// NewReader returns a reader checking the references of the rows read from the source.
+// The foreign keys are read from schema when it knows them (see reader.ForeignKeyer) and
+// from the relationships of the tables configuration.
+// In Include mode the tables are listed children first and reading a table waits for its children
+// to be read, so the parent rows they reference are known.
+func NewReader(source reader.Reader, schema reader.Reader, cfgTables config.Tables, mode Mode) (*Reader, error) {
+	tables, err := source.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("integrity: could not get tables: %w", err)
+	}
+
+	foreignKeys, err := ForeignKeys(schema, cfgTables, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{
+		Reader:      source,
+		mode:        mode,
+		tables:      tables,
+		foreignKeys: foreignKeys,
+		ignored:     make(map[string]bool),
+		position:    make(map[string]int),
+		done:        make(map[string]chan struct{}),
+		keys:        make(map[string]map[string]set),
+		refs:        make(map[int]set),
+		finished:    make(map[string]bool),
+		checked:     make(map[int]bool),
+	}
+
+	for _, table := range tables {
+		if cfg := cfgTables.FindByName(table); cfg != nil && cfg.IgnoreData {
+			r.ignored[table] = true
+		}
+		r.done[table] = make(chan struct{})
+	}
+	for i, fk := range foreignKeys {
+		if r.keys[fk.ReferencedTable] == nil {
+			r.keys[fk.ReferencedTable] = make(map[string]set)
+		}
+		r.keys[fk.ReferencedTable][ColumnsKey(fk.ReferencedKeyColumns())] = make(set)
+		r.refs[i] = make(set)
+	}
+
+	if mode == Include {
+		r.tables = childrenFirst(tables, foreignKeys)
+	}
+	for i, table := range r.tables {
+		r.position[table] = i
+	}
+
+	log.WithField("foreign_keys", len(foreignKeys)).Debug("checking the integrity of the dumped rows")
+
+	return r, nil
+}
+
+// GetTables returns the source tables, children first in Include mode.
+func (r *Reader) GetTables() ([]string, error) {
+	return r.tables, nil
+}
+
+// ReadTable records the keys and references of the rows read from the source,
+// and in Include mode reads the parent rows referenced by the children tables that were not read.
+func (r *Reader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	if r.mode == Include {
+		r.waitForChildren(tableName)
+	}
+
+	rec := r.newRecorder(tableName)
+	err := r.read(tableName, opts, func(row database.Row) {
+		rec.add(row)
+		rowChan <- row
+	})
+	if err == nil && r.mode == Include {
+		err = r.includeParents(tableName, rec, rowChan)
+	}
+
+	// the table is finished before the dumper is done with it, so broken references are known once the dump is done
+	r.merge(rec)
+	r.finish(tableName)
+	close(rowChan)
+
+	return err
+}
+
+// Err returns the broken references found in Fail mode, once all the tables were read.
+func (r *Reader) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode != Fail || len(r.broken) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrBrokenReferences, strings.Join(r.broken, ", "))
+}
+
+// read reads a table from the source, calling publish for each row.
+func (r *Reader) read(tableName string, opts reader.ReadTableOpt, publish func(database.Row)) error {
+	rawChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.Reader.ReadTable(tableName, rawChan, opts)
+	}()
+
+	for row := range rawChan {
+		publish(row)
+	}
+
+	return <-errChan
+}
+
+// waitForChildren waits for the tables referencing the table, and listed before it, to be read.
+func (r *Reader) waitForChildren(tableName string) {
+	for _, fk := range r.foreignKeys {
+		if !r.isWaited(fk) || fk.ReferencedTable != tableName {
+			continue
+		}
+
+		log.WithFields(log.Fields{"table": tableName, "child": fk.Table}).Debug("waiting for the child table to be read")
+		<-r.done[fk.Table]
+	}
+}
+
+// isWaited reports whether reading the referenced table of a foreign key waits for the referencing table.
+func (r *Reader) isWaited(fk reader.ForeignKey) bool {
+	return fk.Table != fk.ReferencedTable &&
+		!r.ignored[fk.Table] &&
+		r.position[fk.Table] < r.position[fk.ReferencedTable]
+}
+
+// finish marks a table as read and checks the foreign keys whose both tables are read.
+func (r *Reader) finish(tableName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.finished[tableName] {
+		return
+	}
+	r.finished[tableName] = true
+	close(r.done[tableName])
+
+	for i, fk := range r.foreignKeys {
+		if r.checked[i] || !r.settled(fk.Table) || !r.settled(fk.ReferencedTable) {
+			continue
+		}
+		r.checked[i] = true
+		r.check(i, fk)
+	}
+}
+
+func (r *Reader) settled(tableName string) bool {
+	return r.finished[tableName] || r.ignored[tableName]
+}
+
+func (r *Reader) check(i int, fk reader.ForeignKey) {
+	missing := r.refs[i].minus(r.keys[fk.ReferencedTable][ColumnsKey(fk.ReferencedKeyColumns())])
+	if len(missing) == 0 {
+		return
+	}
+
+	examples := missing.sorted()
+	if len(examples) > 5 {
+		examples = examples[:5]
+	}
+
+	logger := log.WithFields(log.Fields{
+		"table":             fk.Table,
+		"column":            strings.Join(fk.KeyColumns(), ", "),
+		"referenced_table":  fk.ReferencedTable,
+		"referenced_column": strings.Join(fk.ReferencedKeyColumns(), ", "),
+		"missing":           len(missing),
+		"examples":          examples,
+	})
+	if r.mode == Include {
+		logger.Warn("dumped rows reference parent rows that could not be included")
+	} else {
+		logger.Warn("dumped rows reference parent rows that are not dumped")
+	}
+
+	r.broken = append(r.broken, fmt.Sprintf("%s (%d missing)", fk, len(missing)))
+}
+
+// ForeignKeys returns the foreign keys known by the source and configured as relationships, between the given tables.
+func ForeignKeys(source reader.Reader, cfgTables config.Tables, tables []string) ([]reader.ForeignKey, error) {
+	var candidates []reader.ForeignKey
+	if f, ok := source.(reader.ForeignKeyer); ok {
+		fks, err := f.GetForeignKeys()
+		if err != nil && !errors.Is(err, reader.ErrForeignKeysUnsupported) {
+			return nil, fmt.Errorf("integrity: could not get foreign keys: %w", err)
+		}
+		candidates = append(candidates, fks...)
+	} else {
+		log.Debug("the reader does not know the foreign keys, only the configured relationships are checked")
+	}
+
+	for _, table := range cfgTables {
+		for _, rel := range table.Relationships {
+			fk := reader.ForeignKey{
+				Table:            rel.Table,
+				Column:           rel.ForeignKey,
+				ReferencedTable:  rel.ReferencedTable,
+				ReferencedColumn: rel.ReferencedKey,
+			}
+			if fk.Table == "" {
+				fk.Table = table.Name
+			}
+			candidates = append(candidates, fk)
+		}
+	}
+
+	known := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		known[table] = true
+	}
+
+	seen := make(map[string]bool)
+	var foreignKeys []reader.ForeignKey
+	for _, fk := range candidates {
+		if seen[fk.String()] || !known[fk.Table] || !known[fk.ReferencedTable] {
+			continue
+		}
+		seen[fk.String()] = true
+		foreignKeys = append(foreignKeys, fk)
+	}
+
+	return foreignKeys, nil
+}
+
+// childrenFirst orders the tables so that the tables referencing another table come before it,
+// keeping the given order otherwise. Tables referencing each other keep their order at the end.
+func childrenFirst(tables []string, foreignKeys []reader.ForeignKey) []string {
+	children := make(map[string][]string)
+	for _, fk := range foreignKeys {
+		if fk.Table != fk.ReferencedTable {
+			children[fk.ReferencedTable] = append(children[fk.ReferencedTable], fk.Table)
+		}
+	}
+
+	ordered := make([]string, 0, len(tables))
+	placed := make(map[string]bool, len(tables))
+	ready := func(table string) bool {
+		for _, child := range children[table] {
+			if !placed[child] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for len(ordered) < len(tables) {
+		// place the first ready table, so the given order is kept as much as possible
+		progress := false
+		for _, table := range tables {
+			if placed[table] || !ready(table) {
+				continue
+			}
+			ordered = append(ordered, table)
+			placed[table] = true
+			progress = true
+			break
+		}
+
+		if !progress {
+			// reference cycle, the remaining tables keep their order and only wait for the tables before them
+			for _, table := range tables {
+				if !placed[table] {
+					ordered = append(ordered, table)
+					placed[table] = true
+				}
+			}
+		}
+	}
+
+	return ordered
+}
@@ -0,0 +1,253 @@
+package integrity
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	mode, err := ParseMode("")
+	require.NoError(t, err)
+	assert.Equal(t, Off, mode)
+
+	mode, err = ParseMode("Include")
+	require.NoError(t, err)
+	assert.Equal(t, Include, mode)
+
+	_, err = ParseMode("repair")
+	assert.Error(t, err)
+}
+
+func TestChildrenFirst(t *testing.T) {
+	t.Parallel()
+
+	fks := []reader.ForeignKey{
+		{Table: "orders", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "order_items", Column: "order_id", ReferencedTable: "orders", ReferencedColumn: "id"},
+		{Table: "users", Column: "manager_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+	assert.Equal(t,
+		[]string{"countries", "order_items", "orders", "users"},
+		childrenFirst([]string{"users", "orders", "countries", "order_items"}, fks),
+	)
+}
+
+func TestReadTableFail(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewReader(newMockReader(), newMockReader(), nil, Fail)
+	require.NoError(t, err)
+
+	tables := dumpAll(t, r, map[string]uint64{"users": 1})
+	assert.Len(t, tables["users"], 1)
+	assert.Len(t, tables["orders"], 3)
+	assert.ErrorIs(t, r.Err(), ErrBrokenReferences)
+	assert.Contains(t, r.Err().Error(), "orders.user_id -> users.id (1 missing)")
+}
+
+func TestReadTableWarn(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewReader(newMockReader(), newMockReader(), nil, Warn)
+	require.NoError(t, err)
+
+	dumpAll(t, r, map[string]uint64{"users": 1})
+	assert.NoError(t, r.Err())
+}
+
+func TestReadTableInclude(t *testing.T) {
+	t.Parallel()
+
+	source := newMockReader()
+	cfgTables := config.Tables{{
+		Name: "orders",
+		Relationships: []*config.Relationship{
+			{ForeignKey: "country", ReferencedTable: "countries", ReferencedKey: "code"},
+		},
+	}}
+	r, err := NewReader(source, source, cfgTables, Include)
+	require.NoError(t, err)
+
+	tables, err := r.GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders", "users", "countries"}, tables)
+
+	dumped := dumpAll(t, r, map[string]uint64{"users": 1, "countries": 1})
+	assert.ElementsMatch(t, []interface{}{int64(1), int64(2)}, column(dumped["users"], "id"))
+	assert.ElementsMatch(t, []interface{}{"de", "fr"}, column(dumped["countries"], "code"))
+	assert.Contains(t, source.matches, "`users`.`id` IN (2)")
+	assert.Contains(t, source.matches, "`countries`.`code` IN ('fr')")
+	assert.NoError(t, r.Err())
+}
+
+func TestReadTableIncludeCompositeKey(t *testing.T) {
+	t.Parallel()
+
+	orders := database.NewColumns([]string{"shop_id", "no"})
+	lines := database.NewColumns([]string{"shop_id", "order_no", "sku"})
+	source := &mockReader{
+		tables: []string{"orders", "order_lines"},
+		rows: map[string][]database.Row{
+			"orders": {
+				database.NewRow(orders, []interface{}{int64(1), int64(1)}),
+				database.NewRow(orders, []interface{}{int64(1), int64(2)}),
+				database.NewRow(orders, []interface{}{int64(2), int64(1)}),
+			},
+			"order_lines": {
+				database.NewRow(lines, []interface{}{int64(1), int64(1), "a"}),
+				database.NewRow(lines, []interface{}{int64(2), int64(1), "b"}),
+				database.NewRow(lines, []interface{}{nil, int64(2), "c"}),
+			},
+		},
+		foreignKeys: []reader.ForeignKey{{
+			Table:             "order_lines",
+			Column:            "shop_id",
+			ReferencedTable:   "orders",
+			ReferencedColumn:  "shop_id",
+			Columns:           []string{"shop_id", "order_no"},
+			ReferencedColumns: []string{"shop_id", "no"},
+		}},
+	}
+	r, err := NewReader(source, source, nil, Include)
+	require.NoError(t, err)
+
+	dumped := dumpAll(t, r, map[string]uint64{"orders": 1})
+	assert.ElementsMatch(t, []interface{}{int64(1), int64(2)}, column(dumped["orders"], "shop_id"))
+	assert.ElementsMatch(t, []interface{}{int64(1), int64(1)}, column(dumped["orders"], "no"))
+	assert.Contains(t, source.matches, "(`orders`.`shop_id`, `orders`.`no`) IN ((2, 1))")
+	assert.NoError(t, r.Err())
+}
+
+func TestInCondition(t *testing.T) {
+	t.Parallel()
+
+	source := newMockReader()
+	columns := database.NewColumns([]string{"shop_id", "no"})
+	key, ok := KeyOf(database.NewRow(columns, []interface{}{"de", int64(7)}), "shop_id", "no")
+	require.True(t, ok)
+	_, ok = KeyOf(database.NewRow(columns, []interface{}{"de", nil}), "shop_id", "no")
+	assert.False(t, ok)
+
+	match, ok := InCondition(source, "orders", []string{"shop_id", "no"}, []string{key, "it's\x003"})
+	require.True(t, ok)
+	assert.Equal(t, "(`orders`.`shop_id`, `orders`.`no`) IN (('de', 7), ('it''s', 3))", match)
+
+	match, ok = InCondition(source, "orders", []string{"id"}, []string{"1", "a\\b"})
+	require.True(t, ok)
+	assert.Equal(t, "`orders`.`id` IN (1)", match)
+}
+
+// dumpAll reads the tables concurrently like the dumper engine does, with the given limits.
+func dumpAll(t *testing.T, r reader.Reader, limits map[string]uint64) map[string][]database.Row {
+	tables, err := r.GetTables()
+	require.NoError(t, err)
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		dumped = make(map[string][]database.Row)
+	)
+	for _, table := range tables {
+		rowChan := make(chan database.Row)
+		wg.Add(1)
+		go func(table string) {
+			defer wg.Done()
+			for row := range rowChan {
+				mu.Lock()
+				dumped[table] = append(dumped[table], row)
+				mu.Unlock()
+			}
+		}(table)
+		go func(table string) {
+			assert.NoError(t, r.ReadTable(table, rowChan, reader.ReadTableOpt{Limit: limits[table]}))
+		}(table)
+	}
+	wg.Wait()
+
+	return dumped
+}
+
+func column(rows []database.Row, name string) []interface{} {
+	values := make([]interface{}, len(rows))
+	for i, row := range rows {
+		values[i] = row.Get(name)
+	}
+	return values
+}
+
+// mockReader ignores the read conditions and only applies the limit.
+type mockReader struct {
+	mu          sync.Mutex
+	tables      []string
+	rows        map[string][]database.Row
+	foreignKeys []reader.ForeignKey
+	matches     []string
+}
+
+func newMockReader() *mockReader {
+	users := database.NewColumns([]string{"id", "name"})
+	orders := database.NewColumns([]string{"id", "user_id", "country"})
+	countries := database.NewColumns([]string{"code"})
+
+	return &mockReader{tables: []string{"users", "orders", "countries"}, rows: map[string][]database.Row{
+		"users": {
+			database.NewRow(users, []interface{}{int64(1), "a"}),
+			database.NewRow(users, []interface{}{int64(2), "b"}),
+			database.NewRow(users, []interface{}{int64(3), "c"}),
+		},
+		"orders": {
+			database.NewRow(orders, []interface{}{int64(1), []byte("1"), "de"}),
+			database.NewRow(orders, []interface{}{int64(2), []byte("2"), "fr"}),
+			database.NewRow(orders, []interface{}{int64(3), nil, nil}),
+		},
+		"countries": {
+			database.NewRow(countries, []interface{}{"de"}),
+			database.NewRow(countries, []interface{}{"fr"}),
+		},
+	}, foreignKeys: []reader.ForeignKey{
+		{Table: "orders", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "orders", Column: "missing_table_id", ReferencedTable: "missing", ReferencedColumn: "id"},
+	}}
+}
+
+func (m *mockReader) GetTables() ([]string, error) {
+	return m.tables, nil
+}
+
+func (m *mockReader) GetForeignKeys() ([]reader.ForeignKey, error) {
+	return m.foreignKeys, nil
+}
+
+func (m *mockReader) GetStructure() (string, error)       { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) { return nil, nil }
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return "`" + tableName + "`.`" + columnName + "`"
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	if opts.Match != "" {
+		m.mu.Lock()
+		m.matches = append(m.matches, opts.Match)
+		m.mu.Unlock()
+	}
+
+	for i, row := range m.rows[tableName] {
+		if opts.Limit > 0 && uint64(i) == opts.Limit {
+			break
+		}
+		rowChan <- row
+	}
+
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
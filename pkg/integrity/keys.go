@@ -0,0 +1,276 @@
+package integrity
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+const (
+	// includeBatchSize is the amount of missing keys read per query.
+	includeBatchSize = 500
+	// keySeparator separates the values, or the names, of the columns of a multi column key.
+	keySeparator = "\x00"
+)
+
+// recorder collects the keys and references of the rows of a table while it is read.
+type recorder struct {
+	table string
+	// keys are the values of the table columns referenced by other tables, by ColumnsKey of the columns.
+	keys map[string]set
+	// refs are the values of the table foreign keys, by foreign key index.
+	refs map[int]set
+	// columns are the foreign key columns, by foreign key index.
+	columns map[int][]string
+}
+
+func (r *Reader) newRecorder(tableName string) *recorder {
+	rec := &recorder{table: tableName, keys: make(map[string]set), refs: make(map[int]set), columns: make(map[int][]string)}
+	for i, fk := range r.foreignKeys {
+		if fk.ReferencedTable == tableName {
+			rec.keys[ColumnsKey(fk.ReferencedKeyColumns())] = make(set)
+		}
+		if fk.Table == tableName {
+			rec.refs[i] = make(set)
+			rec.columns[i] = fk.KeyColumns()
+		}
+	}
+
+	return rec
+}
+
+func (rec *recorder) add(row database.Row) {
+	for columns, keys := range rec.keys {
+		if key, ok := KeyOf(row, SplitColumnsKey(columns)...); ok {
+			keys.add(key)
+		}
+	}
+	for i, refs := range rec.refs {
+		if key, ok := KeyOf(row, rec.columns[i]...); ok {
+			refs.add(key)
+		}
+	}
+}
+
+// merge adds the keys and references recorded while reading a table.
+func (r *Reader) merge(rec *recorder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, refs := range rec.refs {
+		r.refs[i].addAll(refs)
+	}
+	for columns, keys := range rec.keys {
+		r.keys[rec.table][columns].addAll(keys)
+	}
+}
+
+// includeParents reads the rows of the table referenced by the children tables that were not read yet.
+func (r *Reader) includeParents(tableName string, rec *recorder, rowChan chan<- database.Row) error {
+	logger := log.WithField("table", tableName)
+
+	r.mu.Lock()
+	missing := make(map[string]set)
+	for i, fk := range r.foreignKeys {
+		if fk.ReferencedTable != tableName || !r.isWaited(fk) {
+			continue
+		}
+		columns := ColumnsKey(fk.ReferencedKeyColumns())
+		if missing[columns] == nil {
+			missing[columns] = make(set)
+		}
+		missing[columns].addAll(r.refs[i].minus(rec.keys[columns]))
+	}
+	r.mu.Unlock()
+
+	for columnsKey, keys := range missing {
+		if len(keys) == 0 {
+			continue
+		}
+
+		columns := SplitColumnsKey(columnsKey)
+		values := keys.sorted()
+		var included int
+		for start := 0; start < len(values); start += includeBatchSize {
+			end := start + includeBatchSize
+			if end > len(values) {
+				end = len(values)
+			}
+
+			match, ok := r.inCondition(tableName, columns, values[start:end])
+			if !ok {
+				continue
+			}
+
+			// readers ignoring the condition return the whole table, only the missing rows are published
+			err := r.read(tableName, reader.ReadTableOpt{Match: match}, func(row database.Row) {
+				key, ok := KeyOf(row, columns...)
+				if !ok || !keys.contains(key) || rec.keys[columnsKey].contains(key) {
+					return
+				}
+				rec.add(row)
+				rowChan <- row
+				included++
+			})
+			if err != nil {
+				return fmt.Errorf("integrity: could not read missing parent rows: %w", err)
+			}
+		}
+
+		logger.WithFields(log.Fields{"column": strings.Join(columns, ", "), "rows": included}).Info("included missing parent rows")
+	}
+
+	return nil
+}
+
+// inCondition builds a condition matching the rows with the given key values.
+func (r *Reader) inCondition(tableName string, columns []string, values []string) (string, bool) {
+	return InCondition(r.Reader, tableName, columns, values)
+}
+
+// InCondition builds a condition matching the rows of the table with the given key values, as returned by KeyOf
+// for the columns, quoted for the source. Multi column keys are matched as tuples.
+// Values that can not be quoted safely are left out, ok is false when no value is left.
+func InCondition(source reader.Reader, tableName string, columns []string, values []string) (string, bool) {
+	literals := make([]string, 0, len(values))
+	for _, value := range values {
+		parts := strings.Split(value, keySeparator)
+		if len(parts) != len(columns) {
+			continue
+		}
+
+		quoted := make([]string, len(parts))
+		for i, part := range parts {
+			literal, ok := quote(part)
+			if !ok {
+				log.WithFields(log.Fields{"table": tableName, "column": columns[i]}).Warn("could not look up a key with a backslash")
+				quoted = nil
+				break
+			}
+			quoted[i] = literal
+		}
+		if quoted == nil {
+			continue
+		}
+
+		if len(quoted) == 1 {
+			literals = append(literals, quoted[0])
+		} else {
+			literals = append(literals, "("+strings.Join(quoted, ", ")+")")
+		}
+	}
+	if len(literals) == 0 {
+		return "", false
+	}
+
+	formatted := make([]string, len(columns))
+	for i, column := range columns {
+		formatted[i] = source.FormatColumn(tableName, column)
+	}
+	if len(formatted) == 1 {
+		return fmt.Sprintf("%s IN (%s)", formatted[0], strings.Join(literals, ", ")), true
+	}
+
+	return fmt.Sprintf("(%s) IN (%s)", strings.Join(formatted, ", "), strings.Join(literals, ", ")), true
+}
+
+// quote quotes a key value as a SQL literal, ok is false when it can not be quoted safely.
+func quote(value string) (string, bool) {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return value, true
+	}
+	// backslashes are escapes in some dialects only, such keys are not looked up
+	if strings.ContainsAny(value, "\\\x00") {
+		return "", false
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'", true
+}
+
+// KeyOf returns the values of the columns of a key as a single key, ok is false when one of them is NULL.
+func KeyOf(row database.Row, columns ...string) (string, bool) {
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		value, ok := valueOf(row, column)
+		if !ok {
+			return "", false
+		}
+		values[i] = value
+	}
+
+	return strings.Join(values, keySeparator), true
+}
+
+func valueOf(row database.Row, column string) (string, bool) {
+	value, ok := row.Lookup(column)
+	if !ok {
+		return "", false
+	}
+	if p, ok := value.(*interface{}); ok && p != nil {
+		value = *p
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	}
+
+	return fmt.Sprint(value), true
+}
+
+// ColumnsKey returns the columns of a key as a map key.
+func ColumnsKey(columns []string) string {
+	return strings.Join(columns, keySeparator)
+}
+
+// SplitColumnsKey returns the columns of a map key returned by ColumnsKey.
+func SplitColumnsKey(key string) []string {
+	return strings.Split(key, keySeparator)
+}
+
+func (s set) add(value string) {
+	s[value] = struct{}{}
+}
+
+func (s set) addAll(other set) {
+	for value := range other {
+		s[value] = struct{}{}
+	}
+}
+
+func (s set) contains(value string) bool {
+	_, ok := s[value]
+	return ok
+}
+
+// minus returns the values of the set that are not in the other set.
+func (s set) minus(other set) set {
+	diff := make(set)
+	for value := range s {
+		if !other.contains(value) {
+			diff.add(value)
+		}
+	}
+
+	return diff
+}
+
+func (s set) sorted() []string {
+	values := make([]string, 0, len(s))
+	for value := range s {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	return values
+}
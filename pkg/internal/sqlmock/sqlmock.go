@@ -0,0 +1,460 @@
+// Package sqlmock is a database/sql driver replaying scripted expectations, in the spirit of go-sqlmock, for the
+// tests of the readers and dumpers that do not need a database server. It is internal to the module, only its tests
+// use it.
+//
+// The expectations are met in the order they are declared: a statement that does not match the next expectation
+// fails with an error, as does a statement left without expectation.
+package sqlmock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Kinds of expectations.
+const (
+	kindBegin    = "begin"
+	kindCommit   = "commit"
+	kindRollback = "rollback"
+	kindPrepare  = "prepare"
+	kindExec     = "exec"
+	kindQuery    = "query"
+)
+
+type (
+	// Mock holds the expectations of a mocked database.
+	Mock struct {
+		mu           sync.Mutex
+		expectations []*Expectation
+	}
+
+	// Expectation is a statement or transaction call expected by the mock, with its outcome.
+	Expectation struct {
+		kind    string
+		pattern *regexp.Regexp
+		args    []interface{}
+		checked bool
+		rows    *Rows
+		result  driver.Result
+		err     error
+		delay   time.Duration
+		run     func() error
+		met     bool
+	}
+
+	// Argument matches an argument of a statement in a different way than being equal to it.
+	Argument interface {
+		Match(driver.Value) bool
+	}
+
+	// Rows are the rows returned by an expected query.
+	Rows struct {
+		columns []string
+		values  [][]driver.Value
+		// errs are the errors the reads of the rows fail with, by row index.
+		errs map[int]error
+	}
+
+	anyArg struct{}
+
+	connector struct {
+		mock *Mock
+	}
+
+	conn struct {
+		mock *Mock
+	}
+
+	stmt struct {
+		conn  *conn
+		query string
+	}
+
+	rows struct {
+		columns []string
+		values  [][]driver.Value
+		errs    map[int]error
+		next    int
+	}
+)
+
+// New returns a database whose statements are checked against the expectations of the mock, closed when the test
+// ends.
+func New(t testing.TB) (*sql.DB, *Mock) {
+	t.Helper()
+
+	mock := &Mock{}
+	db := sql.OpenDB(&connector{mock: mock})
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db, mock
+}
+
+// ExpectBegin expects a transaction to be opened.
+func (m *Mock) ExpectBegin() *Expectation {
+	return m.expect(kindBegin, "")
+}
+
+// ExpectCommit expects a transaction to be committed.
+func (m *Mock) ExpectCommit() *Expectation {
+	return m.expect(kindCommit, "")
+}
+
+// ExpectRollback expects a transaction to be rolled back.
+func (m *Mock) ExpectRollback() *Expectation {
+	return m.expect(kindRollback, "")
+}
+
+// ExpectPrepare expects a statement matching the regular expression to be prepared.
+func (m *Mock) ExpectPrepare(pattern string) *Expectation {
+	return m.expect(kindPrepare, pattern)
+}
+
+// ExpectExec expects a statement matching the regular expression to be executed, directly or once prepared.
+func (m *Mock) ExpectExec(pattern string) *Expectation {
+	return m.expect(kindExec, pattern)
+}
+
+// ExpectQuery expects a query matching the regular expression to be run, directly or once prepared.
+func (m *Mock) ExpectQuery(pattern string) *Expectation {
+	return m.expect(kindQuery, pattern)
+}
+
+// ExpectationsWereMet returns an error naming the first expectation that was not met.
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.expectations {
+		if !e.met {
+			return fmt.Errorf("sqlmock: expected %s was not met", e)
+		}
+	}
+
+	return nil
+}
+
+func (m *Mock) expect(kind string, pattern string) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &Expectation{kind: kind}
+	if pattern != "" {
+		e.pattern = regexp.MustCompile(pattern)
+	}
+	m.expectations = append(m.expectations, e)
+
+	return e
+}
+
+// next meets the next expectation, failing when the call does not match it.
+func (m *Mock) next(kind string, query string, args []driver.NamedValue) (*Expectation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	call := kind
+	if query != "" {
+		call = fmt.Sprintf("%s %q", kind, query)
+	}
+
+	for _, e := range m.expectations {
+		if e.met {
+			continue
+		}
+		if e.kind != kind || (e.pattern != nil && !e.pattern.MatchString(query)) {
+			return nil, fmt.Errorf("sqlmock: unexpected %s, expected %s", call, e)
+		}
+		if err := e.matchArgs(args); err != nil {
+			return nil, fmt.Errorf("sqlmock: %s: %w", call, err)
+		}
+		e.met = true
+
+		return e, nil
+	}
+
+	return nil, fmt.Errorf("sqlmock: unexpected %s, all expectations were met", call)
+}
+
+// WithArgs sets the arguments the statement is expected with, compared once converted to driver values.
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	e.checked = true
+	return e
+}
+
+// WillReturnRows sets the rows returned by the query.
+func (e *Expectation) WillReturnRows(rows *Rows) *Expectation {
+	e.rows = rows
+	return e
+}
+
+// WillReturnResult sets the result of the statement.
+func (e *Expectation) WillReturnResult(result driver.Result) *Expectation {
+	e.result = result
+	return e
+}
+
+// WillReturnError sets the error the call fails with.
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// WillDelayFor delays the outcome of the call, which fails with the error of its context when it is done first.
+func (e *Expectation) WillDelayFor(delay time.Duration) *Expectation {
+	e.delay = delay
+	return e
+}
+
+// WillRun runs fn when the call is made, as the server would e.g. read the file of a LOAD DATA LOCAL statement, its
+// error failing the call.
+func (e *Expectation) WillRun(fn func() error) *Expectation {
+	e.run = fn
+	return e
+}
+
+func (e *Expectation) String() string {
+	if e.pattern == nil {
+		return e.kind
+	}
+
+	return fmt.Sprintf("%s matching %q", e.kind, e.pattern)
+}
+
+func (e *Expectation) matchArgs(args []driver.NamedValue) error {
+	if !e.checked {
+		return nil
+	}
+	if len(args) != len(e.args) {
+		return fmt.Errorf("expected %d arguments, got %d", len(e.args), len(args))
+	}
+
+	for i, expected := range e.args {
+		actual := args[i].Value
+		if arg, ok := expected.(Argument); ok {
+			if !arg.Match(actual) {
+				return fmt.Errorf("argument %d %v does not match", i, actual)
+			}
+			continue
+		}
+
+		value, err := driver.DefaultParameterConverter.ConvertValue(expected)
+		if err != nil {
+			return fmt.Errorf("argument %d: %w", i, err)
+		}
+		if !reflect.DeepEqual(value, actual) {
+			return fmt.Errorf("argument %d is %#v, expected %#v", i, actual, value)
+		}
+	}
+
+	return nil
+}
+
+// outcome runs the function of the expectation, waits for its delay and returns its error.
+func (e *Expectation) outcome(ctx context.Context) error {
+	if e.run != nil {
+		if err := e.run(); err != nil {
+			return err
+		}
+	}
+	if e.delay > 0 {
+		timer := time.NewTimer(e.delay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return e.err
+}
+
+// NewResult returns the result of a statement.
+func NewResult(lastInsertID int64, rowsAffected int64) driver.Result {
+	return result{lastInsertID: lastInsertID, rowsAffected: rowsAffected}
+}
+
+type result struct {
+	lastInsertID, rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r result) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// AnyArg returns an argument matching any value.
+func AnyArg() Argument {
+	return anyArg{}
+}
+
+func (anyArg) Match(driver.Value) bool { return true }
+
+// NewRows returns rows with the given columns.
+func NewRows(columns ...string) *Rows {
+	return &Rows{columns: columns}
+}
+
+// AddRow adds a row with a value for each column.
+func (r *Rows) AddRow(values ...interface{}) *Rows {
+	row := make([]driver.Value, len(values))
+	for i, v := range values {
+		value, err := driver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			panic(fmt.Sprintf("sqlmock: invalid value %#v: %v", v, err))
+		}
+		row[i] = value
+	}
+	r.values = append(r.values, row)
+
+	return r
+}
+
+// RowError makes the read of the row at index fail with err, e.g. a connection lost while the rows are streamed.
+func (r *Rows) RowError(index int, err error) *Rows {
+	if r.errs == nil {
+		r.errs = make(map[int]error)
+	}
+	r.errs[index] = err
+
+	return r
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) { return &conn{mock: c.mock}, nil }
+func (c *connector) Open(string) (driver.Conn, error)             { return &conn{mock: c.mock}, nil }
+func (c *connector) Driver() driver.Driver                        { return c }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	e, err := c.mock.next(kindPrepare, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.outcome(ctx); err != nil {
+		return nil, err
+	}
+
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	e, err := c.mock.next(kindBegin, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.outcome(ctx); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *conn) Commit() error {
+	e, err := c.mock.next(kindCommit, "", nil)
+	if err != nil {
+		return err
+	}
+
+	return e.outcome(context.Background())
+}
+
+func (c *conn) Rollback() error {
+	e, err := c.mock.next(kindRollback, "", nil)
+	if err != nil {
+		return err
+	}
+
+	return e.outcome(context.Background())
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := c.mock.next(kindExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.outcome(ctx); err != nil {
+		return nil, err
+	}
+	if e.result == nil {
+		return driver.ResultNoRows, nil
+	}
+
+	return e.result, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.mock.next(kindQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.outcome(ctx); err != nil {
+		return nil, err
+	}
+	if e.rows == nil {
+		return nil, errors.New("sqlmock: the expected query returns no rows")
+	}
+
+	return &rows{columns: e.rows.columns, values: e.rows.values, errs: e.rows.errs}, nil
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), named(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), named(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if err, ok := r.errs[r.next]; ok {
+		return err
+	}
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+
+	return nil
+}
+
+func named(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+
+	return values
+}
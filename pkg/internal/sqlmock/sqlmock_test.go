@@ -0,0 +1,110 @@
+package sqlmock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMock(t *testing.T) {
+	t.Parallel()
+
+	db, mock := New(t)
+	mock.ExpectQuery(`SELECT name FROM users WHERE id = \?`).WithArgs(1).
+		WillReturnRows(NewRows("name").AddRow("alice"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users`).WithArgs(AnyArg(), 1).WillReturnResult(NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var name string
+	require.NoError(t, db.QueryRow("SELECT name FROM users WHERE id = ?", 1).Scan(&name))
+	assert.Equal(t, "alice", name)
+
+	txn, err := db.Begin()
+	require.NoError(t, err)
+	res, err := txn.Exec("UPDATE users SET name = ? WHERE id = ?", "bob", 1)
+	require.NoError(t, err)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	require.NoError(t, txn.Commit())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMockUnexpected(t *testing.T) {
+	t.Parallel()
+
+	db, mock := New(t)
+	mock.ExpectExec(`DELETE FROM users`).WithArgs(2)
+	mock.ExpectExec(`DELETE FROM orders`).WillReturnError(errors.New("deadlock"))
+
+	_, err := db.Exec("DELETE FROM users WHERE id = ?", 1)
+	assert.EqualError(t, err, `sqlmock: exec "DELETE FROM users WHERE id = ?": argument 0 is 1, expected 2`)
+	_, err = db.Exec("DELETE FROM users WHERE id = ?", 2)
+	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM users")
+	assert.EqualError(t, err, `sqlmock: unexpected exec "DELETE FROM users", expected exec matching "DELETE FROM orders"`)
+	assert.EqualError(t, mock.ExpectationsWereMet(), `sqlmock: expected exec matching "DELETE FROM orders" was not met`)
+
+	_, err = db.Exec("DELETE FROM orders")
+	assert.EqualError(t, err, "deadlock")
+	_, err = db.Exec("DELETE FROM orders")
+	assert.EqualError(t, err, `sqlmock: unexpected exec "DELETE FROM orders", all expectations were met`)
+}
+
+func TestMockDelay(t *testing.T) {
+	t.Parallel()
+
+	db, mock := New(t)
+	mock.ExpectQuery(`SELECT SLEEP`).WillDelayFor(time.Second).WillReturnRows(NewRows("slept").AddRow(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var slept int
+	err := db.QueryRowContext(ctx, "SELECT SLEEP(1)").Scan(&slept)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMockRowError(t *testing.T) {
+	t.Parallel()
+
+	db, mock := New(t)
+	mock.ExpectQuery(`SELECT id FROM users`).
+		WillReturnRows(NewRows("id").AddRow(1).AddRow(2).RowError(1, errors.New("connection reset")))
+
+	rows, err := db.Query("SELECT id FROM users")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	assert.Equal(t, []int{1}, ids)
+	assert.EqualError(t, rows.Err(), "connection reset")
+}
+
+func TestMockRun(t *testing.T) {
+	t.Parallel()
+
+	db, mock := New(t)
+	var ran int
+	mock.ExpectExec(`LOAD DATA`).WillRun(func() error {
+		ran++
+		return nil
+	})
+	mock.ExpectExec(`LOAD DATA`).WillRun(func() error { return errors.New("file not found") })
+
+	_, err := db.Exec("LOAD DATA LOCAL INFILE 'users.csv' INTO TABLE users")
+	require.NoError(t, err)
+	assert.Equal(t, 1, ran)
+	_, err = db.Exec("LOAD DATA LOCAL INFILE 'orders.csv' INTO TABLE orders")
+	assert.EqualError(t, err, "file not found")
+}
@@ -0,0 +1,102 @@
+// Package keyless applies the strategies configured for the tables without a primary key, whose rows can neither be
+// paginated nor have their large values chunked by key.
+package keyless
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// Strategies of reading a table without a primary key.
+const (
+	// Scan reads the table with a single query, its large values being held in memory.
+	Scan Strategy = "scan"
+	// Physical reads the table in pages of physical positions, e.g. by ctid on Postgres.
+	Physical Strategy = "ctid"
+	// Skip does not dump the data of the table.
+	Skip Strategy = "skip"
+)
+
+// DefaultPageSize is the page size of the tables read by physical position without a PageSize.
+const DefaultPageSize = 10000
+
+// Strategy is the way a table without a primary key is read.
+type Strategy string
+
+// ParseStrategy parses a strategy.
+func ParseStrategy(s string) (Strategy, error) {
+	switch strategy := Strategy(strings.ToLower(s)); strategy {
+	case Scan, Physical, Skip:
+		return strategy, nil
+	}
+
+	return "", fmt.Errorf("unknown strategy %q, expected scan, ctid or skip", s)
+}
+
+// Apply applies the NoPrimaryKey strategy of the configured tables whose primary key schema does not know,
+// adjusting their configuration: the tables read with a single query lose their PageSize and ChunkColumns, the
+// tables read by physical position their ChunkColumns and the skipped tables are dumped without their data.
+func Apply(schema reader.Reader, cfgTables config.Tables) error {
+	for _, cfg := range cfgTables {
+		if cfg.NoPrimaryKey == "" {
+			continue
+		}
+
+		strategy, err := ParseStrategy(cfg.NoPrimaryKey)
+		if err != nil {
+			return fmt.Errorf("invalid NoPrimaryKey of table %s: %w", cfg.Name, err)
+		}
+
+		keyer, ok := schema.(reader.PrimaryKeyer)
+		if !ok {
+			log.WithField("table", cfg.Name).Warn("the source does not know the primary keys, NoPrimaryKey is ignored")
+			continue
+		}
+		key, err := keyer.GetPrimaryKey(cfg.Name)
+		if errors.Is(err, reader.ErrPrimaryKeysUnsupported) {
+			log.WithField("table", cfg.Name).Warn("the source does not know the primary keys, NoPrimaryKey is ignored")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not get the primary key of %s: %w", cfg.Name, err)
+		}
+		if len(key) > 0 {
+			continue
+		}
+
+		apply(cfg, strategy)
+	}
+
+	return nil
+}
+
+func apply(cfg *config.Table, strategy Strategy) {
+	logger := log.WithFields(log.Fields{"table": cfg.Name, "strategy": strategy})
+	if strategy == Skip {
+		cfg.IgnoreData = true
+		logger.Warn("the table has no primary key, its data is not dumped")
+		return
+	}
+
+	if len(cfg.ChunkColumns) > 0 {
+		cfg.ChunkColumns = nil
+		logger.Warn("the table has no primary key to read its large values in chunks with, they are held in memory")
+	}
+
+	switch strategy {
+	case Scan:
+		cfg.PageSize = 0
+		logger.Info("the table has no primary key, it is read with a single query")
+	case Physical:
+		if cfg.PageSize == 0 {
+			cfg.PageSize = DefaultPageSize
+		}
+		logger.Info("the table has no primary key, it is read in pages of physical positions")
+	}
+}
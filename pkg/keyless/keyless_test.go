@@ -0,0 +1,55 @@
+package keyless
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type mockReader struct {
+	reader.Reader
+	keys map[string][]string
+}
+
+func (m *mockReader) GetPrimaryKey(tableName string) ([]string, error) {
+	return m.keys[tableName], nil
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{
+		{Name: "users", NoPrimaryKey: "skip", PageSize: 100},
+		{Name: "logs", NoPrimaryKey: "scan", PageSize: 100, ChunkColumns: []string{"payload"}},
+		{Name: "events", NoPrimaryKey: "ctid"},
+		{Name: "audits", NoPrimaryKey: "skip"},
+		{Name: "orders", PageSize: 100},
+	}
+	schema := &mockReader{keys: map[string][]string{"users": {"id"}}}
+	require.NoError(t, Apply(schema, tables))
+
+	assert.Equal(t, config.Table{Name: "users", NoPrimaryKey: "skip", PageSize: 100}, *tables[0])
+	assert.Equal(t, config.Table{Name: "logs", NoPrimaryKey: "scan"}, *tables[1])
+	assert.Equal(t, config.Table{Name: "events", NoPrimaryKey: "ctid", PageSize: DefaultPageSize}, *tables[2])
+	assert.Equal(t, config.Table{Name: "audits", NoPrimaryKey: "skip", IgnoreData: true}, *tables[3])
+	assert.Equal(t, config.Table{Name: "orders", PageSize: 100}, *tables[4])
+}
+
+func TestApplyInvalid(t *testing.T) {
+	t.Parallel()
+
+	err := Apply(&mockReader{}, config.Tables{{Name: "logs", NoPrimaryKey: "offset"}})
+	assert.EqualError(t, err, `invalid NoPrimaryKey of table logs: unknown strategy "offset", expected scan, ctid or skip`)
+}
+
+func TestApplyPrimaryKeysUnsupported(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{{Name: "logs", NoPrimaryKey: "skip"}}
+	require.NoError(t, Apply(struct{ reader.Reader }{}, tables))
+	assert.False(t, tables[0].IgnoreData)
+}
@@ -0,0 +1,142 @@
+package kleptotest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// UpdateEnv is the variable rewriting the golden dumps with the stolen data instead of comparing them, when set to 1.
+const UpdateEnv = "KLEPTO_UPDATE_GOLDEN"
+
+// AssertGolden compares the data of the database with the golden dump of the file, failing the test on the first
+// difference. The golden dump is written instead when the UpdateEnv variable is set to 1.
+func AssertGolden(t testing.TB, dsn string, path string) {
+	t.Helper()
+
+	r, err := reader.Connect(reader.ConnOpts{DSN: dsn, Timeout: timeout})
+	if err != nil {
+		t.Fatalf("could not connect to the database: %v", err)
+	}
+	defer r.Close()
+
+	dump, err := Snapshot(r)
+	if err != nil {
+		t.Fatalf("could not dump the database: %v", err)
+	}
+
+	if err := compareGolden(path, dump, os.Getenv(UpdateEnv) == "1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Snapshot returns a text dump of the data of the tables, the tables being sorted by name and their rows by value,
+// so that it does not depend on the order the rows are stored in. A table is dumped as its name, its columns and
+// its rows, their values separated by tabs and NULL written as \N.
+func Snapshot(r reader.Reader) (string, error) {
+	tables, err := r.GetTables()
+	if err != nil {
+		return "", err
+	}
+	tables = append([]string(nil), tables...)
+	sort.Strings(tables)
+
+	var b strings.Builder
+	for _, table := range tables {
+		columns, err := r.GetColumns(table)
+		if err != nil {
+			return "", fmt.Errorf("table %s: %w", table, err)
+		}
+
+		lines, err := readLines(r, table)
+		if err != nil {
+			return "", fmt.Errorf("table %s: %w", table, err)
+		}
+		sort.Strings(lines)
+
+		fmt.Fprintf(&b, "# %s\n%s\n", table, strings.Join(columns, "\t"))
+		for _, line := range lines {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	return b.String(), nil
+}
+
+// readLines reads the rows of a table as lines of tab separated values.
+func readLines(r reader.Reader, table string) ([]string, error) {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable(table, rowChan, reader.ReadTableOpt{})
+	}()
+
+	var lines []string
+	for row := range rowChan {
+		values := make([]string, row.Len())
+		for i, value := range row.Values() {
+			values[i] = formatValue(value)
+		}
+		lines = append(lines, strings.Join(values, "\t"))
+	}
+
+	return lines, <-errChan
+}
+
+// valueEscaper escapes the characters separating the values and the rows.
+var valueEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// formatValue returns the text of a value in a golden dump.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return `\N`
+	case []byte:
+		return valueEscaper.Replace(string(v))
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return valueEscaper.Replace(fmt.Sprint(v))
+	}
+}
+
+// compareGolden compares the dump with the golden one of the file, or writes it to the file when updating.
+func compareGolden(path string, dump string, update bool) error {
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("could not write golden dump: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(dump), 0644); err != nil {
+			return fmt.Errorf("could not write golden dump: %w", err)
+		}
+		return nil
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read golden dump, run with %s=1 to write it: %w", UpdateEnv, err)
+	}
+
+	want, got := strings.Split(string(golden), "\n"), strings.Split(dump, "\n")
+	for i := 0; i < len(want) || i < len(got); i++ {
+		var w, g string
+		if i < len(want) {
+			w = want[i]
+		}
+		if i < len(got) {
+			g = got[i]
+		}
+		if w != g {
+			return fmt.Errorf("the data differs from golden dump %s at line %d:\nwant: %s\n got: %s", path, i+1, w, g)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package kleptotest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dump, err := Snapshot(&mockReader{})
+	require.NoError(t, err)
+	assert.Equal(t, "# orders\nid\tuser_id\n"+
+		"# users\nid\temail\tnotes\tcreated_at\n"+
+		"1\ta@example.com\tline\\nbreak\t2024-01-02T03:04:05Z\n"+
+		"2\tb@example.com\t\\N\t\\N\n", dump)
+}
+
+func TestCompareGolden(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "testdata", "users.golden")
+	err := compareGolden(path, "# users\n1\n", false)
+	assert.Error(t, err)
+
+	require.NoError(t, compareGolden(path, "# users\n1\n", true))
+	golden, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# users\n1\n", string(golden))
+
+	assert.NoError(t, compareGolden(path, "# users\n1\n", false))
+	err = compareGolden(path, "# users\n2\n", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at line 2:\nwant: 1\n got: 2")
+	err = compareGolden(path, "# users\n1\n3\n", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at line 3:\nwant: \n got: 3")
+}
+
+func TestStealMySQL(t *testing.T) {
+	server := MySQL(t)
+	testSteal(t, server, "../../fixtures/mysql_simple.sql")
+}
+
+func TestStealPostgres(t *testing.T) {
+	server := Postgres(t)
+	testSteal(t, server, "../../fixtures/pg_simple.sql")
+}
+
+// testSteal steals the fixture without a config, the stolen data being the data of the source.
+func testSteal(t *testing.T, server *Server, fixture string) {
+	source, target := server.CreateDatabase(t), server.CreateDatabase(t)
+	server.LoadFixture(t, source, fixture)
+
+	Steal(t, source, target, nil)
+
+	golden := filepath.Join(t.TempDir(), "simple.golden")
+	r, err := reader.Connect(reader.ConnOpts{DSN: source, Timeout: timeout})
+	require.NoError(t, err)
+	defer r.Close()
+	dump, err := Snapshot(r)
+	require.NoError(t, err)
+	require.NoError(t, compareGolden(golden, dump, true))
+
+	AssertGolden(t, target, golden)
+}
+
+// mockReader publishes a users table and an empty orders table, in reverse order.
+type mockReader struct{}
+
+func (m *mockReader) GetTables() ([]string, error)  { return []string{"users", "orders"}, nil }
+func (m *mockReader) GetStructure() (string, error) { return "", nil }
+func (m *mockReader) GetColumns(table string) ([]string, error) {
+	if table == "orders" {
+		return []string{"id", "user_id"}, nil
+	}
+	return []string{"id", "email", "notes", "created_at"}, nil
+}
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return tableName + "." + columnName
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	if tableName == "orders" {
+		return nil
+	}
+	columns := database.NewColumns([]string{"id", "email", "notes", "created_at"})
+	rowChan <- database.NewRow(columns, []interface{}{int64(2), []byte("b@example.com"), nil, nil})
+	rowChan <- database.NewRow(columns, []interface{}{int64(1), "a@example.com", "line\nbreak", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
@@ -0,0 +1,209 @@
+// Package kleptotest runs klepto against real databases in tests, so that the users embedding klepto can test
+// their configs and custom anonymisers: it starts MySQL and PostgreSQL servers in docker containers, loads fixtures,
+// steals them and compares the stolen databases with golden dumps.
+package kleptotest
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	// the MySQL and PostgreSQL drivers the servers are used with
+	_ "github.com/hellofresh/klepto/pkg/dumper/mysql"
+	_ "github.com/hellofresh/klepto/pkg/dumper/postgres"
+	_ "github.com/hellofresh/klepto/pkg/reader/mysql"
+	_ "github.com/hellofresh/klepto/pkg/reader/postgres"
+)
+
+const (
+	// MySQLImage is the image of the MySQL containers.
+	MySQLImage = "mysql:8"
+	// PostgresImage is the image of the PostgreSQL containers.
+	PostgresImage = "postgres:13-alpine"
+	// MySQLEnv is the variable of the DSN of a MySQL server used instead of a container, e.g. in CI.
+	MySQLEnv = "TEST_MYSQL"
+	// PostgresEnv is the variable of the DSN of a PostgreSQL server used instead of a container, e.g. in CI.
+	PostgresEnv = "TEST_POSTGRES"
+	// startTimeout is the maximum time waited for a container to accept connections.
+	startTimeout = 2 * time.Minute
+	// password is the password of the root user of the containers.
+	password = "klepto"
+)
+
+// databases counts the databases created, for their names to be unique.
+var databases uint64
+
+// Server is a database server the test databases are created on.
+type Server struct {
+	// Driver is the name of the database/sql driver of the server, mysql or postgres.
+	Driver string
+	// DSN is the address of the server, with a user allowed to create databases.
+	DSN string
+
+	root *sql.DB
+}
+
+// MySQL returns the MySQL server of the MySQLEnv variable, or starts a container removed when the test ends.
+// The test is skipped when the variable is not set and docker is not available.
+func MySQL(t testing.TB) *Server {
+	t.Helper()
+
+	dsn, ok := os.LookupEnv(MySQLEnv)
+	if !ok {
+		addr := startContainer(t, MySQLImage, "3306/tcp", "MYSQL_ROOT_PASSWORD="+password)
+		dsn = fmt.Sprintf("root:%s@tcp(%s)/", password, addr)
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("invalid MySQL DSN: %v", err)
+	}
+	// the fixtures are scripts of several statements
+	cfg.MultiStatements = true
+
+	return connect(t, "mysql", cfg.FormatDSN())
+}
+
+// Postgres returns the PostgreSQL server of the PostgresEnv variable, or starts a container removed when the test
+// ends. The test is skipped when the variable is not set and docker is not available.
+func Postgres(t testing.TB) *Server {
+	t.Helper()
+
+	dsn, ok := os.LookupEnv(PostgresEnv)
+	if !ok {
+		addr := startContainer(t, PostgresImage, "5432/tcp", "POSTGRES_PASSWORD="+password)
+		dsn = fmt.Sprintf("postgres://postgres:%s@%s/?sslmode=disable", password, addr)
+	}
+
+	return connect(t, "postgres", dsn)
+}
+
+// CreateDatabase creates an empty database dropped when the test ends, and returns its DSN.
+func (s *Server) CreateDatabase(t testing.TB) string {
+	t.Helper()
+
+	name := fmt.Sprintf("klepto_test_%d_%d", os.Getpid(), atomic.AddUint64(&databases, 1))
+	if _, err := s.root.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatalf("could not create database %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		if _, err := s.root.Exec("DROP DATABASE IF EXISTS " + name); err != nil {
+			t.Errorf("could not drop database %s: %v", name, err)
+		}
+	})
+
+	if s.Driver == "mysql" {
+		cfg, _ := mysql.ParseDSN(s.DSN)
+		cfg.DBName = name
+		return cfg.FormatDSN()
+	}
+
+	u, _ := url.Parse(s.DSN)
+	u.Path = name
+	return u.String()
+}
+
+// LoadFixture runs the SQL script of the file on the database.
+func (s *Server) LoadFixture(t testing.TB, dsn string, path string) {
+	t.Helper()
+
+	script, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read fixture: %v", err)
+	}
+
+	db, err := sql.Open(s.Driver, dsn)
+	if err != nil {
+		t.Fatalf("could not connect to the database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(string(script)); err != nil {
+		t.Fatalf("could not load fixture %s: %v", path, err)
+	}
+}
+
+// connect connects to the server, waiting for it to accept connections.
+func connect(t testing.TB, driver string, dsn string) *Server {
+	t.Helper()
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatalf("could not connect to %s: %v", driver, err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	deadline := time.Now().Add(startTimeout)
+	for {
+		err := db.Ping()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s does not accept connections: %v", driver, err)
+		}
+		time.Sleep(time.Second)
+	}
+
+	return &Server{Driver: driver, DSN: dsn, root: db}
+}
+
+// startContainer runs the image with the env, and returns the host address of its port. The container is removed
+// when the test ends.
+func startContainer(t testing.TB, image string, port string, env ...string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not available")
+	}
+
+	args := []string{"run", "--detach", "--publish", "127.0.0.1::" + port}
+	for _, e := range env {
+		args = append(args, "--env", e)
+	}
+	id, err := docker(append(args, image)...)
+	if err != nil {
+		t.Fatalf("could not start %s: %v", image, err)
+	}
+	t.Cleanup(func() {
+		if _, err := docker("rm", "--force", "--volumes", id); err != nil {
+			t.Errorf("could not remove container %s: %v", id, err)
+		}
+	})
+
+	// e.g. 127.0.0.1:49153, one line per published address
+	out, err := docker("port", id, port)
+	if err != nil {
+		t.Fatalf("could not read the port of %s: %v", image, err)
+	}
+	addr := strings.SplitN(out, "\n", 2)[0]
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		t.Fatalf("invalid address %q of %s: %v", addr, image, err)
+	}
+
+	return addr
+}
+
+// docker runs a docker command, returning its trimmed output.
+func docker(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
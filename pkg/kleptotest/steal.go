@@ -0,0 +1,73 @@
+package kleptotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hellofresh/klepto/pkg/allowed"
+	"github.com/hellofresh/klepto/pkg/anonymiser"
+	"github.com/hellofresh/klepto/pkg/cast"
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/filter"
+	"github.com/hellofresh/klepto/pkg/ignore"
+	"github.com/hellofresh/klepto/pkg/paging"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/sampling"
+	"github.com/hellofresh/klepto/pkg/transform"
+)
+
+// Seed is the seed of the fakers of the steals, so that the stolen values can be compared with golden dumps.
+const Seed = 1
+
+// timeout is the timeout of the read and write operations.
+const timeout = 30 * time.Second
+
+// Steal steals the source database into the target one with the tables config, as klepto steal does with its
+// default options. The tables are read and anonymised one after the other, with the fakers seeded with Seed, for
+// the anonymised values to be the same from one run to the other.
+func Steal(t testing.TB, source string, target string, tables config.Tables) {
+	t.Helper()
+
+	if tables == nil {
+		tables = config.Tables{}
+	}
+
+	connected, err := reader.Connect(reader.ConnOpts{DSN: source, Timeout: timeout})
+	if err != nil {
+		t.Fatalf("could not connect to the source: %v", err)
+	}
+	defer connected.Close()
+
+	src, err := filter.NewReader(connected, tables, nil)
+	if err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	src = ignore.NewReader(src, tables)
+	src = paging.NewReader(src, tables)
+	src = sampling.NewReader(src, tables, sampling.Options{})
+	if src, err = transform.NewReader(src, tables); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	anonymiser.Seed(Seed)
+	src = anonymiser.NewAnonymiser(src, tables, 1)
+	src = allowed.NewReader(src, connected, tables)
+	if src, err = cast.NewReader(src, tables); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	src = reader.WithSections(src, connected)
+
+	dmp, err := dumper.NewDumper(dumper.ConnOpts{DSN: target, Timeout: timeout}, src)
+	if err != nil {
+		t.Fatalf("could not connect to the target: %v", err)
+	}
+	defer dmp.Close()
+
+	result, err := dmp.Dump(tables, 1, false)
+	if err != nil {
+		t.Fatalf("could not steal: %v", err)
+	}
+	if err := result.Err(); err != nil {
+		t.Fatalf("could not steal the tables: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package kube
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// serviceAccountDir holds the credentials of the pod service account.
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	// requestTimeout is the maximum time spent on an API request.
+	requestTimeout = 30 * time.Second
+)
+
+// ErrNotInCluster is returned when the in-cluster configuration is not available.
+var ErrNotInCluster = errors.New("not running in a kubernetes cluster, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
+
+type (
+	// Client is a minimal Kubernetes API client, reading and patching resources as JSON.
+	Client struct {
+		server string
+		token  string
+		http   *http.Client
+	}
+
+	// ObjectMeta is the metadata of a resource.
+	ObjectMeta struct {
+		Name              string    `json:"name"`
+		Namespace         string    `json:"namespace"`
+		CreationTimestamp time.Time `json:"creationTimestamp"`
+		Generation        int64     `json:"generation,omitempty"`
+	}
+
+	// StatusError is returned for the API responses that are not successful.
+	StatusError struct {
+		Code    int
+		Message string
+	}
+)
+
+// NewClient returns a client of the API server at the given url, authenticated with the bearer token when set.
+func NewClient(server string, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout}
+	}
+
+	return &Client{server: strings.TrimSuffix(server, "/"), token: token, http: httpClient}
+}
+
+// NewInClusterClient returns a client authenticated with the service account of the pod it runs in.
+func NewInClusterClient() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, ErrNotInCluster
+	}
+
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("could not read the service account token: %w", err)
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("could not read the service account certificate authority: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("could not parse the service account certificate authority")
+	}
+
+	httpClient := &http.Client{
+		Timeout:   requestTimeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}},
+	}
+
+	return NewClient("https://"+net.JoinHostPort(host, port), strings.TrimSpace(string(token)), httpClient), nil
+}
+
+// InClusterNamespace returns the namespace of the pod, empty when unknown.
+func InClusterNamespace() string {
+	namespace, err := os.ReadFile(serviceAccountDir + "/namespace")
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(namespace))
+}
+
+// Get reads the resource at the given API path into out.
+func (c *Client) Get(ctx context.Context, path string, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, "", nil, out)
+}
+
+// MergePatch applies a JSON merge patch to the resource at the given API path, e.g. its status subresource.
+func (c *Client) MergePatch(ctx context.Context, path string, patch interface{}) error {
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, http.MethodPatch, path, "application/merge-patch+json", body, nil)
+}
+
+// SecretValue returns the value of a key of a secret.
+func (c *Client) SecretValue(ctx context.Context, namespace string, name string, key string) (string, error) {
+	var secret struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := c.Get(ctx, fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", url.PathEscape(namespace), url.PathEscape(name)), &secret); err != nil {
+		return "", fmt.Errorf("could not read secret %s/%s: %w", namespace, name, err)
+	}
+
+	encoded, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s has no key %s", namespace, name, key)
+	}
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("could not decode key %s of secret %s/%s: %w", key, namespace, name, err)
+	}
+
+	return string(value), nil
+}
+
+// ConfigMapValue returns the value of a key of a config map.
+func (c *Client) ConfigMapValue(ctx context.Context, namespace string, name string, key string) (string, error) {
+	var configMap struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := c.Get(ctx, fmt.Sprintf("/api/v1/namespaces/%s/configmaps/%s", url.PathEscape(namespace), url.PathEscape(name)), &configMap); err != nil {
+		return "", fmt.Errorf("could not read config map %s/%s: %w", namespace, name, err)
+	}
+
+	value, ok := configMap.Data[key]
+	if !ok {
+		return "", fmt.Errorf("config map %s/%s has no key %s", namespace, name, key)
+	}
+
+	return value, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.server+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// the API returns a Status object explaining the failure
+		var status struct {
+			Message string `json:"message"`
+		}
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(b, &status) != nil || status.Message == "" {
+			status.Message = strings.TrimSpace(string(b))
+		}
+		return &StatusError{Code: resp.StatusCode, Message: status.Message}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Error returns the status code and message of the API response.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("kubernetes api responded %d: %s", e.Code, e.Message)
+}
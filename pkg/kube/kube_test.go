@@ -0,0 +1,173 @@
+package kube
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientAuth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+	}{
+		{name: "bearer token", token: "secret", authorization: "Bearer secret"},
+		{name: "no token", token: "", authorization: ""},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, test.authorization, r.Header.Get("Authorization"))
+				assert.Equal(t, "application/json", r.Header.Get("Accept"))
+				assert.Equal(t, "/api/v1/namespaces/staging", r.URL.Path)
+				_, _ = io.WriteString(w, `{"name":"staging","generation":2}`)
+			}))
+			defer server.Close()
+
+			var meta ObjectMeta
+			require.NoError(t, NewClient(server.URL+"/", test.token, nil).Get(context.Background(), "/api/v1/namespaces/staging", &meta))
+			assert.Equal(t, ObjectMeta{Name: "staging", Generation: 2}, meta)
+		})
+	}
+}
+
+func TestClientErrorResponses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		code     int
+		body     string
+		expected string
+	}{
+		{
+			name:     "status",
+			code:     http.StatusForbidden,
+			body:     `{"kind":"Status","message":"secrets \"db\" is forbidden"}`,
+			expected: `kubernetes api responded 403: secrets "db" is forbidden`,
+		},
+		{
+			name:     "text",
+			code:     http.StatusBadGateway,
+			body:     "upstream unavailable\n",
+			expected: "kubernetes api responded 502: upstream unavailable",
+		},
+		{
+			name:     "status without message",
+			code:     http.StatusUnauthorized,
+			body:     `{"kind":"Status"}`,
+			expected: `kubernetes api responded 401: {"kind":"Status"}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.code)
+				_, _ = io.WriteString(w, test.body)
+			}))
+			defer server.Close()
+
+			err := NewClient(server.URL, "token", nil).Get(context.Background(), "/api/v1/namespaces/staging", &ObjectMeta{})
+			assert.EqualError(t, err, test.expected)
+
+			var statusErr *StatusError
+			require.True(t, errors.As(err, &statusErr))
+			assert.Equal(t, test.code, statusErr.Code)
+		})
+	}
+}
+
+func TestClientMergePatch(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "/apis/klepto.hellofresh.com/v1alpha1/namespaces/staging/databaserefreshes/shop/status", r.URL.Path)
+		assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"status":{"phase":"Succeeded"}}`, string(body))
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+
+	patch := map[string]interface{}{"status": map[string]string{"phase": "Succeeded"}}
+	require.NoError(t, NewClient(server.URL, "token", nil).MergePatch(
+		context.Background(),
+		"/apis/klepto.hellofresh.com/v1alpha1/namespaces/staging/databaserefreshes/shop/status",
+		patch,
+	))
+}
+
+func TestClientSecretValue(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/staging/secrets/db":
+			_, _ = io.WriteString(w, `{"data":{"dsn":"`+base64.StdEncoding.EncodeToString([]byte("mysql://root@db/shop"))+`","bad":"%%%"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"message":"not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token", nil)
+	ctx := context.Background()
+
+	value, err := c.SecretValue(ctx, "staging", "db", "dsn")
+	require.NoError(t, err)
+	assert.Equal(t, "mysql://root@db/shop", value)
+
+	_, err = c.SecretValue(ctx, "staging", "db", "password")
+	assert.EqualError(t, err, "secret staging/db has no key password")
+	_, err = c.SecretValue(ctx, "staging", "db", "bad")
+	assert.Error(t, err)
+	_, err = c.SecretValue(ctx, "staging", "other", "dsn")
+	assert.EqualError(t, err, "could not read secret staging/other: kubernetes api responded 404: not found")
+}
+
+func TestClientConfigMapValue(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/namespaces/staging/configmaps/klepto", r.URL.Path)
+		_, _ = io.WriteString(w, `{"data":{".klepto.toml":"[[Tables]]"}}`)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token", nil)
+	value, err := c.ConfigMapValue(context.Background(), "staging", "klepto", ".klepto.toml")
+	require.NoError(t, err)
+	assert.Equal(t, "[[Tables]]", value)
+
+	_, err = c.ConfigMapValue(context.Background(), "staging", "klepto", "missing")
+	assert.EqualError(t, err, "config map staging/klepto has no key missing")
+}
+
+func TestNewInClusterClientOutsideCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "")
+
+	_, err := NewInClusterClient()
+	assert.Equal(t, ErrNotInCluster, err)
+}
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/deadline"
+	"github.com/hellofresh/klepto/pkg/stats"
+)
+
+// Event kinds.
+const (
+	Start   = "start"
+	Success = "success"
+	Failure = "failure"
+)
+
+// requestTimeout is the maximum time spent sending a notification.
+const requestTimeout = 10 * time.Second
+
+type (
+	// Event is a notification about a run.
+	Event struct {
+		// Kind is either start, success or failure.
+		Kind string `json:"kind"`
+		// Command is the klepto command that was run.
+		Command string `json:"command"`
+		// Duration is the run duration, once finished.
+		Duration time.Duration `json:"-"`
+		// Error is the error that failed the run.
+		Error string `json:"error,omitempty"`
+		// Report lists the tables of the run by state, once finished.
+		Report *deadline.Report `json:"report,omitempty"`
+		// Columns summarises the columns of the dumped tables, once finished and when enabled.
+		Columns stats.Report `json:"columns,omitempty"`
+		// Profiles are the outcomes of the profiles of a run stealing several profiles, once finished.
+		Profiles []ProfileReport `json:"profiles,omitempty"`
+	}
+
+	// ProfileReport is the outcome of a profile of a run stealing several profiles.
+	ProfileReport struct {
+		Name string `json:"name"`
+		// Error is the error that failed the profile.
+		Error string `json:"error,omitempty"`
+		// Report lists the tables of the profile by state, once it read them.
+		Report *deadline.Report `json:"report,omitempty"`
+		// Columns summarises the columns of the dumped tables of the profile, when enabled.
+		Columns stats.Report `json:"columns,omitempty"`
+	}
+
+	// Notifier sends run notifications.
+	Notifier interface {
+		Notify(Event) error
+	}
+
+	// Notifiers sends notifications to several notifiers, failures are only logged.
+	Notifiers []Notifier
+
+	webhook struct {
+		client *http.Client
+		url    string
+		encode func(Event) interface{}
+	}
+
+	directory struct {
+		path string
+		now  func() time.Time
+	}
+)
+
+// NewWebhook returns a notifier posting the events as JSON to the url.
+func NewWebhook(url string) Notifier {
+	return &webhook{client: &http.Client{Timeout: requestTimeout}, url: url, encode: func(e Event) interface{} { return e }}
+}
+
+// NewSlack returns a notifier posting the events as messages to a Slack incoming webhook url.
+func NewSlack(url string) Notifier {
+	return &webhook{client: &http.Client{Timeout: requestTimeout}, url: url, encode: slackMessage}
+}
+
+// NewDirectory returns a notifier writing a JSON report of every finished run to the directory,
+// named after the command and the run start time, e.g. steal-20210303T030000Z.json.
+func NewDirectory(path string) Notifier {
+	return &directory{path: path, now: time.Now}
+}
+
+// MarshalJSON encodes the event with a readable duration, e.g. "1m30s".
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	var duration string
+	if e.Duration > 0 {
+		duration = e.Duration.Round(time.Second).String()
+	}
+
+	return json.Marshal(struct {
+		event
+		Duration string `json:"duration,omitempty"`
+	}{event(e), duration})
+}
+
+// Send sends the event to all the notifiers.
+func (n Notifiers) Send(e Event) {
+	for _, notifier := range n {
+		if err := notifier.Notify(e); err != nil {
+			log.WithError(err).WithField("event", e.Kind).Warn("Failed to send notification")
+		}
+	}
+}
+
+// Notify posts the event.
+func (w *webhook) Notify(e Event) error {
+	body, err := json.Marshal(w.encode(e))
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notification endpoint responded %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+// Notify writes the report of a finished run.
+func (d *directory) Notify(e Event) error {
+	if e.Kind == Start {
+		return nil
+	}
+
+	body, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.MkdirAll(d.path, 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	start := d.now().Add(-e.Duration).UTC()
+	name := filepath.Join(d.path, fmt.Sprintf("%s-%s.json", e.Command, start.Format("20060102T150405Z")))
+	if err := os.WriteFile(name, append(body, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+// slackMessage formats the event as a Slack message.
+func slackMessage(e Event) interface{} {
+	var b strings.Builder
+	switch e.Kind {
+	case Start:
+		fmt.Fprintf(&b, ":hourglass_flowing_sand: klepto %s started", e.Command)
+	case Success:
+		fmt.Fprintf(&b, ":white_check_mark: klepto %s succeeded in %s", e.Command, e.Duration.Round(time.Second))
+	default:
+		fmt.Fprintf(&b, ":x: klepto %s failed after %s: %s", e.Command, e.Duration.Round(time.Second), e.Error)
+	}
+
+	for _, profile := range e.Profiles {
+		if profile.Error != "" {
+			fmt.Fprintf(&b, "\n:x: *%s*: %s", profile.Name, profile.Error)
+		} else {
+			fmt.Fprintf(&b, "\n:white_check_mark: *%s*", profile.Name)
+		}
+		if r := profile.Report; r != nil {
+			fmt.Fprintf(&b, " (%d tables done)", len(r.Done))
+		}
+	}
+
+	if r := e.Report; r != nil {
+		for _, tables := range []struct {
+			state  string
+			tables []string
+		}{
+			{"Done", r.Done},
+			{"Reading", r.Reading},
+			{"Failed", r.Failed},
+			{"Timed out", r.TimedOut},
+			{"Pending", r.Pending},
+		} {
+			if len(tables.tables) > 0 {
+				fmt.Fprintf(&b, "\n*%s* (%d): %s", tables.state, len(tables.tables), strings.Join(tables.tables, ", "))
+			}
+		}
+	}
+
+	return struct {
+		Text string `json:"text"`
+	}{b.String()}
+}
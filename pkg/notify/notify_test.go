@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/deadline"
+	"github.com/hellofresh/klepto/pkg/stats"
+)
+
+func TestWebhook(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+
+	event := Event{
+		Kind:     Failure,
+		Command:  "steal",
+		Duration: 90*time.Second + 300*time.Millisecond,
+		Error:    "tables timed out: events",
+		Report:   &deadline.Report{Done: []string{"orders", "users"}, TimedOut: []string{"events"}},
+	}
+
+	require.NoError(t, NewWebhook(server.URL).Notify(Event{Kind: Start, Command: "steal"}))
+	require.NoError(t, NewWebhook(server.URL).Notify(event))
+	require.NoError(t, NewSlack(server.URL).Notify(event))
+
+	require.Len(t, bodies, 3)
+	assert.JSONEq(t, `{"kind":"start","command":"steal"}`, bodies[0])
+	assert.JSONEq(t, `{
+		"kind": "failure",
+		"command": "steal",
+		"duration": "1m30s",
+		"error": "tables timed out: events",
+		"report": {"done": ["orders", "users"], "timed_out": ["events"]}
+	}`, bodies[1])
+
+	var message struct{ Text string }
+	require.NoError(t, json.Unmarshal([]byte(bodies[2]), &message))
+	assert.Equal(t, ":x: klepto steal failed after 1m30s: tables timed out: events\n*Done* (2): orders, users\n*Timed out* (1): events", message.Text)
+}
+
+func TestWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := NewSlack(server.URL).Notify(Event{Kind: Success, Command: "steal"})
+	assert.EqualError(t, err, "notification endpoint responded 403 Forbidden: invalid_token")
+
+	// failures are only logged
+	Notifiers{NewSlack(server.URL)}.Send(Event{Kind: Success, Command: "steal"})
+}
+
+func TestSlackProfiles(t *testing.T) {
+	message := slackMessage(Event{
+		Kind:     Failure,
+		Command:  "steal",
+		Duration: time.Minute,
+		Error:    "1 of 2 profiles failed: audit",
+		Profiles: []ProfileReport{
+			{Name: "audit", Error: "could not connect"},
+			{Name: "orders", Report: &deadline.Report{Done: []string{"orders", "items"}}},
+		},
+	})
+
+	assert.Equal(t, struct {
+		Text string `json:"text"`
+	}{":x: klepto steal failed after 1m0s: 1 of 2 profiles failed: audit\n:x: *audit*: could not connect\n:white_check_mark: *orders* (2 tables done)"}, message)
+}
+
+func TestDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	notifier := NewDirectory(dir)
+	notifier.(*directory).now = func() time.Time { return time.Date(2021, time.March, 3, 3, 1, 30, 0, time.UTC) }
+
+	require.NoError(t, notifier.Notify(Event{Kind: Start, Command: "steal"}))
+	require.NoError(t, notifier.Notify(Event{
+		Kind:     Success,
+		Command:  "steal",
+		Duration: 90 * time.Second,
+		Report:   &deadline.Report{Done: []string{"orders", "users"}},
+		Columns:  stats.Report{"users": {"id": {Rows: 2, Distinct: 2, Min: "1", Max: "2"}}},
+	}))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "only the finished runs are reported")
+	assert.Equal(t, "steal-20210303T030000Z.json", entries[0].Name())
+
+	body, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"kind":"success","command":"steal","duration":"1m30s","report":{"done":["orders","users"]},`+
+		`"columns":{"users":{"id":{"rows":2,"nulls":0,"distinct":2,"min":"1","max":"2"}}}}`, string(body))
+}
@@ -0,0 +1,96 @@
+package ordering
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/integrity"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type orderingReader struct {
+	reader.Reader
+	tables []string
+}
+
+// NewReader returns a reader listing the tables in alphabetical order, parents first, and reading
+// the rows of each table ordered by primary key, so that two dumps of the same data are identical.
+// The foreign keys are read from schema when it knows them (see reader.ForeignKeyer).
+func NewReader(source reader.Reader, schema reader.Reader, cfgTables config.Tables) (reader.Reader, error) {
+	tables, err := source.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("ordering: could not get tables: %w", err)
+	}
+
+	foreignKeys, err := integrity.ForeignKeys(schema, cfgTables, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]string, len(tables))
+	copy(sorted, tables)
+	sort.Strings(sorted)
+
+	return &orderingReader{Reader: source, tables: ParentsFirst(sorted, foreignKeys)}, nil
+}
+
+// GetTables returns the tables in alphabetical order, the referenced tables coming first.
+func (r *orderingReader) GetTables() ([]string, error) {
+	return r.tables, nil
+}
+
+// ReadTable reads the rows ordered by primary key, after the sorts of the table.
+func (r *orderingReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	opts.KeyOrder = true
+	return r.Reader.ReadTable(tableName, rowChan, opts)
+}
+
+// ParentsFirst orders the tables so that the tables referenced by another table come before it,
+// keeping the given order otherwise. Tables referencing each other keep their order at the end.
+func ParentsFirst(tables []string, foreignKeys []reader.ForeignKey) []string {
+	parents := make(map[string][]string)
+	for _, fk := range foreignKeys {
+		if fk.Table != fk.ReferencedTable {
+			parents[fk.Table] = append(parents[fk.Table], fk.ReferencedTable)
+		}
+	}
+
+	ordered := make([]string, 0, len(tables))
+	placed := make(map[string]bool, len(tables))
+	ready := func(table string) bool {
+		for _, parent := range parents[table] {
+			if !placed[parent] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for len(ordered) < len(tables) {
+		// place the first ready table, so the given order is kept as much as possible
+		progress := false
+		for _, table := range tables {
+			if placed[table] || !ready(table) {
+				continue
+			}
+			ordered = append(ordered, table)
+			placed[table] = true
+			progress = true
+			break
+		}
+
+		if !progress {
+			// reference cycle, the remaining tables keep their order
+			for _, table := range tables {
+				if !placed[table] {
+					ordered = append(ordered, table)
+					placed[table] = true
+				}
+			}
+		}
+	}
+
+	return ordered
+}
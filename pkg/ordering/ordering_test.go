@@ -0,0 +1,71 @@
+package ordering
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type mockReader struct {
+	reader.Reader
+	opts reader.ReadTableOpt
+}
+
+func (m *mockReader) GetTables() ([]string, error) {
+	return []string{"users", "orders", "countries", "addresses"}, nil
+}
+
+func (m *mockReader) GetForeignKeys() ([]reader.ForeignKey, error) {
+	return []reader.ForeignKey{
+		{Table: "addresses", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "users", Column: "manager_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}, nil
+}
+
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	m.opts = opts
+	return nil
+}
+
+func TestGetTables(t *testing.T) {
+	cfgTables := config.Tables{
+		{
+			Name: "orders",
+			Relationships: []*config.Relationship{
+				{ForeignKey: "country_id", ReferencedTable: "countries", ReferencedKey: "id"},
+				{ForeignKey: "address_id", ReferencedTable: "addresses", ReferencedKey: "id"},
+			},
+		},
+	}
+
+	r, err := NewReader(&mockReader{}, &mockReader{}, cfgTables)
+	require.NoError(t, err)
+
+	tables, err := r.GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"countries", "users", "addresses", "orders"}, tables)
+}
+
+func TestReadTable(t *testing.T) {
+	source := &mockReader{}
+	r, err := NewReader(source, source, nil)
+	require.NoError(t, err)
+
+	rowChan := make(chan database.Row)
+	require.NoError(t, r.ReadTable("users", rowChan, reader.ReadTableOpt{Limit: 10}))
+	assert.Equal(t, reader.ReadTableOpt{Limit: 10, KeyOrder: true}, source.opts)
+}
+
+func TestParentsFirstCycle(t *testing.T) {
+	fks := []reader.ForeignKey{
+		{Table: "a", Column: "b_id", ReferencedTable: "b", ReferencedColumn: "id"},
+		{Table: "b", Column: "a_id", ReferencedTable: "a", ReferencedColumn: "id"},
+	}
+	assert.Equal(t, []string{"c", "a", "b"}, ParentsFirst([]string{"a", "b", "c"}, fks))
+}
@@ -0,0 +1,80 @@
+// Package output opens the writers the dumps are written to. The output sinks are looked up by the scheme of the
+// output url, so that new sinks (e.g. object storages) can be registered without changing the dumpers.
+package output
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var sinks sync.Map
+
+// Sink opens the writers of the output urls of its scheme.
+type Sink interface {
+	// Open returns the writer of the output url, the output is complete once the writer is closed.
+	Open(u *url.URL) (io.WriteCloser, error)
+}
+
+// Register makes an output sink available for the urls of the provided scheme.
+// If Register is called twice with the same scheme or if sink is nil,
+// it panics.
+func Register(scheme string, sink Sink) {
+	if sink == nil {
+		log.Fatal("output: Register sink is nil")
+	}
+	if _, dup := sinks.Load(scheme); dup {
+		log.Fatalf("output: Register called twice for sink %s", scheme)
+	}
+	sinks.Store(scheme, sink)
+}
+
+// Schemes returns a sorted list of the schemes of the registered sinks.
+func Schemes() []string {
+	var list []string
+
+	sinks.Range(func(key, value interface{}) bool {
+		scheme, ok := key.(string)
+		if ok {
+			list = append(list, scheme)
+		}
+		return true
+	})
+
+	sort.Strings(list)
+	return list
+}
+
+// IsSupported tells whether a sink is registered for the scheme of the output url.
+func IsSupported(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	_, ok := sinks.Load(u.Scheme)
+	return ok
+}
+
+// Open returns the writer of the output url, opened by the sink registered for its scheme.
+func Open(rawURL string) (io.WriteCloser, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output url: %w", err)
+	}
+
+	value, ok := sinks.Load(u.Scheme)
+	if !ok {
+		return nil, fmt.Errorf("no output sink registered for %q urls", u.Scheme)
+	}
+
+	w, err := value.(Sink).Open(u)
+	if err != nil {
+		return nil, fmt.Errorf("could not open output %q: %w", rawURL, err)
+	}
+
+	return w, nil
+}
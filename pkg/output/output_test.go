@@ -0,0 +1,64 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	w, err := Open("os://stdout/")
+	require.NoError(t, err)
+	assert.Equal(t, os.Stdout, w)
+
+	_, err = Open("os://stdin/")
+	assert.EqualError(t, err, `could not open output "os://stdin/": unknown stream "stdin", supported streams are stdout and stderr`)
+
+	_, err = Open("s3://bucket/dump.sql")
+	assert.EqualError(t, err, `no output sink registered for "s3" urls`)
+	assert.False(t, IsSupported("s3://bucket/dump.sql"))
+	assert.False(t, IsSupported("user:pass@tcp(localhost:3306)/db"))
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dumps", "dump.sql")
+
+	w, err := Open("file://" + path)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, "SELECT 1;\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;\n", string(content))
+}
+
+func TestRegister(t *testing.T) {
+	var output memorySink
+	Register("memory", &output)
+	defer sinks.Delete("memory")
+
+	assert.Contains(t, Schemes(), "memory")
+	assert.True(t, IsSupported("memory://dump"))
+
+	w, err := Open("memory://dump")
+	require.NoError(t, err)
+	_, err = io.WriteString(w, "SELECT 1;\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, "SELECT 1;\n", output.String())
+}
+
+type memorySink struct {
+	bytes.Buffer
+}
+
+func (s *memorySink) Open(*url.URL) (io.WriteCloser, error) { return s, nil }
+func (s *memorySink) Close() error                          { return nil }
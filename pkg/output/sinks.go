@@ -0,0 +1,46 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+type (
+	// osSink writes to the standard streams of the process, e.g. os://stdout/.
+	osSink struct{}
+	// fileSink writes to a local file, e.g. file:///var/dumps/dump.sql or file://dump.sql relative to the working directory.
+	fileSink struct{}
+)
+
+func (s *osSink) Open(u *url.URL) (io.WriteCloser, error) {
+	switch u.Host {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		return nil, fmt.Errorf("unknown stream %q, supported streams are stdout and stderr", u.Host)
+	}
+}
+
+func (s *fileSink) Open(u *url.URL) (io.WriteCloser, error) {
+	path := u.Host + u.Path
+	if path == "" {
+		return nil, errors.New("no file path given")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	return os.Create(path)
+}
+
+func init() {
+	Register("os", &osSink{})
+	Register("file", &fileSink{})
+}
@@ -0,0 +1,266 @@
+// Package ownership strips, keeps or renames the owners, privileges and roles of the dumped structure, so that a dump
+// restores on a target that does not have the roles of the source.
+package ownership
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// Modes of handling the owners or the grants of the structure.
+const (
+	// Strip leaves them out of the structure.
+	Strip Mode = "strip"
+	// Keep keeps them, their roles being renamed by the role map.
+	Keep Mode = "keep"
+)
+
+// roleKeywords are the keywords followed by a role, or a list of roles, in the owner, grant and role statements.
+var roleKeywords = map[string]bool{
+	"TO":            true,
+	"FROM":          true,
+	"ROLE":          true,
+	"USER":          true,
+	"AUTHORIZATION": true,
+	"GRANT":         true,
+	"REVOKE":        true,
+	"DEFINER":       true,
+}
+
+// definer matches the DEFINER clauses of the MySQL views, triggers, routines and events, e.g. DEFINER=`app`@`%`.
+var definer = regexp.MustCompile("(?i)\\bDEFINER\\s*=\\s*(?:`[^`]*`|'[^']*'|\"[^\"]*\"|[\\w$.]+(?:\\(\\))?)" +
+	"(?:\\s*@\\s*(?:`[^`]*`|'[^']*'|\"[^\"]*\"|[\\w$.%-]+))?\\s*")
+
+type (
+	// Mode is the way the owners or the grants of the structure are handled.
+	Mode string
+
+	// Options are the way the owners, grants and roles of the structure are handled.
+	Options struct {
+		// Owners handles the statements changing the owner of an object, e.g. ALTER TABLE ... OWNER TO, and the
+		// DEFINER clauses of the MySQL views, triggers and routines.
+		Owners Mode
+		// Grants handles the GRANT, REVOKE and ALTER DEFAULT PRIVILEGES statements.
+		Grants Mode
+		// Roles keeps the statements creating, altering and dropping roles and users.
+		Roles bool
+		// RoleMap renames the roles of the kept statements, from the source role to the target one.
+		RoleMap map[string]string
+	}
+
+	ownershipReader struct {
+		reader.Reader
+		opts Options
+	}
+)
+
+// ParseMode parses an owners or grants mode.
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(s)); mode {
+	case Strip, Keep:
+		return mode, nil
+	}
+
+	return "", fmt.Errorf("unknown mode %q, expected strip or keep", s)
+}
+
+// ParseRoleMap parses source=target role pairs.
+func ParseRoleMap(pairs []string) (map[string]string, error) {
+	roles := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected source=target", pair)
+		}
+		roles[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return roles, nil
+}
+
+// NewReader returns a reader whose structure has its owners, grants and roles handled according to the options.
+func NewReader(source reader.Reader, opts Options) reader.Reader {
+	return &ownershipReader{Reader: source, opts: opts}
+}
+
+// GetStructure returns the rewritten structure.
+func (r *ownershipReader) GetStructure() (string, error) {
+	structure, err := r.Reader.GetStructure()
+	if err != nil {
+		return "", err
+	}
+
+	return Rewrite(structure, r.opts), nil
+}
+
+// GetStructureSections returns the rewritten sections of the structure.
+func (r *ownershipReader) GetStructureSections() (string, string, error) {
+	preData, postData, err := reader.GetStructureSections(r.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return Rewrite(preData, r.opts), Rewrite(postData, r.opts), nil
+}
+
+// Rewrite returns the structure with its owners, grants and roles handled according to the options. The statements
+// that are left out are left out with the comments preceding them.
+func Rewrite(structure string, opts Options) string {
+	statements := reader.SplitStatements(structure)
+	kept := make([]string, 0, len(statements))
+	for _, statement := range statements {
+		keep := true
+		switch words := reader.Keywords(statement); {
+		case isRole(words):
+			keep = opts.Roles
+		case isGrant(words):
+			keep = opts.Grants == Keep
+		case isOwner(words):
+			keep = opts.Owners == Keep
+		default:
+			kept = append(kept, definer.ReplaceAllStringFunc(statement, func(clause string) string {
+				if opts.Owners != Keep {
+					return ""
+				}
+				return renameRoles(clause, opts.RoleMap)
+			}))
+			continue
+		}
+
+		if keep {
+			kept = append(kept, renameRoles(statement, opts.RoleMap))
+		}
+	}
+
+	return strings.Join(kept, ";")
+}
+
+// isRole reports whether the statement creates, alters or drops a role or a user.
+func isRole(words []string) bool {
+	return len(words) > 1 && (words[0] == "CREATE" || words[0] == "ALTER" || words[0] == "DROP") &&
+		(words[1] == "ROLE" || words[1] == "USER")
+}
+
+// isGrant reports whether the statement grants or revokes privileges or roles.
+func isGrant(words []string) bool {
+	return len(words) > 0 && (words[0] == "GRANT" || words[0] == "REVOKE") ||
+		len(words) > 2 && words[0] == "ALTER" && words[1] == "DEFAULT" && words[2] == "PRIVILEGES"
+}
+
+// isOwner reports whether the statement changes the owner of an object or the role of the session running the
+// following statements.
+func isOwner(words []string) bool {
+	if len(words) > 1 && (words[0] == "SET" || words[0] == "RESET") && (words[1] == "ROLE" || words[1] == "SESSION") {
+		return true
+	}
+	if len(words) == 0 || words[0] != "ALTER" {
+		return false
+	}
+	for i := 1; i < len(words)-1; i++ {
+		if words[i] == "OWNER" && words[i+1] == "TO" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renameRoles renames the roles of the statement found in the role map, the names, quoted or not, following the
+// roleKeywords and the names listed after them. The host of a MySQL account, e.g. 'app'@'%', is kept. GRANT and REVOKE
+// are followed by roles unless privileges on objects are granted.
+func renameRoles(statement string, roles map[string]string) string {
+	if len(roles) == 0 {
+		return statement
+	}
+
+	privileges := false
+	for _, word := range reader.Keywords(statement) {
+		privileges = privileges || word == "ON"
+	}
+	var (
+		out strings.Builder
+		// expect is true when a role comes next, role when the last token was one, host when a host comes next
+		expect, role, host bool
+	)
+	for i := 0; i < len(statement); {
+		c := statement[i]
+		end := i + 1
+		switch {
+		case c == '-' && strings.HasPrefix(statement[i:], "--"):
+			end = len(statement)
+			if n := strings.IndexByte(statement[i:], '\n'); n >= 0 {
+				end = i + n
+			}
+			out.WriteString(statement[i:end])
+			i = end
+			continue
+		case c == '/' && strings.HasPrefix(statement[i:], "/*"):
+			end = len(statement)
+			if n := strings.Index(statement[i+2:], "*/"); n >= 0 {
+				end = i + 2 + n + 2
+			}
+			out.WriteString(statement[i:end])
+			i = end
+			continue
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			out.WriteByte(c)
+			i++
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			end = len(statement)
+			if n := strings.IndexByte(statement[i+1:], c); n >= 0 {
+				end = i + 1 + n + 1
+			}
+		case isWordByte(c):
+			for end < len(statement) && isWordByte(statement[end]) {
+				end++
+			}
+		}
+
+		token := statement[i:end]
+		i = end
+		switch {
+		case c == ',':
+			expect = role
+			role = false
+		case c == '@':
+			host = role
+			role = false
+		case c == '=' && expect:
+		case !isWordByte(c) && c != '\'' && c != '"' && c != '`':
+			expect, role, host = false, false, false
+		case host:
+			host, role = false, true
+		case expect:
+			token = rename(token, roles)
+			expect, role = false, true
+		default:
+			word := strings.ToUpper(token)
+			expect = roleKeywords[word] && isWordByte(c) && !(privileges && (word == "GRANT" || word == "REVOKE"))
+			role = false
+		}
+		out.WriteString(token)
+	}
+
+	return out.String()
+}
+
+// rename renames a role found in the role map, keeping its quotes.
+func rename(token string, roles map[string]string) string {
+	name, quote := token, ""
+	if len(token) >= 2 && (token[0] == '\'' || token[0] == '"' || token[0] == '`') && token[len(token)-1] == token[0] {
+		name, quote = token[1:len(token)-1], token[:1]
+	}
+	if renamed, ok := roles[name]; ok {
+		return quote + renamed + quote
+	}
+
+	return token
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,103 @@
+package ownership
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+const pgStructure = "CREATE ROLE app;\n" +
+	"SET SESSION AUTHORIZATION app;\n" +
+	"CREATE TABLE public.users (id int);\n" +
+	"ALTER TABLE public.users OWNER TO app;\n" +
+	"-- privileges\nGRANT SELECT, INSERT ON public.users TO app, \"Reporting\";\n" +
+	"REVOKE ALL ON public.users FROM PUBLIC;\n" +
+	"GRANT app TO reporting;\n" +
+	"ALTER DEFAULT PRIVILEGES FOR ROLE app GRANT SELECT ON TABLES TO reporting;\n"
+
+const mysqlStructure = "CREATE TABLE `users` (id int);\n" +
+	"CREATE ALGORITHM=UNDEFINED DEFINER=`app`@`%` SQL SECURITY DEFINER VIEW `active` AS SELECT id FROM users;\n" +
+	"/*!50003 CREATE*/ /*!50017 DEFINER=`app`@`localhost`*/ /*!50003 TRIGGER t BEFORE INSERT ON users FOR EACH ROW SET @a = 1 */;\n"
+
+type mockReader struct {
+	reader.Reader
+}
+
+func (m *mockReader) GetStructure() (string, error) {
+	return pgStructure, nil
+}
+
+func TestRewriteStrip(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "\nCREATE TABLE public.users (id int);\n", Rewrite(pgStructure, Options{Owners: Strip, Grants: Strip}))
+	assert.Equal(t, "CREATE TABLE `users` (id int);\n"+
+		"CREATE ALGORITHM=UNDEFINED SQL SECURITY DEFINER VIEW `active` AS SELECT id FROM users;\n"+
+		"/*!50003 CREATE*/ /*!50017 */ /*!50003 TRIGGER t BEFORE INSERT ON users FOR EACH ROW SET @a = 1 */;\n",
+		Rewrite(mysqlStructure, Options{Owners: Strip, Grants: Strip}))
+}
+
+func TestRewriteKeep(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, pgStructure, Rewrite(pgStructure, Options{Owners: Keep, Grants: Keep, Roles: true}))
+	assert.Equal(t, mysqlStructure, Rewrite(mysqlStructure, Options{Owners: Keep, Grants: Keep}))
+
+	assert.Equal(t, "\nSET SESSION AUTHORIZATION app;\n"+
+		"CREATE TABLE public.users (id int);\n"+
+		"ALTER TABLE public.users OWNER TO app;\n",
+		Rewrite(pgStructure, Options{Owners: Keep, Grants: Strip}))
+}
+
+func TestRewriteRoleMap(t *testing.T) {
+	t.Parallel()
+
+	roles := map[string]string{"app": "staging_app", "Reporting": "analyst", "SELECT": "unused"}
+	assert.Equal(t, "CREATE ROLE staging_app;\n"+
+		"SET SESSION AUTHORIZATION staging_app;\n"+
+		"CREATE TABLE public.users (id int);\n"+
+		"ALTER TABLE public.users OWNER TO staging_app;\n"+
+		"-- privileges\nGRANT SELECT, INSERT ON public.users TO staging_app, \"analyst\";\n"+
+		"REVOKE ALL ON public.users FROM PUBLIC;\n"+
+		"GRANT staging_app TO reporting;\n"+
+		"ALTER DEFAULT PRIVILEGES FOR ROLE staging_app GRANT SELECT ON TABLES TO reporting;\n",
+		Rewrite(pgStructure, Options{Owners: Keep, Grants: Keep, Roles: true, RoleMap: roles}))
+
+	assert.Equal(t, "CREATE TABLE `users` (id int);\n"+
+		"CREATE ALGORITHM=UNDEFINED DEFINER=`staging_app`@`%` SQL SECURITY DEFINER VIEW `active` AS SELECT id FROM users;\n"+
+		"/*!50003 CREATE*/ /*!50017 DEFINER=`staging_app`@`localhost`*/ /*!50003 TRIGGER t BEFORE INSERT ON users FOR EACH ROW SET @a = 1 */;\n",
+		Rewrite(mysqlStructure, Options{Owners: Keep, Grants: Keep, RoleMap: roles}))
+}
+
+func TestNewReader(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(&mockReader{}, Options{Owners: Strip, Grants: Strip})
+	structure, err := r.GetStructure()
+	require.NoError(t, err)
+	assert.Equal(t, "\nCREATE TABLE public.users (id int);\n", structure)
+
+	preData, postData, err := reader.GetStructureSections(r)
+	require.NoError(t, err)
+	assert.Equal(t, "\nCREATE TABLE public.users (id int);\n", preData)
+	assert.Empty(t, postData)
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	mode, err := ParseMode("Keep")
+	require.NoError(t, err)
+	assert.Equal(t, Keep, mode)
+	_, err = ParseMode("remap")
+	assert.Error(t, err)
+
+	roles, err := ParseRoleMap([]string{"app=staging_app", " admin = staging_admin "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "staging_app", "admin": "staging_admin"}, roles)
+	_, err = ParseRoleMap([]string{"app"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package paging
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/keyless"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type pager struct {
+	reader.Reader
+	// schema knows the primary keys the tables are paginated by, nil to paginate them by offset.
+	schema reader.Reader
+	tables config.Tables
+	// budget is the amount of bytes read per page, 0 for pages of PageSize rows.
+	budget int64
+}
+
+// NewReader returns a reader reading the tables with a PageSize with one query per page of rows,
+// so giant tables do not hold a single long running query on the source.
+func NewReader(source reader.Reader, tables config.Tables) reader.Reader {
+	return &pager{Reader: source, tables: tables}
+}
+
+// NewAdaptiveReader returns a reader like NewReader whose pages are sized to about budget bytes from the average
+// width of the rows read, the PageSize of a table being the size of its first page.
+// The tables without sorts whose primary key schema knows (see reader.PrimaryKeyer) are paginated by key, each
+// page starting after the last key read, so that the pages deep into a table are as cheap as the first ones.
+// The tables without a primary key read by physical position (see keyless.Physical) are paginated by the pages
+// schema returns (see reader.PhysicalPager).
+func NewAdaptiveReader(source reader.Reader, schema reader.Reader, tables config.Tables, budget int64) reader.Reader {
+	return &pager{Reader: source, schema: schema, tables: tables, budget: budget}
+}
+
+// ReadTable reads the table page by page, until a page is not complete or the limit is reached.
+func (p *pager) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	table := p.tables.FindByName(tableName)
+	if table == nil || table.PageSize == 0 {
+		return p.Reader.ReadTable(tableName, rowChan, opts)
+	}
+	defer close(rowChan)
+
+	logger := log.WithFields(log.Fields{"table": tableName, "page_size": table.PageSize})
+	key := p.primaryKey(tableName, opts)
+	if key == nil && keyless.Strategy(table.NoPrimaryKey) == keyless.Physical && p.unordered(opts) {
+		return p.readPhysicalPages(tableName, rowChan, opts, table.PageSize, logger)
+	}
+	if len(opts.Sorts) == 0 && key == nil {
+		logger.Warn("the table is paginated without sorts, rows may be read twice or skipped if the source order changes")
+	}
+
+	sizer := database.NewBatchSizer(p.budget, table.PageSize)
+	var (
+		read  uint64
+		after []interface{}
+	)
+	for {
+		page := opts
+		page.Limit = sizer.Size()
+		if opts.Limit > 0 && opts.Limit-read < page.Limit {
+			page.Limit = opts.Limit - read
+		}
+		if key != nil {
+			page.KeyOrder, page.KeyAfter = true, after
+			logger.WithFields(log.Fields{"after": after, "limit": page.Limit}).Debug("reading page")
+		} else {
+			page.Offset = opts.Offset + read
+			logger.WithFields(log.Fields{"offset": page.Offset, "limit": page.Limit}).Debug("reading page")
+		}
+
+		n, last, keyed, err := p.readPage(tableName, rowChan, page, sizer, key)
+		read += n
+		if err != nil {
+			return err
+		}
+		if n < page.Limit || (opts.Limit > 0 && read >= opts.Limit) {
+			return nil
+		}
+
+		if key != nil {
+			if after = last; !keyed {
+				// the rows are still ordered by key, the next pages are read by offset
+				logger.Warn("the primary key of the table is not read, the next pages are read by offset")
+				key, opts.KeyOrder = nil, true
+			}
+		}
+	}
+}
+
+// readPhysicalPages reads the table page by page of physical positions, until the last page or the limit is reached.
+func (p *pager) readPhysicalPages(
+	tableName string,
+	rowChan chan<- database.Row,
+	opts reader.ReadTableOpt,
+	pageSize uint64,
+	logger log.FieldLogger,
+) error {
+	pager, ok := p.schema.(reader.PhysicalPager)
+	if !ok {
+		return fmt.Errorf("paging: could not read %s by physical position: %w", tableName, reader.ErrPhysicalPagesUnsupported)
+	}
+	conditions, err := pager.GetPhysicalPages(tableName, pageSize)
+	if err != nil {
+		return fmt.Errorf("paging: could not read %s by physical position: %w", tableName, err)
+	}
+
+	sizer := database.NewBatchSizer(0, pageSize)
+	var read uint64
+	for _, condition := range conditions {
+		page := opts
+		page.Match = condition
+		if opts.Match != "" {
+			page.Match = fmt.Sprintf("(%s) AND %s", opts.Match, condition)
+		}
+		if opts.Limit > 0 {
+			page.Limit = opts.Limit - read
+		}
+
+		logger.WithField("page", condition).Debug("reading page")
+		n, _, _, err := p.readPage(tableName, rowChan, page, sizer, nil)
+		read += n
+		if err != nil {
+			return err
+		}
+		if opts.Limit > 0 && read >= opts.Limit {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// unordered reports whether the table can be read in any order, its pages being read by key or physical position.
+func (p *pager) unordered(opts reader.ReadTableOpt) bool {
+	return p.schema != nil && len(opts.Sorts) == 0 && opts.Offset == 0 && opts.Query == ""
+}
+
+// primaryKey returns the primary key the table is paginated by, nil to paginate it by offset.
+func (p *pager) primaryKey(tableName string, opts reader.ReadTableOpt) []string {
+	if !p.unordered(opts) {
+		return nil
+	}
+
+	keyer, ok := p.schema.(reader.PrimaryKeyer)
+	if !ok {
+		return nil
+	}
+	key, err := keyer.GetPrimaryKey(tableName)
+	if err != nil {
+		if !errors.Is(err, reader.ErrPrimaryKeysUnsupported) {
+			log.WithError(err).WithField("table", tableName).Warn("could not get the primary key, the table is paginated by offset")
+		}
+		return nil
+	}
+	if len(key) == 0 {
+		return nil
+	}
+
+	return key
+}
+
+// keyOf returns a copy of the values of the key columns of a row, ok is false when one of them is not read.
+func keyOf(row database.Row, key []string) ([]interface{}, bool) {
+	values := make([]interface{}, len(key))
+	for i, column := range key {
+		value, ok := row.Lookup(column)
+		if p, isPointer := value.(*interface{}); isPointer && p != nil {
+			value = *p
+		}
+		if !ok || value == nil {
+			return nil, false
+		}
+		if b, isBytes := value.([]byte); isBytes {
+			value = append([]byte(nil), b...)
+		}
+		values[i] = value
+	}
+
+	return values, true
+}
+
+// readPage forwards the rows of a page, observing their width, it returns the amount of rows read and the key values
+// of the last one, keyed being false when they are not read. The key values are copied before the row is forwarded,
+// the readers it is forwarded to change the rows in place.
+func (p *pager) readPage(
+	tableName string,
+	rowChan chan<- database.Row,
+	opts reader.ReadTableOpt,
+	sizer *database.BatchSizer,
+	key []string,
+) (uint64, []interface{}, bool, error) {
+	pageChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- p.Reader.ReadTable(tableName, pageChan, opts)
+	}()
+
+	var (
+		n     uint64
+		last  []interface{}
+		keyed bool
+	)
+	for row := range pageChan {
+		sizer.Observe(row)
+		if key != nil {
+			last, keyed = keyOf(row, key)
+		}
+		rowChan <- row
+		n++
+	}
+
+	return n, last, keyed, <-errChan
+}
@@ -0,0 +1,297 @@
+package paging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{
+		{Name: "orders", PageSize: 4},
+		{Name: "users"},
+	}
+
+	tests := []struct {
+		name     string
+		table    string
+		rows     int
+		opts     reader.ReadTableOpt
+		expected []int64
+		reads    []reader.ReadTableOpt
+	}{
+		{
+			name:     "table without page size is read at once",
+			table:    "users",
+			rows:     5,
+			expected: []int64{0, 1, 2, 3, 4},
+			reads:    []reader.ReadTableOpt{{}},
+		},
+		{
+			name:     "table is read in pages",
+			table:    "orders",
+			rows:     10,
+			expected: []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			reads:    []reader.ReadTableOpt{{Limit: 4}, {Limit: 4, Offset: 4}, {Limit: 4, Offset: 8}},
+		},
+		{
+			name:     "complete last page is followed by an empty page",
+			table:    "orders",
+			rows:     8,
+			expected: []int64{0, 1, 2, 3, 4, 5, 6, 7},
+			reads:    []reader.ReadTableOpt{{Limit: 4}, {Limit: 4, Offset: 4}, {Limit: 4, Offset: 8}},
+		},
+		{
+			name:     "limit is kept",
+			table:    "orders",
+			rows:     10,
+			opts:     reader.ReadTableOpt{Limit: 6, Match: "id > 0"},
+			expected: []int64{0, 1, 2, 3, 4, 5},
+			reads:    []reader.ReadTableOpt{{Limit: 4, Match: "id > 0"}, {Limit: 2, Offset: 4, Match: "id > 0"}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := &mockReader{rows: test.rows}
+			r := NewReader(source, tables)
+
+			rowChan := make(chan database.Row)
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- r.ReadTable(test.table, rowChan, test.opts)
+			}()
+
+			var ids []int64
+			for row := range rowChan {
+				ids = append(ids, row.Get("id").(int64))
+			}
+			require.NoError(t, <-errChan)
+			assert.Equal(t, test.expected, ids)
+			assert.Equal(t, test.reads, source.reads)
+		})
+	}
+}
+
+func TestAdaptiveReadTable(t *testing.T) {
+	t.Parallel()
+
+	// the rows are 16 bytes wide, the pages after the first one hold 3 rows
+	source := &mockReader{rows: 10}
+	r := NewAdaptiveReader(source, nil, config.Tables{{Name: "orders", PageSize: 2}}, 48)
+
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable("orders", rowChan, reader.ReadTableOpt{})
+	}()
+
+	var ids []int64
+	for row := range rowChan {
+		ids = append(ids, row.Get("id").(int64))
+	}
+	require.NoError(t, <-errChan)
+	assert.Equal(t, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, ids)
+	assert.Equal(t, []reader.ReadTableOpt{{Limit: 2}, {Limit: 3, Offset: 2}, {Limit: 3, Offset: 5}, {Limit: 3, Offset: 8}}, source.reads)
+}
+
+func TestKeysetReadTable(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{{Name: "orders", PageSize: 4}}
+	tests := []struct {
+		name   string
+		schema reader.Reader
+		opts   reader.ReadTableOpt
+		reads  []reader.ReadTableOpt
+	}{
+		{
+			name:   "table is read by key",
+			schema: &mockSchema{key: []string{"shop", "id"}},
+			reads: []reader.ReadTableOpt{
+				{Limit: 4, KeyOrder: true},
+				{Limit: 4, KeyOrder: true, KeyAfter: []interface{}{int64(0), int64(3)}},
+				{Limit: 4, KeyOrder: true, KeyAfter: []interface{}{int64(1), int64(7)}},
+			},
+		},
+		{
+			name:   "table without primary key is read by offset",
+			schema: &mockSchema{},
+			reads:  []reader.ReadTableOpt{{Limit: 4}, {Limit: 4, Offset: 4}, {Limit: 4, Offset: 8}},
+		},
+		{
+			name:   "sorted table is read by offset",
+			schema: &mockSchema{key: []string{"id"}},
+			opts:   reader.ReadTableOpt{Sorts: map[string]string{"id": "desc"}},
+			reads: []reader.ReadTableOpt{
+				{Limit: 4, Sorts: map[string]string{"id": "desc"}},
+				{Limit: 4, Offset: 4, Sorts: map[string]string{"id": "desc"}},
+				{Limit: 4, Offset: 8, Sorts: map[string]string{"id": "desc"}},
+			},
+		},
+		{
+			name:   "table whose key is not read is read by offset after the first page",
+			schema: &mockSchema{key: []string{"uuid"}},
+			reads:  []reader.ReadTableOpt{{Limit: 4, KeyOrder: true}, {Limit: 4, Offset: 4, KeyOrder: true}, {Limit: 4, Offset: 8, KeyOrder: true}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := &mockReader{rows: 10}
+			r := NewAdaptiveReader(source, test.schema, tables, 0)
+
+			rowChan := make(chan database.Row)
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- r.ReadTable("orders", rowChan, test.opts)
+			}()
+
+			var ids []int64
+			for row := range rowChan {
+				ids = append(ids, row.Get("id").(int64))
+			}
+			require.NoError(t, <-errChan)
+			assert.Equal(t, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, ids)
+			assert.Equal(t, test.reads, source.reads)
+		})
+	}
+}
+
+func TestKeysetReadTableChangedRows(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{rows: 10}
+	r := NewAdaptiveReader(source, &mockSchema{key: []string{"shop", "id"}}, config.Tables{{Name: "orders", PageSize: 4}}, 0)
+
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable("orders", rowChan, reader.ReadTableOpt{})
+	}()
+
+	var ids []int64
+	for row := range rowChan {
+		ids = append(ids, row.Get("id").(int64))
+		// the rows are changed in place by the readers they are forwarded to, e.g. the anonymiser
+		row.Set("id", int64(-1))
+		row.Set("shop", int64(-1))
+	}
+	require.NoError(t, <-errChan)
+	assert.Equal(t, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, ids)
+	assert.Equal(t, []reader.ReadTableOpt{
+		{Limit: 4, KeyOrder: true},
+		{Limit: 4, KeyOrder: true, KeyAfter: []interface{}{int64(0), int64(3)}},
+		{Limit: 4, KeyOrder: true, KeyAfter: []interface{}{int64(1), int64(7)}},
+	}, source.reads)
+}
+
+func TestPhysicalReadTable(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{{Name: "logs", PageSize: 4, NoPrimaryKey: "ctid"}}
+	schema := &mockSchema{pages: []string{"ctid < 2", "ctid >= 2"}}
+
+	tests := []struct {
+		name  string
+		opts  reader.ReadTableOpt
+		reads []reader.ReadTableOpt
+	}{
+		{
+			name:  "table is read by physical position",
+			opts:  reader.ReadTableOpt{Match: "level = 'error'"},
+			reads: []reader.ReadTableOpt{{Match: "(level = 'error') AND ctid < 2"}, {Match: "(level = 'error') AND ctid >= 2"}},
+		},
+		{
+			name:  "limit is kept",
+			opts:  reader.ReadTableOpt{Limit: 15},
+			reads: []reader.ReadTableOpt{{Match: "ctid < 2", Limit: 15}, {Match: "ctid >= 2", Limit: 5}},
+		},
+		{
+			name: "sorted table is read by offset",
+			opts: reader.ReadTableOpt{Limit: 15, Sorts: map[string]string{"id": "asc"}},
+			reads: []reader.ReadTableOpt{
+				{Limit: 4, Sorts: map[string]string{"id": "asc"}},
+				{Limit: 4, Offset: 4, Sorts: map[string]string{"id": "asc"}},
+				{Limit: 4, Offset: 8, Sorts: map[string]string{"id": "asc"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the pages are not applied, each one returns the whole table
+			source := &mockReader{rows: 10}
+			r := NewAdaptiveReader(source, schema, tables, 0)
+
+			rowChan := make(chan database.Row)
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- r.ReadTable("logs", rowChan, test.opts)
+			}()
+			for range rowChan {
+			}
+			require.NoError(t, <-errChan)
+			assert.Equal(t, test.reads, source.reads)
+		})
+	}
+}
+
+type mockSchema struct {
+	reader.Reader
+	key   []string
+	pages []string
+}
+
+func (m *mockSchema) GetPrimaryKey(string) ([]string, error) { return m.key, nil }
+
+func (m *mockSchema) GetPhysicalPages(string, uint64) ([]string, error) { return m.pages, nil }
+
+// mockReader has the given amount of rows, in shops of 4 rows, and only applies the limit, the offset and the last
+// value of the key after which the rows are read.
+type mockReader struct {
+	rows  int
+	reads []reader.ReadTableOpt
+}
+
+func (m *mockReader) GetTables() ([]string, error)        { return nil, nil }
+func (m *mockReader) GetStructure() (string, error)       { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) { return nil, nil }
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return ""
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	m.reads = append(m.reads, opts)
+	columns := database.NewColumns([]string{"id", "shop"})
+	start := int(opts.Offset)
+	if len(opts.KeyAfter) > 0 {
+		start += int(opts.KeyAfter[len(opts.KeyAfter)-1].(int64)) + 1
+	}
+	for i := start; i < m.rows; i++ {
+		if opts.Limit > 0 && uint64(i-start) == opts.Limit {
+			break
+		}
+		rowChan <- database.NewRow(columns, []interface{}{int64(i), int64(i / 4)})
+	}
+
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
@@ -0,0 +1,88 @@
+package reader
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// enumType matches the MySQL ENUM and SET types, e.g. enum('a','b').
+	enumType = regexp.MustCompile(`(?is)^\s*(enum|set)\s*\((.*)\)\s*$`)
+	// checkIn matches the CHECK constraints restricting a column to a list of values, as written by MySQL,
+	// e.g. (`status` in (_utf8mb4'a',_utf8mb4'b')), and Postgres, e.g. ((status)::text = ANY (ARRAY['a'::text])).
+	checkIn = regexp.MustCompile(`(?is)^\(*\s*["` + "`" + `]?(\w+)["` + "`" + `]?\)*(?:::[\w ]+)?\s*(?:in\s*\(|=\s*any\s*\(+\s*array\s*\[)(.*)$`)
+	// lengthType matches the character types with a maximum length, e.g. varchar(20), character varying(20) or
+	// bpchar(2), the Postgres name of char(2).
+	lengthType = regexp.MustCompile(`(?i)^\s*(?:national\s+)?(?:n?varchar|character\s+varying|n?char|character|bpchar)\s*\(\s*(\d+)\s*\)`)
+	// quoted matches the quoted literals of a list, the quotes being escaped by doubling them.
+	quoted = regexp.MustCompile(`'((?:[^']|'')*)'`)
+)
+
+// ParseEnumType returns the values of a MySQL ENUM or SET column type, false for the other types.
+func ParseEnumType(columnType string) (AllowedValues, bool) {
+	m := enumType.FindStringSubmatch(columnType)
+	if m == nil {
+		return AllowedValues{}, false
+	}
+
+	values := literals(m[2])
+	if len(values) == 0 {
+		return AllowedValues{}, false
+	}
+
+	return AllowedValues{Values: values, Set: strings.EqualFold(m[1], "set")}, true
+}
+
+// ParseCheckValues returns the column and the values of a CHECK constraint clause restricting a column to a list of
+// values, false for the other constraints.
+func ParseCheckValues(clause string) (string, []string, bool) {
+	clause = strings.TrimSpace(clause)
+	if len(clause) > len("CHECK") && strings.EqualFold(clause[:len("CHECK")], "CHECK") {
+		clause = strings.TrimSpace(clause[len("CHECK"):])
+	}
+
+	m := checkIn.FindStringSubmatch(clause)
+	if m == nil {
+		return "", nil, false
+	}
+	// constraints combining a list with other conditions are not restrictions to the list
+	unquoted := strings.ToUpper(quoted.ReplaceAllString(m[2], "''"))
+	if strings.Contains(unquoted, " AND ") || strings.Contains(unquoted, " OR ") {
+		return "", nil, false
+	}
+
+	values := literals(m[2])
+	if len(values) == 0 {
+		return "", nil, false
+	}
+
+	return m[1], values, true
+}
+
+// literals returns the unquoted literals of a list.
+func literals(list string) []string {
+	matches := quoted.FindAllStringSubmatch(list, -1)
+	values := make([]string, len(matches))
+	for i, m := range matches {
+		values[i] = strings.ReplaceAll(m[1], "''", "'")
+	}
+
+	return values
+}
+
+// ParseMaxLength returns the maximum amount of characters of a character column type, e.g. varchar(20),
+// false for the other types.
+func ParseMaxLength(columnType string) (int, bool) {
+	m := lengthType.FindStringSubmatch(columnType)
+	if m == nil {
+		return 0, false
+	}
+
+	length, err := strconv.Atoi(m[1])
+	if err != nil || length <= 0 {
+		return 0, false
+	}
+
+	return length, true
+}
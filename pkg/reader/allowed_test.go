@@ -0,0 +1,61 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnumType(t *testing.T) {
+	values, ok := ParseEnumType("enum('new','paid','it''s')")
+	assert.True(t, ok)
+	assert.Equal(t, AllowedValues{Values: []string{"new", "paid", "it's"}}, values)
+
+	values, ok = ParseEnumType("SET('red', 'green')")
+	assert.True(t, ok)
+	assert.Equal(t, AllowedValues{Values: []string{"red", "green"}, Set: true}, values)
+
+	_, ok = ParseEnumType("varchar(255)")
+	assert.False(t, ok)
+}
+
+func TestParseCheckValues(t *testing.T) {
+	for clause, column := range map[string]string{
+		"(`status` in (_utf8mb4'new',_utf8mb4'paid'))":                                                          "status",
+		"CHECK (((status)::text = ANY ((ARRAY['new'::character varying, 'paid'::character varying])::text[])))": "status",
+		"CHECK ((status = ANY (ARRAY['new'::text, 'paid'::text])))":                                             "status",
+		"CHECK (status IN ('new', 'paid'))":                                                                     "status",
+	} {
+		name, values, ok := ParseCheckValues(clause)
+		assert.True(t, ok, clause)
+		assert.Equal(t, column, name, clause)
+		assert.Equal(t, []string{"new", "paid"}, values, clause)
+	}
+
+	for _, clause := range []string{
+		"CHECK ((price > (0)::numeric))",
+		"CHECK (status IN ('new', 'paid') OR archived)",
+	} {
+		_, _, ok := ParseCheckValues(clause)
+		assert.False(t, ok, clause)
+	}
+}
+
+func TestParseMaxLength(t *testing.T) {
+	for columnType, expected := range map[string]int{
+		"varchar(20)":              20,
+		"character varying(255)":   255,
+		"bpchar(2)":                2,
+		"NVARCHAR(10)":             10,
+		"char(36) CHARACTER SET x": 36,
+	} {
+		length, ok := ParseMaxLength(columnType)
+		assert.True(t, ok, columnType)
+		assert.Equal(t, expected, length, columnType)
+	}
+
+	for _, columnType := range []string{"text", "varchar", "int(11)", "varbinary(16)", ""} {
+		_, ok := ParseMaxLength(columnType)
+		assert.False(t, ok, columnType)
+	}
+}
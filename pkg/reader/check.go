@@ -0,0 +1,233 @@
+package reader
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hellofresh/klepto/pkg/config"
+)
+
+// CheckConfig returns the problems of the tables configuration that reference tables or columns
+// missing from the source, so renamed tables or columns do not silently stop being anonymised.
+func CheckConfig(source Reader, tables config.Tables) ([]string, error) {
+	sourceTables, err := source.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("could not get tables: %w", err)
+	}
+
+	known := make(map[string]bool, len(sourceTables))
+	for _, table := range sourceTables {
+		known[table] = true
+	}
+
+	columns := make(map[string]map[string]bool)
+	getColumns := func(table string) (map[string]bool, error) {
+		if c, ok := columns[table]; ok {
+			return c, nil
+		}
+
+		names, err := source.GetColumns(table)
+		if err != nil {
+			return nil, fmt.Errorf("could not get columns of %s: %w", table, err)
+		}
+		c := make(map[string]bool, len(names))
+		for _, name := range names {
+			c[name] = true
+		}
+		columns[table] = c
+
+		return c, nil
+	}
+
+	var problems []string
+	checkColumns := func(table string, kind string, names ...string) error {
+		if !known[table] {
+			return nil
+		}
+
+		c, err := getColumns(table)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if name != "" && !c[name] {
+				problems = append(problems, fmt.Sprintf("%s column %s.%s does not exist in the source", kind, table, name))
+			}
+		}
+
+		return nil
+	}
+
+	for _, table := range tables {
+		// the shards are checked with the copies of the table configuration, see config.Tables.ExpandShards
+		if table.Shards != "" {
+			if len(table.MatchShards(sourceTables)) == 0 {
+				problems = append(problems, fmt.Sprintf("no table of the source matches the shards %s of table %s", table.Shards, table.Name))
+			}
+			continue
+		}
+		if !known[table.Name] {
+			problems = append(problems, fmt.Sprintf("table %s does not exist in the source", table.Name))
+			continue
+		}
+
+		anonymised := make([]string, 0, len(table.Anonymise))
+		for column := range table.Anonymise {
+			anonymised = append(anonymised, column)
+		}
+		sort.Strings(anonymised)
+		if err := checkColumns(table.Name, "anonymised", anonymised...); err != nil {
+			return nil, err
+		}
+
+		cast := make([]string, 0, len(table.Cast))
+		for column := range table.Cast {
+			cast = append(cast, column)
+		}
+		sort.Strings(cast)
+		if err := checkColumns(table.Name, "cast", cast...); err != nil {
+			return nil, err
+		}
+
+		transformed := make([]string, 0, len(table.Transform))
+		for column := range table.Transform {
+			transformed = append(transformed, column)
+		}
+		sort.Strings(transformed)
+		if err := checkColumns(table.Name, "transformed", transformed...); err != nil {
+			return nil, err
+		}
+
+		if err := checkColumns(table.Name, "ignored", table.IgnoreColumns...); err != nil {
+			return nil, err
+		}
+		if err := checkColumns(table.Name, "chunked", table.ChunkColumns...); err != nil {
+			return nil, err
+		}
+		if err := checkColumns(table.Name, "pseudonymised", table.Pseudonymise...); err != nil {
+			return nil, err
+		}
+		for _, column := range table.Pseudonymise {
+			if _, ok := table.Anonymise[column]; !ok {
+				problems = append(problems, fmt.Sprintf("column %s.%s is pseudonymised but has no anonymiser", table.Name, column))
+			}
+		}
+
+		if err := checkColumns(table.Name, "distribution key", table.DistKey); err != nil {
+			return nil, err
+		}
+		if err := checkColumns(table.Name, "sort key", table.SortKeys...); err != nil {
+			return nil, err
+		}
+
+		for _, rel := range table.Relationships {
+			relTable := rel.Table
+			if relTable == "" {
+				relTable = table.Name
+			}
+
+			for _, t := range []string{relTable, rel.ReferencedTable} {
+				if !known[t] {
+					problems = append(problems, fmt.Sprintf("relationship table %s of %s does not exist in the source", t, table.Name))
+				}
+			}
+			if err := checkColumns(relTable, "foreign key", rel.ForeignKey); err != nil {
+				return nil, err
+			}
+			if err := checkColumns(rel.ReferencedTable, "referenced key", rel.ReferencedKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+// CheckFilters runs the read query of the tables filtered with a Match without reading their rows, returning the
+// error of the source for each table whose query it rejects, e.g. for a filter on a renamed column.
+// Tables whose data is ignored or generated and tables missing from the source are not checked.
+func CheckFilters(source Reader, tables config.Tables) (map[string]error, error) {
+	checker, ok := source.(FilterChecker)
+	if !ok {
+		return nil, ErrFilterCheckUnsupported
+	}
+
+	sourceTables, err := source.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("could not get tables: %w", err)
+	}
+	known := make(map[string]bool, len(sourceTables))
+	for _, table := range sourceTables {
+		known[table] = true
+	}
+
+	rejected := make(map[string]error)
+	for _, table := range tables {
+		if table.Filter.Match == "" || !known[table.Name] || table.IgnoreData || table.SyntheticRows > 0 {
+			continue
+		}
+
+		if err := checker.CheckFilter(table.Name, NewReadTableOpt(table)); err != nil {
+			rejected[table.Name] = err
+		}
+	}
+
+	return rejected, nil
+}
+
+// CheckPII returns the columns of the dumped tables whose name matches a PII pattern but that are not anonymised.
+// Tables whose data is ignored or generated and ignored columns are not checked.
+func CheckPII(source Reader, tables config.Tables, patterns []*regexp.Regexp) ([]string, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	sourceTables, err := source.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("could not get tables: %w", err)
+	}
+
+	var problems []string
+	for _, tableName := range sourceTables {
+		table := tables.FindByName(tableName)
+		if table != nil && (table.IgnoreData || table.SyntheticRows > 0) {
+			continue
+		}
+
+		columns, err := source.GetColumns(tableName)
+		if err != nil {
+			return nil, fmt.Errorf("could not get columns of %s: %w", tableName, err)
+		}
+
+		for _, column := range columns {
+			if table != nil {
+				if _, ok := table.Anonymise[column]; ok {
+					continue
+				}
+				if isIgnored(table, column) {
+					continue
+				}
+			}
+
+			for _, pattern := range patterns {
+				if pattern.MatchString(column) {
+					problems = append(problems, fmt.Sprintf("column %s.%s looks like PII but is not anonymised", tableName, column))
+					break
+				}
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+func isIgnored(table *config.Table, column string) bool {
+	for _, ignored := range table.IgnoreColumns {
+		if ignored == column {
+			return true
+		}
+	}
+
+	return false
+}
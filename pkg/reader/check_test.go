@@ -0,0 +1,115 @@
+package reader
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+func TestCheckConfig(t *testing.T) {
+	tables := config.Tables{
+		{
+			Name:         "users",
+			Anonymise:    map[string]string{"email": "EmailAddress", "mail": "EmailAddress"},
+			Cast:         map[string]string{"id": "string", "amount": "decimal(10,2)"},
+			Transform:    map[string]string{"email": `lower(row.email)`, "domain": `"example.com"`},
+			Pseudonymise: []string{"email", "id"},
+		},
+		{
+			Name:          "orders",
+			IgnoreColumns: []string{"user_id", "notes"},
+			ChunkColumns:  []string{"body"},
+			SortKeys:      []string{"created_at"},
+			Relationships: []*config.Relationship{
+				{ForeignKey: "user_id", ReferencedTable: "users", ReferencedKey: "uid"},
+				{ForeignKey: "shop_id", ReferencedTable: "shops", ReferencedKey: "id"},
+			},
+		},
+		{Name: "customers"},
+		{Name: "user_shards", Shards: "user%"},
+		{Name: "events", Shards: "events_%", MergeShards: true},
+	}
+
+	problems, err := CheckConfig(&mockReader{}, tables)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"anonymised column users.mail does not exist in the source",
+		"cast column users.amount does not exist in the source",
+		"transformed column users.domain does not exist in the source",
+		"column users.id is pseudonymised but has no anonymiser",
+		"ignored column orders.notes does not exist in the source",
+		"chunked column orders.body does not exist in the source",
+		"sort key column orders.created_at does not exist in the source",
+		"referenced key column users.uid does not exist in the source",
+		"relationship table shops of orders does not exist in the source",
+		"foreign key column orders.shop_id does not exist in the source",
+		"table customers does not exist in the source",
+		"no table of the source matches the shards events_% of table events",
+	}, problems)
+}
+
+func TestCheckPII(t *testing.T) {
+	tables := config.Tables{
+		{Name: "users", Anonymise: map[string]string{"email": "EmailAddress"}},
+	}
+	patterns := []*regexp.Regexp{regexp.MustCompile(`(?i)e_?mail`), regexp.MustCompile(`(?i)user_id`)}
+
+	problems, err := CheckPII(&mockReader{}, tables, patterns)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"column orders.user_id looks like PII but is not anonymised"}, problems)
+
+	tables = append(tables, &config.Table{Name: "orders", IgnoreColumns: []string{"user_id"}})
+	problems, err = CheckPII(&mockReader{}, tables, patterns)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestCheckFilters(t *testing.T) {
+	tables := config.Tables{
+		{Name: "users", Filter: config.Filter{Match: "users.active = TRUE"}},
+		{Name: "orders", Filter: config.Filter{Match: "orders.state = 'paid'"}},
+		{Name: "customers", Filter: config.Filter{Match: "customers.deleted_at IS NULL"}},
+	}
+
+	_, err := CheckFilters(&mockReader{}, tables)
+	assert.ErrorIs(t, err, ErrFilterCheckUnsupported)
+
+	checker := &mockFilterChecker{rejected: map[string]error{"orders": errors.New("unknown column orders.state")}}
+	rejected, err := CheckFilters(checker, tables)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]error{"orders": checker.rejected["orders"]}, rejected)
+	assert.Equal(t, []string{"users.active = TRUE", "orders.state = 'paid'"}, checker.checked)
+}
+
+type mockReader struct{}
+
+func (m *mockReader) GetTables() ([]string, error)  { return []string{"users", "orders"}, nil }
+func (m *mockReader) GetStructure() (string, error) { return "", nil }
+func (m *mockReader) GetColumns(table string) ([]string, error) {
+	return map[string][]string{
+		"users":  {"id", "email"},
+		"orders": {"id", "user_id"},
+	}[table], nil
+}
+func (m *mockReader) FormatColumn(tableName string, columnName string) string { return "" }
+func (m *mockReader) ReadTable(string, chan<- database.Row, ReadTableOpt) error {
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
+
+type mockFilterChecker struct {
+	mockReader
+	rejected map[string]error
+	checked  []string
+}
+
+func (m *mockFilterChecker) CheckFilter(tableName string, opts ReadTableOpt) error {
+	m.checked = append(m.checked, opts.Match)
+	return m.rejected[tableName]
+}
@@ -0,0 +1,72 @@
+package csvdir
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	parser "github.com/hellofresh/klepto/pkg/dsn"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+const (
+	scheme = "csv://"
+
+	defaultSchema = "schema.sql"
+	defaultNull   = `\N`
+)
+
+type driver struct{}
+
+// IsSupported checks if the given dsn connection string is supported.
+func (m *driver) IsSupported(dsn string) bool {
+	return strings.HasPrefix(dsn, scheme)
+}
+
+// NewConnection retrieves a new csv directory reader.
+// The dsn is csv:///path/to/dir/ with the optional parameters schema (the schema file inside the
+// directory, schema.sql by default), delimiter (a comma by default) and null (\N by default).
+func (m *driver) NewConnection(opts reader.ConnOpts) (reader.Reader, error) {
+	u, err := url.Parse(opts.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv dsn: %w", err)
+	}
+
+	dir := u.Path
+	if u.Host != "" {
+		// relative paths such as csv://./export/
+		dir = filepath.Join(u.Host, u.Path)
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("no directory given in dsn %q", parser.Redacted(opts.DSN))
+	}
+
+	params := u.Query()
+	cfg := Config{
+		Dir:       dir,
+		Schema:    defaultSchema,
+		Delimiter: ',',
+		Null:      defaultNull,
+	}
+	if schema := params.Get("schema"); schema != "" {
+		cfg.Schema = schema
+	}
+	if params.Has("null") {
+		cfg.Null = params.Get("null")
+	}
+	if delimiter := params.Get("delimiter"); delimiter != "" {
+		r, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) {
+			return nil, fmt.Errorf("the csv delimiter must be a single character, got %q", delimiter)
+		}
+		cfg.Delimiter = r
+	}
+
+	return NewReader(cfg)
+}
+
+func init() {
+	reader.Register("csv", &driver{})
+}
@@ -0,0 +1,192 @@
+package csvdir
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+const extension = ".csv"
+
+type (
+	// Config describes the layout of a csv directory.
+	Config struct {
+		// Dir is the directory holding one <table>.csv file per table.
+		Dir string
+		// Schema is the file, relative to Dir, with the SQL creating the tables.
+		Schema string
+		// Delimiter is the field delimiter of the csv files.
+		Delimiter rune
+		// Null is the field value read as NULL.
+		Null string
+	}
+
+	// dirReader reads tables from a directory of csv files, the first line of each file holds the column names.
+	dirReader struct {
+		cfg     Config
+		tables  []string
+		columns map[string][]string
+	}
+)
+
+// NewReader lists the csv files of the directory and reads their headers.
+func NewReader(cfg Config) (reader.Reader, error) {
+	entries, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv directory: %w", err)
+	}
+
+	r := &dirReader{cfg: cfg, columns: make(map[string][]string)}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), extension) {
+			continue
+		}
+
+		table := strings.TrimSuffix(entry.Name(), extension)
+		columns, err := r.readHeader(table)
+		if err != nil {
+			return nil, err
+		}
+
+		r.tables = append(r.tables, table)
+		r.columns[table] = columns
+	}
+	sort.Strings(r.tables)
+
+	log.WithField("tables", r.tables).Debug("found csv files")
+
+	return r, nil
+}
+
+// GetStructure returns the content of the schema file, empty when there is none.
+func (r *dirReader) GetStructure() (string, error) {
+	structure, err := os.ReadFile(filepath.Join(r.cfg.Dir, r.cfg.Schema))
+	if errors.Is(err, os.ErrNotExist) {
+		log.WithField("schema", r.cfg.Schema).Warn("no schema file found in the csv directory, the structure is empty")
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	return string(structure), nil
+}
+
+// GetTables returns the tables, one per csv file.
+func (r *dirReader) GetTables() ([]string, error) {
+	return r.tables, nil
+}
+
+// GetColumns returns the columns listed in the header of the table csv file.
+func (r *dirReader) GetColumns(tableName string) ([]string, error) {
+	columns, ok := r.columns[tableName]
+	if !ok {
+		return nil, fmt.Errorf("no csv file found for table %s", tableName)
+	}
+
+	return columns, nil
+}
+
+// FormatColumn returns a escaped table.column string
+func (r *dirReader) FormatColumn(tableName string, columnName string) string {
+	return fmt.Sprintf("%q.%q", tableName, columnName)
+}
+
+// ReadTable streams the records of the table csv file, all values are read as strings.
+func (r *dirReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	logger := log.WithField("table", tableName)
+	if opts.Match != "" || len(opts.Sorts) > 0 || len(opts.Relationships) > 0 || opts.Query != "" {
+		logger.Warn("filter match, sorts, relationships and queries are not supported when reading csv files, only the limit and offset are applied")
+	}
+
+	names, err := r.GetColumns(tableName)
+	if err != nil {
+		return err
+	}
+	columns := database.NewColumns(names)
+
+	f, cr, err := r.open(tableName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// skip the header
+	if _, err := cr.Read(); err != nil {
+		return fmt.Errorf("failed to read header of %s: %w", tableName, err)
+	}
+
+	var count, skipped uint64
+	for opts.Limit == 0 || count < opts.Limit {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", tableName, err)
+		}
+
+		values := make([]interface{}, len(record))
+		for i, field := range record {
+			if field != r.cfg.Null {
+				values[i] = field
+			}
+		}
+
+		if skipped < opts.Offset {
+			skipped++
+			continue
+		}
+
+		rowChan <- database.NewRow(columns, values)
+		count++
+	}
+
+	return nil
+}
+
+// Close releases the reader resources, the csv files are only opened while reading.
+func (r *dirReader) Close() error {
+	return nil
+}
+
+func (r *dirReader) readHeader(table string) ([]string, error) {
+	f, cr, err := r.open(table)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header of %s: %w", table, err)
+	}
+	// spreadsheet exports often start with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	return header, nil
+}
+
+func (r *dirReader) open(table string) (*os.File, *csv.Reader, error) {
+	f, err := os.Open(filepath.Join(r.cfg.Dir, table+extension))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open csv file: %w", err)
+	}
+
+	cr := csv.NewReader(f)
+	cr.Comma = r.cfg.Delimiter
+
+	return f, cr, nil
+}
@@ -0,0 +1,83 @@
+package csvdir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "schema.sql", "CREATE TABLE users (id int, name text, email text);\n")
+	writeFile(t, dir, "users.csv", "\ufeffid,name,email\n1,\"Doe, John\",\\N\n2,Jane,\"\"\n")
+	writeFile(t, dir, "orders.csv", "id\n")
+	writeFile(t, dir, "notes.txt", "ignored")
+
+	r, err := NewReader(Config{Dir: dir, Schema: defaultSchema, Delimiter: ',', Null: defaultNull})
+	require.NoError(t, err)
+
+	tables, err := r.GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders", "users"}, tables)
+
+	columns, err := r.GetColumns("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "email"}, columns)
+
+	structure, err := r.GetStructure()
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE users (id int, name text, email text);\n", structure)
+
+	rows := readAll(t, r, "users", reader.ReadTableOpt{})
+	require.Len(t, rows, 2)
+	assert.Equal(t, []interface{}{"1", "Doe, John", nil}, rows[0].Values())
+	assert.Equal(t, []interface{}{"2", "Jane", ""}, rows[1].Values())
+
+	assert.Len(t, readAll(t, r, "users", reader.ReadTableOpt{Limit: 1}), 1)
+	rows = readAll(t, r, "users", reader.ReadTableOpt{Limit: 1, Offset: 1})
+	require.Len(t, rows, 1)
+	assert.Equal(t, []interface{}{"2", "Jane", ""}, rows[0].Values())
+	assert.Empty(t, readAll(t, r, "orders", reader.ReadTableOpt{}))
+}
+
+func TestReadDirectoryWithoutSchema(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "users.csv", "id;name\n1;foo\n")
+
+	r, err := NewReader(Config{Dir: dir, Schema: defaultSchema, Delimiter: ';', Null: defaultNull})
+	require.NoError(t, err)
+
+	structure, err := r.GetStructure()
+	require.NoError(t, err)
+	assert.Empty(t, structure)
+
+	rows := readAll(t, r, "users", reader.ReadTableOpt{})
+	require.Len(t, rows, 1)
+	assert.Equal(t, "foo", rows[0].Get("name"))
+}
+
+func writeFile(t *testing.T, dir string, name string, content string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
+
+func readAll(t *testing.T, r reader.Reader, table string, opts reader.ReadTableOpt) []database.Row {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable(table, rowChan, opts)
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+	require.NoError(t, <-errChan)
+
+	return rows
+}
@@ -37,3 +37,19 @@ func Drivers() []string {
 	sort.Strings(list)
 	return list
 }
+
+// DriverName returns the name of the driver supporting the dsn, empty when no driver does.
+func DriverName(dsn string) string {
+	var found string
+	drivers.Range(func(key, value interface{}) bool {
+		driver, ok := value.(Driver)
+		if !ok || !driver.IsSupported(dsn) {
+			return true
+		}
+
+		found, _ = key.(string)
+		return false
+	})
+
+	return found
+}
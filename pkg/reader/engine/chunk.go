@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	// PrimaryKeyer is implemented by storages that know the primary key of the tables.
+	PrimaryKeyer interface {
+		// GetPrimaryKey returns the primary key columns of a table.
+		GetPrimaryKey(tableName string) ([]string, error)
+	}
+
+	// Chunker is implemented by storages that can read large values in chunks.
+	Chunker interface {
+		PrimaryKeyer
+		// LengthFunction returns the SQL function giving the length of a value in the unit SUBSTRING counts in.
+		LengthFunction() string
+		// Placeholder returns the placeholder format of the query parameters.
+		Placeholder() sq.PlaceholderFormat
+	}
+
+	// chunkReader reads the large values of a table in chunks, looking their rows up by primary key.
+	chunkReader struct {
+		engine    *Engine
+		chunker   Chunker
+		tableName string
+		chunkSize int64
+		// columns are the large columns, true for binary ones.
+		columns map[string]bool
+		key     []string
+	}
+)
+
+func (e *Engine) newChunkReader(tableName string, opts reader.ReadTableOpt) (*chunkReader, error) {
+	chunker, ok := e.Storage.(Chunker)
+	if !ok {
+		return nil, reader.ErrChunksUnsupported
+	}
+
+	key, err := e.GetPrimaryKey(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primary key: %w", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key to read large values with", tableName)
+	}
+
+	types, err := e.GetColumnTypes(tableName)
+	if err != nil && err != reader.ErrColumnTypesUnsupported {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+
+	columns := make(map[string]bool, len(opts.ChunkColumns))
+	for _, column := range opts.ChunkColumns {
+		columns[column] = isBinary(types[column])
+	}
+
+	return &chunkReader{
+		engine:    e,
+		chunker:   chunker,
+		tableName: tableName,
+		chunkSize: opts.ChunkSize,
+		columns:   columns,
+		key:       key,
+	}, nil
+}
+
+// selectColumns replaces the large columns by their length in the selected columns,
+// the primary key must be selected to read the large values.
+func (c *chunkReader) selectColumns(columns []string) ([]string, error) {
+	selected := make([]string, len(columns))
+	copy(selected, columns)
+
+	for column := range c.columns {
+		formatted := c.engine.FormatColumn(c.tableName, column)
+		for i, s := range selected {
+			if s == formatted {
+				selected[i] = fmt.Sprintf("%s(%s) AS %s", c.chunker.LengthFunction(), formatted, c.engine.QuoteIdentifier(column))
+			}
+		}
+	}
+
+	for _, column := range c.key {
+		formatted := c.engine.FormatColumn(c.tableName, column)
+		found := false
+		for _, s := range selected {
+			found = found || s == formatted
+		}
+		if !found {
+			return nil, fmt.Errorf("primary key column %s of %s must be read to read large values", column, c.tableName)
+		}
+	}
+
+	return selected, nil
+}
+
+// toLOBs replaces the lengths read for the large columns of a row by values read in chunks.
+func (c *chunkReader) toLOBs(row database.Row) error {
+	key := make(sq.Eq, len(c.key))
+	for _, column := range c.key {
+		key[c.engine.FormatColumn(c.tableName, column)] = row.Get(column)
+	}
+
+	for column, binary := range c.columns {
+		value, ok := row.Lookup(column)
+		if !ok || value == nil {
+			continue
+		}
+
+		size, err := toInt64(value)
+		if err != nil {
+			return fmt.Errorf("invalid length of %s: %w", column, err)
+		}
+		row.Set(column, database.NewLOB(size, binary, c.chunkSize, c.read(column, key)))
+	}
+
+	return nil
+}
+
+// read returns the function reading a chunk of a large value.
+func (c *chunkReader) read(column string, key sq.Eq) func(offset int64, length int64) ([]byte, error) {
+	formatted := c.engine.FormatColumn(c.tableName, column)
+
+	return func(offset int64, length int64) ([]byte, error) {
+		query := sq.Select().
+			Column(sq.Expr(fmt.Sprintf("SUBSTRING(%s, ?, ?)", formatted), offset, length)).
+			From(c.engine.QuoteIdentifier(c.tableName)).
+			Where(key).
+			PlaceholderFormat(c.chunker.Placeholder())
+		if c.engine.logQueries {
+			c.engine.logQuery(log.WithField("table", c.tableName), query, false)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.engine.timeout)
+		defer cancel()
+
+		var chunk []byte
+		err := c.engine.retry.Do(ctx, func() error {
+			return query.RunWith(c.engine.Conn()).QueryRowContext(ctx).Scan(&chunk)
+		})
+
+		return chunk, err
+	}
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+
+	return 0, fmt.Errorf("unexpected type %T", value)
+}
+
+// isBinary reports whether a column type holds bytes, e.g. blob or bytea.
+func isBinary(columnType string) bool {
+	columnType = strings.ToLower(columnType)
+	return strings.Contains(columnType, "blob") || strings.Contains(columnType, "binary") || columnType == "bytea"
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 
 	"github.com/hellofresh/klepto/pkg/database"
 	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/retry"
 )
 
 type (
@@ -22,8 +25,15 @@ type (
 		tables []string
 		// columns is a cache variable for tables and there columns in the db
 		columns sync.Map
+		// metadata caches the column types, allowed values, keys and indexes of the tables
+		metadata metadata
 		// timeout is the sql read operation timeout
 		timeout time.Duration
+		// retry is the policy for retrying queries failing with transient errors
+		retry retry.Policy
+		// logQueries logs the read queries, explain runs EXPLAIN on the table read queries first
+		logQueries bool
+		explain    bool
 	}
 
 	// Storage is the read storage database interface.
@@ -44,8 +54,8 @@ type (
 )
 
 // New creates a new sql reader engine.
-func New(s Storage, timeout time.Duration) *Engine {
-	return &Engine{Storage: s, timeout: timeout}
+func New(s Storage, timeout time.Duration, policy retry.Policy) *Engine {
+	return &Engine{Storage: s, timeout: timeout, retry: policy}
 }
 
 // GetTables gets a list of all tables in the database
@@ -93,6 +103,17 @@ func (e *Engine) ReadTable(tableName string, rowChan chan<- database.Row, opts r
 		opts.Columns = e.formatColumns(tableName, columns)
 	}
 
+	var chunks *chunkReader
+	if len(opts.ChunkColumns) > 0 {
+		var err error
+		if chunks, err = e.newChunkReader(tableName, opts); err != nil {
+			return err
+		}
+		if opts.Columns, err = chunks.selectColumns(opts.Columns); err != nil {
+			return err
+		}
+	}
+
 	var (
 		query sq.SelectBuilder
 		err   error
@@ -102,6 +123,10 @@ func (e *Engine) ReadTable(tableName string, rowChan chan<- database.Row, opts r
 		return fmt.Errorf("failed to build query for %s: %w", tableName, err)
 	}
 
+	if e.logQueries {
+		e.logQuery(logger, query, e.explain)
+	}
+
 	var rows *sql.Rows
 	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
 	defer cancel()
@@ -109,8 +134,10 @@ func (e *Engine) ReadTable(tableName string, rowChan chan<- database.Row, opts r
 	errChan := make(chan error)
 	go func() {
 		defer close(errChan)
-		rows, err = query.RunWith(e.Conn()).QueryContext(ctx)
-		errChan <- err
+		errChan <- e.retry.Do(ctx, func() error {
+			rows, err = query.RunWith(e.Conn()).QueryContext(ctx)
+			return err
+		})
 	}()
 
 	select {
@@ -129,14 +156,240 @@ func (e *Engine) ReadTable(tableName string, rowChan chan<- database.Row, opts r
 		break
 	}
 
-	return e.publishRows(rows, rowChan, tableName)
+	return e.publishRows(rows, rowChan, tableName, chunks)
+}
+
+// CurrentPosition returns the replication position of the database, if supported by the storage.
+func (e *Engine) CurrentPosition(ctx context.Context) (string, error) {
+	w, ok := e.Storage.(reader.ReplicaWaiter)
+	if !ok {
+		return "", reader.ErrReplicationUnsupported
+	}
+
+	return w.CurrentPosition(ctx)
+}
+
+// WaitForPosition waits for the database to apply a replication position, if supported by the storage.
+func (e *Engine) WaitForPosition(ctx context.Context, position string) error {
+	w, ok := e.Storage.(reader.ReplicaWaiter)
+	if !ok {
+		return reader.ErrReplicationUnsupported
+	}
+
+	return w.WaitForPosition(ctx, position)
+}
+
+// GetColumnTypes returns the SQL type of the columns of a table, if supported by the storage.
+func (e *Engine) GetColumnTypes(tableName string) (map[string]string, error) {
+	t, ok := e.Storage.(reader.ColumnTyper)
+	if !ok {
+		return nil, reader.ErrColumnTypesUnsupported
+	}
+
+	types, err := cached(&e.metadata.types, tableName, func() (interface{}, error) {
+		return t.GetColumnTypes(tableName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return types.(map[string]string), nil
+}
+
+// GetAllowedValues returns the values allowed in the restricted columns of a table, if supported by the storage.
+func (e *Engine) GetAllowedValues(tableName string) (map[string]reader.AllowedValues, error) {
+	v, ok := e.Storage.(reader.AllowedValuer)
+	if !ok {
+		return nil, reader.ErrAllowedValuesUnsupported
+	}
+
+	values, err := cached(&e.metadata.allowed, tableName, func() (interface{}, error) {
+		return v.GetAllowedValues(tableName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values.(map[string]reader.AllowedValues), nil
+}
+
+// GetForeignKeys returns the foreign keys of the tables, if supported by the storage.
+func (e *Engine) GetForeignKeys() ([]reader.ForeignKey, error) {
+	f, ok := e.Storage.(reader.ForeignKeyer)
+	if !ok {
+		return nil, reader.ErrForeignKeysUnsupported
+	}
+
+	return e.metadata.getForeignKeys(f.GetForeignKeys)
+}
+
+// GetIndexes returns the indexes of a table, if supported by the storage.
+func (e *Engine) GetIndexes(tableName string) ([]reader.Index, error) {
+	i, ok := e.Storage.(reader.Indexer)
+	if !ok {
+		return nil, reader.ErrIndexesUnsupported
+	}
+
+	indexes, err := cached(&e.metadata.indexes, tableName, func() (interface{}, error) {
+		return i.GetIndexes(tableName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return indexes.([]reader.Index), nil
+}
+
+// GetPrimaryKey returns the primary key columns of a table, if supported by the storage.
+func (e *Engine) GetPrimaryKey(tableName string) ([]string, error) {
+	k, ok := e.Storage.(PrimaryKeyer)
+	if !ok {
+		return nil, reader.ErrPrimaryKeysUnsupported
+	}
+
+	key, err := cached(&e.metadata.primaryKeys, tableName, func() (interface{}, error) {
+		return k.GetPrimaryKey(tableName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key.([]string), nil
+}
+
+// Exec executes a statement on the source database, retrying it on transient errors.
+func (e *Engine) Exec(query string) error {
+	return e.retry.Do(context.Background(), func() error {
+		_, err := e.Conn().Exec(query)
+		return err
+	})
+}
+
+// QueryValue runs a query returning a single value, retrying it on transient errors.
+func (e *Engine) QueryValue(query string) (string, bool, error) {
+	var (
+		value sql.NullString
+		found bool
+	)
+	err := e.retry.Do(context.Background(), func() error {
+		err := e.Conn().QueryRow(query).Scan(&value)
+		if err == sql.ErrNoRows {
+			found = false
+			return nil
+		}
+		found = err == nil
+		return err
+	})
+
+	return value.String, found, err
+}
+
+// CountRows counts the rows of a table, retrying on transient errors.
+func (e *Engine) CountRows(tableName string) (uint64, error) {
+	var count uint64
+	err := e.retry.Do(context.Background(), func() error {
+		return e.Conn().QueryRow("SELECT COUNT(*) FROM " + e.QuoteIdentifier(tableName)).Scan(&count)
+	})
+
+	return count, err
+}
+
+// GetPhysicalPages returns the conditions matching the pages of a table by physical position, if supported by the
+// storage.
+func (e *Engine) GetPhysicalPages(tableName string, pageSize uint64) ([]string, error) {
+	p, ok := e.Storage.(reader.PhysicalPager)
+	if !ok {
+		return nil, reader.ErrPhysicalPagesUnsupported
+	}
+
+	return p.GetPhysicalPages(tableName, pageSize)
+}
+
+// GetExtensions returns the extensions the objects of the storage depend on, if supported by the storage.
+func (e *Engine) GetExtensions() ([]reader.Extension, error) {
+	l, ok := e.Storage.(reader.ExtensionLister)
+	if !ok {
+		return nil, reader.ErrExtensionsUnsupported
+	}
+
+	return l.GetExtensions()
+}
+
+// GetForeignTables returns the tables of the storage stored on a remote system, if supported by the storage.
+func (e *Engine) GetForeignTables() ([]string, error) {
+	l, ok := e.Storage.(reader.ForeignTableLister)
+	if !ok {
+		return nil, reader.ErrForeignTablesUnsupported
+	}
+
+	return l.GetForeignTables()
+}
+
+// GetVersionedTables returns the system-versioned tables of the storage, if supported by the storage.
+func (e *Engine) GetVersionedTables() ([]reader.VersionedTable, error) {
+	l, ok := e.Storage.(reader.VersionedTableLister)
+	if !ok {
+		return nil, reader.ErrVersionedTablesUnsupported
+	}
+
+	return l.GetVersionedTables()
+}
+
+// GetStructureSections returns the pre-data and post-data sections of the structure, if supported by the storage.
+func (e *Engine) GetStructureSections() (string, string, error) {
+	s, ok := e.Storage.(reader.Sectioner)
+	if !ok {
+		return "", "", reader.ErrSectionsUnsupported
+	}
+
+	return s.GetStructureSections()
+}
+
+// CheckFilter runs the read query of the table with a zero limit, so that the database parses and plans it
+// without returning any row.
+func (e *Engine) CheckFilter(tableName string, opts reader.ReadTableOpt) error {
+	if len(opts.Columns) == 0 {
+		columns, err := e.GetColumns(tableName)
+		if err != nil {
+			return fmt.Errorf("failed to get columns: %w", err)
+		}
+		opts.Columns = e.formatColumns(tableName, columns)
+	}
+
+	query, err := e.buildQuery(tableName, opts)
+	if err != nil {
+		return fmt.Errorf("failed to build query for %s: %w", tableName, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	rows, err := query.Limit(0).RunWith(e.Conn()).QueryContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+// LogQueries logs the read queries with their bind values, running EXPLAIN on the table read queries
+// before they are executed when explain is set.
+func (e *Engine) LogQueries(explain bool) {
+	e.logQueries = true
+	e.explain = explain
 }
 
 // BuildQuery builds the query that will be used to read the table
 func (e *Engine) buildQuery(tableName string, opts reader.ReadTableOpt) (sq.SelectBuilder, error) {
 	var query sq.SelectBuilder
 
-	query = sq.Select(opts.Columns...).From(e.QuoteIdentifier(tableName))
+	// a custom query is read as a subquery named after the table, so that the filter applies to its rows
+	from := e.QuoteIdentifier(tableName)
+	if opts.Query != "" {
+		from = fmt.Sprintf("(%s) AS %s", strings.TrimSuffix(strings.TrimSpace(opts.Query), ";"), from)
+	}
+
+	query = sq.Select(opts.Columns...).From(from)
 	for _, r := range opts.Relationships {
 		if r.Table == "" {
 			r.Table = tableName
@@ -155,17 +408,87 @@ func (e *Engine) buildQuery(tableName string, opts reader.ReadTableOpt) (sq.Sele
 		query = query.Where(opts.Match)
 	}
 
-	for k, v := range opts.Sorts {
-		query = query.OrderBy(fmt.Sprintf("%s %s", k, v))
+	if len(opts.KeyAfter) > 0 {
+		after, err := e.keyAfter(tableName, opts.KeyAfter)
+		if err != nil {
+			return query, err
+		}
+		query = query.Where(after).PlaceholderFormat(e.placeholder())
+	}
+
+	// the sorts are applied in the order of their columns, maps having no order
+	sorts := make([]string, 0, len(opts.Sorts))
+	for k := range opts.Sorts {
+		sorts = append(sorts, k)
+	}
+	sort.Strings(sorts)
+	for _, k := range sorts {
+		query = query.OrderBy(fmt.Sprintf("%s %s", k, opts.Sorts[k]))
+	}
+
+	if opts.KeyOrder {
+		order, err := e.keyOrder(tableName, opts.Columns)
+		if err != nil {
+			return query, err
+		}
+		query = query.OrderBy(order...)
 	}
 
 	if opts.Limit > 0 {
 		query = query.Limit(opts.Limit)
 	}
 
+	if opts.Offset > 0 {
+		query = query.Offset(opts.Offset)
+	}
+
 	return query, nil
 }
 
+// keyAfter returns the condition matching the rows whose primary key comes after the given values,
+// the columns of a multi column key being compared as a row.
+func (e *Engine) keyAfter(tableName string, values []interface{}) (sq.Sqlizer, error) {
+	key, err := e.GetPrimaryKey(tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primary key: %w", err)
+	}
+	if len(key) != len(values) {
+		return nil, fmt.Errorf("the primary key of %s has %d columns, %d values given", tableName, len(key), len(values))
+	}
+
+	columns := e.formatColumns(tableName, key)
+	if len(columns) == 1 {
+		return sq.Expr(columns[0]+" > ?", values[0]), nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	return sq.Expr(fmt.Sprintf("(%s) > (%s)", strings.Join(columns, ", "), placeholders), values...), nil
+}
+
+// placeholder returns the placeholder format of the query parameters of the storage.
+func (e *Engine) placeholder() sq.PlaceholderFormat {
+	if c, ok := e.Storage.(Chunker); ok {
+		return c.Placeholder()
+	}
+
+	return sq.Question
+}
+
+// keyOrder returns the columns ordering the rows of a table by primary key,
+// or by all the read columns when the table has none.
+func (e *Engine) keyOrder(tableName string, columns []string) ([]string, error) {
+	key, err := e.GetPrimaryKey(tableName)
+	if err != nil && err != reader.ErrPrimaryKeysUnsupported {
+		return nil, fmt.Errorf("failed to get primary key: %w", err)
+	}
+	if len(key) > 0 {
+		return e.formatColumns(tableName, key), nil
+	}
+
+	log.WithField("table", tableName).Debug("no primary key to order the rows by, ordering them by all their columns")
+	return columns, nil
+}
+
 // FormatColumn returns a escaped table+column string
 func (e *Engine) FormatColumn(tableName string, columnName string) string {
 	return fmt.Sprintf(
@@ -175,7 +498,8 @@ func (e *Engine) FormatColumn(tableName string, columnName string) string {
 	)
 }
 
-func (e *Engine) publishRows(rows *sql.Rows, rowChan chan<- database.Row, tableName string) error {
+// publishRows publishes the rows read, the large values being read in chunks when chunks is set.
+func (e *Engine) publishRows(rows *sql.Rows, rowChan chan<- database.Row, tableName string, chunks *chunkReader) error {
 	defer rows.Close()
 
 	columnTypes, err := rows.ColumnTypes()
@@ -184,15 +508,17 @@ func (e *Engine) publishRows(rows *sql.Rows, rowChan chan<- database.Row, tableN
 	}
 
 	columnCount := len(columnTypes)
-	columns := make([]string, columnCount)
+	names := make([]string, columnCount)
+	types := make([]string, columnCount)
 	for i, col := range columnTypes {
-		columns[i] = col.Name()
+		names[i] = col.Name()
+		types[i] = col.DatabaseTypeName()
 	}
+	columns := database.NewTypedColumns(names, types)
 
 	fieldPointers := make([]interface{}, columnCount)
 
 	for rows.Next() {
-		row := make(database.Row, columnCount)
 		fields := make([]interface{}, columnCount)
 
 		for i := 0; i < columnCount; i++ {
@@ -204,13 +530,20 @@ func (e *Engine) publishRows(rows *sql.Rows, rowChan chan<- database.Row, tableN
 			continue
 		}
 
-		for idx, column := range columns {
-			row[column] = fields[idx]
+		row := database.NewRow(columns, fields)
+		if chunks != nil {
+			if err := chunks.toLOBs(row); err != nil {
+				return err
+			}
 		}
-
 		rowChan <- row
 	}
 
+	// a connection lost or a read timeout while the rows are streamed ends them early
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rows of %s: %w", tableName, err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/internal/sqlmock"
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/retry"
+)
+
+func TestBuildQueryKeyAfter(t *testing.T) {
+	t.Parallel()
+
+	e := New(&mockStorage{calls: make(map[string]int)}, 0, retry.Policy{})
+	query, err := e.buildQuery("users", reader.ReadTableOpt{
+		Columns:  []string{"*"},
+		Match:    "active = 1",
+		KeyOrder: true,
+		KeyAfter: []interface{}{int64(42)},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+
+	sql, args, err := query.ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE active = 1 AND users.id > ? ORDER BY users.id LIMIT 10", sql)
+	assert.Equal(t, []interface{}{int64(42)}, args)
+
+	_, err = e.buildQuery("users", reader.ReadTableOpt{Columns: []string{"*"}, KeyAfter: []interface{}{1, "a"}})
+	assert.EqualError(t, err, "the primary key of users has 1 columns, 2 values given")
+}
+
+func TestReadTableStreamError(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	e := New(&mockStorage{calls: make(map[string]int), conn: db}, time.Second, retry.Policy{})
+	mock.ExpectQuery(`^SELECT .* FROM users`).
+		WillReturnRows(sqlmock.NewRows("id", "email").
+			AddRow(1, "a@example.com").
+			AddRow(2, "b@example.com").
+			RowError(1, errors.New("connection reset by peer")))
+
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- e.ReadTable("users", rowChan, reader.ReadTableOpt{})
+	}()
+
+	var read int
+	for range rowChan {
+		read++
+	}
+	assert.Equal(t, 1, read)
+	assert.EqualError(t, <-errChan, "failed to read rows of users: connection reset by peer", "the table is not reported as read")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
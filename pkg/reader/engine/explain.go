@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	log "github.com/sirupsen/logrus"
+)
+
+// logQuery logs a read query with its bind values and, when explain is set, its plan.
+// Failing to explain the query is only logged, the query is read anyway.
+func (e *Engine) logQuery(logger *log.Entry, query sq.Sqlizer, explain bool) {
+	querySQL, params, err := query.ToSql()
+	if err != nil {
+		logger.WithError(err).Warn("could not build read query to log")
+		return
+	}
+
+	logger = logger.WithFields(log.Fields{"query": querySQL, "params": params})
+	if explain {
+		plan, err := e.explainQuery(querySQL, params)
+		if err != nil {
+			logger.WithError(err).Warn("could not explain read query")
+		} else {
+			logger = logger.WithField("plan", plan)
+		}
+	}
+
+	logger.Info("read query")
+}
+
+// explainQuery returns the plan of a query, one line per row of the EXPLAIN result.
+// Results of several columns, e.g. MySQL ones, are written as column=value pairs.
+func (e *Engine) explainQuery(query string, params []interface{}) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	rows, err := e.Conn().QueryContext(ctx, "EXPLAIN "+query, params...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return "", err
+		}
+
+		if len(columns) == 1 {
+			lines = append(lines, values[0].String)
+			continue
+		}
+
+		fields := make([]string, len(columns))
+		for i, column := range columns {
+			value := "NULL"
+			if values[i].Valid {
+				value = values[i].String
+			}
+			fields[i] = column + "=" + value
+		}
+		lines = append(lines, strings.Join(fields, " "))
+	}
+
+	return strings.Join(lines, "\n"), rows.Err()
+}
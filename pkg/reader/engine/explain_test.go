@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/internal/sqlmock"
+	"github.com/hellofresh/klepto/pkg/retry"
+)
+
+func TestExplainQuery(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	e := New(&mockStorage{calls: make(map[string]int), conn: db}, time.Second, retry.Policy{})
+
+	mock.ExpectQuery(`^EXPLAIN SELECT id FROM users WHERE id > \$1$`).WithArgs(10).
+		WillReturnRows(sqlmock.NewRows("QUERY PLAN").
+			AddRow("Seq Scan on users  (cost=0.00..35.50 rows=850 width=4)").
+			AddRow("  Filter: (id > 10)"))
+	plan, err := e.explainQuery("SELECT id FROM users WHERE id > $1", []interface{}{10})
+	require.NoError(t, err)
+	assert.Equal(t, "Seq Scan on users  (cost=0.00..35.50 rows=850 width=4)\n  Filter: (id > 10)", plan)
+
+	mock.ExpectQuery("^EXPLAIN SELECT id FROM orders$").
+		WillReturnRows(sqlmock.NewRows("id", "select_type", "table", "type", "key", "rows").
+			AddRow(1, "SIMPLE", "orders", "index", "PRIMARY", 42).
+			AddRow(2, "SUBQUERY", "users", "ALL", nil, 7))
+	plan, err = e.explainQuery("SELECT id FROM orders", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "id=1 select_type=SIMPLE table=orders type=index key=PRIMARY rows=42\n"+
+		"id=2 select_type=SUBQUERY table=users type=ALL key=NULL rows=7", plan)
+
+	mock.ExpectQuery("^EXPLAIN SELECT nope FROM users$").WillReturnError(errors.New("unknown column nope"))
+	_, err = e.explainQuery("SELECT nope FROM users", nil)
+	assert.EqualError(t, err, "unknown column nope")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLogQuery(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	e := New(&mockStorage{calls: make(map[string]int), conn: db}, time.Second, retry.Policy{})
+	query := sq.Select("id").From("users").Where(sq.Gt{"id": 10}).PlaceholderFormat(sq.Dollar)
+
+	logger, hook := test.NewNullLogger()
+	e.logQuery(log.NewEntry(logger), query, false)
+	require.Len(t, hook.Entries, 1)
+	assert.Equal(t, "read query", hook.LastEntry().Message)
+	assert.Equal(t, log.Fields{"query": "SELECT id FROM users WHERE id > $1", "params": []interface{}{10}}, hook.LastEntry().Data)
+
+	mock.ExpectQuery(`^EXPLAIN SELECT id FROM users WHERE id > \$1$`).WithArgs(10).
+		WillReturnRows(sqlmock.NewRows("QUERY PLAN").AddRow("Index Only Scan using users_pkey on users"))
+	hook.Reset()
+	e.logQuery(log.NewEntry(logger), query, true)
+	require.Len(t, hook.Entries, 1)
+	assert.Equal(t, "Index Only Scan using users_pkey on users", hook.LastEntry().Data["plan"])
+
+	// the query is logged, and read, even when it can not be explained
+	mock.ExpectQuery("^EXPLAIN ").WillReturnError(errors.New("permission denied"))
+	hook.Reset()
+	e.logQuery(log.NewEntry(logger), query, true)
+	require.Len(t, hook.Entries, 2)
+	assert.Equal(t, "could not explain read query", hook.Entries[0].Message)
+	assert.Equal(t, log.WarnLevel, hook.Entries[0].Level)
+	assert.Equal(t, "read query", hook.Entries[1].Message)
+	assert.NotContains(t, hook.Entries[1].Data, "plan")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
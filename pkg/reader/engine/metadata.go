@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// metadata caches the schema metadata of the storage, so that the column types, allowed values, keys and indexes
+// are queried once per run and shared by the readers, anonymisers and dumpers reading through the engine.
+// Failed queries are not cached, they are run again by the next caller.
+type metadata struct {
+	types       sync.Map
+	allowed     sync.Map
+	primaryKeys sync.Map
+	indexes     sync.Map
+
+	mu          sync.Mutex
+	foreignKeys []reader.ForeignKey
+	// loadedKeys tells whether the foreign keys are cached, a database may have none
+	loadedKeys bool
+}
+
+// schemaCache is the metadata of the tables kept between runs, see Engine.SaveSchema.
+type schemaCache struct {
+	Tables      []string                                   `json:"tables,omitempty"`
+	Columns     map[string][]string                        `json:"columns,omitempty"`
+	Types       map[string]map[string]string               `json:"types,omitempty"`
+	Allowed     map[string]map[string]reader.AllowedValues `json:"allowed,omitempty"`
+	PrimaryKeys map[string][]string                        `json:"primary_keys,omitempty"`
+	Indexes     map[string][]reader.Index                  `json:"indexes,omitempty"`
+	// ForeignKeys is null when they were not read, a database may have none
+	ForeignKeys []reader.ForeignKey `json:"foreign_keys"`
+}
+
+// LoadSchema reads the metadata of the tables from a cache written by SaveSchema,
+// the metadata missing from the cache is still introspected from the database.
+func (e *Engine) LoadSchema(r io.Reader) error {
+	var cache schemaCache
+	if err := json.NewDecoder(r).Decode(&cache); err != nil {
+		return fmt.Errorf("failed to decode schema cache: %w", err)
+	}
+
+	if len(cache.Tables) > 0 {
+		e.tables = cache.Tables
+	}
+	for table, columns := range cache.Columns {
+		e.columns.Store(table, columns)
+	}
+	for table, types := range cache.Types {
+		e.metadata.types.Store(table, types)
+	}
+	for table, values := range cache.Allowed {
+		e.metadata.allowed.Store(table, values)
+	}
+	for table, key := range cache.PrimaryKeys {
+		e.metadata.primaryKeys.Store(table, key)
+	}
+	for table, indexes := range cache.Indexes {
+		e.metadata.indexes.Store(table, indexes)
+	}
+	if cache.ForeignKeys != nil {
+		e.metadata.mu.Lock()
+		e.metadata.foreignKeys, e.metadata.loadedKeys = cache.ForeignKeys, true
+		e.metadata.mu.Unlock()
+	}
+
+	return nil
+}
+
+// SaveSchema writes the metadata of the tables read so far, including the metadata loaded from a cache.
+func (e *Engine) SaveSchema(w io.Writer) error {
+	cache := schemaCache{
+		Tables:      e.tables,
+		Columns:     make(map[string][]string),
+		Types:       make(map[string]map[string]string),
+		Allowed:     make(map[string]map[string]reader.AllowedValues),
+		PrimaryKeys: make(map[string][]string),
+		Indexes:     make(map[string][]reader.Index),
+	}
+	e.columns.Range(func(table, columns interface{}) bool {
+		cache.Columns[table.(string)] = columns.([]string)
+		return true
+	})
+	e.metadata.types.Range(func(table, types interface{}) bool {
+		cache.Types[table.(string)] = types.(map[string]string)
+		return true
+	})
+	e.metadata.allowed.Range(func(table, values interface{}) bool {
+		cache.Allowed[table.(string)] = values.(map[string]reader.AllowedValues)
+		return true
+	})
+	e.metadata.primaryKeys.Range(func(table, key interface{}) bool {
+		cache.PrimaryKeys[table.(string)] = key.([]string)
+		return true
+	})
+	e.metadata.indexes.Range(func(table, indexes interface{}) bool {
+		cache.Indexes[table.(string)] = indexes.([]reader.Index)
+		return true
+	})
+
+	e.metadata.mu.Lock()
+	if e.metadata.loadedKeys {
+		cache.ForeignKeys = e.metadata.foreignKeys
+		if cache.ForeignKeys == nil {
+			cache.ForeignKeys = []reader.ForeignKey{}
+		}
+	}
+	e.metadata.mu.Unlock()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(cache); err != nil {
+		return fmt.Errorf("failed to encode schema cache: %w", err)
+	}
+
+	return nil
+}
+
+// cached returns the value cached for a table, loading and caching it on the first call.
+func cached(cache *sync.Map, tableName string, load func() (interface{}, error)) (interface{}, error) {
+	if value, ok := cache.Load(tableName); ok {
+		return value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	value, _ = cache.LoadOrStore(tableName, value)
+	return value, nil
+}
+
+// getForeignKeys returns the cached foreign keys, loading them on the first call.
+func (m *metadata) getForeignKeys(load func() ([]reader.ForeignKey, error)) ([]reader.ForeignKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.loadedKeys {
+		keys, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		m.foreignKeys = keys
+		m.loadedKeys = true
+	}
+
+	return m.foreignKeys, nil
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/retry"
+)
+
+func TestMetadataIsCached(t *testing.T) {
+	storage := &mockStorage{calls: make(map[string]int)}
+	e := New(storage, 0, retry.Policy{})
+
+	for i := 0; i < 3; i++ {
+		types, err := e.GetColumnTypes("users")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"id": "int", "email": "varchar(255)"}, types)
+
+		key, err := e.GetPrimaryKey("users")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id"}, key)
+
+		fks, err := e.GetForeignKeys()
+		require.NoError(t, err)
+		assert.Empty(t, fks)
+	}
+
+	_, err := e.GetColumnTypes("orders")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"types users": 1, "types orders": 1, "key users": 1, "foreign keys": 1}, storage.calls)
+}
+
+func TestMetadataErrorsAreNotCached(t *testing.T) {
+	storage := &mockStorage{calls: make(map[string]int), err: errors.New("connection lost")}
+	e := New(storage, 0, retry.Policy{})
+
+	_, err := e.GetPrimaryKey("users")
+	assert.EqualError(t, err, "connection lost")
+	_, err = e.GetForeignKeys()
+	assert.EqualError(t, err, "connection lost")
+
+	storage.err = nil
+	key, err := e.GetPrimaryKey("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id"}, key)
+	_, err = e.GetForeignKeys()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int{"key users": 2, "foreign keys": 2}, storage.calls)
+
+	_, err = e.GetIndexes("users")
+	assert.Equal(t, reader.ErrIndexesUnsupported, err)
+}
+
+func TestSaveSchema(t *testing.T) {
+	storage := &mockStorage{calls: make(map[string]int)}
+	e := New(storage, 0, retry.Policy{})
+
+	_, err := e.GetTables()
+	require.NoError(t, err)
+	_, err = e.GetColumnTypes("users")
+	require.NoError(t, err)
+	_, err = e.GetPrimaryKey("users")
+	require.NoError(t, err)
+	_, err = e.GetForeignKeys()
+	require.NoError(t, err)
+
+	var cache bytes.Buffer
+	require.NoError(t, e.SaveSchema(&cache))
+
+	storage = &mockStorage{calls: make(map[string]int)}
+	e = New(storage, 0, retry.Policy{})
+	require.NoError(t, e.LoadSchema(bytes.NewReader(cache.Bytes())))
+
+	types, err := e.GetColumnTypes("users")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"id": "int", "email": "varchar(255)"}, types)
+	key, err := e.GetPrimaryKey("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id"}, key)
+	fks, err := e.GetForeignKeys()
+	require.NoError(t, err)
+	assert.Empty(t, fks)
+	assert.Empty(t, storage.calls, "the cached metadata is not introspected again")
+
+	_, err = e.GetPrimaryKey("orders")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"key orders": 1}, storage.calls)
+
+	assert.EqualError(t, e.LoadSchema(bytes.NewReader([]byte("{"))), "failed to decode schema cache: unexpected EOF")
+}
+
+type mockStorage struct {
+	calls map[string]int
+	err   error
+	conn  *sql.DB
+}
+
+func (m *mockStorage) GetStructure() (string, error)       { return "", nil }
+func (m *mockStorage) GetTables() ([]string, error)        { return []string{"users", "orders"}, nil }
+func (m *mockStorage) GetColumns(string) ([]string, error) { return []string{"id", "email"}, nil }
+func (m *mockStorage) QuoteIdentifier(name string) string  { return name }
+func (m *mockStorage) Conn() *sql.DB                       { return m.conn }
+func (m *mockStorage) Close() error                        { return nil }
+func (m *mockStorage) GetColumnTypes(tableName string) (map[string]string, error) {
+	m.calls["types "+tableName]++
+	return map[string]string{"id": "int", "email": "varchar(255)"}, m.err
+}
+func (m *mockStorage) GetPrimaryKey(tableName string) ([]string, error) {
+	m.calls["key "+tableName]++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []string{"id"}, nil
+}
+func (m *mockStorage) GetForeignKeys() ([]reader.ForeignKey, error) {
+	m.calls["foreign keys"]++
+	return nil, m.err
+}
@@ -0,0 +1,52 @@
+package reader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AddColumn adds a column to a foreign key read column by column from the schema, in order.
+func (fk *ForeignKey) AddColumn(column string, referencedColumn string) {
+	if fk.Column == "" {
+		fk.Column, fk.ReferencedColumn = column, referencedColumn
+		return
+	}
+
+	if len(fk.Columns) == 0 {
+		fk.Columns, fk.ReferencedColumns = []string{fk.Column}, []string{fk.ReferencedColumn}
+	}
+	fk.Columns = append(fk.Columns, column)
+	fk.ReferencedColumns = append(fk.ReferencedColumns, referencedColumn)
+}
+
+// KeyColumns returns the referencing columns of the foreign key.
+func (fk ForeignKey) KeyColumns() []string {
+	if len(fk.Columns) > 0 {
+		return fk.Columns
+	}
+
+	return []string{fk.Column}
+}
+
+// ReferencedKeyColumns returns the referenced columns of the foreign key.
+func (fk ForeignKey) ReferencedKeyColumns() []string {
+	if len(fk.ReferencedColumns) > 0 {
+		return fk.ReferencedColumns
+	}
+
+	return []string{fk.ReferencedColumn}
+}
+
+// String returns the foreign key as table.column -> referenced_table.referenced_column, the columns of a multi
+// column foreign key being listed in parentheses.
+func (fk ForeignKey) String() string {
+	return fmt.Sprintf("%s.%s -> %s.%s", fk.Table, columnList(fk.KeyColumns()), fk.ReferencedTable, columnList(fk.ReferencedKeyColumns()))
+}
+
+func columnList(columns []string) string {
+	if len(columns) == 1 {
+		return columns[0]
+	}
+
+	return "(" + strings.Join(columns, ", ") + ")"
+}
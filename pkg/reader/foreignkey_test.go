@@ -0,0 +1,26 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForeignKey(t *testing.T) {
+	t.Parallel()
+
+	fk := ForeignKey{Table: "orders", ReferencedTable: "users"}
+	fk.AddColumn("user_id", "id")
+	assert.Equal(t, ForeignKey{Table: "orders", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"}, fk)
+	assert.Equal(t, []string{"user_id"}, fk.KeyColumns())
+	assert.Equal(t, []string{"id"}, fk.ReferencedKeyColumns())
+	assert.Equal(t, "orders.user_id -> users.id", fk.String())
+
+	fk = ForeignKey{Table: "order_lines", ReferencedTable: "orders"}
+	fk.AddColumn("shop_id", "shop_id")
+	fk.AddColumn("order_no", "no")
+	assert.Equal(t, "shop_id", fk.Column)
+	assert.Equal(t, []string{"shop_id", "order_no"}, fk.KeyColumns())
+	assert.Equal(t, []string{"shop_id", "no"}, fk.ReferencedKeyColumns())
+	assert.Equal(t, "order_lines.(shop_id, order_no) -> orders.(shop_id, no)", fk.String())
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/go-sql-driver/mysql"
 
 	"github.com/hellofresh/klepto/pkg/reader"
+	"github.com/hellofresh/klepto/pkg/reader/engine"
 )
 
 type driver struct{}
@@ -22,7 +23,21 @@ func (m *driver) IsSupported(dsn string) bool {
 
 // NewConnection creates a new mysql connection and retrieves a new mysql reader.
 func (m *driver) NewConnection(opts reader.ConnOpts) (reader.Reader, error) {
-	conn, err := sql.Open("mysql", opts.DSN)
+	dsn := opts.DSN
+	if opts.ReadOnly {
+		var err error
+		if dsn, err = readOnlyDSN(dsn); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Vitess {
+		var err error
+		if dsn, err = vitessDSN(dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -30,8 +45,39 @@ func (m *driver) NewConnection(opts reader.ConnOpts) (reader.Reader, error) {
 	conn.SetMaxOpenConns(opts.MaxConns)
 	conn.SetMaxIdleConns(opts.MaxIdleConns)
 	conn.SetConnMaxLifetime(opts.MaxConnLifetime)
+	conn.SetConnMaxIdleTime(opts.MaxConnIdleTime)
+
+	return engine.New(newStorage(conn, opts.Vitess, opts.Views), opts.Timeout, opts.Retry), nil
+}
+
+// readOnlyDSN returns the dsn setting the transaction_read_only variable on every connection, so that the sessions
+// refuse to write (MySQL 5.7.20 and later).
+func readOnlyDSN(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Params == nil {
+		cfg.Params = map[string]string{}
+	}
+	cfg.Params["transaction_read_only"] = "1"
+
+	return cfg.FormatDSN(), nil
+}
+
+// vitessDSN returns the dsn setting the OLAP workload on every connection, so that a Vitess vtgate streams the
+// rows of the tables instead of failing the reads returning more rows than its OLTP row limit.
+func vitessDSN(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Params == nil {
+		cfg.Params = map[string]string{}
+	}
+	cfg.Params["workload"] = "'olap'"
 
-	return NewStorage(conn, opts.Timeout), nil
+	return cfg.FormatDSN(), nil
 }
 
 func init() {
@@ -0,0 +1,25 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyDSN(t *testing.T) {
+	dsn, err := readOnlyDSN("user:pass@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	assert.Equal(t, "user:pass@tcp(localhost:3306)/db?transaction_read_only=1", dsn)
+}
+
+func TestVitessDSN(t *testing.T) {
+	dsn, err := vitessDSN("user:pass@tcp(localhost:3306)/db@replica?tls=true")
+	require.NoError(t, err)
+	assert.Equal(t, "user:pass@tcp(localhost:3306)/db@replica?tls=true&workload=%27olap%27", dsn)
+}
+
+func TestQuoteString(t *testing.T) {
+	assert.Equal(t, `'Full name, as entered'`, quoteString("Full name, as entered"))
+	assert.Equal(t, `'the user''s C:\\name'`, quoteString(`the user's C:\name`))
+}
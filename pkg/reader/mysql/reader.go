@@ -7,27 +7,47 @@ import (
 	"strings"
 	"time"
 
+	sq "github.com/Masterminds/squirrel"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/hellofresh/klepto/pkg/reader"
 	"github.com/hellofresh/klepto/pkg/reader/engine"
+	"github.com/hellofresh/klepto/pkg/retry"
 )
 
 const (
 	baseTable = "BASE TABLE"
+	viewTable = "VIEW"
+	// versionedTable is the type of the MariaDB system-versioned tables, read like base tables.
+	versionedTable = "SYSTEM VERSIONED"
 )
 
 type (
 	storage struct {
 		conn *sql.DB
+		// views are the views read as tables.
+		views map[string]bool
+		// vitess avoids the statements and variables a Vitess vtgate does not support.
+		vitess bool
 	}
 )
 
-// NewStorage creates a new mysql reader.
-func NewStorage(conn *sql.DB, timeout time.Duration) reader.Reader {
-	return engine.New(&storage{
-		conn: conn,
-	}, timeout)
+// NewStorage creates a new mysql reader, the given views are read as tables.
+func NewStorage(conn *sql.DB, timeout time.Duration, policy retry.Policy, views ...string) reader.Reader {
+	return engine.New(newStorage(conn, false, views), timeout, policy)
+}
+
+func newStorage(conn *sql.DB, vitess bool, views []string) *storage {
+	s := &storage{
+		conn:   conn,
+		views:  make(map[string]bool, len(views)),
+		vitess: vitess,
+	}
+	for _, view := range views {
+		s.views[view] = true
+	}
+
+	return s
 }
 
 // GetTables gets a list of all tables in the database.
@@ -46,7 +66,7 @@ func (s *storage) GetTables() ([]string, error) {
 		if err := rows.Scan(&tableName, &tableType); err != nil {
 			return nil, err
 		}
-		if tableType == baseTable {
+		if tableType == baseTable || tableType == versionedTable || (tableType == viewTable && s.views[tableName]) {
 			tables = append(tables, tableName)
 		}
 	}
@@ -56,10 +76,12 @@ func (s *storage) GetTables() ([]string, error) {
 	return tables, nil
 }
 
-// GetColumns returns the columns in the specified database table
+// GetColumns returns the columns in the specified database table, in table order. The invisible columns,
+// e.g. the generated invisible primary keys, are listed too so that they are read and written explicitly. The period
+// columns of the system-versioned tables are left out as their values are set by the target.
 func (s *storage) GetColumns(tableName string) ([]string, error) {
 	rows, err := s.conn.Query(
-		"SELECT `column_name` FROM `information_schema`.`columns` WHERE table_schema=DATABASE() AND table_name=?",
+		"SELECT `column_name` FROM `information_schema`.`columns` WHERE table_schema=DATABASE() AND table_name=? AND `extra` NOT LIKE 'ROW START%' AND `extra` NOT LIKE 'ROW END%' ORDER BY `ordinal_position`",
 		tableName,
 	)
 	if err != nil {
@@ -77,28 +99,273 @@ func (s *storage) GetColumns(tableName string) ([]string, error) {
 		columns = append(columns, column)
 	}
 
-	return columns, nil
+	return columns, rows.Err()
+}
+
+// GetColumnTypes returns the full type of the columns in the specified database table, e.g. varchar(255)
+func (s *storage) GetColumnTypes(tableName string) (map[string]string, error) {
+	rows, err := s.conn.Query(
+		"SELECT `column_name`, `column_type` FROM `information_schema`.`columns` WHERE table_schema=DATABASE() AND table_name=?",
+		tableName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := make(map[string]string)
+	for rows.Next() {
+		var column, columnType string
+		if err := rows.Scan(&column, &columnType); err != nil {
+			return nil, err
+		}
+
+		types[column] = columnType
+	}
+
+	return types, rows.Err()
 }
 
+// GetAllowedValues returns the values of the ENUM and SET columns of the table and of the columns restricted to
+// a list by a CHECK constraint (MySQL 8.0.16 and later).
+func (s *storage) GetAllowedValues(tableName string) (map[string]reader.AllowedValues, error) {
+	types, err := s.GetColumnTypes(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]reader.AllowedValues)
+	for column, columnType := range types {
+		if values, ok := reader.ParseEnumType(columnType); ok {
+			allowed[column] = values
+		}
+	}
+
+	rows, err := s.conn.Query(
+		"SELECT cc.`check_clause` FROM `information_schema`.`table_constraints` tc "+
+			"JOIN `information_schema`.`check_constraints` cc ON cc.constraint_schema = tc.constraint_schema AND cc.constraint_name = tc.constraint_name "+
+			"WHERE tc.table_schema=DATABASE() AND tc.table_name=? AND tc.constraint_type='CHECK'",
+		tableName,
+	)
+	if err != nil {
+		// the check constraints are only known since MySQL 8.0.16
+		log.WithError(err).WithField("table", tableName).Debug("could not read the check constraints")
+		return allowed, nil
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var clause string
+		if err := rows.Scan(&clause); err != nil {
+			return nil, err
+		}
+		if column, values, ok := reader.ParseCheckValues(clause); ok {
+			if _, known := types[column]; known {
+				allowed[column] = reader.AllowedValues{Values: values}
+			}
+		}
+	}
+
+	return allowed, rows.Err()
+}
+
+// GetForeignKeys returns the foreign keys of the database tables, multi column ones included.
+func (s *storage) GetForeignKeys() ([]reader.ForeignKey, error) {
+	rows, err := s.conn.Query(
+		"SELECT `table_name`, `constraint_name`, `column_name`, `referenced_table_name`, `referenced_column_name` " +
+			"FROM `information_schema`.`key_column_usage` WHERE table_schema=DATABASE() AND referenced_table_name IS NOT NULL " +
+			"ORDER BY `table_name`, `constraint_name`, `ordinal_position`",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		foreignKeys []reader.ForeignKey
+		last        string
+	)
+	for rows.Next() {
+		var table, constraint, column, referencedTable, referencedColumn string
+		if err := rows.Scan(&table, &constraint, &column, &referencedTable, &referencedColumn); err != nil {
+			return nil, err
+		}
+
+		// the columns of a multi column foreign key are listed one after the other
+		if key := table + "." + constraint; key != last || len(foreignKeys) == 0 {
+			foreignKeys = append(foreignKeys, reader.ForeignKey{Table: table, ReferencedTable: referencedTable})
+			last = key
+		}
+		foreignKeys[len(foreignKeys)-1].AddColumn(column, referencedColumn)
+	}
+
+	return foreignKeys, rows.Err()
+}
+
+// GetForeignTables returns the FEDERATED tables, whose rows are read from a remote server.
+func (s *storage) GetForeignTables() ([]string, error) {
+	rows, err := s.conn.Query(
+		"SELECT `table_name` FROM `information_schema`.`tables` WHERE table_schema=DATABASE() AND `engine`='FEDERATED' ORDER BY `table_name`",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+// GetVersionedTables returns the MariaDB system-versioned tables and their explicit period columns.
+func (s *storage) GetVersionedTables() ([]reader.VersionedTable, error) {
+	rows, err := s.conn.Query(
+		"SELECT t.`table_name`, " +
+			"COALESCE(MAX(CASE WHEN c.`extra` LIKE 'ROW START%' THEN c.`column_name` END), ''), " +
+			"COALESCE(MAX(CASE WHEN c.`extra` LIKE 'ROW END%' THEN c.`column_name` END), '') " +
+			"FROM `information_schema`.`tables` t " +
+			"LEFT JOIN `information_schema`.`columns` c ON c.table_schema = t.table_schema AND c.table_name = t.table_name " +
+			"WHERE t.table_schema=DATABASE() AND t.`table_type`='SYSTEM VERSIONED' " +
+			"GROUP BY t.`table_name` ORDER BY t.`table_name`",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []reader.VersionedTable
+	for rows.Next() {
+		var table reader.VersionedTable
+		if err := rows.Scan(&table.Name, &table.RowStart, &table.RowEnd); err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+// GetPrimaryKey returns the primary key columns of the specified database table.
+func (s *storage) GetPrimaryKey(tableName string) ([]string, error) {
+	rows, err := s.conn.Query(
+		"SELECT `column_name` FROM `information_schema`.`key_column_usage` WHERE table_schema=DATABASE() AND table_name=? AND constraint_name='PRIMARY' ORDER BY `ordinal_position`",
+		tableName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+// GetIndexes returns the indexes of the specified database table, the primary key being named PRIMARY.
+func (s *storage) GetIndexes(tableName string) ([]reader.Index, error) {
+	rows, err := s.conn.Query(
+		"SELECT `index_name`, `column_name`, `non_unique` FROM `information_schema`.`statistics` WHERE table_schema=DATABASE() AND table_name=? ORDER BY `index_name`, `seq_in_index`",
+		tableName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []reader.Index
+	for rows.Next() {
+		var (
+			name, column string
+			nonUnique    bool
+		)
+		if err := rows.Scan(&name, &column, &nonUnique); err != nil {
+			return nil, err
+		}
+
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			indexes = append(indexes, reader.Index{Name: name, Unique: !nonUnique})
+		}
+		indexes[len(indexes)-1].Columns = append(indexes[len(indexes)-1].Columns, column)
+	}
+
+	return indexes, rows.Err()
+}
+
+// LengthFunction returns the function giving the length of a value in characters, or bytes for binary values.
+func (s *storage) LengthFunction() string { return "CHAR_LENGTH" }
+
+// Placeholder returns the question mark placeholder format.
+func (s *storage) Placeholder() sq.PlaceholderFormat { return sq.Question }
+
 // GetStructure dumps the mysql database structure.
 func (s *storage) GetStructure() (string, error) {
+	structure, _, err := s.structure(false)
+	return structure, err
+}
+
+// GetStructureSections returns the structure with the secondary indexes and foreign keys of the tables
+// moved to a post-data section, added to the tables once they are loaded.
+func (s *storage) GetStructureSections() (string, string, error) {
+	return s.structure(true)
+}
+
+// structure returns the statements creating the tables and, when split is set, the statements
+// adding their secondary indexes and foreign keys.
+func (s *storage) structure(split bool) (string, string, error) {
 	tables, err := s.GetTables()
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	preamble, err := s.getPreamble()
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	buf := bytes.NewBufferString(preamble)
+	var post strings.Builder
 	buf.WriteString("SET FOREIGN_KEY_CHECKS=0;\n")
 	for _, tableName := range tables {
+		if s.views[tableName] {
+			viewStmt, err := s.createViewTable(tableName)
+			if err != nil {
+				return "", "", err
+			}
+			buf.WriteString(viewStmt)
+			buf.WriteString(";\n")
+			continue
+		}
+
 		var stmtTableName, tableStmt string
 		err := s.conn.QueryRow(fmt.Sprintf("SHOW CREATE TABLE %s", s.QuoteIdentifier(tableName))).Scan(&stmtTableName, &tableStmt)
 		if err != nil {
-			return "", err
+			return "", "", err
+		}
+
+		if split {
+			var alterStmt string
+			tableStmt, alterStmt = splitCreateTable(s.QuoteIdentifier(tableName), tableStmt)
+			if alterStmt != "" {
+				post.WriteString(alterStmt)
+				post.WriteString(";\n")
+			}
 		}
 
 		buf.WriteString(tableStmt)
@@ -107,7 +374,46 @@ func (s *storage) GetStructure() (string, error) {
 
 	buf.WriteString("SET FOREIGN_KEY_CHECKS=1;")
 
-	return buf.String(), nil
+	return buf.String(), post.String(), nil
+}
+
+// createViewTable returns the statement creating a table with the columns of a view, and their comments.
+func (s *storage) createViewTable(view string) (string, error) {
+	rows, err := s.conn.Query(
+		"SELECT `column_name`, `column_type`, `is_nullable`, `column_comment` FROM `information_schema`.`columns` WHERE table_schema=DATABASE() AND table_name=? ORDER BY `ordinal_position`",
+		view,
+	)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column, columnType, nullable, comment string
+		if err := rows.Scan(&column, &columnType, &nullable, &comment); err != nil {
+			return "", err
+		}
+
+		definition := fmt.Sprintf("  %s %s", s.QuoteIdentifier(column), columnType)
+		if nullable == "NO" {
+			definition += " NOT NULL"
+		}
+		if comment != "" {
+			definition += " COMMENT " + quoteString(comment)
+		}
+		columns = append(columns, definition)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n)", s.QuoteIdentifier(view), strings.Join(columns, ",\n")), nil
+}
+
+// quoteString returns a single-quoted string literal, its quotes and backslashes escaped.
+func quoteString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(value) + "'"
 }
 
 // QuoteIdentifier ...
@@ -144,20 +450,24 @@ SET NAMES utf8;
 SET FOREIGN_KEY_CHECKS = 0;
 
 `
-	var hostname string
-	row := s.conn.QueryRow("SELECT @@hostname")
-	if err := row.Scan(&hostname); err != nil {
-		return "", err
+	// a vtgate does not tell the host of the tablets it routes to, nor their global variables
+	hostname, sqlModeScope := "vtgate", "SESSION"
+	if !s.vitess {
+		row := s.conn.QueryRow("SELECT @@hostname")
+		if err := row.Scan(&hostname); err != nil {
+			return "", err
+		}
+		sqlModeScope = "GLOBAL"
 	}
 
 	var db string
-	row = s.conn.QueryRow("SELECT DATABASE()")
+	row := s.conn.QueryRow("SELECT DATABASE()")
 	if err := row.Scan(&db); err != nil {
 		return "", err
 	}
 
 	var sqlMode string
-	row = s.conn.QueryRow("SELECT @@GLOBAL.SQL_MODE")
+	row = s.conn.QueryRow(fmt.Sprintf("SELECT @@%s.SQL_MODE", sqlModeScope))
 	if err := row.Scan(&sqlMode); err != nil {
 		return "", err
 	}
@@ -0,0 +1,68 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/internal/sqlmock"
+)
+
+func TestGetColumns(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	mock.ExpectQuery("SELECT `column_name` FROM `information_schema`.`columns` .* ORDER BY `ordinal_position`$").
+		WithArgs("users").
+		WillReturnRows(sqlmock.NewRows("column_name").AddRow("id").AddRow("email").AddRow("created_at"))
+
+	columns, err := newStorage(db, false, nil).GetColumns("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "email", "created_at"}, columns)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTablesViews(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	mock.ExpectQuery(`SHOW FULL TABLES`).WillReturnRows(sqlmock.NewRows("Tables_in_shop", "Table_type").
+		AddRow("active_users", "VIEW").
+		AddRow("orders", "SYSTEM VERSIONED").
+		AddRow("recent_orders", "VIEW").
+		AddRow("users", "BASE TABLE"))
+
+	tables, err := newStorage(db, false, []string{"active_users"}).GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"active_users", "orders", "users"}, tables)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStructureViews(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	mock.ExpectQuery(`SHOW FULL TABLES`).WillReturnRows(sqlmock.NewRows("Tables_in_shop", "Table_type").
+		AddRow("active_users", "VIEW").
+		AddRow("users", "BASE TABLE"))
+	mock.ExpectQuery(`SELECT @@hostname`).WillReturnRows(sqlmock.NewRows("hostname").AddRow("db1"))
+	mock.ExpectQuery(`SELECT DATABASE\(\)`).WillReturnRows(sqlmock.NewRows("database").AddRow("shop"))
+	mock.ExpectQuery(`SELECT @@GLOBAL.SQL_MODE`).WillReturnRows(sqlmock.NewRows("sql_mode").AddRow("STRICT_TRANS_TABLES"))
+	mock.ExpectQuery("SELECT `column_name`, `column_type`, `is_nullable`, `column_comment` FROM `information_schema`.`columns`").
+		WithArgs("active_users").
+		WillReturnRows(sqlmock.NewRows("column_name", "column_type", "is_nullable", "column_comment").
+			AddRow("id", "int(11)", "NO", "").
+			AddRow("email", "varchar(255)", "YES", "The user's e-mail"))
+	mock.ExpectQuery("SHOW CREATE TABLE `users`").WillReturnRows(sqlmock.NewRows("Table", "Create Table").
+		AddRow("users", "CREATE TABLE `users` (\n  `id` int(11) NOT NULL\n) ENGINE=InnoDB"))
+
+	structure, err := newStorage(db, false, []string{"active_users"}).GetStructure()
+	require.NoError(t, err)
+	assert.Contains(t, structure, "SET FOREIGN_KEY_CHECKS=0;\n"+
+		"CREATE TABLE `active_users` (\n  `id` int(11) NOT NULL,\n  `email` varchar(255) COMMENT 'The user''s e-mail'\n);\n"+
+		"CREATE TABLE `users` (\n  `id` int(11) NOT NULL\n) ENGINE=InnoDB;\n"+
+		"SET FOREIGN_KEY_CHECKS=1;")
+	assert.NotContains(t, structure, "CREATE VIEW")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,51 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// gtidWaitSeconds is the timeout of a single WAIT_FOR_EXECUTED_GTID_SET call,
+// kept short so the context cancellation is checked regularly.
+const gtidWaitSeconds = 1
+
+// errVitessReplication is returned when waiting for a replication position through a Vitess vtgate.
+var errVitessReplication = errors.New("vitess does not support waiting for a gtid set, read from a replica tablet with the @replica target of the database instead")
+
+// CurrentPosition returns the executed GTID set of the server.
+func (s *storage) CurrentPosition(ctx context.Context) (string, error) {
+	if s.vitess {
+		return "", errVitessReplication
+	}
+
+	var gtidSet string
+	if err := s.conn.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&gtidSet); err != nil {
+		return "", fmt.Errorf("failed to read executed gtid set: %w", err)
+	}
+
+	return gtidSet, nil
+}
+
+// WaitForPosition waits until the server executed the given GTID set.
+func (s *storage) WaitForPosition(ctx context.Context, gtidSet string) error {
+	if s.vitess {
+		return errVitessReplication
+	}
+
+	for {
+		var timedOut int
+		err := s.conn.QueryRowContext(ctx, "SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)", gtidSet, gtidWaitSeconds).Scan(&timedOut)
+		// the context may end while waiting, failing the query
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("replica did not reach gtid set %q: %w", gtidSet, ctxErr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to wait for gtid set: %w", err)
+		}
+
+		if timedOut == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/internal/sqlmock"
+)
+
+const gtidSet = "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5"
+
+func TestCurrentPosition(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	mock.ExpectQuery(`SELECT @@GLOBAL\.gtid_executed`).WillReturnRows(sqlmock.NewRows("gtid_executed").AddRow(gtidSet))
+	mock.ExpectQuery(`SELECT @@GLOBAL\.gtid_executed`).WillReturnError(errors.New("connection refused"))
+
+	s := newStorage(db, false, nil)
+	position, err := s.CurrentPosition(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, gtidSet, position)
+
+	_, err = s.CurrentPosition(context.Background())
+	assert.EqualError(t, err, "failed to read executed gtid set: connection refused")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = newStorage(db, true, nil).CurrentPosition(context.Background())
+	assert.Equal(t, errVitessReplication, err)
+}
+
+func TestWaitForPosition(t *testing.T) {
+	t.Parallel()
+
+	wait := `SELECT WAIT_FOR_EXECUTED_GTID_SET\(\?, \?\)`
+
+	t.Run("caught up", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := sqlmock.New(t)
+		mock.ExpectQuery(wait).WithArgs(gtidSet, gtidWaitSeconds).WillReturnRows(sqlmock.NewRows("timed_out").AddRow(0))
+
+		require.NoError(t, newStorage(db, false, nil).WaitForPosition(context.Background(), gtidSet))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("catches up", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := sqlmock.New(t)
+		mock.ExpectQuery(wait).WithArgs(gtidSet, gtidWaitSeconds).WillReturnRows(sqlmock.NewRows("timed_out").AddRow(1))
+		mock.ExpectQuery(wait).WithArgs(gtidSet, gtidWaitSeconds).WillReturnRows(sqlmock.NewRows("timed_out").AddRow(1))
+		mock.ExpectQuery(wait).WithArgs(gtidSet, gtidWaitSeconds).WillReturnRows(sqlmock.NewRows("timed_out").AddRow(0))
+
+		require.NoError(t, newStorage(db, false, nil).WaitForPosition(context.Background(), gtidSet))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := sqlmock.New(t)
+		mock.ExpectQuery(wait).WillReturnRows(sqlmock.NewRows("timed_out").AddRow(1))
+		mock.ExpectQuery(wait).WillDelayFor(time.Second).WillReturnRows(sqlmock.NewRows("timed_out").AddRow(1))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := newStorage(db, false, nil).WaitForPosition(ctx, gtidSet)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "replica did not reach gtid set")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("vitess", func(t *testing.T) {
+		t.Parallel()
+
+		db, _ := sqlmock.New(t)
+		assert.Equal(t, errVitessReplication, newStorage(db, true, nil).WaitForPosition(context.Background(), gtidSet))
+	})
+}
@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"strings"
+)
+
+// postDataPrefixes are the table definitions moved to the post-data section.
+var postDataPrefixes = []string{"KEY ", "UNIQUE KEY ", "FULLTEXT KEY ", "SPATIAL KEY "}
+
+// splitCreateTable moves the secondary indexes and the foreign keys out of a SHOW CREATE TABLE
+// statement into an ALTER TABLE statement adding them, which is empty when there are none.
+// The indexes of AUTO_INCREMENT columns are kept, the column must be indexed.
+func splitCreateTable(tableName string, stmt string) (string, string) {
+	lines := strings.Split(stmt, "\n")
+
+	var autoIncrement []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "`") && strings.Contains(line, " AUTO_INCREMENT") {
+			if end := strings.Index(line[1:], "`"); end >= 0 {
+				autoIncrement = append(autoIncrement, line[:end+2])
+			}
+		}
+	}
+
+	var kept, moved []string
+	for _, line := range lines {
+		definition := strings.TrimSuffix(strings.TrimSpace(line), ",")
+		if isPostData(definition, autoIncrement) {
+			moved = append(moved, "  ADD "+definition)
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	if len(moved) == 0 {
+		return stmt, ""
+	}
+
+	// the last definition left before the closing parenthesis must not end with a comma
+	for i := len(kept) - 1; i > 0; i-- {
+		if strings.HasPrefix(kept[i], ")") {
+			kept[i-1] = strings.TrimSuffix(kept[i-1], ",")
+			break
+		}
+	}
+
+	return strings.Join(kept, "\n"), "ALTER TABLE " + tableName + "\n" + strings.Join(moved, ",\n")
+}
+
+// isPostData reports whether a table definition is a secondary index, not indexing an AUTO_INCREMENT
+// column first, or a foreign key.
+func isPostData(definition string, autoIncrement []string) bool {
+	if strings.HasPrefix(definition, "CONSTRAINT ") {
+		return strings.Contains(definition, " FOREIGN KEY ")
+	}
+
+	for _, prefix := range postDataPrefixes {
+		if !strings.HasPrefix(definition, prefix) {
+			continue
+		}
+		for _, column := range autoIncrement {
+			if strings.Contains(definition, "("+column) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
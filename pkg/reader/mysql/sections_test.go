@@ -0,0 +1,40 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCreateTable(t *testing.T) {
+	stmt := "CREATE TABLE `orders` (\n" +
+		"  `id` int NOT NULL,\n" +
+		"  `number` int NOT NULL AUTO_INCREMENT,\n" +
+		"  `user_id` int NOT NULL,\n" +
+		"  `reference` varchar(32) NOT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `orders_reference` (`reference`),\n" +
+		"  KEY `orders_number` (`number`),\n" +
+		"  KEY `orders_user_id` (`user_id`),\n" +
+		"  CONSTRAINT `orders_user_id_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+
+	create, alter := splitCreateTable("`orders`", stmt)
+	assert.Equal(t, "CREATE TABLE `orders` (\n"+
+		"  `id` int NOT NULL,\n"+
+		"  `number` int NOT NULL AUTO_INCREMENT,\n"+
+		"  `user_id` int NOT NULL,\n"+
+		"  `reference` varchar(32) NOT NULL,\n"+
+		"  PRIMARY KEY (`id`),\n"+
+		"  KEY `orders_number` (`number`)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", create)
+	assert.Equal(t, "ALTER TABLE `orders`\n"+
+		"  ADD UNIQUE KEY `orders_reference` (`reference`),\n"+
+		"  ADD KEY `orders_user_id` (`user_id`),\n"+
+		"  ADD CONSTRAINT `orders_user_id_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE", alter)
+
+	stmt = "CREATE TABLE `users` (\n  `id` int NOT NULL,\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB"
+	create, alter = splitCreateTable("`users`", stmt)
+	assert.Equal(t, stmt, create)
+	assert.Empty(t, alter)
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"bytes"
 	"os/exec"
+	"strconv"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -12,34 +13,32 @@ type (
 	PgDump struct {
 		command string
 		dsn     string
+		// excludeTables are the tables and views left out of the structure.
+		excludeTables []string
+		// owners and privileges keep the owners and the privileges of the objects in the structure.
+		owners     bool
+		privileges bool
 	}
 )
 
-// NewPgDump creates a new PgDump.
-func NewPgDump(dsn string) (*PgDump, error) {
+// NewPgDump creates a new PgDump, the structure of the given tables is not dumped.
+func NewPgDump(dsn string, excludeTables ...string) (*PgDump, error) {
 	path, err := exec.LookPath("pg_dump")
 	if err != nil {
 		return nil, err
 	}
 
 	return &PgDump{
-		command: path,
-		dsn:     dsn,
+		command:       path,
+		dsn:           dsn,
+		excludeTables: excludeTables,
 	}, nil
 }
 
 // GetStructure executes the pg dump command.
 func (p *PgDump) GetStructure() (string, error) {
 	logger := log.WithField("command", p.command)
-
-	cmd := exec.Command(
-		p.command,
-		"--dbname", p.dsn,
-		"--schema-only",
-		"--no-privileges",
-		"--no-owner",
-		"--no-comments",
-	)
+	cmd := exec.Command(p.command, p.args()...)
 
 	logger.Debug("loading schema for table")
 	cmdErr := logger.WriterLevel(log.WarnLevel)
@@ -57,3 +56,23 @@ func (p *PgDump) GetStructure() (string, error) {
 
 	return buf.String(), nil
 }
+
+// args returns the arguments of the pg dump command.
+func (p *PgDump) args() []string {
+	args := []string{
+		"--dbname", p.dsn,
+		"--schema-only",
+	}
+	if !p.privileges {
+		args = append(args, "--no-privileges")
+	}
+	if !p.owners {
+		args = append(args, "--no-owner")
+	}
+	for _, table := range p.excludeTables {
+		// a double quoted pattern matches the name exactly
+		args = append(args, "--exclude-table", strconv.Quote(table))
+	}
+
+	return args
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPgDumpArgs(t *testing.T) {
+	t.Parallel()
+
+	p := &PgDump{dsn: "postgres://localhost/shop", excludeTables: []string{"active_users", `odd"name`}}
+	assert.Equal(t, []string{
+		"--dbname", "postgres://localhost/shop",
+		"--schema-only",
+		"--no-privileges",
+		"--no-owner",
+		"--exclude-table", `"active_users"`,
+		"--exclude-table", `"odd\"name"`,
+	}, p.args())
+
+	p = &PgDump{dsn: "postgres://localhost/shop", owners: true, privileges: true}
+	assert.Equal(t, []string{"--dbname", "postgres://localhost/shop", "--schema-only"}, p.args())
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"database/sql"
+	"net/url"
 	"strings"
 
 	_ "github.com/lib/pq" // import postgres driver
@@ -18,7 +19,15 @@ func (m *driver) IsSupported(dsn string) bool {
 
 // NewConnection takes the connection options and returns a new Reader.
 func (m *driver) NewConnection(opts reader.ConnOpts) (reader.Reader, error) {
-	conn, err := sql.Open("postgres", opts.DSN)
+	dsn := opts.DSN
+	if opts.ReadOnly {
+		var err error
+		if dsn, err = readOnlyDSN(dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -26,13 +35,31 @@ func (m *driver) NewConnection(opts reader.ConnOpts) (reader.Reader, error) {
 	conn.SetMaxOpenConns(opts.MaxConns)
 	conn.SetMaxIdleConns(opts.MaxIdleConns)
 	conn.SetConnMaxLifetime(opts.MaxConnLifetime)
+	conn.SetConnMaxIdleTime(opts.MaxConnIdleTime)
 
-	dumper, err := NewPgDump(opts.DSN)
+	// the views read as tables are created as tables instead, pg_dump only reads in its own read-only transaction
+	dumper, err := NewPgDump(opts.DSN, opts.Views...)
 	if err != nil {
 		return nil, err
 	}
+	dumper.owners = opts.Owners
+	dumper.privileges = opts.Grants
+
+	return NewStorage(conn, dumper, opts.Timeout, opts.Retry, opts.Views...), nil
+}
+
+// readOnlyDSN returns the dsn starting every session with default_transaction_read_only, so that the transactions
+// refuse to write.
+func readOnlyDSN(dsn string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("default_transaction_read_only", "on")
+	u.RawQuery = query.Encode()
 
-	return NewStorage(conn, dumper, opts.Timeout), nil
+	return u.String(), nil
 }
 
 func init() {
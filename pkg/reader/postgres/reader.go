@@ -4,18 +4,24 @@ import (
 	"database/sql"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/hellofresh/klepto/pkg/reader"
 	"github.com/hellofresh/klepto/pkg/reader/engine"
+	"github.com/hellofresh/klepto/pkg/retry"
 )
 
 type (
 	storage struct {
 		PgDumper
 		conn *sql.DB
+		// views are the views read as tables.
+		views []string
 	}
 
 	// PgDumper executes the pg dump command.
@@ -24,12 +30,13 @@ type (
 	}
 )
 
-// NewStorage creates a new postgres storage reader.
-func NewStorage(conn *sql.DB, dumper PgDumper, timeout time.Duration) reader.Reader {
+// NewStorage creates a new postgres storage reader, the given views are read as tables.
+func NewStorage(conn *sql.DB, dumper PgDumper, timeout time.Duration, policy retry.Policy, views ...string) reader.Reader {
 	return engine.New(&storage{
 		PgDumper: dumper,
 		conn:     conn,
-	}, timeout)
+		views:    views,
+	}, timeout, policy)
 }
 
 // GetTables gets a list of all tables in the database
@@ -38,8 +45,9 @@ func (s *storage) GetTables() ([]string, error) {
 	rows, err := s.conn.Query(
 		`SELECT table_name FROM information_schema.tables
 		 WHERE table_catalog=current_database()
-		 AND table_type = 'BASE TABLE'
+		 AND (table_type = 'BASE TABLE' OR (table_type = 'VIEW' AND table_name = ANY($1)))
 		 AND table_schema NOT IN ('pg_catalog', 'information_schema')`,
+		pq.Array(s.views),
 	)
 	if err != nil {
 		return nil, err
@@ -61,10 +69,12 @@ func (s *storage) GetTables() ([]string, error) {
 	return tables, nil
 }
 
+// GetColumns returns the columns of the specified table, leaving out the generated columns
+// as their values are computed by the target and can neither be inserted nor copied.
 func (s *storage) GetColumns(table string) ([]string, error) {
 	log.WithField("table", table).Debug("fetching table columns")
 	rows, err := s.conn.Query(
-		"SELECT column_name FROM information_schema.columns WHERE table_catalog=current_database() AND table_name=$1",
+		"SELECT column_name FROM information_schema.columns WHERE table_catalog=current_database() AND table_name=$1 AND is_generated <> 'ALWAYS' ORDER BY ordinal_position",
 		table,
 	)
 	if err != nil {
@@ -82,7 +92,418 @@ func (s *storage) GetColumns(table string) ([]string, error) {
 		columns = append(columns, column)
 	}
 
-	return columns, nil
+	return columns, rows.Err()
+}
+
+// GetColumnTypes returns the type of the columns in the specified table, with the length of character types.
+func (s *storage) GetColumnTypes(table string) (map[string]string, error) {
+	rows, err := s.conn.Query(
+		"SELECT column_name, udt_name, character_maximum_length FROM information_schema.columns WHERE table_catalog=current_database() AND table_name=$1",
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := make(map[string]string)
+	for rows.Next() {
+		var (
+			column, udtName string
+			length          sql.NullInt64
+		)
+		if err := rows.Scan(&column, &udtName, &length); err != nil {
+			return nil, err
+		}
+
+		if length.Valid {
+			udtName = fmt.Sprintf("%s(%d)", udtName, length.Int64)
+		}
+		types[column] = udtName
+	}
+
+	return types, rows.Err()
+}
+
+// GetAllowedValues returns the labels of the enum columns of the table and the values of the columns restricted to
+// a list by a CHECK constraint.
+func (s *storage) GetAllowedValues(table string) (map[string]reader.AllowedValues, error) {
+	allowed := make(map[string]reader.AllowedValues)
+
+	rows, err := s.conn.Query(
+		`SELECT att.attname, e.enumlabel
+		 FROM pg_attribute att
+		 JOIN pg_class cl ON cl.oid = att.attrelid
+		 JOIN pg_namespace ns ON ns.oid = cl.relnamespace
+		 JOIN pg_enum e ON e.enumtypid = att.atttypid
+		 WHERE cl.relname = $1
+		 AND ns.nspname NOT IN ('pg_catalog', 'information_schema')
+		 ORDER BY att.attnum, e.enumsortorder`,
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var column, label string
+		if err := rows.Scan(&column, &label); err != nil {
+			return nil, err
+		}
+		values := allowed[column]
+		values.Values = append(values.Values, label)
+		allowed[column] = values
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	checks, err := s.conn.Query(
+		`SELECT pg_get_constraintdef(con.oid)
+		 FROM pg_constraint con
+		 JOIN pg_class cl ON cl.oid = con.conrelid
+		 JOIN pg_namespace ns ON ns.oid = cl.relnamespace
+		 WHERE con.contype = 'c'
+		 AND cl.relname = $1
+		 AND array_length(con.conkey, 1) = 1
+		 AND ns.nspname NOT IN ('pg_catalog', 'information_schema')`,
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer checks.Close()
+
+	for checks.Next() {
+		var clause string
+		if err := checks.Scan(&clause); err != nil {
+			return nil, err
+		}
+		if column, values, ok := reader.ParseCheckValues(clause); ok {
+			allowed[column] = reader.AllowedValues{Values: values}
+		}
+	}
+
+	return allowed, checks.Err()
+}
+
+// GetForeignKeys returns the foreign keys of the database tables, multi column ones included.
+func (s *storage) GetForeignKeys() ([]reader.ForeignKey, error) {
+	rows, err := s.conn.Query(
+		`SELECT cl.relname, con.conname, att.attname, fcl.relname, fatt.attname
+		 FROM pg_constraint con
+		 JOIN pg_class cl ON cl.oid = con.conrelid
+		 JOIN pg_namespace ns ON ns.oid = cl.relnamespace
+		 JOIN pg_class fcl ON fcl.oid = con.confrelid
+		 CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, fattnum, n)
+		 JOIN pg_attribute att ON att.attrelid = con.conrelid AND att.attnum = k.attnum
+		 JOIN pg_attribute fatt ON fatt.attrelid = con.confrelid AND fatt.attnum = k.fattnum
+		 WHERE con.contype = 'f'
+		 AND ns.nspname NOT IN ('pg_catalog', 'information_schema')
+		 ORDER BY cl.relname, con.conname, k.n`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		foreignKeys []reader.ForeignKey
+		last        string
+	)
+	for rows.Next() {
+		var table, constraint, column, referencedTable, referencedColumn string
+		if err := rows.Scan(&table, &constraint, &column, &referencedTable, &referencedColumn); err != nil {
+			return nil, err
+		}
+
+		// the columns of a multi column foreign key are listed one after the other
+		if key := table + "." + constraint; key != last || len(foreignKeys) == 0 {
+			foreignKeys = append(foreignKeys, reader.ForeignKey{Table: table, ReferencedTable: referencedTable})
+			last = key
+		}
+		foreignKeys[len(foreignKeys)-1].AddColumn(column, referencedColumn)
+	}
+
+	return foreignKeys, rows.Err()
+}
+
+// GetExtensions returns the extensions the visible tables, views, sequences and indexes depend on, through the types
+// of their columns, their defaults, constraints, operator classes or the functions of the views. The objects that are
+// themselves members of an extension, e.g. the spatial_ref_sys table of postgis, are left out.
+func (s *storage) GetExtensions() ([]reader.Extension, error) {
+	rows, err := s.conn.Query(
+		`SELECT DISTINCT e.extname, ns.nspname
+		 FROM pg_depend d
+		 JOIN pg_depend member ON member.classid = d.refclassid AND member.objid = d.refobjid
+		  AND member.refclassid = 'pg_extension'::regclass AND member.deptype = 'e'
+		 JOIN pg_extension e ON e.oid = member.refobjid
+		 JOIN pg_namespace ns ON ns.oid = e.extnamespace
+		 WHERE d.deptype IN ('n', 'a') AND (
+		  d.classid = 'pg_class'::regclass AND d.objid IN (
+		   SELECT cl.oid FROM pg_class cl WHERE pg_table_is_visible(cl.oid) AND cl.relkind IN ('r', 'p', 'v', 'm', 'S', 'i'))
+		  OR d.classid = 'pg_attrdef'::regclass AND d.objid IN (
+		   SELECT ad.oid FROM pg_attrdef ad WHERE pg_table_is_visible(ad.adrelid))
+		  OR d.classid = 'pg_constraint'::regclass AND d.objid IN (
+		   SELECT con.oid FROM pg_constraint con WHERE con.conrelid <> 0 AND pg_table_is_visible(con.conrelid))
+		  OR d.classid = 'pg_rewrite'::regclass AND d.objid IN (
+		   SELECT rw.oid FROM pg_rewrite rw WHERE pg_table_is_visible(rw.ev_class)))
+		 AND NOT EXISTS (
+		  SELECT 1 FROM pg_depend own WHERE own.classid = d.classid AND own.objid = d.objid AND own.deptype = 'e')
+		 ORDER BY e.extname`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var extensions []reader.Extension
+	for rows.Next() {
+		var extension reader.Extension
+		if err := rows.Scan(&extension.Name, &extension.Schema); err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, extension)
+	}
+
+	return extensions, rows.Err()
+}
+
+// GetForeignTables returns the visible foreign tables, whose rows are read through a foreign data wrapper.
+func (s *storage) GetForeignTables() ([]string, error) {
+	rows, err := s.conn.Query(
+		`SELECT cl.relname FROM pg_class cl WHERE cl.relkind = 'f' AND pg_table_is_visible(cl.oid) ORDER BY cl.relname`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+// defaultRowsPerBlock is the amount of rows per block assumed for the tables that were never analyzed.
+const defaultRowsPerBlock = 100
+
+// GetPhysicalPages returns the conditions matching the rows of each page of a table by ctid, the pages being ranges
+// of blocks holding about pageSize rows according to the statistics of the table.
+func (s *storage) GetPhysicalPages(tableName string, pageSize uint64) ([]string, error) {
+	var (
+		blocks, pages uint64
+		tuples        float64
+	)
+	err := s.conn.QueryRow(
+		`SELECT pg_relation_size(cl.oid) / current_setting('block_size')::bigint, cl.relpages, cl.reltuples
+		 FROM pg_class cl WHERE cl.oid = to_regclass($1)`,
+		s.QuoteIdentifier(tableName),
+	).Scan(&blocks, &pages, &tuples)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the size of %s: %w", tableName, err)
+	}
+
+	rowsPerBlock := float64(defaultRowsPerBlock)
+	if pages > 0 && tuples > 0 {
+		rowsPerBlock = tuples / float64(pages)
+	}
+
+	return blockRanges(s.QuoteIdentifier(tableName)+".ctid", blocks, uint64(float64(pageSize)/rowsPerBlock)), nil
+}
+
+// blockRanges returns the conditions matching the rows of the blocks of a table by ranges of step blocks, the last
+// one matching the rows stored after the given amount of blocks too.
+func blockRanges(ctid string, blocks uint64, step uint64) []string {
+	if step < 1 {
+		step = 1
+	}
+
+	var conditions []string
+	start := uint64(0)
+	for ; start+step < blocks; start += step {
+		conditions = append(conditions, fmt.Sprintf("%s >= '(%d,0)'::tid AND %s < '(%d,0)'::tid", ctid, start, ctid, start+step))
+	}
+
+	return append(conditions, fmt.Sprintf("%s >= '(%d,0)'::tid", ctid, start))
+}
+
+// GetPrimaryKey returns the primary key columns of the specified table.
+func (s *storage) GetPrimaryKey(table string) ([]string, error) {
+	rows, err := s.conn.Query(
+		`SELECT att.attname
+		 FROM pg_index idx
+		 JOIN pg_class cl ON cl.oid = idx.indrelid
+		 JOIN pg_attribute att ON att.attrelid = idx.indrelid AND att.attnum = ANY(idx.indkey)
+		 WHERE cl.relname = $1 AND idx.indisprimary AND pg_table_is_visible(cl.oid)
+		 ORDER BY array_position(idx.indkey::int2[], att.attnum)`,
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+// GetIndexes returns the indexes of the specified table, leaving out the expressions of the expression indexes.
+func (s *storage) GetIndexes(table string) ([]reader.Index, error) {
+	rows, err := s.conn.Query(
+		`SELECT ic.relname, att.attname, idx.indisunique
+		 FROM pg_index idx
+		 JOIN pg_class cl ON cl.oid = idx.indrelid
+		 JOIN pg_class ic ON ic.oid = idx.indexrelid
+		 JOIN pg_attribute att ON att.attrelid = idx.indrelid AND att.attnum = ANY(idx.indkey)
+		 WHERE cl.relname = $1 AND pg_table_is_visible(cl.oid)
+		 ORDER BY ic.relname, array_position(idx.indkey::int2[], att.attnum)`,
+		table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []reader.Index
+	for rows.Next() {
+		var (
+			name, column string
+			unique       bool
+		)
+		if err := rows.Scan(&name, &column, &unique); err != nil {
+			return nil, err
+		}
+
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			indexes = append(indexes, reader.Index{Name: name, Unique: unique})
+		}
+		indexes[len(indexes)-1].Columns = append(indexes[len(indexes)-1].Columns, column)
+	}
+
+	return indexes, rows.Err()
+}
+
+// LengthFunction returns the function giving the length of a value in characters, or bytes for bytea values.
+func (s *storage) LengthFunction() string { return "LENGTH" }
+
+// Placeholder returns the dollar placeholder format.
+func (s *storage) Placeholder() sq.PlaceholderFormat { return sq.Dollar }
+
+// GetStructure returns the database structure, the views read as tables being created as tables.
+func (s *storage) GetStructure() (string, error) {
+	var buf strings.Builder
+	for _, view := range s.views {
+		stmt, err := s.createViewTable(view)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(stmt)
+	}
+
+	structure, err := s.PgDumper.GetStructure()
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String() + structure, nil
+}
+
+// GetStructureSections splits the structure into its pre-data and post-data sections.
+func (s *storage) GetStructureSections() (string, string, error) {
+	structure, err := s.GetStructure()
+	if err != nil {
+		return "", "", err
+	}
+
+	preData, postData := SplitSections(structure)
+	return preData, postData, nil
+}
+
+// createViewTable returns the statement creating a table with the columns of a view, and the statements carrying the
+// comments of the view and its columns, framed like pg_dump objects.
+func (s *storage) createViewTable(view string) (string, error) {
+	rows, err := s.conn.Query(
+		`SELECT ns.nspname, att.attname, format_type(att.atttypid, att.atttypmod),
+		 obj_description(cl.oid, 'pg_class'), col_description(cl.oid, att.attnum)
+		 FROM pg_attribute att
+		 JOIN pg_class cl ON cl.oid = att.attrelid
+		 JOIN pg_namespace ns ON ns.oid = cl.relnamespace
+		 WHERE cl.relname = $1 AND cl.relkind = 'v' AND pg_table_is_visible(cl.oid)
+		 AND att.attnum > 0 AND NOT att.attisdropped
+		 ORDER BY att.attnum`,
+		view,
+	)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var (
+		schema   string
+		columns  []string
+		comment  sql.NullString
+		comments strings.Builder
+	)
+	for rows.Next() {
+		var (
+			column, columnType string
+			columnComment      sql.NullString
+		)
+		if err := rows.Scan(&schema, &column, &columnType, &comment, &columnComment); err != nil {
+			return "", err
+		}
+
+		columns = append(columns, fmt.Sprintf("    %s %s", s.QuoteIdentifier(column), columnType))
+		if columnComment.Valid {
+			fmt.Fprintf(&comments,
+				"--\n-- Name: COLUMN %s.%s; Type: COMMENT; Schema: %s; Owner: -\n--\n\nCOMMENT ON COLUMN %s.%s.%s IS %s;\n\n",
+				view, column, schema, s.QuoteIdentifier(schema), s.QuoteIdentifier(view), s.QuoteIdentifier(column),
+				quoteLiteral(columnComment.String),
+			)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("view %s does not exist", view)
+	}
+
+	stmt := fmt.Sprintf(
+		"--\n-- Name: %s; Type: TABLE; Schema: %s; Owner: -\n--\n\nCREATE TABLE %s.%s (\n%s\n);\n\n",
+		view, schema, s.QuoteIdentifier(schema), s.QuoteIdentifier(view), strings.Join(columns, ",\n"),
+	)
+	if comment.Valid {
+		stmt += fmt.Sprintf(
+			"--\n-- Name: TABLE %s; Type: COMMENT; Schema: %s; Owner: -\n--\n\nCOMMENT ON TABLE %s.%s IS %s;\n\n",
+			view, schema, s.QuoteIdentifier(schema), s.QuoteIdentifier(view), quoteLiteral(comment.String),
+		)
+	}
+
+	return stmt + comments.String(), nil
+}
+
+// quoteLiteral returns a single-quoted string literal.
+func quoteLiteral(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
 }
 
 // QuoteIdentifier returns a double-quoted name.
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lib/pq"
+
+	"github.com/hellofresh/klepto/pkg/internal/sqlmock"
+)
+
+type mockPgDump struct {
+	structure string
+}
+
+func (m *mockPgDump) GetStructure() (string, error) {
+	return m.structure, nil
+}
+
+func TestGetColumns(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	mock.ExpectQuery(`SELECT column_name FROM information_schema\.columns .* AND is_generated <> 'ALWAYS' ORDER BY ordinal_position$`).
+		WithArgs("users").
+		WillReturnRows(sqlmock.NewRows("column_name").AddRow("id").AddRow("email").AddRow("created_at"))
+
+	columns, err := (&storage{conn: db}).GetColumns("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "email", "created_at"}, columns)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBlockRanges(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{
+		`"logs".ctid >= '(0,0)'::tid AND "logs".ctid < '(4,0)'::tid`,
+		`"logs".ctid >= '(4,0)'::tid AND "logs".ctid < '(8,0)'::tid`,
+		`"logs".ctid >= '(8,0)'::tid`,
+	}, blockRanges(`"logs".ctid`, 10, 4))
+
+	assert.Equal(t, []string{`"logs".ctid >= '(0,0)'::tid`}, blockRanges(`"logs".ctid`, 0, 4))
+	assert.Len(t, blockRanges(`"logs".ctid`, 3, 0), 3)
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `'Full name, as entered'`, quoteLiteral("Full name, as entered"))
+	assert.Equal(t, `'the user''s C:\name'`, quoteLiteral(`the user's C:\name`))
+}
+
+func TestGetTablesViews(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	mock.ExpectQuery(`table_type = 'BASE TABLE' OR \(table_type = 'VIEW' AND table_name = ANY\(\$1\)\)`).
+		WithArgs(pq.Array([]string{"active_users"})).
+		WillReturnRows(sqlmock.NewRows("table_name").AddRow("active_users").AddRow("users"))
+
+	tables, err := (&storage{conn: db, views: []string{"active_users"}}).GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"active_users", "users"}, tables)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStructureViews(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	mock.ExpectQuery(`WHERE cl\.relname = \$1 AND cl\.relkind = 'v'`).
+		WithArgs("active_users").
+		WillReturnRows(sqlmock.NewRows("nspname", "attname", "format_type", "obj_description", "col_description").
+			AddRow("public", "id", "integer", "Users active this month", nil).
+			AddRow("public", "email", "character varying(255)", "Users active this month", "The user's e-mail"))
+	mock.ExpectQuery(`FROM pg_attribute att`).WithArgs("missing").WillReturnRows(
+		sqlmock.NewRows("nspname", "attname", "format_type", "obj_description", "col_description"))
+
+	s := &storage{
+		PgDumper: &mockPgDump{structure: "CREATE TABLE public.users (\n    id integer\n);\n"},
+		conn:     db,
+		views:    []string{"active_users"},
+	}
+	structure, err := s.GetStructure()
+	require.NoError(t, err)
+	assert.Equal(t, "--\n-- Name: active_users; Type: TABLE; Schema: public; Owner: -\n--\n\n"+
+		"CREATE TABLE \"public\".\"active_users\" (\n    \"id\" integer,\n    \"email\" character varying(255)\n);\n\n"+
+		"--\n-- Name: TABLE active_users; Type: COMMENT; Schema: public; Owner: -\n--\n\n"+
+		"COMMENT ON TABLE \"public\".\"active_users\" IS 'Users active this month';\n\n"+
+		"--\n-- Name: COLUMN active_users.email; Type: COMMENT; Schema: public; Owner: -\n--\n\n"+
+		"COMMENT ON COLUMN \"public\".\"active_users\".\"email\" IS 'The user''s e-mail';\n\n"+
+		"CREATE TABLE public.users (\n    id integer\n);\n", structure)
+
+	s.views = []string{"missing"}
+	_, err = s.GetStructure()
+	assert.EqualError(t, err, "view missing does not exist")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
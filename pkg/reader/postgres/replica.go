@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// lsnPollInterval is the interval between two checks of the replayed LSN.
+const lsnPollInterval = 500 * time.Millisecond
+
+// CurrentPosition returns the WAL position of the server, the replayed one when it is a replica.
+func (s *storage) CurrentPosition(ctx context.Context) (string, error) {
+	var lsn string
+	err := s.conn.QueryRowContext(
+		ctx,
+		"SELECT (CASE WHEN pg_is_in_recovery() THEN pg_last_wal_replay_lsn() ELSE pg_current_wal_lsn() END)::text",
+	).Scan(&lsn)
+	if err != nil {
+		return "", fmt.Errorf("failed to read wal position: %w", err)
+	}
+
+	return lsn, nil
+}
+
+// WaitForPosition waits until the server replayed the WAL up to the given LSN.
+func (s *storage) WaitForPosition(ctx context.Context, lsn string) error {
+	ticker := time.NewTicker(lsnPollInterval)
+	defer ticker.Stop()
+
+	for {
+		var reached bool
+		err := s.conn.QueryRowContext(
+			ctx,
+			"SELECT NOT pg_is_in_recovery() OR pg_last_wal_replay_lsn() >= $1::pg_lsn",
+			lsn,
+		).Scan(&reached)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("replica did not reach lsn %s: %w", lsn, ctxErr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check replayed wal position: %w", err)
+		}
+
+		if reached {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("replica did not reach lsn %s: %w", lsn, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
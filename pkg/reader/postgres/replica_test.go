@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/internal/sqlmock"
+)
+
+const lsn = "0/3000060"
+
+func TestCurrentPosition(t *testing.T) {
+	t.Parallel()
+
+	db, mock := sqlmock.New(t)
+	mock.ExpectQuery(`pg_last_wal_replay_lsn\(\) ELSE pg_current_wal_lsn\(\)`).WillReturnRows(sqlmock.NewRows("lsn").AddRow(lsn))
+	mock.ExpectQuery(`pg_current_wal_lsn`).WillReturnError(errors.New("connection refused"))
+
+	s := &storage{conn: db}
+	position, err := s.CurrentPosition(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, lsn, position)
+
+	_, err = s.CurrentPosition(context.Background())
+	assert.EqualError(t, err, "failed to read wal position: connection refused")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWaitForPosition(t *testing.T) {
+	t.Parallel()
+
+	replayed := `SELECT NOT pg_is_in_recovery\(\) OR pg_last_wal_replay_lsn\(\) >= \$1::pg_lsn`
+
+	t.Run("caught up", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := sqlmock.New(t)
+		mock.ExpectQuery(replayed).WithArgs(lsn).WillReturnRows(sqlmock.NewRows("reached").AddRow(true))
+
+		require.NoError(t, (&storage{conn: db}).WaitForPosition(context.Background(), lsn))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("catches up", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := sqlmock.New(t)
+		mock.ExpectQuery(replayed).WithArgs(lsn).WillReturnRows(sqlmock.NewRows("reached").AddRow(false))
+		mock.ExpectQuery(replayed).WithArgs(lsn).WillReturnRows(sqlmock.NewRows("reached").AddRow(true))
+
+		require.NoError(t, (&storage{conn: db}).WaitForPosition(context.Background(), lsn))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := sqlmock.New(t)
+		mock.ExpectQuery(replayed).WithArgs(lsn).WillReturnRows(sqlmock.NewRows("reached").AddRow(false))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := (&storage{conn: db}).WaitForPosition(ctx, lsn)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "replica did not reach lsn 0/3000060")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+var (
+	// objectHeader matches the comment pg_dump writes before each object, e.g.
+	// "-- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: -"
+	objectHeader = regexp.MustCompile(`^-- (?:Data for )?Name: (.*); Type: ([^;]+);`)
+	// postDataTypes are the object types pg_dump places in the post-data section.
+	postDataTypes = map[string]bool{
+		"CONSTRAINT":             true,
+		"FK CONSTRAINT":          true,
+		"CHECK CONSTRAINT":       true,
+		"INDEX":                  true,
+		"INDEX ATTACH":           true,
+		"TRIGGER":                true,
+		"EVENT TRIGGER":          true,
+		"RULE":                   true,
+		"POLICY":                 true,
+		"PUBLICATION TABLE":      true,
+		"MATERIALIZED VIEW DATA": true,
+	}
+	// postDataComment matches the name of the comments on the post-data objects, e.g. "INDEX users_name_idx", which
+	// must follow them.
+	postDataComment = regexp.MustCompile(`^(?:CONSTRAINT|INDEX|TRIGGER|EVENT TRIGGER|RULE|POLICY) `)
+)
+
+// SplitSections splits a pg_dump schema into its pre-data and post-data sections, the post-data
+// section holding the constraints, indexes and triggers that are best created once the data is loaded.
+func SplitSections(structure string) (string, string) {
+	var pre, post strings.Builder
+
+	current := &pre
+	var pending string
+	scanner := bufio.NewScanner(strings.NewReader(structure))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// pg_dump frames each object header with a "--" line, hold it until we know the section
+		if line == "--" && pending == "" {
+			pending = line + "\n"
+			continue
+		}
+
+		if m := objectHeader.FindStringSubmatch(line); m != nil {
+			current = &pre
+			if postDataTypes[m[2]] || m[2] == "COMMENT" && postDataComment.MatchString(m[1]) {
+				current = &post
+			}
+		}
+
+		current.WriteString(pending)
+		current.WriteString(line + "\n")
+		pending = ""
+	}
+	current.WriteString(pending)
+
+	return pre.String(), post.String()
+}
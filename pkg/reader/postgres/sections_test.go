@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const structure = `SET statement_timeout = 0;
+
+--
+-- Name: users; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    name text
+);
+
+--
+-- Name: COLUMN users.name; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.users.name IS 'Full name, as entered';
+
+--
+-- Name: users_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.users_id_seq;
+
+ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
+
+--
+-- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+--
+-- Name: users_name_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX users_name_idx ON public.users USING btree (name);
+
+--
+-- Name: INDEX users_name_idx; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON INDEX public.users_name_idx IS 'Name lookups';
+`
+
+func TestSplitSections(t *testing.T) {
+	pre, post := SplitSections(structure)
+
+	assert.Contains(t, pre, "CREATE TABLE public.users")
+	assert.Contains(t, pre, "CREATE SEQUENCE public.users_id_seq")
+	assert.NotContains(t, pre, "ADD CONSTRAINT")
+	assert.NotContains(t, pre, "CREATE INDEX")
+	assert.Contains(t, pre, "COMMENT ON COLUMN public.users.name")
+	assert.NotContains(t, pre, "COMMENT ON INDEX")
+
+	assert.Contains(t, post, "ADD CONSTRAINT users_pkey PRIMARY KEY (id)")
+	assert.Contains(t, post, "CREATE INDEX users_name_idx")
+	assert.NotContains(t, post, "CREATE TABLE")
+	assert.Contains(t, post, "COMMENT ON INDEX public.users_name_idx")
+
+	assert.Equal(t, len(structure), len(pre)+len(post))
+}
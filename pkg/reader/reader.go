@@ -1,11 +1,55 @@
 package reader
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/hellofresh/klepto/pkg/config"
 	"github.com/hellofresh/klepto/pkg/database"
+	parser "github.com/hellofresh/klepto/pkg/dsn"
+	"github.com/hellofresh/klepto/pkg/retry"
+)
+
+var (
+	// ErrReplicationUnsupported is returned when the reader can not wait for a replication position.
+	ErrReplicationUnsupported = errors.New("the reader does not support waiting for a replication position")
+	// ErrColumnTypesUnsupported is returned when the reader does not know the type of the columns.
+	ErrColumnTypesUnsupported = errors.New("the reader does not support reading column types")
+	// ErrForeignKeysUnsupported is returned when the reader does not know the foreign keys of the tables.
+	ErrForeignKeysUnsupported = errors.New("the reader does not support reading foreign keys")
+	// ErrAllowedValuesUnsupported is returned when the reader does not know the values allowed in the columns.
+	ErrAllowedValuesUnsupported = errors.New("the reader does not support reading the allowed values of the columns")
+	// ErrChunksUnsupported is returned when the reader can not read large values in chunks.
+	ErrChunksUnsupported = errors.New("the reader does not support reading large values in chunks")
+	// ErrExecUnsupported is returned when the reader can not execute statements.
+	ErrExecUnsupported = errors.New("the reader does not support executing statements")
+	// ErrQueryUnsupported is returned when the reader can not run queries returning a value.
+	ErrQueryUnsupported = errors.New("the reader does not support running queries")
+	// ErrCountUnsupported is returned when the reader can not count the rows of the tables.
+	ErrCountUnsupported = errors.New("the reader does not support counting rows")
+	// ErrSectionsUnsupported is returned when the reader can not split its structure into sections.
+	ErrSectionsUnsupported = errors.New("the reader does not support splitting the structure into pre-data and post-data sections")
+	// ErrQueryLogUnsupported is returned when the reader can not log its read queries.
+	ErrQueryLogUnsupported = errors.New("the reader does not support logging read queries")
+	// ErrIndexesUnsupported is returned when the reader does not know the indexes of the tables.
+	ErrIndexesUnsupported = errors.New("the reader does not support reading indexes")
+	// ErrSchemaCacheUnsupported is returned when the reader can not keep the metadata of the tables between runs.
+	ErrSchemaCacheUnsupported = errors.New("the reader does not support caching the schema metadata")
+	// ErrPrimaryKeysUnsupported is returned when the reader does not know the primary key of the tables.
+	ErrPrimaryKeysUnsupported = errors.New("the reader does not support reading primary keys")
+	// ErrFilterCheckUnsupported is returned when the reader can not check the table filters before reading the tables.
+	ErrFilterCheckUnsupported = errors.New("the reader does not support checking the table filters")
+	// ErrPhysicalPagesUnsupported is returned when the reader can not read the tables by physical position.
+	ErrPhysicalPagesUnsupported = errors.New("the reader does not support reading tables by physical position")
+	// ErrExtensionsUnsupported is returned when the reader can not list the extensions its objects depend on.
+	ErrExtensionsUnsupported = errors.New("the reader does not support listing extensions")
+	// ErrForeignTablesUnsupported is returned when the reader can not list its foreign tables.
+	ErrForeignTablesUnsupported = errors.New("the reader does not support listing foreign tables")
+	// ErrVersionedTablesUnsupported is returned when the reader can not list its system-versioned tables.
+	ErrVersionedTablesUnsupported = errors.New("the reader does not support listing system-versioned tables")
 )
 
 type (
@@ -33,6 +77,174 @@ type (
 		Close() error
 	}
 
+	// ReplicaWaiter is implemented by readers that can wait for a replica to catch up with its primary.
+	ReplicaWaiter interface {
+		// CurrentPosition returns the replication position the database is at (a GTID set or a LSN).
+		CurrentPosition(ctx context.Context) (string, error)
+		// WaitForPosition blocks until the database applied the given replication position.
+		WaitForPosition(ctx context.Context, position string) error
+	}
+
+	// ColumnTyper is implemented by readers that know the SQL type of the table columns.
+	ColumnTyper interface {
+		// GetColumnTypes returns the SQL type of each column of a table (e.g. varchar(255)), keyed by column name.
+		GetColumnTypes(tableName string) (map[string]string, error)
+	}
+
+	// ForeignKeyer is implemented by readers that know the foreign keys of the tables.
+	ForeignKeyer interface {
+		// GetForeignKeys returns the single column foreign keys of all the tables.
+		GetForeignKeys() ([]ForeignKey, error)
+	}
+
+	// Indexer is implemented by readers that know the indexes of the tables.
+	Indexer interface {
+		// GetIndexes returns the indexes of a table, with their columns in index order.
+		GetIndexes(tableName string) ([]Index, error)
+	}
+
+	// AllowedValuer is implemented by readers that know the columns restricted to a list of values, by their ENUM or
+	// SET type or a CHECK constraint.
+	AllowedValuer interface {
+		// GetAllowedValues returns the values allowed in the restricted columns of a table, keyed by column name.
+		GetAllowedValues(tableName string) (map[string]AllowedValues, error)
+	}
+
+	// PrimaryKeyer is implemented by readers that know the primary key of the tables.
+	PrimaryKeyer interface {
+		// GetPrimaryKey returns the primary key columns of a table, none when it has no primary key.
+		GetPrimaryKey(tableName string) ([]string, error)
+	}
+
+	// Executor is implemented by readers that can execute statements on the source, e.g. the before read hooks.
+	Executor interface {
+		// Exec executes the statement.
+		Exec(query string) error
+	}
+
+	// Querier is implemented by readers that can run a query returning a single value, e.g. the target assertions.
+	Querier interface {
+		// QueryValue returns the first column of the first row of the query, NULL being returned as an empty
+		// string, and false when the query returns no row.
+		QueryValue(query string) (string, bool, error)
+	}
+
+	// RowCounter is implemented by readers that can count the rows of the tables, e.g. for the row thresholds.
+	RowCounter interface {
+		// CountRows returns the amount of rows of a table.
+		CountRows(tableName string) (uint64, error)
+	}
+
+	// PhysicalPager is implemented by readers that can read the tables by physical position, e.g. the tables
+	// without a primary key to paginate them by.
+	PhysicalPager interface {
+		// GetPhysicalPages returns the conditions matching the rows of each page of about pageSize rows of a
+		// table, in physical order, the last one matching the rows stored after it too.
+		GetPhysicalPages(tableName string, pageSize uint64) ([]string, error)
+	}
+
+	// ExtensionLister is implemented by readers whose objects may depend on extensions, e.g. a postgres column of the
+	// citext type or an index using the gin_trgm_ops operator class of pg_trgm.
+	ExtensionLister interface {
+		// GetExtensions returns the extensions the tables, their columns, indexes, constraints and views depend on.
+		GetExtensions() ([]Extension, error)
+	}
+
+	// ForeignTableLister is implemented by readers whose tables may be stored on a remote system, e.g. the foreign
+	// tables of a postgres foreign data wrapper or the FEDERATED tables of MySQL.
+	ForeignTableLister interface {
+		// GetForeignTables returns the names of the tables whose rows are read from a remote system.
+		GetForeignTables() ([]string, error)
+	}
+
+	// VersionedTableLister is implemented by readers whose tables may keep the history of their rows, e.g. the
+	// system-versioned tables of MariaDB.
+	VersionedTableLister interface {
+		// GetVersionedTables returns the system-versioned tables and the columns of their period.
+		GetVersionedTables() ([]VersionedTable, error)
+	}
+
+	// VersionedTable is a system-versioned table, reading its current rows unless its history is asked for.
+	VersionedTable struct {
+		// Name is the name of the table.
+		Name string
+		// RowStart and RowEnd are the columns the period of the rows starts and ends at, empty when they are
+		// implicit, i.e. the ROW_START and ROW_END pseudo columns of MariaDB.
+		RowStart string
+		RowEnd   string
+	}
+
+	// Extension is an extension of the source database.
+	Extension struct {
+		// Name is the name of the extension, e.g. uuid-ossp.
+		Name string
+		// Schema is the schema the objects of the extension are created in.
+		Schema string
+	}
+
+	// Sectioner is implemented by readers that can split their structure like pg_dump does.
+	Sectioner interface {
+		// GetStructureSections returns the statements creating the tables (pre-data) and the ones creating
+		// their indexes, constraints and triggers (post-data), the latter being run once the data is loaded.
+		GetStructureSections() (preData string, postData string, err error)
+	}
+
+	// QueryLogger is implemented by readers that can log the queries they read the tables with.
+	QueryLogger interface {
+		// LogQueries logs the read queries with their bind values, explaining them first when explain is set.
+		LogQueries(explain bool)
+	}
+
+	// SchemaCacher is implemented by readers that can keep the metadata of the tables between runs.
+	SchemaCacher interface {
+		// LoadSchema reads the metadata of the tables from a cache written by SaveSchema, so that it is not
+		// introspected from the database again.
+		LoadSchema(r io.Reader) error
+		// SaveSchema writes the metadata of the tables known so far.
+		SaveSchema(w io.Writer) error
+	}
+
+	// FilterChecker is implemented by readers that can check the read query of a table before reading it.
+	FilterChecker interface {
+		// CheckFilter runs the read query of the table without reading any row, returning the error of the source
+		// when it rejects the query, e.g. for a Match filter referencing an unknown column.
+		CheckFilter(tableName string, opts ReadTableOpt) error
+	}
+
+	// ForeignKey is a column, or several, referencing the key of another table.
+	ForeignKey struct {
+		// Table is the referencing table name.
+		Table string `json:"table"`
+		// Column is the referencing column name, the first one of a multi column foreign key.
+		Column string `json:"column"`
+		// ReferencedTable is the referenced table name.
+		ReferencedTable string `json:"referenced_table"`
+		// ReferencedColumn is the referenced column name, the first one of a multi column foreign key.
+		ReferencedColumn string `json:"referenced_column"`
+		// Columns and ReferencedColumns are all the columns of a multi column foreign key, in order, empty for
+		// single column foreign keys.
+		Columns           []string `json:"columns,omitempty"`
+		ReferencedColumns []string `json:"referenced_columns,omitempty"`
+	}
+
+	// AllowedValues are the values allowed in a column.
+	AllowedValues struct {
+		// Values are the allowed values, in the order of the type or constraint.
+		Values []string `json:"values"`
+		// Set is true for the SET columns, which hold a comma separated list of the values.
+		Set bool `json:"set,omitempty"`
+	}
+
+	// Index is an index of a table.
+	Index struct {
+		// Name is the index name.
+		Name string `json:"name"`
+		// Columns are the indexed columns, in index order.
+		Columns []string `json:"columns"`
+		// Unique is true for unique indexes, including the primary key.
+		Unique bool `json:"unique"`
+	}
+
 	// ReadTableOpt represents the read table options
 	ReadTableOpt struct {
 		// Columns contains the (quoted) column of the table
@@ -43,8 +255,21 @@ type (
 		Sorts map[string]string
 		// Limit defines a limit of results to be fetched
 		Limit uint64
+		// Offset defines the amount of results to skip
+		Offset uint64
 		// Relationships defines an slice of relationship definitions
 		Relationships []*RelationshipOpt
+		// ChunkColumns are the columns read as large values, in chunks of ChunkSize
+		ChunkColumns []string
+		// ChunkSize is the length of the chunks large values are read in
+		ChunkSize int64
+		// Query is a SELECT the table is read from instead of the table itself
+		Query string
+		// KeyOrder orders the rows by primary key after the Sorts, so that they are always read in the same order
+		KeyOrder bool
+		// KeyAfter reads the rows whose primary key comes after the given values of its columns, for pages read
+		// by key instead of by Offset
+		KeyAfter []interface{}
 	}
 
 	// RelationshipOpt represents the relationships options
@@ -71,6 +296,20 @@ type (
 		MaxConns int
 		// MaxIdleConns is the maximum number of connections in the idle connection pool for the read database.
 		MaxIdleConns int
+		// MaxConnIdleTime is the maximum amount of time a connection may be idle before being closed.
+		MaxConnIdleTime time.Duration
+		// Retry is the policy for retrying read queries failing with transient errors.
+		Retry retry.Policy
+		// Views are the views read as tables, their structure is a table with the columns of the view.
+		Views []string
+		// ReadOnly makes the sessions of the read database read-only, so that no statement can write to it.
+		ReadOnly bool
+		// Vitess avoids the statements and variables a Vitess or PlanetScale MySQL database does not support.
+		Vitess bool
+		// Owners keeps the owners of the objects in the structure, instead of leaving them out.
+		Owners bool
+		// Grants keeps the privileges on the objects in the structure, instead of leaving them out.
+		Grants bool
 	}
 )
 
@@ -92,7 +331,45 @@ func NewReadTableOpt(tableCfg *config.Table) ReadTableOpt {
 		Sorts:         tableCfg.Filter.Sorts,
 		Limit:         tableCfg.Filter.Limit,
 		Relationships: rOpts,
+		ChunkColumns:  tableCfg.ChunkColumns,
+		ChunkSize:     tableCfg.ChunkSize,
+		Query:         tableCfg.Query,
+	}
+}
+
+// GetStructureSections returns the pre-data and post-data sections of the reader structure,
+// the whole structure being the pre-data section when the reader can not split it.
+func GetStructureSections(r Reader) (string, string, error) {
+	if s, ok := r.(Sectioner); ok {
+		preData, postData, err := s.GetStructureSections()
+		if !errors.Is(err, ErrSectionsUnsupported) {
+			return preData, postData, err
+		}
 	}
+
+	structure, err := r.GetStructure()
+	return structure, "", err
+}
+
+// WithSections returns a reader splitting its structure like source, when source is a Sectioner.
+// It restores the sections of a source hidden by the readers decorating it.
+func WithSections(r Reader, source Reader) Reader {
+	s, ok := source.(Sectioner)
+	if !ok {
+		return r
+	}
+
+	return &sectionedReader{Reader: r, sectioner: s}
+}
+
+type sectionedReader struct {
+	Reader
+	sectioner Sectioner
+}
+
+// GetStructureSections returns the sections of the structure of the decorated source.
+func (r *sectionedReader) GetStructureSections() (string, string, error) {
+	return r.sectioner.GetStructureSections()
 }
 
 // Connect acts as factory method that returns a reader from a DSN
@@ -107,8 +384,12 @@ func Connect(opts ConnOpts) (reader Reader, err error) {
 		return false
 	})
 
+	// the drivers may quote the dsn in their errors, e.g. when it can not be parsed
+	if err != nil {
+		return nil, parser.RedactError(err, opts.DSN)
+	}
 	if reader == nil {
-		err = fmt.Errorf("unsupported dsn %q", opts.DSN)
+		err = fmt.Errorf("unsupported dsn %q", parser.Redacted(opts.DSN))
 	}
 
 	return
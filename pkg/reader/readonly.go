@@ -0,0 +1,128 @@
+package reader
+
+import (
+	"strings"
+)
+
+// readOnlyKeywords are the first keywords of the statements that only read.
+var readOnlyKeywords = map[string]bool{
+	"SELECT":   true,
+	"WITH":     true,
+	"SHOW":     true,
+	"EXPLAIN":  true,
+	"DESCRIBE": true,
+	"DESC":     true,
+	"SET":      true,
+	"VALUES":   true,
+}
+
+// writingKeywords are the keywords making a read statement write, e.g. data modifying common table expressions,
+// SELECT ... INTO or a SET turning the read-only mode off.
+var writingKeywords = map[string]bool{
+	"INSERT":                        true,
+	"UPDATE":                        true,
+	"DELETE":                        true,
+	"MERGE":                         true,
+	"INTO":                          true,
+	"WRITE":                         true,
+	"GLOBAL":                        true,
+	"PERSIST":                       true,
+	"READ_ONLY":                     true,
+	"TX_READ_ONLY":                  true,
+	"TRANSACTION_READ_ONLY":         true,
+	"DEFAULT_TRANSACTION_READ_ONLY": true,
+}
+
+// WritingStatement returns the first statement of the script that may write, empty when all its statements only read.
+// The statements are checked by their keywords, a read-only session still refuses the functions that write.
+func WritingStatement(script string) string {
+	for _, statement := range SplitStatements(script) {
+		words := Keywords(statement)
+		if len(words) == 0 {
+			continue
+		}
+		if !readOnlyKeywords[words[0]] {
+			return strings.TrimSpace(statement)
+		}
+		for _, word := range words[1:] {
+			if writingKeywords[word] {
+				return strings.TrimSpace(statement)
+			}
+		}
+	}
+
+	return ""
+}
+
+// SplitStatements splits the script on the semicolons that are not quoted or commented, leaving them out.
+func SplitStatements(script string) []string {
+	var (
+		statements []string
+		start      int
+	)
+	scanSQL(script, func(i int, c byte) {
+		if c == ';' {
+			statements = append(statements, script[start:i])
+			start = i + 1
+		}
+	})
+
+	return append(statements, script[start:])
+}
+
+// Keywords returns the upper cased words of the statement that are not quoted or commented.
+func Keywords(statement string) []string {
+	var (
+		words []string
+		word  strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, strings.ToUpper(word.String()))
+			word.Reset()
+		}
+	}
+	scanSQL(statement, func(i int, c byte) {
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			word.WriteByte(c)
+			return
+		}
+		flush()
+	})
+	flush()
+
+	return words
+}
+
+// scanSQL calls fn with the characters of the SQL text that are not in quotes or comments, quotes and comments
+// being seen as a space.
+func scanSQL(text string, fn func(i int, c byte)) {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(text[i+1:], c)
+			if end < 0 {
+				return
+			}
+			fn(i, ' ')
+			i += end + 1
+		case c == '-' && strings.HasPrefix(text[i:], "--"):
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				return
+			}
+			fn(i, ' ')
+			i += end
+		case c == '/' && strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return
+			}
+			fn(i, ' ')
+			i += end + 3
+		default:
+			fn(i, c)
+		}
+	}
+}
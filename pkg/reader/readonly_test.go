@@ -0,0 +1,31 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritingStatement(t *testing.T) {
+	for _, script := range []string{
+		"SELECT 1",
+		"SET search_path TO app; SELECT * FROM users WHERE note = 'insert; delete'",
+		"-- refresh the stats\nSHOW TABLES; /* update */ EXPLAIN SELECT 1;",
+		"WITH recent AS (SELECT id FROM orders) SELECT count(*) FROM recent",
+		"",
+	} {
+		assert.Empty(t, WritingStatement(script), script)
+	}
+
+	for script, statement := range map[string]string{
+		"SELECT 1; DELETE FROM users":                            "DELETE FROM users",
+		"CREATE TEMPORARY VIEW active AS SELECT 1":               "CREATE TEMPORARY VIEW active AS SELECT 1",
+		"WITH gone AS (DELETE FROM users RETURNING id) SELECT 1": "WITH gone AS (DELETE FROM users RETURNING id) SELECT 1",
+		"SELECT * INTO backup FROM users":                        "SELECT * INTO backup FROM users",
+		"SET SESSION CHARACTERISTICS AS TRANSACTION READ WRITE":  "SET SESSION CHARACTERISTICS AS TRANSACTION READ WRITE",
+		"set transaction_read_only = 0":                          "set transaction_read_only = 0",
+		"SET GLOBAL max_connections = 10":                        "SET GLOBAL max_connections = 10",
+	} {
+		assert.Equal(t, statement, WritingStatement(script), script)
+	}
+}
@@ -0,0 +1,511 @@
+package sqlfile
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	createTableRe = regexp.MustCompile(`(?is)^CREATE\s+(?:UNLOGGED\s+|TEMPORARY\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\S+?)\s*\(`)
+	insertRe      = regexp.MustCompile(`(?is)^INSERT\s+(?:IGNORE\s+)?INTO\s+(\S+?)\s*(\(.*?\))?\s*VALUES\s*`)
+	copyRe        = regexp.MustCompile(`(?is)^COPY\s+(\S+?)\s*(\(.*?\))?\s+FROM\s+stdin$`)
+	numberRe      = regexp.MustCompile(`^[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+	// typeEndKeywords follow the type in a column definition.
+	typeEndKeywords = map[string]bool{
+		"NOT":            true,
+		"NULL":           true,
+		"DEFAULT":        true,
+		"PRIMARY":        true,
+		"UNIQUE":         true,
+		"REFERENCES":     true,
+		"CHECK":          true,
+		"COLLATE":        true,
+		"CONSTRAINT":     true,
+		"AUTO_INCREMENT": true,
+		"GENERATED":      true,
+		"COMMENT":        true,
+		"ON":             true,
+		"INVISIBLE":      true,
+		"VISIBLE":        true,
+	}
+
+	// constraintKeywords start the CREATE TABLE definitions that are not columns.
+	constraintKeywords = map[string]bool{
+		"CONSTRAINT": true,
+		"PRIMARY":    true,
+		"UNIQUE":     true,
+		"KEY":        true,
+		"INDEX":      true,
+		"FOREIGN":    true,
+		"CHECK":      true,
+		"FULLTEXT":   true,
+		"SPATIAL":    true,
+		"EXCLUDE":    true,
+		"LIKE":       true,
+		"PERIOD":     true,
+	}
+)
+
+// parseCreateTable returns the table name, the columns and their types of a CREATE TABLE statement.
+func parseCreateTable(text string) (string, []string, map[string]string, bool) {
+	m := createTableRe.FindStringSubmatchIndex(text)
+	if m == nil {
+		return "", nil, nil, false
+	}
+
+	table := tableName(text[m[2]:m[3]])
+	body, ok := enclosed(text[m[1]-1:])
+	if !ok {
+		return table, nil, nil, true
+	}
+
+	var columns []string
+	types := make(map[string]string)
+	for _, def := range splitTopLevel(body) {
+		def = strings.TrimSpace(def)
+		name, rest := identifier(def)
+		if name == "" || def[0] != '`' && def[0] != '"' && constraintKeywords[strings.ToUpper(name)] {
+			continue
+		}
+		columns = append(columns, name)
+		types[name] = columnType(rest)
+	}
+
+	return table, columns, types, true
+}
+
+// columnType returns the type of a column definition, up to its first constraint or comment,
+// e.g. the /*!80023 INVISIBLE */ of the MySQL invisible columns.
+func columnType(def string) string {
+	words := strings.Fields(def)
+	for i, word := range words {
+		if typeEndKeywords[strings.ToUpper(word)] || strings.HasPrefix(word, "/*") {
+			words = words[:i]
+			break
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// parseInsert returns the table, the listed columns and the text of the VALUES of an INSERT statement.
+func parseInsert(text string) (string, []string, string, bool) {
+	m := insertRe.FindStringSubmatchIndex(text)
+	if m == nil {
+		return "", nil, "", false
+	}
+
+	var columns []string
+	if m[4] >= 0 {
+		columns = columnList(text[m[4]:m[5]])
+	}
+
+	return tableName(text[m[2]:m[3]]), columns, text[m[1]:], true
+}
+
+// parseValues reads the rows of the VALUES of an INSERT statement.
+func parseValues(values string, dialect string) ([][]interface{}, error) {
+	p := &valueParser{text: values, dialect: dialect}
+	return p.tuples()
+}
+
+// parseCopy returns the table and the listed columns of a COPY ... FROM stdin statement.
+func parseCopy(text string) (string, []string, bool) {
+	m := copyRe.FindStringSubmatch(text)
+	if m == nil {
+		return "", nil, false
+	}
+
+	var columns []string
+	if m[2] != "" {
+		columns = columnList(m[2])
+	}
+
+	return tableName(m[1]), columns, true
+}
+
+// parseCopyRow decodes a line of the COPY text format.
+func parseCopyRow(line string) []interface{} {
+	fields := strings.Split(line, "\t")
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		if field == `\N` {
+			continue
+		}
+		values[i] = unescapeCopy(field)
+	}
+
+	return values
+}
+
+func unescapeCopy(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c != '\\' || i+1 == len(field) {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch field[i] {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'v':
+			b.WriteByte('\v')
+		case 'x':
+			end := i + 1
+			for end < len(field) && end < i+3 && isHex(field[end]) {
+				end++
+			}
+			n, _ := strconv.ParseUint(field[i+1:end], 16, 8)
+			b.WriteByte(byte(n))
+			i = end - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			end := i
+			for end < len(field) && end < i+3 && field[end] >= '0' && field[end] <= '7' {
+				end++
+			}
+			n, _ := strconv.ParseUint(field[i:end], 8, 8)
+			b.WriteByte(byte(n))
+			i = end - 1
+		default:
+			b.WriteByte(field[i])
+		}
+	}
+
+	return b.String()
+}
+
+// valueParser reads the literal tuples of an INSERT statement.
+type valueParser struct {
+	text    string
+	pos     int
+	dialect string
+}
+
+func (p *valueParser) tuples() ([][]interface{}, error) {
+	var rows [][]interface{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.text) {
+			return rows, nil
+		}
+		if p.text[p.pos] != '(' {
+			// trailing clauses such as ON DUPLICATE KEY UPDATE or ON CONFLICT
+			return rows, nil
+		}
+		p.pos++
+
+		var row []interface{}
+		for {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, v)
+
+			if err := p.skipToDelimiter(); err != nil {
+				return nil, err
+			}
+			c := p.text[p.pos]
+			p.pos++
+			if c == ')' {
+				break
+			}
+		}
+		rows = append(rows, row)
+
+		p.skipSpace()
+		if p.pos < len(p.text) && p.text[p.pos] == ',' {
+			p.pos++
+		}
+	}
+}
+
+// value reads a single literal.
+func (p *valueParser) value() (interface{}, error) {
+	p.skipSpace()
+	if p.pos >= len(p.text) {
+		return nil, fmt.Errorf("unexpected end of values")
+	}
+
+	rest := p.text[p.pos:]
+	switch c := rest[0]; {
+	case c == '\'':
+		return p.quoted(p.dialect == dialectMySQL)
+
+	case (c == 'E' || c == 'e') && len(rest) > 1 && rest[1] == '\'':
+		p.pos++
+		return p.quoted(true)
+
+	case (c == 'X' || c == 'x') && len(rest) > 1 && rest[1] == '\'':
+		p.pos++
+		s, err := p.quoted(false)
+		if err != nil {
+			return nil, err
+		}
+		return hex.DecodeString(s)
+
+	case c == '0' && len(rest) > 1 && (rest[1] == 'x' || rest[1] == 'X'):
+		end := 2
+		for end < len(rest) && isHex(rest[end]) {
+			end++
+		}
+		p.pos += end
+		return hex.DecodeString(rest[2:end])
+
+	case c == '_':
+		// MySQL charset introducers such as _binary 'value'
+		word, _ := identifier(rest)
+		p.pos += len(word)
+		return p.value()
+	}
+
+	if num := numberRe.FindString(rest); num != "" {
+		p.pos += len(num)
+		if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+			return n, nil
+		}
+		// decimals are kept as text to avoid losing precision
+		return num, nil
+	}
+
+	word, _ := identifier(rest)
+	p.pos += len(word)
+	switch strings.ToUpper(word) {
+	case "NULL", "DEFAULT":
+		return nil, nil
+	case "TRUE":
+		return true, nil
+	case "FALSE":
+		return false, nil
+	}
+
+	return nil, fmt.Errorf("unsupported value %q", truncate(rest))
+}
+
+// quoted reads a single quoted string.
+func (p *valueParser) quoted(backslashEscapes bool) (string, error) {
+	var b strings.Builder
+	for i := p.pos + 1; i < len(p.text); i++ {
+		c := p.text[i]
+		switch {
+		case c == '\'' && i+1 < len(p.text) && p.text[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case c == '\'':
+			p.pos = i + 1
+			return b.String(), nil
+		case c == '\\' && backslashEscapes && i+1 < len(p.text):
+			i++
+			b.WriteString(unescapeMySQL(p.text[i]))
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", fmt.Errorf("unterminated string")
+}
+
+// skipToDelimiter skips casts and other trailing expressions up to the next top level , or ).
+func (p *valueParser) skipToDelimiter() error {
+	depth := 0
+	for ; p.pos < len(p.text); p.pos++ {
+		switch p.text[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return nil
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				return nil
+			}
+		case '\'':
+			if _, err := p.quoted(p.dialect == dialectMySQL); err != nil {
+				return err
+			}
+			p.pos--
+		}
+	}
+
+	return fmt.Errorf("unexpected end of values")
+}
+
+func (p *valueParser) skipSpace() {
+	for p.pos < len(p.text) && isSpace(p.text[p.pos]) {
+		p.pos++
+	}
+}
+
+func unescapeMySQL(c byte) string {
+	switch c {
+	case '0':
+		return "\x00"
+	case 'b':
+		return "\b"
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case 'Z':
+		return "\x1a"
+	case '%', '_':
+		return `\` + string(c)
+	default:
+		return string(c)
+	}
+}
+
+// identifier reads a quoted or bare identifier at the start of the text and returns it with the remaining text.
+func identifier(text string) (string, string) {
+	if text == "" {
+		return "", text
+	}
+
+	if q := text[0]; q == '`' || q == '"' {
+		var b strings.Builder
+		for i := 1; i < len(text); i++ {
+			if text[i] != q {
+				b.WriteByte(text[i])
+				continue
+			}
+			if i+1 < len(text) && text[i+1] == q {
+				b.WriteByte(q)
+				i++
+				continue
+			}
+			return b.String(), text[i+1:]
+		}
+		return b.String(), ""
+	}
+
+	end := 0
+	for end < len(text) && (text[end] == '_' || text[end] == '$' || isAlnum(text[end]) || text[end] >= 0x80) {
+		end++
+	}
+
+	return text[:end], text[end:]
+}
+
+// tableName returns the unquoted table name of a possibly schema qualified name.
+func tableName(name string) string {
+	var part string
+	for name != "" {
+		part, name = identifier(name)
+		if !strings.HasPrefix(name, ".") {
+			break
+		}
+		name = name[1:]
+	}
+
+	return part
+}
+
+// columnList returns the unquoted names of a parenthesised column list.
+func columnList(list string) []string {
+	body, _ := enclosed(list)
+	var columns []string
+	for _, item := range splitTopLevel(body) {
+		name, _ := identifier(strings.TrimSpace(item))
+		columns = append(columns, name)
+	}
+
+	return columns
+}
+
+// enclosed returns the content of the parenthesis at the start of the text.
+func enclosed(text string) (string, bool) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return text[1:i], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// splitTopLevel splits a comma separated list ignoring the commas nested in parenthesis or quotes.
+func splitTopLevel(text string) []string {
+	var (
+		parts []string
+		depth int
+		quote byte
+		start int
+	)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, text[start:])
+}
+
+func truncate(text string) string {
+	if len(text) > 64 {
+		return text[:64] + "..."
+	}
+	return text
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,338 @@
+package sqlfile
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	// fileReader reads the tables of a mysqldump or pg_dump plain file.
+	// The file is indexed once when opened and then streamed again for every table read,
+	// so the rows are never loaded in memory all at once.
+	fileReader struct {
+		path      string
+		dialect   string
+		structure string
+		tables    []string
+		columns   map[string][]string
+		types     map[string]map[string]string
+	}
+
+	// file is an opened dump file, transparently decompressed when gzipped.
+	file struct {
+		io.Reader
+		closers []io.Closer
+	}
+)
+
+// NewReader opens the dump file and indexes its structure, tables and columns.
+// An empty dialect is detected from the dump header.
+func NewReader(path string, dialect string) (reader.Reader, error) {
+	r := &fileReader{
+		path:    path,
+		dialect: dialect,
+		columns: make(map[string][]string),
+		types:   make(map[string]map[string]string),
+	}
+
+	if r.dialect == "" {
+		detected, err := r.detectDialect()
+		if err != nil {
+			return nil, err
+		}
+		r.dialect = detected
+	}
+
+	if err := r.index(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetStructure returns the statements of the dump that are not data.
+func (r *fileReader) GetStructure() (string, error) {
+	return r.structure, nil
+}
+
+// GetTables returns the tables in the order they appear in the dump.
+func (r *fileReader) GetTables() ([]string, error) {
+	return r.tables, nil
+}
+
+// GetColumns returns the columns of a table.
+func (r *fileReader) GetColumns(tableName string) ([]string, error) {
+	columns, ok := r.columns[tableName]
+	if !ok {
+		return nil, fmt.Errorf("table %s not found in %s", tableName, r.path)
+	}
+
+	return columns, nil
+}
+
+// GetColumnTypes returns the column types found in the CREATE TABLE statement of a table.
+func (r *fileReader) GetColumnTypes(tableName string) (map[string]string, error) {
+	types, ok := r.types[tableName]
+	if !ok {
+		return nil, fmt.Errorf("no CREATE TABLE statement found for %s in %s", tableName, r.path)
+	}
+
+	return types, nil
+}
+
+// GetAllowedValues returns the values of the ENUM and SET columns found in the CREATE TABLE statement of a table.
+func (r *fileReader) GetAllowedValues(tableName string) (map[string]reader.AllowedValues, error) {
+	types, err := r.GetColumnTypes(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]reader.AllowedValues)
+	for column, columnType := range types {
+		if values, ok := reader.ParseEnumType(columnType); ok {
+			allowed[column] = values
+		}
+	}
+
+	return allowed, nil
+}
+
+// FormatColumn returns a escaped table.column string
+func (r *fileReader) FormatColumn(tableName string, columnName string) string {
+	if r.dialect == dialectPostgres {
+		return fmt.Sprintf("%s.%s", pq.QuoteIdentifier(tableName), pq.QuoteIdentifier(columnName))
+	}
+
+	return fmt.Sprintf("`%s`.`%s`", tableName, columnName)
+}
+
+// ReadTable streams the rows of a table found in the INSERT statements and COPY blocks of the dump.
+func (r *fileReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	logger := log.WithField("table", tableName)
+	if opts.Match != "" || len(opts.Sorts) > 0 || len(opts.Relationships) > 0 || opts.Query != "" {
+		logger.Warn("filter match, sorts, relationships and queries are not supported when reading a sql file, only the limit and offset are applied")
+	}
+
+	f, err := r.open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		count   uint64
+		skipped uint64
+		columns *database.Columns
+		listed  string
+	)
+	emit := func(names []string, values []interface{}) bool {
+		if names == nil {
+			names = r.columns[tableName]
+		}
+		// rows of the same statement shape share their column index
+		if key := strings.Join(names, "\x00"); columns == nil || key != listed {
+			columns = database.NewColumns(names)
+			listed = key
+		}
+
+		if len(values) != columns.Len() {
+			logger.WithField("values", len(values)).Warn("skipping row not matching the table columns")
+			return true
+		}
+		if skipped < opts.Offset {
+			skipped++
+			return true
+		}
+
+		rowChan <- database.NewRow(columns, values)
+		count++
+		return opts.Limit == 0 || count < opts.Limit
+	}
+
+	s := newScanner(f, r.dialect)
+	for {
+		stmt, err := s.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", r.path, err)
+		}
+
+		if stmt.copy {
+			table, names, _ := parseCopy(stmt.text)
+			if table != tableName {
+				continue
+			}
+			for {
+				line, ok, err := s.copyLine()
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", r.path, err)
+				}
+				if !ok {
+					break
+				}
+				if !emit(names, parseCopyRow(line)) {
+					return nil
+				}
+			}
+			continue
+		}
+
+		table, names, values, ok := parseInsert(stmt.text)
+		if !ok || table != tableName {
+			continue
+		}
+
+		rows, err := parseValues(values, r.dialect)
+		if err != nil {
+			return fmt.Errorf("failed to parse values of %s: %w", tableName, err)
+		}
+		for _, row := range rows {
+			if !emit(names, row) {
+				return nil
+			}
+		}
+	}
+}
+
+// Close releases the reader resources, the dump file is only opened while reading.
+func (r *fileReader) Close() error {
+	return nil
+}
+
+// index reads the dump once to collect the structure statements, the tables and their columns.
+func (r *fileReader) index() error {
+	f, err := r.open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var structure strings.Builder
+	s := newScanner(f, r.dialect)
+	for {
+		stmt, err := s.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", r.path, err)
+		}
+
+		if stmt.copy {
+			if table, columns, ok := parseCopy(stmt.text); ok {
+				r.addTable(table, columns)
+			}
+			continue
+		}
+
+		if table, columns, _, ok := parseInsert(stmt.text); ok {
+			r.addTable(table, columns)
+			continue
+		}
+
+		if isDataStatement(stmt.text) {
+			continue
+		}
+
+		if table, columns, types, ok := parseCreateTable(stmt.text); ok {
+			r.addTable(table, columns)
+			r.types[table] = types
+		}
+
+		structure.WriteString(stmt.text)
+		structure.WriteString(";\n\n")
+	}
+
+	r.structure = structure.String()
+	log.WithField("tables", r.tables).Debug("indexed sql file")
+
+	return nil
+}
+
+// addTable registers a table, the first statement giving columns defines them.
+func (r *fileReader) addTable(table string, columns []string) {
+	known, ok := r.columns[table]
+	if !ok {
+		r.tables = append(r.tables, table)
+	}
+	if len(known) == 0 {
+		r.columns[table] = columns
+	}
+}
+
+// detectDialect looks for the pg_dump header, anything else is read as a mysqldump.
+func (r *fileReader) detectDialect() (string, error) {
+	f, err := r.open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 4096)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read %s: %w", r.path, err)
+	}
+
+	if strings.Contains(string(head[:n]), "PostgreSQL database dump") {
+		return dialectPostgres, nil
+	}
+
+	return dialectMySQL, nil
+}
+
+func (r *fileReader) open() (*file, error) {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sql file: %w", err)
+	}
+
+	if !strings.HasSuffix(r.path, ".gz") {
+		return &file{Reader: f, closers: []io.Closer{f}}, nil
+	}
+
+	gz, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", r.path, err)
+	}
+
+	return &file{Reader: gz, closers: []io.Closer{gz, f}}, nil
+}
+
+// Close closes the decompressor and the underlying file.
+func (f *file) Close() error {
+	var firstErr error
+	for _, c := range f.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// isDataStatement checks if a statement only matters when loading data, such as mysqldump table locks.
+func isDataStatement(text string) bool {
+	upper := strings.ToUpper(text)
+	if strings.HasPrefix(upper, "LOCK TABLES") || strings.HasPrefix(upper, "UNLOCK TABLES") {
+		return true
+	}
+
+	upper = strings.TrimSpace(strings.TrimSuffix(upper, "*/"))
+	return strings.HasSuffix(upper, "DISABLE KEYS") || strings.HasSuffix(upper, "ENABLE KEYS")
+}
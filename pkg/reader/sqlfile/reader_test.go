@@ -0,0 +1,162 @@
+package sqlfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+const mysqlDump = "-- MySQL dump 10.13  Distrib 8.0.32\n" +
+	"/*!40101 SET NAMES utf8mb4 */;\n" +
+	"DROP TABLE IF EXISTS `users`;\n" +
+	"CREATE TABLE `users` (\n" +
+	"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+	"  `name` varchar(255) DEFAULT 'a;b',\n" +
+	"  `amount` decimal(10,2) /*!80023 INVISIBLE */ DEFAULT NULL,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  KEY `idx_name` (`name`)\n" +
+	") ENGINE=InnoDB;\n" +
+	"LOCK TABLES `users` WRITE;\n" +
+	"/*!40000 ALTER TABLE `users` DISABLE KEYS */;\n" +
+	"INSERT INTO `users` VALUES (1,'it\\'s; ok','10.50'),(2,NULL,-3),(3,_binary 'x\\ny',1.5);\n" +
+	"INSERT INTO `users` (`name`,`id`) VALUES ('o''neil',4);\n" +
+	"/*!40000 ALTER TABLE `users` ENABLE KEYS */;\n" +
+	"UNLOCK TABLES;\n"
+
+const postgresDump = `--
+-- PostgreSQL database dump
+--
+
+SET standard_conforming_strings = on;
+
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$BEGIN NEW.updated_at := now(); RETURN NEW; END;$$;
+
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    name text,
+    CONSTRAINT name_check CHECK ((name <> ''::text))
+);
+
+COPY public.users (id, name) FROM stdin;
+1	foo\tbar
+2	\N
+\.
+
+CREATE TABLE public."Orders" (
+    "Id" integer NOT NULL
+);
+
+COPY public."Orders" ("Id") FROM stdin;
+\.
+
+INSERT INTO public.users (id, name) VALUES (3, 'back\slash'), (4, E'it\'s'::text);
+`
+
+func TestReadMySQLDump(t *testing.T) {
+	r := newTestReader(t, mysqlDump)
+
+	tables, err := r.GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users"}, tables)
+
+	columns, err := r.GetColumns("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "amount"}, columns)
+
+	types, err := r.(reader.ColumnTyper).GetColumnTypes("users")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"id": "int", "name": "varchar(255)", "amount": "decimal(10,2)"}, types)
+
+	structure, err := r.GetStructure()
+	require.NoError(t, err)
+	assert.Contains(t, structure, "/*!40101 SET NAMES utf8mb4 */;")
+	assert.Contains(t, structure, "DEFAULT 'a;b'")
+	assert.NotContains(t, structure, "INSERT")
+	assert.NotContains(t, structure, "LOCK TABLES")
+	assert.NotContains(t, structure, "DISABLE KEYS")
+
+	rows := readAll(t, r, "users", reader.ReadTableOpt{})
+	require.Len(t, rows, 4)
+	assert.Equal(t, []interface{}{int64(1), "it's; ok", "10.50"}, rows[0].Values())
+	assert.Equal(t, []interface{}{int64(2), nil, int64(-3)}, rows[1].Values())
+	assert.Equal(t, []interface{}{int64(3), "x\ny", "1.5"}, rows[2].Values())
+	assert.Equal(t, "o'neil", rows[3].Get("name"))
+	assert.Equal(t, int64(4), rows[3].Get("id"))
+}
+
+func TestReadPostgresDump(t *testing.T) {
+	r := newTestReader(t, postgresDump)
+
+	tables, err := r.GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "Orders"}, tables)
+
+	columns, err := r.GetColumns("users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name"}, columns)
+
+	types, err := r.(reader.ColumnTyper).GetColumnTypes("users")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"id": "integer", "name": "text"}, types)
+
+	structure, err := r.GetStructure()
+	require.NoError(t, err)
+	assert.Contains(t, structure, "RETURN NEW; END;$$;")
+	assert.NotContains(t, structure, "COPY")
+
+	rows := readAll(t, r, "users", reader.ReadTableOpt{})
+	require.Len(t, rows, 4)
+	assert.Equal(t, []interface{}{"1", "foo\tbar"}, rows[0].Values())
+	assert.Equal(t, []interface{}{"2", nil}, rows[1].Values())
+	assert.Equal(t, `back\slash`, rows[2].Get("name"))
+	assert.Equal(t, "it's", rows[3].Get("name"))
+
+	assert.Empty(t, readAll(t, r, "Orders", reader.ReadTableOpt{}))
+}
+
+func TestReadTableLimit(t *testing.T) {
+	r := newTestReader(t, mysqlDump)
+
+	rows := readAll(t, r, "users", reader.ReadTableOpt{Limit: 2})
+	assert.Len(t, rows, 2)
+
+	all := readAll(t, r, "users", reader.ReadTableOpt{})
+	rows = readAll(t, r, "users", reader.ReadTableOpt{Limit: 2, Offset: 1})
+	require.Len(t, rows, 2)
+	assert.Equal(t, all[1].Values(), rows[0].Values())
+	assert.Equal(t, all[2].Values(), rows[1].Values())
+}
+
+func newTestReader(t *testing.T, dump string) reader.Reader {
+	path := filepath.Join(t.TempDir(), "dump.sql")
+	require.NoError(t, os.WriteFile(path, []byte(dump), 0o600))
+
+	r, err := NewReader(path, "")
+	require.NoError(t, err)
+
+	return r
+}
+
+func readAll(t *testing.T, r reader.Reader, table string, opts reader.ReadTableOpt) []database.Row {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable(table, rowChan, opts)
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+	require.NoError(t, <-errChan)
+
+	return rows
+}
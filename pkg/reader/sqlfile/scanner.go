@@ -0,0 +1,240 @@
+package sqlfile
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+const (
+	dialectMySQL    = "mysql"
+	dialectPostgres = "postgres"
+)
+
+type (
+	// statement is a single SQL statement read from a dump, without its trailing semicolon.
+	statement struct {
+		text string
+		// copy is set for COPY ... FROM stdin statements, their rows are read with scanner.copyLine.
+		copy bool
+	}
+
+	// scanner splits a SQL dump into statements.
+	scanner struct {
+		r       *bufio.Reader
+		dialect string
+		// inCopy is set while the rows of a COPY statement have not all been read.
+		inCopy bool
+	}
+)
+
+func newScanner(r io.Reader, dialect string) *scanner {
+	return &scanner{r: bufio.NewReaderSize(r, 1024*1024), dialect: dialect}
+}
+
+// next returns the next statement, io.EOF when the dump has been read.
+func (s *scanner) next() (*statement, error) {
+	// skip the rows of the previous COPY statement the caller did not read
+	for s.inCopy {
+		if _, _, err := s.copyLine(); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		buf       strings.Builder
+		quote     rune
+		escapes   bool
+		dollarTag string
+		dollarEnd int
+	)
+
+	for {
+		c, _, err := s.r.ReadRune()
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(buf.String()) != "" {
+				return s.finish(buf.String())
+			}
+			return nil, err
+		}
+
+		switch {
+		case dollarTag != "":
+			buf.WriteRune(c)
+			if c == '$' && buf.Len() >= dollarEnd && strings.HasSuffix(buf.String(), dollarTag) {
+				dollarTag = ""
+			}
+			continue
+
+		case quote != 0:
+			buf.WriteRune(c)
+			if c == '\\' && escapes {
+				if next, _, err := s.r.ReadRune(); err == nil {
+					buf.WriteRune(next)
+				}
+				continue
+			}
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			// postgres only honours backslash escapes in E'' strings
+			escapes = s.dialect == dialectMySQL && c != '`' || c == '\'' && isEscapePrefix(buf.String())
+			quote = c
+			buf.WriteRune(c)
+
+		case '$':
+			buf.WriteRune(c)
+			if s.dialect == dialectPostgres {
+				if tag := s.peekDollarTag(); tag != "" {
+					buf.WriteString(tag[1:])
+					dollarTag = tag
+					dollarEnd = buf.Len() + len(tag)
+				}
+			}
+
+		case '-', '#':
+			if c == '-' {
+				if next, _ := s.r.Peek(1); len(next) == 0 || next[0] != '-' {
+					buf.WriteRune(c)
+					continue
+				}
+			} else if s.dialect != dialectMySQL {
+				buf.WriteRune(c)
+				continue
+			}
+			// skip the comment up to the end of the line
+			if _, err := s.r.ReadString('\n'); err != nil && err != io.EOF {
+				return nil, err
+			}
+			buf.WriteRune('\n')
+
+		case '/':
+			buf.WriteRune(c)
+			if next, _ := s.r.Peek(1); len(next) == 1 && next[0] == '*' {
+				if err := s.readBlockComment(&buf); err != nil {
+					return nil, err
+				}
+			}
+
+		case ';':
+			if strings.TrimSpace(buf.String()) == "" {
+				buf.Reset()
+				continue
+			}
+			return s.finish(buf.String())
+
+		default:
+			buf.WriteRune(c)
+		}
+	}
+}
+
+// finish builds the statement, the data of a COPY statement starts on the next line.
+func (s *scanner) finish(text string) (*statement, error) {
+	stmt := &statement{text: strings.TrimSpace(text)}
+	if !isCopyFromStdin(stmt.text) {
+		return stmt, nil
+	}
+
+	if _, err := s.r.ReadString('\n'); err != nil && err != io.EOF {
+		return nil, err
+	}
+	stmt.copy = true
+	s.inCopy = true
+
+	return stmt, nil
+}
+
+// copyLine returns the next row of the current COPY statement, false once the \. terminator is reached.
+func (s *scanner) copyLine() (string, bool, error) {
+	if !s.inCopy {
+		return "", false, nil
+	}
+
+	line, err := s.r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", false, err
+	}
+
+	line = strings.TrimRight(line, "\r\n")
+	if line == `\.` || err == io.EOF && line == "" {
+		s.inCopy = false
+		return "", false, nil
+	}
+	if err == io.EOF {
+		s.inCopy = false
+	}
+
+	return line, true, nil
+}
+
+// readBlockComment copies a /* */ comment, MySQL executable comments (/*! */) must be kept in the statement.
+func (s *scanner) readBlockComment(buf *strings.Builder) error {
+	// consume the opening star so that "/*/" is not taken as a complete comment
+	c, _, err := s.r.ReadRune()
+	if err != nil {
+		return err
+	}
+	buf.WriteRune(c)
+
+	var prev rune
+	for {
+		c, _, err := s.r.ReadRune()
+		if err != nil {
+			return err
+		}
+		buf.WriteRune(c)
+		if prev == '*' && c == '/' {
+			return nil
+		}
+		prev = c
+	}
+}
+
+// peekDollarTag returns the dollar quote tag ($$ or $tag$) starting at the last read $, if any.
+func (s *scanner) peekDollarTag() string {
+	for n := 1; n <= 64; n++ {
+		next, err := s.r.Peek(n)
+		if err != nil || len(next) < n {
+			return ""
+		}
+
+		c := next[n-1]
+		if c == '$' {
+			tag := "$" + string(next)
+			if _, err := s.r.Discard(n); err != nil {
+				return ""
+			}
+			return tag
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || n > 1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// isEscapePrefix checks if the text ends with the E prefix of a postgres escape string.
+func isEscapePrefix(text string) bool {
+	if !strings.HasSuffix(text, "E") && !strings.HasSuffix(text, "e") {
+		return false
+	}
+
+	text = text[:len(text)-1]
+	if text == "" {
+		return true
+	}
+	c := text[len(text)-1]
+	return !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+}
+
+func isCopyFromStdin(text string) bool {
+	upper := strings.ToUpper(text)
+	return strings.HasPrefix(upper, "COPY ") && strings.HasSuffix(upper, "FROM STDIN")
+}
@@ -0,0 +1,52 @@
+package sqlfile
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	parser "github.com/hellofresh/klepto/pkg/dsn"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+const scheme = "sqlfile://"
+
+type driver struct{}
+
+// IsSupported checks if the given dsn connection string is supported.
+func (m *driver) IsSupported(dsn string) bool {
+	return strings.HasPrefix(dsn, scheme)
+}
+
+// NewConnection opens the dump file and retrieves a new sql file reader.
+// The dsn is sqlfile:///path/to/dump.sql, the dialect is detected from the dump
+// header unless it is given with the dialect parameter (mysql or postgres).
+func (m *driver) NewConnection(opts reader.ConnOpts) (reader.Reader, error) {
+	u, err := url.Parse(opts.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sql file dsn: %w", err)
+	}
+
+	path := u.Path
+	if u.Host != "" {
+		// relative paths such as sqlfile://dump.sql
+		path = filepath.Join(u.Host, u.Path)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no dump file given in dsn %q", parser.Redacted(opts.DSN))
+	}
+
+	dialect := u.Query().Get("dialect")
+	switch dialect {
+	case "", dialectMySQL, dialectPostgres:
+	default:
+		return nil, fmt.Errorf("unsupported sql file dialect %q, expected %s or %s", dialect, dialectMySQL, dialectPostgres)
+	}
+
+	return NewReader(path, dialect)
+}
+
+func init() {
+	reader.Register("sqlfile", &driver{})
+}
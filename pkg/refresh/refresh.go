@@ -0,0 +1,305 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/kube"
+	"github.com/hellofresh/klepto/pkg/schedule"
+)
+
+// The DatabaseRefresh custom resource.
+const (
+	Group    = "klepto.hellofresh.com"
+	Version  = "v1alpha1"
+	Resource = "databaserefreshes"
+)
+
+// Phases of a refresh.
+const (
+	// Running refreshes are being dumped.
+	Running Phase = "Running"
+	// Succeeded refreshes were dumped on their last run.
+	Succeeded Phase = "Succeeded"
+	// Failed refreshes failed on their last run, or can not be scheduled.
+	Failed Phase = "Failed"
+)
+
+type (
+	// Phase is the state of the last run of a refresh.
+	Phase string
+
+	// DatabaseRefresh is a scheduled steal from a source database to a target database.
+	DatabaseRefresh struct {
+		Metadata kube.ObjectMeta `json:"metadata"`
+		Spec     Spec            `json:"spec"`
+		Status   Status          `json:"status"`
+	}
+
+	// Spec is the desired refresh.
+	Spec struct {
+		// Schedule is a cron expression, e.g. "0 3 * * *", in the time zone of the controller.
+		Schedule string `json:"schedule"`
+		// Suspend stops scheduling the refresh.
+		Suspend bool `json:"suspend,omitempty"`
+		// Source is the database dsn to steal from.
+		Source DSNSource `json:"source"`
+		// Target is the database dsn to output to.
+		Target DSNSource `json:"target"`
+		// ConfigMapRef is the key of a config map holding the klepto configuration file.
+		ConfigMapRef *KeyRef `json:"configMapRef,omitempty"`
+		// Args are additional steal flags, e.g. --data-only.
+		Args []string `json:"args,omitempty"`
+	}
+
+	// DSNSource is a dsn given as is or by the key of a secret.
+	DSNSource struct {
+		DSN          string  `json:"dsn,omitempty"`
+		SecretKeyRef *KeyRef `json:"secretKeyRef,omitempty"`
+	}
+
+	// KeyRef is a key of a secret or config map in the namespace of the refresh.
+	KeyRef struct {
+		Name string `json:"name"`
+		Key  string `json:"key"`
+	}
+
+	// Status is the observed state of the refresh, the message is always set so that patches clear it.
+	Status struct {
+		Phase              Phase      `json:"phase,omitempty"`
+		Message            string     `json:"message"`
+		LastScheduleTime   *time.Time `json:"lastScheduleTime,omitempty"`
+		LastCompletionTime *time.Time `json:"lastCompletionTime,omitempty"`
+		LastSuccessfulTime *time.Time `json:"lastSuccessfulTime,omitempty"`
+	}
+
+	// Run is a refresh to run, with its secrets resolved.
+	Run struct {
+		Name      string
+		Namespace string
+		Source    string
+		Target    string
+		// ConfigPath is the path of the configuration file, empty when the refresh has none.
+		ConfigPath string
+		Args       []string
+	}
+
+	// Runner runs a refresh.
+	Runner func(ctx context.Context, run Run) error
+
+	// Controller runs the refreshes of a namespace when they are due and reports their status.
+	Controller struct {
+		client    *kube.Client
+		namespace string
+		run       Runner
+		now       func() time.Time
+
+		mu      sync.Mutex
+		running map[string]bool
+		wg      sync.WaitGroup
+	}
+)
+
+// NewController returns a controller of the refreshes of a namespace, of all the namespaces when empty.
+func NewController(client *kube.Client, namespace string, run Runner) *Controller {
+	return &Controller{client: client, namespace: namespace, run: run, now: time.Now, running: make(map[string]bool)}
+}
+
+// Run reconciles the refreshes every interval until the context is done, then waits for the running refreshes.
+func (c *Controller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Reconcile(ctx); err != nil {
+			log.WithError(err).Error("could not reconcile the database refreshes")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("waiting for the running database refreshes")
+			c.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile starts the refreshes that are due and not running yet.
+// A refresh is due once its schedule passed since its last run, or since its creation. Missed runs are only run once.
+func (c *Controller) Reconcile(ctx context.Context) error {
+	var list struct {
+		Items []DatabaseRefresh `json:"items"`
+	}
+	if err := c.client.Get(ctx, c.listPath(), &list); err != nil {
+		return fmt.Errorf("could not list the database refreshes: %w", err)
+	}
+
+	now := c.now()
+	for _, refresh := range list.Items {
+		refresh := refresh
+		logger := log.WithFields(log.Fields{"namespace": refresh.Metadata.Namespace, "refresh": refresh.Metadata.Name})
+		if refresh.Spec.Suspend || c.isRunning(refresh) {
+			continue
+		}
+
+		sched, err := schedule.Parse(refresh.Spec.Schedule)
+		if err != nil {
+			if refresh.Status.Phase != Failed || refresh.Status.Message != err.Error() {
+				c.patchStatus(ctx, refresh, Status{Phase: Failed, Message: err.Error()}, logger)
+			}
+			continue
+		}
+
+		last := refresh.Metadata.CreationTimestamp
+		if refresh.Status.LastScheduleTime != nil {
+			last = *refresh.Status.LastScheduleTime
+		}
+		next := sched.Next(last.In(now.Location()))
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+
+		status := refresh.Status
+		status.Phase, status.Message, status.LastScheduleTime = Running, "", &now
+		if err := c.patchStatus(ctx, refresh, status, logger); err != nil {
+			continue
+		}
+
+		c.start(refresh)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			defer c.finish(refresh)
+
+			logger.Info("refreshing the database")
+			err := c.execute(ctx, refresh)
+
+			completed := c.now()
+			status.LastCompletionTime = &completed
+			if err != nil {
+				logger.WithError(err).Error("the database refresh failed")
+				status.Phase, status.Message = Failed, err.Error()
+			} else {
+				logger.Info("the database was refreshed")
+				status.Phase, status.Message, status.LastSuccessfulTime = Succeeded, "", &completed
+			}
+			// the run outlives a cancelled context, its status is still reported
+			c.patchStatus(context.Background(), refresh, status, logger)
+		}()
+	}
+
+	return nil
+}
+
+// Wait waits for the running refreshes to finish.
+func (c *Controller) Wait() {
+	c.wg.Wait()
+}
+
+// execute resolves the secrets and configuration of a refresh and runs it.
+func (c *Controller) execute(ctx context.Context, refresh DatabaseRefresh) error {
+	namespace := refresh.Metadata.Namespace
+	source, err := c.dsn(ctx, namespace, "source", refresh.Spec.Source)
+	if err != nil {
+		return err
+	}
+	target, err := c.dsn(ctx, namespace, "target", refresh.Spec.Target)
+	if err != nil {
+		return err
+	}
+
+	run := Run{Name: refresh.Metadata.Name, Namespace: namespace, Source: source, Target: target, Args: refresh.Spec.Args}
+	if ref := refresh.Spec.ConfigMapRef; ref != nil {
+		content, err := c.client.ConfigMapValue(ctx, namespace, ref.Name, ref.Key)
+		if err != nil {
+			return err
+		}
+
+		dir, err := os.MkdirTemp("", "klepto-refresh-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		// the extension of the key tells the format of the configuration
+		name := filepath.Base(ref.Key)
+		if filepath.Ext(name) == "" {
+			name = config.DefaultConfigFileName
+		}
+		run.ConfigPath = filepath.Join(dir, name)
+		if err := os.WriteFile(run.ConfigPath, []byte(content), 0o600); err != nil {
+			return err
+		}
+	}
+
+	return c.run(ctx, run)
+}
+
+func (c *Controller) dsn(ctx context.Context, namespace string, name string, source DSNSource) (string, error) {
+	switch {
+	case source.SecretKeyRef != nil:
+		return c.client.SecretValue(ctx, namespace, source.SecretKeyRef.Name, source.SecretKeyRef.Key)
+	case source.DSN != "":
+		return source.DSN, nil
+	}
+
+	return "", fmt.Errorf("the %s has neither a dsn nor a secretKeyRef", name)
+}
+
+func (c *Controller) patchStatus(ctx context.Context, refresh DatabaseRefresh, status Status, logger *log.Entry) error {
+	path := fmt.Sprintf("%s/%s/status", c.namespacePath(refresh.Metadata.Namespace), url.PathEscape(refresh.Metadata.Name))
+	err := c.client.MergePatch(ctx, path, map[string]interface{}{"status": status})
+	if err != nil {
+		var statusErr *kube.StatusError
+		if errors.As(err, &statusErr) && statusErr.Code == 404 {
+			logger.Debug("the database refresh was deleted")
+		} else {
+			logger.WithError(err).Error("could not update the database refresh status")
+		}
+	}
+
+	return err
+}
+
+func (c *Controller) listPath() string {
+	if c.namespace == "" {
+		return fmt.Sprintf("/apis/%s/%s/%s", Group, Version, Resource)
+	}
+
+	return c.namespacePath(c.namespace)
+}
+
+func (c *Controller) namespacePath(namespace string) string {
+	return fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s", Group, Version, url.PathEscape(namespace), Resource)
+}
+
+func (c *Controller) isRunning(refresh DatabaseRefresh) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.running[refresh.Metadata.Namespace+"/"+refresh.Metadata.Name]
+}
+
+func (c *Controller) start(refresh DatabaseRefresh) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.running[refresh.Metadata.Namespace+"/"+refresh.Metadata.Name] = true
+}
+
+func (c *Controller) finish(refresh DatabaseRefresh) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.running, refresh.Metadata.Namespace+"/"+refresh.Metadata.Name)
+}
@@ -0,0 +1,114 @@
+package refresh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/kube"
+)
+
+// fakeAPI serves the refreshes, secrets and config maps of the staging namespace and records the status patches.
+type fakeAPI struct {
+	mu      sync.Mutex
+	patches map[string][]Status
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/apis/klepto.hellofresh.com/v1alpha1/namespaces/staging/databaserefreshes":
+		io.WriteString(w, `{"items": [
+			{"metadata": {"name": "users", "namespace": "staging", "creationTimestamp": "2021-03-01T00:00:00Z"},
+			 "spec": {"schedule": "0 3 * * *", "source": {"secretKeyRef": {"name": "prod", "key": "dsn"}}, "target": {"dsn": "os://stdout/"},
+			          "configMapRef": {"name": "klepto", "key": ".klepto.toml"}, "args": ["--data-only"]}},
+			{"metadata": {"name": "done", "namespace": "staging", "creationTimestamp": "2021-03-01T00:00:00Z"},
+			 "spec": {"schedule": "0 3 * * *", "source": {"dsn": "a"}, "target": {"dsn": "b"}},
+			 "status": {"phase": "Succeeded", "lastScheduleTime": "2021-03-03T03:00:00Z"}},
+			{"metadata": {"name": "suspended", "namespace": "staging", "creationTimestamp": "2021-03-01T00:00:00Z"},
+			 "spec": {"schedule": "0 3 * * *", "suspend": true, "source": {"dsn": "a"}, "target": {"dsn": "b"}}},
+			{"metadata": {"name": "invalid", "namespace": "staging", "creationTimestamp": "2021-03-01T00:00:00Z"},
+			 "spec": {"schedule": "every day", "source": {"dsn": "a"}, "target": {"dsn": "b"}}},
+			{"metadata": {"name": "broken", "namespace": "staging", "creationTimestamp": "2021-03-01T00:00:00Z"},
+			 "spec": {"schedule": "@hourly", "source": {"secretKeyRef": {"name": "missing", "key": "dsn"}}, "target": {"dsn": "b"}}}
+		]}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/staging/secrets/prod":
+		io.WriteString(w, `{"data": {"dsn": "bXlzcWw6Ly9wcm9k"}}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/staging/configmaps/klepto":
+		io.WriteString(w, `{"data": {".klepto.toml": "[[Tables]]\n  Name = \"users\"\n"}}`)
+	case r.Method == http.MethodPatch:
+		var patch struct {
+			Status Status `json:"status"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || r.Header.Get("Content-Type") != "application/merge-patch+json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.patches[r.URL.Path] = append(f.patches[r.URL.Path], patch.Status)
+		f.mu.Unlock()
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"kind": "Status", "message": "not found"}`)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeAPI{patches: make(map[string][]Status)}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	var runs []Run
+	c := NewController(kube.NewClient(server.URL, "token", nil), "staging", func(ctx context.Context, run Run) error {
+		config, err := os.ReadFile(run.ConfigPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(config), `Name = "users"`)
+
+		runs = append(runs, run)
+		return nil
+	})
+	now := time.Date(2021, time.March, 3, 10, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Reconcile(context.Background()))
+	c.Wait()
+
+	require.Len(t, runs, 1)
+	assert.Equal(t, "mysql://prod", runs[0].Source)
+	assert.Equal(t, "os://stdout/", runs[0].Target)
+	assert.Equal(t, []string{"--data-only"}, runs[0].Args)
+	_, err := os.Stat(runs[0].ConfigPath)
+	assert.True(t, errors.Is(err, os.ErrNotExist), "the config file is removed after the run")
+
+	path := "/apis/klepto.hellofresh.com/v1alpha1/namespaces/staging/databaserefreshes/"
+	users := api.patches[path+"users/status"]
+	require.Len(t, users, 2)
+	assert.Equal(t, Running, users[0].Phase)
+	assert.Equal(t, now, *users[0].LastScheduleTime)
+	assert.Equal(t, Succeeded, users[1].Phase)
+	assert.Equal(t, now, *users[1].LastSuccessfulTime)
+
+	broken := api.patches[path+"broken/status"]
+	require.Len(t, broken, 2)
+	assert.Equal(t, Failed, broken[1].Phase)
+	assert.Contains(t, broken[1].Message, "could not read secret staging/missing")
+	assert.Nil(t, broken[1].LastSuccessfulTime)
+
+	invalid := api.patches[path+"invalid/status"]
+	require.Len(t, invalid, 1)
+	assert.Equal(t, Failed, invalid[0].Phase)
+
+	assert.Empty(t, api.patches[path+"done/status"])
+	assert.Empty(t, api.patches[path+"suspended/status"])
+}
@@ -0,0 +1,36 @@
+package replicas
+
+import (
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type balancer struct {
+	reader.Reader
+	// readers are the source and its replicas, the table reads are distributed over.
+	readers []reader.Reader
+	next    uint64
+}
+
+// NewReader returns a reader distributing the table reads round-robin over the source and its replicas, the
+// structure and the metadata of the tables being read from the source. The pages of the tables read in pages
+// (see config.Table.PageSize) are distributed too, as each page is read on its own.
+func NewReader(source reader.Reader, replicas []reader.Reader) reader.Reader {
+	if len(replicas) == 0 {
+		return source
+	}
+
+	return &balancer{Reader: source, readers: append([]reader.Reader{source}, replicas...)}
+}
+
+// ReadTable reads the table from the next reader.
+func (b *balancer) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	i := (atomic.AddUint64(&b.next, 1) - 1) % uint64(len(b.readers))
+	log.WithFields(log.Fields{"table": tableName, "reader": i}).Debug("reading table from replica")
+
+	return b.readers[i].ReadTable(tableName, rowChan, opts)
+}
@@ -0,0 +1,49 @@
+package replicas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	source, first, second := &mockReader{name: "source"}, &mockReader{name: "first"}, &mockReader{name: "second"}
+	r := NewReader(source, []reader.Reader{first, second})
+
+	var read []string
+	for _, table := range []string{"users", "orders", "payments", "logs"} {
+		rowChan := make(chan database.Row, 1)
+		require.NoError(t, r.ReadTable(table, rowChan, reader.ReadTableOpt{}))
+		for row := range rowChan {
+			read = append(read, row.Values()[0].(string)+":"+table)
+		}
+	}
+	assert.Equal(t, []string{"source:users", "first:orders", "second:payments", "source:logs"}, read)
+
+	structure, err := r.GetStructure()
+	require.NoError(t, err)
+	assert.Equal(t, "source", structure, "the structure is read from the source")
+
+	assert.Same(t, source, NewReader(source, nil))
+}
+
+type mockReader struct {
+	name string
+}
+
+func (m *mockReader) GetStructure() (string, error)            { return m.name, nil }
+func (m *mockReader) GetTables() ([]string, error)             { return nil, nil }
+func (m *mockReader) GetColumns(string) ([]string, error)      { return nil, nil }
+func (m *mockReader) FormatColumn(table, column string) string { return column }
+func (m *mockReader) ReadTable(table string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	rowChan <- database.NewRow(database.NewColumns([]string{"reader"}), []interface{}{m.name})
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
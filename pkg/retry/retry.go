@@ -0,0 +1,164 @@
+package retry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+// transientMySQLErrors are the MySQL error numbers worth retrying.
+var transientMySQLErrors = map[uint16]bool{
+	1205: true, // ER_LOCK_WAIT_TIMEOUT
+	1213: true, // ER_LOCK_DEADLOCK
+	1040: true, // ER_CON_COUNT_ERROR
+	1053: true, // ER_SERVER_SHUTDOWN
+	2006: true, // CR_SERVER_GONE_ERROR
+	2013: true, // CR_SERVER_LOST
+}
+
+// transientPostgresErrors are the Postgres error codes worth retrying, codes of length 2 match a whole class.
+var transientPostgresErrors = map[string]bool{
+	"08":    true, // connection_exception
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"55P03": true, // lock_not_available
+	"57P01": true, // admin_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// transientError marks an error as transient, see Transient.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// Transient marks an error as worth retrying, for errors that are not database errors such as
+// an unavailable HTTP endpoint.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &transientError{err: err}
+}
+
+// permanentError marks an error as not worth retrying, see Permanent.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error as not worth retrying even when it is transient, for operations that can not be
+// replayed.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+// Policy defines how operations failing with transient errors are retried.
+type Policy struct {
+	// Attempts is the total amount of attempts, 0 or 1 disables retries.
+	Attempts int
+	// Backoff is the wait before the first retry, it doubles on each retry.
+	Backoff time.Duration
+	// MaxBackoff caps the wait between retries, 0 means no cap.
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the wait that is randomised, between 0 and 1.
+	Jitter float64
+}
+
+// Do runs fn until it succeeds, fails with a non transient error or the attempts are exhausted.
+func (p Policy) Do(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= p.Attempts || !IsTransient(err) {
+			return err
+		}
+
+		wait := p.wait(attempt)
+		log.WithError(err).WithFields(log.Fields{
+			"attempt": attempt,
+			"wait":    wait,
+		}).Warn("transient error, retrying")
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// wait returns the time to wait after the given failed attempt.
+func (p Policy) wait(attempt int) time.Duration {
+	wait := p.Backoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 && wait > 0 {
+		jitter := time.Duration(p.Jitter * float64(wait) * rand.Float64())
+		wait = wait - time.Duration(p.Jitter*float64(wait)/2) + jitter
+	}
+
+	return wait
+}
+
+// IsTransient checks if an error is a transient database error, such as a deadlock,
+// a serialization failure or a dropped connection, or an error marked with Transient, unless it is marked with
+// Permanent.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+
+	var marked *transientError
+	if errors.As(err, &marked) {
+		return true
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return transientMySQLErrors[myErr.Number]
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		code := string(pqErr.Code)
+		return transientPostgresErrors[code] || (len(code) >= 2 && transientPostgresErrors[code[:2]])
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	policy := Policy{Attempts: 3, Backoff: time.Millisecond}
+
+	var calls int
+	err := policy.Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = policy.Do(context.Background(), func() error {
+		calls++
+		return driver.ErrBadConn
+	})
+	assert.Equal(t, driver.ErrBadConn, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	syntaxErr := errors.New("syntax error")
+	err = policy.Do(context.Background(), func() error {
+		calls++
+		return syntaxErr
+	})
+	assert.Equal(t, syntaxErr, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWait(t *testing.T) {
+	policy := Policy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	assert.Equal(t, 100*time.Millisecond, policy.wait(1))
+	assert.Equal(t, 200*time.Millisecond, policy.wait(2))
+	assert.Equal(t, 800*time.Millisecond, policy.wait(4))
+	assert.Equal(t, time.Second, policy.wait(10))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		wait := policy.wait(1)
+		assert.True(t, wait >= 75*time.Millisecond && wait <= 125*time.Millisecond, wait)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(fmt.Errorf("wrapped: %w", &pq.Error{Code: "40001"})))
+	assert.True(t, IsTransient(&pq.Error{Code: "08006"}))
+	assert.False(t, IsTransient(&pq.Error{Code: "42601"}))
+	assert.True(t, IsTransient(&mysql.MySQLError{Number: 1205}))
+	assert.False(t, IsTransient(&mysql.MySQLError{Number: 1064}))
+	assert.False(t, IsTransient(nil))
+	assert.True(t, IsTransient(fmt.Errorf("wrapped: %w", Transient(errors.New("unavailable")))))
+	assert.False(t, IsTransient(errors.New("bad request")))
+	assert.Nil(t, Transient(nil))
+	assert.False(t, IsTransient(fmt.Errorf("wrapped: %w", Permanent(&mysql.MySQLError{Number: 1213}))))
+	assert.Nil(t, Permanent(nil))
+}
@@ -0,0 +1,141 @@
+// Package rowcheck fails the runs dumping fewer or more rows of a table than expected, e.g. a table dumped empty
+// because of a broken filter.
+package rowcheck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	// Thresholds are the expected amounts of dumped rows of the tables.
+	Thresholds struct {
+		tables map[string]bounds
+	}
+
+	bounds struct {
+		min, max *threshold
+	}
+
+	// threshold is an amount of rows, or a percentage of the source rows when relative.
+	threshold struct {
+		rows     uint64
+		percent  float64
+		relative bool
+	}
+)
+
+// New returns the thresholds of the MinRows and MaxRows of the tables.
+func New(tables config.Tables) (*Thresholds, error) {
+	t := &Thresholds{tables: make(map[string]bounds)}
+	for _, table := range tables {
+		if table.MinRows == "" && table.MaxRows == "" {
+			continue
+		}
+
+		var (
+			b   bounds
+			err error
+		)
+		if b.min, err = parse(table.MinRows); err != nil {
+			return nil, fmt.Errorf("invalid MinRows of table %s: %w", table.Name, err)
+		}
+		if b.max, err = parse(table.MaxRows); err != nil {
+			return nil, fmt.Errorf("invalid MaxRows of table %s: %w", table.Name, err)
+		}
+		t.tables[table.Name] = b
+	}
+
+	return t, nil
+}
+
+// Check returns an error listing the dumped tables whose amount of rows is outside their thresholds. The source
+// rows of the tables with a relative threshold are counted with source.
+func (t *Thresholds) Check(result *dumper.Result, source reader.Reader) error {
+	var failed []string
+	for _, table := range result.Tables {
+		b, ok := t.tables[table.Name]
+		if !ok || table.Skipped || table.Err != nil {
+			continue
+		}
+
+		var sourceRows uint64
+		if b.min.isRelative() || b.max.isRelative() {
+			counter, ok := source.(reader.RowCounter)
+			if !ok {
+				return reader.ErrCountUnsupported
+			}
+			var err error
+			if sourceRows, err = counter.CountRows(table.Name); err != nil {
+				return fmt.Errorf("could not count the source rows of %s: %w", table.Name, err)
+			}
+		}
+
+		logger := log.WithFields(log.Fields{"table": table.Name, "rows": table.Rows})
+		if b.min != nil && table.Rows < b.min.of(sourceRows) {
+			logger.Error("Fewer rows than expected were dumped")
+			failed = append(failed, fmt.Sprintf("%s: %d rows, expected at least %s", table.Name, table.Rows, b.min.describe(sourceRows)))
+		}
+		if b.max != nil && table.Rows > b.max.of(sourceRows) {
+			logger.Error("More rows than expected were dumped")
+			failed = append(failed, fmt.Sprintf("%s: %d rows, expected at most %s", table.Name, table.Rows, b.max.describe(sourceRows)))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d row thresholds failed: %s", len(failed), strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+// parse parses an amount of rows, or a percentage of the source rows, nil when empty.
+func parse(value string) (*threshold, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "%")), 64)
+		if err != nil || percent < 0 {
+			return nil, fmt.Errorf("%q is not a percentage", value)
+		}
+		return &threshold{percent: percent, relative: true}, nil
+	}
+
+	rows, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an amount of rows or a percentage", value)
+	}
+
+	return &threshold{rows: rows}, nil
+}
+
+func (t *threshold) isRelative() bool {
+	return t != nil && t.relative
+}
+
+// of returns the amount of rows of the threshold, given the amount of source rows.
+func (t *threshold) of(sourceRows uint64) uint64 {
+	if !t.relative {
+		return t.rows
+	}
+
+	return uint64(float64(sourceRows) * t.percent / 100)
+}
+
+func (t *threshold) describe(sourceRows uint64) string {
+	if !t.relative {
+		return strconv.FormatUint(t.rows, 10)
+	}
+
+	return fmt.Sprintf("%d (%s%% of %d source rows)", t.of(sourceRows), strconv.FormatFloat(t.percent, 'f', -1, 64), sourceRows)
+}
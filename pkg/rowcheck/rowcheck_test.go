@@ -0,0 +1,108 @@
+package rowcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/dumper"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type mockReader struct {
+	reader.Reader
+	counts map[string]uint64
+}
+
+func (m *mockReader) CountRows(tableName string) (uint64, error) {
+	return m.counts[tableName], nil
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	thresholds, err := New(config.Tables{
+		{Name: "users", MinRows: "100"},
+		{Name: "orders", MinRows: "10%", MaxRows: "50%"},
+		{Name: "logs", MaxRows: "1000"},
+		{Name: "countries"},
+	})
+	require.NoError(t, err)
+	source := &mockReader{counts: map[string]uint64{"orders": 1000}}
+
+	tests := []struct {
+		name     string
+		tables   []dumper.TableResult
+		expected string
+	}{
+		{
+			name: "within thresholds",
+			tables: []dumper.TableResult{
+				{Name: "users", Rows: 100},
+				{Name: "orders", Rows: 500},
+				{Name: "logs", Rows: 1000},
+				{Name: "countries", Rows: 0},
+			},
+		},
+		{
+			name: "outside thresholds",
+			tables: []dumper.TableResult{
+				{Name: "users", Rows: 0},
+				{Name: "orders", Rows: 501},
+				{Name: "logs", Rows: 1001},
+			},
+			expected: "3 row thresholds failed: users: 0 rows, expected at least 100; " +
+				"orders: 501 rows, expected at most 500 (50% of 1000 source rows); logs: 1001 rows, expected at most 1000",
+		},
+		{
+			name: "relative to the source",
+			tables: []dumper.TableResult{
+				{Name: "orders", Rows: 99},
+			},
+			expected: "1 row thresholds failed: orders: 99 rows, expected at least 100 (10% of 1000 source rows)",
+		},
+		{
+			name: "skipped and failed tables are not checked",
+			tables: []dumper.TableResult{
+				{Name: "users", Skipped: true},
+				{Name: "logs", Rows: 2000, Err: assert.AnError},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := thresholds.Check(&dumper.Result{Tables: test.tables}, source)
+			if test.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestCheckCountUnsupported(t *testing.T) {
+	t.Parallel()
+
+	thresholds, err := New(config.Tables{{Name: "orders", MinRows: "10%"}})
+	require.NoError(t, err)
+
+	err = thresholds.Check(&dumper.Result{Tables: []dumper.TableResult{{Name: "orders", Rows: 1}}}, struct{ reader.Reader }{})
+	assert.ErrorIs(t, err, reader.ErrCountUnsupported)
+}
+
+func TestNewInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(config.Tables{{Name: "users", MinRows: "lots"}})
+	assert.EqualError(t, err, `invalid MinRows of table users: "lots" is not an amount of rows or a percentage`)
+
+	_, err = New(config.Tables{{Name: "users", MaxRows: "-5%"}})
+	assert.EqualError(t, err, `invalid MaxRows of table users: "-5%" is not a percentage`)
+}
@@ -0,0 +1,211 @@
+package ruletest
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/hellofresh/klepto/pkg/anonymiser"
+	"github.com/hellofresh/klepto/pkg/config"
+)
+
+type (
+	// Suite is a file of anonymisation rule tests.
+	Suite struct {
+		Tests []Test `yaml:"tests"`
+	}
+
+	// Test asserts the properties of the values an anonymisation rule outputs for the given inputs.
+	Test struct {
+		// Name describes the test, the rule tested by default.
+		Name string `yaml:"name"`
+		// Table and Column test the Anonymise rule of a column of the config.
+		Table  string `yaml:"table"`
+		Column string `yaml:"column"`
+		// Rule tests an anonymiser without a config, e.g. "Trim | Lower | Hash:salt".
+		Rule string `yaml:"rule"`
+		// Inputs are the original values anonymised, null for NULL.
+		Inputs []interface{} `yaml:"inputs"`
+		// Expect are the properties of every output.
+		Expect Expect `yaml:"expect"`
+	}
+
+	// Expect are the properties expected from the anonymised values, the ones left unset are not checked.
+	Expect struct {
+		// Regex is a regular expression the outputs must match.
+		Regex string `yaml:"regex"`
+		// Length is the exact length of the outputs, in characters.
+		Length *int `yaml:"length"`
+		// MinLength and MaxLength bound the length of the outputs, in characters.
+		MinLength *int `yaml:"min_length"`
+		MaxLength *int `yaml:"max_length"`
+		// Equals is the exact output.
+		Equals *string `yaml:"equals"`
+		// Changed tells whether the outputs differ from their input.
+		Changed *bool `yaml:"changed"`
+		// Stable tells whether an input is anonymised into the same output by different runs.
+		Stable *bool `yaml:"stable"`
+	}
+
+	// Result is the outcome of a test, it passed when it has no failures.
+	Result struct {
+		Name     string
+		Failures []string
+	}
+)
+
+// Load reads a rule tests file, failing on unknown keys.
+func Load(path string) (*Suite, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read rule tests: %w", err)
+	}
+
+	var suite Suite
+	if err := yaml.UnmarshalStrict(b, &suite); err != nil {
+		return nil, fmt.Errorf("could not parse rule tests %s: %w", path, err)
+	}
+	for i, test := range suite.Tests {
+		configured := test.Table != "" || test.Column != ""
+		if (test.Rule != "") == configured || (configured && (test.Table == "" || test.Column == "")) {
+			return nil, fmt.Errorf("test %d of %s must set either a rule or a table and a column", i+1, path)
+		}
+		if len(test.Inputs) == 0 {
+			return nil, fmt.Errorf("test %d of %s has no inputs", i+1, path)
+		}
+	}
+
+	return &suite, nil
+}
+
+// UsesConfig tells whether tests of the suite test the rules of a config.
+func (s *Suite) UsesConfig() bool {
+	for _, test := range s.Tests {
+		if test.Table != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run runs the tests, the rules of the tables and columns being read from the tables config.
+func (s *Suite) Run(tables config.Tables) []Result {
+	results := make([]Result, len(s.Tests))
+	for i, test := range s.Tests {
+		results[i] = test.run(tables)
+	}
+
+	return results
+}
+
+// Passed tells whether the test passed.
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+func (t Test) run(tables config.Tables) Result {
+	result := Result{Name: t.Name}
+	fail := func(format string, args ...interface{}) {
+		result.Failures = append(result.Failures, fmt.Sprintf(format, args...))
+	}
+
+	rule := t.Rule
+	if rule == "" {
+		if result.Name == "" {
+			result.Name = t.Table + "." + t.Column
+		}
+		table := tables.FindByName(t.Table)
+		if table == nil {
+			fail("table %s is not configured", t.Table)
+			return result
+		}
+		if rule = table.Anonymise[t.Column]; rule == "" {
+			fail("column %s.%s is not anonymised", t.Table, t.Column)
+			return result
+		}
+	}
+	if result.Name == "" {
+		result.Name = rule
+	}
+
+	if err := anonymiser.Validate(rule); err != nil {
+		fail("%v", err)
+		return result
+	}
+	var pattern *regexp.Regexp
+	if t.Expect.Regex != "" {
+		var err error
+		if pattern, err = regexp.Compile(t.Expect.Regex); err != nil {
+			fail("invalid regex %q: %v", t.Expect.Regex, err)
+			return result
+		}
+	}
+
+	for i, input := range t.Inputs {
+		n := uint64(i + 1)
+		output := anonymiser.Apply(rule, input, n)
+		for _, failure := range t.Expect.check(pattern, input, output) {
+			fail("input %s: %s", quote(input), failure)
+		}
+
+		if t.Expect.Stable != nil {
+			// another run anonymises the input again, with the same row number
+			stable := text(anonymiser.Apply(rule, input, n)) == text(output)
+			if stable != *t.Expect.Stable {
+				fail("input %s: stable is %t, expected %t", quote(input), stable, *t.Expect.Stable)
+			}
+		}
+	}
+
+	return result
+}
+
+// check returns the expectations an output does not meet.
+func (e Expect) check(pattern *regexp.Regexp, input interface{}, output interface{}) []string {
+	var failures []string
+	value, quoted := text(output), quote(output)
+	length := utf8.RuneCountInString(value)
+
+	if pattern != nil && !pattern.MatchString(value) {
+		failures = append(failures, fmt.Sprintf("output %s does not match %s", quoted, e.Regex))
+	}
+	if e.Length != nil && length != *e.Length {
+		failures = append(failures, fmt.Sprintf("output %s has length %d, expected %d", quoted, length, *e.Length))
+	}
+	if e.MinLength != nil && length < *e.MinLength {
+		failures = append(failures, fmt.Sprintf("output %s is shorter than %d", quoted, *e.MinLength))
+	}
+	if e.MaxLength != nil && length > *e.MaxLength {
+		failures = append(failures, fmt.Sprintf("output %s is longer than %d", quoted, *e.MaxLength))
+	}
+	if e.Equals != nil && (output == nil || value != *e.Equals) {
+		failures = append(failures, fmt.Sprintf("output %s is not %q", quoted, *e.Equals))
+	}
+	if changed := quoted != quote(input); e.Changed != nil && changed != *e.Changed {
+		failures = append(failures, fmt.Sprintf("output %s changed is %t, expected %t", quoted, changed, *e.Changed))
+	}
+
+	return failures
+}
+
+// text returns the text of a value, NULL being empty.
+func text(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+// quote returns the quoted text of a value for the failures, NULL being unquoted.
+func quote(value interface{}) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	return fmt.Sprintf("%q", text(value))
+}
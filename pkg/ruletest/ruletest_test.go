@@ -0,0 +1,84 @@
+package ruletest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+)
+
+const rules = `
+tests:
+  - name: emails are hashed
+    table: users
+    column: email
+    inputs: [" Jane@Example.com", "jane@example.com"]
+    expect:
+      regex: "^[0-9a-f]+$"
+      length: 64
+      stable: true
+      changed: true
+  - rule: KeepLast:4
+    inputs: ["4111111111111111", null]
+    expect:
+      equals: "************1111"
+  - table: users
+    column: name
+    inputs: [Jane]
+    expect:
+      min_length: 1
+      max_length: 2
+      stable: true
+  - table: users
+    column: phone
+    inputs: [123]
+  - rule: Trim | Nope
+    inputs: [a]
+`
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rules_test.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(rules), 0o600))
+
+	suite, err := Load(path)
+	require.NoError(t, err)
+	assert.True(t, suite.UsesConfig())
+
+	tables := config.Tables{{Name: "users", Anonymise: map[string]string{"email": "Trim | Lower | Hash:salt", "name": "DigitsN:12"}}}
+	results := suite.Run(tables)
+	require.Len(t, results, 5)
+
+	assert.Equal(t, Result{Name: "emails are hashed"}, results[0])
+	assert.True(t, results[0].Passed())
+	assert.Equal(t, "KeepLast:4", results[1].Name)
+	assert.Equal(t, []string{`input NULL: output NULL is not "************1111"`}, results[1].Failures)
+	assert.Equal(t, "users.name", results[2].Name)
+	assert.Contains(t, results[2].Failures[0], `input "Jane": output `)
+	assert.Contains(t, results[2].Failures[0], `is longer than 2`)
+	assert.Equal(t, `input "Jane": stable is false, expected true`, results[2].Failures[len(results[2].Failures)-1])
+	assert.Equal(t, []string{"column users.phone is not anonymised"}, results[3].Failures)
+	assert.Equal(t, []string{"unknown anonymiser Nope"}, results[4].Failures)
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Parallel()
+
+	for content, expected := range map[string]string{
+		"tests:\n  - rule: Lower\n":                                                "has no inputs",
+		"tests:\n  - rule: Lower\n    table: users\n    inputs: [a]\n":             "must set either a rule or a table and a column",
+		"tests:\n  - rule: Lower\n    inputs: [a]\n    expect:\n      lenght: 2\n": "field lenght not found",
+	} {
+		path := filepath.Join(t.TempDir(), "rules_test.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		_, err := Load(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), expected)
+	}
+}
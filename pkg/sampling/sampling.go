@@ -0,0 +1,136 @@
+package sampling
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	// Options are the sampling options applied to all the tables.
+	Options struct {
+		// DefaultLimit is the limit of rows read from tables without a configured limit or match, 0 for no limit.
+		DefaultLimit uint64
+		// FullTableRows is the amount of rows under which a table is read completely whatever its filter, 0 to disable.
+		FullTableRows uint64
+		// LimitPerTable overrides the configured limit of all the tables not marked as Full, 0 to keep it.
+		LimitPerTable uint64
+		// Tables restricts the tables whose data is read, all the tables are read when empty.
+		Tables []string
+	}
+
+	sampler struct {
+		reader.Reader
+		tables config.Tables
+		opts   Options
+	}
+)
+
+// NewReader returns a reader restricted to the selected tables, applying the limit per table and the default limit
+// to the tables without a filter, and reading completely the tables marked as Full and the tables with at most FullTableRows rows.
+func NewReader(source reader.Reader, tables config.Tables, opts Options) reader.Reader {
+	return &sampler{Reader: source, tables: tables, opts: opts}
+}
+
+// GetTables returns the source tables, restricted to the Tables option when set.
+func (s *sampler) GetTables() ([]string, error) {
+	tables, err := s.Reader.GetTables()
+	if err != nil || len(s.opts.Tables) == 0 {
+		return tables, err
+	}
+
+	exists := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		exists[table] = true
+	}
+
+	selected := make([]string, 0, len(s.opts.Tables))
+	for _, table := range s.opts.Tables {
+		if !exists[table] {
+			return nil, fmt.Errorf("sampling: table %s does not exist", table)
+		}
+		selected = append(selected, table)
+	}
+
+	return selected, nil
+}
+
+// ReadTable reads the table with the sampling options applied.
+func (s *sampler) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	logger := log.WithField("table", tableName)
+
+	table := s.tables.FindByName(tableName)
+	if table != nil && table.Full {
+		logger.Debug("the table is read completely")
+		return s.Reader.ReadTable(tableName, rowChan, reader.ReadTableOpt{
+			Columns:      opts.Columns,
+			ChunkColumns: opts.ChunkColumns,
+			ChunkSize:    opts.ChunkSize,
+			Query:        opts.Query,
+		})
+	}
+
+	if s.opts.LimitPerTable > 0 {
+		opts.Limit = s.opts.LimitPerTable
+	}
+	// the default limit does not apply to the tables filtered by an expression either
+	filtered := opts.Match != "" || (table != nil && table.Filter.Expression != "")
+	if opts.Limit == 0 && !filtered && s.opts.DefaultLimit > 0 {
+		opts.Limit = s.opts.DefaultLimit
+	}
+
+	if s.opts.FullTableRows == 0 || !isFiltered(opts) {
+		return s.Reader.ReadTable(tableName, rowChan, opts)
+	}
+
+	rows, err := s.probe(tableName, opts)
+	if err != nil {
+		close(rowChan)
+		return err
+	}
+	if uint64(len(rows)) > s.opts.FullTableRows {
+		return s.Reader.ReadTable(tableName, rowChan, opts)
+	}
+
+	logger.WithField("rows", len(rows)).Debug("the table is small, it is read completely")
+	for _, row := range rows {
+		rowChan <- row
+	}
+	close(rowChan)
+
+	return nil
+}
+
+// probe reads up to one row more than FullTableRows, without filter.
+func (s *sampler) probe(tableName string, opts reader.ReadTableOpt) ([]database.Row, error) {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.Reader.ReadTable(tableName, rowChan, reader.ReadTableOpt{
+			Columns:      opts.Columns,
+			Limit:        s.opts.FullTableRows + 1,
+			ChunkColumns: opts.ChunkColumns,
+			ChunkSize:    opts.ChunkSize,
+			Query:        opts.Query,
+		})
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+	if err := <-errChan; err != nil {
+		return nil, fmt.Errorf("sampling: could not count the table rows: %w", err)
+	}
+
+	return rows, nil
+}
+
+// isFiltered reports whether the read options may leave out rows of the table.
+func isFiltered(opts reader.ReadTableOpt) bool {
+	return opts.Limit > 0 || opts.Match != "" || len(opts.Relationships) > 0
+}
@@ -0,0 +1,180 @@
+package sampling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{
+		{Name: "countries", Full: true},
+		{Name: "orders"},
+		{Name: "events", Filter: config.Filter{Expression: `row.kind == "login"`}},
+	}
+
+	tests := []struct {
+		name     string
+		table    string
+		rows     int
+		opts     reader.ReadTableOpt
+		sampling Options
+		expected int
+		reads    []reader.ReadTableOpt
+	}{
+		{
+			name:     "full table ignores the filter",
+			table:    "countries",
+			rows:     10,
+			opts:     reader.ReadTableOpt{Limit: 2, Match: "code = 'de'"},
+			sampling: Options{DefaultLimit: 5},
+			expected: 10,
+			reads:    []reader.ReadTableOpt{{}},
+		},
+		{
+			name:     "default limit",
+			table:    "orders",
+			rows:     10,
+			sampling: Options{DefaultLimit: 5},
+			expected: 5,
+			reads:    []reader.ReadTableOpt{{Limit: 5}},
+		},
+		{
+			name:     "default limit does not apply to matched tables",
+			table:    "orders",
+			rows:     10,
+			opts:     reader.ReadTableOpt{Match: "id > 1"},
+			sampling: Options{DefaultLimit: 5},
+			expected: 10,
+			reads:    []reader.ReadTableOpt{{Match: "id > 1"}},
+		},
+		{
+			name:     "default limit does not apply to tables filtered by an expression",
+			table:    "events",
+			rows:     10,
+			sampling: Options{DefaultLimit: 5},
+			expected: 10,
+			reads:    []reader.ReadTableOpt{{}},
+		},
+		{
+			name:     "small table is read completely",
+			table:    "orders",
+			rows:     3,
+			opts:     reader.ReadTableOpt{Limit: 1},
+			sampling: Options{FullTableRows: 3},
+			expected: 3,
+			reads:    []reader.ReadTableOpt{{Limit: 4}},
+		},
+		{
+			name:     "large table keeps its filter",
+			table:    "orders",
+			rows:     10,
+			opts:     reader.ReadTableOpt{Limit: 1},
+			sampling: Options{FullTableRows: 3},
+			expected: 1,
+			reads:    []reader.ReadTableOpt{{Limit: 4}, {Limit: 1}},
+		},
+		{
+			name:     "limit per table overrides the configured limit",
+			table:    "orders",
+			rows:     10,
+			opts:     reader.ReadTableOpt{Limit: 1, Match: "id > 1"},
+			sampling: Options{LimitPerTable: 3, DefaultLimit: 5},
+			expected: 3,
+			reads:    []reader.ReadTableOpt{{Limit: 3, Match: "id > 1"}},
+		},
+		{
+			name:     "limit per table does not apply to full tables",
+			table:    "countries",
+			rows:     10,
+			sampling: Options{LimitPerTable: 3},
+			expected: 10,
+			reads:    []reader.ReadTableOpt{{}},
+		},
+		{
+			name:     "unfiltered table is not counted",
+			table:    "orders",
+			rows:     10,
+			sampling: Options{FullTableRows: 3},
+			expected: 10,
+			reads:    []reader.ReadTableOpt{{}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := &mockReader{rows: test.rows}
+			r := NewReader(source, tables, test.sampling)
+
+			rowChan := make(chan database.Row)
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- r.ReadTable(test.table, rowChan, test.opts)
+			}()
+
+			var read int
+			for range rowChan {
+				read++
+			}
+			require.NoError(t, <-errChan)
+			assert.Equal(t, test.expected, read)
+			assert.Equal(t, test.reads, source.reads)
+		})
+	}
+}
+
+func TestGetTables(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{tables: []string{"users", "orders", "countries"}}
+
+	tables, err := NewReader(source, nil, Options{}).GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "orders", "countries"}, tables)
+
+	tables, err = NewReader(source, nil, Options{Tables: []string{"orders", "users"}}).GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders", "users"}, tables)
+
+	_, err = NewReader(source, nil, Options{Tables: []string{"orders", "payments"}}).GetTables()
+	assert.EqualError(t, err, "sampling: table payments does not exist")
+}
+
+// mockReader has the given amount of rows and only applies the limit.
+type mockReader struct {
+	rows   int
+	tables []string
+	reads  []reader.ReadTableOpt
+}
+
+func (m *mockReader) GetTables() ([]string, error)        { return m.tables, nil }
+func (m *mockReader) GetStructure() (string, error)       { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) { return nil, nil }
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return ""
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	m.reads = append(m.reads, opts)
+	columns := database.NewColumns([]string{"id"})
+	for i := 0; i < m.rows; i++ {
+		if opts.Limit > 0 && uint64(i) == opts.Limit {
+			break
+		}
+		rowChan <- database.NewRow(columns, []interface{}{int64(i)})
+	}
+
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
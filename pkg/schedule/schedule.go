@@ -0,0 +1,147 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// descriptors are the schedules that can be given by name.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type (
+	// Schedule is a cron schedule: minute, hour, day of month, month and day of week.
+	Schedule struct {
+		minute, hour, dom, month, dow field
+		// domAny and dowAny are true when the day of month and day of week are not restricted,
+		// when both are restricted a day matching either of them matches.
+		domAny, dowAny bool
+	}
+
+	// field is the set of values matching a schedule field.
+	field map[int]bool
+
+	bounds struct{ min, max int }
+)
+
+// Parse parses a standard 5 fields cron expression, e.g. "30 2 * * 1-5", or a descriptor such as @daily.
+// Fields accept lists, ranges and steps, day of week 7 is Sunday like 0.
+func Parse(expr string) (*Schedule, error) {
+	if d, ok := descriptors[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = d
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q, expected 5 fields: minute hour day-of-month month day-of-week", expr)
+	}
+
+	all := []bounds{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	parsed := make([]field, len(fields))
+	for i, f := range fields {
+		var err error
+		if parsed[i], err = parseField(f, all[i]); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", expr, err)
+		}
+	}
+	if parsed[4][7] {
+		parsed[4][0] = true
+	}
+
+	return &Schedule{
+		minute: parsed[0],
+		hour:   parsed[1],
+		dom:    parsed[2],
+		month:  parsed[3],
+		dow:    parsed[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+func parseField(f string, b bounds) (field, error) {
+	values := make(field)
+	for _, part := range strings.Split(f, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rng = part[:i]
+		}
+
+		lo, hi := b.min, b.max
+		if rng != "*" {
+			var err error
+			ends := strings.SplitN(rng, "-", 2)
+			if lo, err = strconv.Atoi(ends[0]); err != nil {
+				return nil, fmt.Errorf("invalid value in %q", part)
+			}
+			hi = lo
+			if len(ends) == 2 {
+				if hi, err = strconv.Atoi(ends[1]); err != nil {
+					return nil, fmt.Errorf("invalid value in %q", part)
+				}
+			} else if step > 1 {
+				hi = b.max
+			}
+		}
+		if lo < b.min || hi > b.max || lo > hi {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, b.min, b.max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+// Next returns the first time matching the schedule after t, in the location of t.
+// The zero time is returned when no time matches within five years, e.g. for February 30th.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !s.month[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom, dow := s.dom[t.Day()], s.dow[int(t.Weekday())]
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+
+	return dom || dow
+}
@@ -0,0 +1,54 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for _, invalid := range []string{"", "* * * *", "60 * * * *", "* 5-2 * * *", "*/0 * * * *", "a * * * *", "@often"} {
+		_, err := Parse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	t.Parallel()
+
+	// a Wednesday
+	from := time.Date(2021, time.March, 3, 10, 30, 15, 0, time.UTC)
+	cases := map[string]time.Time{
+		"* * * * *":       time.Date(2021, time.March, 3, 10, 31, 0, 0, time.UTC),
+		"@daily":          time.Date(2021, time.March, 4, 0, 0, 0, 0, time.UTC),
+		"0 3 * * *":       time.Date(2021, time.March, 4, 3, 0, 0, 0, time.UTC),
+		"*/15 10 * * *":   time.Date(2021, time.March, 3, 10, 45, 0, 0, time.UTC),
+		"0 9-17/4 * * *":  time.Date(2021, time.March, 3, 13, 0, 0, 0, time.UTC),
+		"0 0 * * 7":       time.Date(2021, time.March, 7, 0, 0, 0, 0, time.UTC),
+		"0 0 1 * 1":       time.Date(2021, time.March, 8, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":      time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"30 2 * * 1,3,5":  time.Date(2021, time.March, 5, 2, 30, 0, 0, time.UTC),
+		"0 0 1 1,6 *":     time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 30 2 *":      {},
+		"59 23 31 12 *":   time.Date(2021, time.December, 31, 23, 59, 0, 0, time.UTC),
+		"0 0 * * *   ":    time.Date(2021, time.March, 4, 0, 0, 0, 0, time.UTC),
+		"@hourly":         time.Date(2021, time.March, 3, 11, 0, 0, 0, time.UTC),
+		"0-5 11 * * 3":    time.Date(2021, time.March, 3, 11, 0, 0, 0, time.UTC),
+		"15 10 3 3 *":     time.Date(2022, time.March, 3, 10, 15, 0, 0, time.UTC),
+		"0 0 1 * *":       time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC),
+		"0 12 * * 1-5":    time.Date(2021, time.March, 3, 12, 0, 0, 0, time.UTC),
+		"0 12 * * 6":      time.Date(2021, time.March, 6, 12, 0, 0, 0, time.UTC),
+		"0 0 31 * *":      time.Date(2021, time.March, 31, 0, 0, 0, 0, time.UTC),
+		"0 0 31 4,6,9 *":  {},
+		"0 0 31 4,6,12 *": time.Date(2021, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}
+	for expr, want := range cases {
+		schedule, err := Parse(expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, want, schedule.Next(from), expr)
+	}
+}
@@ -0,0 +1,294 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// Changes of a table, column or index between two schemas.
+const (
+	// Added is something only in the second schema.
+	Added Change = "added"
+	// Removed is something only in the first schema.
+	Removed Change = "removed"
+	// Changed is something in both schemas that differs.
+	Changed Change = "changed"
+)
+
+type (
+	// Change is the way a table, column or index differs between two schemas.
+	Change string
+
+	// Schema is the structure of the tables of a database.
+	Schema struct {
+		Tables []Table
+	}
+
+	// Table is the structure of a table.
+	Table struct {
+		Name    string
+		Columns []Column
+		// Indexes are nil when the reader does not know them.
+		Indexes []reader.Index
+	}
+
+	// Column is a table column, its type is empty when the reader does not know it.
+	Column struct {
+		Name string
+		Type string
+	}
+
+	// Diff is the difference between two schemas.
+	Diff struct {
+		Tables []TableDiff `json:"tables"`
+	}
+
+	// TableDiff is the difference between a table of two schemas, its columns and indexes
+	// are only compared when it is in both.
+	TableDiff struct {
+		Name    string       `json:"name"`
+		Change  Change       `json:"change"`
+		Columns []ColumnDiff `json:"columns,omitempty"`
+		Indexes []IndexDiff  `json:"indexes,omitempty"`
+	}
+
+	// ColumnDiff is the difference between a column of two schemas, with its types.
+	ColumnDiff struct {
+		Name   string `json:"name"`
+		Change Change `json:"change"`
+		From   string `json:"from,omitempty"`
+		To     string `json:"to,omitempty"`
+	}
+
+	// IndexDiff is the difference between an index of two schemas.
+	IndexDiff struct {
+		Name   string        `json:"name"`
+		Change Change        `json:"change"`
+		From   *reader.Index `json:"from,omitempty"`
+		To     *reader.Index `json:"to,omitempty"`
+	}
+)
+
+// Read reads the structure of the database tables, with the column types and indexes when the reader knows them.
+func Read(r reader.Reader) (*Schema, error) {
+	tables, err := r.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("could not get tables: %w", err)
+	}
+
+	typer, hasTypes := r.(reader.ColumnTyper)
+	indexer, hasIndexes := r.(reader.Indexer)
+
+	s := &Schema{Tables: make([]Table, len(tables))}
+	for i, name := range tables {
+		columns, err := r.GetColumns(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not get columns of %s: %w", name, err)
+		}
+
+		var types map[string]string
+		if hasTypes {
+			types, err = typer.GetColumnTypes(name)
+			if err != nil && !errors.Is(err, reader.ErrColumnTypesUnsupported) {
+				return nil, fmt.Errorf("could not get column types of %s: %w", name, err)
+			}
+		}
+
+		table := Table{Name: name, Columns: make([]Column, len(columns))}
+		for j, column := range columns {
+			table.Columns[j] = Column{Name: column, Type: types[column]}
+		}
+
+		if hasIndexes {
+			indexes, err := indexer.GetIndexes(name)
+			switch {
+			case errors.Is(err, reader.ErrIndexesUnsupported):
+			case err != nil:
+				return nil, fmt.Errorf("could not get indexes of %s: %w", name, err)
+			default:
+				// a table without indexes is told apart from one whose indexes are unknown
+				table.Indexes = append([]reader.Index{}, indexes...)
+			}
+		}
+
+		s.Tables[i] = table
+	}
+
+	return s, nil
+}
+
+// Table returns the table with the given name, nil when there is none.
+func (s *Schema) Table(name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+
+	return nil
+}
+
+// Compare returns the difference from one schema to another, in the order of the tables and columns of from
+// followed by the ones only in to. The column types are compared case insensitively and only when compareTypes
+// is set, e.g. when both databases are of the same kind, the indexes only when both readers know them.
+func Compare(from, to *Schema, compareTypes bool) *Diff {
+	diff := &Diff{Tables: []TableDiff{}}
+	for _, table := range from.Tables {
+		other := to.Table(table.Name)
+		if other == nil {
+			diff.Tables = append(diff.Tables, TableDiff{Name: table.Name, Change: Removed})
+			continue
+		}
+
+		if d := compareTable(table, *other, compareTypes); len(d.Columns) > 0 || len(d.Indexes) > 0 {
+			diff.Tables = append(diff.Tables, d)
+		}
+	}
+	for _, table := range to.Tables {
+		if from.Table(table.Name) == nil {
+			diff.Tables = append(diff.Tables, TableDiff{Name: table.Name, Change: Added})
+		}
+	}
+
+	return diff
+}
+
+// Table returns the difference of the table with the given name, nil when it does not differ.
+func (d *Diff) Table(name string) *TableDiff {
+	for i := range d.Tables {
+		if d.Tables[i].Name == name {
+			return &d.Tables[i]
+		}
+	}
+
+	return nil
+}
+
+// Empty reports whether the schemas are the same.
+func (d *Diff) Empty() bool {
+	return len(d.Tables) == 0
+}
+
+// WriteText writes the difference in a human readable form, one line per change prefixed by
+// - for removals, + for additions and ~ for changes.
+func (d *Diff) WriteText(w io.Writer) error {
+	for _, table := range d.Tables {
+		if table.Change != Changed {
+			if _, err := fmt.Fprintf(w, "%s table %s\n", prefix(table.Change), table.Name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, column := range table.Columns {
+			line := fmt.Sprintf("%s column %s.%s", prefix(column.Change), table.Name, column.Name)
+			switch column.Change {
+			case Changed:
+				line += fmt.Sprintf(" %s -> %s", column.From, column.To)
+			case Removed:
+				line += " " + column.From
+			case Added:
+				line += " " + column.To
+			}
+			if _, err := fmt.Fprintln(w, strings.TrimSpace(line)); err != nil {
+				return err
+			}
+		}
+		for _, index := range table.Indexes {
+			line := fmt.Sprintf("%s index %s.%s", prefix(index.Change), table.Name, index.Name)
+			switch index.Change {
+			case Changed:
+				line += fmt.Sprintf(" %s -> %s", describeIndex(*index.From), describeIndex(*index.To))
+			case Removed:
+				line += " " + describeIndex(*index.From)
+			case Added:
+				line += " " + describeIndex(*index.To)
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func compareTable(from, to Table, compareTypes bool) TableDiff {
+	d := TableDiff{Name: from.Name, Change: Changed}
+
+	toTypes := make(map[string]string, len(to.Columns))
+	for _, column := range to.Columns {
+		toTypes[column.Name] = column.Type
+	}
+	fromColumns := make(map[string]bool, len(from.Columns))
+	for _, column := range from.Columns {
+		fromColumns[column.Name] = true
+
+		toType, ok := toTypes[column.Name]
+		switch {
+		case !ok:
+			d.Columns = append(d.Columns, ColumnDiff{Name: column.Name, Change: Removed, From: column.Type})
+		case compareTypes && column.Type != "" && toType != "" && !strings.EqualFold(column.Type, toType):
+			d.Columns = append(d.Columns, ColumnDiff{Name: column.Name, Change: Changed, From: column.Type, To: toType})
+		}
+	}
+	for _, column := range to.Columns {
+		if !fromColumns[column.Name] {
+			d.Columns = append(d.Columns, ColumnDiff{Name: column.Name, Change: Added, To: column.Type})
+		}
+	}
+
+	if from.Indexes == nil || to.Indexes == nil {
+		return d
+	}
+
+	toIndexes := make(map[string]*reader.Index, len(to.Indexes))
+	for i := range to.Indexes {
+		toIndexes[to.Indexes[i].Name] = &to.Indexes[i]
+	}
+	fromIndexes := make(map[string]bool, len(from.Indexes))
+	for i := range from.Indexes {
+		index := &from.Indexes[i]
+		fromIndexes[index.Name] = true
+
+		other, ok := toIndexes[index.Name]
+		switch {
+		case !ok:
+			d.Indexes = append(d.Indexes, IndexDiff{Name: index.Name, Change: Removed, From: index})
+		case !reflect.DeepEqual(*index, *other):
+			d.Indexes = append(d.Indexes, IndexDiff{Name: index.Name, Change: Changed, From: index, To: other})
+		}
+	}
+	for i := range to.Indexes {
+		if index := &to.Indexes[i]; !fromIndexes[index.Name] {
+			d.Indexes = append(d.Indexes, IndexDiff{Name: index.Name, Change: Added, To: index})
+		}
+	}
+
+	return d
+}
+
+func prefix(change Change) string {
+	switch change {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	}
+
+	return "~"
+}
+
+func describeIndex(index reader.Index) string {
+	s := "(" + strings.Join(index.Columns, ", ") + ")"
+	if index.Unique {
+		s += " unique"
+	}
+
+	return s
+}
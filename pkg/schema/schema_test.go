@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type mockReader struct {
+	reader.Reader
+	tables  []string
+	columns map[string][]Column
+	indexes map[string][]reader.Index
+}
+
+func (m *mockReader) GetTables() ([]string, error) { return m.tables, nil }
+
+func (m *mockReader) GetColumns(table string) ([]string, error) {
+	var columns []string
+	for _, column := range m.columns[table] {
+		columns = append(columns, column.Name)
+	}
+	return columns, nil
+}
+
+func (m *mockReader) GetColumnTypes(table string) (map[string]string, error) {
+	types := make(map[string]string)
+	for _, column := range m.columns[table] {
+		types[column.Name] = column.Type
+	}
+	return types, nil
+}
+
+func (m *mockReader) GetIndexes(table string) ([]reader.Index, error) {
+	return m.indexes[table], nil
+}
+
+func newSchemas(t *testing.T) (*Schema, *Schema) {
+	from, err := Read(&mockReader{
+		tables: []string{"users", "logs"},
+		columns: map[string][]Column{
+			"users": {{"id", "int"}, {"email", "varchar(255)"}, {"notes", "text"}},
+			"logs":  {{"id", "int"}},
+		},
+		indexes: map[string][]reader.Index{
+			"users": {{Name: "PRIMARY", Columns: []string{"id"}, Unique: true}, {Name: "email", Columns: []string{"email"}}},
+		},
+	})
+	require.NoError(t, err)
+
+	to, err := Read(&mockReader{
+		tables: []string{"users", "orders"},
+		columns: map[string][]Column{
+			"users":  {{"id", "bigint"}, {"email", "VARCHAR(255)"}, {"name", "varchar(64)"}},
+			"orders": {{"id", "int"}},
+		},
+		indexes: map[string][]reader.Index{
+			"users": {{Name: "PRIMARY", Columns: []string{"id"}, Unique: true}, {Name: "email", Columns: []string{"email"}, Unique: true}, {Name: "name", Columns: []string{"name"}}},
+		},
+	})
+	require.NoError(t, err)
+
+	return from, to
+}
+
+func TestRead(t *testing.T) {
+	from, _ := newSchemas(t)
+
+	users := from.Table("users")
+	require.NotNil(t, users)
+	assert.Equal(t, []Column{{"id", "int"}, {"email", "varchar(255)"}, {"notes", "text"}}, users.Columns)
+	assert.Len(t, users.Indexes, 2)
+
+	logs := from.Table("logs")
+	require.NotNil(t, logs)
+	assert.NotNil(t, logs.Indexes)
+	assert.Empty(t, logs.Indexes)
+
+	assert.Nil(t, from.Table("orders"))
+}
+
+func TestCompare(t *testing.T) {
+	from, to := newSchemas(t)
+
+	var text bytes.Buffer
+	require.NoError(t, Compare(from, to, true).WriteText(&text))
+	assert.Equal(t, `~ column users.id int -> bigint
+- column users.notes text
++ column users.name varchar(64)
+~ index users.email (email) -> (email) unique
++ index users.name (name)
+- table logs
++ table orders
+`, text.String())
+
+	diff := Compare(from, to, false)
+	users := diff.Table("users")
+	require.NotNil(t, users)
+	assert.Equal(t, []ColumnDiff{
+		{Name: "notes", Change: Removed, From: "text"},
+		{Name: "name", Change: Added, To: "varchar(64)"},
+	}, users.Columns)
+
+	assert.True(t, Compare(from, from, true).Empty())
+
+	// the indexes are not compared when a reader does not know them
+	to.Table("users").Indexes = nil
+	assert.Empty(t, Compare(from, to, true).Table("users").Indexes)
+}
+
+func TestDiffJSON(t *testing.T) {
+	from, to := newSchemas(t)
+
+	b, err := json.Marshal(Compare(from, to, true).Table("logs"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "logs", "change": "removed"}`, string(b))
+}
@@ -0,0 +1,180 @@
+package shard
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// identifier matches the words of the SQL text that may be table names.
+var identifier = regexp.MustCompile(`\w+`)
+
+type shardReader struct {
+	reader.Reader
+	// shards are the shards of the merged tables, in the order of the source tables.
+	shards map[string][]string
+	// merged maps the shards to their merged table.
+	merged map[string]string
+}
+
+// NewReader returns a reader dumping the shards of the tables configured with MergeShards as a single table,
+// named like the table and created like its first shard. The shards are read one after the other with the
+// read options of the merged table, the filters and limits applying to each shard.
+func NewReader(source reader.Reader, cfgTables config.Tables) (reader.Reader, error) {
+	var mergedTables []*config.Table
+	for _, table := range cfgTables {
+		if table.Shards != "" && table.MergeShards {
+			mergedTables = append(mergedTables, table)
+		}
+	}
+	if len(mergedTables) == 0 {
+		return source, nil
+	}
+
+	tables, err := source.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("shard: could not get tables: %w", err)
+	}
+
+	r := &shardReader{Reader: source, shards: make(map[string][]string), merged: make(map[string]string)}
+	for _, table := range mergedTables {
+		shards := table.MatchShards(tables)
+		if len(shards) == 0 {
+			continue
+		}
+
+		r.shards[table.Name] = shards
+		for _, shard := range shards {
+			r.merged[shard] = table.Name
+		}
+	}
+
+	return r, nil
+}
+
+// GetTables returns the tables with the shards of the merged tables replaced by their merged table.
+func (r *shardReader) GetTables() ([]string, error) {
+	tables, err := r.Reader.GetTables()
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make(map[string]bool, len(r.shards))
+	merged := make([]string, 0, len(tables))
+	for _, table := range tables {
+		name, ok := r.merged[table]
+		if !ok {
+			merged = append(merged, table)
+			continue
+		}
+		if !listed[name] {
+			listed[name] = true
+			merged = append(merged, name)
+		}
+	}
+
+	return merged, nil
+}
+
+// GetColumns returns the columns of the first shard of a merged table.
+func (r *shardReader) GetColumns(tableName string) ([]string, error) {
+	return r.Reader.GetColumns(r.source(tableName))
+}
+
+// FormatColumn formats the column of the first shard of a merged table.
+func (r *shardReader) FormatColumn(tableName string, columnName string) string {
+	return r.Reader.FormatColumn(r.source(tableName), columnName)
+}
+
+// ReadTable reads the rows of the shards of a merged table one after the other.
+func (r *shardReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	shards, ok := r.shards[tableName]
+	if !ok {
+		return r.Reader.ReadTable(tableName, rowChan, opts)
+	}
+	defer close(rowChan)
+
+	for _, shard := range shards {
+		if err := r.readShard(shard, rowChan, opts); err != nil {
+			return fmt.Errorf("shard %s: %w", shard, err)
+		}
+	}
+
+	return nil
+}
+
+// readShard forwards the rows of a shard.
+func (r *shardReader) readShard(shard string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	shardChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.Reader.ReadTable(shard, shardChan, opts)
+	}()
+
+	for row := range shardChan {
+		rowChan <- row
+	}
+
+	return <-errChan
+}
+
+// GetStructure returns the structure with the merged tables created like their first shard.
+func (r *shardReader) GetStructure() (string, error) {
+	structure, err := r.Reader.GetStructure()
+	if err != nil {
+		return "", err
+	}
+
+	return r.mergeStructure(structure), nil
+}
+
+// GetStructureSections returns the sections of the structure with the merged tables created like their first shard.
+func (r *shardReader) GetStructureSections() (string, string, error) {
+	preData, postData, err := reader.GetStructureSections(r.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return r.mergeStructure(preData), r.mergeStructure(postData), nil
+}
+
+// source returns the first shard of a merged table, the table itself otherwise.
+func (r *shardReader) source(tableName string) string {
+	if shards, ok := r.shards[tableName]; ok {
+		return shards[0]
+	}
+
+	return tableName
+}
+
+// mergeStructure renames the first shard of the merged tables in the statements of the structure and leaves out
+// the statements of the other shards.
+func (r *shardReader) mergeStructure(structure string) string {
+	statements := reader.SplitStatements(structure)
+	kept := make([]string, 0, len(statements))
+	for _, statement := range statements {
+		skip := false
+		for _, word := range identifier.FindAllString(statement, -1) {
+			if name, ok := r.merged[word]; ok && r.shards[name][0] != word {
+				skip = true
+				break
+			}
+		}
+		if skip {
+			continue
+		}
+
+		kept = append(kept, identifier.ReplaceAllStringFunc(statement, func(word string) string {
+			if name, ok := r.merged[word]; ok {
+				return name
+			}
+			return word
+		}))
+	}
+
+	return strings.Join(kept, ";")
+}
@@ -0,0 +1,100 @@
+package shard
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{
+		{Name: "events", Shards: "events_%", MergeShards: true},
+		{Name: "logs", Shards: "logs_%"},
+	}
+	r, err := NewReader(&mockReader{}, tables)
+	require.NoError(t, err)
+
+	names, err := r.GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "events", "logs_0", "logs_1"}, names)
+
+	columns, err := r.GetColumns("events")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"events_0.id"}, columns)
+	assert.Equal(t, "events_0.id", r.FormatColumn("events", "id"))
+
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable("events", rowChan, reader.ReadTableOpt{})
+	}()
+	var read []interface{}
+	for row := range rowChan {
+		read = append(read, row.Values()[0])
+	}
+	require.NoError(t, <-errChan)
+	assert.Equal(t, []interface{}{"events_0", "events_1", "events_2"}, read)
+
+	structure, err := r.GetStructure()
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE users (id int);\n"+
+		"-- events; first shard\nCREATE TABLE `events` (id int);\n"+
+		"CREATE INDEX events_0_id ON events (id);\n"+
+		"CREATE TABLE logs_0 (id int);\nCREATE TABLE logs_1 (id int);\n", structure)
+
+	r, err = NewReader(&mockReader{failing: "events_1"}, tables)
+	require.NoError(t, err)
+	rowChan = make(chan database.Row)
+	go func() {
+		errChan <- r.ReadTable("events", rowChan, reader.ReadTableOpt{})
+	}()
+	for range rowChan {
+	}
+	assert.EqualError(t, <-errChan, "shard events_1: connection lost")
+}
+
+func TestNewReaderWithoutMergedShards(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{}
+	r, err := NewReader(source, config.Tables{{Name: "logs", Shards: "logs_%"}})
+	require.NoError(t, err)
+	assert.Same(t, source, r)
+}
+
+type mockReader struct {
+	failing string
+}
+
+func (m *mockReader) GetStructure() (string, error) {
+	return "CREATE TABLE users (id int);\n" +
+		"-- events_0; first shard\nCREATE TABLE `events_0` (id int);\n" +
+		"CREATE INDEX events_0_id ON events_0 (id);\n" +
+		"-- events_1; other shard\nCREATE TABLE `events_1` (id int);\n" +
+		"CREATE TABLE events_2 (id int);\n" +
+		"CREATE TABLE logs_0 (id int);\nCREATE TABLE logs_1 (id int);\n", nil
+}
+func (m *mockReader) GetTables() ([]string, error) {
+	return []string{"users", "events_0", "events_1", "logs_0", "events_2", "logs_1"}, nil
+}
+func (m *mockReader) GetColumns(table string) ([]string, error) { return []string{table + ".id"}, nil }
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return tableName + "." + columnName
+}
+func (m *mockReader) ReadTable(table string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	if table == m.failing {
+		return errors.New("connection lost")
+	}
+	rowChan <- database.NewRow(database.NewColumns([]string{"table"}), []interface{}{table})
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
@@ -0,0 +1,102 @@
+package spool
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+// sizeUnits are the suffixes accepted by ParseSize.
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// Budget is the amount of memory rows buffered by all tables may use.
+type Budget struct {
+	limit int64
+	used  int64
+}
+
+// NewBudget creates a new memory budget of limit bytes.
+func NewBudget(limit int64) *Budget {
+	return &Budget{limit: limit}
+}
+
+// Used returns the amount of memory currently reserved.
+func (b *Budget) Used() int64 {
+	return atomic.LoadInt64(&b.used)
+}
+
+// reserve takes n bytes from the budget, it returns false when they are not available.
+func (b *Budget) reserve(n int64) bool {
+	for {
+		used := atomic.LoadInt64(&b.used)
+		if used+n > b.limit {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&b.used, used, used+n) {
+			return true
+		}
+	}
+}
+
+// force takes n bytes from the budget even if they are not available.
+func (b *Budget) force(n int64) {
+	atomic.AddInt64(&b.used, n)
+}
+
+// release gives n bytes back to the budget.
+func (b *Budget) release(n int64) {
+	atomic.AddInt64(&b.used, -n)
+}
+
+// ParseSize parses a human readable size such as 512MB or 2GB into bytes.
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	return n * multiplier, nil
+}
+
+// rowSize estimates the memory used by a row.
+func rowSize(row database.Row) int64 {
+	// slice header plus one interface value per column
+	size := int64(24 + 16*row.Len())
+	for _, v := range row.Values() {
+		switch value := v.(type) {
+		case string:
+			size += int64(len(value))
+		case []byte:
+			size += int64(len(value))
+		case time.Time:
+			size += 24
+		case nil:
+		default:
+			size += 8
+		}
+	}
+
+	return size
+}
@@ -0,0 +1,251 @@
+package spool
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+)
+
+type (
+	// segment is a run of consecutive rows, kept either in memory or in a spill file.
+	segment struct {
+		// rows and sizes are the in memory rows and their reserved memory.
+		rows  []database.Row
+		sizes []int64
+
+		// file is the spill file, nil for in memory segments.
+		file    *os.File
+		buf     *bufio.Writer
+		gz      *gzip.Writer
+		enc     *gob.Encoder
+		columns *database.Columns
+		sealed  bool
+	}
+
+	// buffer is a FIFO queue of rows bounded by a memory budget, rows exceeding it are spilled to disk.
+	buffer struct {
+		mu       sync.Mutex
+		cond     *sync.Cond
+		segments []*segment
+		closed   bool
+
+		table  string
+		budget *Budget
+		dir    string
+		// spillFailed is set when spilling failed and rows are kept in memory regardless of the budget.
+		spillFailed bool
+	}
+)
+
+func init() {
+	gob.Register(time.Time{})
+}
+
+func newBuffer(table string, budget *Budget, dir string) *buffer {
+	b := &buffer{table: table, budget: budget, dir: dir}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// push appends a row to the buffer.
+func (b *buffer) push(row database.Row) {
+	size := rowSize(row)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer b.cond.Signal()
+
+	// large values are read when the row is written and can not be spilled, their rows are small anyway
+	inMemory := b.spillFailed || row.HasLOB()
+
+	tail := b.tail()
+	if inMemory || b.budget.reserve(size) {
+		if tail == nil || tail.file != nil {
+			if tail != nil {
+				b.seal(tail)
+			}
+			tail = new(segment)
+			b.segments = append(b.segments, tail)
+		}
+		if inMemory {
+			// keep track of the memory even if it exceeds the budget
+			b.budget.force(size)
+		}
+		tail.rows = append(tail.rows, row)
+		tail.sizes = append(tail.sizes, size)
+		return
+	}
+
+	if tail == nil || tail.file == nil || tail.sealed {
+		spill, err := b.newSpillSegment(row.ColumnIndex())
+		if err != nil {
+			b.failSpill(err, row, size)
+			return
+		}
+		tail = spill
+		b.segments = append(b.segments, tail)
+	}
+
+	if err := tail.enc.Encode(row.Values()); err != nil {
+		b.failSpill(err, row, size)
+	}
+}
+
+// close marks the end of the rows.
+func (b *buffer) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	b.cond.Broadcast()
+}
+
+// drainTo publishes the buffered rows in order until the buffer is closed and empty.
+func (b *buffer) drainTo(out chan<- database.Row) {
+	for {
+		b.mu.Lock()
+		for len(b.segments) == 0 && !b.closed {
+			b.cond.Wait()
+		}
+		if len(b.segments) == 0 {
+			b.mu.Unlock()
+			return
+		}
+
+		head := b.segments[0]
+		if head.file != nil {
+			if !head.sealed {
+				b.seal(head)
+			}
+			b.segments = b.segments[1:]
+			b.mu.Unlock()
+
+			if err := b.replay(head, out); err != nil {
+				log.WithError(err).WithField("table", b.table).Error("failed to read spilled rows")
+			}
+			continue
+		}
+
+		if len(head.rows) == 0 {
+			if len(b.segments) > 1 || b.closed {
+				b.segments = b.segments[1:]
+			} else {
+				b.cond.Wait()
+			}
+			b.mu.Unlock()
+			continue
+		}
+
+		row, size := head.rows[0], head.sizes[0]
+		head.rows[0] = database.Row{}
+		head.rows, head.sizes = head.rows[1:], head.sizes[1:]
+		b.mu.Unlock()
+
+		b.budget.release(size)
+		out <- row
+	}
+}
+
+func (b *buffer) tail() *segment {
+	if len(b.segments) == 0 {
+		return nil
+	}
+
+	return b.segments[len(b.segments)-1]
+}
+
+func (b *buffer) newSpillSegment(columns *database.Columns) (*segment, error) {
+	f, err := os.CreateTemp(b.dir, "klepto-spool-*.gob.gz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill file: %w", err)
+	}
+
+	log.WithFields(log.Fields{"table": b.table, "file": f.Name()}).Debug("memory budget exceeded, spilling rows to disk")
+
+	buf := bufio.NewWriter(f)
+	gz, err := gzip.NewWriterLevel(buf, gzip.BestSpeed)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	return &segment{
+		file:    f,
+		buf:     buf,
+		gz:      gz,
+		enc:     gob.NewEncoder(gz),
+		columns: columns,
+	}, nil
+}
+
+// seal flushes a spill segment so it can be read back, no rows can be added to it afterwards.
+func (b *buffer) seal(s *segment) {
+	if s.file == nil || s.sealed {
+		return
+	}
+	s.sealed = true
+
+	err := s.gz.Close()
+	if err == nil {
+		err = s.buf.Flush()
+	}
+	if err != nil {
+		log.WithError(err).WithField("table", b.table).Error("failed to flush spill file")
+	}
+}
+
+// replay publishes the rows of a sealed spill segment and removes its file.
+func (b *buffer) replay(s *segment, out chan<- database.Row) error {
+	defer func() {
+		s.file.Close()
+		os.Remove(s.file.Name())
+	}()
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewReader(bufio.NewReader(s.file))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	dec := gob.NewDecoder(gz)
+	for {
+		var values []interface{}
+		if err := dec.Decode(&values); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		out <- database.NewRow(s.columns, values)
+	}
+}
+
+// failSpill keeps the row in memory when it could not be spilled.
+func (b *buffer) failSpill(err error, row database.Row, size int64) {
+	log.WithError(err).WithField("table", b.table).Error("failed to spill rows to disk, keeping them in memory")
+
+	b.spillFailed = true
+	b.budget.force(size)
+
+	s := &segment{rows: []database.Row{row}, sizes: []int64{size}}
+	if tail := b.tail(); tail != nil {
+		b.seal(tail)
+	}
+	b.segments = append(b.segments, s)
+}
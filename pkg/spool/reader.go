@@ -0,0 +1,59 @@
+package spool
+
+import (
+	"os"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	spoolReader struct {
+		reader.Reader
+		budget *Budget
+		dir    string
+	}
+)
+
+// NewReader returns a reader that buffers the rows of the source reader, so reading is not
+// held back by slower dumpers. Buffered rows of all tables share the memory budget,
+// rows exceeding it are spilled to compressed temporary files in dir.
+func NewReader(source reader.Reader, budget *Budget, dir string) reader.Reader {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	return &spoolReader{Reader: source, budget: budget, dir: dir}
+}
+
+// ReadTable decorates reader.ReadTable method for buffering rows published from the reader.Reader
+func (r *spoolReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	rawChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.Reader.ReadTable(tableName, rawChan, opts)
+	}()
+
+	Pipe(tableName, r.budget, r.dir, rawChan, rowChan)
+
+	return <-errChan
+}
+
+// Pipe buffers the rows of the table received on in until they are read from out, so that the sender is never held
+// back. It returns once in is closed, out is closed once the buffered rows are read.
+func Pipe(tableName string, budget *Budget, dir string, in <-chan database.Row, out chan<- database.Row) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	buf := newBuffer(tableName, budget, dir)
+	go func() {
+		defer close(out)
+		buf.drainTo(out)
+	}()
+
+	for row := range in {
+		buf.push(row)
+	}
+	buf.close()
+}
@@ -0,0 +1,85 @@
+package spool
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTableSpillsToDisk(t *testing.T) {
+	dir := t.TempDir()
+	budget := NewBudget(1024)
+	rdr := NewReader(&mockReader{rows: 500}, budget, dir)
+
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- rdr.ReadTable("test", rowChan, reader.ReadTableOpt{})
+	}()
+
+	// let the reader finish before consuming, so most rows have to be spilled
+	require.NoError(t, <-errChan)
+
+	var read int64
+	for row := range rowChan {
+		assert.Equal(t, read, row.Get("id"))
+		assert.Equal(t, fmt.Sprintf("row %d", read), row.Get("name"))
+		read++
+	}
+
+	assert.Equal(t, int64(500), read)
+	assert.Equal(t, int64(0), budget.Used())
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files, "spill files should be removed once read")
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+	}{
+		{value: "1024", expected: 1024},
+		{value: "10B", expected: 10},
+		{value: "64kb", expected: 64 << 10},
+		{value: "512MB", expected: 512 << 20},
+		{value: "2 GB", expected: 2 << 30},
+	}
+
+	for _, test := range tests {
+		size, err := ParseSize(test.value)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, size)
+	}
+
+	_, err := ParseSize("lots")
+	assert.Error(t, err)
+}
+
+type mockReader struct {
+	rows int
+}
+
+func (m *mockReader) GetTables() ([]string, error)        { return []string{"test"}, nil }
+func (m *mockReader) GetStructure() (string, error)       { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) { return []string{"id", "name"}, nil }
+func (m *mockReader) Close() error                        { return nil }
+func (m *mockReader) FormatColumn(tbl string, col string) string {
+	return fmt.Sprintf("%s.%s", tbl, col)
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	columns := database.NewColumns([]string{"id", "name"})
+	for i := 0; i < m.rows; i++ {
+		rowChan <- database.NewRow(columns, []interface{}{int64(i), fmt.Sprintf("row %d", i)})
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the amount of hash bits selecting a register, the estimates having a standard error of about 1.6%.
+const hllPrecision = 12
+
+// hyperLogLog estimates the amount of distinct values in a fixed amount of memory.
+type hyperLogLog struct {
+	registers [1 << hllPrecision]uint8
+}
+
+// add adds a value, given as its text.
+func (h *hyperLogLog) add(value string) {
+	hash := fnv.New64a()
+	hash.Write([]byte(value))
+	x := mix(hash.Sum64())
+
+	i := x >> (64 - hllPrecision)
+	// the remaining bits, with a bit set to bound the rank
+	w := x<<hllPrecision | 1<<(hllPrecision-1)
+	if rank := uint8(bits.LeadingZeros64(w) + 1); rank > h.registers[i] {
+		h.registers[i] = rank
+	}
+}
+
+// merge adds the values of another estimate.
+func (h *hyperLogLog) merge(other *hyperLogLog) {
+	for i, rank := range other.registers {
+		if rank > h.registers[i] {
+			h.registers[i] = rank
+		}
+	}
+}
+
+// estimate returns the estimated amount of distinct values added.
+func (h *hyperLogLog) estimate() uint64 {
+	m := float64(len(h.registers))
+
+	var (
+		sum   float64
+		zeros int
+	)
+	for _, rank := range h.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// small cardinalities are counted from the empty registers
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(math.Round(e))
+}
+
+// mix spreads the bits of the FNV hash, whose high bits depend little on the last bytes of short values.
+func mix(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	return x
+}
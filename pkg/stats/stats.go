@@ -0,0 +1,185 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+const (
+	// DefaultSampleSize is the amount of rows sampled when none is given.
+	DefaultSampleSize = 10000
+	// topValues is the amount of most frequent values kept per column.
+	topValues = 10
+)
+
+type (
+	// Table holds the statistics of the sampled columns of a table by column name.
+	Table map[string]*Column
+
+	// Column describes the distribution of the sampled values of a column.
+	Column struct {
+		// Rows is the amount of sampled rows.
+		Rows uint64
+		// Nulls is the amount of sampled NULL values.
+		Nulls uint64
+		// Distinct is the amount of distinct non NULL values.
+		Distinct uint64
+		// Numeric is set when all the non NULL values are numbers, Min and Max are then set.
+		Numeric  bool
+		Min, Max float64
+		// Temporal is set when all the non NULL values are times, MinTime and MaxTime are then set.
+		Temporal         bool
+		MinTime, MaxTime time.Time
+		// Top are the most frequent values, most frequent first.
+		Top []Value
+
+		counts map[string]*Value
+	}
+
+	// Value is a sampled value and the amount of times it was seen.
+	Value struct {
+		Value interface{}
+		Count uint64
+	}
+)
+
+// Sample reads up to limit rows of a table and computes the statistics of its columns.
+func Sample(source reader.Reader, tableName string, limit uint64) (Table, error) {
+	if limit == 0 {
+		limit = DefaultSampleSize
+	}
+
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- source.ReadTable(tableName, rowChan, reader.ReadTableOpt{Limit: limit})
+	}()
+
+	table := make(Table)
+	for row := range rowChan {
+		for i, name := range row.Columns() {
+			column, ok := table[name]
+			if !ok {
+				column = &Column{Numeric: true, Temporal: true, counts: make(map[string]*Value)}
+				table[name] = column
+			}
+			column.add(row.Values()[i])
+		}
+	}
+	if err := <-errChan; err != nil {
+		return nil, fmt.Errorf("could not sample table %s: %w", tableName, err)
+	}
+
+	for _, column := range table {
+		column.finish()
+	}
+
+	return table, nil
+}
+
+// NullRatio returns the fraction of sampled values that are NULL.
+func (c *Column) NullRatio() float64 {
+	if c.Rows == 0 {
+		return 0
+	}
+
+	return float64(c.Nulls) / float64(c.Rows)
+}
+
+// Unique reports whether no sampled value was seen twice.
+func (c *Column) Unique() bool {
+	return c.Distinct == c.Rows-c.Nulls
+}
+
+func (c *Column) add(value interface{}) {
+	if p, ok := value.(*interface{}); ok && p != nil {
+		value = *p
+	}
+
+	c.Rows++
+	if value == nil {
+		c.Nulls++
+		return
+	}
+
+	key := fmt.Sprint(value)
+	if b, ok := value.([]byte); ok {
+		key = string(b)
+	}
+	if v, ok := c.counts[key]; ok {
+		v.Count++
+	} else {
+		c.counts[key] = &Value{Value: value, Count: 1}
+	}
+
+	first := c.Rows-c.Nulls == 1
+	if n, ok := toFloat(value); ok && c.Numeric {
+		if first || n < c.Min {
+			c.Min = n
+		}
+		if first || n > c.Max {
+			c.Max = n
+		}
+	} else {
+		c.Numeric = false
+	}
+
+	if t, ok := value.(time.Time); ok && c.Temporal {
+		if first || t.Before(c.MinTime) {
+			c.MinTime = t
+		}
+		if first || t.After(c.MaxTime) {
+			c.MaxTime = t
+		}
+	} else {
+		c.Temporal = false
+	}
+}
+
+func (c *Column) finish() {
+	if c.Rows == c.Nulls {
+		c.Numeric, c.Temporal = false, false
+	}
+
+	c.Distinct = uint64(len(c.counts))
+	c.Top = make([]Value, 0, len(c.counts))
+	for _, v := range c.counts {
+		c.Top = append(c.Top, *v)
+	}
+	sort.Slice(c.Top, func(i, j int) bool {
+		if c.Top[i].Count != c.Top[j].Count {
+			return c.Top[i].Count > c.Top[j].Count
+		}
+		return fmt.Sprint(c.Top[i].Value) < fmt.Sprint(c.Top[j].Value)
+	})
+	if len(c.Top) > topValues {
+		c.Top = c.Top[:topValues]
+	}
+	c.counts = nil
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case []byte:
+		return parseFloat(string(v))
+	case string:
+		return parseFloat(v)
+	}
+
+	return 0, false
+}
+
+func parseFloat(s string) (float64, bool) {
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil && !math.IsNaN(n) && !math.IsInf(n, 0)
+}
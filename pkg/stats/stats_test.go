@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestSample(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := &mockReader{rows: [][]interface{}{
+		{int64(1), []byte("de"), day},
+		{int64(2), []byte("de"), nil},
+		{int64(3), []byte("fr"), day.AddDate(0, 1, 0)},
+		{int64(4), nil, nil},
+	}}
+
+	table, err := Sample(source, "users", 3)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), source.limit)
+
+	id := table["id"]
+	assert.Equal(t, uint64(3), id.Rows)
+	assert.True(t, id.Unique())
+	assert.True(t, id.Numeric)
+	assert.Equal(t, 1.0, id.Min)
+	assert.Equal(t, 3.0, id.Max)
+
+	country := table["country"]
+	assert.False(t, country.Unique())
+	assert.False(t, country.Numeric)
+	assert.Equal(t, uint64(2), country.Distinct)
+	assert.Equal(t, Value{Value: []byte("de"), Count: 2}, country.Top[0])
+
+	created := table["created_at"]
+	assert.InDelta(t, 1.0/3, created.NullRatio(), 0.001)
+	assert.True(t, created.Temporal)
+	assert.Equal(t, day, created.MinTime)
+	assert.Equal(t, day.AddDate(0, 1, 0), created.MaxTime)
+}
+
+type mockReader struct {
+	rows  [][]interface{}
+	limit uint64
+}
+
+func (m *mockReader) GetTables() ([]string, error)        { return []string{"users"}, nil }
+func (m *mockReader) GetStructure() (string, error)       { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) { return nil, nil }
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return ""
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	m.limit = opts.Limit
+	columns := database.NewColumns([]string{"id", "country", "created_at"})
+	for i, values := range m.rows {
+		if uint64(i) == opts.Limit {
+			break
+		}
+		rowChan <- database.NewRow(columns, values)
+	}
+
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
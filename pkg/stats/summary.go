@@ -0,0 +1,258 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// maxBoundLength is the maximum length in bytes of the text minimum and maximum of a column.
+const maxBoundLength = 64
+
+type (
+	// Summary is a reader summarising the columns of the rows read through it, e.g. the dumped rows.
+	Summary struct {
+		reader.Reader
+		sensitive func(table string, column string) bool
+
+		mu     sync.Mutex
+		tables map[string]*tableSummary
+	}
+
+	// Report is the summary of the columns of the read tables, by table and column name.
+	Report map[string]map[string]ColumnReport
+
+	// ColumnReport is the summary of the values of a column.
+	ColumnReport struct {
+		// Rows is the amount of rows read.
+		Rows uint64 `json:"rows"`
+		// Nulls is the amount of NULL values.
+		Nulls uint64 `json:"nulls"`
+		// Distinct is an estimate of the amount of distinct non NULL values, within about 2%.
+		Distinct uint64 `json:"distinct"`
+		// Min and Max are the smallest and largest values, compared as numbers, times or text cut to 64 bytes, and
+		// are not set for the sensitive columns.
+		Min string `json:"min,omitempty"`
+		Max string `json:"max,omitempty"`
+		// Sensitive tells that the bounds of the column are not reported.
+		Sensitive bool `json:"sensitive,omitempty"`
+	}
+
+	tableSummary struct {
+		columns map[string]*columnSummary
+		// order are the column names in the order they were read.
+		order []string
+	}
+
+	columnSummary struct {
+		rows, nulls uint64
+		distinct    hyperLogLog
+		sensitive   bool
+		bounds      bounds
+	}
+
+	// bounds are the smallest and largest non NULL values of a column, as numbers, times and text.
+	bounds struct {
+		set bool
+		// numeric and temporal are set while all the values are numbers or times.
+		numeric, temporal bool
+		minNum, maxNum    float64
+		minTime, maxTime  time.Time
+		minText, maxText  string
+	}
+)
+
+// NewSummary returns a reader summarising the columns of the rows read, the bounds of the columns for which
+// sensitive returns true are not summarised.
+func NewSummary(source reader.Reader, sensitive func(table string, column string) bool) *Summary {
+	return &Summary{Reader: source, sensitive: sensitive, tables: make(map[string]*tableSummary)}
+}
+
+// ReadTable reads the table, summarising its columns.
+func (s *Summary) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	sourceChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.Reader.ReadTable(tableName, sourceChan, opts)
+	}()
+
+	table := &tableSummary{columns: make(map[string]*columnSummary)}
+	for row := range sourceChan {
+		table.add(tableName, row, s.sensitive)
+		rowChan <- row
+	}
+
+	// a table read several times, e.g. by the integrity check, is summarised once
+	s.mu.Lock()
+	if summarised, ok := s.tables[tableName]; ok {
+		summarised.merge(table)
+	} else {
+		s.tables[tableName] = table
+	}
+	s.mu.Unlock()
+
+	return <-errChan
+}
+
+// Report returns the summary of the columns of the tables read so far.
+func (s *Summary) Report() Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := make(Report, len(s.tables))
+	for name, table := range s.tables {
+		columns := make(map[string]ColumnReport, len(table.columns))
+		for column, summary := range table.columns {
+			columns[column] = summary.report()
+		}
+		report[name] = columns
+	}
+
+	return report
+}
+
+func (t *tableSummary) add(tableName string, row database.Row, sensitive func(table string, column string) bool) {
+	for i, name := range row.Columns() {
+		column, ok := t.columns[name]
+		if !ok {
+			column = &columnSummary{sensitive: sensitive != nil && sensitive(tableName, name)}
+			t.columns[name] = column
+			t.order = append(t.order, name)
+		}
+		column.add(row.Values()[i])
+	}
+}
+
+func (t *tableSummary) merge(other *tableSummary) {
+	for _, name := range other.order {
+		column, ok := t.columns[name]
+		if !ok {
+			t.columns[name] = other.columns[name]
+			t.order = append(t.order, name)
+			continue
+		}
+		column.merge(other.columns[name])
+	}
+}
+
+func (c *columnSummary) add(value interface{}) {
+	if p, ok := value.(*interface{}); ok && p != nil {
+		value = *p
+	}
+
+	c.rows++
+	if value == nil {
+		c.nulls++
+		return
+	}
+
+	text := fmt.Sprint(value)
+	switch v := value.(type) {
+	case []byte:
+		text = string(v)
+	case time.Time:
+		text = v.Format(time.RFC3339Nano)
+	}
+	c.distinct.add(text)
+	if c.sensitive {
+		return
+	}
+
+	n, numeric := toFloat(value)
+	t, temporal := value.(time.Time)
+	text = cut(text)
+	c.bounds.extend(bounds{
+		set:     true,
+		numeric: numeric, minNum: n, maxNum: n,
+		temporal: temporal, minTime: t, maxTime: t,
+		minText: text, maxText: text,
+	})
+}
+
+func (c *columnSummary) merge(other *columnSummary) {
+	c.rows += other.rows
+	c.nulls += other.nulls
+	c.distinct.merge(&other.distinct)
+	c.bounds.extend(other.bounds)
+}
+
+func (c *columnSummary) report() ColumnReport {
+	report := ColumnReport{Rows: c.rows, Nulls: c.nulls, Sensitive: c.sensitive}
+	if c.rows > c.nulls {
+		report.Distinct = c.distinct.estimate()
+		// the estimate may exceed the amount of values
+		if values := c.rows - c.nulls; report.Distinct > values {
+			report.Distinct = values
+		}
+	}
+	report.Min, report.Max = c.bounds.format()
+
+	return report
+}
+
+// extend extends the bounds with the bounds of other values.
+func (b *bounds) extend(other bounds) {
+	if !other.set {
+		return
+	}
+	if !b.set {
+		*b = other
+		return
+	}
+
+	b.numeric = b.numeric && other.numeric
+	if b.numeric {
+		b.minNum, b.maxNum = math.Min(b.minNum, other.minNum), math.Max(b.maxNum, other.maxNum)
+	}
+	b.temporal = b.temporal && other.temporal
+	if b.temporal {
+		if other.minTime.Before(b.minTime) {
+			b.minTime = other.minTime
+		}
+		if other.maxTime.After(b.maxTime) {
+			b.maxTime = other.maxTime
+		}
+	}
+	if other.minText < b.minText {
+		b.minText = other.minText
+	}
+	if other.maxText > b.maxText {
+		b.maxText = other.maxText
+	}
+}
+
+// format returns the text of the bounds, empty when no value was seen.
+func (b *bounds) format() (string, string) {
+	switch {
+	case !b.set:
+		return "", ""
+	case b.numeric:
+		return strconv.FormatFloat(b.minNum, 'f', -1, 64), strconv.FormatFloat(b.maxNum, 'f', -1, 64)
+	case b.temporal:
+		return b.minTime.Format(time.RFC3339Nano), b.maxTime.Format(time.RFC3339Nano)
+	}
+
+	return b.minText, b.maxText
+}
+
+// cut cuts a text to maxBoundLength bytes, without splitting a character.
+func cut(text string) string {
+	if len(text) <= maxBoundLength {
+		return text
+	}
+
+	end := maxBoundLength
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	return text[:end]
+}
@@ -0,0 +1,89 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestSummary(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	source := &mockReader{rows: [][]interface{}{
+		{int64(10), []byte("de"), day},
+		{int64(2), []byte("de"), nil},
+		{int64(3), []byte("fr"), day.AddDate(0, 1, 0)},
+		{int64(4), nil, nil},
+	}}
+	summary := NewSummary(source, func(table string, column string) bool { return column == "country" })
+
+	read := func() {
+		rowChan := make(chan database.Row)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- summary.ReadTable("users", rowChan, reader.ReadTableOpt{Limit: 10})
+		}()
+		var rows int
+		for range rowChan {
+			rows++
+		}
+		require.NoError(t, <-errChan)
+		assert.Equal(t, 4, rows)
+	}
+	read()
+
+	assert.Equal(t, Report{"users": {
+		"id":         {Rows: 4, Distinct: 4, Min: "2", Max: "10"},
+		"country":    {Rows: 4, Nulls: 1, Distinct: 2, Sensitive: true},
+		"created_at": {Rows: 4, Nulls: 2, Distinct: 2, Min: "2020-01-01T00:00:00Z", Max: "2020-02-01T00:00:00Z"},
+	}}, summary.Report())
+
+	// the tables read again are summarised once
+	read()
+	report := summary.Report()["users"]
+	assert.Equal(t, ColumnReport{Rows: 8, Distinct: 4, Min: "2", Max: "10"}, report["id"])
+	assert.Equal(t, ColumnReport{Rows: 8, Nulls: 2, Distinct: 2, Sensitive: true}, report["country"])
+}
+
+func TestBounds(t *testing.T) {
+	t.Parallel()
+
+	column := &columnSummary{}
+	for _, value := range []interface{}{int64(3), "a", []byte("b" + strings.Repeat("é", 40))} {
+		column.add(value)
+	}
+	report := column.report()
+	assert.Equal(t, "3", report.Min, "numbers and text are compared as text")
+	assert.Equal(t, "b"+strings.Repeat("é", 31), report.Max, "long text is cut between characters")
+	assert.Equal(t, ColumnReport{}, (&columnSummary{}).report())
+}
+
+func TestHyperLogLog(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{1, 100, 10000, 200000} {
+		var h hyperLogLog
+		for i := 0; i < n; i++ {
+			h.add(fmt.Sprintf("value-%d", i))
+			// duplicates do not count
+			h.add(fmt.Sprintf("value-%d", i/2))
+		}
+		assert.InEpsilon(t, n, h.estimate(), 0.05, "%d distinct values", n)
+	}
+
+	var a, b hyperLogLog
+	for i := 0; i < 1000; i++ {
+		a.add(fmt.Sprint(i))
+		b.add(fmt.Sprint(i + 500))
+	}
+	a.merge(&b)
+	assert.InEpsilon(t, 1500, a.estimate(), 0.05)
+}
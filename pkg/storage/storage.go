@@ -0,0 +1,308 @@
+// Package storage strips or renames the tablespaces, engines, row formats and storage parameters of the dumped
+// structure, so that it restores on a target configured differently from the source.
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// Modes of handling the storage clauses of the structure.
+const (
+	// Keep keeps them, their tablespaces and engines being renamed by the maps.
+	Keep Mode = "keep"
+	// Strip leaves them out of the structure, so that the target defaults apply.
+	Strip Mode = "strip"
+)
+
+// value matches the value of a clause, quoted or not.
+const value = "(`[^`]*`|'[^']*'|\"[^\"]*\"|[\\w$.]+)"
+
+var (
+	// tablespace matches the TABLESPACE clauses of the tables, indexes and constraints, e.g. USING INDEX TABLESPACE fast
+	// or the TABLESPACE `ts` STORAGE DISK of MySQL.
+	tablespace = regexp.MustCompile(`(?i)(?:\bUSING\s+INDEX\s+)?\bTABLESPACE\s*=?\s*` + value +
+		`(?:\s+STORAGE\s+(?:DISK|MEMORY|DEFAULT)\b)?`)
+	// defaultTablespace matches the tablespace pg_dump sets before creating the objects stored in it.
+	defaultTablespace = regexp.MustCompile(`(?i)\bdefault_tablespace\s*(?:=|\bTO\b)\s*` + value)
+	// engine matches the ENGINE option of the MySQL tables and partitions.
+	engine = regexp.MustCompile(`(?i)\b(?:STORAGE\s+)?ENGINE\s*=\s*` + value)
+	// parameters matches the MySQL storage options and the postgres storage parameters of the tables and indexes.
+	parameters = regexp.MustCompile(`(?i)\b(?:ROW_FORMAT|KEY_BLOCK_SIZE|COMPRESSION|(?:DATA|INDEX)\s+DIRECTORY)\s*=\s*` +
+		value + `|\bWITH\s*\([^()]*\)`)
+)
+
+type (
+	// Mode is the way the storage clauses of the structure are handled.
+	Mode string
+
+	// Options are the way the storage clauses of the structure are handled.
+	Options struct {
+		// Mode keeps or strips the TABLESPACE, ENGINE, ROW_FORMAT and storage parameter clauses.
+		Mode Mode
+		// Tablespaces renames the tablespaces of the kept clauses, from the source tablespace to the target one.
+		Tablespaces map[string]string
+		// Engines renames the engines of the kept clauses, from the source engine to the target one.
+		Engines map[string]string
+	}
+
+	storageReader struct {
+		reader.Reader
+		opts Options
+	}
+)
+
+// ParseMode parses a storage mode.
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(s)); mode {
+	case Keep, Strip:
+		return mode, nil
+	}
+
+	return "", fmt.Errorf("unknown storage mode %q, expected keep or strip", s)
+}
+
+// ParseMap parses source=target pairs of tablespaces or engines.
+func ParseMap(pairs []string) (map[string]string, error) {
+	names := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid mapping %q, expected source=target", pair)
+		}
+		names[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return names, nil
+}
+
+// NewReader returns a reader whose structure has its storage clauses handled according to the options.
+func NewReader(source reader.Reader, opts Options) reader.Reader {
+	return &storageReader{Reader: source, opts: opts}
+}
+
+// GetStructure returns the rewritten structure.
+func (r *storageReader) GetStructure() (string, error) {
+	structure, err := r.Reader.GetStructure()
+	if err != nil {
+		return "", err
+	}
+
+	return Rewrite(structure, r.opts), nil
+}
+
+// GetStructureSections returns the rewritten sections of the structure.
+func (r *storageReader) GetStructureSections() (string, string, error) {
+	preData, postData, err := reader.GetStructureSections(r.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return Rewrite(preData, r.opts), Rewrite(postData, r.opts), nil
+}
+
+// Rewrite returns the structure with its storage clauses handled according to the options. Stripped, the statements
+// setting the default tablespace or moving an object to another tablespace are left out with the comments preceding
+// them.
+func Rewrite(structure string, opts Options) string {
+	if opts.Mode != Strip && len(opts.Tablespaces) == 0 && len(opts.Engines) == 0 {
+		return structure
+	}
+
+	statements := reader.SplitStatements(structure)
+	kept := make([]string, 0, len(statements))
+	for _, statement := range statements {
+		words := reader.Keywords(statement)
+		if opts.Mode == Strip && setsTablespace(words) {
+			continue
+		}
+		kept = append(kept, rewriteStatement(statement, words, opts))
+	}
+
+	return strings.Join(kept, ";")
+}
+
+// setsTablespace reports whether the statement sets the default tablespace or moves an object to another tablespace,
+// e.g. ALTER TABLE users SET TABLESPACE fast.
+func setsTablespace(words []string) bool {
+	if len(words) > 1 && words[0] == "SET" && words[1] == "DEFAULT_TABLESPACE" {
+		return true
+	}
+	if len(words) == 0 || words[0] != "ALTER" {
+		return false
+	}
+	for i := 1; i < len(words)-1; i++ {
+		if words[i] == "SET" && words[i+1] == "TABLESPACE" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rewriteStatement strips or renames the storage clauses of the statement. The clauses are matched on the masked
+// statement so that the quoted values and the comments are not taken for clauses.
+func rewriteStatement(statement string, words []string, opts Options) string {
+	type edit struct {
+		start, end  int
+		replacement string
+	}
+
+	var (
+		masked = mask(statement)
+		edits  []edit
+	)
+	clauses := func(re *regexp.Regexp, names map[string]string, fold, strippable bool) {
+		for _, loc := range re.FindAllStringSubmatchIndex(masked, -1) {
+			if !isClause(masked, loc[0]) {
+				continue
+			}
+			switch {
+			case opts.Mode == Strip && strippable:
+				start := loc[0]
+				for start > 0 && isSpace(statement[start-1]) {
+					start--
+				}
+				edits = append(edits, edit{start: start, end: loc[1]})
+			case len(loc) > 3 && loc[2] >= 0 && names != nil:
+				if renamed, ok := rename(statement[loc[2]:loc[3]], names, fold); ok {
+					edits = append(edits, edit{start: loc[2], end: loc[3], replacement: renamed})
+				}
+			}
+		}
+	}
+	clauses(tablespace, opts.Tablespaces, false, true)
+	clauses(defaultTablespace, opts.Tablespaces, false, false)
+	clauses(engine, opts.Engines, true, true)
+	clauses(parameters, nil, false, createsStorage(words))
+
+	if len(edits) == 0 {
+		return statement
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var out strings.Builder
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			continue
+		}
+		out.WriteString(statement[last:e.start])
+		out.WriteString(e.replacement)
+		last = e.end
+	}
+	out.WriteString(statement[last:])
+
+	return out.String()
+}
+
+// createsStorage reports whether the statement creates or alters a table or an index, the only statements whose
+// WITH (...) clause holds storage parameters.
+func createsStorage(words []string) bool {
+	if len(words) == 0 || words[0] != "CREATE" && words[0] != "ALTER" {
+		return false
+	}
+	for i := 1; i < len(words) && i < 4; i++ {
+		if words[i] == "TABLE" || words[i] == "INDEX" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isClause reports whether the match at i is a clause rather than a column named like one, e.g. a postgres column
+// named tablespace, which follows an opening parenthesis or a comma.
+func isClause(masked string, i int) bool {
+	for i > 0 && isSpace(masked[i-1]) {
+		i--
+	}
+
+	return i == 0 || masked[i-1] != '(' && masked[i-1] != ','
+}
+
+// rename renames a tablespace or an engine found in the map, keeping its quotes. The name is matched case insensitively
+// with fold, MySQL ignoring the case of the engines.
+func rename(name string, names map[string]string, fold bool) (string, bool) {
+	quote := ""
+	if len(name) >= 2 && (name[0] == '\'' || name[0] == '"' || name[0] == '`') && name[len(name)-1] == name[0] {
+		name, quote = name[1:len(name)-1], name[:1]
+	}
+	if renamed, ok := names[name]; ok {
+		return quote + renamed + quote, true
+	}
+	for source, renamed := range names {
+		if fold && strings.EqualFold(source, name) {
+			return quote + renamed + quote, true
+		}
+	}
+
+	return "", false
+}
+
+// mask returns the statement with its comments and the content of its quoted strings and identifiers blanked, keeping
+// the offsets of the statement. The content of the MySQL versioned comments, e.g. /*!50100 TABLESPACE `ts` */, is
+// kept as it is executed.
+func mask(statement string) string {
+	masked := []byte(statement)
+	blank := func(start, end int) {
+		for i := start; i < end; i++ {
+			if !isSpace(masked[i]) {
+				masked[i] = ' '
+			}
+		}
+	}
+	for i := 0; i < len(statement); i++ {
+		c := statement[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(statement) && statement[end] != c {
+				if statement[end] == '\\' && c != '`' {
+					end++
+				}
+				end++
+			}
+			if end >= len(statement) {
+				blank(i+1, len(statement))
+				return string(masked)
+			}
+			blank(i+1, end)
+			i = end
+		case c == '-' && strings.HasPrefix(statement[i:], "--"):
+			end := strings.IndexByte(statement[i:], '\n')
+			if end < 0 {
+				end = len(statement) - i
+			}
+			blank(i, i+end)
+			i += end
+		case c == '/' && strings.HasPrefix(statement[i:], "/*!"):
+			end := i + 3
+			for end < len(statement) && statement[end] >= '0' && statement[end] <= '9' {
+				end++
+			}
+			blank(i, end)
+			i = end - 1
+		case c == '*' && strings.HasPrefix(statement[i:], "*/"):
+			blank(i, i+2)
+			i++
+		case c == '/' && strings.HasPrefix(statement[i:], "/*"):
+			end := strings.Index(statement[i+2:], "*/")
+			if end < 0 {
+				blank(i, len(statement))
+				return string(masked)
+			}
+			blank(i, i+2+end+2)
+			i += end + 3
+		}
+	}
+
+	return string(masked)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+const pgStructure = "SET default_tablespace = fast;\n" +
+	"CREATE TABLE public.users (id int, tablespace text, note text DEFAULT 'TABLESPACE x') WITH (fillfactor='70');\n" +
+	"ALTER TABLE ONLY public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id) USING INDEX TABLESPACE fast;\n" +
+	"CREATE INDEX users_note ON public.users USING btree (note) WITH (fillfactor='90') TABLESPACE \"Archive\";\n" +
+	"ALTER TABLE public.users SET TABLESPACE archive;\n" +
+	"CREATE VIEW public.active WITH (security_barrier='true') AS SELECT id FROM public.users;\n"
+
+const mysqlStructure = "CREATE TABLE `users` (\n" +
+	"  `id` int NOT NULL,\n" +
+	"  `engine` varchar(10) DEFAULT 'ENGINE=x',\n" +
+	"  PRIMARY KEY (`id`) KEY_BLOCK_SIZE=8\n" +
+	") /*!50100 TABLESPACE `ts` */ ENGINE=MyISAM DEFAULT CHARSET=utf8mb4 ROW_FORMAT=COMPRESSED COMPRESSION='zlib'\n" +
+	"/*!50100 PARTITION BY RANGE (id) (PARTITION p0 VALUES LESS THAN (10) ENGINE = MyISAM) */;\n"
+
+type mockReader struct {
+	reader.Reader
+}
+
+func (m *mockReader) GetStructure() (string, error) {
+	return mysqlStructure, nil
+}
+
+func TestRewriteStrip(t *testing.T) {
+	t.Parallel()
+
+	opts := Options{Mode: Strip}
+	assert.Equal(t, "\n"+
+		"CREATE TABLE public.users (id int, tablespace text, note text DEFAULT 'TABLESPACE x');\n"+
+		"ALTER TABLE ONLY public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id);\n"+
+		"CREATE INDEX users_note ON public.users USING btree (note);\n"+
+		"CREATE VIEW public.active WITH (security_barrier='true') AS SELECT id FROM public.users;\n",
+		Rewrite(pgStructure, opts))
+	assert.Equal(t, "CREATE TABLE `users` (\n"+
+		"  `id` int NOT NULL,\n"+
+		"  `engine` varchar(10) DEFAULT 'ENGINE=x',\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		") /*!50100 */ DEFAULT CHARSET=utf8mb4\n"+
+		"/*!50100 PARTITION BY RANGE (id) (PARTITION p0 VALUES LESS THAN (10)) */;\n",
+		Rewrite(mysqlStructure, opts))
+}
+
+func TestRewriteKeep(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, pgStructure, Rewrite(pgStructure, Options{Mode: Keep}))
+	assert.Equal(t, mysqlStructure, Rewrite(mysqlStructure, Options{Mode: Keep}))
+
+	opts := Options{
+		Mode:        Keep,
+		Tablespaces: map[string]string{"fast": "pg_default", "Archive": "slow", "ts": "innodb_system"},
+		Engines:     map[string]string{"myisam": "InnoDB"},
+	}
+	assert.Equal(t, "SET default_tablespace = pg_default;\n"+
+		"CREATE TABLE public.users (id int, tablespace text, note text DEFAULT 'TABLESPACE x') WITH (fillfactor='70');\n"+
+		"ALTER TABLE ONLY public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id) USING INDEX TABLESPACE pg_default;\n"+
+		"CREATE INDEX users_note ON public.users USING btree (note) WITH (fillfactor='90') TABLESPACE \"slow\";\n"+
+		"ALTER TABLE public.users SET TABLESPACE archive;\n"+
+		"CREATE VIEW public.active WITH (security_barrier='true') AS SELECT id FROM public.users;\n",
+		Rewrite(pgStructure, opts))
+	assert.Equal(t, "CREATE TABLE `users` (\n"+
+		"  `id` int NOT NULL,\n"+
+		"  `engine` varchar(10) DEFAULT 'ENGINE=x',\n"+
+		"  PRIMARY KEY (`id`) KEY_BLOCK_SIZE=8\n"+
+		") /*!50100 TABLESPACE `innodb_system` */ ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ROW_FORMAT=COMPRESSED COMPRESSION='zlib'\n"+
+		"/*!50100 PARTITION BY RANGE (id) (PARTITION p0 VALUES LESS THAN (10) ENGINE = InnoDB) */;\n",
+		Rewrite(mysqlStructure, opts))
+}
+
+func TestNewReader(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(&mockReader{}, Options{Mode: Strip})
+	structure, err := r.GetStructure()
+	require.NoError(t, err)
+	assert.NotContains(t, structure, "ENGINE=MyISAM")
+
+	preData, postData, err := reader.GetStructureSections(r)
+	require.NoError(t, err)
+	assert.Equal(t, structure, preData)
+	assert.Empty(t, postData)
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	mode, err := ParseMode("STRIP")
+	require.NoError(t, err)
+	assert.Equal(t, Strip, mode)
+	_, err = ParseMode("remap")
+	assert.Error(t, err)
+
+	names, err := ParseMap([]string{"fast=pg_default"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"fast": "pg_default"}, names)
+	_, err = ParseMap([]string{"=pg_default"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,276 @@
+package subject
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/integrity"
+	"github.com/hellofresh/klepto/pkg/ordering"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// batchSize is the amount of parent keys looked up per query.
+const batchSize = 500
+
+type (
+	// Subject is the data subject whose rows are extracted: the rows of Table whose Column is Key.
+	Subject struct {
+		Table  string
+		Column string
+		Key    string
+	}
+
+	subjectReader struct {
+		reader.Reader
+		subject Subject
+		tables  []string
+		// parents are the foreign keys followed to read each table, referencing a table listed before it.
+		parents map[string][]reader.ForeignKey
+		done    map[string]chan struct{}
+
+		// keys are the values of the columns referenced by the followed foreign keys, by table and integrity.ColumnsKey of the columns.
+		keys map[string]map[string]set
+		mu   sync.Mutex
+	}
+
+	set map[string]bool
+)
+
+// Parse parses a subject given as table.column=key, the table may be qualified with its schema.
+func Parse(s string) (Subject, error) {
+	eq := strings.Index(s, "=")
+	if eq < 0 {
+		return Subject{}, fmt.Errorf("invalid subject %q, expected table.column=key", s)
+	}
+	ref, key := s[:eq], s[eq+1:]
+
+	dot := strings.LastIndex(ref, ".")
+	if dot <= 0 || dot == len(ref)-1 || key == "" {
+		return Subject{}, fmt.Errorf("invalid subject %q, expected table.column=key", s)
+	}
+
+	return Subject{Table: ref[:dot], Column: ref[dot+1:], Key: key}, nil
+}
+
+// String returns the subject as table.column=key.
+func (s Subject) String() string {
+	return fmt.Sprintf("%s.%s=%s", s.Table, s.Column, s.Key)
+}
+
+// NewReader returns a reader restricted to the rows of the data subject and to the rows referencing them,
+// following the foreign keys known by the source (see reader.ForeignKeyer) and the relationships of the tables
+// configuration down to the last referencing table. The tables are listed parents first and reading a table
+// waits for the tables it references to be read, so their keys are known.
+// The filters and limits of the read options are not applied, only the subject rows are read.
+func NewReader(source reader.Reader, cfgTables config.Tables, subject Subject) (reader.Reader, error) {
+	tables, err := source.GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("subject: could not get tables: %w", err)
+	}
+
+	found := false
+	for _, table := range tables {
+		found = found || table == subject.Table
+	}
+	if !found {
+		return nil, fmt.Errorf("subject: table %s does not exist", subject.Table)
+	}
+
+	foreignKeys, err := integrity.ForeignKeys(source, cfgTables, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	// the tables referencing a reachable table are reachable, the tables whose data is ignored are not followed
+	reachable := map[string]bool{subject.Table: true}
+	for progress := true; progress; {
+		progress = false
+		for _, fk := range foreignKeys {
+			if !reachable[fk.ReferencedTable] || reachable[fk.Table] {
+				continue
+			}
+			if cfg := cfgTables.FindByName(fk.Table); cfg != nil && cfg.IgnoreData {
+				continue
+			}
+			reachable[fk.Table] = true
+			progress = true
+		}
+	}
+
+	var (
+		selected []string
+		followed []reader.ForeignKey
+	)
+	for _, table := range tables {
+		if reachable[table] {
+			selected = append(selected, table)
+		}
+	}
+	for _, fk := range foreignKeys {
+		// the subject table only holds the subject rows and self references are not followed
+		if reachable[fk.Table] && reachable[fk.ReferencedTable] && fk.Table != fk.ReferencedTable && fk.Table != subject.Table {
+			followed = append(followed, fk)
+		}
+	}
+
+	r := &subjectReader{
+		Reader:  source,
+		subject: subject,
+		tables:  ordering.ParentsFirst(selected, followed),
+		parents: make(map[string][]reader.ForeignKey),
+		done:    make(map[string]chan struct{}),
+		keys:    make(map[string]map[string]set),
+	}
+
+	position := make(map[string]int, len(r.tables))
+	for i, table := range r.tables {
+		position[table] = i
+		r.done[table] = make(chan struct{})
+		r.keys[table] = make(map[string]set)
+	}
+	for _, fk := range followed {
+		// reference cycles are only followed in the order the tables are listed
+		if position[fk.ReferencedTable] > position[fk.Table] {
+			continue
+		}
+		r.parents[fk.Table] = append(r.parents[fk.Table], fk)
+		r.keys[fk.ReferencedTable][integrity.ColumnsKey(fk.ReferencedKeyColumns())] = make(set)
+	}
+	for _, table := range r.tables {
+		if table != subject.Table && len(r.parents[table]) == 0 {
+			log.WithField("table", table).Warn("the table is only reachable through a reference cycle, its subject rows are not read")
+		}
+	}
+
+	log.WithFields(log.Fields{"subject": subject.String(), "tables": len(r.tables)}).Info("extracting the rows of the data subject")
+
+	return r, nil
+}
+
+// GetTables returns the subject table and the tables referencing it, parents first.
+func (r *subjectReader) GetTables() ([]string, error) {
+	return r.tables, nil
+}
+
+// ReadTable reads the subject rows of the table, once the tables it references are read.
+func (r *subjectReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	defer r.finish(tableName)
+
+	opts.Match, opts.Limit, opts.Offset = "", 0, 0
+	keys := r.keys[tableName]
+	publish := func(row database.Row) {
+		for columns, values := range keys {
+			if key, ok := integrity.KeyOf(row, integrity.SplitColumnsKey(columns)...); ok {
+				values[key] = true
+			}
+		}
+		rowChan <- row
+	}
+
+	if tableName == r.subject.Table {
+		return r.readKeys(tableName, []string{r.subject.Column}, []string{r.subject.Key}, opts, nil, publish)
+	}
+
+	for i, fk := range r.parents[tableName] {
+		// the keys of a table are complete once it is read
+		<-r.done[fk.ReferencedTable]
+		values := r.keys[fk.ReferencedTable][integrity.ColumnsKey(fk.ReferencedKeyColumns())].sorted()
+
+		// rows referencing several subject rows are only read through the first foreign key
+		earlier := r.parents[tableName][:i]
+		err := r.readKeys(tableName, fk.KeyColumns(), values, opts, func(row database.Row) bool {
+			for _, prev := range earlier {
+				parentKeys := r.keys[prev.ReferencedTable][integrity.ColumnsKey(prev.ReferencedKeyColumns())]
+				if key, ok := integrity.KeyOf(row, prev.KeyColumns()...); ok && parentKeys[key] {
+					return false
+				}
+			}
+			return true
+		}, publish)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readKeys reads the rows of the table whose columns hold one of the given keys, in batches,
+// calling publish for the rows accepted by keep, when set.
+func (r *subjectReader) readKeys(
+	tableName string,
+	columns []string,
+	values []string,
+	opts reader.ReadTableOpt,
+	keep func(database.Row) bool,
+	publish func(database.Row),
+) error {
+	for start := 0; start < len(values); start += batchSize {
+		end := start + batchSize
+		if end > len(values) {
+			end = len(values)
+		}
+
+		batch := make(set, end-start)
+		for _, value := range values[start:end] {
+			batch[value] = true
+		}
+
+		match, ok := integrity.InCondition(r.Reader, tableName, columns, values[start:end])
+		if !ok {
+			continue
+		}
+		opts.Match = match
+
+		rawChan := make(chan database.Row)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- r.Reader.ReadTable(tableName, rawChan, opts)
+		}()
+
+		// readers ignoring the condition return the whole table, only the matching rows are published
+		for row := range rawChan {
+			if key, ok := integrity.KeyOf(row, columns...); ok && batch[key] && (keep == nil || keep(row)) {
+				publish(row)
+			}
+		}
+		if err := <-errChan; err != nil {
+			return fmt.Errorf("subject: could not read the rows of %s: %w", tableName, err)
+		}
+	}
+
+	return nil
+}
+
+// finish marks a table as read, so the tables referencing it can be read.
+func (r *subjectReader) finish(tableName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	done, ok := r.done[tableName]
+	if !ok {
+		return
+	}
+	select {
+	case <-done:
+	default:
+		close(done)
+	}
+}
+
+func (s set) sorted() []string {
+	values := make([]string, 0, len(s))
+	for value := range s {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	return values
+}
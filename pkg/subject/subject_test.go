@@ -0,0 +1,183 @@
+package subject
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	s, err := Parse("public.users.email=jane@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, Subject{Table: "public.users", Column: "email", Key: "jane@example.com"}, s)
+	assert.Equal(t, "public.users.email=jane@example.com", s.String())
+
+	for _, invalid := range []string{"users", "users=1", "users.id=", ".id=1", "users.=1"} {
+		_, err := Parse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	source := newMockReader()
+	cfgTables := config.Tables{
+		{Name: "audit_logs", IgnoreData: true},
+		{
+			Name: "order_items",
+			Relationships: []*config.Relationship{
+				{ForeignKey: "order_id", ReferencedTable: "orders", ReferencedKey: "id"},
+			},
+		},
+	}
+	r, err := NewReader(source, cfgTables, Subject{Table: "users", Column: "email", Key: "b@example.com"})
+	require.NoError(t, err)
+
+	tables, err := r.GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "orders", "order_items", "refunds"}, tables)
+
+	dumped := dumpAll(t, r)
+	assert.Equal(t, []interface{}{int64(2)}, column(dumped["users"], "id"))
+	assert.ElementsMatch(t, []interface{}{int64(20), int64(21)}, column(dumped["orders"], "id"))
+	// item 201 references both a subject order and the subject user, it is only dumped once
+	assert.ElementsMatch(t, []interface{}{int64(200), int64(201), int64(202)}, column(dumped["order_items"], "id"))
+	assert.Contains(t, source.matches, "`users`.`email` IN ('b@example.com')")
+	assert.Contains(t, source.matches, "`orders`.`user_id` IN (2)")
+	assert.Contains(t, source.matches, "`order_items`.`order_id` IN (20, 21)")
+	// refunds reference an order of a user, the refund of order 20 for another user is not dumped
+	assert.Equal(t, []interface{}{int64(1)}, column(dumped["refunds"], "id"))
+	assert.Contains(t, source.matches, "(`refunds`.`order_id`, `refunds`.`user_id`) IN ((20, 2), (21, 2))")
+
+	_, err = NewReader(source, nil, Subject{Table: "customers", Column: "id", Key: "1"})
+	assert.Error(t, err)
+}
+
+// dumpAll reads the tables concurrently like the dumper engine does, with a limit the reader does not apply.
+func dumpAll(t *testing.T, r reader.Reader) map[string][]database.Row {
+	tables, err := r.GetTables()
+	require.NoError(t, err)
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		dumped = make(map[string][]database.Row)
+	)
+	for _, table := range tables {
+		rowChan := make(chan database.Row)
+		wg.Add(1)
+		go func(table string) {
+			defer wg.Done()
+			for row := range rowChan {
+				mu.Lock()
+				dumped[table] = append(dumped[table], row)
+				mu.Unlock()
+			}
+		}(table)
+		go func(table string) {
+			assert.NoError(t, r.ReadTable(table, rowChan, reader.ReadTableOpt{Limit: 1}))
+		}(table)
+	}
+	wg.Wait()
+
+	return dumped
+}
+
+func column(rows []database.Row, name string) []interface{} {
+	values := make([]interface{}, len(rows))
+	for i, row := range rows {
+		values[i] = row.Get(name)
+	}
+	return values
+}
+
+// mockReader ignores the read conditions and the limit.
+type mockReader struct {
+	reader.Reader
+	mu      sync.Mutex
+	rows    map[string][]database.Row
+	matches []string
+}
+
+func newMockReader() *mockReader {
+	users := database.NewColumns([]string{"id", "email"})
+	orders := database.NewColumns([]string{"id", "user_id"})
+	items := database.NewColumns([]string{"id", "order_id", "user_id"})
+	logs := database.NewColumns([]string{"id", "user_id"})
+	refunds := database.NewColumns([]string{"id", "order_id", "user_id"})
+
+	return &mockReader{rows: map[string][]database.Row{
+		"users": {
+			database.NewRow(users, []interface{}{int64(1), "a@example.com"}),
+			database.NewRow(users, []interface{}{int64(2), []byte("b@example.com")}),
+		},
+		"orders": {
+			database.NewRow(orders, []interface{}{int64(10), int64(1)}),
+			database.NewRow(orders, []interface{}{int64(20), int64(2)}),
+			database.NewRow(orders, []interface{}{int64(21), int64(2)}),
+		},
+		"order_items": {
+			database.NewRow(items, []interface{}{int64(100), int64(10), nil}),
+			database.NewRow(items, []interface{}{int64(200), int64(20), nil}),
+			database.NewRow(items, []interface{}{int64(201), int64(21), int64(2)}),
+			database.NewRow(items, []interface{}{int64(202), nil, int64(2)}),
+		},
+		"audit_logs": {
+			database.NewRow(logs, []interface{}{int64(1), int64(2)}),
+		},
+		"refunds": {
+			database.NewRow(refunds, []interface{}{int64(1), int64(20), int64(2)}),
+			database.NewRow(refunds, []interface{}{int64(2), int64(20), int64(1)}),
+			database.NewRow(refunds, []interface{}{int64(3), int64(10), int64(1)}),
+		},
+	}}
+}
+
+func (m *mockReader) GetTables() ([]string, error) {
+	return []string{"countries", "order_items", "orders", "users", "audit_logs", "refunds"}, nil
+}
+
+func (m *mockReader) GetForeignKeys() ([]reader.ForeignKey, error) {
+	return []reader.ForeignKey{
+		{Table: "orders", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "order_items", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "users", Column: "country", ReferencedTable: "countries", ReferencedColumn: "code"},
+		{Table: "users", Column: "referrer_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "audit_logs", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{
+			Table:             "refunds",
+			Column:            "order_id",
+			ReferencedTable:   "orders",
+			ReferencedColumn:  "id",
+			Columns:           []string{"order_id", "user_id"},
+			ReferencedColumns: []string{"id", "user_id"},
+		},
+	}, nil
+}
+
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return "`" + tableName + "`.`" + columnName + "`"
+}
+
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+
+	m.mu.Lock()
+	m.matches = append(m.matches, opts.Match)
+	m.mu.Unlock()
+
+	for _, row := range m.rows[tableName] {
+		rowChan <- row
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package transform
+
+import (
+	"fmt"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/expr"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type (
+	transformReader struct {
+		reader.Reader
+		// rules are the expressions by table.
+		rules map[string]*rules
+	}
+
+	// rules are the expressions of a table.
+	rules struct {
+		drop      *expr.Expression
+		transform map[string]*expr.Expression
+	}
+)
+
+// NewReader returns a reader dropping the rows matching the Drop expression of their table
+// and transforming the columns configured with Transform, it fails on invalid expressions.
+func NewReader(source reader.Reader, tables config.Tables) (reader.Reader, error) {
+	return newReader(source, tables, true)
+}
+
+// NewDropReader returns a reader only dropping the rows matching the Drop expression of their table.
+func NewDropReader(source reader.Reader, tables config.Tables) (reader.Reader, error) {
+	return newReader(source, tables, false)
+}
+
+func newReader(source reader.Reader, tables config.Tables, transform bool) (reader.Reader, error) {
+	tableRules := make(map[string]*rules)
+	for _, table := range tables {
+		if table.Drop == "" && (!transform || len(table.Transform) == 0) {
+			continue
+		}
+
+		r := &rules{transform: make(map[string]*expr.Expression, len(table.Transform))}
+		if table.Drop != "" {
+			drop, err := expr.Compile(table.Drop)
+			if err != nil {
+				return nil, fmt.Errorf("table %s drop: %w", table.Name, err)
+			}
+			r.drop = drop
+		}
+		if transform {
+			for column, expression := range table.Transform {
+				e, err := expr.Compile(expression)
+				if err != nil {
+					return nil, fmt.Errorf("table %s column %s: %w", table.Name, column, err)
+				}
+				r.transform[column] = e
+			}
+		}
+		tableRules[table.Name] = r
+	}
+
+	if len(tableRules) == 0 {
+		return source, nil
+	}
+
+	return &transformReader{Reader: source, rules: tableRules}, nil
+}
+
+// ReadTable drops and transforms the rows read, an expression that can not be evaluated fails the table.
+func (r *transformReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	tableRules, ok := r.rules[tableName]
+	if !ok {
+		return r.Reader.ReadTable(tableName, rowChan, opts)
+	}
+	defer close(rowChan)
+
+	rawChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.Reader.ReadTable(tableName, rawChan, opts)
+	}()
+
+	var evalErr error
+	for row := range rawChan {
+		if evalErr != nil {
+			continue
+		}
+
+		keep, err := tableRules.apply(row)
+		if err != nil {
+			evalErr = err
+			continue
+		}
+		if keep {
+			rowChan <- row
+		}
+	}
+
+	if err := <-errChan; err != nil {
+		return err
+	}
+	if evalErr != nil {
+		return fmt.Errorf("transform: %w", evalErr)
+	}
+
+	return nil
+}
+
+// apply transforms the row, all the expressions being evaluated on the row as read.
+// It returns false when the row is dropped.
+func (r *rules) apply(row database.Row) (bool, error) {
+	if r.drop != nil {
+		drop, err := r.drop.EvalBool(row)
+		if err != nil {
+			return false, fmt.Errorf("drop: %w", err)
+		}
+		if drop {
+			return false, nil
+		}
+	}
+
+	values := make(map[string]interface{}, len(r.transform))
+	for column, e := range r.transform {
+		value, err := e.Eval(row)
+		if err != nil {
+			return false, fmt.Errorf("column %s: %w", column, err)
+		}
+		values[column] = value
+	}
+	for column, value := range values {
+		row.Set(column, value)
+	}
+
+	return true, nil
+}
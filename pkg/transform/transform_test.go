@@ -0,0 +1,101 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/config"
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+func TestReadTable(t *testing.T) {
+	t.Parallel()
+
+	tables := config.Tables{{
+		Name: "users",
+		Drop: `row.country == "US" && row.opt_out`,
+		Transform: map[string]string{
+			"email":   `lower(row.email)`,
+			"country": `when(row.country == "DE", "EU", row.country)`,
+			"opt_out": `row.country == "DE"`,
+		},
+	}}
+	source := &mockReader{rows: [][]interface{}{
+		{"A@Example.com", "US", []byte("1")},
+		{"B@Example.com", "US", []byte("0")},
+		{"C@Example.com", "DE", []byte("0")},
+	}}
+
+	r, err := NewReader(source, tables)
+	require.NoError(t, err)
+
+	rows, err := readAll(r, "users")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []interface{}{"b@example.com", "US", false}, rows[0].Values())
+	// the expressions are evaluated on the row as read
+	assert.Equal(t, []interface{}{"c@example.com", "EU", true}, rows[1].Values())
+
+	r, err = NewDropReader(source, tables)
+	require.NoError(t, err)
+
+	rows, err = readAll(r, "users")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []interface{}{"B@Example.com", "US", []byte("0")}, rows[0].Values())
+
+	r, err = NewReader(&mockReader{rows: [][]interface{}{{"a", "US", []byte("maybe")}}}, tables)
+	require.NoError(t, err)
+	_, err = readAll(r, "users")
+	assert.EqualError(t, err, `transform: drop: could not evaluate "row.country == \"US\" && row.opt_out": "maybe" is not a boolean`)
+
+	r, err = NewReader(source, config.Tables{{Name: "users", Anonymise: map[string]string{"email": "EmailAddress"}}})
+	require.NoError(t, err)
+	assert.Same(t, source, r)
+
+	_, err = NewReader(source, config.Tables{{Name: "users", Transform: map[string]string{"email": "lower("}}})
+	assert.Error(t, err)
+}
+
+func readAll(r reader.Reader, table string) ([]database.Row, error) {
+	rowChan := make(chan database.Row)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.ReadTable(table, rowChan, reader.ReadTableOpt{})
+	}()
+
+	var rows []database.Row
+	for row := range rowChan {
+		rows = append(rows, row)
+	}
+
+	return rows, <-errChan
+}
+
+// mockReader publishes the rows of a users table.
+type mockReader struct {
+	rows [][]interface{}
+}
+
+func (m *mockReader) GetTables() ([]string, error)  { return []string{"users"}, nil }
+func (m *mockReader) GetStructure() (string, error) { return "", nil }
+func (m *mockReader) GetColumns(string) ([]string, error) {
+	return []string{"email", "country", "opt_out"}, nil
+}
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return ""
+}
+func (m *mockReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	defer close(rowChan)
+	columns := database.NewColumns([]string{"email", "country", "opt_out"})
+	for _, values := range m.rows {
+		row := make([]interface{}, len(values))
+		copy(row, values)
+		rowChan <- database.NewRow(columns, row)
+	}
+	return nil
+}
+func (m *mockReader) Close() error { return nil }
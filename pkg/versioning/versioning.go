@@ -0,0 +1,227 @@
+// Package versioning dumps the history of the system-versioned tables, e.g. the MariaDB tables created WITH SYSTEM
+// VERSIONING, into history tables next to them. The system-versioned tables themselves are read as of now, their
+// period columns being set by the target.
+package versioning
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+// Suffix is appended to the name of a system-versioned table to name its history table.
+const Suffix = "_history"
+
+// defaultPeriodType is the type of the implicit period columns of MariaDB.
+const defaultPeriodType = "timestamp(6)"
+
+type (
+	// versionHistory is the history table of a system-versioned table.
+	versionHistory struct {
+		table reader.VersionedTable
+		// columns are the columns of the history table, the period columns being the last two.
+		columns []string
+	}
+
+	versioningReader struct {
+		reader.Reader
+		connected reader.Reader
+		// histories are the history tables, keyed by name.
+		histories map[string]*versionHistory
+		// names are the names of the history tables, in the order of their tables.
+		names []string
+	}
+)
+
+// NewReader returns a reader dumping a history table with every version of the rows of each system-versioned table
+// listed by connected, the current ones included. The source is returned as it is when the history is not asked for,
+// or when connected can not list its system-versioned tables or has none.
+func NewReader(source reader.Reader, connected reader.Reader, history bool) (reader.Reader, error) {
+	lister, ok := connected.(reader.VersionedTableLister)
+	if !ok || !history {
+		return source, nil
+	}
+
+	tables, err := lister.GetVersionedTables()
+	if errors.Is(err, reader.ErrVersionedTablesUnsupported) {
+		return source, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not list the system-versioned tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return source, nil
+	}
+
+	existing, err := connected.GetTables()
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, table := range existing {
+		taken[table] = true
+	}
+
+	r := &versioningReader{Reader: source, connected: connected, histories: make(map[string]*versionHistory, len(tables))}
+	for _, table := range tables {
+		name := table.Name + Suffix
+		if taken[name] {
+			return nil, fmt.Errorf("the history table %s of %s already exists in the source", name, table.Name)
+		}
+
+		columns, err := connected.GetColumns(table.Name)
+		if err != nil {
+			return nil, err
+		}
+		rowStart, rowEnd := periodColumns(table)
+		columns = append(columns[:len(columns):len(columns)], rowStart, rowEnd)
+
+		log.WithFields(log.Fields{"table": table.Name, "history": name}).Info("dumping the history of the system-versioned table")
+		r.histories[name] = &versionHistory{table: table, columns: columns}
+		r.names = append(r.names, name)
+	}
+
+	return r, nil
+}
+
+// GetTables returns the tables of the source, each system-versioned table followed by its history table.
+func (r *versioningReader) GetTables() ([]string, error) {
+	tables, err := r.Reader.GetTables()
+	if err != nil {
+		return nil, err
+	}
+
+	withHistory := make([]string, 0, len(tables)+len(r.names))
+	for _, table := range tables {
+		withHistory = append(withHistory, table)
+		if _, ok := r.histories[table+Suffix]; ok {
+			withHistory = append(withHistory, table+Suffix)
+		}
+	}
+
+	return withHistory, nil
+}
+
+// GetColumns returns the columns of a table, the columns of a history table ending with the period columns.
+func (r *versioningReader) GetColumns(tableName string) ([]string, error) {
+	if h, ok := r.histories[tableName]; ok {
+		return h.columns, nil
+	}
+
+	return r.Reader.GetColumns(tableName)
+}
+
+// ReadTable reads the rows of a table, the rows of a history table being every version of the rows of its
+// system-versioned table.
+func (r *versioningReader) ReadTable(tableName string, rowChan chan<- database.Row, opts reader.ReadTableOpt) error {
+	h, ok := r.histories[tableName]
+	if !ok {
+		return r.Reader.ReadTable(tableName, rowChan, opts)
+	}
+
+	opts.Query = h.query()
+	if len(opts.Columns) == 0 {
+		for _, column := range h.columns {
+			opts.Columns = append(opts.Columns, r.Reader.FormatColumn(tableName, column))
+		}
+	}
+
+	return r.Reader.ReadTable(tableName, rowChan, opts)
+}
+
+// GetStructure returns the structure followed by the statements creating the history tables.
+func (r *versioningReader) GetStructure() (string, error) {
+	structure, err := r.Reader.GetStructure()
+	if err != nil {
+		return "", err
+	}
+
+	histories, err := r.structure()
+	if err != nil {
+		return "", err
+	}
+
+	return structure + "\n" + histories, nil
+}
+
+// GetStructureSections returns the sections of the structure, the pre-data one followed by the statements creating
+// the history tables.
+func (r *versioningReader) GetStructureSections() (string, string, error) {
+	preData, postData, err := reader.GetStructureSections(r.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	histories, err := r.structure()
+	if err != nil {
+		return "", "", err
+	}
+
+	return preData + "\n" + histories, postData, nil
+}
+
+// structure returns the statements creating the history tables, plain tables without keys nor indexes with the
+// columns of their system-versioned table and its period.
+func (r *versioningReader) structure() (string, error) {
+	typer, ok := r.connected.(reader.ColumnTyper)
+	if !ok {
+		return "", errors.New("the source does not know the types of the columns of the system-versioned tables")
+	}
+
+	var buf strings.Builder
+	for _, name := range r.names {
+		h := r.histories[name]
+		types, err := typer.GetColumnTypes(h.table.Name)
+		if err != nil {
+			return "", err
+		}
+
+		definitions := make([]string, len(h.columns))
+		for i, column := range h.columns {
+			columnType, ok := types[column]
+			if !ok {
+				columnType = defaultPeriodType
+			}
+			definitions[i] = fmt.Sprintf("  %s %s", quote(column), columnType)
+		}
+		fmt.Fprintf(&buf, "CREATE TABLE %s (\n%s\n);\n", quote(name), strings.Join(definitions, ",\n"))
+	}
+
+	return buf.String(), nil
+}
+
+// query returns the query reading every version of the rows of the system-versioned table.
+func (h *versionHistory) query() string {
+	n := len(h.columns) - 2
+	selected := make([]string, 0, len(h.columns))
+	for _, column := range h.columns[:n] {
+		selected = append(selected, quote(column))
+	}
+
+	rowStart, rowEnd := h.table.RowStart, h.table.RowEnd
+	if rowStart == "" {
+		selected = append(selected, "ROW_START AS "+quote(h.columns[n]), "ROW_END AS "+quote(h.columns[n+1]))
+	} else {
+		selected = append(selected, quote(rowStart), quote(rowEnd))
+	}
+
+	return fmt.Sprintf("SELECT %s FROM %s FOR SYSTEM_TIME ALL", strings.Join(selected, ", "), quote(h.table.Name))
+}
+
+// periodColumns returns the names of the period columns of the history table of a system-versioned table.
+func periodColumns(table reader.VersionedTable) (string, string) {
+	if table.RowStart == "" || table.RowEnd == "" {
+		return "row_start", "row_end"
+	}
+
+	return table.RowStart, table.RowEnd
+}
+
+func quote(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
@@ -0,0 +1,137 @@
+package versioning
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/klepto/pkg/database"
+	"github.com/hellofresh/klepto/pkg/reader"
+)
+
+type mockReader struct {
+	reader.Reader
+	tables    []string
+	versioned []reader.VersionedTable
+	err       error
+	opts      reader.ReadTableOpt
+}
+
+func (m *mockReader) GetTables() ([]string, error) {
+	return m.tables, nil
+}
+
+func (m *mockReader) GetColumns(string) ([]string, error) {
+	return []string{"id", "price"}, nil
+}
+
+func (m *mockReader) GetColumnTypes(string) (map[string]string, error) {
+	return map[string]string{"id": "int(11)", "price": "decimal(10,2)", "valid_from": "timestamp(6)", "valid_to": "timestamp(6)"}, nil
+}
+
+func (m *mockReader) FormatColumn(tableName string, columnName string) string {
+	return "`" + tableName + "`.`" + columnName + "`"
+}
+
+func (m *mockReader) ReadTable(_ string, _ chan<- database.Row, opts reader.ReadTableOpt) error {
+	m.opts = opts
+	return nil
+}
+
+func (m *mockReader) GetStructure() (string, error) {
+	return "CREATE TABLE `products` (\n  `id` int(11) NOT NULL\n) WITH SYSTEM VERSIONING;\n", nil
+}
+
+func (m *mockReader) GetVersionedTables() ([]reader.VersionedTable, error) {
+	return m.versioned, m.err
+}
+
+func TestNewReader(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{tables: []string{"products"}, versioned: []reader.VersionedTable{{Name: "products"}}}
+
+	r, err := NewReader(source, source, false)
+	require.NoError(t, err)
+	assert.Equal(t, source, r)
+
+	unsupported := &mockReader{err: reader.ErrVersionedTablesUnsupported}
+	r, err = NewReader(unsupported, unsupported, true)
+	require.NoError(t, err)
+	assert.Equal(t, unsupported, r)
+
+	failing := &mockReader{err: errors.New("boom")}
+	_, err = NewReader(failing, failing, true)
+	assert.EqualError(t, err, "could not list the system-versioned tables: boom")
+
+	taken := &mockReader{tables: []string{"products", "products_history"}, versioned: source.versioned}
+	_, err = NewReader(taken, taken, true)
+	assert.EqualError(t, err, "the history table products_history of products already exists in the source")
+}
+
+func TestHistoryImplicitPeriod(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{tables: []string{"orders", "products"}, versioned: []reader.VersionedTable{{Name: "products"}}}
+
+	r, err := NewReader(source, source, true)
+	require.NoError(t, err)
+
+	tables, err := r.GetTables()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders", "products", "products_history"}, tables)
+
+	columns, err := r.GetColumns("products_history")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "price", "row_start", "row_end"}, columns)
+
+	require.NoError(t, r.ReadTable("products_history", nil, reader.ReadTableOpt{}))
+	assert.Equal(t, "SELECT `id`, `price`, ROW_START AS `row_start`, ROW_END AS `row_end` FROM `products` FOR SYSTEM_TIME ALL", source.opts.Query)
+	assert.Equal(t, []string{
+		"`products_history`.`id`",
+		"`products_history`.`price`",
+		"`products_history`.`row_start`",
+		"`products_history`.`row_end`",
+	}, source.opts.Columns)
+
+	require.NoError(t, r.ReadTable("products", nil, reader.ReadTableOpt{}))
+	assert.Empty(t, source.opts.Query)
+
+	structure, err := r.GetStructure()
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE `products` (\n  `id` int(11) NOT NULL\n) WITH SYSTEM VERSIONING;\n\n"+
+		"CREATE TABLE `products_history` (\n"+
+		"  `id` int(11),\n"+
+		"  `price` decimal(10,2),\n"+
+		"  `row_start` timestamp(6),\n"+
+		"  `row_end` timestamp(6)\n"+
+		");\n", structure)
+}
+
+func TestHistoryExplicitPeriod(t *testing.T) {
+	t.Parallel()
+
+	source := &mockReader{
+		tables:    []string{"products"},
+		versioned: []reader.VersionedTable{{Name: "products", RowStart: "valid_from", RowEnd: "valid_to"}},
+	}
+
+	r, err := NewReader(source, source, true)
+	require.NoError(t, err)
+
+	columns, err := r.GetColumns("products_history")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "price", "valid_from", "valid_to"}, columns)
+
+	require.NoError(t, r.ReadTable("products_history", nil, reader.ReadTableOpt{Columns: []string{"`id`"}}))
+	assert.Equal(t, "SELECT `id`, `price`, `valid_from`, `valid_to` FROM `products` FOR SYSTEM_TIME ALL", source.opts.Query)
+	assert.Equal(t, []string{"`id`"}, source.opts.Columns)
+
+	preData, postData, err := reader.GetStructureSections(r)
+	require.NoError(t, err)
+	assert.Contains(t, preData, "CREATE TABLE `products_history` (\n  `id` int(11),\n  `price` decimal(10,2),\n"+
+		"  `valid_from` timestamp(6),\n  `valid_to` timestamp(6)\n);\n")
+	assert.Empty(t, postData)
+}